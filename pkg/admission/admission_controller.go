@@ -27,6 +27,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -36,14 +37,17 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
+	listersv1 "k8s.io/client-go/listers/core/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/admission/common"
 	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/admission/metadata"
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 const (
@@ -51,8 +55,10 @@ const (
 	admissionReviewAPIVersion       = "admission.k8s.io/v1"
 	admissionReviewKind             = "AdmissionReview"
 	schedulerValidateConfURLPattern = "http://%s/ws/v1/validate-conf"
+	healthURL                       = "/health"
 	mutateURL                       = "/mutate"
 	validateConfURL                 = "/validate-conf"
+	validatePodsURL                 = "/validate-pods"
 )
 
 var (
@@ -65,6 +71,13 @@ type AdmissionController struct {
 	conf              *conf.AdmissionControllerConf
 	pcCache           *PriorityClassCache
 	nsCache           *NamespaceCache
+	pgCache           *PodGroupCache
+	ownerCache        *OwnerCache
+	nodeCache         *NodeCache
+	appIDCache        *AppIDCache
+	queueCache        *QueueCache
+	burstLimiter      *BurstLimiter
+	duplicatePods     *DuplicatePodDetector
 	annotationHandler *metadata.UserGroupAnnotationHandler
 	labelExtractor    metadata.LabelExtractor
 }
@@ -74,18 +87,40 @@ type ValidateConfResponse struct {
 	Reason  string `json:"reason"`
 }
 
-func InitAdmissionController(conf *conf.AdmissionControllerConf, pcCache *PriorityClassCache, nsCache *NamespaceCache) *AdmissionController {
+func InitAdmissionController(conf *conf.AdmissionControllerConf, pcCache *PriorityClassCache, nsCache *NamespaceCache, pgCache *PodGroupCache, ownerCache *OwnerCache, nodeCache *NodeCache) *AdmissionController {
+	if ownerCache == nil {
+		ownerCache = NewOwnerCache(nil, nil)
+	}
 	hook := &AdmissionController{
 		conf:              conf,
 		pcCache:           pcCache,
 		nsCache:           nsCache,
+		pgCache:           pgCache,
+		ownerCache:        ownerCache,
+		nodeCache:         nodeCache,
+		appIDCache:        NewAppIDCache(nil),
+		queueCache:        NewQueueCache(conf),
+		burstLimiter:      NewBurstLimiter(conf),
+		duplicatePods:     NewDuplicatePodDetector(conf),
 		annotationHandler: metadata.NewUserGroupAnnotationHandler(conf),
 	}
+	// pods are admitted without a queue check until the queues are retrieved from the scheduler
+	hook.queueCache.prime()
 
 	log.Log(log.Admission).Info("Initialized YuniKorn Admission Controller")
 	return hook
 }
 
+// SetAppIDCache sets the cache used to detect applicationIds that are in use in another namespace.
+func (c *AdmissionController) SetAppIDCache(appIDCache *AppIDCache) {
+	c.appIDCache = appIDCache
+}
+
+// SetUserGroupsConfigMapLister sets the lister used to resolve the groups of a user from a ConfigMap.
+func (c *AdmissionController) SetUserGroupsConfigMapLister(lister listersv1.ConfigMapLister) {
+	c.annotationHandler.SetConfigMapLister(lister)
+}
+
 func parseRegexes(patterns string) ([]*regexp.Regexp, error) {
 	result := make([]*regexp.Regexp, 0)
 	for _, pattern := range strings.Split(patterns, ",") {
@@ -163,6 +198,14 @@ func (c *AdmissionController) processPod(req *admissionv1.AdmissionRequest, name
 		return admissionResponseBuilder(uid, false, err.Error(), nil)
 	}
 
+	// the mirror pods of static pods are created by the kubelet for pods it runs itself, they are never scheduled
+	if utils.IsMirrorPod(&pod) {
+		log.Log(log.Admission).Info("ignore mirror pod",
+			zap.String("namespace", namespace),
+			zap.String("podName", pod.Name))
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+
 	userName := req.UserInfo.Username
 	groups := req.UserInfo.Groups
 	failureResponse, userInfoSet := c.checkUserInfoAnnotation(func() (string, bool) {
@@ -193,11 +236,45 @@ func (c *AdmissionController) processPod(req *admissionv1.AdmissionRequest, name
 		log.Log(log.Admission).Info("bypassing namespace", zap.String("namespace", namespace))
 		return admissionResponseBuilder(uid, true, "", nil)
 	}
+	if workloadKind, process := c.shouldProcessOwner(namespace, &pod); !process {
+		log.Log(log.Admission).Info("bypassing workload kind",
+			zap.String("namespace", namespace),
+			zap.String("kind", workloadKind))
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+	if err := c.checkTaskGroups(&pod); err != nil {
+		log.Log(log.Admission).Info("rejecting pod with invalid task groups",
+			zap.String("namespace", namespace),
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return admissionResponseBuilder(uid, false, err.Error(), nil)
+	}
+	if err := c.checkGangLimits(namespace, &pod); err != nil {
+		log.Log(log.Admission).Info("rejecting pod exceeding the gang limits of the namespace",
+			zap.String("namespace", namespace),
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return admissionResponseBuilder(uid, false, err.Error(), nil)
+	}
+	if err := c.checkAppIDCollision(namespace, &pod); err != nil {
+		log.Log(log.Admission).Info("rejecting pod with an applicationId used in another namespace",
+			zap.String("namespace", namespace),
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return admissionResponseBuilder(uid, false, err.Error(), nil)
+	}
+	if patch, failureResponse = c.checkDuplicatePods(uid, req, &pod, patch); failureResponse != nil {
+		return failureResponse
+	}
 	patch = updateSchedulerName(patch)
 
 	if c.shouldLabelNamespace(namespace) {
+		patch = c.updatePodGroup(namespace, &pod, patch)
 		patch = c.updateLabels(namespace, &pod, patch)
+		patch = c.updatePriorityClass(&pod, patch)
 		patch = c.updatePreemptionInfo(&pod, patch)
+		patch = c.updateResources(namespace, &pod, patch)
+		patch = c.updatePlacement(&pod, patch)
 	} else {
 		patch = disableYuniKorn(namespace, &pod, patch)
 	}
@@ -415,6 +492,173 @@ func (c *AdmissionController) updatePreemptionInfo(pod *v1.Pod, patch []common.P
 	return patch
 }
 
+// checkDuplicatePods records the creation of the pod and checks if its controller recreates identical pods in a
+// tight loop. A duplicate pod is rejected if configured, otherwise it is annotated with the suggested backoff.
+// Placeholder pods and dry run requests are not recorded.
+func (c *AdmissionController) checkDuplicatePods(uid string, req *admissionv1.AdmissionRequest, pod *v1.Pod, patch []common.PatchOperation) ([]common.PatchOperation, *admissionv1.AdmissionResponse) {
+	if (req.DryRun != nil && *req.DryRun) || utils.GetPlaceholderFlagFromPodSpec(pod) {
+		return patch, nil
+	}
+	duplicates := c.duplicatePods.record(pod, time.Now())
+	if duplicates == nil {
+		return patch, nil
+	}
+	if c.conf.GetDuplicatePodReject() {
+		log.Log(log.Admission).Info("rejecting duplicate pod",
+			zap.String("namespace", pod.Namespace),
+			zap.String("generateName", pod.GenerateName),
+			zap.Stringer("duplicates", duplicates))
+		return patch, admissionResponseBuilder(uid, false, duplicates.String(), nil)
+	}
+	log.Log(log.Admission).Info("annotating duplicate pod with backoff",
+		zap.String("namespace", pod.Namespace),
+		zap.String("generateName", pod.GenerateName),
+		zap.Stringer("duplicates", duplicates))
+	backoff := duplicates.backoff.String()
+
+	// check for an existing patch on annotations and update it
+	for _, p := range patch {
+		if p.Op == "add" && p.Path == "/metadata/annotations" {
+			if annotations, ok := p.Value.(map[string]string); ok {
+				annotations[constants.AnnotationDuplicatePodBackoff] = backoff
+				return patch, nil
+			}
+		}
+	}
+
+	result := updatePodAnnotation(pod, constants.AnnotationDuplicatePodBackoff, backoff)
+	return append(patch, common.PatchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations",
+		Value: result,
+	}), nil
+}
+
+// checkAppIDCollision returns an error if the applicationId set on the pod is used by pods in another namespace.
+// The pods would otherwise be merged into one application, the generated applicationIds are unique per namespace.
+func (c *AdmissionController) checkAppIDCollision(namespace string, pod *v1.Pod) error {
+	if !c.conf.GetRejectAppIDCollisions() {
+		return nil
+	}
+	appID := getAppID(pod.Labels, pod.Annotations)
+	if appID == "" {
+		return nil
+	}
+	if others := c.appIDCache.getOtherNamespaces(appID, namespace); len(others) > 0 {
+		return fmt.Errorf("applicationId %s is already used by pods in namespace %s, use a unique applicationId", appID, strings.Join(others, ","))
+	}
+	return nil
+}
+
+// checkTaskGroups returns an error if the task groups set on the pod cannot be used to create the placeholders:
+// the annotation is not valid JSON, names are missing or duplicated, or the minMember is not positive.
+// If the node capacity check is enabled the minResource of each task group must also fit on at least one node.
+func (c *AdmissionController) checkTaskGroups(pod *v1.Pod) error {
+	taskGroups, err := utils.GetTaskGroupsFromAnnotation(pod)
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation: %w", constants.AnnotationTaskGroups, err)
+	}
+	if c.nodeCache == nil || !c.conf.GetNodeCapacityCheck() {
+		return nil
+	}
+	for _, tg := range taskGroups {
+		minResource := make(v1.ResourceList, len(tg.MinResource))
+		for name, quantity := range tg.MinResource {
+			minResource[v1.ResourceName(name)] = quantity
+		}
+		if !c.nodeCache.fitsAnyNode(minResource) {
+			return fmt.Errorf("minResource of task group %s does not fit on any node", tg.Name)
+		}
+	}
+	return nil
+}
+
+// checkGangLimits returns an error if the task groups set on the pod exceed the maximum gang size or placeholder
+// resources set on the namespace. Invalid task groups are rejected by checkTaskGroups before the limits are checked.
+func (c *AdmissionController) checkGangLimits(namespace string, pod *v1.Pod) error {
+	maxGangSize, maxResource := c.nsCache.getGangLimits(namespace)
+	if maxGangSize == 0 && maxResource == nil {
+		return nil
+	}
+	taskGroups, err := utils.GetTaskGroupsFromAnnotation(pod)
+	if err != nil || len(taskGroups) == 0 {
+		return nil
+	}
+	return utils.CheckGangLimits(taskGroups, maxGangSize, maxResource)
+}
+
+// updatePodGroup adds the task group annotations to pods that are a member of a coscheduling PodGroup.
+// Pods that define task groups themselves are not changed. Members without an application ID are added to
+// the application of the PodGroup, the pod is updated in place so the label update picks up the ID.
+func (c *AdmissionController) updatePodGroup(namespace string, pod *v1.Pod, patch []common.PatchOperation) []common.PatchOperation {
+	if c.pgCache == nil || !c.conf.GetPodGroupEnable() {
+		return patch
+	}
+	podGroupName := utils.GetPodLabelValue(pod, constants.LabelPodGroup)
+	if podGroupName == "" || utils.GetPodAnnotationValue(pod, constants.AnnotationTaskGroups) != "" {
+		return patch
+	}
+	pg := c.pgCache.getPodGroup(namespace, podGroupName)
+	if pg == nil {
+		log.Log(log.Admission).Warn("PodGroup referenced by pod does not exist",
+			zap.String("namespace", namespace),
+			zap.String("podName", pod.Name),
+			zap.String("podGroup", podGroupName))
+		return patch
+	}
+	if pg.Spec.MinMember <= 0 {
+		return patch
+	}
+	taskGroups, err := json.Marshal([]v1alpha1.TaskGroup{podGroupTaskGroup(pg, pod)})
+	if err != nil {
+		log.Log(log.Admission).Error("failed to marshal task groups", zap.Error(err))
+		return patch
+	}
+
+	log.Log(log.Admission).Info("updating pod task groups from PodGroup",
+		zap.String("namespace", namespace),
+		zap.String("podName", pod.Name),
+		zap.String("generateName", pod.GenerateName),
+		zap.String("podGroup", podGroupName),
+		zap.Int32("minMember", pg.Spec.MinMember))
+
+	if utils.GetPodLabelValue(pod, constants.LabelApplicationID) == "" && utils.GetPodLabelValue(pod, constants.SparkLabelAppID) == "" {
+		pod.Labels[constants.LabelApplicationID] = generatePodGroupAppID(namespace, podGroupName)
+	}
+	values := map[string]string{
+		constants.AnnotationTaskGroups:    string(taskGroups),
+		constants.AnnotationTaskGroupName: pg.Name,
+	}
+	if pg.Spec.ScheduleTimeoutSeconds != nil && utils.GetPodAnnotationValue(pod, constants.AnnotationSchedulingPolicyParam) == "" {
+		values[constants.AnnotationSchedulingPolicyParam] = fmt.Sprintf("%s=%d", constants.SchedulingPolicyTimeoutParam, *pg.Spec.ScheduleTimeoutSeconds)
+	}
+
+	// check for an existing patch on annotations and update it
+	for _, p := range patch {
+		if p.Op == "add" && p.Path == "/metadata/annotations" {
+			if annotations, ok := p.Value.(map[string]string); ok {
+				for k, v := range values {
+					annotations[k] = v
+				}
+				return patch
+			}
+		}
+	}
+
+	result := make(map[string]string)
+	for k, v := range pod.Annotations {
+		result[k] = v
+	}
+	for k, v := range values {
+		result[k] = v
+	}
+	return append(patch, common.PatchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations",
+		Value: result,
+	})
+}
+
 func (c *AdmissionController) updateLabels(namespace string, pod *v1.Pod, patch []common.PatchOperation) []common.PatchOperation {
 	log.Log(log.Admission).Info("updating pod labels",
 		zap.String("podName", pod.Name),
@@ -433,6 +677,174 @@ func (c *AdmissionController) updateLabels(namespace string, pod *v1.Pod, patch
 	return patch
 }
 
+// updateResources adds the configured default requests and limits to the containers of the pod that do not
+// specify them. Defaults configured for the queue of the pod take precedence over the namespace defaults.
+func (c *AdmissionController) updateResources(namespace string, pod *v1.Pod, patch []common.PatchOperation) []common.PatchOperation {
+	queueName := c.getQueueName(pod)
+	defaults, ok := c.conf.GetQueueResourceDefaults(queueName)
+	if !ok {
+		if defaults, ok = c.conf.GetNamespaceResourceDefaults(namespace); !ok {
+			return patch
+		}
+	}
+
+	ops := len(patch)
+	for i := range pod.Spec.InitContainers {
+		if resources, updated := applyResourceDefaults(pod.Spec.InitContainers[i].Resources, defaults); updated {
+			patch = append(patch, common.PatchOperation{
+				Op:    "add",
+				Path:  fmt.Sprintf("/spec/initContainers/%d/resources", i),
+				Value: resources,
+			})
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if resources, updated := applyResourceDefaults(pod.Spec.Containers[i].Resources, defaults); updated {
+			patch = append(patch, common.PatchOperation{
+				Op:    "add",
+				Path:  fmt.Sprintf("/spec/containers/%d/resources", i),
+				Value: resources,
+			})
+		}
+	}
+	if len(patch) > ops {
+		log.Log(log.Admission).Info("updated pod resources with defaults",
+			zap.String("podName", pod.Name),
+			zap.String("generateName", pod.GenerateName),
+			zap.String("namespace", namespace),
+			zap.String("queue", queueName))
+	}
+
+	return patch
+}
+
+// updatePlacement adds the tolerations and the node selector configured for the queue of the pod.
+// Tolerations already on the pod are not duplicated. A node selector key set on the pod is not overwritten.
+func (c *AdmissionController) updatePlacement(pod *v1.Pod, patch []common.PatchOperation) []common.PatchOperation {
+	queueName := c.getQueueName(pod)
+	placement, ok := c.conf.GetQueuePlacement(queueName)
+	if !ok {
+		return patch
+	}
+
+	ops := len(patch)
+	tolerations := append([]v1.Toleration(nil), pod.Spec.Tolerations...)
+	for i := range placement.Tolerations {
+		if !hasToleration(tolerations, &placement.Tolerations[i]) {
+			tolerations = append(tolerations, placement.Tolerations[i])
+		}
+	}
+	if len(tolerations) != len(pod.Spec.Tolerations) {
+		patch = append(patch, common.PatchOperation{
+			Op:    "add",
+			Path:  "/spec/tolerations",
+			Value: tolerations,
+		})
+	}
+
+	nodeSelector := make(map[string]string)
+	for k, v := range pod.Spec.NodeSelector {
+		nodeSelector[k] = v
+	}
+	for k, v := range placement.NodeSelector {
+		if current, ok := nodeSelector[k]; ok {
+			if current != v {
+				log.Log(log.Admission).Info("pod node selector overrides queue placement",
+					zap.String("podName", pod.Name),
+					zap.String("queue", queueName),
+					zap.String("key", k))
+			}
+			continue
+		}
+		nodeSelector[k] = v
+	}
+	if len(nodeSelector) != len(pod.Spec.NodeSelector) {
+		patch = append(patch, common.PatchOperation{
+			Op:    "add",
+			Path:  "/spec/nodeSelector",
+			Value: nodeSelector,
+		})
+	}
+
+	if len(patch) > ops {
+		log.Log(log.Admission).Info("updated pod placement for queue",
+			zap.String("podName", pod.Name),
+			zap.String("generateName", pod.GenerateName),
+			zap.String("queue", queueName))
+	}
+
+	return patch
+}
+
+// updatePriorityClass sets the PriorityClass configured for the queue on pods that do not specify one.
+// A PriorityClass set by the API server because it is the global default is replaced.
+// The pod is updated in place so the preemption info is derived from the new PriorityClass.
+func (c *AdmissionController) updatePriorityClass(pod *v1.Pod, patch []common.PatchOperation) []common.PatchOperation {
+	queueName := c.getQueueName(pod)
+	name, ok := c.conf.GetQueuePriorityClass(queueName)
+	if !ok || name == pod.Spec.PriorityClassName {
+		return patch
+	}
+	if pod.Spec.PriorityClassName != "" {
+		if current := c.pcCache.getPriorityClass(pod.Spec.PriorityClassName); current == nil || !current.GlobalDefault {
+			return patch
+		}
+	}
+	priorityClass := c.pcCache.getPriorityClass(name)
+	if priorityClass == nil {
+		log.Log(log.Admission).Warn("PriorityClass configured for queue does not exist",
+			zap.String("queue", queueName),
+			zap.String("priorityClass", name))
+		return patch
+	}
+
+	log.Log(log.Admission).Info("updating pod priority class",
+		zap.String("podName", pod.Name),
+		zap.String("generateName", pod.GenerateName),
+		zap.String("queue", queueName),
+		zap.String("priorityClass", name))
+
+	// the priority and preemption policy must match the PriorityClass, they are set by the API server before
+	// the webhook is called
+	preemptionPolicy := v1.PreemptLowerPriority
+	if priorityClass.PreemptionPolicy != nil {
+		preemptionPolicy = *priorityClass.PreemptionPolicy
+	}
+	pod.Spec.PriorityClassName = name
+	return append(patch,
+		common.PatchOperation{
+			Op:    "add",
+			Path:  "/spec/priorityClassName",
+			Value: name,
+		},
+		common.PatchOperation{
+			Op:    "add",
+			Path:  "/spec/priority",
+			Value: priorityClass.Value,
+		},
+		common.PatchOperation{
+			Op:    "add",
+			Path:  "/spec/preemptionPolicy",
+			Value: preemptionPolicy,
+		})
+}
+
+// getQueueName returns the fully qualified name of the queue the pod is submitted to, using the default queue
+// if the pod does not specify one.
+func (c *AdmissionController) getQueueName(pod *v1.Pod) string {
+	queueName := utils.GetPodLabelValue(pod, constants.LabelQueueName)
+	if queueName == "" {
+		queueName = utils.GetPodAnnotationValue(pod, constants.AnnotationQueueName)
+	}
+	if queueName == "" {
+		queueName = c.conf.GetDefaultQueueName()
+	}
+	if queueName != "" && !strings.HasPrefix(strings.ToLower(queueName), "root.") {
+		queueName = "root." + queueName
+	}
+	return queueName
+}
+
 func disableYuniKorn(namespace string, pod *v1.Pod, patch []common.PatchOperation) []common.PatchOperation {
 	log.Log(log.Admission).Info("disabling yunikorn on pod since namespace is set to no-label",
 		zap.String("podName", pod.Name),
@@ -484,6 +896,124 @@ func (c *AdmissionController) validateConf(req *admissionv1.AdmissionRequest) *a
 	return admissionResponseBuilder(uid, true, "", nil)
 }
 
+func (c *AdmissionController) validatePod(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req == nil {
+		log.Log(log.Admission).Warn("empty request received")
+		return admissionResponseBuilder("", false, "", nil)
+	}
+
+	uid := string(req.UID)
+	if req.Kind.Kind != metadata.Pod || req.Operation != admissionv1.Create {
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+
+	var pod v1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		log.Log(log.Admission).Error("unmarshal failed", zap.Error(err))
+		return admissionResponseBuilder(uid, false, err.Error(), nil)
+	}
+
+	// only pods that are handled by YuniKorn are validated, system namespaces are never validated
+	if pod.Spec.SchedulerName != constants.SchedulerName || c.namespaceMatchesBypassList(req.Namespace) {
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+
+	response := admissionResponseBuilder(uid, true, "", nil)
+	queueName := utils.GetPodLabelValue(&pod, constants.LabelQueueName)
+	if queueName == "" {
+		queueName = utils.GetPodAnnotationValue(&pod, constants.AnnotationQueueName)
+	}
+	// no queue set: the placement rules decide, nothing to validate
+	if queueName != "" {
+		partition := utils.GetPartitionFromPod(&pod)
+		if partition == "" {
+			partition = constants.DefaultPartition
+		}
+		response = c.checkQueueState(uid, partition, queueName)
+	}
+	if !response.Allowed {
+		return response
+	}
+	// dry run requests must not count against the burst limits
+	if req.DryRun != nil && *req.DryRun {
+		return response
+	}
+	if failureResponse := c.checkBurstLimit(uid, req, &pod); failureResponse != nil {
+		return failureResponse
+	}
+	return response
+}
+
+// checkBurstLimit rejects the pod if the submitter has reached the burst limit of its user or one of its groups.
+// The submitter is the user set in the user info annotation, or the requesting user if the annotation is not set.
+// Placeholder pods are created by the scheduler for an admitted application and are never limited.
+func (c *AdmissionController) checkBurstLimit(uid string, req *admissionv1.AdmissionRequest, pod *v1.Pod) *admissionv1.AdmissionResponse {
+	if utils.GetPlaceholderFlagFromPodSpec(pod) {
+		return nil
+	}
+	userName := req.UserInfo.Username
+	groups := req.UserInfo.Groups
+	if annotation, ok := pod.Annotations[common.UserInfoAnnotation]; ok {
+		var userGroups si.UserGroupInformation
+		if err := json.Unmarshal([]byte(annotation), &userGroups); err == nil {
+			userName = userGroups.User
+			groups = userGroups.Groups
+		}
+	}
+	if err := c.burstLimiter.admit(userName, groups, time.Now()); err != nil {
+		log.Log(log.Admission).Info("rejecting pod over the burst limit",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("user", userName),
+			zap.Error(err))
+		return admissionResponseBuilder(uid, false, err.Error(), nil)
+	}
+	return nil
+}
+
+// checkQueueState checks the state of the queue in the partition the pod is submitted to.
+// Pods submitted to a draining or stopped queue are either rejected or admitted with a warning,
+// depending on the configuration. Pods submitted to a queue that does not exist are rejected only
+// if configured, an unreachable scheduler never causes a rejection.
+func (c *AdmissionController) checkQueueState(uid string, partition string, queueName string) *admissionv1.AdmissionResponse {
+	if !strings.HasPrefix(strings.ToLower(queueName), "root.") {
+		queueName = "root." + queueName
+	}
+	state, exists, loaded := c.queueCache.getQueueState(partition, queueName)
+	if !loaded {
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+	if !exists {
+		if c.conf.GetRejectUnknownQueues() {
+			log.Log(log.Admission).Info("rejecting pod submitted to unknown queue",
+				zap.String("partition", partition),
+				zap.String("queue", queueName))
+			return admissionResponseBuilder(uid, false, fmt.Sprintf("queue %s does not exist in the scheduler configuration", queueName), nil)
+		}
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+	if state == queueStateActive {
+		return admissionResponseBuilder(uid, true, "", nil)
+	}
+
+	message := fmt.Sprintf("queue %s is in %s state and does not accept new applications", queueName, state)
+	if c.conf.GetRejectInactiveQueues() {
+		log.Log(log.Admission).Info("rejecting pod submitted to inactive queue",
+			zap.String("partition", partition),
+			zap.String("queue", queueName),
+			zap.String("state", state))
+		return admissionResponseBuilder(uid, false, message, nil)
+	}
+
+	log.Log(log.Admission).Info("pod submitted to inactive queue",
+		zap.String("partition", partition),
+		zap.String("queue", queueName),
+		zap.String("state", state))
+	response := admissionResponseBuilder(uid, true, "", nil)
+	response.Warnings = []string{message}
+	return response
+}
+
 func (c *AdmissionController) namespaceMatchesProcessList(namespace string) bool {
 	processNamespaces := c.conf.GetProcessNamespaces()
 	if len(processNamespaces) == 0 {
@@ -504,7 +1034,8 @@ func (c *AdmissionController) namespaceMatchesBypassList(namespace string) bool
 			return true
 		}
 	}
-	return false
+	// the labels are tracked by the namespace informer, changes take effect without a restart
+	return c.nsCache.matchesSelector(namespace, c.conf.GetBypassNamespaceSelector())
 }
 
 func (c *AdmissionController) namespaceMatchesLabelList(namespace string) bool {
@@ -543,6 +1074,33 @@ func (c *AdmissionController) shouldProcessNamespace(namespace string) bool {
 	return c.namespaceMatchesProcessList(namespace) && !c.namespaceMatchesBypassList(namespace)
 }
 
+// shouldProcessOwner returns the workload kind of the pod and true if the pod must be redirected to the
+// YuniKorn scheduler based on that kind. The workload kind is the kind of the top level controller found by
+// walking the owner references of the pod, "Pod" for a pod without a controller.
+// The owner chain is only resolved if owner kind filtering is configured.
+func (c *AdmissionController) shouldProcessOwner(namespace string, pod *v1.Pod) (string, bool) {
+	processOwnerKinds := c.conf.GetProcessOwnerKinds()
+	bypassOwnerKinds := c.conf.GetBypassOwnerKinds()
+	if len(processOwnerKinds) == 0 && len(bypassOwnerKinds) == 0 {
+		return "", true
+	}
+	kind := c.ownerCache.getWorkloadKind(namespace, metav1.GetControllerOf(pod))
+	for _, re := range bypassOwnerKinds {
+		if re.MatchString(kind) {
+			return kind, false
+		}
+	}
+	if len(processOwnerKinds) == 0 {
+		return kind, true
+	}
+	for _, re := range processOwnerKinds {
+		if re.MatchString(kind) {
+			return kind, true
+		}
+	}
+	return kind, false
+}
+
 // shouldLabelNamespace returns true if the pod in the namespace must be labeled
 // First check is the namespace annotation (tri-state)
 // - if present (FALSE, TRUE) return the value as boolean
@@ -627,6 +1185,21 @@ func (c *AdmissionController) Health(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ServedURLs returns the URL paths handled by the mux returned from NewServeMux
+func ServedURLs() []string {
+	return []string{healthURL, mutateURL, validateConfURL, validatePodsURL}
+}
+
+// NewServeMux returns a mux serving the health check and all webhook endpoints of the admission controller
+func (c *AdmissionController) NewServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(healthURL, c.Health)
+	mux.HandleFunc(mutateURL, c.Serve)
+	mux.HandleFunc(validateConfURL, c.Serve)
+	mux.HandleFunc(validatePodsURL, c.Serve)
+	return mux
+}
+
 func (c *AdmissionController) Serve(w http.ResponseWriter, r *http.Request) {
 	log.Log(log.Admission).Debug("request", zap.Any("httpRequest", r))
 	var body []byte
@@ -649,7 +1222,7 @@ func (c *AdmissionController) Serve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	urlPath := r.URL.Path
-	if urlPath != mutateURL && urlPath != validateConfURL {
+	if urlPath != mutateURL && urlPath != validateConfURL && urlPath != validatePodsURL {
 		log.Log(log.Admission).Debug("unsupported request received", zap.String("urlPath", urlPath))
 		http.Error(w, "request is neither mutation nor validation", http.StatusNotFound)
 		return
@@ -671,6 +1244,8 @@ func (c *AdmissionController) Serve(w http.ResponseWriter, r *http.Request) {
 			admissionResponse = c.mutate(req)
 		case validateConfURL:
 			admissionResponse = c.validateConf(req)
+		case validatePodsURL:
+			admissionResponse = c.validatePod(req)
 		}
 	}
 	admissionReview := admissionv1.AdmissionReview{
@@ -19,6 +19,7 @@
 package admission
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -26,6 +27,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -33,12 +35,16 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	authv1 "k8s.io/api/authentication/v1"
 	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/apache/yunikorn-k8shim/pkg/admission/common"
 	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 )
 
 type responseMode int
@@ -266,10 +272,241 @@ func TestUpdateSchedulerName(t *testing.T) {
 	}
 }
 
+func TestUpdateResources(t *testing.T) {
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMResourceDefaultsQueues:     `{"root.Batch": {"requests": {"cpu": "500m"}}}`,
+		conf.AMResourceDefaultsNamespaces: `{"test-ns": {"requests": {"cpu": "100m", "memory": "128Mi"}, "limits": {"memory": "256Mi"}}}`,
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "test-ns",
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init"}},
+			Containers: []v1.Container{
+				{Name: "no-resources"},
+				{Name: "all-resources", Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+					Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+				}},
+			},
+		},
+	}
+
+	// namespace defaults: the container with resources set is not patched
+	var patch []common.PatchOperation
+	patch = ac.updateResources("test-ns", pod, patch)
+	assert.Equal(t, len(patch), 2)
+	assert.Equal(t, patch[0].Op, "add")
+	assert.Equal(t, patch[0].Path, "/spec/initContainers/0/resources")
+	assert.Equal(t, patch[1].Path, "/spec/containers/0/resources")
+	resources, ok := patch[1].Value.(v1.ResourceRequirements)
+	assert.Assert(t, ok, "patch value is not a resource requirement")
+	assert.Equal(t, resources.Requests.Cpu().String(), "100m")
+	assert.Equal(t, resources.Requests.Memory().String(), "128Mi")
+	assert.Equal(t, resources.Limits.Memory().String(), "256Mi")
+
+	// queue defaults take precedence over the namespace defaults, queue names are case-insensitive
+	pod.Labels = map[string]string{constants.LabelQueueName: "batch"}
+	patch = ac.updateResources("test-ns", pod, nil)
+	assert.Equal(t, len(patch), 2)
+	resources, ok = patch[1].Value.(v1.ResourceRequirements)
+	assert.Assert(t, ok, "patch value is not a resource requirement")
+	assert.Equal(t, resources.Requests.Cpu().String(), "500m")
+	assert.Assert(t, resources.Limits == nil, "unexpected limits set")
+
+	// no defaults for the queue or namespace
+	pod.Labels = nil
+	patch = ac.updateResources("other-ns", pod, nil)
+	assert.Equal(t, len(patch), 0)
+}
+
+func TestUpdatePodGroup(t *testing.T) {
+	timeout := int32(60)
+	pgCache := NewPodGroupCache(nil)
+	pgCache.podGroups[podGroupKey("test-ns", "test-pg")] = &podGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pg", Namespace: "test-ns"},
+		Spec: podGroupSpec{
+			MinMember:              2,
+			MinResources:           v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+			ScheduleTimeoutSeconds: &timeout,
+		},
+	}
+	pgCache.podGroups[podGroupKey("test-ns", "no-members")] = &podGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-members", Namespace: "test-ns"},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "test-ns",
+			Labels:      map[string]string{constants.LabelPodGroup: "test-pg"},
+			Annotations: map[string]string{"existing": "value"},
+		},
+	}
+
+	// disabled: pod is not changed
+	ac := InitAdmissionController(createConfig(), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), pgCache, nil, nil)
+	patch := ac.updatePodGroup("test-ns", pod, nil)
+	assert.Equal(t, len(patch), 0)
+
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMPodGroupEnable: "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), pgCache, nil, nil)
+
+	// no pod group, unknown pod group or a pod group without members
+	patch = ac.updatePodGroup("test-ns", &v1.Pod{}, nil)
+	assert.Equal(t, len(patch), 0)
+	patch = ac.updatePodGroup("other-ns", pod, nil)
+	assert.Equal(t, len(patch), 0)
+	noMembers := pod.DeepCopy()
+	noMembers.Labels[constants.LabelPodGroup] = "no-members"
+	patch = ac.updatePodGroup("test-ns", noMembers, nil)
+	assert.Equal(t, len(patch), 0)
+
+	// task groups defined on the pod take precedence
+	defined := pod.DeepCopy()
+	defined.Annotations[constants.AnnotationTaskGroups] = "[]"
+	patch = ac.updatePodGroup("test-ns", defined, nil)
+	assert.Equal(t, len(patch), 0)
+
+	// pod group member
+	member := pod.DeepCopy()
+	patch = ac.updatePodGroup("test-ns", member, nil)
+	assert.Equal(t, len(patch), 1)
+	assert.Equal(t, patch[0].Path, "/metadata/annotations")
+	annotations, ok := patch[0].Value.(map[string]string)
+	assert.Assert(t, ok, "patch value is not an annotation map")
+	assert.Equal(t, annotations["existing"], "value")
+	assert.Equal(t, annotations[constants.AnnotationTaskGroupName], "test-pg")
+	assert.Equal(t, annotations[constants.AnnotationSchedulingPolicyParam], "placeholderTimeoutInSeconds=60")
+	assert.Equal(t, annotations[constants.AnnotationTaskGroups], `[{"name":"test-pg","minMember":2,"minResource":{"cpu":"1"}}]`)
+	assert.Equal(t, member.Labels[constants.LabelApplicationID], "test-ns-test-pg")
+
+	// existing application ID and annotation patch are kept
+	member = pod.DeepCopy()
+	member.Labels[constants.LabelApplicationID] = "app-1"
+	existing := map[string]string{common.UserInfoAnnotation: "user"}
+	patch = ac.updatePodGroup("test-ns", member, []common.PatchOperation{{
+		Op:    "add",
+		Path:  "/metadata/annotations",
+		Value: existing,
+	}})
+	assert.Equal(t, len(patch), 1)
+	assert.Equal(t, existing[common.UserInfoAnnotation], "user")
+	assert.Equal(t, existing[constants.AnnotationTaskGroupName], "test-pg")
+	assert.Equal(t, member.Labels[constants.LabelApplicationID], "app-1")
+}
+
+func TestUpdatePlacement(t *testing.T) {
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMPlacementQueues: `{"root.GPU": {"tolerations": [{"key": "gpu", "operator": "Exists", "effect": "NoSchedule"}], "nodeSelector": {"pool": "gpu", "zone": "a"}}}`,
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	gpuToleration := v1.Toleration{Key: "gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}
+	otherToleration := v1.Toleration{Key: "other", Operator: v1.TolerationOpExists}
+
+	// no placement for the queue
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod"}}
+	patch := ac.updatePlacement(pod, nil)
+	assert.Equal(t, len(patch), 0)
+
+	// tolerations and node selector added, queue names are case-insensitive
+	pod.Labels = map[string]string{constants.LabelQueueName: "root.gpu"}
+	pod.Spec.Tolerations = []v1.Toleration{otherToleration}
+	patch = ac.updatePlacement(pod, nil)
+	assert.Equal(t, len(patch), 2)
+	assert.Equal(t, patch[0].Path, "/spec/tolerations")
+	tolerations, ok := patch[0].Value.([]v1.Toleration)
+	assert.Assert(t, ok, "patch value is not a toleration list")
+	assert.DeepEqual(t, tolerations, []v1.Toleration{otherToleration, gpuToleration})
+	assert.Equal(t, len(pod.Spec.Tolerations), 1)
+	assert.Equal(t, patch[1].Path, "/spec/nodeSelector")
+	nodeSelector, ok := patch[1].Value.(map[string]string)
+	assert.Assert(t, ok, "patch value is not a node selector")
+	assert.DeepEqual(t, nodeSelector, map[string]string{"pool": "gpu", "zone": "a"})
+
+	// existing toleration is not duplicated, pod node selector takes precedence
+	pod.Spec.Tolerations = []v1.Toleration{gpuToleration}
+	pod.Spec.NodeSelector = map[string]string{"zone": "b"}
+	patch = ac.updatePlacement(pod, nil)
+	assert.Equal(t, len(patch), 1)
+	assert.Equal(t, patch[0].Path, "/spec/nodeSelector")
+	nodeSelector, ok = patch[0].Value.(map[string]string)
+	assert.Assert(t, ok, "patch value is not a node selector")
+	assert.DeepEqual(t, nodeSelector, map[string]string{"pool": "gpu", "zone": "b"})
+
+	// everything already set
+	pod.Spec.NodeSelector = map[string]string{"pool": "gpu", "zone": "a"}
+	patch = ac.updatePlacement(pod, nil)
+	assert.Equal(t, len(patch), 0)
+}
+
+func TestUpdatePriorityClass(t *testing.T) {
+	never := v1.PreemptNever
+	pcCache := NewPriorityClassCache(nil)
+	pcCache.definitions["batch-low"] = &schedulingv1.PriorityClass{
+		ObjectMeta:       metav1.ObjectMeta{Name: "batch-low"},
+		Value:            100,
+		PreemptionPolicy: &never,
+	}
+	pcCache.priorityClasses["batch-low"] = false
+	pcCache.definitions["cluster-default"] = &schedulingv1.PriorityClass{
+		ObjectMeta:    metav1.ObjectMeta{Name: "cluster-default"},
+		Value:         10,
+		GlobalDefault: true,
+	}
+	pcCache.definitions["critical"] = &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "critical"},
+		Value:      1000,
+	}
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMPriorityClassQueues: `{"root.batch": "batch-low", "root.missing": "not-found"}`,
+	}), pcCache, createNamespaceClassCacheForTest(), nil, nil, nil)
+
+	tests := map[string]struct {
+		queue         string
+		priorityClass string
+		expected      string
+	}{
+		"not configured":          {"root.default", "", ""},
+		"no priority class":       {"batch", "", "batch-low"},
+		"global default replaced": {"root.batch", "cluster-default", "batch-low"},
+		"explicit class kept":     {"root.batch", "critical", "critical"},
+		"class does not exist":    {"root.missing", "", ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-pod",
+					Labels: map[string]string{constants.LabelQueueName: tc.queue},
+				},
+				Spec: v1.PodSpec{PriorityClassName: tc.priorityClass},
+			}
+			patch := ac.updatePriorityClass(pod, nil)
+			assert.Equal(t, pod.Spec.PriorityClassName, tc.expected)
+			if tc.expected == tc.priorityClass {
+				assert.Equal(t, len(patch), 0)
+				return
+			}
+			assert.Equal(t, len(patch), 3)
+			assert.Equal(t, patch[0].Path, "/spec/priorityClassName")
+			assert.Equal(t, patch[0].Value, "batch-low")
+			assert.Equal(t, patch[1].Path, "/spec/priority")
+			assert.Equal(t, patch[1].Value, int32(100))
+			assert.Equal(t, patch[2].Path, "/spec/preemptionPolicy")
+			assert.Equal(t, patch[2].Value, v1.PreemptNever)
+			// the preemption info follows the injected priority class
+			patch = ac.updatePreemptionInfo(pod, patch)
+			assert.Equal(t, patch[3].Value.(map[string]string)[constants.AnnotationAllowPreemption], constants.False)
+		})
+	}
+}
+
 func TestValidateConfigMapEmpty(t *testing.T) {
 	pcCache := createPriorityClassCacheForTest()
 	nsCache := createNamespaceClassCacheForTest()
-	controller := InitAdmissionController(createConfig(), pcCache, nsCache)
+	controller := InitAdmissionController(createConfig(), pcCache, nsCache, nil, nil, nil)
 	configmap := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: constants.ConfigMapName,
@@ -366,7 +603,7 @@ func prepareController(t *testing.T, url string, processNs string, bypassNs stri
 		conf.AMAccessControlExternalUsers:     "^testExtUser$",
 		conf.AMAccessControlExternalGroups:    "^testExtGroup$",
 	})
-	return InitAdmissionController(config, pcCache, nsCache)
+	return InitAdmissionController(config, pcCache, nsCache, nil, nil, nil)
 }
 
 func serverMock(mode responseMode) *httptest.Server {
@@ -709,6 +946,273 @@ func TestMutateUpdate(t *testing.T) {
 	assert.Check(t, resp.Allowed, "response was not allowed")
 }
 
+const queuesData = `{
+	"queuename": "root",
+	"status": "Active",
+	"children": [
+		{"queuename": "root.active", "status": "Active"},
+		{"queuename": "root.draining", "status": "Draining"},
+		{"queuename": "root.stopped", "status": "Stopped"}
+	]
+}`
+
+const gpuQueuesData = `{
+	"queuename": "root",
+	"status": "Active",
+	"children": [
+		{"queuename": "root.gpu", "status": "Active"}
+	]
+}`
+
+func queuesServerMock() *httptest.Server {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/ws/v1/partitions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(`[{"name": "default"}, {"name": "gpu"}]`)) //nolint:errcheck
+	})
+	handler.HandleFunc("/ws/v1/partition/default/queues", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(queuesData)) //nolint:errcheck
+	})
+	handler.HandleFunc("/ws/v1/partition/gpu/queues", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(gpuQueuesData)) //nolint:errcheck
+	})
+	return httptest.NewServer(handler)
+}
+
+// waitForQueues waits until the queue cache of the admission controller has retrieved the queues
+func waitForQueues(t *testing.T, ac *AdmissionController) {
+	err := utils.WaitForCondition(func() bool {
+		ac.queueCache.RLock()
+		defer ac.queueCache.RUnlock()
+		return ac.queueCache.loaded
+	}, 10*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err, "queues not retrieved from scheduler")
+}
+
+func TestValidatePod(t *testing.T) {
+	srv := queuesServerMock()
+	defer srv.Close()
+	url := strings.Replace(srv.URL, "http://", "", 1)
+
+	podRequest := func(t *testing.T, schedulerName string, queue string) *admissionv1.AdmissionRequest {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns"},
+			Spec:       v1.PodSpec{SchedulerName: schedulerName},
+		}
+		if queue != "" {
+			pod.Labels = map[string]string{constants.LabelQueueName: queue}
+		}
+		podJSON, err := json.Marshal(pod)
+		assert.NilError(t, err, "failed to marshal pod")
+		return &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Namespace: "test-ns",
+			Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: podJSON},
+		}
+	}
+
+	// warn only
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMWebHookSchedulerServiceAddress: url,
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	waitForQueues(t, ac)
+
+	resp := ac.validatePod(nil)
+	assert.Check(t, !resp.Allowed, "response allowed with nil request")
+
+	resp = ac.validatePod(podRequest(t, "default-scheduler", "root.stopped"))
+	assert.Check(t, resp.Allowed, "pod for other scheduler not allowed")
+	assert.Equal(t, len(resp.Warnings), 0, "pod for other scheduler has warnings")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, ""))
+	assert.Check(t, resp.Allowed, "pod without queue not allowed")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.active"))
+	assert.Check(t, resp.Allowed, "pod for active queue not allowed")
+	assert.Equal(t, len(resp.Warnings), 0, "pod for active queue has warnings")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.unknown"))
+	assert.Check(t, resp.Allowed, "pod for unknown queue not allowed")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "draining"))
+	assert.Check(t, resp.Allowed, "pod for draining queue not allowed")
+	assert.Equal(t, len(resp.Warnings), 1, "pod for draining queue has no warning")
+	assert.Assert(t, strings.Contains(resp.Warnings[0], "Draining"), "queue state missing from warning")
+
+	// reject
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMWebHookSchedulerServiceAddress:      url,
+		conf.AMQueueValidationRejectInactiveQueues: "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	waitForQueues(t, ac)
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.stopped"))
+	assert.Check(t, !resp.Allowed, "pod for stopped queue allowed")
+	assert.Assert(t, strings.Contains(resp.Result.Message, "Stopped"), "queue state missing from message")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.active"))
+	assert.Check(t, resp.Allowed, "pod for active queue not allowed")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.unknown"))
+	assert.Check(t, resp.Allowed, "pod for unknown queue not allowed")
+
+	// reject unknown queues
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMWebHookSchedulerServiceAddress:     url,
+		conf.AMQueueValidationRejectUnknownQueues: "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	waitForQueues(t, ac)
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.unknown"))
+	assert.Check(t, !resp.Allowed, "pod for unknown queue allowed")
+	assert.Assert(t, strings.Contains(resp.Result.Message, "root.unknown does not exist"), "wrong message for unknown queue")
+
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "ACTIVE"))
+	assert.Check(t, resp.Allowed, "pod for existing queue not allowed")
+
+	// queues are validated in the partition of the pod
+	partitionRequest := func(t *testing.T, queue string) *admissionv1.AdmissionRequest {
+		partitionPod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test-ns",
+				Labels:    map[string]string{constants.LabelQueueName: queue, constants.LabelPartition: "gpu"},
+			},
+			Spec: v1.PodSpec{SchedulerName: constants.SchedulerName},
+		}
+		podJSON, err := json.Marshal(partitionPod)
+		assert.NilError(t, err, "failed to marshal pod")
+		req := podRequest(t, constants.SchedulerName, queue)
+		req.Object = runtime.RawExtension{Raw: podJSON}
+		return req
+	}
+	resp = ac.validatePod(partitionRequest(t, "root.gpu"))
+	assert.Check(t, resp.Allowed, "pod for queue in gpu partition not allowed")
+	resp = ac.validatePod(partitionRequest(t, "root.active"))
+	assert.Check(t, !resp.Allowed, "pod for queue of default partition allowed in gpu partition")
+
+	// system namespaces are not validated
+	req := podRequest(t, constants.SchedulerName, "root.unknown")
+	req.Namespace = "kube-system"
+	resp = ac.validatePod(req)
+	assert.Check(t, resp.Allowed, "pod in kube-system not allowed")
+
+	// scheduler unreachable
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMWebHookSchedulerServiceAddress:      "localhost:1",
+		conf.AMQueueValidationRejectInactiveQueues: "true",
+		conf.AMQueueValidationRejectUnknownQueues:  "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.stopped"))
+	assert.Check(t, resp.Allowed, "pod not allowed with unreachable scheduler")
+	resp = ac.validatePod(podRequest(t, constants.SchedulerName, "root.unknown"))
+	assert.Check(t, resp.Allowed, "pod for unknown queue not allowed with unreachable scheduler")
+}
+
+func TestServeMux(t *testing.T) {
+	queues := queuesServerMock()
+	defer queues.Close()
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMWebHookSchedulerServiceAddress:      strings.Replace(queues.URL, "http://", "", 1),
+		conf.AMQueueValidationRejectInactiveQueues: "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	waitForQueues(t, ac)
+	srv := httptest.NewServer(ac.NewServeMux())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + healthURL)
+	assert.NilError(t, err, "health check failed")
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.NilError(t, resp.Body.Close())
+
+	review := func(path, queue string) *admissionv1.AdmissionResponse {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Labels: map[string]string{constants.LabelQueueName: queue}},
+			Spec:       v1.PodSpec{SchedulerName: constants.SchedulerName},
+		}
+		podJSON, err := json.Marshal(pod)
+		assert.NilError(t, err, "failed to marshal pod")
+		body, err := json.Marshal(admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: admissionReviewAPIVersion, Kind: admissionReviewKind},
+			Request: &admissionv1.AdmissionRequest{
+				UID:       "test-uid",
+				Namespace: "test-ns",
+				Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: podJSON},
+			},
+		})
+		assert.NilError(t, err, "failed to marshal admission review")
+		resp, err := http.Post(srv.URL+path, "application/json", bytes.NewReader(body))
+		assert.NilError(t, err, "request to %s failed", path)
+		defer resp.Body.Close()
+		assert.Equal(t, resp.StatusCode, http.StatusOK, "unexpected status for %s", path)
+		var result admissionv1.AdmissionReview
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&result), "failed to decode response")
+		assert.Assert(t, result.Response != nil, "no admission response for %s", path)
+		return result.Response
+	}
+
+	for _, path := range ServedURLs() {
+		if path == healthURL || path == validateConfURL {
+			continue
+		}
+		assert.Check(t, review(path, "root.active").Allowed, "pod for active queue not allowed on %s", path)
+	}
+	assert.Check(t, !review(validatePodsURL, "root.stopped").Allowed, "pod for stopped queue allowed")
+}
+
+func TestValidatePodBurstLimit(t *testing.T) {
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMBurstLimitUsers: `{"alice": 2}`,
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+
+	podRequest := func(t *testing.T, annotations map[string]string) *admissionv1.AdmissionRequest {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Annotations: annotations},
+			Spec:       v1.PodSpec{SchedulerName: constants.SchedulerName},
+		}
+		podJSON, err := json.Marshal(pod)
+		assert.NilError(t, err, "failed to marshal pod")
+		return &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Namespace: "test-ns",
+			Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: podJSON},
+			UserInfo:  authv1.UserInfo{Username: "alice"},
+		}
+	}
+
+	// dry run requests are not counted
+	dryRun := true
+	req := podRequest(t, nil)
+	req.DryRun = &dryRun
+	resp := ac.validatePod(req)
+	assert.Check(t, resp.Allowed, "dry run pod not allowed")
+
+	for i := 0; i < 2; i++ {
+		resp = ac.validatePod(podRequest(t, nil))
+		assert.Check(t, resp.Allowed, "pod %d within burst limit not allowed", i)
+	}
+	resp = ac.validatePod(podRequest(t, nil))
+	assert.Check(t, !resp.Allowed, "pod over burst limit allowed")
+	assert.Assert(t, strings.Contains(resp.Result.Message, "user alice exceeded the burst limit"), "wrong message for burst limit")
+
+	// placeholders are not limited
+	resp = ac.validatePod(podRequest(t, map[string]string{constants.AnnotationPlaceholderFlag: constants.True}))
+	assert.Check(t, resp.Allowed, "placeholder pod not allowed")
+
+	// the user info annotation takes precedence over the requesting user
+	resp = ac.validatePod(podRequest(t, map[string]string{common.UserInfoAnnotation: `{"user":"bob"}`}))
+	assert.Check(t, resp.Allowed, "pod submitted for other user not allowed")
+	resp = ac.validatePod(podRequest(t, map[string]string{common.UserInfoAnnotation: `{"user":"alice"}`}))
+	assert.Check(t, !resp.Allowed, "pod submitted for limited user allowed")
+}
+
 func TestExternalAuthentication(t *testing.T) {
 	ac := prepareController(t, "", "", "^kube-system$,^bypass$", "", "^nolabel$", false, true)
 
@@ -899,6 +1403,93 @@ func TestShouldProcessNamespace(t *testing.T) {
 	assert.Check(t, ac.shouldProcessNamespace("ns-regexp-deny"), "namespace override via annotation")
 }
 
+func TestShouldProcessNamespaceBypassSelector(t *testing.T) {
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMFilteringBypassNamespaceSelector: "yunikorn.apache.org/ignore=true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	ac.nsCache.nameSpaces["ns-ignored"] = nsFlags{enableYuniKorn: UNSET, generateAppID: UNSET}
+	ac.nsCache.nsLabels["ns-ignored"] = map[string]string{"yunikorn.apache.org/ignore": "true"}
+	ac.nsCache.nameSpaces["ns-not-ignored"] = nsFlags{enableYuniKorn: UNSET, generateAppID: UNSET}
+	ac.nsCache.nsLabels["ns-not-ignored"] = map[string]string{"yunikorn.apache.org/ignore": "false"}
+	ac.nsCache.nameSpaces["ns-annotated"] = nsFlags{enableYuniKorn: TRUE, generateAppID: UNSET}
+	ac.nsCache.nsLabels["ns-annotated"] = map[string]string{"yunikorn.apache.org/ignore": "true"}
+
+	assert.Check(t, !ac.shouldProcessNamespace("ns-ignored"), "namespace selected by bypass selector allowed")
+	assert.Check(t, ac.shouldProcessNamespace("ns-not-ignored"), "namespace not selected by bypass selector not allowed")
+	assert.Check(t, ac.shouldProcessNamespace("unknown"), "unknown namespace not allowed")
+	assert.Check(t, !ac.shouldProcessNamespace("kube-system"), "kube-system namespace allowed")
+	// the annotation overrides the bypass selector
+	assert.Check(t, ac.shouldProcessNamespace("ns-annotated"), "namespace override via annotation")
+
+	// labels removed from the namespace
+	ac.nsCache.nsLabels["ns-ignored"] = map[string]string{}
+	assert.Check(t, ac.shouldProcessNamespace("ns-ignored"), "namespace without label not allowed")
+}
+
+func TestShouldProcessOwner(t *testing.T) {
+	ownerCache := NewOwnerCache(nil, nil)
+	handler := &ownerUpdateHandler{cache: ownerCache, kind: "Job"}
+	handler.OnAdd(createObjectMetaForTest("batch/v1", "Job", "test-job", controllerRef("batch/v1", "CronJob", "test-cron")), false)
+	bare := &v1.Pod{}
+	daemon := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{*controllerRef("apps/v1", "DaemonSet", "test-ds")},
+	}}
+	job := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{*controllerRef("batch/v1", "Job", "test-job")},
+	}}
+
+	// no filtering configured
+	ac := InitAdmissionController(createConfig(), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, ownerCache, nil)
+	_, process := ac.shouldProcessOwner("test-ns", daemon)
+	assert.Check(t, process, "pod not allowed without owner kind filtering")
+
+	// bypass list only
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMFilteringBypassOwnerKinds: "^DaemonSet$",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, ownerCache, nil)
+	kind, process := ac.shouldProcessOwner("test-ns", daemon)
+	assert.Check(t, !process, "DaemonSet pod allowed when on bypass list")
+	assert.Equal(t, kind, "DaemonSet")
+	_, process = ac.shouldProcessOwner("test-ns", bare)
+	assert.Check(t, process, "bare pod not allowed when not on bypass list")
+
+	// process list, the owner chain is walked up to the CronJob
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMFilteringProcessOwnerKinds: "^CronJob$,^SparkApplication$",
+		conf.AMFilteringBypassOwnerKinds:  "^DaemonSet$",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, ownerCache, nil)
+	kind, process = ac.shouldProcessOwner("test-ns", job)
+	assert.Check(t, process, "CronJob pod not allowed when on process list")
+	assert.Equal(t, kind, "CronJob")
+	kind, process = ac.shouldProcessOwner("test-ns", bare)
+	assert.Check(t, !process, "bare pod allowed when not on process list")
+	assert.Equal(t, kind, "Pod")
+	_, process = ac.shouldProcessOwner("test-ns", daemon)
+	assert.Check(t, !process, "DaemonSet pod allowed when on bypass list")
+
+	// the scheduler name is not set on a bypassed pod
+	podJSON, err := json.Marshal(daemon)
+	assert.NilError(t, err, "failed to marshal pod")
+	resp := ac.mutate(&admissionv1.AdmissionRequest{
+		UID:       "test-uid",
+		Namespace: "test-ns",
+		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		Object:    runtime.RawExtension{Raw: podJSON},
+	})
+	assert.Check(t, resp.Allowed, "response not allowed for bypassed pod")
+	assert.Equal(t, len(resp.Patch), 0, "non-empty patch for bypassed pod")
+	podJSON, err = json.Marshal(job)
+	assert.NilError(t, err, "failed to marshal pod")
+	resp = ac.mutate(&admissionv1.AdmissionRequest{
+		UID:       "test-uid",
+		Namespace: "test-ns",
+		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		Object:    runtime.RawExtension{Raw: podJSON},
+	})
+	assert.Check(t, resp.Allowed, "response not allowed for pod")
+	assert.Equal(t, schedulerName(t, resp.Patch), "yunikorn", "yunikorn not set as scheduler for pod")
+}
+
 func TestShouldLabelNamespace(t *testing.T) {
 	ac := prepareController(t, "", "", "", "", "^skip$", false, true)
 	assert.Check(t, ac.shouldLabelNamespace("test"), "test namespace not allowed")
@@ -958,31 +1549,31 @@ func TestParseRegexes(t *testing.T) {
 func TestInitAdmissionControllerRegexErrorHandling(t *testing.T) {
 	pcCache := createPriorityClassCacheForTest()
 	nsCache := createNamespaceClassCacheForTest()
-	ac := InitAdmissionController(createConfig(), pcCache, nil)
+	ac := InitAdmissionController(createConfig(), pcCache, nil, nil, nil, nil)
 	assert.Equal(t, 1, len(ac.conf.GetBypassNamespaces()))
 	assert.Equal(t, conf.DefaultFilteringBypassNamespaces, ac.conf.GetBypassNamespaces()[0].String(), "didn't set default bypassNamespaces")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringProcessNamespaces: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringProcessNamespaces: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 0, len(ac.conf.GetProcessNamespaces()), "didn't fail on bad processNamespaces list")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringBypassNamespaces: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringBypassNamespaces: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 1, len(ac.conf.GetBypassNamespaces()))
 	assert.Equal(t, conf.DefaultFilteringBypassNamespaces, ac.conf.GetBypassNamespaces()[0].String(), "didn't fail on bad bypassNamespaces list")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringLabelNamespaces: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringLabelNamespaces: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 0, len(ac.conf.GetLabelNamespaces()), "didn't fail on bad labelNamespaces list")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringNoLabelNamespaces: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringNoLabelNamespaces: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 0, len(ac.conf.GetNoLabelNamespaces()), "didn't fail on bad noLabelNamespaces list")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMAccessControlSystemUsers: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMAccessControlSystemUsers: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 1, len(ac.conf.GetSystemUsers()))
 	assert.Equal(t, conf.DefaultAccessControlSystemUsers, ac.conf.GetSystemUsers()[0].String(), "didn't fail on bad systemUsers list")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMAccessControlExternalUsers: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMAccessControlExternalUsers: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 0, len(ac.conf.GetExternalUsers()), "didn't fail on bad externalUsers list")
 
-	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMAccessControlExternalGroups: "("}), pcCache, nsCache)
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMAccessControlExternalGroups: "("}), pcCache, nsCache, nil, nil, nil)
 	assert.Equal(t, 0, len(ac.conf.GetExternalGroups()), "didn't fail on bad externalGroups list")
 }
 
@@ -992,14 +1583,240 @@ func createPriorityClassCacheForTest() *PriorityClassCache {
 	}
 }
 
+func TestCheckGangLimits(t *testing.T) {
+	nsCache := createNamespaceClassCacheForTest()
+	ac := InitAdmissionController(createConfig(), createPriorityClassCacheForTest(), nsCache, nil, nil, nil)
+	gang := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{
+			constants.AnnotationTaskGroups: `[{"name":"tg-1","minMember":4,"minResource":{"cpu":"1","memory":"1G"}},{"name":"tg-2","minMember":2,"minResource":{"cpu":"2"}}]`,
+		},
+	}}
+
+	// no limits on the namespace
+	assert.NilError(t, ac.checkGangLimits(testNS, gang))
+
+	nsCache.gangLimits[testNS] = nsGangLimits{maxGangSize: 6}
+	assert.NilError(t, ac.checkGangLimits(testNS, gang))
+	assert.NilError(t, ac.checkGangLimits(testNS, &v1.Pod{}), "pod without task groups")
+	nsCache.gangLimits[testNS] = nsGangLimits{maxGangSize: 5}
+	assert.Error(t, ac.checkGangLimits(testNS, gang), "gang size 6 exceeds the maximum gang size 5 of the namespace")
+
+	nsCache.gangLimits[testNS] = nsGangLimits{maxResource: utils.GetNamespaceMaxPlaceholderResource(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constants.NamespaceMaxPlaceholderResource: `{"cpu":"7"}`}},
+	})}
+	assert.Error(t, ac.checkGangLimits(testNS, gang), "placeholder resource vcore of 8000 exceeds the maximum 7000 of the namespace")
+
+	// the pod is rejected by the webhook
+	gang.Namespace = testNS
+	req := &admissionv1.AdmissionRequest{
+		UID:       "7f5fd6c5d5f0",
+		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		Namespace: testNS,
+		Operation: admissionv1.Create,
+	}
+	podJSON, err := json.Marshal(gang)
+	assert.NilError(t, err)
+	req.Object = runtime.RawExtension{Raw: podJSON}
+	resp := ac.processPod(req, testNS)
+	assert.Check(t, !resp.Allowed, "pod exceeding the gang limits allowed")
+}
+
+func TestCheckAppIDCollision(t *testing.T) {
+	ac := InitAdmissionController(createConfig(), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	appIDCache := NewAppIDCache(nil)
+	appIDCache.add("other-ns/pod-1", "other-ns", "app-1")
+	ac.SetAppIDCache(appIDCache)
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "pod-2",
+		Namespace: testNS,
+		Labels:    map[string]string{constants.LabelApplicationID: "app-1"},
+	}}
+	assert.Error(t, ac.checkAppIDCollision(testNS, pod), "applicationId app-1 is already used by pods in namespace other-ns, use a unique applicationId")
+	assert.NilError(t, ac.checkAppIDCollision("other-ns", pod), "same namespace")
+	assert.NilError(t, ac.checkAppIDCollision(testNS, &v1.Pod{}), "pod without applicationId")
+
+	// the pod is rejected by the webhook
+	req := &admissionv1.AdmissionRequest{
+		UID:       "7f5fd6c5d5f0",
+		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		Namespace: testNS,
+		Operation: admissionv1.Create,
+	}
+	podJSON, err := json.Marshal(pod)
+	assert.NilError(t, err)
+	req.Object = runtime.RawExtension{Raw: podJSON}
+	resp := ac.processPod(req, testNS)
+	assert.Check(t, !resp.Allowed, "pod with a colliding applicationId allowed")
+
+	// the check is disabled
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{conf.AMFilteringRejectAppIdCollisions: "false"}),
+		createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	ac.SetAppIDCache(appIDCache)
+	assert.NilError(t, ac.checkAppIDCollision(testNS, pod))
+}
+
+func TestCheckTaskGroups(t *testing.T) {
+	nodeCache := NewNodeCache(nil)
+	nodeCache.allocatable["node-1"] = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("4"),
+		v1.ResourceMemory: resource.MustParse("8Gi"),
+	}
+	ac := InitAdmissionController(createConfig(), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nodeCache)
+	tests := map[string]struct {
+		taskGroups string
+		err        string
+	}{
+		"no task groups":    {"", ""},
+		"valid":             {`[{"name":"tg-1","minMember":2,"minResource":{"cpu":"4","memory":"8Gi"}}]`, ""},
+		"malformed JSON":    {`[{"name":"tg-1","minMember":2,`, "invalid yunikorn.apache.org/task-groups annotation"},
+		"duplicate name":    {`[{"name":"tg-1","minMember":2,"minResource":{"cpu":"1"}},{"name":"tg-1","minMember":1,"minResource":{"cpu":"1"}}]`, "duplicate taskGroup Name tg-1"},
+		"negative member":   {`[{"name":"tg-1","minMember":-1,"minResource":{"cpu":"1"}}]`, "minMember cannot be negative"},
+		"exceeds node size": {`[{"name":"tg-1","minMember":1,"minResource":{"cpu":"5"}}]`, ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if tc.taskGroups != "" {
+				pod.Annotations[constants.AnnotationTaskGroups] = tc.taskGroups
+			}
+			err := ac.checkTaskGroups(pod)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+
+	// node capacity is only checked if enabled
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMTaskGroupNodeCapacityCheck: "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nodeCache)
+	gang := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: testNS,
+		Annotations: map[string]string{
+			constants.AnnotationTaskGroups: `[{"name":"tg-1","minMember":1,"minResource":{"cpu":"2"}},{"name":"tg-2","minMember":1,"minResource":{"cpu":"2","nvidia.com/gpu":"1"}}]`,
+		},
+	}}
+	assert.Error(t, ac.checkTaskGroups(gang), "minResource of task group tg-2 does not fit on any node")
+	nodeCache.allocatable["node-2"] = v1.ResourceList{
+		v1.ResourceCPU:                    resource.MustParse("2"),
+		v1.ResourceName("nvidia.com/gpu"): resource.MustParse("1"),
+	}
+	assert.NilError(t, ac.checkTaskGroups(gang))
+
+	// the pod is rejected by the webhook
+	gang.Annotations[constants.AnnotationTaskGroups] = `[{"name":"tg-1","minMember":1,"minResource":{"cpu":"8"}}]`
+	req := &admissionv1.AdmissionRequest{
+		UID:       "7f5fd6c5d5f1",
+		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		Namespace: testNS,
+		Operation: admissionv1.Create,
+	}
+	podJSON, err := json.Marshal(gang)
+	assert.NilError(t, err)
+	req.Object = runtime.RawExtension{Raw: podJSON}
+	resp := ac.processPod(req, testNS)
+	assert.Check(t, !resp.Allowed, "pod with task group exceeding the node size allowed")
+	assert.Equal(t, resp.Result.Message, "minResource of task group tg-1 does not fit on any node")
+}
+
+func TestProcessPodDuplicatePods(t *testing.T) {
+	podRequest := func(t *testing.T, annotations map[string]string) *admissionv1.AdmissionRequest {
+		pod := duplicatePodForTest("uid-1", "busybox")
+		pod.Namespace = testNS
+		pod.Annotations = annotations
+		podJSON, err := json.Marshal(pod)
+		assert.NilError(t, err, "failed to marshal pod")
+		return &admissionv1.AdmissionRequest{
+			UID:       "7f5fd6c5d5f1",
+			Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+			Namespace: testNS,
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: podJSON},
+		}
+	}
+	backoff := func(t *testing.T, patch []byte) interface{} {
+		for _, op := range parsePatch(t, patch) {
+			if op.Path == "/metadata/annotations" {
+				return op.Value.(map[string]interface{})[constants.AnnotationDuplicatePodBackoff]
+			}
+		}
+		return nil
+	}
+
+	ac := InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMDuplicatePodThreshold: "2",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+
+	// dry run requests and placeholders are not counted
+	dryRun := true
+	req := podRequest(t, nil)
+	req.DryRun = &dryRun
+	resp := ac.processPod(req, testNS)
+	assert.Check(t, resp.Allowed, "dry run pod not allowed")
+	resp = ac.processPod(podRequest(t, map[string]string{constants.AnnotationPlaceholderFlag: constants.True}), testNS)
+	assert.Check(t, resp.Allowed, "placeholder pod not allowed")
+
+	for i := 0; i < 2; i++ {
+		resp = ac.processPod(podRequest(t, nil), testNS)
+		assert.Check(t, resp.Allowed, "pod %d within threshold not allowed", i)
+		assert.Check(t, backoff(t, resp.Patch) == nil, "pod %d within threshold annotated", i)
+	}
+	resp = ac.processPod(podRequest(t, nil), testNS)
+	assert.Check(t, resp.Allowed, "duplicate pod not allowed")
+	assert.Equal(t, backoff(t, resp.Patch), "1s")
+
+	// reject instead of annotating
+	ac = InitAdmissionController(createConfigWithOverrides(map[string]string{
+		conf.AMDuplicatePodThreshold: "2",
+		conf.AMDuplicatePodReject:    "true",
+	}), createPriorityClassCacheForTest(), createNamespaceClassCacheForTest(), nil, nil, nil)
+	for i := 0; i < 2; i++ {
+		resp = ac.processPod(podRequest(t, nil), testNS)
+		assert.Check(t, resp.Allowed, "pod %d within threshold not allowed", i)
+	}
+	resp = ac.processPod(podRequest(t, nil), testNS)
+	assert.Check(t, !resp.Allowed, "duplicate pod allowed")
+	assert.Equal(t, resp.Result.Message, "Job job-uid-1 created 3 identical pods within 1m0s, back off for 1s")
+}
+
+func TestProcessPodMirrorPod(t *testing.T) {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "kube-apiserver-node1",
+			Namespace:   testNS,
+			UID:         "7f5fd6c5d5f1",
+			Annotations: map[string]string{v1.MirrorPodAnnotationKey: "hash"},
+		},
+		Spec: v1.PodSpec{NodeName: "node1"},
+	}
+	podJSON, err := json.Marshal(pod)
+	assert.NilError(t, err, "failed to marshal pod")
+	req := &admissionv1.AdmissionRequest{
+		UID:       "7f5fd6c5d5f1",
+		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		Namespace: testNS,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: podJSON},
+	}
+	ac := createAdmissionControllerForTest()
+	resp := ac.processPod(req, testNS)
+	assert.Check(t, resp.Allowed, "mirror pod not allowed")
+	assert.Check(t, resp.Patch == nil, "mirror pod was mutated")
+}
+
 func createNamespaceClassCacheForTest() *NamespaceCache {
 	return &NamespaceCache{
 		nameSpaces: make(map[string]nsFlags),
+		nsLabels:   make(map[string]k8slabels.Set),
+		gangLimits: make(map[string]nsGangLimits),
 	}
 }
 
 func createAdmissionControllerForTest() *AdmissionController {
 	pcCache := createPriorityClassCacheForTest()
 	nsCache := createNamespaceClassCacheForTest()
-	return InitAdmissionController(createConfig(), pcCache, nsCache)
+	return InitAdmissionController(createConfig(), pcCache, nsCache, nil, nil, nil)
 }
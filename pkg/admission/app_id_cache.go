@@ -0,0 +1,144 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/client-go/informers"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+// AppIDCache tracks the namespaces in which an applicationId is used by a pod.
+// Only the metadata of the pods is retrieved from the API server.
+type AppIDCache struct {
+	// applicationId of each pod, keyed by namespace and name
+	pods map[string]string
+	// number of pods per namespace for each applicationId
+	apps map[string]map[string]int
+
+	sync.RWMutex
+}
+
+// NewAppIDCache creates a new cache and registers the handler for the cache with the Informer.
+// The informer is nil if the collision check is not configured, the cache is always empty in that case.
+func NewAppIDCache(pods informers.GenericInformer) *AppIDCache {
+	ac := &AppIDCache{
+		pods: make(map[string]string),
+		apps: make(map[string]map[string]int),
+	}
+	if pods != nil {
+		pods.Informer().AddEventHandler(&appIDUpdateHandler{cache: ac})
+	}
+	return ac
+}
+
+// getOtherNamespaces returns the namespaces, other than the given namespace, that have pods using the applicationId.
+func (ac *AppIDCache) getOtherNamespaces(appID, namespace string) []string {
+	ac.RLock()
+	defer ac.RUnlock()
+	var result []string
+	for ns := range ac.apps[appID] {
+		if ns != namespace {
+			result = append(result, ns)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (ac *AppIDCache) add(key, namespace, appID string) {
+	ac.Lock()
+	defer ac.Unlock()
+	if current, ok := ac.pods[key]; ok {
+		if current == appID {
+			return
+		}
+		ac.removeInternal(key, namespace, current)
+	}
+	if appID == "" {
+		return
+	}
+	ac.pods[key] = appID
+	if ac.apps[appID] == nil {
+		ac.apps[appID] = make(map[string]int)
+	}
+	ac.apps[appID][namespace]++
+}
+
+func (ac *AppIDCache) remove(key, namespace string) {
+	ac.Lock()
+	defer ac.Unlock()
+	if current, ok := ac.pods[key]; ok {
+		ac.removeInternal(key, namespace, current)
+	}
+}
+
+func (ac *AppIDCache) removeInternal(key, namespace, appID string) {
+	delete(ac.pods, key)
+	ac.apps[appID][namespace]--
+	if ac.apps[appID][namespace] <= 0 {
+		delete(ac.apps[appID], namespace)
+	}
+	if len(ac.apps[appID]) == 0 {
+		delete(ac.apps, appID)
+	}
+}
+
+// getAppID returns the applicationId set by the user in the annotations or labels of a pod.
+// The scheduler name is not checked: it is only set by the admission controller after the check.
+func getAppID(labels, annotations map[string]string) string {
+	if value := annotations[constants.AnnotationApplicationID]; value != "" {
+		return value
+	}
+	if value := labels[constants.LabelApplicationID]; value != "" {
+		return value
+	}
+	return labels[constants.SparkLabelAppID]
+}
+
+// appIDUpdateHandler implements the K8s ResourceEventHandler interface for the pods.
+type appIDUpdateHandler struct {
+	cache *AppIDCache
+}
+
+// OnAdd adds or replaces the applicationId of the pod in the cache.
+func (h *appIDUpdateHandler) OnAdd(obj interface{}, _ bool) {
+	meta := convert2ObjectMeta(obj)
+	if meta == nil {
+		return
+	}
+	h.cache.add(meta.Namespace+"/"+meta.Name, meta.Namespace, getAppID(meta.Labels, meta.Annotations))
+}
+
+// OnUpdate calls OnAdd for processing the cache update.
+func (h *appIDUpdateHandler) OnUpdate(_, newObj interface{}) {
+	h.OnAdd(newObj, false)
+}
+
+// OnDelete removes the pod from the cache.
+func (h *appIDUpdateHandler) OnDelete(obj interface{}) {
+	meta := convert2ObjectMeta(obj)
+	if meta == nil {
+		return
+	}
+	h.cache.remove(meta.Namespace+"/"+meta.Name, meta.Namespace)
+}
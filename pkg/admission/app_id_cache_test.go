@@ -0,0 +1,74 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+func createPodMetaForTest(namespace, name string, labels, annotations map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: kindPod},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestAppIDHandlers(t *testing.T) {
+	ac := NewAppIDCache(nil)
+	handler := &appIDUpdateHandler{cache: ac}
+
+	// validate OnAdd
+	handler.OnAdd(createPodMetaForTest("ns-1", "pod-1", map[string]string{constants.LabelApplicationID: "app-1"}, nil), false)
+	handler.OnAdd(createPodMetaForTest("ns-1", "pod-2", nil, map[string]string{constants.AnnotationApplicationID: "app-1"}), false)
+	handler.OnAdd(createPodMetaForTest("ns-2", "pod-3", map[string]string{constants.SparkLabelAppID: "app-1"}, nil), false)
+	handler.OnAdd(createPodMetaForTest("ns-2", "pod-4", nil, nil), false)
+	assert.DeepEqual(t, ac.getOtherNamespaces("app-1", "ns-1"), []string{"ns-2"})
+	assert.DeepEqual(t, ac.getOtherNamespaces("app-1", "ns-3"), []string{"ns-1", "ns-2"})
+	assert.Equal(t, len(ac.pods), 3)
+
+	// validate OnUpdate, the applicationId of the pod changes
+	handler.OnUpdate(nil, createPodMetaForTest("ns-2", "pod-3", map[string]string{constants.LabelApplicationID: "app-2"}, nil))
+	assert.Equal(t, len(ac.getOtherNamespaces("app-1", "ns-1")), 0)
+	assert.DeepEqual(t, ac.getOtherNamespaces("app-2", "ns-1"), []string{"ns-2"})
+
+	// validate OnDelete, the namespace is tracked until the last pod is removed
+	handler.OnDelete(createPodMetaForTest("ns-1", "pod-1", nil, nil))
+	assert.DeepEqual(t, ac.getOtherNamespaces("app-1", "ns-2"), []string{"ns-1"})
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Obj: createPodMetaForTest("ns-1", "pod-2", nil, nil)})
+	assert.Equal(t, len(ac.getOtherNamespaces("app-1", "ns-2")), 0)
+	handler.OnDelete(createPodMetaForTest("ns-2", "pod-3", nil, nil))
+	assert.Equal(t, len(ac.pods), 0)
+	assert.Equal(t, len(ac.apps), 0)
+
+	// objects of the wrong type are ignored
+	handler.OnAdd("not an object", false)
+	handler.OnDelete(nil)
+	assert.Equal(t, len(ac.pods), 0)
+}
@@ -0,0 +1,98 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+const burstLimitWindow = time.Minute
+
+// BurstLimiter limits the number of pods a user, or the members of a group, can submit per minute.
+// It protects the scheduler from a runaway submission loop of a single user. The submissions are
+// tracked in memory as a sliding window per user and group that has a limit configured.
+type BurstLimiter struct {
+	conf        *conf.AdmissionControllerConf
+	submissions map[string][]time.Time
+
+	sync.Mutex
+}
+
+type burstLimit struct {
+	key   string
+	limit int
+}
+
+// NewBurstLimiter creates a new burst limiter without any submissions recorded.
+func NewBurstLimiter(conf *conf.AdmissionControllerConf) *BurstLimiter {
+	return &BurstLimiter{
+		conf:        conf,
+		submissions: make(map[string][]time.Time),
+	}
+}
+
+// admit records the submission of a pod at the given time if none of the burst limits of the user and its groups
+// has been reached within the last minute. If the pod is not admitted the exceeded limit is returned as an error.
+func (bl *BurstLimiter) admit(userName string, groups []string, now time.Time) error {
+	limits := make([]burstLimit, 0)
+	if limit, ok := bl.conf.GetUserBurstLimit(userName); ok {
+		limits = append(limits, burstLimit{key: "user " + userName, limit: limit})
+	}
+	for _, group := range groups {
+		if limit, ok := bl.conf.GetGroupBurstLimit(group); ok {
+			limits = append(limits, burstLimit{key: "group " + group, limit: limit})
+		}
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	bl.Lock()
+	defer bl.Unlock()
+	for _, l := range limits {
+		if len(bl.recentSubmissions(l.key, now)) >= l.limit {
+			return fmt.Errorf("%s exceeded the burst limit of %d pods per minute", l.key, l.limit)
+		}
+	}
+	for _, l := range limits {
+		bl.submissions[l.key] = append(bl.submissions[l.key], now)
+	}
+	return nil
+}
+
+// recentSubmissions drops the submissions that are outside the window and returns the remaining ones.
+// The caller must hold the lock.
+func (bl *BurstLimiter) recentSubmissions(key string, now time.Time) []time.Time {
+	submissions := bl.submissions[key]
+	cutoff := now.Add(-burstLimitWindow)
+	i := 0
+	for i < len(submissions) && !submissions[i].After(cutoff) {
+		i++
+	}
+	if i == len(submissions) {
+		delete(bl.submissions, key)
+		return nil
+	}
+	bl.submissions[key] = submissions[i:]
+	return submissions[i:]
+}
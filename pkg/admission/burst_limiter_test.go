@@ -0,0 +1,69 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+func TestBurstLimiterAdmit(t *testing.T) {
+	limiter := NewBurstLimiter(createConfigWithOverrides(map[string]string{
+		conf.AMBurstLimitUsers:  `{"alice": 2, "*": 3}`,
+		conf.AMBurstLimitGroups: `{"dev": 4}`,
+	}))
+	start := time.Now()
+
+	// user limit
+	assert.NilError(t, limiter.admit("alice", nil, start))
+	assert.NilError(t, limiter.admit("alice", nil, start.Add(10*time.Second)))
+	err := limiter.admit("alice", nil, start.Add(20*time.Second))
+	assert.ErrorContains(t, err, "user alice exceeded the burst limit of 2 pods per minute")
+	// the oldest submission leaves the window
+	assert.NilError(t, limiter.admit("alice", nil, start.Add(61*time.Second)))
+
+	// wildcard limit is tracked per user
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, limiter.admit("bob", nil, start))
+		assert.NilError(t, limiter.admit("carol", nil, start))
+	}
+	assert.ErrorContains(t, limiter.admit("bob", nil, start), "user bob exceeded")
+
+	// group limit is shared by the members, rejected pods are not counted
+	limiter = NewBurstLimiter(createConfigWithOverrides(map[string]string{
+		conf.AMBurstLimitUsers:  `{"alice": 2}`,
+		conf.AMBurstLimitGroups: `{"dev": 3}`,
+	}))
+	assert.NilError(t, limiter.admit("alice", []string{"dev"}, start))
+	assert.NilError(t, limiter.admit("alice", []string{"dev"}, start))
+	assert.ErrorContains(t, limiter.admit("alice", []string{"dev"}, start), "user alice exceeded")
+	assert.NilError(t, limiter.admit("bob", []string{"dev"}, start))
+	assert.ErrorContains(t, limiter.admit("bob", []string{"dev", "ops"}, start), "group dev exceeded the burst limit of 3 pods per minute")
+
+	// no limits configured
+	limiter = NewBurstLimiter(createConfigWithOverrides(nil))
+	for i := 0; i < 10; i++ {
+		assert.NilError(t, limiter.admit("alice", []string{"dev"}, start))
+	}
+	assert.Equal(t, len(limiter.submissions), 0)
+}
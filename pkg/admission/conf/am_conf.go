@@ -19,14 +19,17 @@
 package conf
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	informersv1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -41,18 +44,37 @@ const (
 	WebHookPrefix             = AdmissionControllerPrefix + "webHook."
 	FilteringPrefix           = AdmissionControllerPrefix + "filtering."
 	AccessControlPrefix       = AdmissionControllerPrefix + "accessControl."
+	QueueValidationPrefix     = AdmissionControllerPrefix + "queueValidation."
+	ResourceDefaultsPrefix    = AdmissionControllerPrefix + "resourceDefaults."
+	PriorityClassPrefix       = AdmissionControllerPrefix + "priorityClass."
+	PodGroupPrefix            = AdmissionControllerPrefix + "podGroup."
+	PlacementPrefix           = AdmissionControllerPrefix + "placement."
+	BurstLimitPrefix          = AdmissionControllerPrefix + "burstLimit."
+	LeaderElectionPrefix      = AdmissionControllerPrefix + "leaderElection."
+	TaskGroupPrefix           = AdmissionControllerPrefix + "taskGroups."
+	DuplicatePodPrefix        = AdmissionControllerPrefix + "duplicatePods."
+	UserGroupsPrefix          = AdmissionControllerPrefix + "userGroups."
+	KubernetesPrefix          = AdmissionControllerPrefix + "kubernetes."
 
 	// webhook configuration
 	AMWebHookAMServiceName           = WebHookPrefix + "amServiceName"
 	AMWebHookSchedulerServiceAddress = WebHookPrefix + "schedulerServiceAddress"
+	AMWebHookFailurePolicy           = WebHookPrefix + "failurePolicy"
+	AMWebHookMinReadyReplicas        = WebHookPrefix + "minReadyReplicas"
+	AMWebHookHealthyChecks           = WebHookPrefix + "healthyChecks"
+	AMWebHookUnhealthyChecks         = WebHookPrefix + "unhealthyChecks"
 
 	// filtering configuration
-	AMFilteringProcessNamespaces    = FilteringPrefix + "processNamespaces"
-	AMFilteringBypassNamespaces     = FilteringPrefix + "bypassNamespaces"
-	AMFilteringLabelNamespaces      = FilteringPrefix + "labelNamespaces"
-	AMFilteringNoLabelNamespaces    = FilteringPrefix + "noLabelNamespaces"
-	AMFilteringGenerateUniqueAppIds = FilteringPrefix + "generateUniqueAppId"
-	AMFilteringDefaultQueueName     = FilteringPrefix + "defaultQueue"
+	AMFilteringProcessNamespaces       = FilteringPrefix + "processNamespaces"
+	AMFilteringBypassNamespaces        = FilteringPrefix + "bypassNamespaces"
+	AMFilteringBypassNamespaceSelector = FilteringPrefix + "bypassNamespaceSelector"
+	AMFilteringLabelNamespaces         = FilteringPrefix + "labelNamespaces"
+	AMFilteringNoLabelNamespaces       = FilteringPrefix + "noLabelNamespaces"
+	AMFilteringProcessOwnerKinds       = FilteringPrefix + "processOwnerKinds"
+	AMFilteringBypassOwnerKinds        = FilteringPrefix + "bypassOwnerKinds"
+	AMFilteringGenerateUniqueAppIds    = FilteringPrefix + "generateUniqueAppId"
+	AMFilteringDefaultQueueName        = FilteringPrefix + "defaultQueue"
+	AMFilteringRejectAppIdCollisions   = FilteringPrefix + "rejectAppIdCollisions"
 
 	// access control configuration
 	AMAccessControlBypassAuth       = AccessControlPrefix + "bypassAuth"
@@ -60,20 +82,71 @@ const (
 	AMAccessControlSystemUsers      = AccessControlPrefix + "systemUsers"
 	AMAccessControlExternalUsers    = AccessControlPrefix + "externalUsers"
 	AMAccessControlExternalGroups   = AccessControlPrefix + "externalGroups"
+
+	// queue validation configuration
+	AMQueueValidationRejectInactiveQueues = QueueValidationPrefix + "rejectInactiveQueues"
+	AMQueueValidationRejectUnknownQueues  = QueueValidationPrefix + "rejectUnknownQueues"
+
+	// resource defaults configuration
+	AMResourceDefaultsQueues     = ResourceDefaultsPrefix + "queues"
+	AMResourceDefaultsNamespaces = ResourceDefaultsPrefix + "namespaces"
+
+	// priority class configuration
+	AMPriorityClassQueues = PriorityClassPrefix + "queues"
+
+	// pod group configuration
+	AMPodGroupEnable = PodGroupPrefix + "enable"
+
+	// placement configuration
+	AMPlacementQueues = PlacementPrefix + "queues"
+
+	// burst limit configuration
+	AMBurstLimitUsers  = BurstLimitPrefix + "users"
+	AMBurstLimitGroups = BurstLimitPrefix + "groups"
+
+	// leader election configuration
+	AMLeaderElectionEnable = LeaderElectionPrefix + "enable"
+
+	// task group configuration
+	AMTaskGroupNodeCapacityCheck = TaskGroupPrefix + "nodeCapacityCheck"
+
+	// duplicate pod configuration
+	AMDuplicatePodThreshold = DuplicatePodPrefix + "threshold"
+	AMDuplicatePodReject    = DuplicatePodPrefix + "reject"
+
+	// user groups configuration
+	AMUserGroupsProvider        = UserGroupsPrefix + "provider"
+	AMUserGroupsStatic          = UserGroupsPrefix + "static"
+	AMUserGroupsConfigMapName   = UserGroupsPrefix + "configMapName"
+	AMUserGroupsURL             = UserGroupsPrefix + "url"
+	AMUserGroupsCacheTTLSeconds = UserGroupsPrefix + "cacheTTLSeconds"
+
+	// kubernetes client configuration
+	AMKubeQPS               = KubernetesPrefix + "qps"
+	AMKubeBurst             = KubernetesPrefix + "burst"
+	AMKubeAdaptiveRateLimit = KubernetesPrefix + "adaptiveRateLimit"
 )
 
 const (
 	// webhook defaults
 	DefaultWebHookAmServiceName           = "yunikorn-admission-controller-service"
 	DefaultWebHookSchedulerServiceAddress = "yunikorn-service:9080"
+	DefaultWebHookFailurePolicy           = FailurePolicyIgnore
+	DefaultWebHookMinReadyReplicas        = 1
+	DefaultWebHookHealthyChecks           = 3
+	DefaultWebHookUnhealthyChecks         = 1
 
 	// filtering defaults
-	DefaultFilteringProcessNamespaces    = ""
-	DefaultFilteringBypassNamespaces     = "^kube-system$"
-	DefaultFilteringLabelNamespaces      = ""
-	DefaultFilteringNoLabelNamespaces    = ""
-	DefaultFilteringGenerateUniqueAppIds = false
-	DefaultFilteringQueueName            = "root.default"
+	DefaultFilteringProcessNamespaces       = ""
+	DefaultFilteringBypassNamespaces        = "^kube-system$"
+	DefaultFilteringBypassNamespaceSelector = ""
+	DefaultFilteringLabelNamespaces         = ""
+	DefaultFilteringNoLabelNamespaces       = ""
+	DefaultFilteringProcessOwnerKinds       = ""
+	DefaultFilteringBypassOwnerKinds        = ""
+	DefaultFilteringGenerateUniqueAppIds    = false
+	DefaultFilteringQueueName               = "root.default"
+	DefaultFilteringRejectAppIdCollisions   = true
 
 	// access control defaults
 	DefaultAccessControlBypassAuth       = false
@@ -81,8 +154,71 @@ const (
 	DefaultAccessControlSystemUsers      = "^system:serviceaccount:kube-system:"
 	DefaultAccessControlExternalUsers    = ""
 	DefaultAccessControlExternalGroups   = ""
+
+	// queue validation defaults
+	DefaultQueueValidationRejectInactiveQueues = false
+	DefaultQueueValidationRejectUnknownQueues  = false
+
+	// resource defaults
+	DefaultResourceDefaultsQueues     = ""
+	DefaultResourceDefaultsNamespaces = ""
+
+	// priority class defaults
+	DefaultPriorityClassQueues = ""
+
+	// pod group defaults
+	DefaultPodGroupEnable = false
+
+	// placement defaults
+	DefaultPlacementQueues = ""
+
+	// burst limit defaults
+	DefaultBurstLimitUsers  = ""
+	DefaultBurstLimitGroups = ""
+
+	// leader election defaults
+	DefaultLeaderElectionEnable = false
+
+	// task group defaults
+	DefaultTaskGroupNodeCapacityCheck = false
+
+	// duplicate pod defaults
+	DefaultDuplicatePodThreshold = 0
+	DefaultDuplicatePodReject    = false
+
+	// user groups defaults
+	DefaultUserGroupsProvider        = ""
+	DefaultUserGroupsStatic          = ""
+	DefaultUserGroupsConfigMapName   = ""
+	DefaultUserGroupsURL             = ""
+	DefaultUserGroupsCacheTTLSeconds = 60
+
+	// kubernetes client defaults
+	DefaultKubeQPS               = schedulerconf.DefaultKubeQPS
+	DefaultKubeBurst             = schedulerconf.DefaultKubeBurst
+	DefaultKubeAdaptiveRateLimit = false
+
+	// BurstLimitWildcard configures the burst limit of each user without a limit of its own
+	BurstLimitWildcard = "*"
+
+	// providers resolving the groups of a user
+	UserGroupsProviderStatic    = "static"
+	UserGroupsProviderConfigMap = "configMap"
+	UserGroupsProviderHTTP      = "http"
+
+	// failure policies of the mutating webhook, Auto switches between Fail and Ignore based on the replica health
+	FailurePolicyIgnore = "Ignore"
+	FailurePolicyFail   = "Fail"
+	FailurePolicyAuto   = "Auto"
 )
 
+// QueuePlacement contains the tolerations and the node selector added to the pods of a queue,
+// e.g. to place the pods on the node pool dedicated to the queue.
+type QueuePlacement struct {
+	Tolerations  []v1.Toleration   `json:"tolerations,omitempty"`
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
 type AdmissionControllerConf struct {
 	namespace  string
 	kubeConfig string
@@ -92,17 +228,46 @@ type AdmissionControllerConf struct {
 	policyGroup             string
 	amServiceName           string
 	schedulerServiceAddress string
+	failurePolicy           string
+	minReadyReplicas        int
+	healthyChecks           int
+	unhealthyChecks         int
 	processNamespaces       []*regexp.Regexp
 	bypassNamespaces        []*regexp.Regexp
+	bypassNamespaceSelector labels.Selector
 	labelNamespaces         []*regexp.Regexp
 	noLabelNamespaces       []*regexp.Regexp
+	processOwnerKinds       []*regexp.Regexp
+	bypassOwnerKinds        []*regexp.Regexp
 	generateUniqueAppIds    bool
+	rejectAppIDCollisions   bool
 	bypassAuth              bool
 	trustControllers        bool
 	systemUsers             []*regexp.Regexp
 	externalUsers           []*regexp.Regexp
 	externalGroups          []*regexp.Regexp
 	defaultQueueName        string
+	rejectInactiveQueues    bool
+	rejectUnknownQueues     bool
+	queueResourceDefaults   map[string]v1.ResourceRequirements
+	nsResourceDefaults      map[string]v1.ResourceRequirements
+	queuePriorityClasses    map[string]string
+	podGroupEnable          bool
+	queuePlacements         map[string]QueuePlacement
+	userBurstLimits         map[string]int
+	groupBurstLimits        map[string]int
+	leaderElectionEnable    bool
+	nodeCapacityCheck       bool
+	duplicatePodThreshold   int
+	duplicatePodReject      bool
+	userGroupsProvider      string
+	staticUserGroups        map[string][]string
+	userGroupsConfigMapName string
+	userGroupsURL           string
+	userGroupsCacheTTL      time.Duration
+	kubeQPS                 int
+	kubeBurst               int
+	kubeAdaptiveRateLimit   bool
 	configMaps              []*v1.ConfigMap
 
 	lock sync.RWMutex
@@ -157,6 +322,34 @@ func (acc *AdmissionControllerConf) GetSchedulerServiceAddress() string {
 	return acc.schedulerServiceAddress
 }
 
+// GetWebHookFailurePolicy returns the failure policy mode of the mutating webhook: Ignore, Fail or Auto
+func (acc *AdmissionControllerConf) GetWebHookFailurePolicy() string {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.failurePolicy
+}
+
+// GetWebHookMinReadyReplicas returns the number of ready replicas required to consider the webhook healthy
+func (acc *AdmissionControllerConf) GetWebHookMinReadyReplicas() int {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.minReadyReplicas
+}
+
+// GetWebHookHealthyChecks returns the number of consecutive healthy checks before the Auto policy switches to Fail
+func (acc *AdmissionControllerConf) GetWebHookHealthyChecks() int {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.healthyChecks
+}
+
+// GetWebHookUnhealthyChecks returns the number of consecutive unhealthy checks before the Auto policy switches to Ignore
+func (acc *AdmissionControllerConf) GetWebHookUnhealthyChecks() int {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.unhealthyChecks
+}
+
 func (acc *AdmissionControllerConf) GetProcessNamespaces() []*regexp.Regexp {
 	acc.lock.RLock()
 	defer acc.lock.RUnlock()
@@ -169,6 +362,13 @@ func (acc *AdmissionControllerConf) GetBypassNamespaces() []*regexp.Regexp {
 	return acc.bypassNamespaces
 }
 
+// GetBypassNamespaceSelector returns the label selector for namespaces that are bypassed, nil if not set.
+func (acc *AdmissionControllerConf) GetBypassNamespaceSelector() labels.Selector {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.bypassNamespaceSelector
+}
+
 func (acc *AdmissionControllerConf) GetLabelNamespaces() []*regexp.Regexp {
 	acc.lock.RLock()
 	defer acc.lock.RUnlock()
@@ -181,12 +381,32 @@ func (acc *AdmissionControllerConf) GetNoLabelNamespaces() []*regexp.Regexp {
 	return acc.noLabelNamespaces
 }
 
+func (acc *AdmissionControllerConf) GetProcessOwnerKinds() []*regexp.Regexp {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.processOwnerKinds
+}
+
+func (acc *AdmissionControllerConf) GetBypassOwnerKinds() []*regexp.Regexp {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.bypassOwnerKinds
+}
+
 func (acc *AdmissionControllerConf) GetGenerateUniqueAppIds() bool {
 	acc.lock.RLock()
 	defer acc.lock.RUnlock()
 	return acc.generateUniqueAppIds
 }
 
+// GetRejectAppIDCollisions returns true if pods using an applicationId that is in use in another namespace are rejected.
+// The pod informer is only created on startup, changing the value requires a restart.
+func (acc *AdmissionControllerConf) GetRejectAppIDCollisions() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.rejectAppIDCollisions
+}
+
 func (acc *AdmissionControllerConf) GetBypassAuth() bool {
 	acc.lock.RLock()
 	defer acc.lock.RUnlock()
@@ -223,6 +443,172 @@ func (acc *AdmissionControllerConf) GetDefaultQueueName() string {
 	return acc.defaultQueueName
 }
 
+func (acc *AdmissionControllerConf) GetRejectInactiveQueues() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.rejectInactiveQueues
+}
+
+func (acc *AdmissionControllerConf) GetRejectUnknownQueues() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.rejectUnknownQueues
+}
+
+// GetPodGroupEnable returns true if pods that are members of a coscheduling PodGroup get task groups generated.
+// The PodGroup informer is only created on startup, changing the value requires a restart.
+func (acc *AdmissionControllerConf) GetPodGroupEnable() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.podGroupEnable
+}
+
+// GetLeaderElectionEnable returns true if the replicas elect a leader that manages the CA certificates and webhooks.
+// Leader election is only started on startup, changing the value requires a restart.
+func (acc *AdmissionControllerConf) GetLeaderElectionEnable() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.leaderElectionEnable
+}
+
+// GetNodeCapacityCheck returns true if pods with a task group that does not fit on any node are rejected.
+// The node informer is only created on startup, changing the value requires a restart.
+func (acc *AdmissionControllerConf) GetNodeCapacityCheck() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.nodeCapacityCheck
+}
+
+// GetQueueResourceDefaults returns the default container resources for the queue, the lookup is case-insensitive.
+// The second return value is false if no defaults are configured for the queue.
+func (acc *AdmissionControllerConf) GetQueueResourceDefaults(queueName string) (v1.ResourceRequirements, bool) {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	defaults, ok := acc.queueResourceDefaults[strings.ToLower(queueName)]
+	return defaults, ok
+}
+
+// GetNamespaceResourceDefaults returns the default container resources for the namespace.
+// The second return value is false if no defaults are configured for the namespace.
+func (acc *AdmissionControllerConf) GetNamespaceResourceDefaults(namespace string) (v1.ResourceRequirements, bool) {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	defaults, ok := acc.nsResourceDefaults[namespace]
+	return defaults, ok
+}
+
+// GetQueuePriorityClass returns the name of the PriorityClass for pods in the queue, the lookup is case-insensitive.
+// The second return value is false if no PriorityClass is configured for the queue.
+func (acc *AdmissionControllerConf) GetQueuePriorityClass(queueName string) (string, bool) {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	priorityClass, ok := acc.queuePriorityClasses[strings.ToLower(queueName)]
+	return priorityClass, ok
+}
+
+// GetQueuePlacement returns the tolerations and node selector for the pods of the queue, the lookup is case-insensitive.
+// The second return value is false if no placement is configured for the queue.
+func (acc *AdmissionControllerConf) GetQueuePlacement(queueName string) (QueuePlacement, bool) {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	placement, ok := acc.queuePlacements[strings.ToLower(queueName)]
+	return placement, ok
+}
+
+// GetUserBurstLimit returns the number of pods the user may submit per minute. The limit configured for the
+// wildcard user applies to users without a limit of their own. The second return value is false if no limit applies.
+func (acc *AdmissionControllerConf) GetUserBurstLimit(userName string) (int, bool) {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	if limit, ok := acc.userBurstLimits[userName]; ok {
+		return limit, true
+	}
+	limit, ok := acc.userBurstLimits[BurstLimitWildcard]
+	return limit, ok
+}
+
+// GetGroupBurstLimit returns the number of pods the members of the group may submit together per minute.
+// The second return value is false if no limit is configured for the group.
+func (acc *AdmissionControllerConf) GetGroupBurstLimit(groupName string) (int, bool) {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	limit, ok := acc.groupBurstLimits[groupName]
+	return limit, ok
+}
+
+// GetDuplicatePodThreshold returns the number of identical pods a controller may create per minute before the pods
+// are reported as duplicates. A value of 0 disables the detection.
+func (acc *AdmissionControllerConf) GetDuplicatePodThreshold() int {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.duplicatePodThreshold
+}
+
+// GetDuplicatePodReject returns true if duplicate pods are rejected, instead of being annotated with a backoff.
+func (acc *AdmissionControllerConf) GetDuplicatePodReject() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.duplicatePodReject
+}
+
+// GetUserGroupsProvider returns the provider used to resolve the groups of a user, an empty string if the groups
+// of the authenticated user are used as is.
+func (acc *AdmissionControllerConf) GetUserGroupsProvider() string {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.userGroupsProvider
+}
+
+// GetStaticUserGroups returns the groups configured for the user by the static provider.
+func (acc *AdmissionControllerConf) GetStaticUserGroups(userName string) []string {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.staticUserGroups[userName]
+}
+
+// GetUserGroupsConfigMapName returns the name of the ConfigMap in the scheduler namespace with the groups per user.
+func (acc *AdmissionControllerConf) GetUserGroupsConfigMapName() string {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.userGroupsConfigMapName
+}
+
+// GetUserGroupsURL returns the endpoint queried for the groups of a user.
+func (acc *AdmissionControllerConf) GetUserGroupsURL() string {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.userGroupsURL
+}
+
+// GetKubeQPS returns the QPS of the client of the admission controller. The client is created at startup,
+// changes require a restart.
+func (acc *AdmissionControllerConf) GetKubeQPS() int {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.kubeQPS
+}
+
+// GetKubeBurst returns the burst of the client of the admission controller.
+func (acc *AdmissionControllerConf) GetKubeBurst() int {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.kubeBurst
+}
+
+// GetKubeAdaptiveRateLimit returns true if the client lowers its QPS while the API server throttles it.
+func (acc *AdmissionControllerConf) GetKubeAdaptiveRateLimit() bool {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.kubeAdaptiveRateLimit
+}
+
+// GetUserGroupsCacheTTL returns how long the groups retrieved from the endpoint are cached.
+func (acc *AdmissionControllerConf) GetUserGroupsCacheTTL() time.Duration {
+	acc.lock.RLock()
+	defer acc.lock.RUnlock()
+	return acc.userGroupsCacheTTL
+}
+
 type configMapUpdateHandler struct {
 	conf *AdmissionControllerConf
 }
@@ -311,13 +697,28 @@ func (acc *AdmissionControllerConf) updateConfigMaps(configMaps []*v1.ConfigMap,
 	// webhook
 	acc.amServiceName = parseConfigString(configs, AMWebHookAMServiceName, DefaultWebHookAmServiceName)
 	acc.schedulerServiceAddress = parseConfigString(configs, AMWebHookSchedulerServiceAddress, DefaultWebHookSchedulerServiceAddress)
+	acc.failurePolicy = parseConfigString(configs, AMWebHookFailurePolicy, DefaultWebHookFailurePolicy)
+	switch acc.failurePolicy {
+	case FailurePolicyIgnore, FailurePolicyFail, FailurePolicyAuto:
+	default:
+		log.Log(log.AdmissionConf).Error("Unknown webhook failure policy, using default",
+			zap.String("key", AMWebHookFailurePolicy), zap.String("value", acc.failurePolicy))
+		acc.failurePolicy = DefaultWebHookFailurePolicy
+	}
+	acc.minReadyReplicas = parseConfigInt(configs, AMWebHookMinReadyReplicas, DefaultWebHookMinReadyReplicas)
+	acc.healthyChecks = parseConfigInt(configs, AMWebHookHealthyChecks, DefaultWebHookHealthyChecks)
+	acc.unhealthyChecks = parseConfigInt(configs, AMWebHookUnhealthyChecks, DefaultWebHookUnhealthyChecks)
 
 	// filtering
 	acc.processNamespaces = parseConfigRegexps(configs, AMFilteringProcessNamespaces, DefaultFilteringProcessNamespaces)
 	acc.bypassNamespaces = parseConfigRegexps(configs, AMFilteringBypassNamespaces, DefaultFilteringBypassNamespaces)
+	acc.bypassNamespaceSelector = parseConfigLabelSelector(configs, AMFilteringBypassNamespaceSelector, DefaultFilteringBypassNamespaceSelector)
 	acc.labelNamespaces = parseConfigRegexps(configs, AMFilteringLabelNamespaces, DefaultFilteringLabelNamespaces)
 	acc.noLabelNamespaces = parseConfigRegexps(configs, AMFilteringNoLabelNamespaces, DefaultFilteringNoLabelNamespaces)
+	acc.processOwnerKinds = parseConfigRegexps(configs, AMFilteringProcessOwnerKinds, DefaultFilteringProcessOwnerKinds)
+	acc.bypassOwnerKinds = parseConfigRegexps(configs, AMFilteringBypassOwnerKinds, DefaultFilteringBypassOwnerKinds)
 	acc.generateUniqueAppIds = parseConfigBool(configs, AMFilteringGenerateUniqueAppIds, DefaultFilteringGenerateUniqueAppIds)
+	acc.rejectAppIDCollisions = parseConfigBool(configs, AMFilteringRejectAppIdCollisions, DefaultFilteringRejectAppIdCollisions)
 
 	// access control
 	acc.bypassAuth = parseConfigBool(configs, AMAccessControlBypassAuth, DefaultAccessControlBypassAuth)
@@ -329,6 +730,66 @@ func (acc *AdmissionControllerConf) updateConfigMaps(configMaps []*v1.ConfigMap,
 	// labeling
 	acc.defaultQueueName = parseConfigString(configs, AMFilteringDefaultQueueName, DefaultFilteringQueueName)
 
+	// queue validation
+	acc.rejectInactiveQueues = parseConfigBool(configs, AMQueueValidationRejectInactiveQueues, DefaultQueueValidationRejectInactiveQueues)
+	acc.rejectUnknownQueues = parseConfigBool(configs, AMQueueValidationRejectUnknownQueues, DefaultQueueValidationRejectUnknownQueues)
+
+	// resource defaults
+	acc.queueResourceDefaults = make(map[string]v1.ResourceRequirements)
+	for queueName, defaults := range parseConfigResourceDefaults(configs, AMResourceDefaultsQueues, DefaultResourceDefaultsQueues) {
+		// queue names are case-insensitive
+		acc.queueResourceDefaults[strings.ToLower(queueName)] = defaults
+	}
+	acc.nsResourceDefaults = parseConfigResourceDefaults(configs, AMResourceDefaultsNamespaces, DefaultResourceDefaultsNamespaces)
+
+	// priority classes
+	acc.queuePriorityClasses = make(map[string]string)
+	for queueName, priorityClass := range parseConfigStringMap(configs, AMPriorityClassQueues, DefaultPriorityClassQueues) {
+		acc.queuePriorityClasses[strings.ToLower(queueName)] = priorityClass
+	}
+
+	// placement
+	acc.queuePlacements = make(map[string]QueuePlacement)
+	for queueName, placement := range parseConfigQueuePlacements(configs, AMPlacementQueues, DefaultPlacementQueues) {
+		acc.queuePlacements[strings.ToLower(queueName)] = placement
+	}
+
+	// burst limits
+	acc.userBurstLimits = parseConfigBurstLimits(configs, AMBurstLimitUsers, DefaultBurstLimitUsers)
+	acc.groupBurstLimits = parseConfigBurstLimits(configs, AMBurstLimitGroups, DefaultBurstLimitGroups)
+
+	// duplicate pods
+	acc.duplicatePodThreshold = parseConfigInt(configs, AMDuplicatePodThreshold, DefaultDuplicatePodThreshold)
+	acc.duplicatePodReject = parseConfigBool(configs, AMDuplicatePodReject, DefaultDuplicatePodReject)
+
+	// user groups
+	acc.userGroupsProvider = parseConfigString(configs, AMUserGroupsProvider, DefaultUserGroupsProvider)
+	switch acc.userGroupsProvider {
+	case DefaultUserGroupsProvider, UserGroupsProviderStatic, UserGroupsProviderConfigMap, UserGroupsProviderHTTP:
+	default:
+		log.Log(log.AdmissionConf).Error("Unknown user groups provider, using default",
+			zap.String("key", AMUserGroupsProvider), zap.String("value", acc.userGroupsProvider))
+		acc.userGroupsProvider = DefaultUserGroupsProvider
+	}
+	acc.staticUserGroups = parseConfigUserGroups(configs, AMUserGroupsStatic, DefaultUserGroupsStatic)
+	acc.userGroupsConfigMapName = parseConfigString(configs, AMUserGroupsConfigMapName, DefaultUserGroupsConfigMapName)
+	acc.userGroupsURL = parseConfigString(configs, AMUserGroupsURL, DefaultUserGroupsURL)
+	acc.userGroupsCacheTTL = time.Duration(parseConfigInt(configs, AMUserGroupsCacheTTLSeconds, DefaultUserGroupsCacheTTLSeconds)) * time.Second
+
+	// kubernetes client
+	acc.kubeQPS = parseConfigInt(configs, AMKubeQPS, DefaultKubeQPS)
+	acc.kubeBurst = parseConfigInt(configs, AMKubeBurst, DefaultKubeBurst)
+	acc.kubeAdaptiveRateLimit = parseConfigBool(configs, AMKubeAdaptiveRateLimit, DefaultKubeAdaptiveRateLimit)
+
+	// pod groups
+	acc.podGroupEnable = parseConfigBool(configs, AMPodGroupEnable, DefaultPodGroupEnable)
+
+	// leader election
+	acc.leaderElectionEnable = parseConfigBool(configs, AMLeaderElectionEnable, DefaultLeaderElectionEnable)
+
+	// task groups
+	acc.nodeCapacityCheck = parseConfigBool(configs, AMTaskGroupNodeCapacityCheck, DefaultTaskGroupNodeCapacityCheck)
+
 	// logging
 	log.UpdateLoggingConfig(configs)
 
@@ -348,15 +809,44 @@ func (acc *AdmissionControllerConf) dumpConfigurationInternal() {
 		zap.String("policyGroup", acc.policyGroup),
 		zap.String("amServiceName", acc.amServiceName),
 		zap.String("schedulerServiceAddress", acc.schedulerServiceAddress),
+		zap.String("failurePolicy", acc.failurePolicy),
+		zap.Int("minReadyReplicas", acc.minReadyReplicas),
+		zap.Int("healthyChecks", acc.healthyChecks),
+		zap.Int("unhealthyChecks", acc.unhealthyChecks),
 		zap.Strings("processNamespaces", regexpsString(acc.processNamespaces)),
 		zap.Strings("bypassNamespaces", regexpsString(acc.bypassNamespaces)),
+		zap.Any("bypassNamespaceSelector", acc.bypassNamespaceSelector),
 		zap.Strings("labelNamespaces", regexpsString(acc.labelNamespaces)),
 		zap.Strings("noLabelNamespaces", regexpsString(acc.noLabelNamespaces)),
+		zap.Strings("processOwnerKinds", regexpsString(acc.processOwnerKinds)),
+		zap.Strings("bypassOwnerKinds", regexpsString(acc.bypassOwnerKinds)),
+		zap.Bool("rejectAppIdCollisions", acc.rejectAppIDCollisions),
 		zap.Bool("bypassAuth", acc.bypassAuth),
 		zap.Bool("trustControllers", acc.trustControllers),
 		zap.Strings("systemUsers", regexpsString(acc.systemUsers)),
 		zap.Strings("externalUsers", regexpsString(acc.externalUsers)),
-		zap.Strings("externalGroups", regexpsString(acc.externalGroups)))
+		zap.Strings("externalGroups", regexpsString(acc.externalGroups)),
+		zap.Bool("rejectInactiveQueues", acc.rejectInactiveQueues),
+		zap.Bool("rejectUnknownQueues", acc.rejectUnknownQueues),
+		zap.Any("queueResourceDefaults", acc.queueResourceDefaults),
+		zap.Any("namespaceResourceDefaults", acc.nsResourceDefaults),
+		zap.Any("queuePriorityClasses", acc.queuePriorityClasses),
+		zap.Bool("podGroupEnable", acc.podGroupEnable),
+		zap.Any("queuePlacements", acc.queuePlacements),
+		zap.Any("userBurstLimits", acc.userBurstLimits),
+		zap.Any("groupBurstLimits", acc.groupBurstLimits),
+		zap.Int("duplicatePodThreshold", acc.duplicatePodThreshold),
+		zap.Bool("duplicatePodReject", acc.duplicatePodReject),
+		zap.String("userGroupsProvider", acc.userGroupsProvider),
+		zap.Any("staticUserGroups", acc.staticUserGroups),
+		zap.String("userGroupsConfigMapName", acc.userGroupsConfigMapName),
+		zap.String("userGroupsURL", acc.userGroupsURL),
+		zap.Duration("userGroupsCacheTTL", acc.userGroupsCacheTTL),
+		zap.Int("kubeQPS", acc.kubeQPS),
+		zap.Int("kubeBurst", acc.kubeBurst),
+		zap.Bool("kubeAdaptiveRateLimit", acc.kubeAdaptiveRateLimit),
+		zap.Bool("leaderElectionEnable", acc.leaderElectionEnable),
+		zap.Bool("nodeCapacityCheck", acc.nodeCapacityCheck))
 }
 
 func regexpsString(regexes []*regexp.Regexp) []string {
@@ -381,6 +871,99 @@ func parseConfigRegexps(config map[string]string, key string, defaultValue strin
 	return result
 }
 
+// parseConfigResourceDefaults parses a JSON object that maps a name to the default requests and limits, e.g.
+// {"root.batch": {"requests": {"cpu": "100m", "memory": "128Mi"}, "limits": {"memory": "256Mi"}}}
+func parseConfigResourceDefaults(config map[string]string, key string, defaultValue string) map[string]v1.ResourceRequirements {
+	result := make(map[string]v1.ResourceRequirements)
+	value := parseConfigString(config, key, defaultValue)
+	if strings.TrimSpace(value) == "" {
+		return result
+	}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.Log(log.AdmissionConf).Error("Unable to parse resource defaults, ignoring setting",
+			zap.String("key", key), zap.String("value", value), zap.Error(err))
+		return make(map[string]v1.ResourceRequirements)
+	}
+	return result
+}
+
+// parseConfigQueuePlacements parses a JSON object with the placement per queue,
+// e.g. {"root.gpu": {"tolerations": [{"key": "gpu", "operator": "Exists"}], "nodeSelector": {"pool": "gpu"}}}
+func parseConfigQueuePlacements(config map[string]string, key string, defaultValue string) map[string]QueuePlacement {
+	result := make(map[string]QueuePlacement)
+	value := parseConfigString(config, key, defaultValue)
+	if strings.TrimSpace(value) == "" {
+		return result
+	}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.Log(log.AdmissionConf).Error("Unable to parse queue placements, ignoring setting",
+			zap.String("key", key), zap.String("value", value), zap.Error(err))
+		return make(map[string]QueuePlacement)
+	}
+	return result
+}
+
+// parseConfigBurstLimits parses a JSON object with the pods per minute per name, e.g. {"alice": 20, "*": 100}.
+// Limits that are not positive are ignored.
+func parseConfigBurstLimits(config map[string]string, key string, defaultValue string) map[string]int {
+	result := make(map[string]int)
+	value := parseConfigString(config, key, defaultValue)
+	if strings.TrimSpace(value) == "" {
+		return result
+	}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.Log(log.AdmissionConf).Error("Unable to parse burst limits, ignoring setting",
+			zap.String("key", key), zap.String("value", value), zap.Error(err))
+		return make(map[string]int)
+	}
+	for name, limit := range result {
+		if limit <= 0 {
+			log.Log(log.AdmissionConf).Warn("Ignoring burst limit that is not positive",
+				zap.String("key", key), zap.String("name", name), zap.Int("limit", limit))
+			delete(result, name)
+		}
+	}
+	return result
+}
+
+// ParseUserGroups parses a JSON object with the groups per user, e.g. {"alice": ["dev", "ml"]}
+func ParseUserGroups(value string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if strings.TrimSpace(value) == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseConfigUserGroups(config map[string]string, key string, defaultValue string) map[string][]string {
+	value := parseConfigString(config, key, defaultValue)
+	result, err := ParseUserGroups(value)
+	if err != nil {
+		log.Log(log.AdmissionConf).Error("Unable to parse user groups, ignoring setting",
+			zap.String("key", key), zap.String("value", value), zap.Error(err))
+		return make(map[string][]string)
+	}
+	return result
+}
+
+// parseConfigStringMap parses a JSON object with string values, e.g. {"root.batch": "low-priority"}
+func parseConfigStringMap(config map[string]string, key string, defaultValue string) map[string]string {
+	result := make(map[string]string)
+	value := parseConfigString(config, key, defaultValue)
+	if strings.TrimSpace(value) == "" {
+		return result
+	}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.Log(log.AdmissionConf).Error("Unable to parse map value, ignoring setting",
+			zap.String("key", key), zap.String("value", value), zap.Error(err))
+		return make(map[string]string)
+	}
+	return result
+}
+
 func parseConfigBool(config map[string]string, key string, defaultValue bool) bool {
 	value := parseConfigString(config, key, fmt.Sprintf("%t", defaultValue))
 	result, err := strconv.ParseBool(value)
@@ -403,6 +986,22 @@ func parseConfigInt(config map[string]string, key string, defaultValue int) int
 	return int(result)
 }
 
+// parseConfigLabelSelector parses a label selector, e.g. "yunikorn.apache.org/ignore=true".
+// An empty or invalid value returns nil, which does not select any object.
+func parseConfigLabelSelector(config map[string]string, key string, defaultValue string) labels.Selector {
+	value := strings.TrimSpace(parseConfigString(config, key, defaultValue))
+	if value == "" {
+		return nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		log.Log(log.AdmissionConf).Error("Unable to parse label selector, ignoring setting",
+			zap.String("key", key), zap.String("value", value), zap.Error(err))
+		return nil
+	}
+	return selector
+}
+
 func parseConfigString(config map[string]string, key string, defaultValue string) string {
 	if value, ok := config[key]; ok {
 		return value
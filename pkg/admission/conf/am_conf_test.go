@@ -20,6 +20,7 @@ package conf
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
@@ -30,35 +31,114 @@ import (
 func TestConfigMapVars(t *testing.T) {
 	// test valid settings
 	conf := NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: map[string]string{
-		schedulerconf.CMSvcPolicyGroup:   "testPolicyGroup",
-		AMWebHookAMServiceName:           "testYunikornService",
-		AMWebHookSchedulerServiceAddress: "testAddress",
-		AMFilteringProcessNamespaces:     "testProcessNamespaces",
-		AMFilteringBypassNamespaces:      "testBypassNamespaces",
-		AMFilteringLabelNamespaces:       "testLabelNamespaces",
-		AMFilteringNoLabelNamespaces:     "testNolabelNamespaces",
-		AMFilteringGenerateUniqueAppIds:  "true",
-		AMAccessControlBypassAuth:        "true",
-		AMAccessControlSystemUsers:       "^systemuser$",
-		AMAccessControlExternalUsers:     "^yunikorn$",
-		AMAccessControlExternalGroups:    "^devs$",
-		AMAccessControlTrustControllers:  "false",
-		AMFilteringDefaultQueueName:      "default.queue",
+		schedulerconf.CMSvcPolicyGroup:        "testPolicyGroup",
+		AMWebHookAMServiceName:                "testYunikornService",
+		AMWebHookSchedulerServiceAddress:      "testAddress",
+		AMFilteringProcessNamespaces:          "testProcessNamespaces",
+		AMFilteringBypassNamespaces:           "testBypassNamespaces",
+		AMFilteringBypassNamespaceSelector:    "yunikorn.apache.org/ignore=true",
+		AMFilteringLabelNamespaces:            "testLabelNamespaces",
+		AMFilteringNoLabelNamespaces:          "testNolabelNamespaces",
+		AMFilteringProcessOwnerKinds:          "^Job$",
+		AMFilteringBypassOwnerKinds:           "^DaemonSet$",
+		AMFilteringGenerateUniqueAppIds:       "true",
+		AMFilteringRejectAppIdCollisions:      "false",
+		AMAccessControlBypassAuth:             "true",
+		AMAccessControlSystemUsers:            "^systemuser$",
+		AMAccessControlExternalUsers:          "^yunikorn$",
+		AMAccessControlExternalGroups:         "^devs$",
+		AMAccessControlTrustControllers:       "false",
+		AMFilteringDefaultQueueName:           "default.queue",
+		AMQueueValidationRejectInactiveQueues: "true",
+		AMQueueValidationRejectUnknownQueues:  "true",
+		AMResourceDefaultsQueues:              `{"root.Test": {"requests": {"cpu": "100m"}}}`,
+		AMResourceDefaultsNamespaces:          `{"test": {"limits": {"memory": "1Gi"}}}`,
+		AMPriorityClassQueues:                 `{"root.Test": "high-priority"}`,
+		AMPodGroupEnable:                      "true",
+		AMPlacementQueues:                     `{"root.GPU": {"tolerations": [{"key": "gpu", "operator": "Exists"}], "nodeSelector": {"pool": "gpu"}}}`,
+		AMBurstLimitUsers:                     `{"alice": 10, "*": 100, "bob": 0}`,
+		AMBurstLimitGroups:                    `{"dev": 50}`,
+		AMLeaderElectionEnable:                "true",
+		AMTaskGroupNodeCapacityCheck:          "true",
+		AMDuplicatePodThreshold:               "20",
+		AMDuplicatePodReject:                  "true",
+		AMUserGroupsProvider:                  "static",
+		AMUserGroupsStatic:                    `{"alice": ["ml", "data"]}`,
+		AMUserGroupsConfigMapName:             "user-groups",
+		AMUserGroupsURL:                       "http://groups.example.com/groups",
+		AMUserGroupsCacheTTLSeconds:           "30",
+		AMKubeQPS:                             "200",
+		AMKubeBurst:                           "300",
+		AMKubeAdaptiveRateLimit:               "true",
+		AMWebHookFailurePolicy:                "Auto",
+		AMWebHookMinReadyReplicas:             "2",
+		AMWebHookHealthyChecks:                "5",
+		AMWebHookUnhealthyChecks:              "2",
 	}}})
 	assert.Equal(t, conf.GetPolicyGroup(), "testPolicyGroup")
 	assert.Equal(t, conf.GetAmServiceName(), "testYunikornService")
 	assert.Equal(t, conf.GetSchedulerServiceAddress(), "testAddress")
 	assert.Equal(t, conf.GetProcessNamespaces()[0].String(), "testProcessNamespaces")
 	assert.Equal(t, conf.GetBypassNamespaces()[0].String(), "testBypassNamespaces")
+	assert.Equal(t, conf.GetBypassNamespaceSelector().String(), "yunikorn.apache.org/ignore=true")
 	assert.Equal(t, conf.GetLabelNamespaces()[0].String(), "testLabelNamespaces")
 	assert.Equal(t, conf.GetNoLabelNamespaces()[0].String(), "testNolabelNamespaces")
+	assert.Equal(t, conf.GetProcessOwnerKinds()[0].String(), "^Job$")
+	assert.Equal(t, conf.GetBypassOwnerKinds()[0].String(), "^DaemonSet$")
 	assert.Equal(t, conf.GetGenerateUniqueAppIds(), true)
+	assert.Equal(t, conf.GetRejectAppIDCollisions(), false)
 	assert.Equal(t, conf.GetBypassAuth(), true)
 	assert.Equal(t, conf.GetSystemUsers()[0].String(), "^systemuser$")
 	assert.Equal(t, conf.GetExternalUsers()[0].String(), "^yunikorn$")
 	assert.Equal(t, conf.GetExternalGroups()[0].String(), "^devs$")
 	assert.Equal(t, conf.GetTrustControllers(), false)
 	assert.Equal(t, conf.GetDefaultQueueName(), "default.queue")
+	assert.Equal(t, conf.GetRejectInactiveQueues(), true)
+	assert.Equal(t, conf.GetRejectUnknownQueues(), true)
+	defaults, ok := conf.GetQueueResourceDefaults("ROOT.test")
+	assert.Assert(t, ok, "queue resource defaults not found")
+	assert.Equal(t, defaults.Requests.Cpu().String(), "100m")
+	defaults, ok = conf.GetNamespaceResourceDefaults("test")
+	assert.Assert(t, ok, "namespace resource defaults not found")
+	assert.Equal(t, defaults.Limits.Memory().String(), "1Gi")
+	priorityClass, ok := conf.GetQueuePriorityClass("root.test")
+	assert.Assert(t, ok, "queue priority class not found")
+	assert.Equal(t, priorityClass, "high-priority")
+	assert.Equal(t, conf.GetPodGroupEnable(), true)
+	assert.Equal(t, conf.GetLeaderElectionEnable(), true)
+	assert.Equal(t, conf.GetNodeCapacityCheck(), true)
+	assert.Equal(t, conf.GetDuplicatePodThreshold(), 20)
+	assert.Equal(t, conf.GetDuplicatePodReject(), true)
+	assert.Equal(t, conf.GetUserGroupsProvider(), UserGroupsProviderStatic)
+	assert.DeepEqual(t, conf.GetStaticUserGroups("alice"), []string{"ml", "data"})
+	assert.Equal(t, conf.GetUserGroupsConfigMapName(), "user-groups")
+	assert.Equal(t, conf.GetUserGroupsURL(), "http://groups.example.com/groups")
+	assert.Equal(t, conf.GetUserGroupsCacheTTL(), 30*time.Second)
+	assert.Equal(t, conf.GetKubeQPS(), 200)
+	assert.Equal(t, conf.GetKubeBurst(), 300)
+	assert.Equal(t, conf.GetKubeAdaptiveRateLimit(), true)
+	assert.Equal(t, conf.GetWebHookFailurePolicy(), FailurePolicyAuto)
+	assert.Equal(t, conf.GetWebHookMinReadyReplicas(), 2)
+	assert.Equal(t, conf.GetWebHookHealthyChecks(), 5)
+	assert.Equal(t, conf.GetWebHookUnhealthyChecks(), 2)
+	placement, ok := conf.GetQueuePlacement("root.gpu")
+	assert.Assert(t, ok, "queue placement not found")
+	assert.Equal(t, len(placement.Tolerations), 1)
+	assert.Equal(t, placement.Tolerations[0].Key, "gpu")
+	assert.Equal(t, placement.Tolerations[0].Operator, v1.TolerationOpExists)
+	assert.DeepEqual(t, placement.NodeSelector, map[string]string{"pool": "gpu"})
+	limit, ok := conf.GetUserBurstLimit("alice")
+	assert.Assert(t, ok, "user burst limit not found")
+	assert.Equal(t, limit, 10)
+	// non-positive limits are ignored, the wildcard applies
+	limit, ok = conf.GetUserBurstLimit("bob")
+	assert.Assert(t, ok, "wildcard user burst limit not found")
+	assert.Equal(t, limit, 100)
+	limit, ok = conf.GetGroupBurstLimit("dev")
+	assert.Assert(t, ok, "group burst limit not found")
+	assert.Equal(t, limit, 50)
+	_, ok = conf.GetGroupBurstLimit("ops")
+	assert.Assert(t, !ok, "unexpected group burst limit")
 
 	// test missing settings
 	conf = NewAdmissionControllerConf([]*v1.ConfigMap{nil, nil})
@@ -68,14 +148,46 @@ func TestConfigMapVars(t *testing.T) {
 	assert.Equal(t, conf.GetSchedulerServiceAddress(), DefaultWebHookSchedulerServiceAddress)
 	assert.Equal(t, 0, len(conf.GetProcessNamespaces()))
 	assert.Equal(t, conf.GetBypassNamespaces()[0].String(), DefaultFilteringBypassNamespaces)
+	assert.Assert(t, conf.GetBypassNamespaceSelector() == nil, "unexpected bypass namespace selector")
 	assert.Equal(t, 0, len(conf.GetLabelNamespaces()))
 	assert.Equal(t, 0, len(conf.GetNoLabelNamespaces()))
+	assert.Equal(t, 0, len(conf.GetProcessOwnerKinds()))
+	assert.Equal(t, 0, len(conf.GetBypassOwnerKinds()))
 	assert.Equal(t, conf.GetBypassAuth(), DefaultAccessControlBypassAuth)
 	assert.Equal(t, conf.GetSystemUsers()[0].String(), DefaultAccessControlSystemUsers)
 	assert.Equal(t, 0, len(conf.GetExternalUsers()))
 	assert.Equal(t, 0, len(conf.GetExternalGroups()))
 	assert.Equal(t, conf.GetTrustControllers(), DefaultAccessControlTrustControllers)
 	assert.Equal(t, conf.GetDefaultQueueName(), DefaultFilteringQueueName)
+	assert.Equal(t, conf.GetRejectInactiveQueues(), DefaultQueueValidationRejectInactiveQueues)
+	assert.Equal(t, conf.GetRejectUnknownQueues(), DefaultQueueValidationRejectUnknownQueues)
+	_, ok = conf.GetQueueResourceDefaults("root.default")
+	assert.Assert(t, !ok, "unexpected queue resource defaults")
+	_, ok = conf.GetNamespaceResourceDefaults("default")
+	assert.Assert(t, !ok, "unexpected namespace resource defaults")
+	_, ok = conf.GetQueuePriorityClass("root.default")
+	assert.Assert(t, !ok, "unexpected queue priority class")
+	assert.Equal(t, conf.GetPodGroupEnable(), DefaultPodGroupEnable)
+	assert.Equal(t, conf.GetLeaderElectionEnable(), DefaultLeaderElectionEnable)
+	assert.Equal(t, conf.GetNodeCapacityCheck(), DefaultTaskGroupNodeCapacityCheck)
+	assert.Equal(t, conf.GetDuplicatePodThreshold(), DefaultDuplicatePodThreshold)
+	assert.Equal(t, conf.GetDuplicatePodReject(), DefaultDuplicatePodReject)
+	assert.Equal(t, conf.GetUserGroupsProvider(), DefaultUserGroupsProvider)
+	assert.Equal(t, len(conf.GetStaticUserGroups("alice")), 0)
+	assert.Equal(t, conf.GetUserGroupsConfigMapName(), DefaultUserGroupsConfigMapName)
+	assert.Equal(t, conf.GetUserGroupsURL(), DefaultUserGroupsURL)
+	assert.Equal(t, conf.GetUserGroupsCacheTTL(), DefaultUserGroupsCacheTTLSeconds*time.Second)
+	assert.Equal(t, conf.GetKubeQPS(), DefaultKubeQPS)
+	assert.Equal(t, conf.GetKubeBurst(), DefaultKubeBurst)
+	assert.Equal(t, conf.GetKubeAdaptiveRateLimit(), DefaultKubeAdaptiveRateLimit)
+	assert.Equal(t, conf.GetWebHookFailurePolicy(), DefaultWebHookFailurePolicy)
+	assert.Equal(t, conf.GetWebHookMinReadyReplicas(), DefaultWebHookMinReadyReplicas)
+	assert.Equal(t, conf.GetWebHookHealthyChecks(), DefaultWebHookHealthyChecks)
+	assert.Equal(t, conf.GetWebHookUnhealthyChecks(), DefaultWebHookUnhealthyChecks)
+	_, ok = conf.GetQueuePlacement("root.default")
+	assert.Assert(t, !ok, "unexpected queue placement")
+	_, ok = conf.GetUserBurstLimit("alice")
+	assert.Assert(t, !ok, "unexpected user burst limit")
 
 	// test faulty settings for boolean values
 	conf = NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: map[string]string{
@@ -87,12 +199,43 @@ func TestConfigMapVars(t *testing.T) {
 	assert.Equal(t, conf.GetTrustControllers(), DefaultAccessControlTrustControllers)
 	assert.Equal(t, conf.GetGenerateUniqueAppIds(), DefaultFilteringGenerateUniqueAppIds)
 
+	// test faulty settings for label selector values
+	conf = NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: map[string]string{
+		AMFilteringBypassNamespaceSelector: "pool in teamA",
+	}}})
+	assert.Assert(t, conf.GetBypassNamespaceSelector() == nil, "invalid selector should be ignored")
+
 	// test faulty settings for regexp values
 	conf = NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: map[string]string{
 		AMFilteringProcessNamespaces: "?",
 	}}})
 	assert.Equal(t, len(conf.GetProcessNamespaces()), 0)
 
+	// test faulty settings for resource defaults
+	conf = NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: map[string]string{
+		AMResourceDefaultsQueues:     "xyz",
+		AMResourceDefaultsNamespaces: `{"test": {"requests": {"cpu": "abc"}}}`,
+		AMPriorityClassQueues:        `["high-priority"]`,
+		AMPlacementQueues:            `{"root.gpu": {"tolerations": "gpu"}}`,
+		AMBurstLimitUsers:            `{"alice": "10"}`,
+		AMUserGroupsProvider:         "ldap",
+		AMUserGroupsStatic:           `{"alice": "ml"}`,
+		AMWebHookFailurePolicy:       "Never",
+	}}})
+	_, ok = conf.GetQueueResourceDefaults("xyz")
+	assert.Assert(t, !ok, "unexpected queue resource defaults")
+	_, ok = conf.GetNamespaceResourceDefaults("test")
+	assert.Assert(t, !ok, "unexpected namespace resource defaults")
+	_, ok = conf.GetQueuePriorityClass("high-priority")
+	assert.Assert(t, !ok, "unexpected queue priority class")
+	_, ok = conf.GetQueuePlacement("root.gpu")
+	assert.Assert(t, !ok, "unexpected queue placement")
+	_, ok = conf.GetUserBurstLimit("alice")
+	assert.Assert(t, !ok, "unexpected user burst limit")
+	assert.Equal(t, conf.GetUserGroupsProvider(), DefaultUserGroupsProvider)
+	assert.Equal(t, len(conf.GetStaticUserGroups("alice")), 0)
+	assert.Equal(t, conf.GetWebHookFailurePolicy(), DefaultWebHookFailurePolicy)
+
 	// test disable / enable of config hot refresh
 	conf = NewAdmissionControllerConf([]*v1.ConfigMap{nil, nil})
 
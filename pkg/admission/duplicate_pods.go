@@ -0,0 +1,185 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+const (
+	duplicatePodWindow = time.Minute
+	// prefix of the projected service account token volume, the name has a random suffix per pod
+	serviceAccountVolumePrefix = "kube-api-access-"
+	replicaSetKind             = "ReplicaSet"
+)
+
+// DuplicatePodDetector detects controllers that recreate identical pods in a tight loop, e.g. a controller that
+// retries a failing pod without a backoff. Pods are identical if they have the same controller and the same spec.
+// Each pod the scheduler has to process creates an application and a task, a retry loop of thousands of short-lived
+// pods churns through those objects. The creations are tracked in memory as a sliding window per controller and spec.
+type DuplicatePodDetector struct {
+	conf      *conf.AdmissionControllerConf
+	creations map[string][]time.Time
+	lastSweep time.Time
+
+	sync.Mutex
+}
+
+// duplicatePods describes the identical pods created by a controller within the window
+type duplicatePods struct {
+	owner   *metav1.OwnerReference
+	count   int
+	backoff time.Duration
+}
+
+func (d *duplicatePods) String() string {
+	return fmt.Sprintf("%s %s created %d identical pods within %v, back off for %v",
+		d.owner.Kind, d.owner.Name, d.count, duplicatePodWindow, d.backoff)
+}
+
+// NewDuplicatePodDetector creates a new detector without any pod creations recorded.
+func NewDuplicatePodDetector(conf *conf.AdmissionControllerConf) *DuplicatePodDetector {
+	return &DuplicatePodDetector{
+		conf:      conf,
+		creations: make(map[string][]time.Time),
+	}
+}
+
+// record records the creation of the pod at the given time. Returns the duplicates if the controller of the pod
+// created more identical pods within the window than the threshold, nil otherwise. Pods without a controller are
+// never duplicates. Pods of a ReplicaSet are not tracked: a ReplicaSet creates identical pods when it scales up.
+func (d *DuplicatePodDetector) record(pod *v1.Pod, now time.Time) *duplicatePods {
+	threshold := d.conf.GetDuplicatePodThreshold()
+	if threshold <= 0 {
+		return nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind == replicaSetKind {
+		return nil
+	}
+	spec, err := json.Marshal(duplicatePodSpec(&pod.Spec))
+	if err != nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%x", owner.UID, sha256.Sum256(spec))
+
+	d.Lock()
+	defer d.Unlock()
+	d.sweep(now)
+	creations := append(d.recentCreations(key, now), now)
+	d.creations[key] = creations
+	if len(creations) <= threshold {
+		return nil
+	}
+	return &duplicatePods{
+		owner:   owner,
+		count:   len(creations),
+		backoff: duplicatePodBackoff(len(creations) - threshold),
+	}
+}
+
+// duplicatePodSpec returns the part of the pod spec that identifies identical pods. The service account token
+// volume is added with a random name before the webhook is called, the volume and its mounts are removed.
+func duplicatePodSpec(spec *v1.PodSpec) *v1.PodSpec {
+	tokenVolumes := make(map[string]bool)
+	for _, volume := range spec.Volumes {
+		if strings.HasPrefix(volume.Name, serviceAccountVolumePrefix) {
+			tokenVolumes[volume.Name] = true
+		}
+	}
+	if len(tokenVolumes) == 0 {
+		return spec
+	}
+	spec = spec.DeepCopy()
+	volumes := make([]v1.Volume, 0, len(spec.Volumes))
+	for _, volume := range spec.Volumes {
+		if !tokenVolumes[volume.Name] {
+			volumes = append(volumes, volume)
+		}
+	}
+	spec.Volumes = volumes
+	withoutTokenMounts := func(mounts []v1.VolumeMount) []v1.VolumeMount {
+		filtered := make([]v1.VolumeMount, 0, len(mounts))
+		for _, mount := range mounts {
+			if !tokenVolumes[mount.Name] {
+				filtered = append(filtered, mount)
+			}
+		}
+		return filtered
+	}
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].VolumeMounts = withoutTokenMounts(spec.InitContainers[i].VolumeMounts)
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].VolumeMounts = withoutTokenMounts(spec.Containers[i].VolumeMounts)
+	}
+	for i := range spec.EphemeralContainers {
+		spec.EphemeralContainers[i].VolumeMounts = withoutTokenMounts(spec.EphemeralContainers[i].VolumeMounts)
+	}
+	return spec
+}
+
+// duplicatePodBackoff returns the suggested backoff for the given number of pods over the threshold. The backoff
+// starts at a second and doubles with each pod, up to the window.
+func duplicatePodBackoff(excess int) time.Duration {
+	if excess > 6 {
+		return duplicatePodWindow
+	}
+	return time.Second << (excess - 1)
+}
+
+// recentCreations drops the creations that are outside the window and returns the remaining ones.
+// The caller must hold the lock.
+func (d *DuplicatePodDetector) recentCreations(key string, now time.Time) []time.Time {
+	creations := d.creations[key]
+	cutoff := now.Add(-duplicatePodWindow)
+	i := 0
+	for i < len(creations) && !creations[i].After(cutoff) {
+		i++
+	}
+	if i == len(creations) {
+		delete(d.creations, key)
+		return nil
+	}
+	d.creations[key] = creations[i:]
+	return creations[i:]
+}
+
+// sweep removes the controllers and specs without recent creations once per window. Unlike the burst limits the
+// keys are not bound by the configuration, a controller that is deleted would otherwise never be removed.
+// The caller must hold the lock.
+func (d *DuplicatePodDetector) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < duplicatePodWindow {
+		return
+	}
+	d.lastSweep = now
+	for key := range d.creations {
+		d.recentCreations(key, now)
+	}
+}
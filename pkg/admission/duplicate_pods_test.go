@@ -0,0 +1,136 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+func duplicatePodForTest(ownerUID types.UID, image string) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-ns",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Job", Name: "job-" + string(ownerUID), UID: ownerUID, Controller: &controller},
+			},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "main", Image: image}}},
+	}
+}
+
+func TestDuplicatePodDetectorRecord(t *testing.T) {
+	detector := NewDuplicatePodDetector(createConfigWithOverrides(map[string]string{
+		conf.AMDuplicatePodThreshold: "2",
+	}))
+	start := time.Now()
+
+	assert.Assert(t, detector.record(duplicatePodForTest("uid-1", "busybox"), start) == nil)
+	assert.Assert(t, detector.record(duplicatePodForTest("uid-1", "busybox"), start.Add(time.Second)) == nil)
+	duplicates := detector.record(duplicatePodForTest("uid-1", "busybox"), start.Add(2*time.Second))
+	assert.Assert(t, duplicates != nil, "pod over the threshold not detected")
+	assert.Equal(t, duplicates.count, 3)
+	assert.Equal(t, duplicates.backoff, time.Second)
+	assert.Equal(t, duplicates.String(), "Job job-uid-1 created 3 identical pods within 1m0s, back off for 1s")
+	duplicates = detector.record(duplicatePodForTest("uid-1", "busybox"), start.Add(3*time.Second))
+	assert.Equal(t, duplicates.backoff, 2*time.Second)
+
+	// a different spec or controller is tracked separately
+	assert.Assert(t, detector.record(duplicatePodForTest("uid-1", "nginx"), start) == nil)
+	assert.Assert(t, detector.record(duplicatePodForTest("uid-2", "busybox"), start) == nil)
+
+	// the first creations leave the window
+	assert.Assert(t, detector.record(duplicatePodForTest("uid-1", "busybox"), start.Add(62*time.Second)) == nil)
+
+	// the sweep removes keys without recent creations
+	detector.record(duplicatePodForTest("uid-1", "busybox"), start.Add(5*time.Minute))
+	assert.Equal(t, len(detector.creations), 1)
+
+	// pods without a controller are never duplicates
+	pod := duplicatePodForTest("uid-3", "busybox")
+	pod.OwnerReferences = nil
+	for i := 0; i < 5; i++ {
+		assert.Assert(t, detector.record(pod, start) == nil)
+	}
+
+	// disabled by default
+	detector = NewDuplicatePodDetector(createConfig())
+	for i := 0; i < 5; i++ {
+		assert.Assert(t, detector.record(duplicatePodForTest("uid-1", "busybox"), start) == nil)
+	}
+	assert.Equal(t, len(detector.creations), 0)
+}
+
+func TestDuplicatePodServiceAccountVolume(t *testing.T) {
+	detector := NewDuplicatePodDetector(createConfigWithOverrides(map[string]string{
+		conf.AMDuplicatePodThreshold: "1",
+	}))
+	withToken := func(volumeName string) *v1.Pod {
+		pod := duplicatePodForTest("uid-1", "busybox")
+		pod.Spec.Volumes = []v1.Volume{
+			{Name: "data"},
+			{Name: volumeName, VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{}}},
+		}
+		pod.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{
+			{Name: "data", MountPath: "/data"},
+			{Name: volumeName, MountPath: "/var/run/secrets/kubernetes.io/serviceaccount"},
+		}
+		return pod
+	}
+	now := time.Now()
+	first := withToken("kube-api-access-abcde")
+	assert.Assert(t, detector.record(first, now) == nil)
+	assert.Assert(t, detector.record(withToken("kube-api-access-fghij"), now) != nil, "pods with different token volumes not identical")
+	// the spec of the pod is not changed
+	assert.Equal(t, len(first.Spec.Volumes), 2)
+	assert.Equal(t, len(first.Spec.Containers[0].VolumeMounts), 2)
+
+	// other volumes are still part of the spec
+	other := withToken("kube-api-access-klmno")
+	other.Spec.Containers[0].VolumeMounts[0].MountPath = "/other"
+	assert.Assert(t, detector.record(other, now) == nil, "pods with different mounts identical")
+}
+
+func TestDuplicatePodReplicaSet(t *testing.T) {
+	detector := NewDuplicatePodDetector(createConfigWithOverrides(map[string]string{
+		conf.AMDuplicatePodThreshold: "1",
+	}))
+	pod := duplicatePodForTest("uid-1", "busybox")
+	pod.OwnerReferences[0].Kind = "ReplicaSet"
+	for i := 0; i < 5; i++ {
+		assert.Assert(t, detector.record(pod, time.Now()) == nil, "scale up of ReplicaSet detected as duplicates")
+	}
+	assert.Equal(t, len(detector.creations), 0)
+}
+
+func TestDuplicatePodBackoff(t *testing.T) {
+	assert.Equal(t, duplicatePodBackoff(1), time.Second)
+	assert.Equal(t, duplicatePodBackoff(2), 2*time.Second)
+	assert.Equal(t, duplicatePodBackoff(6), 32*time.Second)
+	assert.Equal(t, duplicatePodBackoff(7), time.Minute)
+	assert.Equal(t, duplicatePodBackoff(1000), time.Minute)
+}
@@ -0,0 +1,199 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	ctx "context"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// interval at which the health of the replicas is checked for the Auto failure policy
+	policyCheckInterval = 10 * time.Second
+	// namespace of the control plane pods, never blocked by the webhook
+	kubeSystemNamespace = "kube-system"
+)
+
+// initialFailurePolicy returns the failure policy installed at startup. The Auto mode starts with Ignore:
+// pod creation is only blocked once the replicas have proven to be healthy.
+func initialFailurePolicy(mode string) v1.FailurePolicyType {
+	if mode == conf.FailurePolicyFail {
+		return v1.Fail
+	}
+	return v1.Ignore
+}
+
+// failurePolicyNamespaceSelector returns the namespace selector of the mutating webhook for the failure policy.
+// With the Fail policy the namespace of the admission controller and kube-system are excluded from the webhook:
+// the replicas and the control plane must be able to start while no replica is serving requests.
+func failurePolicyNamespaceSelector(policy v1.FailurePolicyType, namespace string) *metav1.LabelSelector {
+	if policy != v1.Fail {
+		return nil
+	}
+	return systemNamespaceSelector(namespace)
+}
+
+// systemNamespaceSelector returns a namespace selector that excludes kube-system and the namespace of the
+// admission controller from a webhook.
+func systemNamespaceSelector(namespace string) *metav1.LabelSelector {
+	excluded := []string{kubeSystemNamespace}
+	if namespace != kubeSystemNamespace {
+		excluded = append(excluded, namespace)
+	}
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      corev1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   excluded,
+		}},
+	}
+}
+
+func (wm *webhookManagerImpl) getFailurePolicy() v1.FailurePolicyType {
+	wm.RLock()
+	defer wm.RUnlock()
+	return wm.failurePolicy
+}
+
+func (wm *webhookManagerImpl) ManageFailurePolicy(stop <-chan struct{}) {
+	ticker := time.NewTicker(wm.policyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			wm.updateFailurePolicy()
+		}
+	}
+}
+
+// updateFailurePolicy changes the failure policy of the mutating webhook if required. All replicas track the
+// policy, which makes sure a newly elected leader installs the current policy, only the leader updates the webhook.
+func (wm *webhookManagerImpl) updateFailurePolicy() {
+	policy := wm.nextFailurePolicy()
+	wm.Lock()
+	if wm.policyReleased {
+		wm.Unlock()
+		return
+	}
+	changed := wm.failurePolicy != policy
+	wm.failurePolicy = policy
+	wm.Unlock()
+	if !changed || !wm.isLeader() {
+		return
+	}
+	log.Log(log.AdmissionWebhook).Info("Changing failure policy of mutating webhook",
+		zap.String("failurePolicy", string(policy)))
+	if err := wm.InstallWebhooks(); err != nil {
+		log.Log(log.AdmissionWebhook).Error("Unable to change failure policy of mutating webhook", zap.Error(err))
+	}
+}
+
+// ReleaseFailurePolicy stops tracking the failure policy. Called on shutdown: pod creation must not depend on a
+// replica that is stopping. The leader reinstalls the mutating webhook with the Ignore policy, unless other
+// replicas are ready: one of them takes over the leadership and keeps managing the policy.
+func (wm *webhookManagerImpl) ReleaseFailurePolicy() {
+	wm.Lock()
+	wm.policyReleased = true
+	changed := wm.failurePolicy != v1.Ignore
+	wm.Unlock()
+	if !changed || !wm.isLeader() {
+		return
+	}
+	others, err := wm.readyReplicas(wm.identity)
+	if err != nil {
+		log.Log(log.AdmissionWebhook).Warn("Unable to check admission controller replicas", zap.Error(err))
+	} else if others > 0 {
+		log.Log(log.AdmissionWebhook).Info("Shutting down, other replicas keep serving the webhook",
+			zap.Int("readyReplicas", others))
+		return
+	}
+	wm.Lock()
+	wm.failurePolicy = v1.Ignore
+	wm.Unlock()
+	log.Log(log.AdmissionWebhook).Info("Shutting down, pod creation no longer requires the webhook")
+	if err = wm.InstallWebhooks(); err != nil {
+		log.Log(log.AdmissionWebhook).Error("Unable to change failure policy of mutating webhook", zap.Error(err))
+	}
+}
+
+// nextFailurePolicy returns the failure policy based on the configured mode. In Auto mode the policy changes
+// to Fail after a number of consecutive checks with enough ready replicas, and back to Ignore after a number of
+// consecutive checks without. A single check in the opposite direction resets the count: the policy does not
+// flap when the number of ready replicas fluctuates around the minimum.
+func (wm *webhookManagerImpl) nextFailurePolicy() v1.FailurePolicyType {
+	mode := wm.conf.GetWebHookFailurePolicy()
+	if mode != conf.FailurePolicyAuto {
+		wm.healthyChecks = 0
+		wm.unhealthyChecks = 0
+		return initialFailurePolicy(mode)
+	}
+	ready, err := wm.readyReplicas("")
+	if err != nil {
+		log.Log(log.AdmissionWebhook).Warn("Unable to check admission controller replicas", zap.Error(err))
+	}
+	if err == nil && ready >= wm.conf.GetWebHookMinReadyReplicas() {
+		wm.healthyChecks++
+		wm.unhealthyChecks = 0
+	} else {
+		wm.unhealthyChecks++
+		wm.healthyChecks = 0
+	}
+	current := wm.getFailurePolicy()
+	switch {
+	case current == v1.Fail && wm.unhealthyChecks >= wm.conf.GetWebHookUnhealthyChecks():
+		log.Log(log.AdmissionWebhook).Warn("Admission controller replicas unhealthy, pod creation no longer requires the webhook",
+			zap.Int("readyReplicas", ready))
+		return v1.Ignore
+	case current != v1.Fail && wm.healthyChecks >= wm.conf.GetWebHookHealthyChecks():
+		log.Log(log.AdmissionWebhook).Info("Admission controller replicas healthy, pod creation requires the webhook",
+			zap.Int("readyReplicas", ready))
+		return v1.Fail
+	}
+	return current
+}
+
+// readyReplicas returns the number of ready addresses of the admission controller service.
+// The address of the pod with the excluded name is not counted.
+func (wm *webhookManagerImpl) readyReplicas(exclude string) (int, error) {
+	endpoints, err := wm.clientset.CoreV1().Endpoints(wm.conf.GetNamespace()).Get(ctx.Background(), wm.conf.GetAmServiceName(), metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	// a replica is listed in each subset of the ports it serves
+	ips := make(map[string]bool)
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if exclude != "" && address.TargetRef != nil && address.TargetRef.Name == exclude {
+				continue
+			}
+			ips[address.IP] = true
+		}
+	}
+	return len(ips), nil
+}
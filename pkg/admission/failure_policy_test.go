@@ -0,0 +1,190 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	arv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+// setReadyReplicas publishes the endpoints of the admission controller service with the given number of ready
+// replicas, each replica serves two ports to make sure replicas are not counted twice
+func setReadyReplicas(clientset *fakeClient, ready int) {
+	addresses := make([]v1.EndpointAddress, 0, ready)
+	for i := 0; i < ready; i++ {
+		addresses = append(addresses, v1.EndpointAddress{IP: fmt.Sprintf("10.0.0.%d", i+1)})
+	}
+	clientset.endpoints["default/"+conf.DefaultWebHookAmServiceName] = &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{Addresses: addresses, Ports: []v1.EndpointPort{{Port: 9089}}},
+			{Addresses: addresses, Ports: []v1.EndpointPort{{Port: 9090}}},
+		},
+	}
+}
+
+func installedFailurePolicy(t *testing.T, clientset *fakeClient) arv1.FailurePolicyType {
+	hook, ok := clientset.mutatingWebhooks[mutatingWebhook]
+	assert.Assert(t, ok, "mutating webhook not found")
+	return *hook.Webhooks[0].FailurePolicy
+}
+
+func TestInitialFailurePolicy(t *testing.T) {
+	assert.Equal(t, initialFailurePolicy(conf.FailurePolicyIgnore), arv1.Ignore)
+	assert.Equal(t, initialFailurePolicy(conf.FailurePolicyFail), arv1.Fail)
+	assert.Equal(t, initialFailurePolicy(conf.FailurePolicyAuto), arv1.Ignore)
+}
+
+func TestInstallWebhooksWithFailPolicy(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	assert.NilError(t, wm.InstallWebhooks(), "Install webhooks failed")
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Ignore)
+
+	// a static Fail policy replaces the installed policy
+	wm.conf = createConfigWithOverrides(map[string]string{conf.AMWebHookFailurePolicy: conf.FailurePolicyFail})
+	wm.updateFailurePolicy()
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Fail)
+	assert.NilError(t, wm.checkMutatingWebhook(clientset.mutatingWebhooks[mutatingWebhook]), "mutating webhook is malformed")
+	selector := clientset.mutatingWebhooks[mutatingWebhook].Webhooks[0].NamespaceSelector
+	assert.Assert(t, selector != nil, "namespaces not excluded with Fail policy")
+	assert.DeepEqual(t, selector.MatchExpressions[0].Values, []string{"kube-system", "default"})
+}
+
+func TestFailurePolicyNamespaceSelector(t *testing.T) {
+	assert.Assert(t, failurePolicyNamespaceSelector(arv1.Ignore, "yunikorn") == nil, "selector set for Ignore policy")
+	selector := failurePolicyNamespaceSelector(arv1.Fail, "yunikorn")
+	assert.Equal(t, len(selector.MatchExpressions), 1)
+	assert.Equal(t, selector.MatchExpressions[0].Key, v1.LabelMetadataName)
+	assert.Equal(t, selector.MatchExpressions[0].Operator, metav1.LabelSelectorOpNotIn)
+	assert.DeepEqual(t, selector.MatchExpressions[0].Values, []string{"kube-system", "yunikorn"})
+	selector = failurePolicyNamespaceSelector(arv1.Fail, "kube-system")
+	assert.DeepEqual(t, selector.MatchExpressions[0].Values, []string{"kube-system"})
+}
+
+func TestReleaseFailurePolicy(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	wm.conf = createConfigWithOverrides(map[string]string{conf.AMWebHookFailurePolicy: conf.FailurePolicyFail})
+	wm.updateFailurePolicy()
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Fail)
+
+	wm.ReleaseFailurePolicy()
+	assert.Equal(t, wm.getFailurePolicy(), arv1.Ignore)
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Ignore)
+	assert.Assert(t, clientset.mutatingWebhooks[mutatingWebhook].Webhooks[0].NamespaceSelector == nil, "namespaces excluded with Ignore policy")
+
+	// the policy is no longer managed after the release
+	wm.updateFailurePolicy()
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Ignore)
+}
+
+func TestReleaseFailurePolicyOtherReplicas(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	wm.identity = "replica-1"
+	wm.conf = createConfigWithOverrides(map[string]string{conf.AMWebHookFailurePolicy: conf.FailurePolicyFail})
+	wm.updateFailurePolicy()
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Fail)
+
+	// another replica is ready: it takes over the policy
+	setReadyReplicas(clientset, 2)
+	wm.ReleaseFailurePolicy()
+	assert.Equal(t, wm.getFailurePolicy(), arv1.Fail)
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Fail)
+
+	// the replica itself is not counted
+	clientset.endpoints["default/"+conf.DefaultWebHookAmServiceName] = &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{{
+			Addresses: []v1.EndpointAddress{{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "replica-1"}}},
+		}},
+	}
+	wm.ReleaseFailurePolicy()
+	assert.Equal(t, wm.getFailurePolicy(), arv1.Ignore)
+	assert.Equal(t, installedFailurePolicy(t, clientset), arv1.Ignore)
+}
+
+func TestUpdateFailurePolicyAuto(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	wm.conf = createConfigWithOverrides(map[string]string{
+		conf.AMWebHookFailurePolicy:    conf.FailurePolicyAuto,
+		conf.AMWebHookMinReadyReplicas: "2",
+		conf.AMWebHookHealthyChecks:    "3",
+		conf.AMWebHookUnhealthyChecks:  "2",
+	})
+	wm.failurePolicy = initialFailurePolicy(conf.FailurePolicyAuto)
+	assert.NilError(t, wm.InstallWebhooks(), "Install webhooks failed")
+
+	tests := []struct {
+		name   string
+		ready  int
+		policy arv1.FailurePolicyType
+	}{
+		{"healthy 1", 2, arv1.Ignore},
+		{"healthy 2", 3, arv1.Ignore},
+		{"unhealthy resets", 1, arv1.Ignore},
+		{"healthy 1 again", 2, arv1.Ignore},
+		{"healthy 2 again", 2, arv1.Ignore},
+		{"healthy 3", 2, arv1.Fail},
+		{"unhealthy 1", 1, arv1.Fail},
+		{"healthy resets", 2, arv1.Fail},
+		{"unhealthy 1 again", 0, arv1.Fail},
+		{"unhealthy 2", 0, arv1.Ignore},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			setReadyReplicas(clientset, tc.ready)
+			wm.updateFailurePolicy()
+			assert.Equal(t, wm.getFailurePolicy(), tc.policy, "unexpected tracked policy")
+			assert.Equal(t, installedFailurePolicy(t, clientset), tc.policy, "unexpected installed policy")
+		})
+	}
+
+	// missing endpoints count as unhealthy
+	wm.failurePolicy = arv1.Fail
+	wm.unhealthyChecks = 0
+	delete(clientset.endpoints, "default/"+conf.DefaultWebHookAmServiceName)
+	wm.updateFailurePolicy()
+	assert.Equal(t, wm.getFailurePolicy(), arv1.Fail)
+	wm.updateFailurePolicy()
+	assert.Equal(t, wm.getFailurePolicy(), arv1.Ignore)
+}
+
+func TestUpdateFailurePolicyAsFollower(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	wm.conf = createConfigWithOverrides(map[string]string{conf.AMWebHookFailurePolicy: conf.FailurePolicyFail})
+	wm.SetLeader(false)
+	wm.updateFailurePolicy()
+	assert.Equal(t, wm.getFailurePolicy(), arv1.Fail, "follower should track the policy")
+	_, ok := clientset.mutatingWebhooks[mutatingWebhook]
+	assert.Assert(t, !ok, "follower should not install the webhook")
+}
@@ -22,9 +22,14 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	informersv1 "k8s.io/client-go/informers/core/v1"
 	schedulinginformersv1 "k8s.io/client-go/informers/scheduling/v1"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
@@ -34,6 +39,11 @@ type Informers struct {
 	ConfigMap     informersv1.ConfigMapInformer
 	PriorityClass schedulinginformersv1.PriorityClassInformer
 	Namespace     informersv1.NamespaceInformer
+	Node          informersv1.NodeInformer
+	PodGroup      informers.GenericInformer
+	Owners        map[string]informers.GenericInformer
+	Pods          informers.GenericInformer
+	OwnerClient   metadata.Interface
 	stopChan      chan struct{}
 }
 
@@ -53,10 +63,72 @@ func NewInformers(kubeClient client.KubeClient, namespace string) *Informers {
 	return result
 }
 
+// AddPodGroupInformer creates the informer for the coscheduling PodGroups in all namespaces.
+// The CRD is not part of the K8s API, the PodGroups are retrieved using the dynamic client.
+func (i *Informers) AddPodGroupInformer(kubeClient client.KubeClient) error {
+	dynamicClient, err := dynamic.NewForConfig(kubeClient.GetConfigs())
+	if err != nil {
+		return err
+	}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, metav1.NamespaceAll, nil)
+	i.PodGroup = factory.ForResource(podGroupResource)
+	return nil
+}
+
+// AddNodeInformer creates the informer for the nodes, used to check the task groups of a pod against the node sizes.
+func (i *Informers) AddNodeInformer(kubeClient client.KubeClient) {
+	informerFactory := informers.NewSharedInformerFactory(kubeClient.GetClientSet(), 0)
+	i.Node = informerFactory.Core().V1().Nodes()
+}
+
+// AddOwnerInformers creates the informers for the objects that can be part of the owner chain of a pod in all
+// namespaces. Only the metadata of the objects is retrieved to limit the memory used by the informers.
+func (i *Informers) AddOwnerInformers(kubeClient client.KubeClient) error {
+	metadataClient, err := metadata.NewForConfig(kubeClient.GetConfigs())
+	if err != nil {
+		return err
+	}
+	factory := metadatainformer.NewFilteredSharedInformerFactory(metadataClient, 0, metav1.NamespaceAll, nil)
+	i.Owners = make(map[string]informers.GenericInformer)
+	for kind, resource := range ownerResources {
+		i.Owners[kind] = factory.ForResource(resource)
+	}
+	i.OwnerClient = metadataClient
+	return nil
+}
+
+// AddPodInformer creates the informer for the pods in all namespaces, used to detect applicationId collisions.
+// Only the metadata of the pods is retrieved. The pod informer of the owners is shared if it exists.
+func (i *Informers) AddPodInformer(kubeClient client.KubeClient) error {
+	if owner, ok := i.Owners[kindPod]; ok {
+		i.Pods = owner
+		return nil
+	}
+	metadataClient, err := metadata.NewForConfig(kubeClient.GetConfigs())
+	if err != nil {
+		return err
+	}
+	factory := metadatainformer.NewFilteredSharedInformerFactory(metadataClient, 0, metav1.NamespaceAll, nil)
+	i.Pods = factory.ForResource(ownerResources[kindPod])
+	return nil
+}
+
 func (i *Informers) Start() {
 	go i.ConfigMap.Informer().Run(i.stopChan)
 	go i.PriorityClass.Informer().Run(i.stopChan)
 	go i.Namespace.Informer().Run(i.stopChan)
+	if i.PodGroup != nil {
+		go i.PodGroup.Informer().Run(i.stopChan)
+	}
+	if i.Node != nil {
+		go i.Node.Informer().Run(i.stopChan)
+	}
+	for _, owner := range i.Owners {
+		go owner.Informer().Run(i.stopChan)
+	}
+	if i.Pods != nil && i.Pods != i.Owners[kindPod] {
+		go i.Pods.Informer().Run(i.stopChan)
+	}
 	i.waitForSync()
 }
 
@@ -72,7 +144,11 @@ func (i *Informers) waitForSync() {
 	for {
 		if i.ConfigMap.Informer().HasSynced() &&
 			i.PriorityClass.Informer().HasSynced() &&
-			i.Namespace.Informer().HasSynced() {
+			i.Namespace.Informer().HasSynced() &&
+			(i.PodGroup == nil || i.PodGroup.Informer().HasSynced()) &&
+			(i.Node == nil || i.Node.Informer().HasSynced()) &&
+			(i.Pods == nil || i.Pods.Informer().HasSynced()) &&
+			i.ownersSynced() {
 			return
 		}
 		time.Sleep(time.Second)
@@ -83,3 +159,12 @@ func (i *Informers) waitForSync() {
 		}
 	}
 }
+
+func (i *Informers) ownersSynced() bool {
+	for _, owner := range i.Owners {
+		if !owner.Informer().HasSynced() {
+			return false
+		}
+	}
+	return true
+}
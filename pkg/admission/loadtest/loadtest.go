@@ -0,0 +1,273 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission"
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+const mutatePath = "/mutate"
+
+// Config defines a single load test run against the mutating webhook
+type Config struct {
+	// URL of the mutate endpoint of the webhook
+	Target string
+	// Rate is the number of requests per second to send
+	Rate int
+	// Duration of the run
+	Duration time.Duration
+	// Workers is the number of concurrent connections used to send the requests
+	Workers int
+}
+
+// Result contains the statistics of a single load test run
+type Result struct {
+	Requests   int
+	Errors     int
+	Throughput float64
+	P50        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// ErrorRate returns the fraction of the requests that failed
+func (r *Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("requests=%d errors=%d (%.2f%%) throughput=%.1f/s p50=%v p99=%v max=%v",
+		r.Requests, r.Errors, r.ErrorRate()*100, r.Throughput, r.P50, r.P99, r.Max)
+}
+
+// Run sends AdmissionReview requests for pod creation to the target at the configured rate and collects
+// the latency of each request. A request is counted as an error if it fails, returns a non 200 status code
+// or is not allowed by the webhook.
+func Run(ctx context.Context, cfg Config, client *http.Client) (*Result, error) {
+	if cfg.Rate <= 0 || cfg.Workers <= 0 || cfg.Duration <= 0 {
+		return nil, fmt.Errorf("rate, workers and duration must be positive: %+v", cfg)
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	work := make(chan int, cfg.Workers)
+	var lock sync.Mutex
+	latencies := make([]time.Duration, 0, cfg.Rate*int(cfg.Duration/time.Second+1))
+	errCount := 0
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := range work {
+				start := time.Now()
+				err := sendReview(client, cfg.Target, seq)
+				elapsed := time.Since(start)
+				lock.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil {
+					errCount++
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+	interval := time.Second / time.Duration(cfg.Rate)
+	ticker := time.NewTicker(interval)
+	seq := 0
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			// tickers drop ticks if the receiver is slow, catch up based on the elapsed time
+			expected := int(time.Since(start) / interval)
+			for ; seq < expected; seq++ {
+				select {
+				case work <- seq:
+				case <-ctx.Done():
+					break loop
+				}
+			}
+		}
+	}
+	ticker.Stop()
+	close(work)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	return newResult(latencies, errCount, elapsed), nil
+}
+
+func newResult(latencies []time.Duration, errCount int, elapsed time.Duration) *Result {
+	result := &Result{
+		Requests: len(latencies),
+		Errors:   errCount,
+	}
+	if elapsed > 0 {
+		result.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	if len(latencies) == 0 {
+		return result
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.P50 = percentile(latencies, 50)
+	result.P99 = percentile(latencies, 99)
+	result.Max = latencies[len(latencies)-1]
+	return result
+}
+
+// percentile returns the nearest rank percentile of the sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func sendReview(client *http.Client, target string, seq int) error {
+	review, err := newPodReview(seq)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	response := admissionv1.AdmissionReview{}
+	if err = json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+	if response.Response == nil || !response.Response.Allowed {
+		return errors.New("request not allowed")
+	}
+	return nil
+}
+
+// newPodReview creates the AdmissionReview for the creation of a pod that is mutated by the webhook
+func newPodReview(seq int) (*admissionv1.AdmissionReview, error) {
+	name := fmt.Sprintf("loadtest-pod-%d", seq)
+	pod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "loadtest",
+			Labels:    map[string]string{"app": "loadtest"},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "sleep", Image: "alpine:latest"}},
+		},
+	}
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID(fmt.Sprintf("loadtest-%d", seq)),
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Name:      name,
+			Namespace: "loadtest",
+			Operation: admissionv1.Create,
+			UserInfo:  authv1.UserInfo{Username: "loadtest-user"},
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}, nil
+}
+
+// NewLocalServer starts an in process webhook with a configuration of the given size. The size is the number of
+// namespace and user regular expressions configured, none of them match the requests, which means that every
+// request is checked against all of them.
+func NewLocalServer(configSize int) *httptest.Server {
+	ac := admission.InitAdmissionController(conf.NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: sizedConfig(configSize)}}),
+		admission.NewPriorityClassCache(nil), admission.NewNamespaceCache(nil), admission.NewPodGroupCache(nil), admission.NewOwnerCache(nil, nil), admission.NewNodeCache(nil))
+	mux := http.NewServeMux()
+	mux.HandleFunc(mutatePath, ac.Serve)
+	return httptest.NewServer(mux)
+}
+
+// LocalTarget returns the mutate URL of a server started with NewLocalServer
+func LocalTarget(server *httptest.Server) string {
+	return server.URL + mutatePath
+}
+
+func sizedConfig(size int) map[string]string {
+	config := map[string]string{
+		// logging every request would dominate the measured latency
+		"log.level": "WARN",
+	}
+	if size <= 0 {
+		return config
+	}
+	namespaces := make([]string, size)
+	users := make([]string, size)
+	for i := 0; i < size; i++ {
+		namespaces[i] = fmt.Sprintf("^bypass-%d$", i)
+		users[i] = fmt.Sprintf("^external-user-%d$", i)
+	}
+	config[conf.AMFilteringBypassNamespaces] = strings.Join(namespaces, ",")
+	config[conf.AMFilteringNoLabelNamespaces] = strings.Join(namespaces, ",")
+	config[conf.AMAccessControlExternalUsers] = strings.Join(users, ",")
+	return config
+}
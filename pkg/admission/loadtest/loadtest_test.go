@@ -0,0 +1,80 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package loadtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	assert.Equal(t, percentile(sorted, 50), 50*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 99), 99*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 100), 100*time.Millisecond)
+	assert.Equal(t, percentile(sorted[:1], 99), time.Millisecond)
+	assert.Equal(t, percentile(nil, 50), time.Duration(0))
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	_, err := Run(context.Background(), Config{Target: "http://localhost", Rate: 0, Duration: time.Second, Workers: 1}, http.DefaultClient)
+	assert.ErrorContains(t, err, "must be positive")
+}
+
+func TestRunLocal(t *testing.T) {
+	for _, size := range []int{0, 100} {
+		server := NewLocalServer(size)
+		result, err := Run(context.Background(), Config{
+			Target:   LocalTarget(server),
+			Rate:     200,
+			Duration: 250 * time.Millisecond,
+			Workers:  4,
+		}, server.Client())
+		server.Close()
+		assert.NilError(t, err)
+		assert.Assert(t, result.Requests > 0, "no requests sent")
+		assert.Equal(t, result.Errors, 0, "unexpected errors: %s", result)
+		assert.Assert(t, result.P50 <= result.P99 && result.P99 <= result.Max, "latencies out of order: %s", result)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	result, err := Run(context.Background(), Config{
+		Target:   server.URL,
+		Rate:     100,
+		Duration: 100 * time.Millisecond,
+		Workers:  2,
+	}, server.Client())
+	assert.NilError(t, err)
+	assert.Assert(t, result.Requests > 0, "no requests sent")
+	assert.Equal(t, result.Errors, result.Requests)
+	assert.Equal(t, result.ErrorRate(), 1.0)
+}
@@ -0,0 +1,183 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// userGroupsConfigMapKey is the key of the ConfigMap data with the groups per user as a JSON object
+	userGroupsConfigMapKey = "groups"
+	userGroupsHTTPTimeout  = 5 * time.Second
+)
+
+// GroupProvider resolves the groups a user is a member of, in addition to the groups of the authenticated user.
+type GroupProvider interface {
+	GetGroups(userName string) ([]string, error)
+}
+
+// staticGroupProvider returns the groups configured in the admission controller configuration.
+type staticGroupProvider struct {
+	conf *conf.AdmissionControllerConf
+}
+
+func (p *staticGroupProvider) GetGroups(userName string) ([]string, error) {
+	return p.conf.GetStaticUserGroups(userName), nil
+}
+
+// configMapGroupProvider returns the groups stored as a JSON object in a ConfigMap in the scheduler namespace,
+// e.g. groups: '{"alice": ["dev", "ml"]}'.
+type configMapGroupProvider struct {
+	conf   *conf.AdmissionControllerConf
+	lister listersv1.ConfigMapLister
+}
+
+func (p *configMapGroupProvider) GetGroups(userName string) ([]string, error) {
+	if p.lister == nil {
+		return nil, fmt.Errorf("no ConfigMap lister available")
+	}
+	name := p.conf.GetUserGroupsConfigMapName()
+	cm, err := p.lister.ConfigMaps(p.conf.GetNamespace()).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	userGroups, err := conf.ParseUserGroups(cm.Data[userGroupsConfigMapKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid user groups in ConfigMap %s: %w", name, err)
+	}
+	return userGroups[userName], nil
+}
+
+type cachedGroups struct {
+	url     string
+	groups  []string
+	expires time.Time
+}
+
+// httpGroupProvider queries an external endpoint for the groups of the user: GET <url>?user=<name> must return
+// a JSON object like {"groups": ["dev", "ml"]}. Responses are cached to keep the endpoint out of the admission path.
+type httpGroupProvider struct {
+	conf   *conf.AdmissionControllerConf
+	client *http.Client
+	cache  map[string]cachedGroups
+	lock   sync.Mutex
+}
+
+type httpGroupsResponse struct {
+	Groups []string `json:"groups"`
+}
+
+func newHTTPGroupProvider(conf *conf.AdmissionControllerConf) *httpGroupProvider {
+	return &httpGroupProvider{
+		conf:   conf,
+		client: &http.Client{Timeout: userGroupsHTTPTimeout},
+		cache:  make(map[string]cachedGroups),
+	}
+}
+
+func (p *httpGroupProvider) GetGroups(userName string) ([]string, error) {
+	endpoint := p.conf.GetUserGroupsURL()
+	now := time.Now()
+	p.lock.Lock()
+	entry, ok := p.cache[userName]
+	p.lock.Unlock()
+	if ok && entry.url == endpoint && now.Before(entry.expires) {
+		return entry.groups, nil
+	}
+
+	reqURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	query := reqURL.Query()
+	query.Set("user", userName)
+	reqURL.RawQuery = query.Encode()
+	resp, err := p.client.Get(reqURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+	var result httpGroupsResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	// drop the expired entries to keep the cache from growing with users that stopped submitting
+	for name, cached := range p.cache {
+		if !now.Before(cached.expires) {
+			delete(p.cache, name)
+		}
+	}
+	p.cache[userName] = cachedGroups{
+		url:     endpoint,
+		groups:  result.Groups,
+		expires: now.Add(p.conf.GetUserGroupsCacheTTL()),
+	}
+	return result.Groups, nil
+}
+
+// resolveGroups adds the groups returned by the configured provider to the groups of the authenticated user.
+// If the provider fails the groups of the authenticated user are used as is.
+func (u *UserGroupAnnotationHandler) resolveGroups(userName string, groups []string) []string {
+	var provider GroupProvider
+	switch u.conf.GetUserGroupsProvider() {
+	case conf.UserGroupsProviderStatic:
+		provider = &staticGroupProvider{conf: u.conf}
+	case conf.UserGroupsProviderConfigMap:
+		provider = &configMapGroupProvider{conf: u.conf, lister: u.configMaps}
+	case conf.UserGroupsProviderHTTP:
+		provider = u.httpGroups
+	default:
+		return groups
+	}
+	resolved, err := provider.GetGroups(userName)
+	if err != nil {
+		log.Log(log.Admission).Warn("Unable to resolve the groups of the user, using the authenticated groups",
+			zap.String("userName", userName),
+			zap.String("provider", u.conf.GetUserGroupsProvider()),
+			zap.Error(err))
+		return groups
+	}
+	result := make([]string, 0, len(groups)+len(resolved))
+	seen := make(map[string]bool)
+	for _, group := range append(append([]string{}, groups...), resolved...) {
+		if !seen[group] {
+			seen[group] = true
+			result = append(result, group)
+		}
+	}
+	return result
+}
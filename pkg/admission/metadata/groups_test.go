@@ -0,0 +1,142 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/common"
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func getGroupsHandler(overrides map[string]string) *UserGroupAnnotationHandler {
+	return NewUserGroupAnnotationHandler(conf.NewAdmissionControllerConf([]*v1.ConfigMap{nil, {Data: overrides}}))
+}
+
+func getPatchGroups(t *testing.T, ah *UserGroupAnnotationHandler, user string, groups []string) []string {
+	patchOp, err := ah.GetPatchForPod(nil, user, groups)
+	assert.NilError(t, err)
+	value, ok := patchOp.Value.(map[string]string)
+	assert.Assert(t, ok, "type assertion failed")
+	var userGroup si.UserGroupInformation
+	err = json.Unmarshal([]byte(value[common.UserInfoAnnotation]), &userGroup)
+	assert.NilError(t, err)
+	assert.Equal(t, userGroup.User, user)
+	return userGroup.Groups
+}
+
+func TestResolveGroupsNoProvider(t *testing.T) {
+	ah := getGroupsHandler(map[string]string{
+		conf.AMUserGroupsStatic: `{"alice": ["ml"]}`,
+	})
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"system:authenticated"}), []string{"system:authenticated"})
+}
+
+func TestResolveGroupsStatic(t *testing.T) {
+	ah := getGroupsHandler(map[string]string{
+		conf.AMUserGroupsProvider: conf.UserGroupsProviderStatic,
+		conf.AMUserGroupsStatic:   `{"alice": ["ml", "system:authenticated", "data"]}`,
+	})
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"system:authenticated"}),
+		[]string{"system:authenticated", "ml", "data"})
+	assert.DeepEqual(t, getPatchGroups(t, ah, "bob", []string{"system:authenticated"}), []string{"system:authenticated"})
+}
+
+func TestResolveGroupsConfigMap(t *testing.T) {
+	ah := getGroupsHandler(map[string]string{
+		conf.AMUserGroupsProvider:      conf.UserGroupsProviderConfigMap,
+		conf.AMUserGroupsConfigMapName: "user-groups",
+	})
+	// no lister: fall back to the authenticated groups
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"devs"}), []string{"devs"})
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	ah.SetConfigMapLister(listersv1.NewConfigMapLister(indexer))
+	// ConfigMap missing
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"devs"}), []string{"devs"})
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-groups", Namespace: schedulerconf.DefaultNamespace},
+		Data:       map[string]string{userGroupsConfigMapKey: `{"alice": ["ml"]}`},
+	}
+	assert.NilError(t, indexer.Add(cm))
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"devs"}), []string{"devs", "ml"})
+	assert.DeepEqual(t, getPatchGroups(t, ah, "bob", []string{"devs"}), []string{"devs"})
+
+	// invalid content
+	cm.Data[userGroupsConfigMapKey] = "xyz"
+	assert.NilError(t, indexer.Update(cm))
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"devs"}), []string{"devs"})
+}
+
+func TestResolveGroupsHTTP(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Query().Get("user") {
+		case "alice":
+			_, _ = w.Write([]byte(`{"groups": ["ml", "data"]}`))
+		case "system:serviceaccount:batch:runner":
+			_, _ = w.Write([]byte(`{"groups": ["batch"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ah := getGroupsHandler(map[string]string{
+		conf.AMUserGroupsProvider: conf.UserGroupsProviderHTTP,
+		conf.AMUserGroupsURL:      server.URL,
+	})
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"devs"}), []string{"devs", "ml", "data"})
+	assert.Equal(t, requests.Load(), int32(1))
+	// cached
+	assert.DeepEqual(t, getPatchGroups(t, ah, "alice", []string{"devs"}), []string{"devs", "ml", "data"})
+	assert.Equal(t, requests.Load(), int32(1))
+	assert.DeepEqual(t, getPatchGroups(t, ah, "system:serviceaccount:batch:runner", nil), []string{"batch"})
+	assert.Equal(t, requests.Load(), int32(2))
+	// errors are not cached and fall back to the authenticated groups
+	assert.DeepEqual(t, getPatchGroups(t, ah, "bob", []string{"devs"}), []string{"devs"})
+	assert.DeepEqual(t, getPatchGroups(t, ah, "bob", []string{"devs"}), []string{"devs"})
+	assert.Equal(t, requests.Load(), int32(4))
+
+	// caching disabled
+	ah = getGroupsHandler(map[string]string{
+		conf.AMUserGroupsProvider:        conf.UserGroupsProviderHTTP,
+		conf.AMUserGroupsURL:             server.URL,
+		conf.AMUserGroupsCacheTTLSeconds: "0",
+	})
+	getPatchGroups(t, ah, "alice", nil)
+	getPatchGroups(t, ah, "alice", nil)
+	assert.Equal(t, requests.Load(), int32(6))
+}
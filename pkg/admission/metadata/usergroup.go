@@ -24,6 +24,7 @@ import (
 
 	"go.uber.org/zap"
 	admissionv1 "k8s.io/api/admission/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
 
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 
@@ -33,15 +34,23 @@ import (
 )
 
 type UserGroupAnnotationHandler struct {
-	conf *conf.AdmissionControllerConf
+	conf       *conf.AdmissionControllerConf
+	configMaps listersv1.ConfigMapLister
+	httpGroups *httpGroupProvider
 }
 
 func NewUserGroupAnnotationHandler(conf *conf.AdmissionControllerConf) *UserGroupAnnotationHandler {
 	return &UserGroupAnnotationHandler{
-		conf: conf,
+		conf:       conf,
+		httpGroups: newHTTPGroupProvider(conf),
 	}
 }
 
+// SetConfigMapLister sets the lister used to read the ConfigMap of the configMap group provider.
+func (u *UserGroupAnnotationHandler) SetConfigMapLister(lister listersv1.ConfigMapLister) {
+	u.configMaps = lister
+}
+
 const (
 	defaultPodAnnotationsPath = "/spec/template/metadata/annotations"
 	cronJobPodAnnotationsPath = "/spec/jobTemplate/spec/template/metadata/annotations"
@@ -142,7 +151,7 @@ func (u *UserGroupAnnotationHandler) getPatchOperation(annotations map[string]st
 
 	userGroups := &si.UserGroupInformation{
 		User:   user,
-		Groups: groups,
+		Groups: u.resolveGroups(user, groups),
 	}
 	jsonBytes, err := json.Marshal(userGroups)
 	if err != nil {
@@ -22,15 +22,20 @@ import (
 	"sync"
 
 	v1 "k8s.io/api/core/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
 	informersv1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 type NamespaceCache struct {
 	nameSpaces map[string]nsFlags
+	nsLabels   map[string]k8slabels.Set
+	gangLimits map[string]nsGangLimits
 
 	sync.RWMutex
 }
@@ -53,10 +58,19 @@ type nsFlags struct {
 	generateAppID  triState
 }
 
+// nsGangLimits defines the limits for gangs set on the namespace.
+// A maxGangSize of 0 or a nil maxResource means the limit is not set.
+type nsGangLimits struct {
+	maxGangSize int32
+	maxResource *si.Resource
+}
+
 // NewNamespaceCache creates a new cache and registers the handler for the cache with the Informer.
 func NewNamespaceCache(namespaces informersv1.NamespaceInformer) *NamespaceCache {
 	nsc := &NamespaceCache{
 		nameSpaces: make(map[string]nsFlags),
+		nsLabels:   make(map[string]k8slabels.Set),
+		gangLimits: make(map[string]nsGangLimits),
 	}
 	if namespaces != nil {
 		namespaces.Informer().AddEventHandler(&namespaceUpdateHandler{cache: nsc})
@@ -88,6 +102,32 @@ func (nsc *NamespaceCache) generateAppID(name string) triState {
 	return flag.generateAppID
 }
 
+// getGangLimits returns the maximum gang size and placeholder resources for the namespace.
+// Returns 0 and nil if the limits are not set or the namespace is not known.
+func (nsc *NamespaceCache) getGangLimits(name string) (int32, *si.Resource) {
+	nsc.RLock()
+	defer nsc.RUnlock()
+
+	limits := nsc.gangLimits[name]
+	return limits.maxGangSize, limits.maxResource
+}
+
+// matchesSelector returns true if the labels of the namespace match the selector.
+// Returns false if the selector is nil or the namespace is not known.
+func (nsc *NamespaceCache) matchesSelector(name string, selector k8slabels.Selector) bool {
+	if selector == nil {
+		return false
+	}
+	nsc.RLock()
+	defer nsc.RUnlock()
+
+	nsLabels, ok := nsc.nsLabels[name]
+	if !ok {
+		return false
+	}
+	return selector.Matches(nsLabels)
+}
+
 // namespaceExists for test only to see if the namespace has been added to the cache or not.
 func (nsc *NamespaceCache) namespaceExists(name string) bool {
 	nsc.RLock()
@@ -103,7 +143,7 @@ type namespaceUpdateHandler struct {
 }
 
 // OnAdd adds or replaces the namespace entry in the cache.
-// The cached value is only the resulting value of the annotation, not the whole namespace object.
+// The cached values are only the resulting value of the annotations and the labels, not the whole namespace object.
 // An empty string for the Name is technically possible but should not occur.
 func (h *namespaceUpdateHandler) OnAdd(obj interface{}, _ bool) {
 	ns := convert2Namespace(obj)
@@ -112,9 +152,15 @@ func (h *namespaceUpdateHandler) OnAdd(obj interface{}, _ bool) {
 	}
 
 	newFlags := getAnnotationValues(ns)
+	newLimits := nsGangLimits{
+		maxGangSize: utils.GetNamespaceMaxGangSize(ns),
+		maxResource: utils.GetNamespaceMaxPlaceholderResource(ns),
+	}
 	h.cache.Lock()
 	defer h.cache.Unlock()
 	h.cache.nameSpaces[ns.Name] = newFlags
+	h.cache.nsLabels[ns.Name] = k8slabels.Set(ns.Labels)
+	h.cache.gangLimits[ns.Name] = newLimits
 }
 
 // OnUpdate calls OnAdd for processing the namespace cache update.
@@ -141,6 +187,8 @@ func (h *namespaceUpdateHandler) OnDelete(obj interface{}) {
 	h.cache.Lock()
 	defer h.cache.Unlock()
 	delete(h.cache.nameSpaces, ns.Name)
+	delete(h.cache.nsLabels, ns.Name)
+	delete(h.cache.gangLimits, ns.Name)
 }
 
 // getAnnotationValues retrieves the annotation from the namespace.
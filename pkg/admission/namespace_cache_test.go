@@ -26,6 +26,7 @@ import (
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
@@ -95,6 +96,9 @@ func TestNamespaceHandlers(t *testing.T) {
 	assert.NilError(t, err)
 
 	assert.Equal(t, UNSET, cache.enableYuniKorn(testNS), "cache should have contained NS")
+	selector, err := k8slabels.Parse("yunikorn.apache.org/ignore=true")
+	assert.NilError(t, err)
+	assert.Check(t, !cache.matchesSelector(testNS, selector), "namespace without labels should not match")
 
 	// validate OnUpdate
 	ns2 := ns.DeepCopy()
@@ -122,6 +126,18 @@ func TestNamespaceHandlers(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, UNSET, cache.enableYuniKorn(testNS), "enable should have been cleared")
 
+	ns2 = ns.DeepCopy()
+	ns2.Labels = map[string]string{"yunikorn.apache.org/ignore": "true"}
+
+	_, err = nsInterface.Update(context.Background(), ns2, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+
+	err = utils.WaitForCondition(func() bool {
+		return cache.matchesSelector(testNS, selector)
+	}, 10*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err, "namespace labels not updated")
+	assert.Check(t, !cache.matchesSelector(testNS, nil), "nil selector should not match")
+
 	// validate OnDelete
 	err = nsInterface.Delete(context.Background(), ns.Name, metav1.DeleteOptions{})
 	assert.NilError(t, err)
@@ -130,6 +146,34 @@ func TestNamespaceHandlers(t *testing.T) {
 		return !cache.namespaceExists(testNS)
 	}, 10*time.Millisecond, 5*time.Second)
 	assert.NilError(t, err, "ns not removed from cache")
+	assert.Check(t, !cache.matchesSelector(testNS, selector), "deleted namespace should not match")
+}
+
+func TestGangLimits(t *testing.T) {
+	cache := NewNamespaceCache(nil)
+	handler := &namespaceUpdateHandler{cache: cache}
+	ns := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testNS,
+			Annotations: map[string]string{
+				constants.NamespaceMaxGangSize:            "10",
+				constants.NamespaceMaxPlaceholderResource: "{\"cpu\": \"4\"}",
+			},
+		},
+	}
+	size, res := cache.getGangLimits(testNS)
+	assert.Equal(t, size, int32(0), "not in cache")
+	assert.Assert(t, res == nil, "not in cache")
+
+	handler.OnAdd(ns, false)
+	size, res = cache.getGangLimits(testNS)
+	assert.Equal(t, size, int32(10))
+	assert.Equal(t, res.Resources["vcore"].Value, int64(4000))
+
+	handler.OnDelete(ns)
+	size, res = cache.getGangLimits(testNS)
+	assert.Equal(t, size, int32(0), "removed from cache")
+	assert.Assert(t, res == nil, "removed from cache")
 }
 
 func TestGetAnnotations(t *testing.T) {
@@ -0,0 +1,118 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	informersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+type NodeCache struct {
+	allocatable map[string]v1.ResourceList
+
+	sync.RWMutex
+}
+
+// NewNodeCache creates a new cache and registers the handler for the cache with the Informer.
+func NewNodeCache(nodes informersv1.NodeInformer) *NodeCache {
+	nc := &NodeCache{
+		allocatable: make(map[string]v1.ResourceList),
+	}
+	if nodes != nil {
+		nodes.Informer().AddEventHandler(&nodeUpdateHandler{cache: nc})
+	}
+	return nc
+}
+
+// fitsAnyNode returns true if the resources fit in the allocatable resources of at least one node.
+// A resource not reported by a node is not available on that node.
+// Returns true if no nodes are known: the nodes might not have joined the cluster yet.
+func (nc *NodeCache) fitsAnyNode(resources v1.ResourceList) bool {
+	nc.RLock()
+	defer nc.RUnlock()
+
+	if len(nc.allocatable) == 0 {
+		return true
+	}
+	for _, allocatable := range nc.allocatable {
+		if fitsNode(resources, allocatable) {
+			return true
+		}
+	}
+	return false
+}
+
+func fitsNode(resources, allocatable v1.ResourceList) bool {
+	for name, quantity := range resources {
+		available := allocatable[name]
+		if quantity.Cmp(available) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeUpdateHandler implements the K8s ResourceEventHandler interface for Node.
+type nodeUpdateHandler struct {
+	cache *NodeCache
+}
+
+// OnAdd adds or replaces the node entry in the cache.
+// Only the allocatable resources of the node are cached, not the whole node object.
+func (h *nodeUpdateHandler) OnAdd(obj interface{}, _ bool) {
+	node := convert2Node(obj)
+	if node == nil {
+		return
+	}
+
+	h.cache.Lock()
+	defer h.cache.Unlock()
+	h.cache.allocatable[node.Name] = node.Status.Allocatable.DeepCopy()
+}
+
+// OnUpdate calls OnAdd for processing the node cache update.
+func (h *nodeUpdateHandler) OnUpdate(_, newObj interface{}) {
+	h.OnAdd(newObj, false)
+}
+
+// OnDelete removes the node from the cache.
+func (h *nodeUpdateHandler) OnDelete(obj interface{}) {
+	var node *v1.Node
+	switch t := obj.(type) {
+	case *v1.Node:
+		node = t
+	case cache.DeletedFinalStateUnknown:
+		node = convert2Node(t.Obj)
+	default:
+		log.Log(log.Admission).Warn("unable to convert to Node")
+		return
+	}
+	if node == nil {
+		return
+	}
+
+	h.cache.Lock()
+	defer h.cache.Unlock()
+	delete(h.cache.allocatable, node.Name)
+}
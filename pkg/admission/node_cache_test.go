@@ -0,0 +1,103 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+)
+
+func TestFitsAnyNode(t *testing.T) {
+	cache := NewNodeCache(nil)
+	request := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("2"),
+		v1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	assert.Check(t, cache.fitsAnyNode(request), "no nodes known should fit")
+
+	cache.allocatable["small"] = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("1"),
+		v1.ResourceMemory: resource.MustParse("8Gi"),
+	}
+	assert.Check(t, !cache.fitsAnyNode(request), "cpu exceeds the only node")
+	cache.allocatable["large"] = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("2"),
+		v1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	assert.Check(t, cache.fitsAnyNode(request), "exact fit on the large node")
+	request["nvidia.com/gpu"] = resource.MustParse("1")
+	assert.Check(t, !cache.fitsAnyNode(request), "resource not reported by any node")
+	assert.Check(t, cache.fitsAnyNode(v1.ResourceList{}), "empty request should fit")
+}
+
+func TestNodeHandlers(t *testing.T) {
+	kubeClient := client.NewKubeClientMock(false)
+
+	informers := NewInformers(kubeClient, "default")
+	informers.AddNodeInformer(kubeClient)
+	cache := NewNodeCache(informers.Node)
+	informers.Start()
+	defer informers.Stop()
+
+	request := v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+		},
+	}
+	nodes := kubeClient.GetClientSet().CoreV1().Nodes()
+
+	// validate OnAdd
+	_, err := nodes.Create(context.Background(), node, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	err = utils.WaitForCondition(func() bool {
+		return !cache.fitsAnyNode(request)
+	}, 10*time.Millisecond, 10*time.Second)
+	assert.NilError(t, err)
+
+	// validate OnUpdate
+	node2 := node.DeepCopy()
+	node2.Status.Allocatable[v1.ResourceCPU] = resource.MustParse("4")
+	_, err = nodes.Update(context.Background(), node2, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+	err = utils.WaitForCondition(func() bool {
+		return cache.fitsAnyNode(request)
+	}, 10*time.Millisecond, 10*time.Second)
+	assert.NilError(t, err)
+
+	// validate OnDelete
+	_, err = nodes.Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}}, metav1.CreateOptions{})
+	assert.NilError(t, err)
+	err = nodes.Delete(context.Background(), "node-1", metav1.DeleteOptions{})
+	assert.NilError(t, err)
+	err = utils.WaitForCondition(func() bool {
+		return !cache.fitsAnyNode(request)
+	}, 10*time.Millisecond, 10*time.Second)
+	assert.NilError(t, err)
+}
@@ -0,0 +1,167 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// maxOwnerDepth limits the walk of the owner chain, protecting against reference cycles
+	maxOwnerDepth = 10
+	// ownerLookupTimeout limits the API call for an owner that is not (yet) in the cache
+	ownerLookupTimeout = 2 * time.Second
+	// kindPod is the workload kind of a pod without a controller
+	kindPod = "Pod"
+)
+
+// ownerResources are the kinds that can own a pod and can be owned themselves. The owner chain is resolved
+// through these kinds, e.g. Pod -> ReplicaSet -> Deployment or Pod -> Job -> CronJob. Other kinds, like a
+// Deployment or a SparkApplication, end the chain.
+var ownerResources = map[string]schema.GroupVersionResource{
+	kindPod:      {Group: "", Version: "v1", Resource: "pods"},
+	"ReplicaSet": {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"Job":        {Group: "batch", Version: "v1", Resource: "jobs"},
+}
+
+// OwnerCache tracks the controller of the objects that can be part of the owner chain of a pod.
+// Only the metadata of the objects is retrieved from the API server.
+type OwnerCache struct {
+	// controller reference per object, nil if the object has no controller
+	owners map[string]*metav1.OwnerReference
+	client metadata.Interface
+
+	sync.RWMutex
+}
+
+// NewOwnerCache creates a new cache and registers the handler for the cache with the Informers.
+// The informers are nil if owner kind filtering is not configured, the cache is always empty in that case.
+// The client is used to look up owners that are not in the cache, it is nil if not needed.
+func NewOwnerCache(owners map[string]informers.GenericInformer, client metadata.Interface) *OwnerCache {
+	oc := &OwnerCache{
+		owners: make(map[string]*metav1.OwnerReference),
+		client: client,
+	}
+	for kind, informer := range owners {
+		informer.Informer().AddEventHandler(&ownerUpdateHandler{cache: oc, kind: kind})
+	}
+	return oc
+}
+
+// getWorkloadKind walks the controller references of the pod and returns the kind of the top level owner.
+// The walk stops at the first owner that is not a known owner resource or that cannot be found.
+// Returns "Pod" if the pod has no controller.
+func (oc *OwnerCache) getWorkloadKind(namespace string, owner *metav1.OwnerReference) string {
+	kind := kindPod
+	for depth := 0; owner != nil && depth < maxOwnerDepth; depth++ {
+		kind = owner.Kind
+		if _, ok := ownerResources[kind]; !ok {
+			break
+		}
+		owner = oc.getController(kind, namespace, owner.Name)
+	}
+	return kind
+}
+
+// getController returns the controller of the object, nil if the object has no controller or is not found.
+func (oc *OwnerCache) getController(kind, namespace, name string) *metav1.OwnerReference {
+	oc.RLock()
+	owner, ok := oc.owners[ownerKey(kind, namespace, name)]
+	oc.RUnlock()
+	if ok || oc.client == nil {
+		return owner
+	}
+
+	// the informer might lag behind the controller that created the object, ask the API server directly
+	ctx, cancel := context.WithTimeout(context.Background(), ownerLookupTimeout)
+	defer cancel()
+	obj, err := oc.client.Resource(ownerResources[kind]).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		log.Log(log.Admission).Debug("unable to look up owner",
+			zap.String("kind", kind),
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err))
+		return nil
+	}
+	return metav1.GetControllerOf(obj)
+}
+
+func ownerKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// convert2ObjectMeta converts the object returned by the metadata informer.
+func convert2ObjectMeta(obj interface{}) *metav1.PartialObjectMetadata {
+	if deleted, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = deleted.Obj
+	}
+	meta, ok := obj.(*metav1.PartialObjectMetadata)
+	if !ok {
+		log.Log(log.Admission).Warn("unable to convert to object metadata")
+		return nil
+	}
+	return meta
+}
+
+// ownerUpdateHandler implements the K8s ResourceEventHandler interface for the owner resources of one kind.
+type ownerUpdateHandler struct {
+	cache *OwnerCache
+	kind  string
+}
+
+// OnAdd adds or replaces the controller of the object in the cache.
+func (h *ownerUpdateHandler) OnAdd(obj interface{}, _ bool) {
+	meta := convert2ObjectMeta(obj)
+	if meta == nil {
+		return
+	}
+
+	h.cache.Lock()
+	defer h.cache.Unlock()
+	h.cache.owners[ownerKey(h.kind, meta.Namespace, meta.Name)] = metav1.GetControllerOf(meta)
+}
+
+// OnUpdate calls OnAdd for processing the cache update.
+func (h *ownerUpdateHandler) OnUpdate(_, newObj interface{}) {
+	h.OnAdd(newObj, false)
+}
+
+// OnDelete removes the object from the cache.
+func (h *ownerUpdateHandler) OnDelete(obj interface{}) {
+	meta := convert2ObjectMeta(obj)
+	if meta == nil {
+		return
+	}
+
+	h.cache.Lock()
+	defer h.cache.Unlock()
+	delete(h.cache.owners, ownerKey(h.kind, meta.Namespace, meta.Name))
+}
@@ -0,0 +1,119 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func createObjectMetaForTest(apiVersion, kind, name string, owner *metav1.OwnerReference) *metav1.PartialObjectMetadata {
+	meta := &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiVersion, Kind: kind},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-ns",
+			Name:      name,
+			UID:       types.UID(name),
+		},
+	}
+	if owner != nil {
+		meta.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return meta
+}
+
+func controllerRef(apiVersion, kind, name string) *metav1.OwnerReference {
+	controller := true
+	return &metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, UID: types.UID(name), Controller: &controller}
+}
+
+func TestOwnerHandlers(t *testing.T) {
+	oc := NewOwnerCache(nil, nil)
+	handler := &ownerUpdateHandler{cache: oc, kind: "ReplicaSet"}
+
+	// validate OnAdd
+	handler.OnAdd(createObjectMetaForTest("apps/v1", "ReplicaSet", "test-rs", controllerRef("apps/v1", "Deployment", "test-deploy")), false)
+	owner := oc.getController("ReplicaSet", "test-ns", "test-rs")
+	assert.Assert(t, owner != nil, "controller of ReplicaSet not found")
+	assert.Equal(t, owner.Kind, "Deployment")
+	assert.Assert(t, oc.getController("ReplicaSet", "other-ns", "test-rs") == nil, "ReplicaSet should not exist in other namespace")
+
+	// validate OnUpdate, the controller is removed
+	handler.OnUpdate(nil, createObjectMetaForTest("apps/v1", "ReplicaSet", "test-rs", nil))
+	oc.RLock()
+	owner, ok := oc.owners[ownerKey("ReplicaSet", "test-ns", "test-rs")]
+	oc.RUnlock()
+	assert.Assert(t, ok, "ReplicaSet not in cache")
+	assert.Assert(t, owner == nil, "ReplicaSet should not have a controller")
+
+	// validate OnDelete
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Obj: createObjectMetaForTest("apps/v1", "ReplicaSet", "test-rs", nil)})
+	oc.RLock()
+	_, ok = oc.owners[ownerKey("ReplicaSet", "test-ns", "test-rs")]
+	oc.RUnlock()
+	assert.Assert(t, !ok, "ReplicaSet should have been removed")
+
+	// objects of the wrong type are ignored
+	handler.OnAdd("not an object", false)
+	handler.OnDelete(nil)
+	assert.Equal(t, len(oc.owners), 0)
+}
+
+func TestGetWorkloadKind(t *testing.T) {
+	oc := NewOwnerCache(nil, nil)
+	rsHandler := &ownerUpdateHandler{cache: oc, kind: "ReplicaSet"}
+	jobHandler := &ownerUpdateHandler{cache: oc, kind: "Job"}
+	podHandler := &ownerUpdateHandler{cache: oc, kind: kindPod}
+	rsHandler.OnAdd(createObjectMetaForTest("apps/v1", "ReplicaSet", "test-rs", controllerRef("apps/v1", "Deployment", "test-deploy")), false)
+	rsHandler.OnAdd(createObjectMetaForTest("apps/v1", "ReplicaSet", "bare-rs", nil), false)
+	jobHandler.OnAdd(createObjectMetaForTest("batch/v1", "Job", "test-job", controllerRef("batch/v1", "CronJob", "test-cron")), false)
+	podHandler.OnAdd(createObjectMetaForTest("v1", kindPod, "test-driver", controllerRef("sparkoperator.k8s.io/v1beta2", "SparkApplication", "test-spark")), false)
+
+	assert.Equal(t, oc.getWorkloadKind("test-ns", nil), kindPod)
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("apps/v1", "ReplicaSet", "test-rs")), "Deployment")
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("apps/v1", "ReplicaSet", "bare-rs")), "ReplicaSet")
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("batch/v1", "Job", "test-job")), "CronJob")
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("v1", kindPod, "test-driver")), "SparkApplication")
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("apps/v1", "DaemonSet", "test-ds")), "DaemonSet")
+	// owner not in the cache and no client to look it up
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("batch/v1", "Job", "unknown")), "Job")
+
+	// reference cycle
+	jobHandler.OnAdd(createObjectMetaForTest("batch/v1", "Job", "cycle", controllerRef("batch/v1", "Job", "cycle")), false)
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("batch/v1", "Job", "cycle")), "Job")
+}
+
+func TestGetWorkloadKindLookup(t *testing.T) {
+	scheme := metadatafake.NewTestScheme()
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, &metav1.PartialObjectMetadata{})
+	client := metadatafake.NewSimpleMetadataClient(scheme,
+		createObjectMetaForTest("batch/v1", "Job", "test-job", controllerRef("batch/v1", "CronJob", "test-cron")))
+	oc := NewOwnerCache(nil, client)
+
+	// the Job is not in the cache, it is retrieved from the API server
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("batch/v1", "Job", "test-job")), "CronJob")
+	// the Job does not exist
+	assert.Equal(t, oc.getWorkloadKind("test-ns", controllerRef("batch/v1", "Job", "unknown")), "Job")
+}
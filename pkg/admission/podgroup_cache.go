@@ -0,0 +1,139 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// podGroupResource is the PodGroup CRD of the coscheduling plugin from the kubernetes-sigs/scheduler-plugins project.
+var podGroupResource = schema.GroupVersionResource{
+	Group:    "scheduling.x-k8s.io",
+	Version:  "v1alpha1",
+	Resource: "podgroups",
+}
+
+// podGroup contains the parts of the PodGroup CRD that are translated into a task group.
+// The CRD types are not imported to prevent a dependency on the scheduler-plugins project.
+type podGroup struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              podGroupSpec `json:"spec,omitempty"`
+}
+
+type podGroupSpec struct {
+	MinMember              int32           `json:"minMember,omitempty"`
+	MinResources           v1.ResourceList `json:"minResources,omitempty"`
+	ScheduleTimeoutSeconds *int32          `json:"scheduleTimeoutSeconds,omitempty"`
+}
+
+type PodGroupCache struct {
+	podGroups map[string]*podGroup
+
+	sync.RWMutex
+}
+
+// NewPodGroupCache creates a new cache and registers the handler for the cache with the Informer.
+// The informer is nil if PodGroup support is not enabled, the cache is always empty in that case.
+func NewPodGroupCache(podGroups informers.GenericInformer) *PodGroupCache {
+	pgc := &PodGroupCache{
+		podGroups: make(map[string]*podGroup),
+	}
+	if podGroups != nil {
+		podGroups.Informer().AddEventHandler(&podGroupUpdateHandler{cache: pgc})
+	}
+	return pgc
+}
+
+// getPodGroup returns the PodGroup with the given name from the namespace, nil if the PodGroup does not exist.
+func (pgc *PodGroupCache) getPodGroup(namespace, name string) *podGroup {
+	pgc.RLock()
+	defer pgc.RUnlock()
+
+	return pgc.podGroups[podGroupKey(namespace, name)]
+}
+
+func podGroupKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// convert2PodGroup converts the unstructured object returned by the dynamic informer into a podGroup.
+func convert2PodGroup(obj interface{}) *podGroup {
+	if deleted, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = deleted.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Log(log.Admission).Warn("unable to convert to PodGroup")
+		return nil
+	}
+	pg := &podGroup{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), pg); err != nil {
+		log.Log(log.Admission).Warn("unable to convert to PodGroup",
+			zap.String("namespace", u.GetNamespace()),
+			zap.String("name", u.GetName()),
+			zap.Error(err))
+		return nil
+	}
+	return pg
+}
+
+// podGroupUpdateHandler implements the K8s ResourceEventHandler interface for PodGroup.
+type podGroupUpdateHandler struct {
+	cache *PodGroupCache
+}
+
+// OnAdd adds or replaces the PodGroup entry in the cache.
+func (h *podGroupUpdateHandler) OnAdd(obj interface{}, _ bool) {
+	pg := convert2PodGroup(obj)
+	if pg == nil {
+		return
+	}
+
+	h.cache.Lock()
+	defer h.cache.Unlock()
+	h.cache.podGroups[podGroupKey(pg.Namespace, pg.Name)] = pg
+}
+
+// OnUpdate calls OnAdd for processing the PodGroup cache update.
+func (h *podGroupUpdateHandler) OnUpdate(_, newObj interface{}) {
+	h.OnAdd(newObj, false)
+}
+
+// OnDelete removes the PodGroup from the cache.
+func (h *podGroupUpdateHandler) OnDelete(obj interface{}) {
+	pg := convert2PodGroup(obj)
+	if pg == nil {
+		return
+	}
+
+	h.cache.Lock()
+	defer h.cache.Unlock()
+	delete(h.cache.podGroups, podGroupKey(pg.Namespace, pg.Name))
+}
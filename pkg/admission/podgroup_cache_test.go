@@ -0,0 +1,119 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+)
+
+const testPG = "test-pg"
+
+func createPodGroupForTest(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": podGroupResource.GroupVersion().String(),
+		"kind":       "PodGroup",
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+		},
+		"spec": spec,
+	}}
+}
+
+func TestConvert2PodGroup(t *testing.T) {
+	assert.Assert(t, convert2PodGroup(nil) == nil, "nil object converted")
+	assert.Assert(t, convert2PodGroup("not a podgroup") == nil, "string converted")
+
+	pg := convert2PodGroup(createPodGroupForTest("test-ns", testPG, map[string]interface{}{
+		"minMember":              int64(3),
+		"minResources":           map[string]interface{}{"cpu": "3", "memory": "3Gi"},
+		"scheduleTimeoutSeconds": int64(60),
+	}))
+	assert.Assert(t, pg != nil, "PodGroup not converted")
+	assert.Equal(t, pg.Namespace, "test-ns")
+	assert.Equal(t, pg.Name, testPG)
+	assert.Equal(t, pg.Spec.MinMember, int32(3))
+	assert.Equal(t, pg.Spec.MinResources.Cpu().String(), "3")
+	assert.Equal(t, pg.Spec.MinResources.Memory().String(), "3Gi")
+	assert.Equal(t, *pg.Spec.ScheduleTimeoutSeconds, int32(60))
+
+	// invalid spec
+	pg = convert2PodGroup(createPodGroupForTest("test-ns", testPG, map[string]interface{}{
+		"minMember": "three",
+	}))
+	assert.Assert(t, pg == nil, "invalid PodGroup converted")
+}
+
+func TestPodGroupHandlers(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podGroupResource: "PodGroupList"})
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, metav1.NamespaceAll, nil)
+	informer := factory.ForResource(podGroupResource)
+	cache := NewPodGroupCache(informer)
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	go informer.Informer().Run(stopChan)
+
+	assert.Assert(t, cache.getPodGroup("test-ns", testPG) == nil, "PodGroup should not exist")
+
+	// validate OnAdd
+	podGroups := dynamicClient.Resource(podGroupResource).Namespace("test-ns")
+	_, err := podGroups.Create(context.Background(), createPodGroupForTest("test-ns", testPG, map[string]interface{}{
+		"minMember": int64(2),
+	}), metav1.CreateOptions{})
+	assert.NilError(t, err)
+
+	err = utils.WaitForCondition(func() bool {
+		return cache.getPodGroup("test-ns", testPG) != nil
+	}, 10*time.Millisecond, 10*time.Second)
+	assert.NilError(t, err)
+	assert.Assert(t, cache.getPodGroup("other-ns", testPG) == nil, "PodGroup should not exist in other namespace")
+
+	// validate OnUpdate
+	_, err = podGroups.Update(context.Background(), createPodGroupForTest("test-ns", testPG, map[string]interface{}{
+		"minMember": int64(4),
+	}), metav1.UpdateOptions{})
+	assert.NilError(t, err)
+
+	err = utils.WaitForCondition(func() bool {
+		return cache.getPodGroup("test-ns", testPG).Spec.MinMember == 4
+	}, 10*time.Millisecond, 10*time.Second)
+	assert.NilError(t, err)
+
+	// validate OnDelete
+	err = podGroups.Delete(context.Background(), testPG, metav1.DeleteOptions{})
+	assert.NilError(t, err)
+
+	err = utils.WaitForCondition(func() bool {
+		return cache.getPodGroup("test-ns", testPG) == nil
+	}, 10*time.Millisecond, 10*time.Second)
+	assert.NilError(t, err)
+}
@@ -32,6 +32,7 @@ import (
 
 type PriorityClassCache struct {
 	priorityClasses map[string]bool
+	definitions     map[string]*schedulingv1.PriorityClass
 
 	sync.RWMutex
 }
@@ -40,6 +41,7 @@ type PriorityClassCache struct {
 func NewPriorityClassCache(priorityClasses informersv1.PriorityClassInformer) *PriorityClassCache {
 	pcc := &PriorityClassCache{
 		priorityClasses: make(map[string]bool),
+		definitions:     make(map[string]*schedulingv1.PriorityClass),
 	}
 	if priorityClasses != nil {
 		priorityClasses.Informer().AddEventHandler(&priorityClassUpdateHandler{cache: pcc})
@@ -59,6 +61,14 @@ func (pcc *PriorityClassCache) isPreemptSelfAllowed(priorityClassName string) bo
 	return value
 }
 
+// getPriorityClass returns the PriorityClass with the given name, nil if the PriorityClass does not exist.
+func (pcc *PriorityClassCache) getPriorityClass(priorityClassName string) *schedulingv1.PriorityClass {
+	pcc.RLock()
+	defer pcc.RUnlock()
+
+	return pcc.definitions[priorityClassName]
+}
+
 // priorityClassExists for test only to see if the PriorityClass has been added to the cache or not.
 func (pcc *PriorityClassCache) priorityClassExists(priorityClassName string) bool {
 	pcc.RLock()
@@ -74,7 +84,7 @@ type priorityClassUpdateHandler struct {
 }
 
 // OnAdd adds or replaces the priority class entry in the cache.
-// The cached value is only the resulting value of the annotation, not the whole PriorityClass object.
+// Besides the resulting value of the annotation the PriorityClass object is cached for the priority class injection.
 // An empty string for the Name is technically possible but should not occur.
 func (h *priorityClassUpdateHandler) OnAdd(obj interface{}, _ bool) {
 	pc := utils.Convert2PriorityClass(obj)
@@ -86,6 +96,7 @@ func (h *priorityClassUpdateHandler) OnAdd(obj interface{}, _ bool) {
 	h.cache.Lock()
 	defer h.cache.Unlock()
 	h.cache.priorityClasses[pc.Name] = b
+	h.cache.definitions[pc.Name] = pc.DeepCopy()
 }
 
 // OnUpdate calls OnAdd for processing the PriorityClass cache update.
@@ -112,6 +123,7 @@ func (h *priorityClassUpdateHandler) OnDelete(obj interface{}) {
 	h.cache.Lock()
 	defer h.cache.Unlock()
 	delete(h.cache.priorityClasses, pc.Name)
+	delete(h.cache.definitions, pc.Name)
 }
 
 // getAnnotationBoolean retrieves the value from the map and returns it.
@@ -73,6 +73,7 @@ func TestPriorityClassHandlers(t *testing.T) {
 	assert.NilError(t, err)
 
 	assert.Assert(t, cache.isPreemptSelfAllowed(testPC), "exists, not set should return true")
+	assert.Assert(t, cache.getPriorityClass(testPC) != nil, "PriorityClass not cached")
 
 	// validate OnUpdate
 	priorityClass2 := priorityClass.DeepCopy()
@@ -94,6 +95,7 @@ func TestPriorityClassHandlers(t *testing.T) {
 		return !cache.priorityClassExists(testPC)
 	}, 10*time.Millisecond, 10*time.Second)
 	assert.NilError(t, err)
+	assert.Assert(t, cache.getPriorityClass(testPC) == nil, "PriorityClass still cached")
 }
 
 func TestGetBoolAnnotation(t *testing.T) {
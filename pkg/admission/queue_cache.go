@@ -0,0 +1,162 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	schedulerPartitionsURLPattern = "http://%s/ws/v1/partitions"
+	schedulerQueuesURLPattern     = "http://%s/ws/v1/partition/%s/queues"
+	queueCacheRefreshInterval     = 10 * time.Second
+	queueStateActive              = "Active"
+)
+
+// QueueCache keeps a copy of the queue states of all partitions as reported by the scheduler.
+// The states are retrieved from the scheduler REST API and refreshed in the background when the cached copy is
+// older than the refresh interval. Callers never wait for a retrieval, at most one retrieval runs at a time.
+type QueueCache struct {
+	conf        *conf.AdmissionControllerConf
+	queueStates map[string]map[string]string
+	loaded      bool
+	lastRefresh time.Time
+	refreshing  chan struct{}
+	client      *http.Client
+
+	sync.RWMutex
+}
+
+// NewQueueCache creates a new, empty, queue cache.
+func NewQueueCache(conf *conf.AdmissionControllerConf) *QueueCache {
+	return &QueueCache{
+		conf:        conf,
+		queueStates: make(map[string]map[string]string),
+		client:      &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// prime starts the first retrieval of the queue states in the background
+func (qc *QueueCache) prime() {
+	qc.refreshIfNeeded()
+}
+
+// getQueueState returns the state of the queue in the partition as known by the scheduler.
+// The second return value is false if the queue is unknown to the scheduler.
+// The last return value is false if the queues have not been retrieved from the scheduler yet,
+// in that case the existence of the queue cannot be determined.
+func (qc *QueueCache) getQueueState(partition, queueName string) (string, bool, bool) {
+	qc.refreshIfNeeded()
+
+	qc.RLock()
+	defer qc.RUnlock()
+	state, ok := qc.queueStates[strings.ToLower(partition)][strings.ToLower(queueName)]
+	return state, ok, qc.loaded
+}
+
+// refreshIfNeeded starts a reload of the queue states from the scheduler if the cached copy is stale and no
+// reload is running.
+func (qc *QueueCache) refreshIfNeeded() {
+	qc.Lock()
+	defer qc.Unlock()
+	if qc.refreshing == nil && time.Since(qc.lastRefresh) > queueCacheRefreshInterval {
+		qc.refreshing = make(chan struct{})
+		go qc.refresh(qc.refreshing)
+	}
+}
+
+// refresh reloads the queue states from the scheduler and closes done when finished.
+// Failures are logged and leave the existing content of the cache untouched.
+func (qc *QueueCache) refresh(done chan struct{}) {
+	states, err := qc.fetchQueueStates()
+
+	qc.Lock()
+	defer qc.Unlock()
+	defer close(done)
+	qc.refreshing = nil
+	// always update the refresh time to prevent hammering an unreachable scheduler
+	qc.lastRefresh = time.Now()
+	if err != nil {
+		log.Log(log.Admission).Warn("Unable to retrieve queue states from YuniKorn scheduler", zap.Error(err))
+		return
+	}
+	qc.queueStates = states
+	qc.loaded = true
+}
+
+// fetchQueueStates retrieves the queue states of all partitions, keyed by the lower-cased partition name
+func (qc *QueueCache) fetchQueueStates() (map[string]map[string]string, error) {
+	address := qc.conf.GetSchedulerServiceAddress()
+	var partitions []dao.PartitionInfo
+	if err := qc.get(fmt.Sprintf(schedulerPartitionsURLPattern, address), &partitions); err != nil {
+		return nil, err
+	}
+	states := make(map[string]map[string]string)
+	for _, partition := range partitions {
+		var root dao.PartitionQueueDAOInfo
+		if err := qc.get(fmt.Sprintf(schedulerQueuesURLPattern, address, url.PathEscape(partition.Name)), &root); err != nil {
+			return nil, err
+		}
+		queues := make(map[string]string)
+		addQueueStates(queues, &root)
+		states[strings.ToLower(partition.Name)] = queues
+	}
+	return states, nil
+}
+
+// get retrieves the URL from the scheduler and decodes the JSON response into result
+func (qc *QueueCache) get(requestURL string, result interface{}) error {
+	response, err := qc.client.Get(requestURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d from scheduler", response.StatusCode)
+	}
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(responseBytes, result)
+}
+
+// addQueueStates walks the queue hierarchy and records the state of each queue using the
+// lower-cased fully qualified queue name as the key.
+func addQueueStates(states map[string]string, queue *dao.PartitionQueueDAOInfo) {
+	if queue.QueueName != "" {
+		states[strings.ToLower(queue.QueueName)] = queue.Status
+	}
+	for i := range queue.Children {
+		addQueueStates(states, &queue.Children[i])
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package admission
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+)
+
+func TestQueueCacheRefresh(t *testing.T) {
+	var requests atomic.Int32
+	var blocked atomic.Bool
+	release := make(chan struct{})
+	handler := http.NewServeMux()
+	handler.HandleFunc("/ws/v1/partitions", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if blocked.Load() {
+			<-release
+		}
+		w.Write([]byte(`[{"name": "default"}]`)) //nolint:errcheck
+	})
+	handler.HandleFunc("/ws/v1/partition/default/queues", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(queuesData)) //nolint:errcheck
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	qc := NewQueueCache(createConfigWithOverrides(map[string]string{
+		conf.AMWebHookSchedulerServiceAddress: strings.Replace(srv.URL, "http://", "", 1),
+	}))
+	// the first call starts the retrieval without waiting for it
+	blocked.Store(true)
+	_, _, loaded := qc.getQueueState("default", "root.active")
+	assert.Assert(t, !loaded, "queues loaded before the retrieval finished")
+	qc.RLock()
+	done := qc.refreshing
+	qc.RUnlock()
+	assert.Assert(t, done != nil, "no retrieval running")
+	release <- struct{}{}
+	<-done
+	state, exists, loaded := qc.getQueueState("default", "root.active")
+	assert.Assert(t, loaded, "queues not loaded")
+	assert.Assert(t, exists, "queue not found")
+	assert.Equal(t, state, queueStateActive)
+	_, exists, _ = qc.getQueueState("gpu", "root.active")
+	assert.Assert(t, !exists, "queue found in unknown partition")
+	assert.Equal(t, requests.Load(), int32(1))
+
+	// a stale cache is refreshed once in the background, the callers get the cached states
+	blocked.Store(true)
+	qc.Lock()
+	qc.lastRefresh = time.Now().Add(-2 * queueCacheRefreshInterval)
+	qc.Unlock()
+	for i := 0; i < 5; i++ {
+		state, exists, loaded = qc.getQueueState("default", "root.stopped")
+		assert.Assert(t, loaded && exists, "cached queue not returned")
+		assert.Equal(t, state, "Stopped")
+	}
+	qc.RLock()
+	done = qc.refreshing
+	qc.RUnlock()
+	assert.Assert(t, done != nil, "no refresh running")
+	close(release)
+	<-done
+	assert.Equal(t, requests.Load(), int32(2), "stale cache not refreshed exactly once")
+}
@@ -21,11 +21,16 @@ package admission
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
@@ -48,6 +53,12 @@ func updatePodLabel(pod *v1.Pod, namespace string, generateUniqueAppIds bool, de
 		// else
 		// 		application ID convention: ${AUTO_GEN_PREFIX}-${NAMESPACE}-${AUTO_GEN_SUFFIX}
 		generatedID := generateAppID(namespace, generateUniqueAppIds)
+		// a service keeps the same application ID over the rolling updates of its controller
+		if utils.IsServiceApplicationPod(pod) {
+			if serviceID := generateServiceAppID(pod, namespace); serviceID != "" {
+				generatedID = serviceID
+			}
+		}
 		result[constants.LabelApplicationID] = generatedID
 
 		// if we generate an app ID, disable state-aware scheduling for this app
@@ -79,6 +90,41 @@ func updatePodAnnotation(pod *v1.Pod, key string, value string) map[string]strin
 	return result
 }
 
+// applyResourceDefaults fills in the requests and limits missing from the container resources.
+// A default is skipped if it would make the request larger than the limit, as the pod would be rejected.
+// The second return value is false if nothing was added.
+func applyResourceDefaults(resources v1.ResourceRequirements, defaults v1.ResourceRequirements) (v1.ResourceRequirements, bool) {
+	result := *resources.DeepCopy()
+	updated := false
+	for name, quantity := range defaults.Requests {
+		if _, ok := result.Requests[name]; ok {
+			continue
+		}
+		if limit, ok := result.Limits[name]; ok && quantity.Cmp(limit) > 0 {
+			continue
+		}
+		if result.Requests == nil {
+			result.Requests = make(v1.ResourceList)
+		}
+		result.Requests[name] = quantity.DeepCopy()
+		updated = true
+	}
+	for name, quantity := range defaults.Limits {
+		if _, ok := result.Limits[name]; ok {
+			continue
+		}
+		if request, ok := result.Requests[name]; ok && quantity.Cmp(request) < 0 {
+			continue
+		}
+		if result.Limits == nil {
+			result.Limits = make(v1.ResourceList)
+		}
+		result.Limits[name] = quantity.DeepCopy()
+		updated = true
+	}
+	return result, updated
+}
+
 func convert2Namespace(obj interface{}) *v1.Namespace {
 	if nameSpace, ok := obj.(*v1.Namespace); ok {
 		return nameSpace
@@ -87,11 +133,82 @@ func convert2Namespace(obj interface{}) *v1.Namespace {
 	return nil
 }
 
+func convert2Node(obj interface{}) *v1.Node {
+	if node, ok := obj.(*v1.Node); ok {
+		return node
+	}
+	log.Log(log.AdmissionUtils).Warn("cannot convert to *v1.Node", zap.Stringer("type", reflect.TypeOf(obj)))
+	return nil
+}
+
 // Generate a new uuid. The chance of getting duplicate are very small
 func GetNewUUID() string {
 	return uuid.NewString()
 }
 
+// generateServiceAppID generates the application ID of a service from the controller of the pod as
+// <namespace>-<controller>. A rolling update of a Deployment creates a new ReplicaSet, the pod template
+// hash is removed from the ReplicaSet name to get the same ID for all the ReplicaSets of the Deployment.
+// Returns an empty string if the pod is not controlled.
+func generateServiceAppID(pod *v1.Pod, namespace string) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return ""
+	}
+	name := owner.Name
+	if owner.Kind == "ReplicaSet" {
+		if hash := utils.GetPodLabelValue(pod, appsv1.DefaultDeploymentUniqueLabelKey); hash != "" {
+			name = strings.TrimSuffix(name, "-"+hash)
+		}
+	}
+	return fmt.Sprintf("%.63s", fmt.Sprintf("%s-%s", namespace, name))
+}
+
+// hasToleration returns true if the list contains a toleration that matches the key, operator, value and effect.
+func hasToleration(tolerations []v1.Toleration, toleration *v1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].MatchToleration(toleration) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatePodGroupAppID returns the application ID for the members of a coscheduling PodGroup:
+// <namespace>-<podgroup>, capped at 63 characters.
+func generatePodGroupAppID(namespace, podGroupName string) string {
+	return fmt.Sprintf("%.63s", fmt.Sprintf("%s-%s", namespace, podGroupName))
+}
+
+// podGroupTaskGroup translates a coscheduling PodGroup into a task group with the same name.
+// The minResources of the PodGroup cover all members, the resources of a member are an equal share.
+// If the PodGroup does not define minResources the requests of the pod are used.
+func podGroupTaskGroup(pg *podGroup, pod *v1.Pod) v1alpha1.TaskGroup {
+	minResource := make(map[string]resource.Quantity)
+	if len(pg.Spec.MinResources) > 0 {
+		for name, quantity := range pg.Spec.MinResources {
+			minResource[name.String()] = *resource.NewMilliQuantity(quantity.MilliValue()/int64(pg.Spec.MinMember), quantity.Format)
+		}
+	} else {
+		for _, container := range pod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				sum, ok := minResource[name.String()]
+				if !ok {
+					minResource[name.String()] = quantity.DeepCopy()
+					continue
+				}
+				sum.Add(quantity)
+				minResource[name.String()] = sum
+			}
+		}
+	}
+	return v1alpha1.TaskGroup{
+		Name:        pg.Name,
+		MinMember:   pg.Spec.MinMember,
+		MinResource: minResource,
+	}
+}
+
 // generate appID based on the namespace value
 // if configured to generate unique appID, generate appID as <namespace>-<pod-uid> namespace capped at 26chars
 // if not set or configured as false, appID generated as <autogen-prefix>-<namespace>-<autogen-suffix>
@@ -25,6 +25,7 @@ import (
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
@@ -175,6 +176,44 @@ func TestUpdatePodLabelForAdmissionController(t *testing.T) {
 	}
 }
 
+func TestUpdatePodLabelServiceProfile(t *testing.T) {
+	controller := true
+	pod := createMinimalTestingPod()
+	pod.Annotations = map[string]string{constants.AnnotationApplicationProfile: constants.ApplicationProfileService}
+	pod.Labels = map[string]string{"pod-template-hash": "5d4f8c7b9"}
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d4f8c7b9", UID: "UID-RS-1", Controller: &controller},
+	}
+	result := updatePodLabel(pod, "default", false, "root.default")
+	assert.Equal(t, result["applicationId"], "default-web")
+	assert.Equal(t, result["disableStateAware"], "true")
+
+	// the ReplicaSet of the next rollout generates the same ID
+	pod.Labels["pod-template-hash"] = "7c9d6b5f4"
+	pod.OwnerReferences[0].Name = "web-7c9d6b5f4"
+	result = updatePodLabel(pod, "default", true, "root.default")
+	assert.Equal(t, result["applicationId"], "default-web")
+
+	// other controllers use the name as is
+	pod.OwnerReferences[0].Kind = "StatefulSet"
+	pod.OwnerReferences[0].Name = "db"
+	result = updatePodLabel(pod, "default", false, "root.default")
+	assert.Equal(t, result["applicationId"], "default-db")
+
+	// no controller: default generated ID
+	pod.OwnerReferences = nil
+	result = updatePodLabel(pod, "default", false, "root.default")
+	assert.Equal(t, result["applicationId"], "yunikorn-default-autogen")
+
+	// batch profile ignores the controller
+	pod.Annotations[constants.AnnotationApplicationProfile] = constants.ApplicationProfileBatch
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d4f8c7b9", UID: "UID-RS-1", Controller: &controller},
+	}
+	result = updatePodLabel(pod, "default", false, "root.default")
+	assert.Equal(t, result["applicationId"], "yunikorn-default-autogen")
+}
+
 func TestDefaultQueueName(t *testing.T) {
 	defaultConf := createConfig()
 	pod := createTestingPodWithMeta()
@@ -265,3 +304,128 @@ func TestGenerateAppID(t *testing.T) {
 	assert.Equal(t, strings.HasPrefix(appID, ns[0:26]+"-"), true)
 	assert.Equal(t, len(appID), 63)
 }
+
+func TestHasToleration(t *testing.T) {
+	tolerations := []v1.Toleration{
+		{Key: "gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+		{Key: "pool", Operator: v1.TolerationOpEqual, Value: "a"},
+	}
+	assert.Assert(t, hasToleration(tolerations, &v1.Toleration{Key: "gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}))
+	assert.Assert(t, hasToleration(tolerations, &v1.Toleration{Key: "pool", Operator: v1.TolerationOpEqual, Value: "a"}))
+	assert.Assert(t, !hasToleration(tolerations, &v1.Toleration{Key: "gpu", Operator: v1.TolerationOpExists}))
+	assert.Assert(t, !hasToleration(tolerations, &v1.Toleration{Key: "pool", Operator: v1.TolerationOpEqual, Value: "b"}))
+	assert.Assert(t, !hasToleration(nil, &v1.Toleration{Key: "gpu"}))
+}
+
+func TestGeneratePodGroupAppID(t *testing.T) {
+	assert.Equal(t, generatePodGroupAppID("test-ns", "test-pg"), "test-ns-test-pg")
+	appID := generatePodGroupAppID(strings.Repeat("long", 20), "test-pg")
+	assert.Equal(t, len(appID), 63)
+}
+
+func TestPodGroupTaskGroup(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				}},
+				{Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m")},
+				}},
+			},
+		},
+	}
+
+	// minResources of the PodGroup are shared by the members
+	pg := &podGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pg"},
+		Spec: podGroupSpec{
+			MinMember:    4,
+			MinResources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("8Gi")},
+		},
+	}
+	taskGroup := podGroupTaskGroup(pg, pod)
+	assert.Equal(t, taskGroup.Name, "test-pg")
+	assert.Equal(t, taskGroup.MinMember, int32(4))
+	assert.Equal(t, len(taskGroup.MinResource), 2)
+	cpu := taskGroup.MinResource[v1.ResourceCPU.String()]
+	assert.Equal(t, cpu.String(), "500m")
+	memory := taskGroup.MinResource[v1.ResourceMemory.String()]
+	assert.Equal(t, memory.String(), "2Gi")
+
+	// no minResources: pod requests are used
+	pg.Spec.MinResources = nil
+	taskGroup = podGroupTaskGroup(pg, pod)
+	assert.Equal(t, len(taskGroup.MinResource), 2)
+	cpu = taskGroup.MinResource[v1.ResourceCPU.String()]
+	assert.Equal(t, cpu.String(), "750m")
+	memory = taskGroup.MinResource[v1.ResourceMemory.String()]
+	assert.Equal(t, memory.String(), "1Gi")
+}
+
+func TestApplyResourceDefaults(t *testing.T) {
+	defaults := v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("128Mi")},
+		Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("256Mi")},
+	}
+	tests := map[string]struct {
+		resources v1.ResourceRequirements
+		expected  v1.ResourceRequirements
+		updated   bool
+	}{
+		"no resources": {
+			resources: v1.ResourceRequirements{},
+			expected:  defaults,
+			updated:   true,
+		},
+		"all set": {
+			resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+			},
+			expected: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+			},
+			updated: false,
+		},
+		"partial requests": {
+			resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			},
+			expected: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("128Mi")},
+				Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("256Mi")},
+			},
+			updated: true,
+		},
+		"default limit below request": {
+			resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			expected: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			updated: true,
+		},
+		"default request above limit": {
+			resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+			expected: v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+			updated: false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			original := tc.resources.DeepCopy()
+			result, updated := applyResourceDefaults(tc.resources, defaults)
+			assert.Equal(t, updated, tc.updated)
+			assert.DeepEqual(t, result, tc.expected)
+			assert.DeepEqual(t, &tc.resources, original)
+		})
+	}
+}
@@ -19,17 +19,20 @@
 package admission
 
 import (
+	"bytes"
 	ctx "context"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -44,14 +47,23 @@ const (
 	secretName        = "admission-controller-secrets"
 	validatingWebhook = "yunikorn-admission-controller-validations"
 	validateConfHook  = "admission-webhook.yunikorn.validate-conf"
+	validatePodsHook  = "admission-webhook.yunikorn.validate-pods"
 	mutatingWebhook   = "yunikorn-admission-controller-mutations"
 	mutatePodsWebhook = "admission-webhook.yunikorn.mutate-pods"
 	caCert1Path       = "cacert1.pem"
 	caCert2Path       = "cacert2.pem"
 	caPrivateKey1Path = "cakey1.pem"
 	caPrivateKey2Path = "cakey2.pem"
+
+	// interval at which the secret is checked for CA certificates changed by the leader
+	caCheckInterval = time.Minute
+	// interval and number of attempts to wait for the leader to generate the CA certificates
+	caWaitInterval = 5 * time.Second
+	caWaitAttempts = 24
 )
 
+var errCACertificatesMissing = errors.New("webhook: valid CA certificates not found, waiting for the leader to generate them")
+
 // WebhookManager is used to handle all registration requirements for the webhook, including certificates
 type WebhookManager interface {
 	// LoadCACertificates is used to load CA certs from K8s secrets and update if needed
@@ -63,8 +75,21 @@ type WebhookManager interface {
 	// GenerateServerCertificate is used to generate a server certificate chain
 	GenerateServerCertificate() (*tls.Certificate, error)
 
-	// WaitForCertificateExpiration blocks until certificates need to be renewed
+	// WaitForCertificateExpiration blocks until certificates need to be renewed, the CA certificates were changed
+	// by another replica, or the replica became the leader
 	WaitForCertificateExpiration()
+
+	// SetLeader marks the replica as the one that generates and rotates the CA certificates and installs the
+	// webhooks. All other replicas only use the CA certificates from the shared secret.
+	SetLeader(leader bool)
+
+	// ManageFailurePolicy blocks until stopped, it keeps the failure policy of the mutating webhook in line with
+	// the configuration and, in Auto mode, the health of the admission controller replicas
+	ManageFailurePolicy(stop <-chan struct{})
+
+	// ReleaseFailurePolicy stops managing the failure policy and installs the mutating webhook with the Ignore
+	// policy if the replica is the leader and no other replica is ready
+	ReleaseFailurePolicy()
 }
 
 type webhookManagerImpl struct {
@@ -73,13 +98,27 @@ type webhookManagerImpl struct {
 	serviceName      string
 	clientset        kubernetes.Interface
 	conflictAttempts int
+	checkInterval    time.Duration
+	waitInterval     time.Duration
+	waitAttempts     int
+	leaderChanged    chan struct{}
+	policyInterval   time.Duration
+	identity         string
+
+	// health check counters, only used by the failure policy controller
+	healthyChecks   int
+	unhealthyChecks int
 
 	// mutable values (require locking)
-	caCert1    *x509.Certificate
-	caKey1     *rsa.PrivateKey
-	caCert2    *x509.Certificate
-	caKey2     *rsa.PrivateKey
-	expiration time.Time
+	caCert1        *x509.Certificate
+	caKey1         *rsa.PrivateKey
+	caCert2        *x509.Certificate
+	caKey2         *rsa.PrivateKey
+	caCertPems     [][]byte
+	expiration     time.Time
+	leader         bool
+	failurePolicy  v1.FailurePolicyType
+	policyReleased bool
 
 	sync.RWMutex
 }
@@ -100,10 +139,24 @@ func NewWebhookManager(conf *conf.AdmissionControllerConf) (WebhookManager, erro
 }
 
 func newWebhookManagerImpl(conf *conf.AdmissionControllerConf, clientset kubernetes.Interface) *webhookManagerImpl {
+	// the pod name of the replica, used to find the replica in the endpoints of the service
+	identity, err := os.Hostname()
+	if err != nil {
+		log.Log(log.AdmissionWebhook).Warn("Unable to get the pod name of the replica", zap.Error(err))
+	}
 	wm := &webhookManagerImpl{
 		conf:             conf,
 		clientset:        clientset,
 		conflictAttempts: 10,
+		checkInterval:    caCheckInterval,
+		waitInterval:     caWaitInterval,
+		waitAttempts:     caWaitAttempts,
+		leaderChanged:    make(chan struct{}, 1),
+		policyInterval:   policyCheckInterval,
+		identity:         identity,
+		failurePolicy:    initialFailurePolicy(conf.GetWebHookFailurePolicy()),
+		// a single replica manages the certificates, leader election revokes this until elected
+		leader: true,
 	}
 
 	return wm
@@ -111,8 +164,16 @@ func newWebhookManagerImpl(conf *conf.AdmissionControllerConf, clientset kuberne
 
 func (wm *webhookManagerImpl) LoadCACertificates() error {
 	attempts := 0
+	waits := 0
 	for {
 		updated, err := wm.loadCaCertificatesInternal()
+		if errors.Is(err, errCACertificatesMissing) && waits < wm.waitAttempts {
+			// the certificates are generated by the leader, which might not be elected yet
+			log.Log(log.AdmissionWebhook).Info("Waiting for the leader to generate the CA certificates")
+			waits++
+			time.Sleep(wm.waitInterval)
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -183,6 +244,10 @@ func (wm *webhookManagerImpl) GenerateServerCertificate() (*tls.Certificate, err
 }
 
 func (wm *webhookManagerImpl) InstallWebhooks() error {
+	if !wm.isLeader() {
+		log.Log(log.AdmissionWebhook).Info("Not the leader, webhooks are installed by the leader")
+		return nil
+	}
 	attempts := 0
 	for {
 		recheck, err := wm.installValidatingWebhook()
@@ -222,7 +287,58 @@ func (wm *webhookManagerImpl) InstallWebhooks() error {
 
 func (wm *webhookManagerImpl) WaitForCertificateExpiration() {
 	renewTime := wm.getExpiration().AddDate(0, 0, -30)
-	time.Sleep(time.Until(renewTime))
+	ticker := time.NewTicker(wm.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wm.leaderChanged:
+			log.Log(log.AdmissionWebhook).Info("Leadership changed, reloading CA certificates")
+			return
+		case <-ticker.C:
+			// only the leader renews the certificates, other replicas follow the changes in the secret
+			if wm.isLeader() && !time.Now().Before(renewTime) {
+				return
+			}
+			if wm.caCertificatesChanged() {
+				log.Log(log.AdmissionWebhook).Info("CA certificates changed by another replica")
+				return
+			}
+		}
+	}
+}
+
+func (wm *webhookManagerImpl) SetLeader(leader bool) {
+	wm.Lock()
+	changed := wm.leader != leader
+	wm.leader = leader
+	wm.Unlock()
+	if changed && leader {
+		select {
+		case wm.leaderChanged <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (wm *webhookManagerImpl) isLeader() bool {
+	wm.RLock()
+	defer wm.RUnlock()
+	return wm.leader
+}
+
+// caCertificatesChanged returns true if the CA certificates in the secret differ from the loaded certificates
+func (wm *webhookManagerImpl) caCertificatesChanged() bool {
+	secret, err := wm.clientset.CoreV1().Secrets(wm.conf.GetNamespace()).Get(ctx.Background(), secretName, metav1.GetOptions{})
+	if err != nil {
+		log.Log(log.AdmissionWebhook).Warn("Unable to retrieve admission-controller-secrets secrets", zap.Error(err))
+		return false
+	}
+	wm.RLock()
+	defer wm.RUnlock()
+	if len(wm.caCertPems) != 2 {
+		return false
+	}
+	return !bytes.Equal(secret.Data[caCert1Path], wm.caCertPems[0]) || !bytes.Equal(secret.Data[caCert2Path], wm.caCertPems[1])
 }
 
 func (wm *webhookManagerImpl) getExpiration() time.Time {
@@ -387,10 +503,14 @@ func (wm *webhookManagerImpl) checkValidatingWebhook(webhook *v1.ValidatingWebho
 		return errors.New("webhook: missing label app=yunikorn")
 	}
 
-	if len(webhook.Webhooks) != 1 {
+	if len(webhook.Webhooks) != 2 {
 		return errors.New("webhook: wrong webhook count")
 	}
 
+	if err := wm.checkValidatePodsWebhook(webhook.Webhooks[1]); err != nil {
+		return err
+	}
+
 	hook := webhook.Webhooks[0]
 	if hook.Name != validateConfHook {
 		return errors.New("webhook: wrong webhook name")
@@ -452,9 +572,76 @@ func (wm *webhookManagerImpl) checkValidatingWebhook(webhook *v1.ValidatingWebho
 	return nil
 }
 
-func (wm *webhookManagerImpl) checkMutatingWebhook(webhook *v1.MutatingWebhookConfiguration) error {
+func (wm *webhookManagerImpl) checkValidatePodsWebhook(hook v1.ValidatingWebhook) error {
 	ignore := v1.Ignore
 	none := v1.SideEffectClassNone
+	path := validatePodsURL
+
+	if hook.Name != validatePodsHook {
+		return errors.New("webhook: wrong webhook name")
+	}
+
+	svc := hook.ClientConfig.Service
+	if svc == nil {
+		return errors.New("webhook: missing service")
+	}
+
+	if svc.Name != wm.conf.GetAmServiceName() {
+		return errors.New("webhook: wrong service name")
+	}
+
+	if svc.Namespace != wm.conf.GetNamespace() {
+		return errors.New("webhook: wrong service namespace")
+	}
+
+	if svc.Path == nil || *svc.Path != path {
+		return errors.New("webhook: wrong service path")
+	}
+
+	err := wm.validateCaBundle(hook.ClientConfig.CABundle)
+	if err != nil {
+		return err
+	}
+
+	if len(hook.Rules) != 1 {
+		return errors.New("webhook: wrong rule count")
+	}
+
+	rule := hook.Rules[0]
+	if len(rule.Operations) != 1 || rule.Operations[0] != v1.Create {
+		return errors.New("webhook: wrong operations")
+	}
+
+	if len(rule.APIGroups) != 1 || rule.APIGroups[0] != "" {
+		return errors.New("webhook: wrong api groups")
+	}
+
+	if len(rule.APIVersions) != 1 || rule.APIVersions[0] != "v1" {
+		return errors.New("webhook: wrong api versions")
+	}
+
+	if len(rule.Resources) != 1 || rule.Resources[0] != "pods" {
+		return errors.New("webhook: wrong resources")
+	}
+
+	if hook.FailurePolicy == nil || *hook.FailurePolicy != ignore {
+		return errors.New("webhook: wrong failure policy")
+	}
+
+	if !equality.Semantic.DeepEqual(hook.NamespaceSelector, systemNamespaceSelector(wm.conf.GetNamespace())) {
+		return errors.New("webhook: wrong namespace selector")
+	}
+
+	if hook.SideEffects == nil || *hook.SideEffects != none {
+		return errors.New("webhook: wrong side effects")
+	}
+
+	return nil
+}
+
+func (wm *webhookManagerImpl) checkMutatingWebhook(webhook *v1.MutatingWebhookConfiguration) error {
+	policy := wm.getFailurePolicy()
+	none := v1.SideEffectClassNone
 	path := "/mutate"
 
 	value, ok := webhook.ObjectMeta.GetLabels()["app"]
@@ -520,10 +707,14 @@ func (wm *webhookManagerImpl) checkMutatingWebhook(webhook *v1.MutatingWebhookCo
 		return errors.New("webhook: wrong resources")
 	}
 
-	if hook.FailurePolicy == nil || *hook.FailurePolicy != ignore {
+	if hook.FailurePolicy == nil || *hook.FailurePolicy != policy {
 		return errors.New("webhook: wrong failure policy")
 	}
 
+	if !equality.Semantic.DeepEqual(hook.NamespaceSelector, failurePolicyNamespaceSelector(policy, wm.conf.GetNamespace())) {
+		return errors.New("webhook: wrong namespace selector")
+	}
+
 	if hook.SideEffects == nil || *hook.SideEffects != none {
 		return errors.New("webhook: wrong side effects")
 	}
@@ -580,6 +771,7 @@ func (wm *webhookManagerImpl) populateValidatingWebhook(webhook *v1.ValidatingWe
 	ignore := v1.Ignore
 	none := v1.SideEffectClassNone
 	path := "/validate-conf"
+	podsPath := validatePodsURL
 
 	namespace := wm.conf.GetNamespace()
 	serviceName := wm.conf.GetAmServiceName()
@@ -601,6 +793,21 @@ func (wm *webhookManagerImpl) populateValidatingWebhook(webhook *v1.ValidatingWe
 			AdmissionReviewVersions: []string{"v1"},
 			SideEffects:             &none,
 		},
+		{
+			Name: validatePodsHook,
+			ClientConfig: v1.WebhookClientConfig{
+				Service:  &v1.ServiceReference{Name: serviceName, Namespace: namespace, Path: &podsPath},
+				CABundle: caBundle,
+			},
+			Rules: []v1.RuleWithOperations{{
+				Operations: []v1.OperationType{v1.Create},
+				Rule:       v1.Rule{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"pods"}},
+			}},
+			FailurePolicy:           &ignore,
+			NamespaceSelector:       systemNamespaceSelector(namespace),
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             &none,
+		},
 	}
 }
 
@@ -612,7 +819,7 @@ func (wm *webhookManagerImpl) createEmptyMutatingWebhook() *v1.MutatingWebhookCo
 }
 
 func (wm *webhookManagerImpl) populateMutatingWebhook(webhook *v1.MutatingWebhookConfiguration, caBundle []byte) {
-	ignore := v1.Ignore
+	policy := wm.getFailurePolicy()
 	none := v1.SideEffectClassNone
 	path := "/mutate"
 
@@ -633,7 +840,8 @@ func (wm *webhookManagerImpl) populateMutatingWebhook(webhook *v1.MutatingWebhoo
 				Rule: v1.Rule{APIGroups: []string{"", "apps", "batch"}, APIVersions: []string{"v1"}, Resources: []string{
 					"pods", "deployments", "replicasets", "statefulsets", "daemonsets", "jobs", "cronjobs"}},
 			}},
-			FailurePolicy:           &ignore,
+			FailurePolicy:           &policy,
+			NamespaceSelector:       failurePolicyNamespaceSelector(policy, namespace),
 			AdmissionReviewVersions: []string{"v1"},
 			SideEffects:             &none,
 		},
@@ -673,16 +881,26 @@ func (wm *webhookManagerImpl) loadCaCertificatesInternal() (bool, error) {
 
 	dirty := false
 
-	cert1, key1, err := getAndValidateCertificate(secret.Data, caCert1Path, caPrivateKey1Path)
+	// the leader replaces certificates well before they expire, other replicas use them until they expire
+	cutoff := time.Now()
+	if wm.leader {
+		cutoff = cutoff.AddDate(0, 0, 90)
+	}
+
+	cert1, key1, err := getAndValidateCertificate(secret.Data, caCert1Path, caPrivateKey1Path, cutoff)
 	if err != nil {
 		log.Log(log.AdmissionWebhook).Info("Unable to get CA certificate #1", zap.Error(err))
 	}
 
-	cert2, key2, err := getAndValidateCertificate(secret.Data, caCert2Path, caPrivateKey2Path)
+	cert2, key2, err := getAndValidateCertificate(secret.Data, caCert2Path, caPrivateKey2Path, cutoff)
 	if err != nil {
 		log.Log(log.AdmissionWebhook).Info("Unable to get CA certificate #2", zap.Error(err))
 	}
 
+	if !wm.leader && (cert1 == nil || cert2 == nil) {
+		return false, errCACertificatesMissing
+	}
+
 	if cert1 == nil {
 		log.Log(log.AdmissionWebhook).Info("Generating CA Certificate #1...")
 		notAfter := time.Now().AddDate(1, 0, 0)
@@ -762,6 +980,7 @@ func (wm *webhookManagerImpl) loadCaCertificatesInternal() (bool, error) {
 	wm.caKey1 = key1
 	wm.caCert2 = cert2
 	wm.caKey2 = key2
+	wm.caCertPems = [][]byte{secret.Data[caCert1Path], secret.Data[caCert2Path]}
 	wm.expiration = cert1.NotAfter
 	if cert2.NotAfter.Before(cert1.NotAfter) {
 		wm.expiration = cert2.NotAfter
@@ -770,7 +989,7 @@ func (wm *webhookManagerImpl) loadCaCertificatesInternal() (bool, error) {
 	return false, nil
 }
 
-func getAndValidateCertificate(secretData map[string][]byte, certName string, keyName string) (*x509.Certificate, *rsa.PrivateKey, error) {
+func getAndValidateCertificate(secretData map[string][]byte, certName string, keyName string, cutoff time.Time) (*x509.Certificate, *rsa.PrivateKey, error) {
 	certPem, ok := secretData[certName]
 	if !ok {
 		return nil, nil, fmt.Errorf("webhook: no certificate found with id %s", certName)
@@ -790,10 +1009,8 @@ func getAndValidateCertificate(secretData map[string][]byte, certName string, ke
 		return nil, nil, err
 	}
 
-	cutoff := time.Now().AddDate(0, 0, 90)
-
 	if cert.NotAfter.Before(cutoff) {
-		return nil, nil, fmt.Errorf("webhook: ca certificate %s will expire within 90 days", certName)
+		return nil, nil, fmt.Errorf("webhook: ca certificate %s expires before %s", certName, cutoff.Format(time.RFC3339))
 	}
 	return cert, privateKey, nil
 }
@@ -127,6 +127,100 @@ func TestLoadCACertificatesWithConflict(t *testing.T) {
 	assert.ErrorContains(t, err, "max attempts", "update secrets didn't fail")
 }
 
+func TestLoadCACertificatesAsFollower(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+
+	secret := createSecret()
+	clientset.secrets["default/admission-controller-secrets"] = secret
+
+	// a follower never generates certificates and gives up if the leader does not create them
+	wm := newWebhookManagerImpl(createConfig(), clientset)
+	wm.SetLeader(false)
+	wm.waitAttempts = 1
+	wm.waitInterval = time.Millisecond
+	err := wm.LoadCACertificates()
+	assert.Equal(t, err, errCACertificatesMissing)
+	assert.Equal(t, len(clientset.secrets["default/admission-controller-secrets"].Data), 0, "follower changed the secret")
+
+	// a follower uses certificates close to their expiration, the leader replaces them
+	expiring, expiringKey := caCertKeyPair(t, 30)
+	addCert(t, secret, expiring, expiringKey, 1)
+	addCert(t, secret, cacert2, cakey2, 2)
+	err = wm.LoadCACertificates()
+	assert.NilError(t, err, "failed to load CA certificates")
+	assert.Assert(t, wm.caCert1.Equal(expiring), "wrong CA certificate #1")
+	assert.Assert(t, !wm.caCertificatesChanged(), "unexpected change of the CA certificates")
+
+	wm.SetLeader(true)
+	err = wm.LoadCACertificates()
+	assert.NilError(t, err, "failed to load CA certificates")
+	assert.Assert(t, !wm.caCert1.Equal(expiring), "CA certificate #1 was not replaced")
+	assert.Assert(t, wm.caCert2.Equal(cacert2), "wrong CA certificate #2")
+}
+
+func TestCACertificatesChanged(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+
+	secret := createSecret()
+	addCert(t, secret, cacert1, cakey1, 1)
+	addCert(t, secret, cacert2, cakey2, 2)
+	clientset.secrets["default/admission-controller-secrets"] = secret
+
+	wm := newWebhookManagerImpl(createConfig(), clientset)
+	assert.Assert(t, !wm.caCertificatesChanged(), "change reported before loading")
+	err := wm.LoadCACertificates()
+	assert.NilError(t, err, "failed to load CA certificates")
+	assert.Assert(t, !wm.caCertificatesChanged(), "unexpected change of the CA certificates")
+
+	// another replica rotates a certificate
+	rotated, rotatedKey := caCertKeyPair(t, 365)
+	addCert(t, secret, rotated, rotatedKey, 1)
+	assert.Assert(t, wm.caCertificatesChanged(), "change of the CA certificates not detected")
+
+	// the waiting replica wakes up to reload the certificates
+	wm.checkInterval = time.Millisecond
+	wm.WaitForCertificateExpiration()
+}
+
+func TestWaitForCertificateExpirationOnLeaderChange(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	wm.expiration = time.Now().AddDate(1, 0, 0)
+
+	wm.SetLeader(false)
+	done := make(chan struct{})
+	go func() {
+		wm.WaitForCertificateExpiration()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("wait ended without a change")
+	case <-time.After(10 * time.Millisecond):
+	}
+	wm.SetLeader(true)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("wait did not end when elected as leader")
+	}
+}
+
+func TestInstallWebhooksAsFollower(t *testing.T) {
+	testSetupOnce(t)
+	clientset := fakeClientSet()
+	wm := createPopulatedWm(clientset)
+	wm.SetLeader(false)
+
+	err := wm.InstallWebhooks()
+	assert.NilError(t, err, "install webhooks failed")
+	assert.Equal(t, len(clientset.validatingWebhooks), 0, "follower installed validating webhook")
+	assert.Equal(t, len(clientset.mutatingWebhooks), 0, "follower installed mutating webhook")
+}
+
 func TestGenerateServerCertificate(t *testing.T) {
 	testSetupOnce(t)
 	clientset := fakeClientSet()
@@ -357,6 +451,19 @@ func TestCheckValidatingWebhook(t *testing.T) {
 			some := arv1.SideEffectClassSome
 			h.Webhooks[0].SideEffects = &some
 		}},
+		{name: "WrongPodsWebhookName", expected: "webhook name", mutator: func(h *arv1.ValidatingWebhookConfiguration) {
+			h.Webhooks[1].Name = "invalid-hook-name"
+		}},
+		{name: "WrongPodsServicePath", expected: "service path", mutator: func(h *arv1.ValidatingWebhookConfiguration) {
+			var path = "/validate-conf"
+			h.Webhooks[1].ClientConfig.Service.Path = &path
+		}},
+		{name: "WrongPodsOperations", expected: "operations", mutator: func(h *arv1.ValidatingWebhookConfiguration) {
+			h.Webhooks[1].Rules[0].Operations = []arv1.OperationType{arv1.Create, arv1.Update}
+		}},
+		{name: "WrongPodsResources", expected: "resources", mutator: func(h *arv1.ValidatingWebhookConfiguration) {
+			h.Webhooks[1].Rules[0].Resources[0] = "configmaps"
+		}},
 	}
 
 	testSetupOnce(t)
@@ -532,6 +639,7 @@ func keyPem(t *testing.T, key *rsa.PrivateKey) []byte {
 type fakeClient struct {
 	fake.Clientset
 	secrets            map[string]*v1.Secret
+	endpoints          map[string]*v1.Endpoints
 	validatingWebhooks map[string]*arv1.ValidatingWebhookConfiguration
 	mutatingWebhooks   map[string]*arv1.MutatingWebhookConfiguration
 }
@@ -547,6 +655,12 @@ type fakeSecrets struct {
 	ns   string
 }
 
+type fakeEndpoints struct {
+	fakecorev1.FakeEndpoints
+	core *fakeCoreV1
+	ns   string
+}
+
 type fakeAdmissionregistrationV1 struct {
 	fakeadmissionregistrationv1.FakeAdmissionregistrationV1
 	client *fakeClient
@@ -565,6 +679,7 @@ type fakeMutatingWebhookConfigurations struct {
 func fakeClientSet() *fakeClient {
 	return &fakeClient{
 		secrets:            make(map[string]*v1.Secret),
+		endpoints:          make(map[string]*v1.Endpoints),
 		validatingWebhooks: make(map[string]*arv1.ValidatingWebhookConfiguration),
 		mutatingWebhooks:   make(map[string]*arv1.MutatingWebhookConfiguration),
 	}
@@ -578,6 +693,10 @@ func (c *fakeCoreV1) Secrets(namespace string) corev1.SecretInterface {
 	return &fakeSecrets{core: c, ns: namespace}
 }
 
+func (c *fakeCoreV1) Endpoints(namespace string) corev1.EndpointsInterface {
+	return &fakeEndpoints{core: c, ns: namespace}
+}
+
 func (c *fakeClient) AdmissionregistrationV1() admissionregistrationv1.AdmissionregistrationV1Interface {
 	return &fakeAdmissionregistrationV1{client: c}
 }
@@ -599,6 +718,14 @@ func (s *fakeSecrets) Get(_ context.Context, name string, _ metav1.GetOptions) (
 	return secret, nil
 }
 
+func (e *fakeEndpoints) Get(_ context.Context, name string, _ metav1.GetOptions) (*v1.Endpoints, error) {
+	endpoints, ok := e.core.client.endpoints[fmt.Sprintf("%s/%s", e.ns, name)]
+	if !ok {
+		return nil, notFoundErr()
+	}
+	return endpoints, nil
+}
+
 func (s *fakeSecrets) Update(_ context.Context, secret *v1.Secret, opts metav1.UpdateOptions) (*v1.Secret, error) {
 	existing, ok := s.core.client.secrets[fmt.Sprintf("%s/%s", s.ns, secret.Name)]
 	if !ok || secret.Namespace != s.ns {
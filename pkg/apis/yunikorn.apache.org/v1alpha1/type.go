@@ -66,6 +66,12 @@ type TaskGroup struct {
 	NodeSelector map[string]string            `json:"nodeSelector,omitempty"`
 	Tolerations  []v1.Toleration              `json:"tolerations,omitempty"`
 	Affinity     *v1.Affinity                 `json:"affinity,omitempty"`
+	// TopologyKey requires all members of the task group to be placed in the same topology domain,
+	// e.g. topology.kubernetes.io/zone. The placeholders are created with a matching pod affinity.
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// PlaceholderTemplate references a placeholder template of the scheduler configuration by name,
+	// the template defines the image, security context, runtime class and priority class of the placeholders.
+	PlaceholderTemplate string `json:"placeholderTemplate,omitempty"`
 }
 
 // Status part
@@ -84,10 +90,12 @@ const (
 )
 
 type ApplicationStatus struct {
-	AppID      string               `json:"appID,,omitempty"`
-	AppStatus  ApplicationStateType `json:"applicationState,omitempty"`
-	Message    string               `json:"message,omitempty"`
-	LastUpdate metav1.Time          `json:"lastUpdate,omitempty"`
+	AppID             string                       `json:"appID,,omitempty"`
+	AppStatus         ApplicationStateType         `json:"applicationState,omitempty"`
+	Message           string                       `json:"message,omitempty"`
+	LastUpdate        metav1.Time                  `json:"lastUpdate,omitempty"`
+	AllocatedResource map[string]resource.Quantity `json:"allocatedResource,omitempty"`
+	PendingAsks       int32                        `json:"pendingAsks,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
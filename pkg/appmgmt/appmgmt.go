@@ -56,14 +56,19 @@ func NewAMService(amProtocol interfaces.ApplicationManagementProtocol,
 	}
 
 	log.Log(log.ShimAppMgmt).Info("Initializing new AppMgmt service")
+	sparkManager := sparkoperator.NewManager(amProtocol, apiProvider)
 	appManager.register(
 		// registered app plugins
 		// for general apps
 		general.NewManager(apiProvider, podEventHandler),
 		// for spark operator - SparkApplication
-		sparkoperator.NewManager(amProtocol, apiProvider),
+		sparkManager,
 		// for application crds
 		application.NewAppManager(amProtocol, apiProvider))
+	// spark operator pods are handled by the general pod handler, enrich their metadata from the SparkApplication
+	if appManager.GetManagerByName(sparkManager.Name()) != nil {
+		podEventHandler.AddMetadataHandler(sparkManager)
+	}
 
 	return appManager
 }
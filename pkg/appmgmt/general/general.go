@@ -174,8 +174,19 @@ func (os *Manager) updatePod(old, new interface{}) {
 				zap.String("podUID", string(newPod.UID)),
 				zap.String("podStatus", string(newPod.Status.Phase)))
 			os.podEventHandler.HandleEvent(UpdatePod, Informers, newPod)
+			return
 		}
 	}
+
+	// triggered when the pod is resized in place
+	if !utils.IsPodTerminated(newPod) && !common.Equals(common.GetPodResource(oldPod), common.GetPodResource(newPod)) {
+		log.Log(log.ShimAppMgmtGeneral).Info("task resource updated",
+			zap.String("appType", os.Name()),
+			zap.String("namespace", newPod.Namespace),
+			zap.String("podName", newPod.Name),
+			zap.String("podUID", string(newPod.UID)))
+		os.podEventHandler.HandleEvent(ResizePod, Informers, newPod)
+	}
 }
 
 // this function is called when a pod is deleted from api-server.
@@ -254,6 +265,13 @@ func (os *Manager) GetExistingAllocation(pod *v1.Pod) *si.Allocation {
 		// for an Allocation.
 		placeholder := utils.GetPlaceholderFlagFromPodSpec(pod)
 		taskGroupName := utils.GetTaskGroupFromPodSpec(pod)
+		if taskMeta, ok := os.podEventHandler.getTaskMetadata(pod); ok && taskMeta.TaskGroupName != "" {
+			taskGroupName = taskMeta.TaskGroupName
+		}
+		partition := meta.Partition
+		if partition == "" {
+			partition = constants.DefaultPartition
+		}
 
 		creationTime := pod.CreationTimestamp.Unix()
 		meta.Tags[siCommon.CreationTime] = strconv.FormatInt(creationTime, 10)
@@ -267,7 +285,7 @@ func (os *Manager) GetExistingAllocation(pod *v1.Pod) *si.Allocation {
 			ApplicationID:    meta.ApplicationID,
 			Placeholder:      placeholder,
 			TaskGroupName:    taskGroupName,
-			PartitionName:    constants.DefaultPartition,
+			PartitionName:    partition,
 		}
 	}
 	return nil
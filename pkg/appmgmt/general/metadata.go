@@ -81,6 +81,11 @@ func getAppMetadata(pod *v1.Pod, recovery bool) (interfaces.ApplicationMetadata,
 	if isStateAwareDisabled(pod) {
 		tags[siCommon.AppTagStateAwareDisable] = "true"
 	}
+	// a service never waits for more pods before it runs
+	if utils.IsServiceApplicationPod(pod) {
+		tags[constants.AnnotationApplicationProfile] = constants.ApplicationProfileService
+		tags[siCommon.AppTagStateAwareDisable] = "true"
+	}
 
 	// attach imagePullSecrets if present
 	secrets := pod.Spec.ImagePullSecrets
@@ -114,6 +119,16 @@ func getAppMetadata(pod *v1.Pod, recovery bool) (interfaces.ApplicationMetadata,
 	schedulingPolicyParams := utils.GetSchedulingPolicyParam(pod)
 	tags[constants.AnnotationSchedulingPolicyParam] = pod.Annotations[constants.AnnotationSchedulingPolicyParam]
 
+	maxRunDuration, err := utils.GetAppMaxRunDuration(pod)
+	if err != nil {
+		log.Log(log.ShimAppMgmtGeneral).Warn("invalid max run duration for pod, the application is not limited",
+			zap.String("namespace", pod.Namespace),
+			zap.String("name", pod.Name),
+			zap.Error(err))
+		events.GetRecorder().Eventf(pod, nil, v1.EventTypeWarning, "MaxRunDurationError", "MaxRunDurationError",
+			"invalid max run duration, reason: %s", err.Error())
+	}
+
 	var creationTime int64
 	if recovery {
 		creationTime = pod.CreationTimestamp.Unix()
@@ -122,6 +137,7 @@ func getAppMetadata(pod *v1.Pod, recovery bool) (interfaces.ApplicationMetadata,
 	return interfaces.ApplicationMetadata{
 		ApplicationID:              appID,
 		QueueName:                  utils.GetQueueNameFromPod(pod),
+		Partition:                  utils.GetPartitionFromPod(pod),
 		User:                       user,
 		Groups:                     groups,
 		Tags:                       tags,
@@ -129,5 +145,7 @@ func getAppMetadata(pod *v1.Pod, recovery bool) (interfaces.ApplicationMetadata,
 		OwnerReferences:            ownerReferences,
 		SchedulingPolicyParameters: schedulingPolicyParams,
 		CreationTime:               creationTime,
+		StrictFIFO:                 utils.GetPodAnnotationValue(pod, constants.AnnotationStrictFIFO) == constants.True,
+		MaxRunDuration:             maxRunDuration,
 	}, true
 }
@@ -20,6 +20,7 @@ package general
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
@@ -27,6 +28,7 @@ import (
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 )
 
 func TestGetTaskMetadata(t *testing.T) {
@@ -94,12 +96,15 @@ func TestGetAppMetadata(t *testing.T) { //nolint:funlen
 			Namespace: "default",
 			UID:       "UID-POD-00001",
 			Labels: map[string]string{
-				"applicationId": "app00001",
-				"queue":         "root.a",
+				"applicationId":          "app00001",
+				"queue":                  "root.a",
+				constants.LabelPartition: "gpu",
 			},
 			Annotations: map[string]string{
 				constants.AnnotationTaskGroups:            taskGroupInfo,
 				constants.AnnotationSchedulingPolicyParam: "gangSchedulingStyle=Soft",
+				constants.AnnotationStrictFIFO:            "true",
+				constants.AnnotationAppMaxRunDuration:     "2h",
 			},
 		},
 		Spec: v1.PodSpec{
@@ -115,6 +120,7 @@ func TestGetAppMetadata(t *testing.T) { //nolint:funlen
 	assert.Equal(t, ok, true)
 	assert.Equal(t, app.ApplicationID, "app00001")
 	assert.Equal(t, app.QueueName, "root.a")
+	assert.Equal(t, app.Partition, "gpu")
 	assert.Equal(t, app.User, constants.DefaultUser)
 	assert.Equal(t, app.Tags["namespace"], "default")
 	assert.Equal(t, app.Tags[constants.AnnotationSchedulingPolicyParam], "gangSchedulingStyle=Soft")
@@ -125,6 +131,17 @@ func TestGetAppMetadata(t *testing.T) { //nolint:funlen
 	assert.Equal(t, app.TaskGroups[0].MinResource["cpu"], resource.MustParse("2"))
 	assert.Equal(t, app.TaskGroups[0].MinResource["memory"], resource.MustParse("1Gi"))
 	assert.Equal(t, app.SchedulingPolicyParameters.GetGangSchedulingStyle(), "Soft")
+	assert.Equal(t, app.StrictFIFO, true)
+	assert.Equal(t, app.MaxRunDuration, 2*time.Hour)
+	assert.Equal(t, app.Tags[constants.AnnotationApplicationProfile], "")
+
+	// service profile
+	servicePod := pod.DeepCopy()
+	servicePod.Annotations[constants.AnnotationApplicationProfile] = constants.ApplicationProfileService
+	app, ok = getAppMetadata(servicePod, false)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, app.Tags[constants.AnnotationApplicationProfile], constants.ApplicationProfileService)
+	assert.Equal(t, app.Tags[siCommon.AppTagStateAwareDisable], "true")
 
 	pod = v1.Pod{
 		TypeMeta: apis.TypeMeta{
@@ -162,6 +179,7 @@ func TestGetAppMetadata(t *testing.T) { //nolint:funlen
 	assert.Equal(t, app.Tags["namespace"], "app-namespace-01")
 	assert.DeepEqual(t, len(app.TaskGroups), 0)
 	assert.Equal(t, app.SchedulingPolicyParameters.GetGangSchedulingStyle(), "Hard")
+	assert.Equal(t, app.MaxRunDuration, time.Duration(0))
 
 	pod = v1.Pod{
 		TypeMeta: apis.TypeMeta{
@@ -29,16 +29,25 @@ import (
 )
 
 type PodEventHandler struct {
-	recoveryRunning bool
-	amProtocol      interfaces.ApplicationManagementProtocol
-	asyncEvents     []*podAsyncEvent
+	recoveryRunning  bool
+	amProtocol       interfaces.ApplicationManagementProtocol
+	asyncEvents      []*podAsyncEvent
+	metadataHandlers []PodMetadataHandler
 	sync.Mutex
 }
 
+// PodMetadataHandler allows an operator plugin to enrich the metadata derived from a pod
+// before the application or task is added. Each method returns true if the metadata was changed.
+type PodMetadataHandler interface {
+	UpdateAppMetadata(pod *v1.Pod, meta *interfaces.ApplicationMetadata) bool
+	UpdateTaskMetadata(pod *v1.Pod, meta *interfaces.TaskMetadata) bool
+}
+
 const (
 	AddPod = iota
 	UpdatePod
 	DeletePod
+	ResizePod
 )
 
 const (
@@ -83,6 +92,8 @@ func (p *PodEventHandler) internalHandle(eventType EventType, source EventSource
 		return p.updatePod(pod)
 	case DeletePod:
 		return p.deletePod(pod)
+	case ResizePod:
+		return p.resizePod(pod)
 	default:
 		log.Log(log.ShimAppMgmtGeneral).Error("Unknown pod eventType", zap.Int("eventType", int(eventType)))
 		return nil
@@ -120,7 +131,7 @@ func (p *PodEventHandler) addPod(pod *v1.Pod, eventSource EventSource) interface
 	var appExists bool
 
 	// add app
-	if appMeta, ok := getAppMetadata(pod, recovery); ok {
+	if appMeta, ok := p.getAppMetadata(pod, recovery); ok {
 		// check if app already exist
 		if app := p.amProtocol.GetApplication(appMeta.ApplicationID); app == nil {
 			managedApp = p.amProtocol.AddApplication(&interfaces.AddApplicationRequest{
@@ -133,7 +144,7 @@ func (p *PodEventHandler) addPod(pod *v1.Pod, eventSource EventSource) interface
 	}
 
 	// add task
-	if taskMeta, ok := getTaskMetadata(pod); ok {
+	if taskMeta, ok := p.getTaskMetadata(pod); ok {
 		if app := p.amProtocol.GetApplication(taskMeta.ApplicationID); app != nil {
 			if _, taskErr := app.GetTask(string(pod.UID)); taskErr != nil {
 				p.amProtocol.AddTask(&interfaces.AddTaskRequest{
@@ -156,7 +167,7 @@ func (p *PodEventHandler) addPod(pod *v1.Pod, eventSource EventSource) interface
 }
 
 func (p *PodEventHandler) updatePod(pod *v1.Pod) interfaces.ManagedApp {
-	if taskMeta, ok := getTaskMetadata(pod); ok {
+	if taskMeta, ok := p.getTaskMetadata(pod); ok {
 		if app := p.amProtocol.GetApplication(taskMeta.ApplicationID); app != nil {
 			p.amProtocol.NotifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
 			return app
@@ -165,8 +176,18 @@ func (p *PodEventHandler) updatePod(pod *v1.Pod) interfaces.ManagedApp {
 	return nil
 }
 
+func (p *PodEventHandler) resizePod(pod *v1.Pod) interfaces.ManagedApp {
+	if taskMeta, ok := p.getTaskMetadata(pod); ok {
+		if app := p.amProtocol.GetApplication(taskMeta.ApplicationID); app != nil {
+			p.amProtocol.NotifyTaskResourceUpdate(taskMeta.ApplicationID, taskMeta.TaskID, pod)
+			return app
+		}
+	}
+	return nil
+}
+
 func (p *PodEventHandler) deletePod(pod *v1.Pod) interfaces.ManagedApp {
-	if taskMeta, ok := getTaskMetadata(pod); ok {
+	if taskMeta, ok := p.getTaskMetadata(pod); ok {
 		if app := p.amProtocol.GetApplication(taskMeta.ApplicationID); app != nil {
 			p.amProtocol.NotifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
 			return app
@@ -175,6 +196,38 @@ func (p *PodEventHandler) deletePod(pod *v1.Pod) interfaces.ManagedApp {
 	return nil
 }
 
+// AddMetadataHandler registers a handler that is called for every pod after the generic metadata is built.
+// Handlers must be registered before the handler starts processing events.
+func (p *PodEventHandler) AddMetadataHandler(handler PodMetadataHandler) {
+	p.metadataHandlers = append(p.metadataHandlers, handler)
+}
+
+func (p *PodEventHandler) getAppMetadata(pod *v1.Pod, recovery bool) (interfaces.ApplicationMetadata, bool) {
+	appMeta, ok := getAppMetadata(pod, recovery)
+	if !ok {
+		return appMeta, false
+	}
+	for _, handler := range p.metadataHandlers {
+		if handler.UpdateAppMetadata(pod, &appMeta) {
+			break
+		}
+	}
+	return appMeta, true
+}
+
+func (p *PodEventHandler) getTaskMetadata(pod *v1.Pod) (interfaces.TaskMetadata, bool) {
+	taskMeta, ok := getTaskMetadata(pod)
+	if !ok {
+		return taskMeta, false
+	}
+	for _, handler := range p.metadataHandlers {
+		if handler.UpdateTaskMetadata(pod, &taskMeta) {
+			break
+		}
+	}
+	return taskMeta, true
+}
+
 func NewPodEventHandler(amProtocol interfaces.ApplicationManagementProtocol, recoveryRunning bool) *PodEventHandler {
 	asyncEvents := make([]*podAsyncEvent, 0)
 	podEventHandler := &PodEventHandler{
@@ -26,6 +26,7 @@ import (
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
 	"github.com/apache/yunikorn-k8shim/pkg/cache"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 )
@@ -119,3 +120,41 @@ func newPod(name string) *v1.Pod {
 		},
 	}
 }
+
+type testMetadataHandler struct {
+	value string
+}
+
+func (h *testMetadataHandler) UpdateAppMetadata(_ *v1.Pod, meta *interfaces.ApplicationMetadata) bool {
+	meta.QueueName = h.value
+	return true
+}
+
+func (h *testMetadataHandler) UpdateTaskMetadata(_ *v1.Pod, meta *interfaces.TaskMetadata) bool {
+	meta.TaskGroupName = h.value
+	return true
+}
+
+func TestMetadataHandler(t *testing.T) {
+	amProtocol := cache.NewMockedAMProtocol()
+	podEventHandler := NewPodEventHandler(amProtocol, false)
+	podEventHandler.AddMetadataHandler(&testMetadataHandler{value: "root.first"})
+	// not called: the first handler already updated the metadata
+	podEventHandler.AddMetadataHandler(&testMetadataHandler{value: "root.second"})
+
+	pod := newPod("pod1")
+	appMeta, ok := podEventHandler.getAppMetadata(pod, false)
+	assert.Assert(t, ok)
+	assert.Equal(t, appMeta.ApplicationID, appID)
+	assert.Equal(t, appMeta.QueueName, "root.first")
+	taskMeta, ok := podEventHandler.getTaskMetadata(pod)
+	assert.Assert(t, ok)
+	assert.Equal(t, taskMeta.TaskGroupName, "root.first")
+
+	// pods without an application are never passed to the handlers
+	pod.Spec.SchedulerName = ""
+	_, ok = podEventHandler.getAppMetadata(pod, false)
+	assert.Assert(t, !ok)
+	_, ok = podEventHandler.getTaskMetadata(pod)
+	assert.Assert(t, !ok)
+}
@@ -19,6 +19,8 @@
 package interfaces
 
 import (
+	"time"
+
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -62,6 +64,10 @@ type ApplicationManagementProtocol interface {
 	// this will trigger some consequent operations for a given task,
 	// e.g release the allocations that assigned for this task.
 	NotifyTaskComplete(appID, taskID string)
+
+	// notify the context that the resources of a task have changed,
+	// e.g. the pod of the task was resized in place.
+	NotifyTaskResourceUpdate(appID, taskID string, pod *v1.Pod)
 }
 
 type AddApplicationRequest struct {
@@ -75,6 +81,7 @@ type AddTaskRequest struct {
 type ApplicationMetadata struct {
 	ApplicationID              string
 	QueueName                  string
+	Partition                  string
 	User                       string
 	Tags                       map[string]string
 	Groups                     []string
@@ -82,6 +89,8 @@ type ApplicationMetadata struct {
 	OwnerReferences            []metav1.OwnerReference
 	SchedulingPolicyParameters *SchedulingPolicyParameters
 	CreationTime               int64
+	StrictFIFO                 bool
+	MaxRunDuration             time.Duration
 }
 
 type TaskMetadata struct {
@@ -0,0 +1,264 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sparkoperator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/spark-on-k8s-operator/pkg/apis/sparkoperator.k8s.io/v1beta2"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	driverTaskGroup   = "spark-driver"
+	executorTaskGroup = "spark-executor"
+
+	// defaults as used by Spark on Kubernetes
+	defaultMemory                  = "1g"
+	defaultExecutorInstances       = 2
+	defaultJVMOverheadFactor       = 0.1
+	defaultNonJVMOverhead          = 0.4
+	minMemoryOverheadMiB           = 384
+	bytesPerMiB                    = 1024 * 1024
+	defaultCores             int32 = 1
+)
+
+// UpdateAppMetadata implements general#PodMetadataHandler.
+// Pods created by the spark operator are grouped into one application with the driver pod as the originator.
+// If gang scheduling is enabled and the pod does not define task groups, the driver and executor task groups
+// are derived from the SparkApplication spec.
+func (os *Manager) UpdateAppMetadata(pod *v1.Pod, meta *interfaces.ApplicationMetadata) bool {
+	role, ok := sparkOperatorRole(pod)
+	if !ok {
+		return false
+	}
+	if role == constants.SparkLabelRoleExecutor {
+		for _, ref := range pod.OwnerReferences {
+			if ref.Kind == "Pod" {
+				meta.OwnerReferences = []metav1.OwnerReference{ref}
+				break
+			}
+		}
+	}
+	if conf.GetSchedulerConf().DisableGangScheduling || len(meta.TaskGroups) > 0 || os.crdLister == nil {
+		return true
+	}
+	appName := pod.Labels[constants.SparkOperatorLabelAppName]
+	sparkApp, err := os.crdLister.SparkApplications(pod.Namespace).Get(appName)
+	if err != nil {
+		log.Log(log.ShimAppMgmtSparkOperator).Debug("unable to find SparkApplication for pod",
+			zap.String("namespace", pod.Namespace),
+			zap.String("name", pod.Name),
+			zap.String("sparkApplication", appName),
+			zap.Error(err))
+		return true
+	}
+	taskGroups, err := getTaskGroups(sparkApp)
+	if err != nil {
+		log.Log(log.ShimAppMgmtSparkOperator).Warn("unable to derive task groups from SparkApplication",
+			zap.String("namespace", pod.Namespace),
+			zap.String("sparkApplication", appName),
+			zap.Error(err))
+		return true
+	}
+	tgJSON, err := json.Marshal(taskGroups)
+	if err != nil {
+		log.Log(log.ShimAppMgmtSparkOperator).Warn("unable to marshal task groups",
+			zap.String("sparkApplication", appName),
+			zap.Error(err))
+		return true
+	}
+	meta.TaskGroups = taskGroups
+	meta.Tags[constants.AnnotationTaskGroups] = string(tgJSON)
+	return true
+}
+
+// UpdateTaskMetadata implements general#PodMetadataHandler.
+// The task group of a spark operator pod is derived from its role if it is not set explicitly.
+func (os *Manager) UpdateTaskMetadata(pod *v1.Pod, meta *interfaces.TaskMetadata) bool {
+	role, ok := sparkOperatorRole(pod)
+	if !ok {
+		return false
+	}
+	if conf.GetSchedulerConf().DisableGangScheduling || meta.TaskGroupName != "" || meta.Placeholder {
+		return true
+	}
+	if role == constants.SparkLabelRoleDriver {
+		meta.TaskGroupName = driverTaskGroup
+	} else {
+		meta.TaskGroupName = executorTaskGroup
+	}
+	return true
+}
+
+// sparkOperatorRole returns the spark role of a pod that was created for a SparkApplication
+func sparkOperatorRole(pod *v1.Pod) (string, bool) {
+	if pod.Labels[constants.SparkOperatorLabelAppName] == "" {
+		return "", false
+	}
+	role := pod.Labels[constants.SparkLabelRole]
+	if role != constants.SparkLabelRoleDriver && role != constants.SparkLabelRoleExecutor {
+		return "", false
+	}
+	return role, true
+}
+
+// multipliers to convert JVM memory units into MiB
+var jvmMemoryUnits = map[string]float64{
+	"k": 1.0 / 1024,
+	"m": 1,
+	"g": 1024,
+	"t": 1024 * 1024,
+}
+
+func getTaskGroups(app *v1beta2.SparkApplication) ([]v1alpha1.TaskGroup, error) {
+	driverRes, err := getMinResource(app, app.Spec.Driver.SparkPodSpec, app.Spec.Driver.CoreRequest)
+	if err != nil {
+		return nil, fmt.Errorf("driver: %w", err)
+	}
+	executorRes, err := getMinResource(app, app.Spec.Executor.SparkPodSpec, app.Spec.Executor.CoreRequest)
+	if err != nil {
+		return nil, fmt.Errorf("executor: %w", err)
+	}
+	taskGroups := []v1alpha1.TaskGroup{
+		newTaskGroup(driverTaskGroup, 1, driverRes, app.Spec.Driver.SparkPodSpec),
+	}
+	if executors := getMinExecutors(app); executors > 0 {
+		taskGroups = append(taskGroups, newTaskGroup(executorTaskGroup, executors, executorRes, app.Spec.Executor.SparkPodSpec))
+	}
+	return taskGroups, nil
+}
+
+func newTaskGroup(name string, minMember int32, minResource map[string]resource.Quantity, spec v1beta2.SparkPodSpec) v1alpha1.TaskGroup {
+	return v1alpha1.TaskGroup{
+		Name:         name,
+		MinMember:    minMember,
+		MinResource:  minResource,
+		NodeSelector: spec.NodeSelector,
+		Tolerations:  spec.Tolerations,
+		Affinity:     spec.Affinity,
+	}
+}
+
+// getMinExecutors returns the number of executors the application needs to start
+func getMinExecutors(app *v1beta2.SparkApplication) int32 {
+	if da := app.Spec.DynamicAllocation; da != nil && da.Enabled {
+		if da.InitialExecutors != nil {
+			return *da.InitialExecutors
+		}
+		if da.MinExecutors != nil {
+			return *da.MinExecutors
+		}
+		return 0
+	}
+	if app.Spec.Executor.Instances != nil {
+		return *app.Spec.Executor.Instances
+	}
+	return defaultExecutorInstances
+}
+
+// getMinResource calculates the resources requested by a driver or executor pod the same way Spark does:
+// the CPU request is the core request or the number of cores, the memory is the heap plus the overhead.
+func getMinResource(app *v1beta2.SparkApplication, spec v1beta2.SparkPodSpec, coreRequest *string) (map[string]resource.Quantity, error) {
+	var cpu resource.Quantity
+	if coreRequest != nil {
+		var err error
+		if cpu, err = resource.ParseQuantity(*coreRequest); err != nil {
+			return nil, fmt.Errorf("invalid core request %s: %w", *coreRequest, err)
+		}
+	} else {
+		cores := defaultCores
+		if spec.Cores != nil {
+			cores = *spec.Cores
+		}
+		cpu = *resource.NewQuantity(int64(cores), resource.DecimalSI)
+	}
+
+	memory := defaultMemory
+	if spec.Memory != nil {
+		memory = *spec.Memory
+	}
+	memoryMiB, err := parseJVMMemoryMiB(memory)
+	if err != nil {
+		return nil, err
+	}
+	var overheadMiB int64
+	if spec.MemoryOverhead != nil {
+		if overheadMiB, err = parseJVMMemoryMiB(*spec.MemoryOverhead); err != nil {
+			return nil, err
+		}
+	} else {
+		factor, err := getMemoryOverheadFactor(app)
+		if err != nil {
+			return nil, err
+		}
+		overheadMiB = int64(math.Max(factor*float64(memoryMiB), minMemoryOverheadMiB))
+	}
+
+	return map[string]resource.Quantity{
+		v1.ResourceCPU.String():    cpu,
+		v1.ResourceMemory.String(): *resource.NewQuantity((memoryMiB+overheadMiB)*bytesPerMiB, resource.BinarySI),
+	}, nil
+}
+
+func getMemoryOverheadFactor(app *v1beta2.SparkApplication) (float64, error) {
+	if app.Spec.MemoryOverheadFactor != nil {
+		factor, err := strconv.ParseFloat(*app.Spec.MemoryOverheadFactor, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid memory overhead factor %s: %w", *app.Spec.MemoryOverheadFactor, err)
+		}
+		return factor, nil
+	}
+	if app.Spec.Type == v1beta2.PythonApplicationType || app.Spec.Type == v1beta2.RApplicationType {
+		return defaultNonJVMOverhead, nil
+	}
+	return defaultJVMOverheadFactor, nil
+}
+
+// parseJVMMemoryMiB converts a JVM memory string, e.g. 512m or 2g, into MiB.
+// A value without a unit is interpreted as MiB, as Spark does.
+func parseJVMMemoryMiB(value string) (int64, error) {
+	mem := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "b")
+	multiplier := 1.0
+	for suffix, m := range jvmMemoryUnits {
+		if strings.HasSuffix(mem, suffix) {
+			mem = strings.TrimSuffix(mem, suffix)
+			multiplier = m
+			break
+		}
+	}
+	size, err := strconv.ParseInt(mem, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid memory value %s", value)
+	}
+	return int64(math.Ceil(float64(size) * multiplier)), nil
+}
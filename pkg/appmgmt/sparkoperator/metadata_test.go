@@ -0,0 +1,231 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sparkoperator
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/spark-on-k8s-operator/pkg/apis/sparkoperator.k8s.io/v1beta2"
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	crListers "github.com/apache/yunikorn-k8shim/pkg/sparkclient/listers/sparkoperator.k8s.io/v1beta2"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func newSparkPod(name, role string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid-" + name),
+			Labels: map[string]string{
+				constants.SparkLabelAppID:           "spark-0001",
+				constants.SparkLabelRole:            role,
+				constants.SparkOperatorLabelAppName: "spark-pi",
+			},
+		},
+	}
+}
+
+func newSparkApp() *v1beta2.SparkApplication {
+	return &v1beta2.SparkApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spark-pi",
+			Namespace: "default",
+		},
+		Spec: v1beta2.SparkApplicationSpec{
+			Type: v1beta2.ScalaApplicationType,
+			Driver: v1beta2.DriverSpec{
+				SparkPodSpec: v1beta2.SparkPodSpec{
+					Cores:        int32Ptr(1),
+					Memory:       strPtr("512m"),
+					NodeSelector: map[string]string{"disk": "ssd"},
+				},
+			},
+			Executor: v1beta2.ExecutorSpec{
+				SparkPodSpec: v1beta2.SparkPodSpec{
+					Memory: strPtr("4g"),
+				},
+				Instances:   int32Ptr(3),
+				CoreRequest: strPtr("500m"),
+			},
+		},
+	}
+}
+
+func newTestManager(t *testing.T, apps ...*v1beta2.SparkApplication) *Manager {
+	indexer := k8sCache.NewIndexer(k8sCache.MetaNamespaceKeyFunc, k8sCache.Indexers{})
+	for _, app := range apps {
+		assert.NilError(t, indexer.Add(app))
+	}
+	return &Manager{crdLister: crListers.NewSparkApplicationLister(indexer)}
+}
+
+func TestParseJVMMemoryMiB(t *testing.T) {
+	tests := map[string]int64{
+		"512":   512,
+		"512m":  512,
+		"512MB": 512,
+		"2g":    2048,
+		"1t":    1024 * 1024,
+		"1536k": 2,
+	}
+	for value, expected := range tests {
+		mib, err := parseJVMMemoryMiB(value)
+		assert.NilError(t, err, value)
+		assert.Equal(t, mib, expected, value)
+	}
+	for _, value := range []string{"", "abc", "5x", "-1g"} {
+		_, err := parseJVMMemoryMiB(value)
+		assert.ErrorContains(t, err, "invalid memory value", value)
+	}
+}
+
+func TestGetTaskGroups(t *testing.T) {
+	app := newSparkApp()
+	taskGroups, err := getTaskGroups(app)
+	assert.NilError(t, err)
+	assert.Equal(t, len(taskGroups), 2)
+
+	driver := taskGroups[0]
+	assert.Equal(t, driver.Name, driverTaskGroup)
+	assert.Equal(t, driver.MinMember, int32(1))
+	assert.Equal(t, quantity(driver.MinResource, "cpu"), "1")
+	// 512Mi + the minimum overhead of 384Mi
+	assert.Equal(t, quantity(driver.MinResource, "memory"), "896Mi")
+	assert.Equal(t, driver.NodeSelector["disk"], "ssd")
+
+	executor := taskGroups[1]
+	assert.Equal(t, executor.Name, executorTaskGroup)
+	assert.Equal(t, executor.MinMember, int32(3))
+	assert.Equal(t, quantity(executor.MinResource, "cpu"), "500m")
+	// 4096Mi + 10% overhead
+	assert.Equal(t, quantity(executor.MinResource, "memory"), "4505Mi")
+
+	// python apps use a higher overhead factor
+	app.Spec.Type = v1beta2.PythonApplicationType
+	taskGroups, err = getTaskGroups(app)
+	assert.NilError(t, err)
+	assert.Equal(t, quantity(taskGroups[1].MinResource, "memory"), "5734Mi")
+
+	// explicit overhead
+	app.Spec.Executor.MemoryOverhead = strPtr("1g")
+	taskGroups, err = getTaskGroups(app)
+	assert.NilError(t, err)
+	assert.Equal(t, quantity(taskGroups[1].MinResource, "memory"), "5Gi")
+
+	// dynamic allocation starts with the initial executors
+	app.Spec.DynamicAllocation = &v1beta2.DynamicAllocation{Enabled: true, MinExecutors: int32Ptr(1)}
+	taskGroups, err = getTaskGroups(app)
+	assert.NilError(t, err)
+	assert.Equal(t, taskGroups[1].MinMember, int32(1))
+	app.Spec.DynamicAllocation.InitialExecutors = int32Ptr(4)
+	taskGroups, err = getTaskGroups(app)
+	assert.NilError(t, err)
+	assert.Equal(t, taskGroups[1].MinMember, int32(4))
+	app.Spec.DynamicAllocation = &v1beta2.DynamicAllocation{Enabled: true}
+	taskGroups, err = getTaskGroups(app)
+	assert.NilError(t, err)
+	assert.Equal(t, len(taskGroups), 1)
+
+	app.Spec.MemoryOverheadFactor = strPtr("x")
+	app.Spec.Driver.MemoryOverhead = nil
+	_, err = getTaskGroups(app)
+	assert.ErrorContains(t, err, "invalid memory overhead factor")
+}
+
+func TestUpdateAppMetadata(t *testing.T) {
+	mgr := newTestManager(t, newSparkApp())
+
+	// not a spark operator pod
+	pod := newSparkPod("driver", constants.SparkLabelRoleDriver)
+	delete(pod.Labels, constants.SparkOperatorLabelAppName)
+	meta := &interfaces.ApplicationMetadata{Tags: map[string]string{}}
+	assert.Assert(t, !mgr.UpdateAppMetadata(pod, meta))
+	assert.Assert(t, meta.TaskGroups == nil)
+
+	driver := newSparkPod("driver", constants.SparkLabelRoleDriver)
+	meta = &interfaces.ApplicationMetadata{Tags: map[string]string{}}
+	assert.Assert(t, mgr.UpdateAppMetadata(driver, meta))
+	assert.Equal(t, len(meta.TaskGroups), 2)
+	assert.Assert(t, meta.Tags[constants.AnnotationTaskGroups] != "")
+
+	// the executor uses the driver as the originator
+	executor := newSparkPod("exec-1", constants.SparkLabelRoleExecutor)
+	executor.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: driver.Name, UID: driver.UID}}
+	meta = &interfaces.ApplicationMetadata{Tags: map[string]string{}}
+	assert.Assert(t, mgr.UpdateAppMetadata(executor, meta))
+	assert.Equal(t, len(meta.OwnerReferences), 1)
+	assert.Equal(t, meta.OwnerReferences[0].UID, driver.UID)
+
+	// task groups defined on the pod are not replaced
+	meta = &interfaces.ApplicationMetadata{Tags: map[string]string{}, TaskGroups: []v1alpha1.TaskGroup{{Name: "custom", MinMember: 1, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}}}}
+	assert.Assert(t, mgr.UpdateAppMetadata(driver, meta))
+	assert.Equal(t, len(meta.TaskGroups), 1)
+
+	// unknown SparkApplication
+	driver.Labels[constants.SparkOperatorLabelAppName] = "unknown"
+	meta = &interfaces.ApplicationMetadata{Tags: map[string]string{}}
+	assert.Assert(t, mgr.UpdateAppMetadata(driver, meta))
+	assert.Assert(t, meta.TaskGroups == nil)
+}
+
+func TestUpdateTaskMetadata(t *testing.T) {
+	mgr := newTestManager(t)
+	meta := &interfaces.TaskMetadata{}
+	assert.Assert(t, mgr.UpdateTaskMetadata(newSparkPod("driver", constants.SparkLabelRoleDriver), meta))
+	assert.Equal(t, meta.TaskGroupName, driverTaskGroup)
+	meta = &interfaces.TaskMetadata{}
+	assert.Assert(t, mgr.UpdateTaskMetadata(newSparkPod("exec-1", constants.SparkLabelRoleExecutor), meta))
+	assert.Equal(t, meta.TaskGroupName, executorTaskGroup)
+	meta = &interfaces.TaskMetadata{TaskGroupName: "custom"}
+	assert.Assert(t, mgr.UpdateTaskMetadata(newSparkPod("exec-1", constants.SparkLabelRoleExecutor), meta))
+	assert.Equal(t, meta.TaskGroupName, "custom")
+
+	// gang scheduling disabled
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.DisableGangScheduling = true
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+	meta = &interfaces.TaskMetadata{}
+	assert.Assert(t, mgr.UpdateTaskMetadata(newSparkPod("driver", constants.SparkLabelRoleDriver), meta))
+	assert.Equal(t, meta.TaskGroupName, "")
+}
+
+func quantity(resources map[string]resource.Quantity, name string) string {
+	q := resources[name]
+	return q.String()
+}
@@ -28,13 +28,15 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	crcClientSet "github.com/apache/yunikorn-k8shim/pkg/sparkclient/clientset/versioned"
 	crInformers "github.com/apache/yunikorn-k8shim/pkg/sparkclient/informers/externalversions"
+	crListers "github.com/apache/yunikorn-k8shim/pkg/sparkclient/listers/sparkoperator.k8s.io/v1beta2"
 )
 
-// Manager implements interfaces#Recoverable, interfaces#AppManager
+// Manager implements interfaces#Recoverable, interfaces#AppManager and general#PodMetadataHandler
 type Manager struct {
 	amProtocol         interfaces.ApplicationManagementProtocol
 	apiProvider        client.APIProvider
 	crdInformer        k8sCache.SharedIndexInformer
+	crdLister          crListers.SparkApplicationLister
 	crdInformerFactory crInformers.SharedInformerFactory
 	stopCh             chan struct{}
 }
@@ -67,6 +69,7 @@ func (os *Manager) ServiceInit() error {
 		crClient, 0, factoryOpts...)
 	os.crdInformerFactory.Sparkoperator().V1beta2().SparkApplications().Informer()
 	os.crdInformer = os.crdInformerFactory.Sparkoperator().V1beta2().SparkApplications().Informer()
+	os.crdLister = os.crdInformerFactory.Sparkoperator().V1beta2().SparkApplications().Lister()
 	os.crdInformer.AddEventHandler(k8sCache.ResourceEventHandlerFuncs{
 		UpdateFunc: os.updateApplication,
 		DeleteFunc: os.deleteApplication,
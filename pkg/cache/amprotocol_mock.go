@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
 	"github.com/apache/yunikorn-k8shim/pkg/common/test"
@@ -139,6 +140,16 @@ func (m *MockedAMProtocol) NotifyApplicationFail(appID string) {
 	}
 }
 
+func (m *MockedAMProtocol) NotifyTaskResourceUpdate(appID, taskID string, pod *v1.Pod) {
+	if app := m.GetApplication(appID); app != nil {
+		if task, err := app.GetTask(taskID); err == nil {
+			if t, ok := task.(*Task); ok {
+				t.updateResource(pod)
+			}
+		}
+	}
+}
+
 func (m *MockedAMProtocol) NotifyTaskComplete(appID, taskID string) {
 	if app := m.GetApplication(appID); app != nil {
 		if task, err := app.GetTask(taskID); err == nil {
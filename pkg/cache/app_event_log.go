@@ -0,0 +1,333 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// appEventLogRetainedApps is the number of removed applications for which the event log is kept
+	appEventLogRetainedApps = 1000
+	// appEventLogFileSuffix is the suffix of the files the event logs are persisted in
+	appEventLogFileSuffix = ".jsonl"
+)
+
+// AppEvent is a single entry in the event log of an application
+type AppEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	TaskID  string    `json:"taskID,omitempty"`
+	Message string    `json:"message"`
+}
+
+// GetApplicationEventLog returns the events of the application, oldest first. Returns false if the event log is
+// disabled or the application has no events.
+func (ctx *Context) GetApplicationEventLog(appID string) ([]AppEvent, bool) {
+	return ctx.eventLog.get(appID)
+}
+
+// appEventLog keeps the most recent events of each application: the submission, the state changes, the placeholders
+// and the released allocations. The log of an application is kept after the application is removed so the whole
+// lifetime of the application can be retrieved, the logs of the oldest removed applications are dropped first.
+// If a directory is set the events are also appended to a file per application, which survives a restart.
+type appEventLog struct {
+	size       int                   // maximum number of events per application
+	dir        string                // directory the logs are persisted in, empty if not persisted
+	logs       map[string][]AppEvent // appID -> events, oldest first
+	written    map[string]int        // appID -> events in the file, kept and dropped ones
+	removed    []string              // removed applications, oldest first
+	removedSet map[string]bool       // removed applications for a quick lookup
+	lock       sync.Mutex
+}
+
+// newAppEventLog creates the event log keeping the given number of events per application.
+// Returns nil if the size is not positive. Logs persisted before a restart are retained like removed applications.
+func newAppEventLog(size int, dir string) *appEventLog {
+	if size <= 0 {
+		return nil
+	}
+	l := &appEventLog{
+		size:       size,
+		dir:        strings.TrimSpace(dir),
+		logs:       make(map[string][]AppEvent),
+		written:    make(map[string]int),
+		removedSet: make(map[string]bool),
+	}
+	if l.dir == "" {
+		return l
+	}
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		log.Log(log.ShimContext).Warn("unable to create application event log directory, events are not persisted",
+			zap.String("dir", l.dir),
+			zap.Error(err))
+		l.dir = ""
+		return l
+	}
+	for _, appID := range l.persistedApps() {
+		l.removed = append(l.removed, appID)
+		l.removedSet[appID] = true
+	}
+	l.evict()
+	return l
+}
+
+// record adds an event to the log of the application, the oldest event is dropped if the log is full
+func (l *appEventLog) record(appID, eventType, taskID, messageFmt string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	event := AppEvent{
+		Time:    time.Now(),
+		Type:    eventType,
+		TaskID:  taskID,
+		Message: fmt.Sprintf(messageFmt, args...),
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	events, ok := l.logs[appID]
+	if !ok && l.dir != "" {
+		// continue the log persisted before a restart
+		events = l.load(appID)
+	}
+	// an application that is added again after it was removed is kept
+	l.unremove(appID)
+	events = append(events, event)
+	if len(events) > l.size {
+		events = events[len(events)-l.size:]
+	}
+	l.logs[appID] = events
+	if l.dir != "" {
+		l.persist(appID, event)
+	}
+}
+
+// get returns the events of the application, oldest first. Returns false if the application has no events.
+func (l *appEventLog) get(appID string) ([]AppEvent, bool) {
+	if l == nil {
+		return nil, false
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	events, ok := l.logs[appID]
+	if !ok && l.dir != "" {
+		events = l.load(appID)
+		ok = len(events) > 0
+	}
+	if !ok {
+		return nil, false
+	}
+	result := make([]AppEvent, len(events))
+	copy(result, events)
+	return result, true
+}
+
+// remove marks the application as removed, the log is kept until more than the retained number of
+// applications are removed after it.
+func (l *appEventLog) remove(appID string) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, ok := l.logs[appID]; !ok {
+		return
+	}
+	l.unremove(appID)
+	l.removed = append(l.removed, appID)
+	l.removedSet[appID] = true
+	l.evict()
+}
+
+// unremove takes the application off the removed list, must be called while holding the lock
+func (l *appEventLog) unremove(appID string) {
+	if !l.removedSet[appID] {
+		return
+	}
+	delete(l.removedSet, appID)
+	for i, id := range l.removed {
+		if id == appID {
+			l.removed = append(l.removed[:i], l.removed[i+1:]...)
+			return
+		}
+	}
+}
+
+// evict drops the logs of the oldest removed applications, must be called while holding the lock
+func (l *appEventLog) evict() {
+	for len(l.removed) > appEventLogRetainedApps {
+		appID := l.removed[0]
+		l.removed = l.removed[1:]
+		delete(l.removedSet, appID)
+		delete(l.logs, appID)
+		delete(l.written, appID)
+		if l.dir != "" {
+			if err := os.Remove(l.path(appID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Log(log.ShimContext).Warn("unable to remove application event log",
+					zap.String("appID", appID),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// path returns the file the log of the application is persisted in
+func (l *appEventLog) path(appID string) string {
+	return filepath.Join(l.dir, url.PathEscape(appID)+appEventLogFileSuffix)
+}
+
+// persist appends the event to the file of the application. The file is rewritten from the events in memory
+// once it holds twice the number of events that are kept, which bounds the size of the file.
+// Must be called while holding the lock.
+func (l *appEventLog) persist(appID string, event AppEvent) {
+	var err error
+	if l.written[appID] >= 2*l.size {
+		err = l.rewrite(appID)
+	} else {
+		err = l.append(appID, event)
+	}
+	if err != nil {
+		log.Log(log.ShimContext).Warn("unable to write application event log",
+			zap.String("appID", appID),
+			zap.Error(err))
+	}
+}
+
+func (l *appEventLog) append(appID string, event AppEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path(appID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	l.written[appID]++
+	return file.Close()
+}
+
+func (l *appEventLog) rewrite(appID string) error {
+	var sb strings.Builder
+	for _, event := range l.logs[appID] {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+		sb.WriteByte('\n')
+	}
+	// write to a temporary file first to never leave a partial log behind
+	path := l.path(appID)
+	if err := os.WriteFile(path+".tmp", []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	l.written[appID] = len(l.logs[appID])
+	return nil
+}
+
+// load reads the most recent events of the application from its file. Lines that cannot be parsed are skipped.
+// Must be called while holding the lock.
+func (l *appEventLog) load(appID string) []AppEvent {
+	file, err := os.Open(l.path(appID))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Log(log.ShimContext).Warn("unable to read application event log",
+				zap.String("appID", appID),
+				zap.Error(err))
+		}
+		return nil
+	}
+	defer file.Close()
+	events := make([]AppEvent, 0)
+	written := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		written++
+		var event AppEvent
+		if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	l.written[appID] = written
+	if len(events) > l.size {
+		events = events[len(events)-l.size:]
+	}
+	return events
+}
+
+// persistedApps returns the applications with a log in the directory, ordered by the last modification of the log
+func (l *appEventLog) persistedApps() []string {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		log.Log(log.ShimContext).Warn("unable to list application event logs",
+			zap.String("dir", l.dir),
+			zap.Error(err))
+		return nil
+	}
+	type persisted struct {
+		appID    string
+		modified time.Time
+	}
+	logs := make([]persisted, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, appEventLogFileSuffix) {
+			continue
+		}
+		appID, err := url.PathUnescape(strings.TrimSuffix(name, appEventLogFileSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, persisted{appID: appID, modified: info.ModTime()})
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].modified.Before(logs[j].modified)
+	})
+	apps := make([]string, len(logs))
+	for i, p := range logs {
+		apps[i] = p.appID
+	}
+	return apps
+}
@@ -0,0 +1,171 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+func eventTypes(events []AppEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestAppEventLogRecord(t *testing.T) {
+	// disabled log is safe to use
+	assert.Assert(t, newAppEventLog(0, "") == nil)
+	var disabled *appEventLog
+	disabled.record("app-1", "Submitted", "", "submitted")
+	disabled.remove("app-1")
+	_, ok := disabled.get("app-1")
+	assert.Assert(t, !ok, "disabled log returned events")
+
+	eventLog := newAppEventLog(3, "")
+	_, ok = eventLog.get("app-1")
+	assert.Assert(t, !ok, "unknown application returned events")
+	for i := 0; i < 4; i++ {
+		eventLog.record("app-1", fmt.Sprintf("event-%d", i), "task-1", "event %d of %s", i, "app-1")
+	}
+	events, ok := eventLog.get("app-1")
+	assert.Assert(t, ok, "events not found")
+	assert.DeepEqual(t, eventTypes(events), []string{"event-1", "event-2", "event-3"})
+	assert.Equal(t, events[2].Message, "event 3 of app-1")
+	assert.Equal(t, events[2].TaskID, "task-1")
+
+	// the returned events are a copy
+	events[0].Type = "changed"
+	events, _ = eventLog.get("app-1")
+	assert.Equal(t, events[0].Type, "event-1")
+}
+
+func TestAppEventLogRetention(t *testing.T) {
+	eventLog := newAppEventLog(5, "")
+	eventLog.record("app-0", "Submitted", "", "submitted")
+	eventLog.remove("app-0")
+	_, ok := eventLog.get("app-0")
+	assert.Assert(t, ok, "removed application not retained")
+
+	// an application that is added again is no longer removed
+	eventLog.record("app-0", "Submitted", "", "submitted again")
+	assert.Equal(t, len(eventLog.removed), 0)
+
+	for i := 1; i <= appEventLogRetainedApps; i++ {
+		appID := fmt.Sprintf("app-%d", i)
+		eventLog.record(appID, "Submitted", "", "submitted")
+		eventLog.remove(appID)
+	}
+	_, ok = eventLog.get("app-0")
+	assert.Assert(t, ok, "application that is not removed dropped")
+	_, ok = eventLog.get("app-1")
+	assert.Assert(t, ok, "removed application dropped before the limit")
+
+	eventLog.remove("app-0")
+	_, ok = eventLog.get("app-1")
+	assert.Assert(t, !ok, "oldest removed application not dropped")
+	assert.Equal(t, len(eventLog.logs), appEventLogRetainedApps)
+}
+
+func TestAppEventLogPersisted(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "events")
+	eventLog := newAppEventLog(2, dir)
+	for i := 0; i < 3; i++ {
+		eventLog.record("ns/app-1", fmt.Sprintf("event-%d", i), "", "event %d", i)
+	}
+	eventLog.record("app-2", "Submitted", "", "submitted")
+	_, err := os.Stat(filepath.Join(dir, "ns%2Fapp-1.jsonl"))
+	assert.NilError(t, err, "event log not persisted")
+
+	// the file is rewritten once it holds twice the kept events
+	eventLog.record("ns/app-1", "event-3", "", "event 3")
+	eventLog.record("ns/app-1", "event-4", "", "event 4")
+	data, err := os.ReadFile(filepath.Join(dir, "ns%2Fapp-1.jsonl"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(splitLines(string(data))), 2)
+
+	// a new log reads the persisted events, the least recently modified log is dropped first
+	now := time.Now()
+	assert.NilError(t, os.Chtimes(filepath.Join(dir, "app-2.jsonl"), now.Add(-time.Minute), now.Add(-time.Minute)))
+	assert.NilError(t, os.Chtimes(filepath.Join(dir, "ns%2Fapp-1.jsonl"), now, now))
+	restarted := newAppEventLog(2, dir)
+	assert.DeepEqual(t, restarted.removed, []string{"app-2", "ns/app-1"})
+	events, ok := restarted.get("ns/app-1")
+	assert.Assert(t, ok, "persisted events not found")
+	assert.DeepEqual(t, eventTypes(events), []string{"event-3", "event-4"})
+	restarted.record("ns/app-1", "event-5", "", "event 5")
+	events, _ = restarted.get("ns/app-1")
+	assert.DeepEqual(t, eventTypes(events), []string{"event-4", "event-5"})
+	assert.DeepEqual(t, restarted.removed, []string{"app-2"})
+}
+
+func splitLines(data string) []string {
+	lines := make([]string, 0)
+	start := 0
+	for i, c := range data {
+		if c == '\n' {
+			lines = append(lines, data[start:i])
+			start = i + 1
+		}
+	}
+	return lines
+}
+
+func TestApplicationEventLog(t *testing.T) {
+	const appID = "app00001"
+	context := initContextForTest()
+	context.eventLog = newAppEventLog(10, "")
+
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags:          map[string]string{constants.AppTagNamespace: "default"},
+		},
+	})
+	app := context.getApplication(appID).(*Application) //nolint:errcheck
+	assert.NilError(t, app.handle(NewSubmitApplicationEvent(appID)))
+	app.lock.Lock()
+	app.publishAppEvent(v1.EventTypeNormal, "ApplicationAccepted", "Application %s is accepted", appID)
+	app.lock.Unlock()
+
+	events, ok := context.GetApplicationEventLog(appID)
+	assert.Assert(t, ok, "application events not found")
+	assert.DeepEqual(t, eventTypes(events), []string{"Submitted", "StateChange", "ApplicationAccepted"})
+	assert.Equal(t, events[0].Message, "Application app00001 submitted to queue root.a by user test-user")
+	assert.Equal(t, events[1].Message, "Application state changed from New to Submitted on SubmitApplication")
+
+	// the log outlives the application
+	assert.NilError(t, context.RemoveApplication(appID))
+	_, ok = context.GetApplicationEventLog(appID)
+	assert.Assert(t, ok, "events of removed application not found")
+}
@@ -0,0 +1,109 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// appTagLabelKey returns the label key for the application tag. The domain of a tag is dropped, the key is
+// empty if the tag cannot be turned into a valid label key.
+func appTagLabelKey(tag string) string {
+	name := tag[strings.LastIndex(tag, "/")+1:]
+	key := constants.LabelAppTagPrefix + name
+	if name == "" || len(validation.IsQualifiedName(key)) != 0 {
+		return ""
+	}
+	return key
+}
+
+// getTagLabels returns the labels for the application tags in the allow list. The queue of the application is
+// returned for the "queue" entry. Tags that are not set, or with a value that is not a valid label value like
+// the JSON encoded namespace quota, are skipped.
+func (app *Application) getTagLabels(allowList []string) map[string]string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	labels := make(map[string]string)
+	for _, tag := range allowList {
+		value, ok := app.tags[tag]
+		if tag == constants.LabelQueueName {
+			value, ok = app.queue, app.queue != ""
+		}
+		if !ok {
+			continue
+		}
+		key := appTagLabelKey(tag)
+		if key == "" || len(validation.IsValidLabelValue(value)) != 0 {
+			log.Log(log.ShimCacheApplication).Debug("application tag cannot be used as a pod label",
+				zap.String("appID", app.applicationID),
+				zap.String("tag", tag),
+				zap.String("value", value))
+			continue
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// propagateAppTagLabels copies the allowed tags of the application onto the pod of the task as labels, which
+// allows grouping pods by application and queue without querying the scheduler. The labels are only updated
+// if they differ from the labels of the pod. The update is done in the background: a failure is logged and
+// does not affect the task.
+// This is called while processing a task event: the task lock is held and the application lock must not be
+// taken, the tags are read in the background too.
+func (task *Task) propagateAppTagLabels() {
+	allowList := conf.GetSchedulerConf().GetAppTagLabels()
+	if len(allowList) == 0 {
+		return
+	}
+	go task.updateAppTagLabels(allowList)
+}
+
+func (task *Task) updateAppTagLabels(allowList []string) {
+	labels := task.application.getTagLabels(allowList)
+	pod := task.GetTaskPod()
+	for key, value := range labels {
+		if current, ok := pod.Labels[key]; ok && current == value {
+			delete(labels, key)
+		}
+	}
+	if len(labels) == 0 {
+		return
+	}
+	if _, err := task.UpdateTaskPod(pod.DeepCopy(), func(pod *v1.Pod) {
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		for key, value := range labels {
+			pod.Labels[key] = value
+		}
+	}); err != nil {
+		log.Log(log.ShimCacheTask).Warn("failed to add application tag labels to pod",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+	}
+}
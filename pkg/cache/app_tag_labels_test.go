@@ -0,0 +1,137 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func TestAppTagLabelKey(t *testing.T) {
+	assert.Equal(t, appTagLabelKey("namespace"), "tag.yunikorn.apache.org/namespace")
+	assert.Equal(t, appTagLabelKey("namespace.parentqueue"), "tag.yunikorn.apache.org/namespace.parentqueue")
+	assert.Equal(t, appTagLabelKey("yunikorn.apache.org/namespace.quota"), "tag.yunikorn.apache.org/namespace.quota")
+	assert.Equal(t, appTagLabelKey("yunikorn.apache.org/"), "")
+	assert.Equal(t, appTagLabelKey("invalid name"), "")
+}
+
+func TestGetTagLabels(t *testing.T) {
+	app := NewApplication("app01", "root.default", "bob", testGroups, map[string]string{
+		constants.AppTagNamespace:             "ns-1",
+		constants.AppTagNamespaceParentQueue:  "root.parent",
+		"yunikorn.apache.org/namespace.quota": `{"cpu": "1"}`,
+	}, newMockSchedulerAPI())
+
+	assert.Equal(t, len(app.getTagLabels(nil)), 0)
+	labels := app.getTagLabels([]string{constants.AppTagNamespace, constants.LabelQueueName, "yunikorn.apache.org/namespace.quota", "unknown"})
+	assert.DeepEqual(t, labels, map[string]string{
+		"tag.yunikorn.apache.org/namespace": "ns-1",
+		"tag.yunikorn.apache.org/queue":     "root.default",
+	})
+
+	app.queue = ""
+	labels = app.getTagLabels([]string{constants.AppTagNamespaceParentQueue, constants.LabelQueueName})
+	assert.DeepEqual(t, labels, map[string]string{
+		"tag.yunikorn.apache.org/namespace.parentqueue": "root.parent",
+	})
+}
+
+func TestPropagateAppTagLabels(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.AppTagLabels = "namespace,queue"
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	updated := make(chan map[string]string, 1)
+	apiProvider.MockUpdatePodFn(func(pod *v1.Pod, podMutator func(pod *v1.Pod)) (*v1.Pod, error) {
+		podMutator(pod)
+		updated <- pod.Labels
+		return pod, nil
+	})
+	app := NewApplication("app01", "root.default", "bob", testGroups,
+		map[string]string{constants.AppTagNamespace: "default"}, newMockSchedulerAPI())
+	pod := &v1.Pod{ObjectMeta: apis.ObjectMeta{
+		Name:      "pod-tags",
+		Namespace: "default",
+		UID:       "UID-00001",
+		Labels:    map[string]string{"app": "sleep", "tag.yunikorn.apache.org/namespace": "default"},
+	}}
+	task := NewTask("task01", app, context, pod)
+
+	task.propagateAppTagLabels()
+	select {
+	case labels := <-updated:
+		assert.DeepEqual(t, labels, map[string]string{
+			"app":                               "sleep",
+			"tag.yunikorn.apache.org/namespace": "default",
+			"tag.yunikorn.apache.org/queue":     "root.default",
+		})
+	case <-time.After(time.Second):
+		t.Fatal("pod labels not updated")
+	}
+
+	// no update if the pod already has the labels
+	podCopy := task.GetTaskPod().DeepCopy()
+	podCopy.Labels["tag.yunikorn.apache.org/queue"] = "root.default"
+	task.lock.Lock()
+	task.pod = podCopy
+	task.lock.Unlock()
+	task.propagateAppTagLabels()
+	select {
+	case <-updated:
+		t.Fatal("pod updated with unchanged labels")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the application lock is not taken while the task lock is held
+	podCopy = task.GetTaskPod().DeepCopy()
+	delete(podCopy.Labels, "tag.yunikorn.apache.org/queue")
+	task.lock.Lock()
+	task.pod = podCopy
+	task.lock.Unlock()
+	app.lock.Lock()
+	done := make(chan struct{})
+	go func() {
+		task.lock.Lock()
+		defer task.lock.Unlock()
+		task.propagateAppTagLabels()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("propagating labels blocked on the application lock")
+	}
+	app.lock.Unlock()
+	select {
+	case labels := <-updated:
+		assert.Equal(t, labels["tag.yunikorn.apache.org/queue"], "root.default")
+	case <-time.After(time.Second):
+		t.Fatal("pod labels not updated")
+	}
+}
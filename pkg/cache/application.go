@@ -20,10 +20,12 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
@@ -32,13 +34,17 @@ import (
 
 	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/eventstream"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
@@ -61,7 +67,14 @@ type Application struct {
 	placeholderAsk             *si.Resource // total placeholder request for the app (all task groups)
 	placeholderTimeoutInSec    int64
 	schedulingStyle            string
+	strictFIFO                 bool                   // submit tasks in pod creation order
+	service                    bool                   // service profile, set on creation and never changed
 	originatingTask            interfaces.ManagedTask // Original Pod which creates the requests
+	maxRunDuration             time.Duration          // wall-clock limit of the application, zero if not limited
+	maxRunTimer                *time.Timer            // started when the application starts running
+	maxRunExceeded             bool                   // the application ran longer than the max run duration
+	eventLog                   *appEventLog           // shared event log of the applications, nil if disabled
+	queueNodesUnavailable      bool                   // reported that no node matches the node selector of the queue
 }
 
 func (app *Application) String() string {
@@ -87,6 +100,7 @@ func NewApplication(appID, queueName, user string, groups []string, tags map[str
 		schedulerAPI:            scheduler,
 		placeholderTimeoutInSec: 0,
 		schedulingStyle:         constants.SchedulingPolicyStyleParamDefault,
+		service:                 tags[constants.AnnotationApplicationProfile] == constants.ApplicationProfileService,
 	}
 	return app
 }
@@ -139,6 +153,18 @@ func (app *Application) GetQueue() string {
 	return app.queue
 }
 
+// SetQueue changes the queue of an application that is not submitted to the core yet. The core does not support
+// moving an application to another queue: an error is returned once the application is submitted.
+func (app *Application) SetQueue(queue string) error {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.sm.Current() != ApplicationStates().New {
+		return fmt.Errorf("queue of application %s cannot be changed in state %s", app.applicationID, app.sm.Current())
+	}
+	app.queue = queue
+	return nil
+}
+
 func (app *Application) GetUser() string {
 	app.lock.RLock()
 	defer app.lock.RUnlock()
@@ -220,6 +246,18 @@ func (app *Application) setSchedulingStyle(schedulingStyle string) {
 	app.schedulingStyle = schedulingStyle
 }
 
+func (app *Application) setStrictFIFO(strictFIFO bool) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	app.strictFIFO = strictFIFO
+}
+
+func (app *Application) isStrictFIFO() bool {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.strictFIFO
+}
+
 func (app *Application) setOriginatingTask(task interfaces.ManagedTask) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -232,6 +270,60 @@ func (app *Application) GetOriginatingTask() interfaces.ManagedTask {
 	return app.originatingTask
 }
 
+// isOriginatorReplacement returns true if the pod replaces the originating pod of the application, e.g. a Spark
+// driver that is recreated by its controller. The pod must reuse the name of the originating pod, or be created by
+// the same controller after the originating task has terminated.
+func (app *Application) isOriginatorReplacement(pod *v1.Pod) bool {
+	if pod == nil || utils.GetPlaceholderFlagFromPodSpec(pod) {
+		return false
+	}
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	if app.originatingTask == nil {
+		return false
+	}
+	previous := app.originatingTask.GetTaskPod()
+	if previous == nil || previous.UID == pod.UID || previous.Namespace != pod.Namespace {
+		return false
+	}
+	// pod names are unique within a namespace, the previous pod is gone
+	if previous.Name == pod.Name {
+		return true
+	}
+	previousController := metav1.GetControllerOf(previous)
+	controller := metav1.GetControllerOf(pod)
+	if previousController == nil || controller == nil || previousController.UID != controller.UID {
+		return false
+	}
+	task, ok := app.originatingTask.(*Task)
+	return ok && task.isTerminated()
+}
+
+// replaceOriginatingTask makes the task the originating task of the application after the previous originating pod
+// was replaced. Placeholders created from now on are owned by the new pod.
+func (app *Application) replaceOriginatingTask(task *Task) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	previous := app.originatingTask
+	app.originatingTask = task
+	if previous == nil || previous.GetTaskPod() == nil {
+		return
+	}
+	previousUID := previous.GetTaskPod().UID
+	pod := task.GetTaskPod()
+	refs := make([]metav1.OwnerReference, len(app.placeholderOwnerReferences))
+	for i, ref := range app.placeholderOwnerReferences {
+		if ref.Kind == "Pod" && ref.UID == previousUID {
+			ref.Name = pod.Name
+			ref.UID = pod.UID
+		}
+		refs[i] = ref
+	}
+	app.placeholderOwnerReferences = refs
+	app.publishAppEvent(v1.EventTypeNormal, "OriginatorReplaced",
+		"Application %s originating pod %s replaced by %s", app.applicationID, previous.GetTaskPod().Name, pod.Name)
+}
+
 func (app *Application) addTask(task *Task) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -255,6 +347,12 @@ func (app *Application) removeTask(taskID string) {
 		zap.String("taskID", taskID))
 }
 
+// isService returns true if the application has the service profile.
+// The profile does not change after creation and is safe to read without the lock.
+func (app *Application) isService() bool {
+	return app.service
+}
+
 func (app *Application) GetApplicationState() string {
 	return app.sm.Current()
 }
@@ -320,10 +418,86 @@ func (app *Application) getTasks(state string) []*Task {
 	return taskList
 }
 
+// GetAllocatedResource returns the total resource of the tasks that are allocated or bound, including placeholders.
+func (app *Application) GetAllocatedResource() *si.Resource {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	allocated := common.NewResourceBuilder().Build()
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state == TaskStates().Allocated || state == TaskStates().Bound {
+			allocated = common.Add(allocated, task.resource)
+		}
+	}
+	return allocated
+}
+
+// GetPendingAskCount returns the number of tasks that are waiting for an allocation.
+func (app *Application) GetPendingAskCount() int {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	count := 0
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state == TaskStates().Pending || state == TaskStates().Scheduling {
+			count++
+		}
+	}
+	return count
+}
+
 func (app *Application) GetTags() map[string]string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
 	return app.tags
 }
 
+// setQueueNodesUnavailable marks the application as reported for a queue without matching nodes.
+// Returns false if the application was already reported.
+func (app *Application) setQueueNodesUnavailable() bool {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.queueNodesUnavailable {
+		return false
+	}
+	app.queueNodesUnavailable = true
+	return true
+}
+
+// setNamespaceQuotaTags replaces the namespace quota and guaranteed tags of the application.
+// The tags are replaced with an updated copy as the map is shared with the requests sent to the core.
+func (app *Application) setNamespaceQuotaTags(quotaTags map[string]string) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	tags := make(map[string]string, len(app.tags)+len(quotaTags))
+	for key, value := range app.tags {
+		if key == siCommon.AppTagNamespaceResourceQuota || key == siCommon.AppTagNamespaceResourceGuaranteed {
+			continue
+		}
+		tags[key] = value
+	}
+	for key, value := range quotaTags {
+		tags[key] = value
+	}
+	app.tags = tags
+}
+
+// hasTaskWaitingSince returns true if a task of the application waits for resources and was created before the time
+func (app *Application) hasTaskWaitingSince(created metav1.Time) bool {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state != TaskStates().Pending && state != TaskStates().Scheduling {
+			continue
+		}
+		if task.pod.CreationTimestamp.Before(&created) {
+			return true
+		}
+	}
+	return false
+}
+
 func (app *Application) getNonTerminatedTaskAlias() []string {
 	var nonTerminatedTaskAlias []string
 	for _, task := range app.taskMap {
@@ -396,18 +570,30 @@ func (app *Application) Schedule() bool {
 }
 
 func (app *Application) scheduleTasks(taskScheduleCondition func(t *Task) bool) {
-	for _, task := range app.GetNewTasks() {
+	tasks := app.GetNewTasks()
+	strictFIFO := app.isStrictFIFO()
+	if strictFIFO {
+		sortTasksFIFO(tasks)
+	}
+	for _, task := range tasks {
 		if taskScheduleCondition(task) {
 			// for each new task, we do a sanity check before moving the state to Pending_Schedule
 			if err := task.sanityCheckBeforeScheduling(); err == nil {
 				// note, if we directly trigger submit task event, it may spawn too many duplicate
 				// events, because a task might be submitted multiple times before its state transits to PENDING.
+				// in strict FIFO mode the task is submitted directly: the dispatcher does not guarantee the order.
+				task.setStrictFIFO(strictFIFO)
+				app.markRollingUpdate(task)
 				if handleErr := task.handle(
 					NewSimpleTaskEvent(task.applicationID, task.taskID, InitTask)); handleErr != nil {
 					// something goes wrong when transit task to PENDING state,
 					// this should not happen because we already checked the state
 					// before calling the transition. Nowhere to go, just log the error.
 					log.Log(log.ShimCacheApplication).Warn("init task failed", zap.Error(err))
+				} else if strictFIFO {
+					if handleErr = task.handle(NewSubmitTaskEvent(task.applicationID, task.taskID)); handleErr != nil {
+						log.Log(log.ShimCacheApplication).Warn("submit task failed", zap.Error(handleErr))
+					}
 				}
 			} else {
 				events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "FailedScheduling", "FailedScheduling", err.Error())
@@ -415,11 +601,28 @@ func (app *Application) scheduleTasks(taskScheduleCondition func(t *Task) bool)
 					zap.String("appID", task.applicationID),
 					zap.String("taskID", task.taskID),
 					zap.Error(err))
+				// later tasks must not overtake a task that is not ready
+				if strictFIFO {
+					return
+				}
 			}
 		}
 	}
 }
 
+// sortTasksFIFO sorts the tasks in pod creation order. The creation time has a one second granularity,
+// tasks created in the same second are ordered by pod name.
+func sortTasksFIFO(tasks []*Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		l := tasks[i]
+		r := tasks[j]
+		if !l.createTime.Equal(r.createTime) {
+			return l.createTime.Before(r.createTime)
+		}
+		return l.alias < r.alias
+	})
+}
+
 func (app *Application) handleSubmitApplicationEvent() {
 	log.Log(log.ShimCacheApplication).Info("handle app submission",
 		zap.Stringer("app", app),
@@ -444,7 +647,8 @@ func (app *Application) handleSubmitApplicationEvent() {
 			RmID: conf.GetSchedulerConf().ClusterID,
 		})
 
-	if err != nil {
+	// a buffered request is sent once the communication with the core is restored
+	if err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		// submission failed
 		log.Log(log.ShimCacheApplication).Warn("failed to submit app", zap.Error(err))
 		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, err.Error()))
@@ -475,7 +679,7 @@ func (app *Application) handleRecoverApplicationEvent() {
 			RmID: conf.GetSchedulerConf().ClusterID,
 		})
 
-	if err != nil {
+	if err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		// recovery failed
 		log.Log(log.ShimCacheApplication).Warn("failed to recover app", zap.Error(err))
 		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, err.Error()))
@@ -517,6 +721,8 @@ func (app *Application) postAppAccepted() {
 		zap.String("appID", app.applicationID),
 		zap.Int("numTaskGroups", len(app.taskGroups)),
 		zap.Int("numAllocatedTasks", len(app.GetAllocatedTasks())))
+	app.publishAppEvent(v1.EventTypeNormal, "ApplicationAccepted",
+		"Application %s is accepted by the scheduler in queue %s", app.applicationID, app.queue)
 	if app.skipReservationStage() {
 		ev = NewRunApplicationEvent(app.applicationID)
 		log.Log(log.ShimCacheApplication).Info("Skip the reservation stage",
@@ -545,7 +751,17 @@ func (app *Application) onReserving() {
 			getPlaceholderManager().cleanUp(app)
 			ev := NewRunApplicationEvent(app.applicationID)
 			dispatcher.Dispatch(ev)
+			return
+		}
+		var members int32
+		taskGroups := app.getTaskGroups()
+		for _, tg := range taskGroups {
+			members += tg.MinMember
 		}
+		app.lock.RLock()
+		defer app.lock.RUnlock()
+		app.publishAppEvent(v1.EventTypeNormal, "GangPlaceholdersCreated",
+			"Application %s created %d placeholders for %d task groups", app.applicationID, members, len(taskGroups))
 	}()
 }
 
@@ -587,7 +803,8 @@ func (app *Application) handleRejectApplicationEvent(reason string) {
 }
 
 func (app *Application) handleCompleteApplicationEvent() {
-	// TODO app lifecycle updates
+	app.stopMaxRunTimer()
+	app.publishAppEvent(v1.EventTypeNormal, "ApplicationCompleted", "Application %s is completed", app.applicationID)
 	go func() {
 		getPlaceholderManager().cleanUp(app)
 	}()
@@ -610,10 +827,15 @@ func failTaskPodWithReasonAndMsg(task *Task, reason string, msg string) {
 }
 
 func (app *Application) handleFailApplicationEvent(errMsg string) {
+	app.stopMaxRunTimer()
 	go func() {
 		getPlaceholderManager().cleanUp(app)
 	}()
 	log.Log(log.ShimCacheApplication).Info("failApplication reason", zap.String("applicationID", app.applicationID), zap.String("errMsg", errMsg))
+	// the application fails in two steps, only publish once it has failed
+	if app.sm.Current() == ApplicationStates().Failed {
+		app.publishAppEvent(v1.EventTypeWarning, "ApplicationFailed", "Application %s failed, reason: %s", app.applicationID, errMsg)
+	}
 	// unallocated task states include New, Pending and Scheduling
 	unalloc := app.getTasks(TaskStates().New)
 	unalloc = append(unalloc, app.getTasks(TaskStates().Pending)...)
@@ -641,6 +863,11 @@ func (app *Application) handleReleaseAppAllocationEvent(allocUUID string, termin
 
 	for _, task := range app.taskMap {
 		if task.allocationUUID == allocUUID {
+			app.eventLog.record(app.applicationID, "TaskReleased", task.taskID, "Task %s released by the scheduler: %s",
+				task.alias, terminationType)
+			if terminationType == si.TerminationType_name[int32(si.TerminationType_PREEMPTED_BY_SCHEDULER)] {
+				app.publishPreemptionEvents(task)
+			}
 			task.setTaskTerminationType(terminationType)
 			err := task.DeleteTaskPod(task.pod)
 			if err != nil {
@@ -659,6 +886,8 @@ func (app *Application) handleReleaseAppAllocationAskEvent(taskID string, termin
 	if task, ok := app.taskMap[taskID]; ok {
 		task.setTaskTerminationType(terminationType)
 		if task.IsPlaceholder() {
+			app.eventLog.record(app.applicationID, "TaskReleased", task.taskID, "Placeholder %s released by the scheduler: %s",
+				task.alias, terminationType)
 			err := task.DeleteTaskPod(task.pod)
 			if err != nil {
 				log.Log(log.ShimCacheApplication).Error("failed to release allocation ask from application", zap.Error(err))
@@ -699,6 +928,29 @@ func (app *Application) publishPlaceholderTimeoutEvents(task *Task) {
 	}
 }
 
+// publishPreemptionEvents publishes the preemption of the task on the pod and on the originating pod of the application
+func (app *Application) publishPreemptionEvents(task *Task) {
+	events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "Preempted", "Preempted",
+		"Task %s is preempted by the scheduler", task.alias)
+	eventstream.Publish(eventstream.TopicPreemption, task.taskID, app.applicationID,
+		"Task %s is preempted by the scheduler", task.alias)
+	if app.originatingTask != nil && app.originatingTask.GetTaskID() != task.taskID {
+		app.publishAppEvent(v1.EventTypeWarning, "ApplicationPreempted",
+			"Application %s task %s is preempted by the scheduler", app.applicationID, task.alias)
+	}
+}
+
+// publishAppEvent publishes an event for a milestone in the lifecycle of the application on the originating pod.
+// Must be called while holding the application lock, nothing is published if the originating pod is not known.
+// The milestone is always added to the event log of the application.
+func (app *Application) publishAppEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	app.eventLog.record(app.applicationID, reason, "", messageFmt, args...)
+	if app.originatingTask == nil {
+		return
+	}
+	events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, eventType, reason, reason, messageFmt, args...)
+}
+
 func (app *Application) SetPlaceholderTimeout(timeout int64) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -510,11 +510,17 @@ func newAppState() *fsm.FSM { //nolint:funlen
 					zap.String("source", event.Src),
 					zap.String("destination", event.Dst),
 					zap.String("event", event.Event))
+				app.eventLog.record(app.applicationID, "StateChange", "", "Application state changed from %s to %s on %s",
+					event.Src, event.Dst, event.Event)
 			},
 			states.Reserving: func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
 				app.onReserving()
 			},
+			states.Running: func(_ context.Context, event *fsm.Event) {
+				app := event.Args[0].(*Application) //nolint:errcheck
+				app.startMaxRunTimer()
+			},
 			SubmitApplication.String(): func(_ context.Context, event *fsm.Event) {
 				app := event.Args[0].(*Application) //nolint:errcheck
 				app.handleSubmitApplicationEvent()
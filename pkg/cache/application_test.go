@@ -33,6 +33,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	k8sEvents "k8s.io/client-go/tools/events"
 
 	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
@@ -66,6 +67,17 @@ func TestNewApplication(t *testing.T) {
 	assert.Assert(t, reflect.DeepEqual(app.groups, []string{"dev", "yunikorn"}))
 }
 
+func TestSetQueue(t *testing.T) {
+	app := NewApplication("app00001", "root.queue", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	assert.NilError(t, app.SetQueue("root.other"))
+	assert.Equal(t, app.GetQueue(), "root.other")
+
+	// submitted apps cannot move
+	app.SetState(ApplicationStates().Submitted)
+	assert.ErrorContains(t, app.SetQueue("root.moved"), "cannot be changed")
+	assert.Equal(t, app.GetQueue(), "root.other")
+}
+
 func TestSubmitApplication(t *testing.T) {
 	app := NewApplication("app00001", "root.abc", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
 	err := app.handle(NewSubmitApplicationEvent(app.applicationID))
@@ -1144,6 +1156,28 @@ func TestGetPlaceholderTasks(t *testing.T) {
 	assert.Assert(t, phTasksMap["task0002"])
 }
 
+func TestGetAllocatedResourceAndPendingAsks(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	res := common.NewResourceBuilder().AddResource(siCommon.CPU, 500).AddResource(siCommon.Memory, 1024).Build()
+	states := []string{TaskStates().New, TaskStates().Pending, TaskStates().Scheduling, TaskStates().Allocated, TaskStates().Bound, TaskStates().Completed}
+	for i, state := range states {
+		task := NewTask(fmt.Sprintf("task%04d", i), app, context, &v1.Pod{})
+		task.resource = res
+		task.sm.SetState(state)
+		app.addTask(task)
+	}
+
+	allocated := app.GetAllocatedResource()
+	assert.Equal(t, allocated.Resources[siCommon.CPU].Value, int64(1000))
+	assert.Equal(t, allocated.Resources[siCommon.Memory].Value, int64(2048))
+	assert.Equal(t, app.GetPendingAskCount(), 2)
+
+	empty := NewApplication("app-empty", "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	assert.Equal(t, len(empty.GetAllocatedResource().Resources), 0)
+	assert.Equal(t, empty.GetPendingAskCount(), 0)
+}
+
 func TestPlaceholderTimeoutEvents(t *testing.T) {
 	context := initContextForTest()
 	recorder, ok := events.GetRecorder().(*k8sEvents.FakeRecorder)
@@ -1344,3 +1378,126 @@ func (ctx *Context) addApplication(app *Application) {
 	defer ctx.lock.Unlock()
 	ctx.applications[app.applicationID] = app
 }
+
+func TestScheduleTasksStrictFIFO(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	context.askBatcher = nil
+	var lock sync.Mutex
+	submitted := make([]string, 0)
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, ask := range request.Asks {
+			submitted = append(submitted, ask.AllocationKey)
+		}
+		return nil
+	})
+
+	app := NewApplication("app00001", "root.abc", "test-user",
+		testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	app.setStrictFIFO(true)
+	app.sm.SetState(ApplicationStates().Running)
+	created := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	// tasks are added in informer order, not in creation order
+	for _, p := range []struct {
+		name    string
+		created time.Time
+	}{
+		{"worker-1", created.Add(time.Second)},
+		{"worker-0", created},
+		{"launcher", created},
+	} {
+		pod := &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:              p.name,
+				Namespace:         "default",
+				UID:               types.UID(p.name),
+				CreationTimestamp: apis.NewTime(p.created),
+			},
+		}
+		app.addTask(NewTask(p.name, app, context, pod))
+	}
+
+	app.Schedule()
+	for _, task := range app.getTasks(TaskStates().Scheduling) {
+		assert.Assert(t, task.strictFIFO, "task should be submitted in strict FIFO mode")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	assert.DeepEqual(t, submitted, []string{"launcher", "worker-0", "worker-1"})
+}
+
+func TestApplicationLifecycleEvents(t *testing.T) {
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
+	context := initContextForTest()
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	mgr.Start()
+	defer mgr.Stop()
+
+	newPod := func(name string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID("UID-" + name),
+			},
+		}
+	}
+	publishedEvents := func() []string {
+		var published []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				published = append(published, event)
+			default:
+				return published
+			}
+		}
+	}
+
+	// no originating pod, nothing is published
+	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.publishAppEvent(v1.EventTypeNormal, "ApplicationAccepted", "Application %s is accepted", appID)
+	assert.Equal(t, len(publishedEvents()), 0)
+
+	originator := NewTask("task01", app, context, newPod("pod-01"))
+	app.addTask(originator)
+	app.setOriginatingTask(originator)
+	member := NewTask("task02", app, context, newPod("pod-02"))
+	member.allocationUUID = "UUID-02"
+	app.addTask(member)
+
+	app.publishAppEvent(v1.EventTypeNormal, "ApplicationAccepted", "Application %s is accepted", appID)
+	assert.DeepEqual(t, publishedEvents(), []string{"Normal ApplicationAccepted Application app01 is accepted"})
+
+	// preemption of a member is published on the member and the originating pod
+	app.SetState(ApplicationStates().Running)
+	err := app.handle(NewReleaseAppAllocationEvent(appID, si.TerminationType_PREEMPTED_BY_SCHEDULER, "UUID-02"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, publishedEvents(), []string{
+		"Warning Preempted Task default/pod-02 is preempted by the scheduler",
+		"Warning ApplicationPreempted Application app01 task default/pod-02 is preempted by the scheduler",
+	})
+
+	// failure is published once the application has failed, unallocated tasks get their own events
+	appFailed := func(published []string) []string {
+		var failed []string
+		for _, event := range published {
+			if strings.HasPrefix(event, "Warning ApplicationFailed Application app01 failed") {
+				failed = append(failed, event)
+			}
+		}
+		return failed
+	}
+	err = app.handle(NewFailApplicationEvent(appID, "test failure"))
+	assert.NilError(t, err)
+	assertAppState(t, app, ApplicationStates().Failing, 3*time.Second)
+	assert.Equal(t, len(appFailed(publishedEvents())), 0)
+	err = app.handle(NewFailApplicationEvent(appID, "test failure"))
+	assert.NilError(t, err)
+	assertAppState(t, app, ApplicationStates().Failed, 3*time.Second)
+	assert.DeepEqual(t, appFailed(publishedEvents()), []string{"Warning ApplicationFailed Application app01 failed, reason: test failure"})
+}
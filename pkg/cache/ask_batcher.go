@@ -0,0 +1,270 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// askBatcher aggregates the allocation asks of an application that arrive in quick succession,
+// for example when a Job controller creates a large number of pods at once.
+// The first ask for an application opens a batch window, all asks for the same application
+// that arrive within the window are sent to the core in a single UpdateAllocation call.
+// A batch is sent early if it reaches the maximum batch size.
+// The batches of an application are sent one at a time, in order: the asks added while a batch is being sent
+// wait for the send to finish. A batch that fails to be sent is queued again and retried after the window,
+// the sent callback is called once the asks are sent or the retries are exhausted.
+type askBatcher struct {
+	schedulerAPI api.SchedulerAPI
+	interval     time.Duration
+	maxSize      int
+	sent         func(asks []*si.AllocationAsk, err error)
+	pending      map[string][]*si.AllocationAsk // pending asks per application
+	timers       map[string]*time.Timer         // batch window timers per application
+	failures     map[string]int                 // consecutive send failures per application
+	inFlight     map[string]map[string]bool     // asks being sent per application, false if removed while sending
+	sync.Mutex
+}
+
+// maximum number of times a batch is retried before the failure is reported
+const askBatchRetries = 3
+
+// newAskBatcher creates a batcher based on the scheduler configuration.
+// Returns nil if batching is disabled, which is the case if the interval is not a positive value.
+func newAskBatcher(schedulerAPI api.SchedulerAPI, interval time.Duration, maxSize int, sent func(asks []*si.AllocationAsk, err error)) *askBatcher {
+	if interval <= 0 {
+		return nil
+	}
+	if maxSize <= 0 {
+		maxSize = conf.DefaultAskBatchSize
+	}
+	return &askBatcher{
+		schedulerAPI: schedulerAPI,
+		interval:     interval,
+		maxSize:      maxSize,
+		sent:         sent,
+		pending:      make(map[string][]*si.AllocationAsk),
+		timers:       make(map[string]*time.Timer),
+		failures:     make(map[string]int),
+		inFlight:     make(map[string]map[string]bool),
+	}
+}
+
+// add queues the asks from the request for sending to the core.
+// A full batch is sent in the background: the caller might hold the lock of the task.
+func (b *askBatcher) add(request *si.AllocationRequest) {
+	b.Lock()
+	defer b.Unlock()
+	for _, ask := range request.Asks {
+		appID := ask.ApplicationID
+		b.pending[appID] = append(b.pending[appID], ask)
+		if len(b.pending[appID]) >= b.maxSize {
+			b.sendLocked(appID)
+			continue
+		}
+		b.startTimerLocked(appID)
+	}
+}
+
+// startTimerLocked opens the batch window for the application if it is not open yet.
+// Must be called while holding the lock.
+func (b *askBatcher) startTimerLocked(appID string) {
+	if _, ok := b.timers[appID]; !ok {
+		b.timers[appID] = time.AfterFunc(b.interval, func() {
+			b.flush(appID)
+		})
+	}
+}
+
+// remove drops the ask of the task. A pending ask is dropped before it is sent.
+// Returns true if the ask is being sent: the batcher releases the ask in the core once the send has finished,
+// the caller must not send the release itself as it could overtake the ask.
+func (b *askBatcher) remove(appID, taskID string) bool {
+	b.Lock()
+	defer b.Unlock()
+	if _, ok := b.inFlight[appID][taskID]; ok {
+		b.inFlight[appID][taskID] = false
+		return true
+	}
+	asks := b.pending[appID]
+	for i, ask := range asks {
+		if ask.AllocationKey == taskID {
+			b.pending[appID] = append(asks[:i], asks[i+1:]...)
+			if len(b.pending[appID]) == 0 {
+				b.takeLocked(appID)
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// flush sends all pending asks for the application to the core when the batch window closes.
+func (b *askBatcher) flush(appID string) {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.timers, appID)
+	b.sendLocked(appID)
+}
+
+// sendLocked sends the pending asks of the application in the background, unless a batch of the application is
+// being sent: the asks are then sent once that send has finished.
+// Must be called while holding the lock.
+func (b *askBatcher) sendLocked(appID string) {
+	if _, ok := b.inFlight[appID]; ok {
+		return
+	}
+	asks := b.takeLocked(appID)
+	if len(asks) == 0 {
+		return
+	}
+	b.markInFlightLocked(appID, asks)
+	go b.send(appID, asks)
+}
+
+// takeLocked removes and returns all pending asks for the application and stops the batch timer.
+// Must be called while holding the lock.
+func (b *askBatcher) takeLocked(appID string) []*si.AllocationAsk {
+	asks := b.pending[appID]
+	delete(b.pending, appID)
+	if timer, ok := b.timers[appID]; ok {
+		timer.Stop()
+		delete(b.timers, appID)
+	}
+	return asks
+}
+
+// markInFlightLocked tracks the asks that are being sent for the application.
+// Must be called while holding the lock.
+func (b *askBatcher) markInFlightLocked(appID string, asks []*si.AllocationAsk) {
+	sending := make(map[string]bool, len(asks))
+	for _, ask := range asks {
+		sending[ask.AllocationKey] = true
+	}
+	b.inFlight[appID] = sending
+}
+
+// send sends the batches of an application to the core, it is the only sender for the application until it
+// returns. A buffered request is sent once the communication with the core is restored. Any other failure queues
+// the asks again, in front of the asks added in the meantime, until the retries are exhausted.
+// Asks removed while being sent are not queued again, or are released once they were sent.
+func (b *askBatcher) send(appID string, asks []*si.AllocationAsk) {
+	for len(asks) > 0 {
+		log.Log(log.ShimCacheTask).Debug("sending batched asks to scheduler",
+			zap.String("appID", appID),
+			zap.Int("numOfAsks", len(asks)))
+		request := &si.AllocationRequest{
+			Asks: asks,
+			RmID: conf.GetSchedulerConf().ClusterID,
+		}
+		err := b.schedulerAPI.UpdateAllocation(request)
+		if errors.Is(err, client.ErrCoreRequestBuffered) {
+			err = nil
+		}
+
+		b.Lock()
+		var active, removed []*si.AllocationAsk
+		for _, ask := range asks {
+			if b.inFlight[appID][ask.AllocationKey] {
+				active = append(active, ask)
+			} else {
+				removed = append(removed, ask)
+			}
+		}
+		delete(b.inFlight, appID)
+		var failed []*si.AllocationAsk
+		if err == nil {
+			delete(b.failures, appID)
+		} else {
+			// the asks were not sent: the removed asks do not need to be released
+			removed = nil
+			b.failures[appID]++
+			if b.failures[appID] <= askBatchRetries {
+				log.Log(log.ShimCacheTask).Warn("failed to send batched asks to scheduler, retrying",
+					zap.String("appID", appID),
+					zap.Int("numOfAsks", len(active)),
+					zap.Int("attempt", b.failures[appID]),
+					zap.Error(err))
+				b.pending[appID] = append(active, b.pending[appID]...)
+			} else {
+				delete(b.failures, appID)
+				failed = active
+			}
+			active = nil
+		}
+		// a full batch added while sending is sent right away, otherwise the asks wait for the window
+		asks = nil
+		if err == nil && len(b.pending[appID]) >= b.maxSize {
+			asks = b.takeLocked(appID)
+			b.markInFlightLocked(appID, asks)
+		} else if len(b.pending[appID]) > 0 {
+			b.startTimerLocked(appID)
+		}
+		b.Unlock()
+
+		if len(removed) > 0 {
+			b.release(appID, removed)
+		}
+		if len(active) > 0 {
+			b.sent(active, nil)
+		}
+		if len(failed) > 0 {
+			log.Log(log.ShimCacheTask).Error("failed to send batched asks to scheduler",
+				zap.String("appID", appID),
+				zap.Int("numOfAsks", len(failed)),
+				zap.Error(err))
+			b.sent(failed, err)
+		}
+	}
+}
+
+// release releases the asks that were removed while they were being sent
+func (b *askBatcher) release(appID string, asks []*si.AllocationAsk) {
+	releases := make([]*si.AllocationAskRelease, 0, len(asks))
+	for _, ask := range asks {
+		releases = append(releases, &si.AllocationAskRelease{
+			ApplicationID: ask.ApplicationID,
+			AllocationKey: ask.AllocationKey,
+			PartitionName: ask.PartitionName,
+			Message:       "task request is canceled",
+		})
+	}
+	request := &si.AllocationRequest{
+		Releases: &si.AllocationReleasesRequest{
+			AllocationAsksToRelease: releases,
+		},
+		RmID: conf.GetSchedulerConf().ClusterID,
+	}
+	if err := b.schedulerAPI.UpdateAllocation(request); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+		log.Log(log.ShimCacheTask).Warn("failed to release removed asks",
+			zap.String("appID", appID),
+			zap.Int("numOfAsks", len(asks)),
+			zap.Error(err))
+	}
+}
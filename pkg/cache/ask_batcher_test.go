@@ -0,0 +1,296 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/test"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+type askRecorder struct {
+	requests []*si.AllocationRequest
+	sync.Mutex
+}
+
+func (r *askRecorder) record(request *si.AllocationRequest) error {
+	r.Lock()
+	defer r.Unlock()
+	r.requests = append(r.requests, request)
+	return nil
+}
+
+func (r *askRecorder) getRequests() []*si.AllocationRequest {
+	r.Lock()
+	defer r.Unlock()
+	return append([]*si.AllocationRequest{}, r.requests...)
+}
+
+// sentRecorder records the asks reported back by the batcher
+type sentRecorder struct {
+	sent   []string
+	failed []string
+	sync.Mutex
+}
+
+func (r *sentRecorder) record(asks []*si.AllocationAsk, err error) {
+	r.Lock()
+	defer r.Unlock()
+	for _, ask := range asks {
+		if err != nil {
+			r.failed = append(r.failed, ask.AllocationKey)
+		} else {
+			r.sent = append(r.sent, ask.AllocationKey)
+		}
+	}
+}
+
+func (r *sentRecorder) get() ([]string, []string) {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string{}, r.sent...), append([]string{}, r.failed...)
+}
+
+func askRequest(appID, taskID string) *si.AllocationRequest {
+	return &si.AllocationRequest{
+		Asks: []*si.AllocationAsk{{ApplicationID: appID, AllocationKey: taskID}},
+	}
+}
+
+func TestNewAskBatcherDisabled(t *testing.T) {
+	assert.Assert(t, newAskBatcher(test.NewSchedulerAPIMock(), 0, 10, nil) == nil, "batcher created with zero interval")
+	assert.Assert(t, newAskBatcher(test.NewSchedulerAPIMock(), -time.Second, 10, nil) == nil, "batcher created with negative interval")
+	b := newAskBatcher(test.NewSchedulerAPIMock(), time.Second, 0, nil)
+	assert.Assert(t, b != nil, "batcher not created")
+	assert.Assert(t, b.maxSize > 0, "batch size not defaulted")
+}
+
+func TestAskBatcherWindow(t *testing.T) {
+	recorder := &askRecorder{}
+	api := test.NewSchedulerAPIMock().UpdateAllocationFunction(recorder.record)
+	sent := &sentRecorder{}
+	b := newAskBatcher(api, 50*time.Millisecond, 100, sent.record)
+
+	for _, taskID := range []string{"task-1", "task-2", "task-3"} {
+		b.add(askRequest("app-1", taskID))
+	}
+	b.add(askRequest("app-2", "task-4"))
+	assert.Equal(t, len(recorder.getRequests()), 0, "asks sent before window closed")
+
+	err := utils.WaitForCondition(func() bool {
+		return len(recorder.getRequests()) == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "batched asks not sent")
+
+	asksPerApp := make(map[string]int)
+	for _, request := range recorder.getRequests() {
+		for _, ask := range request.Asks {
+			asksPerApp[ask.ApplicationID]++
+		}
+	}
+	assert.Equal(t, asksPerApp["app-1"], 3, "wrong number of asks for app-1")
+	assert.Equal(t, asksPerApp["app-2"], 1, "wrong number of asks for app-2")
+	sentAsks, failedAsks := sent.get()
+	assert.Equal(t, len(sentAsks), 4, "sent asks not reported")
+	assert.Equal(t, len(failedAsks), 0, "failed asks reported")
+}
+
+func TestAskBatcherMaxSize(t *testing.T) {
+	recorder := &askRecorder{}
+	api := test.NewSchedulerAPIMock().UpdateAllocationFunction(recorder.record)
+	sent := &sentRecorder{}
+	b := newAskBatcher(api, time.Hour, 2, sent.record)
+
+	b.add(askRequest("app-1", "task-1"))
+	assert.Equal(t, len(recorder.getRequests()), 0, "asks sent before batch is full")
+	b.add(askRequest("app-1", "task-2"))
+	err := utils.WaitForCondition(func() bool {
+		sentAsks, _ := sent.get()
+		return len(sentAsks) == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "full batch not sent")
+	requests := recorder.getRequests()
+	assert.Equal(t, len(requests), 1, "full batch not sent")
+	assert.Equal(t, len(requests[0].Asks), 2, "wrong number of asks in batch")
+	assert.Equal(t, len(b.timers), 0, "timer not cleaned up")
+}
+
+func TestAskBatcherRemove(t *testing.T) {
+	recorder := &askRecorder{}
+	api := test.NewSchedulerAPIMock().UpdateAllocationFunction(recorder.record)
+	b := newAskBatcher(api, time.Hour, 100, nil)
+
+	b.add(askRequest("app-1", "task-1"))
+	b.add(askRequest("app-1", "task-2"))
+	// pending asks are dropped, the caller releases them
+	assert.Assert(t, !b.remove("app-1", "task-1"), "pending ask released by the batcher")
+	assert.Equal(t, len(b.pending["app-1"]), 1, "pending ask not removed")
+	assert.Assert(t, !b.remove("app-2", "task-1"), "ask removed from unknown app")
+	assert.Assert(t, !b.remove("app-1", "task-2"), "pending ask released by the batcher")
+	assert.Equal(t, len(b.pending), 0, "pending asks not cleaned up")
+	assert.Equal(t, len(b.timers), 0, "timer not cleaned up")
+
+	b.flush("app-1")
+	assert.Equal(t, len(recorder.getRequests()), 0, "removed asks were sent")
+}
+
+// blockingAPI blocks the sending of asks until it is released, and records all requests
+type blockingAPI struct {
+	askRecorder
+	started chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingAPI) record(request *si.AllocationRequest) error {
+	if len(request.Asks) > 0 {
+		a.started <- struct{}{}
+		<-a.release
+	}
+	return a.askRecorder.record(request)
+}
+
+func TestAskBatcherRemoveInFlight(t *testing.T) {
+	blocking := &blockingAPI{started: make(chan struct{}, 10), release: make(chan struct{})}
+	api := test.NewSchedulerAPIMock().UpdateAllocationFunction(blocking.record)
+	sent := &sentRecorder{}
+	b := newAskBatcher(api, time.Hour, 2, sent.record)
+
+	b.add(askRequest("app-1", "task-1"))
+	b.add(askRequest("app-1", "task-2"))
+	<-blocking.started
+	// the ask is being sent: the batcher releases it once the send has finished
+	assert.Assert(t, b.remove("app-1", "task-1"), "ask being sent not released by the batcher")
+	close(blocking.release)
+	err := utils.WaitForCondition(func() bool {
+		return len(blocking.getRequests()) == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "removed ask not released")
+	requests := blocking.getRequests()
+	assert.Equal(t, len(requests[0].Asks), 2, "asks not sent")
+	assert.Assert(t, requests[1].Releases != nil, "release not sent after the asks")
+	assert.Equal(t, len(requests[1].Releases.AllocationAsksToRelease), 1)
+	assert.Equal(t, requests[1].Releases.AllocationAsksToRelease[0].AllocationKey, "task-1")
+	sentAsks, _ := sent.get()
+	assert.DeepEqual(t, sentAsks, []string{"task-2"})
+	// the ask was sent, it is no longer tracked
+	assert.Assert(t, !b.remove("app-1", "task-2"), "sent ask still tracked")
+}
+
+func TestAskBatcherOrder(t *testing.T) {
+	blocking := &blockingAPI{started: make(chan struct{}, 10), release: make(chan struct{})}
+	api := test.NewSchedulerAPIMock().UpdateAllocationFunction(blocking.record)
+	b := newAskBatcher(api, time.Hour, 2, func([]*si.AllocationAsk, error) {})
+
+	b.add(askRequest("app-1", "task-1"))
+	b.add(askRequest("app-1", "task-2"))
+	<-blocking.started
+	// a full batch added while sending waits for the running send
+	b.add(askRequest("app-1", "task-3"))
+	b.add(askRequest("app-1", "task-4"))
+	select {
+	case <-blocking.started:
+		t.Fatal("second batch sent while the first batch is being sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(blocking.release)
+	err := utils.WaitForCondition(func() bool {
+		return len(blocking.getRequests()) == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "second batch not sent")
+	requests := blocking.getRequests()
+	assert.Equal(t, requests[0].Asks[0].AllocationKey, "task-1")
+	assert.Equal(t, requests[1].Asks[0].AllocationKey, "task-3")
+	b.Lock()
+	defer b.Unlock()
+	assert.Equal(t, len(b.inFlight), 0, "in flight asks not cleaned up")
+}
+
+func TestAskBatcherRetry(t *testing.T) {
+	var lock sync.Mutex
+	var calls int
+	var sendErr error
+	api := test.NewSchedulerAPIMock().UpdateAllocationFunction(func(request *si.AllocationRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calls++
+		return sendErr
+	})
+	getCalls := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls
+	}
+	sent := &sentRecorder{}
+	b := newAskBatcher(api, 10*time.Millisecond, 100, sent.record)
+
+	// a buffered request counts as sent
+	sendErr = client.ErrCoreRequestBuffered
+	b.add(askRequest("app-1", "task-1"))
+	err := utils.WaitForCondition(func() bool {
+		sentAsks, _ := sent.get()
+		return len(sentAsks) == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "buffered ask not reported as sent")
+
+	// failures are retried and reported once the retries are exhausted
+	lock.Lock()
+	calls = 0
+	sendErr = errors.New("core failure")
+	lock.Unlock()
+	b.add(askRequest("app-1", "task-2"))
+	err = utils.WaitForCondition(func() bool {
+		_, failedAsks := sent.get()
+		return len(failedAsks) == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "failed ask not reported")
+	assert.Equal(t, getCalls(), askBatchRetries+1, "ask not retried")
+	_, failedAsks := sent.get()
+	assert.DeepEqual(t, failedAsks, []string{"task-2"})
+	b.Lock()
+	assert.Equal(t, len(b.pending), 0, "failed ask still pending")
+	assert.Equal(t, len(b.failures), 0, "failures not cleaned up")
+	b.Unlock()
+
+	// an ask removed while its batch is failing is not queued again
+	lock.Lock()
+	calls = 0
+	lock.Unlock()
+	b.add(askRequest("app-1", "task-3"))
+	err = utils.WaitForCondition(func() bool {
+		return getCalls() == 1
+	}, 5*time.Millisecond, time.Second)
+	assert.NilError(t, err, "ask not sent")
+	// the ask is either still being sent or already queued again
+	b.remove("app-1", "task-3")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, getCalls(), 1, "removed ask sent again")
+	_, failedAsks = sent.get()
+	assert.DeepEqual(t, failedAsks, []string{"task-2"})
+	b.Lock()
+	assert.Equal(t, len(b.pending), 0, "removed ask still pending")
+	b.Unlock()
+}
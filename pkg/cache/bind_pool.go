@@ -0,0 +1,135 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+)
+
+// errBindPoolStopped is returned for a bind that is requested after the pool was stopped
+var errBindPoolStopped = errors.New("bind pool stopped")
+
+// bindRequest is a single pod bind waiting for a worker, the result of the API call is sent on the done channel
+type bindRequest struct {
+	pod      *v1.Pod
+	nodeName string
+	done     chan error
+}
+
+// bindPool limits the number of concurrent pod bind calls to the API server, for example when the core
+// allocates a large number of pods in one scheduling cycle. The API server does not offer a bulk bind, each
+// pod is still bound with its own call: the pool replaces one unbounded call per allocated task.
+// Binds are not coalesced in a time window like the asks: without a bulk call a window only delays each bind.
+// The binds of one scheduling cycle arrive together and are spread over the workers as they arrive.
+type bindPool struct {
+	apiProvider client.APIProvider
+	work        chan *bindRequest
+	stopChan    chan struct{}
+	stopOnce    sync.Once
+	workers     sync.WaitGroup
+}
+
+// newBindPool creates a pool based on the scheduler configuration and starts the workers.
+// Returns nil if the pool is disabled, which is the case if the number of workers is not a positive value.
+func newBindPool(apiProvider client.APIProvider, workers int) *bindPool {
+	if workers <= 0 {
+		return nil
+	}
+	p := &bindPool{
+		apiProvider: apiProvider,
+		work:        make(chan *bindRequest),
+		stopChan:    make(chan struct{}),
+	}
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+// bind waits for a free worker and the result of the API call
+func (p *bindPool) bind(pod *v1.Pod, nodeName string) error {
+	request := &bindRequest{
+		pod:      pod,
+		nodeName: nodeName,
+		done:     make(chan error, 1),
+	}
+	metrics.IncBindsInFlight()
+	// the work channel is not buffered: a request that is handed over is always processed by a worker
+	select {
+	case p.work <- request:
+		return <-request.done
+	case <-p.stopChan:
+		metrics.DecBindsInFlight()
+		return errBindPoolStopped
+	}
+}
+
+// stop stops the workers after the running binds are finished, binds requested afterwards fail
+func (p *bindPool) stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+	p.workers.Wait()
+}
+
+func (p *bindPool) worker() {
+	defer p.workers.Done()
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case request := <-p.work:
+			request.done <- bindPod(p.apiProvider, request.pod, request.nodeName)
+			metrics.DecBindsInFlight()
+		}
+	}
+}
+
+// bindPod binds the pod to the node in the API server and counts the result of the call
+func bindPod(apiProvider client.APIProvider, pod *v1.Pod, nodeName string) error {
+	err := apiProvider.GetAPIs().KubeClient.Bind(pod, nodeName)
+	if err != nil {
+		metrics.IncPodBind(metrics.BindFailed)
+	} else {
+		metrics.IncPodBind(metrics.BindSucceeded)
+	}
+	return err
+}
+
+// bindPod binds the pod to the node, through the bind pool if the pool is enabled
+func (ctx *Context) bindPod(pod *v1.Pod, nodeName string) error {
+	if ctx.binder != nil {
+		return ctx.binder.bind(pod, nodeName)
+	}
+	return bindPod(ctx.apiProvider, pod, nodeName)
+}
+
+// Stop stops the background workers of the context
+func (ctx *Context) Stop() {
+	if ctx.binder != nil {
+		ctx.binder.stop()
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+)
+
+func bindTestPod(name string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+}
+
+func TestNewBindPoolDisabled(t *testing.T) {
+	apis := client.NewMockedAPIProvider(false)
+	assert.Assert(t, newBindPool(apis, 0) == nil, "pool created without workers")
+	assert.Assert(t, newBindPool(apis, -1) == nil, "pool created with negative workers")
+	p := newBindPool(apis, 1)
+	assert.Assert(t, p != nil, "pool not created")
+	p.stop()
+}
+
+func TestBindPoolLimit(t *testing.T) {
+	apis := client.NewMockedAPIProvider(false)
+	var calls int32
+	release := make(chan struct{})
+	apis.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	})
+	succeeded, err := metrics.GetPodBinds(metrics.BindSucceeded)
+	assert.NilError(t, err)
+	p := newBindPool(apis, 1)
+	defer p.stop()
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			errs <- p.bind(bindTestPod(fmt.Sprintf("pod-%d", i)), "node-1")
+		}(i)
+	}
+	err = utils.WaitForCondition(func() bool {
+		inFlight, err := metrics.GetBindsInFlight()
+		return err == nil && inFlight == 3 && atomic.LoadInt32(&calls) == 1
+	}, 5*time.Millisecond, time.Second)
+	assert.NilError(t, err, "binds not waiting for a worker")
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, atomic.LoadInt32(&calls), int32(1), "binds not limited to the number of workers")
+	close(release)
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, <-errs)
+	}
+	assert.Equal(t, atomic.LoadInt32(&calls), int32(3), "wrong number of pods bound")
+
+	count, err := metrics.GetPodBinds(metrics.BindSucceeded)
+	assert.NilError(t, err)
+	assert.Equal(t, count, succeeded+3, "successful binds not counted")
+	count, err = metrics.GetBindsInFlight()
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0, "binds still in flight")
+}
+
+func TestBindPoolFailure(t *testing.T) {
+	apis := client.NewMockedAPIProvider(false)
+	apis.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		if pod.Name == "pod-fail" {
+			return fmt.Errorf("bind failed")
+		}
+		return nil
+	})
+	failed, err := metrics.GetPodBinds(metrics.BindFailed)
+	assert.NilError(t, err)
+	p := newBindPool(apis, 2)
+	defer p.stop()
+
+	assert.NilError(t, p.bind(bindTestPod("pod-ok"), "node-1"))
+	assert.ErrorContains(t, p.bind(bindTestPod("pod-fail"), "node-1"), "bind failed")
+	count, err := metrics.GetPodBinds(metrics.BindFailed)
+	assert.NilError(t, err)
+	assert.Equal(t, count, failed+1, "failed bind not counted")
+}
+
+func TestBindPoolStop(t *testing.T) {
+	apis := client.NewMockedAPIProvider(false)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	apis.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		close(started)
+		<-release
+		return nil
+	})
+	p := newBindPool(apis, 1)
+
+	running := make(chan error, 1)
+	go func() {
+		running <- p.bind(bindTestPod("pod-1"), "node-1")
+	}()
+	<-started
+	stopped := make(chan struct{})
+	go func() {
+		p.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("pool stopped before the running bind finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.NilError(t, <-running, "running bind failed")
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("workers not stopped")
+	}
+	assert.Equal(t, p.bind(bindTestPod("pod-2"), "node-1"), errBindPoolStopped)
+	// stopping twice must not panic
+	p.stop()
+	count, err := metrics.GetBindsInFlight()
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0, "binds still in flight")
+}
@@ -0,0 +1,108 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// maxBindRetryBackoff limits the exponential backoff between two bind attempts
+const maxBindRetryBackoff = 5 * time.Second
+
+// errBindCancelled is returned when the task left the Allocated state while waiting for a bind retry
+var errBindCancelled = errors.New("task is no longer allocated, bind cancelled")
+
+// isRetryableBindError returns true if the API server rejected the bind for a reason that is expected
+// to be transient. All other failures are not retried. A conflict means the pod is already assigned to
+// a node, a retry cannot succeed: see isBoundToNode.
+func isRetryableBindError(err error) bool {
+	return k8serrors.IsServerTimeout(err) ||
+		k8serrors.IsTimeout(err) ||
+		k8serrors.IsTooManyRequests(err) ||
+		k8serrors.IsServiceUnavailable(err) ||
+		k8serrors.IsInternalError(err)
+}
+
+// bindPodWithRetry binds the pod of the task to the allocated node. Transient failures are retried with an
+// exponential backoff, up to the configured number of retries. The task lock must not be held by the caller:
+// the task can be completed while waiting, the retry stops with errBindCancelled if the task is no longer
+// allocated when the wait is over.
+func (task *Task) bindPodWithRetry() error {
+	task.lock.RLock()
+	pod := task.pod
+	nodeName := task.nodeName
+	task.lock.RUnlock()
+
+	maxRetries := conf.GetSchedulerConf().GetBindMaxRetries()
+	backoff := conf.GetSchedulerConf().GetBindRetryBackoff()
+	for attempt := 1; ; attempt++ {
+		err := task.context.bindPod(pod, nodeName)
+		if k8serrors.IsConflict(err) && task.isBoundToNode(pod, nodeName) {
+			return nil
+		}
+		if err == nil || attempt > maxRetries || !isRetryableBindError(err) {
+			return err
+		}
+		metrics.IncBindRetry(string(k8serrors.ReasonForError(err)))
+		log.Log(log.ShimCacheTask).Warn("transient failure binding pod, retrying",
+			zap.String("podName", pod.Name),
+			zap.String("nodeName", nodeName),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		time.Sleep(backoff)
+		if state := task.GetTaskState(); state != TaskStates().Allocated {
+			return fmt.Errorf("%w: state %s", errBindCancelled, state)
+		}
+		backoff *= 2
+		if backoff > maxBindRetryBackoff {
+			backoff = maxBindRetryBackoff
+		}
+	}
+}
+
+// isBoundToNode re-reads the pod after a bind conflict and returns true if the pod is already bound to the node.
+// A previous bind attempt that timed out on the client side could still have been processed by the API server.
+func (task *Task) isBoundToNode(pod *v1.Pod, nodeName string) bool {
+	current, err := task.context.apiProvider.GetAPIs().KubeClient.Get(pod.Namespace, pod.Name)
+	if err != nil {
+		log.Log(log.ShimCacheTask).Warn("failed to read pod after bind conflict",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return false
+	}
+	if current.Spec.NodeName != nodeName {
+		return false
+	}
+	log.Log(log.ShimCacheTask).Info("pod already bound to the allocated node",
+		zap.String("podName", pod.Name),
+		zap.String("nodeName", nodeName))
+	return true
+}
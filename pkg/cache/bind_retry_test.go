@@ -0,0 +1,136 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func TestIsRetryableBindError(t *testing.T) {
+	podResource := schema.GroupResource{Resource: "pods"}
+	assert.Assert(t, isRetryableBindError(k8serrors.NewServerTimeout(podResource, "bind", 1)))
+	assert.Assert(t, isRetryableBindError(k8serrors.NewTimeoutError("timeout", 1)))
+	assert.Assert(t, isRetryableBindError(k8serrors.NewTooManyRequests("slow down", 1)))
+	assert.Assert(t, isRetryableBindError(k8serrors.NewServiceUnavailable("unavailable")))
+	assert.Assert(t, isRetryableBindError(k8serrors.NewInternalError(fmt.Errorf("internal"))))
+	assert.Assert(t, !isRetryableBindError(k8serrors.NewConflict(podResource, "pod", fmt.Errorf("conflict"))))
+	assert.Assert(t, !isRetryableBindError(k8serrors.NewNotFound(podResource, "pod")))
+	assert.Assert(t, !isRetryableBindError(k8serrors.NewForbidden(podResource, "pod", fmt.Errorf("forbidden"))))
+	assert.Assert(t, !isRetryableBindError(fmt.Errorf("plain error")))
+}
+
+func TestBindPodWithRetry(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.BindMaxRetries = 2
+	testConf.BindRetryBackoff = time.Millisecond
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	app := NewApplication("app01", "root.default", "bob", testGroups, map[string]string{}, newMockSchedulerAPI())
+	pod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "pod-bind-retry", Namespace: "default", UID: "UID-00001"}}
+	task := NewTask("task01", app, context, pod)
+	task.nodeName = "node-1"
+	task.sm.SetState(TaskStates().Allocated)
+	transient := k8serrors.NewTooManyRequests("slow down", 1)
+	before, err := metrics.GetBindRetries(string(k8serrors.ReasonForError(transient)))
+	assert.NilError(t, err)
+
+	bindWithFailures := func(failures int, bindErr error) *int {
+		attempts := 0
+		apiProvider.MockBindFn(func(pod *v1.Pod, hostID string) error {
+			attempts++
+			if attempts <= failures {
+				return bindErr
+			}
+			return nil
+		})
+		return &attempts
+	}
+
+	// transient failures within the retry limit
+	attempts := bindWithFailures(2, transient)
+	err = task.bindPodWithRetry()
+	assert.NilError(t, err)
+	assert.Equal(t, *attempts, 3)
+	after, err := metrics.GetBindRetries(string(k8serrors.ReasonForError(transient)))
+	assert.NilError(t, err)
+	assert.Equal(t, after-before, 2)
+
+	// transient failures over the retry limit
+	attempts = bindWithFailures(3, transient)
+	err = task.bindPodWithRetry()
+	assert.Assert(t, k8serrors.IsTooManyRequests(err))
+	assert.Equal(t, *attempts, 3)
+
+	// pod already assigned to another node is not retried
+	conflict := k8serrors.NewConflict(schema.GroupResource{Resource: "pods"}, pod.Name, fmt.Errorf("conflict"))
+	assigned := pod.DeepCopy()
+	assigned.Spec.NodeName = "node-2"
+	_, err = apiProvider.GetAPIs().KubeClient.Create(assigned)
+	assert.NilError(t, err)
+	attempts = bindWithFailures(1, conflict)
+	err = task.bindPodWithRetry()
+	assert.Assert(t, k8serrors.IsConflict(err))
+	assert.Equal(t, *attempts, 1)
+
+	// pod already bound to the allocated node by an earlier attempt
+	assigned.Spec.NodeName = "node-1"
+	_, err = apiProvider.GetAPIs().KubeClient.Create(assigned)
+	assert.NilError(t, err)
+	attempts = bindWithFailures(1, conflict)
+	err = task.bindPodWithRetry()
+	assert.NilError(t, err)
+	assert.Equal(t, *attempts, 1)
+
+	// permanent failure is not retried
+	attempts = bindWithFailures(1, k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, pod.Name))
+	err = task.bindPodWithRetry()
+	assert.Assert(t, k8serrors.IsNotFound(err))
+	assert.Equal(t, *attempts, 1)
+
+	// task completed while waiting for the retry, the task lock is not held while binding
+	apiProvider.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		task.lock.Lock()
+		defer task.lock.Unlock()
+		task.sm.SetState(TaskStates().Completed)
+		return transient
+	})
+	err = task.bindPodWithRetry()
+	assert.Assert(t, errors.Is(err, errBindCancelled))
+
+	// the result of a bind that completes after the task left the Allocated state is ignored
+	state := task.schedulingState
+	task.completeBind(nil, time.Now())
+	assert.Equal(t, task.GetTaskState(), TaskStates().Completed)
+	assert.Equal(t, task.schedulingState, state)
+}
@@ -21,14 +21,20 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
@@ -39,6 +45,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
@@ -57,11 +64,35 @@ type Context struct {
 	apiProvider    client.APIProvider             // apis to interact with api-server, scheduler-core, etc
 	predManager    predicates.PredicateManager    // K8s predicates
 	pluginMode     bool                           // true if we are configured as a scheduler plugin
+	extenders      []framework.Extender           // scheduler extenders, only set in plugin mode
 	namespace      string                         // yunikorn namespace
 	configMaps     []*v1.ConfigMap                // cached yunikorn configmaps
+	askBatcher     *askBatcher                    // batches asks for bulk pod creation, nil if disabled
+	binder         *bindPool                      // limits the concurrent pod binds, nil if disabled
+	dsReservations *daemonSetReservations         // resources reserved for DaemonSet pods on new nodes, nil if disabled
+	deletingNodes  map[string]*deletingNode       // deleted nodes waiting for their pods to be removed
+	queueSelectors *queueNodeSelectors            // node selectors configured on queues
+	failedNodes    *failedNodes                   // nodes with recently failed pods per application
+	migrations     *podMigrations                 // pods deleted from deleted nodes waiting for their replacement
+	claimClasses   *claimClasses                  // resource classes of the claims reported on the nodes
+	nsQueues       *namespaceQueues               // queues generated for namespaces with a parent queue
+	stuckApps      *stuckApps                     // progress of the applications waiting for resources
+	sizing         *placeholderSizing             // requests of previous runs used to size task groups
+	journal        *taskJournal                   // bindings of tasks used during recovery, nil if disabled
+	podGroups      *podGroupSync                  // PodGroups mirroring the gang applications, nil if disabled
+	preemptions    *preemptionWindows             // preemptions between queues within the observation windows
+	advisor        *remediationAdvisor            // suggestions for pods waiting for resources, nil without queue source
+	eventLog       *appEventLog                   // recent events of each application, nil if disabled
+	shadow         *shadowScheduler               // simulated decisions for the default scheduler, nil if disabled
 	lock           *sync.RWMutex                  // lock
 }
 
+// deletingNode tracks a deleted node that is kept until all pods on the node are removed or the grace period expires
+type deletingNode struct {
+	node  *v1.Node
+	timer *time.Timer
+}
+
 // NewContext create a new context for the scheduler using a default (empty) configuration
 // VisibleForTesting
 func NewContext(apis client.APIProvider) *Context {
@@ -76,12 +107,22 @@ func NewContextWithBootstrapConfigMaps(apis client.APIProvider, bootstrapConfigM
 	// nodecontroller needs the cache
 	// predictor need the cache, volumebinder and informers
 	ctx := &Context{
-		applications: make(map[string]*Application),
-		apiProvider:  apis,
-		namespace:    apis.GetAPIs().GetConf().Namespace,
-		configMaps:   bootstrapConfigMaps,
-		lock:         &sync.RWMutex{},
-	}
+		applications:   make(map[string]*Application),
+		apiProvider:    apis,
+		namespace:      apis.GetAPIs().GetConf().Namespace,
+		configMaps:     bootstrapConfigMaps,
+		deletingNodes:  make(map[string]*deletingNode),
+		queueSelectors: newQueueNodeSelectors(),
+		failedNodes:    newFailedNodes(),
+		migrations:     newPodMigrations(),
+		claimClasses:   newClaimClasses(),
+		nsQueues:       newNamespaceQueues(),
+		stuckApps:      newStuckApps(),
+		sizing:         newPlaceholderSizing(),
+		preemptions:    newPreemptionWindows(),
+		lock:           &sync.RWMutex{},
+	}
+	ctx.queueSelectors.update(utils.GetCoreSchedulerConfigFromConfigMap(schedulerconf.FlattenConfigMaps(bootstrapConfigMaps)))
 
 	// create the cache
 	ctx.schedulerCache = schedulercache.NewSchedulerCache(apis.GetAPIs())
@@ -89,6 +130,46 @@ func NewContextWithBootstrapConfigMaps(apis client.APIProvider, bootstrapConfigM
 	// init the controllers and plugins (need the cache)
 	ctx.nodes = newSchedulerNodes(apis.GetAPIs().SchedulerAPI, ctx.schedulerCache)
 
+	// batching of asks is only enabled if an interval is configured
+	schedulerConf := apis.GetAPIs().GetConf()
+	ctx.askBatcher = newAskBatcher(apis.GetAPIs().SchedulerAPI, schedulerConf.AskBatchInterval, schedulerConf.AskBatchSize, ctx.asksSent)
+
+	// the concurrent pod binds are limited by the number of bind workers, the limit is disabled with zero workers
+	ctx.binder = newBindPool(apis, schedulerConf.BindWorkers)
+
+	// node updates are only sampled if an interval is configured, otherwise each change is reported immediately
+	if schedulerConf.NodeSampleInterval > 0 {
+		ctx.nodes.sampler = newNodeSampler()
+	}
+
+	// the task journal is only kept if a location is configured
+	ctx.journal = newTaskJournal(schedulerConf.TaskJournal, ctx.namespace, apis.GetAPIs().KubeClient.GetClientSet())
+
+	// the application event log is only kept if a size is configured
+	ctx.eventLog = newAppEventLog(schedulerConf.AppEventLogSize, schedulerConf.AppEventLogDir)
+
+	// the pods of the default scheduler are only evaluated in shadow mode
+	if schedulerConf.ShadowMode {
+		ctx.shadow = newShadowScheduler(ctx)
+	}
+
+	// PodGroups are only emitted if enabled, a dynamic client is needed as the CRD types are not imported
+	if schedulerConf.PodGroupSync {
+		if restConfig := apis.GetAPIs().KubeClient.GetConfigs(); restConfig != nil {
+			if dynamicClient, err := dynamic.NewForConfig(restConfig); err != nil {
+				log.Log(log.ShimContext).Error("failed to create dynamic client, PodGroups are not emitted", zap.Error(err))
+			} else {
+				ctx.podGroups = newPodGroupSync(dynamicClient)
+			}
+		}
+	}
+
+	// resources are only reserved for DaemonSet pods if a timeout is configured, the informer is not created otherwise
+	if dsInformer := apis.GetAPIs().DaemonSetInformer; dsInformer != nil {
+		ctx.dsReservations = newDaemonSetReservations(dsInformer.Lister(), apis.GetAPIs().PodInformer.Lister(),
+			schedulerConf.DaemonSetReservationTimeout, ctx.releaseDaemonSetReservations)
+	}
+
 	// create the predicate manager
 	sharedLister := support.NewSharedLister(ctx.schedulerCache)
 	clientSet := apis.GetAPIs().KubeClient.GetClientSet()
@@ -114,6 +195,25 @@ func (ctx *Context) AddSchedulingEventHandlers() {
 		DeleteFn: ctx.removePodFromCache,
 	})
 
+	if ctx.shadow != nil {
+		ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
+			Type:     client.PodInformerHandlers,
+			FilterFn: ctx.shadow.filterPods,
+			AddFn:    ctx.shadow.addPod,
+			UpdateFn: ctx.shadow.updatePod,
+			DeleteFn: ctx.shadow.deletePod,
+		})
+	}
+
+	if ctx.dsReservations != nil {
+		ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
+			Type:     client.PodInformerHandlers,
+			FilterFn: filterDaemonSetPods,
+			AddFn:    ctx.addDaemonSetPod,
+			UpdateFn: ctx.updateDaemonSetPod,
+		})
+	}
+
 	nodeCoordinator := newNodeResourceCoordinator(ctx.nodes)
 	ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
 		Type:     client.PodInformerHandlers,
@@ -136,6 +236,12 @@ func (ctx *Context) AddSchedulingEventHandlers() {
 		UpdateFn: ctx.updatePriorityClass,
 		DeleteFn: ctx.deletePriorityClass,
 	})
+	ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
+		Type:     client.NamespaceInformerHandlers,
+		AddFn:    ctx.addNamespace,
+		UpdateFn: ctx.updateNamespace,
+		DeleteFn: ctx.deleteNamespace,
+	})
 }
 
 func (ctx *Context) IsPluginMode() bool {
@@ -146,19 +252,40 @@ func (ctx *Context) SetPluginMode(pluginMode bool) {
 	ctx.pluginMode = pluginMode
 }
 
+// SetExtenders sets the scheduler extenders configured in the default scheduler.
+// The extenders are only available when running as a plugin.
+func (ctx *Context) SetExtenders(extenders []framework.Extender) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.extenders = extenders
+}
+
 func (ctx *Context) addNode(obj interface{}) {
 	node, err := convertToNode(obj)
 	if err != nil {
 		log.Log(log.ShimContext).Error("node conversion failed", zap.Error(err))
 		return
 	}
+	node = ctx.claimClasses.applyCapacity(utils.ApplyNodeOvercommit(node))
+
+	// a node that is re-added while waiting for its pods to be removed must become schedulable again
+	if ctx.cancelNodeDeletion(node.Name) {
+		log.Log(log.ShimContext).Info("deleted node re-added, cancelling node deletion", zap.String("nodeName", node.Name))
+		ctx.nodes.restoreNode(node.Name)
+	}
 
 	// add node to secondary scheduler cache
 	log.Log(log.ShimContext).Warn("adding node to cache", zap.String("NodeName", node.Name))
 	ctx.schedulerCache.AddNode(node)
+	ctx.queueSelectors.nodesChanged()
 
-	// add node to internal cache
-	ctx.nodes.addNode(node)
+	// add node to internal cache, a node that joins the cluster is registered with the resources of the
+	// DaemonSet pods that are not placed on the node yet reserved
+	if ctx.dsReservations != nil && ctx.nodes.getNode(node.Name) == nil {
+		ctx.nodes.addReservedNode(node, ctx.dsReservations.reserve(node))
+	} else {
+		ctx.nodes.addNode(node)
+	}
 
 	// post the event
 	events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeAccepted", "NodeAccepted",
@@ -181,8 +308,14 @@ func (ctx *Context) updateNode(oldObj, newObj interface{}) {
 		return
 	}
 
+	// the overcommit ratios are applied to both nodes, a change of the ratios is a change of the capacity,
+	// the claim resource types are added to both nodes as they are only reported on a change of the classes
+	oldNode = ctx.claimClasses.applyCapacity(utils.ApplyNodeOvercommit(oldNode))
+	newNode = ctx.claimClasses.applyCapacity(utils.ApplyNodeOvercommit(newNode))
+
 	// update secondary cache
 	ctx.schedulerCache.UpdateNode(newNode)
+	ctx.queueSelectors.nodesChanged()
 
 	// update primary cache
 	ctx.nodes.updateNode(oldNode, newNode)
@@ -205,18 +338,171 @@ func (ctx *Context) deleteNode(obj interface{}) {
 		return
 	}
 
+	switch schedulerconf.GetSchedulerConf().GetNodeDeletionMode() {
+	case schedulerconf.NodeDeletionModeWait:
+		ctx.waitForNodePods(node)
+	case schedulerconf.NodeDeletionModeMigrate:
+		pods := ctx.getPodsOnNode(node.Name)
+		ctx.removeNode(node)
+		ctx.migratePods(node.Name, pods)
+	default:
+		ctx.removeNode(node)
+	}
+}
+
+// removeNode removes the node from the caches and decommissions the node in the core,
+// which releases all allocations that are still on the node.
+func (ctx *Context) removeNode(node *v1.Node) {
 	// delete node from secondary cache
 	log.Log(log.ShimContext).Debug("delete node from cache", zap.String("nodeName", node.Name))
 	ctx.schedulerCache.RemoveNode(node)
+	ctx.queueSelectors.nodesChanged()
 
 	// delete node from primary cache
 	ctx.nodes.deleteNode(node)
+	if ctx.dsReservations != nil {
+		ctx.dsReservations.releaseNode(node.Name)
+	}
 
 	// post the event
 	events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeDeleted", "NodeDeleted",
 		fmt.Sprintf("node %s is deleted from the scheduler", node.Name))
 }
 
+// waitForNodePods postpones the removal of a deleted node until all pods on the node are removed.
+// The node is drained in the core to prevent new allocations, the node is removed when the last pod
+// is removed or when the grace period expires, whichever comes first.
+func (ctx *Context) waitForNodePods(node *v1.Node) {
+	pods := ctx.getPodsOnNode(node.Name)
+	if len(pods) == 0 {
+		ctx.removeNode(node)
+		return
+	}
+
+	gracePeriod := schedulerconf.GetSchedulerConf().GetNodeDeletionGracePeriod()
+	ctx.lock.Lock()
+	if _, ok := ctx.deletingNodes[node.Name]; ok {
+		ctx.lock.Unlock()
+		return
+	}
+	nodeName := node.Name
+	ctx.deletingNodes[nodeName] = &deletingNode{
+		node: node,
+		timer: time.AfterFunc(gracePeriod, func() {
+			log.Log(log.ShimContext).Warn("grace period expired for deleted node, releasing remaining allocations",
+				zap.String("nodeName", nodeName),
+				zap.Int("numOfPods", len(ctx.getPodsOnNode(nodeName))))
+			ctx.finishNodeDeletion(nodeName)
+		}),
+	}
+	ctx.lock.Unlock()
+
+	log.Log(log.ShimContext).Info("node deleted with pods still running, waiting for pods to be removed",
+		zap.String("nodeName", nodeName),
+		zap.Int("numOfPods", len(pods)),
+		zap.Duration("gracePeriod", gracePeriod))
+	ctx.nodes.drainNode(nodeName)
+}
+
+// checkDeletingNode removes a deleted node that was waiting for its pods once the last pod is gone.
+func (ctx *Context) checkDeletingNode(nodeName string) {
+	if nodeName == "" {
+		return
+	}
+	ctx.lock.RLock()
+	_, ok := ctx.deletingNodes[nodeName]
+	ctx.lock.RUnlock()
+	if ok && len(ctx.getPodsOnNode(nodeName)) == 0 {
+		ctx.finishNodeDeletion(nodeName)
+	}
+}
+
+func (ctx *Context) finishNodeDeletion(nodeName string) {
+	ctx.lock.Lock()
+	pending, ok := ctx.deletingNodes[nodeName]
+	if ok {
+		pending.timer.Stop()
+		delete(ctx.deletingNodes, nodeName)
+	}
+	ctx.lock.Unlock()
+	if ok {
+		ctx.removeNode(pending.node)
+	}
+}
+
+// cancelNodeDeletion stops waiting for the pods of a deleted node.
+// Returns true if the node was waiting for its pods to be removed.
+func (ctx *Context) cancelNodeDeletion(nodeName string) bool {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	pending, ok := ctx.deletingNodes[nodeName]
+	if ok {
+		pending.timer.Stop()
+		delete(ctx.deletingNodes, nodeName)
+	}
+	return ok
+}
+
+// migratePods deletes the pods from a deleted node that are managed by a controller which recreates them.
+// The replacement pods are scheduled on the remaining nodes, their asks are marked as replacing a migrated pod.
+func (ctx *Context) migratePods(nodeName string, pods []*v1.Pod) {
+	replaceable := make([]*v1.Pod, 0)
+	for _, pod := range pods {
+		if isReplaceablePod(pod) {
+			replaceable = append(replaceable, pod)
+		}
+	}
+	if len(replaceable) == 0 {
+		return
+	}
+	log.Log(log.ShimContext).Info("migrating pods from deleted node",
+		zap.String("nodeName", nodeName),
+		zap.Int("numOfPods", len(replaceable)))
+	go func() {
+		for _, pod := range replaceable {
+			events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeNormal, "PodMigration", "PodMigration",
+				"node %s is deleted, pod is deleted to allow its controller to replace it", nodeName)
+			if err := ctx.apiProvider.GetAPIs().KubeClient.Delete(pod); err != nil {
+				log.Log(log.ShimContext).Warn("failed to delete pod from deleted node",
+					zap.String("podName", pod.Name),
+					zap.String("namespace", pod.Namespace),
+					zap.Error(err))
+				continue
+			}
+			ctx.migrations.record(metav1.GetControllerOf(pod).UID, nodeName, time.Now())
+		}
+	}()
+}
+
+// getPodsOnNode returns the pods assigned to the node in the scheduler cache.
+func (ctx *Context) getPodsOnNode(nodeName string) []*v1.Pod {
+	ctx.schedulerCache.LockForReads()
+	defer ctx.schedulerCache.UnlockForReads()
+	pods := make([]*v1.Pod, 0)
+	if nodeInfo, ok := ctx.schedulerCache.GetNodesInfoMap()[nodeName]; ok {
+		for _, podInfo := range nodeInfo.Pods {
+			pods = append(pods, podInfo.Pod)
+		}
+	}
+	return pods
+}
+
+// isReplaceablePod returns true if the pod is managed by a controller that recreates the pod after deletion.
+// DaemonSet pods are bound to a node and are never replaceable. Job pods are not replaceable: the Job controller
+// counts a deleted pod as failed against the backoff limit of the Job.
+func isReplaceablePod(pod *v1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false
+	}
+	switch owner.Kind {
+	case "ReplicaSet", "StatefulSet", "ReplicationController":
+		return true
+	default:
+		return false
+	}
+}
+
 func (ctx *Context) addPodToCache(obj interface{}) {
 	pod, err := utils.Convert2Pod(obj)
 	if err != nil {
@@ -254,10 +540,11 @@ func (ctx *Context) removePodFromCache(obj interface{}) {
 
 	log.Log(log.ShimContext).Debug("removing pod from cache", zap.String("podName", pod.Name))
 	ctx.schedulerCache.RemovePod(pod)
+	ctx.checkDeletingNode(pod.Spec.NodeName)
 }
 
 func (ctx *Context) updatePodInCache(oldObj, newObj interface{}) {
-	_, err := utils.Convert2Pod(oldObj)
+	oldPod, err := utils.Convert2Pod(oldObj)
 	if err != nil {
 		log.Log(log.ShimContext).Error("failed to update pod in cache", zap.Error(err))
 		return
@@ -268,10 +555,18 @@ func (ctx *Context) updatePodInCache(oldObj, newObj interface{}) {
 		return
 	}
 
+	if utils.IsPodRejectedByKubelet(newPod) && !utils.IsPodRejectedByKubelet(oldPod) {
+		ctx.handleKubeletRejection(newPod)
+	}
+	if newPod.Status.Phase == v1.PodFailed && oldPod.Status.Phase != v1.PodFailed {
+		ctx.recordPodFailure(newPod)
+	}
+
 	// treat terminated pods like a remove
 	if utils.IsPodTerminated(newPod) {
 		log.Log(log.ShimContext).Debug("Request to update terminated pod, removing from cache", zap.String("podName", newPod.Name))
 		ctx.schedulerCache.RemovePod(newPod)
+		ctx.checkDeletingNode(newPod.Spec.NodeName)
 		return
 	}
 
@@ -382,6 +677,114 @@ func (ctx *Context) updatePriorityClass(_, newObj interface{}) {
 	}
 }
 
+// updateNamespace keeps the namespace quota of the applications in the namespace in sync with the annotations.
+// The queue generated for a namespace with a parent queue is updated in the core through the configuration,
+// see namespaceQueues. For all other namespaces the core applies the quota to the dynamic queue of the namespace when an application
+// is added to the queue: the scheduler interface has no message to update a queue. Applications that are not
+// submitted yet, or are recovered, use the updated quota. The queue of running applications keeps the old quota
+// until the next application is added, and a removed quota is not cleared from the queue.
+func (ctx *Context) updateNamespace(oldObj, newObj interface{}) {
+	oldNamespace := utils.Convert2Namespace(oldObj)
+	newNamespace := utils.Convert2Namespace(newObj)
+	if oldNamespace == nil || newNamespace == nil {
+		return
+	}
+	ctx.checkNamespaceQueue(newNamespace.Name, getNamespaceQueue(newNamespace))
+	oldTags := getNamespaceQuotaTags(oldNamespace)
+	newTags := getNamespaceQuotaTags(newNamespace)
+	if reflect.DeepEqual(oldTags, newTags) {
+		return
+	}
+	log.Log(log.ShimContext).Info("namespace quota changed",
+		zap.String("namespace", newNamespace.Name),
+		zap.Any("quota", newTags))
+
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	for _, app := range ctx.applications {
+		if app.GetTags()[constants.AppTagNamespace] == newNamespace.Name {
+			app.setNamespaceQuotaTags(newTags)
+		}
+	}
+}
+
+func (ctx *Context) addNamespace(obj interface{}) {
+	if namespace := utils.Convert2Namespace(obj); namespace != nil {
+		ctx.checkNamespaceQueue(namespace.Name, getNamespaceQueue(namespace))
+	}
+}
+
+func (ctx *Context) deleteNamespace(obj interface{}) {
+	var namespace *v1.Namespace
+	switch t := obj.(type) {
+	case *v1.Namespace:
+		namespace = t
+	case cache.DeletedFinalStateUnknown:
+		namespace = utils.Convert2Namespace(t.Obj)
+	default:
+		log.Log(log.ShimContext).Warn("unable to convert to namespace")
+		return
+	}
+	if namespace != nil {
+		ctx.checkNamespaceQueue(namespace.Name, namespaceQueue{})
+	}
+}
+
+// checkNamespaceQueue sends an updated configuration to the core if the queue generated for the namespace changed
+func (ctx *Context) checkNamespaceQueue(namespace string, queue namespaceQueue) {
+	if !schedulerconf.GetSchedulerConf().IsNamespaceQueues() || !ctx.nsQueues.changed(namespace, queue) {
+		return
+	}
+	log.Log(log.ShimContext).Info("namespace queue changed, reloading scheduler configuration",
+		zap.String("namespace", namespace),
+		zap.String("parentQueue", queue.parent))
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if !ctx.apiProvider.GetAPIs().GetConf().EnableConfigHotRefresh {
+		log.Log(log.ShimContext).Info("hot-refresh disabled, skipping scheduler configuration update")
+		return
+	}
+	ctx.updateCoreConfig()
+}
+
+// GetCoreSchedulerConfig resolves the flattened configmaps into the core scheduler config,
+// including the queues generated for namespaces with a parent queue if enabled.
+func (ctx *Context) GetCoreSchedulerConfig(confMap map[string]string) string {
+	config := utils.GetCoreSchedulerConfigFromConfigMap(confMap)
+	var namespaces []*v1.Namespace
+	if schedulerconf.GetSchedulerConf().IsNamespaceQueues() {
+		var err error
+		namespaces, err = ctx.apiProvider.GetAPIs().NamespaceInformer.Lister().List(labels.Everything())
+		if err != nil {
+			log.Log(log.ShimContext).Error("failed to list namespaces, namespace queues not added", zap.Error(err))
+			return config
+		}
+	}
+	return ctx.nsQueues.apply(config, namespaces)
+}
+
+// getNamespaceQuotaTags returns the application tags for the quota and guaranteed resources set on the namespace.
+// Only tags with a non-zero resource are returned.
+func getNamespaceQuotaTags(namespaceObj *v1.Namespace) map[string]string {
+	tags := make(map[string]string)
+	// add resource quota info as an app tag
+	resourceQuota := utils.GetNamespaceQuotaFromAnnotation(namespaceObj)
+	if resourceQuota != nil && !common.IsZero(resourceQuota) {
+		if quota, err := json.Marshal(resourceQuota); err == nil {
+			tags[siCommon.AppTagNamespaceResourceQuota] = string(quota)
+		}
+	}
+
+	// add guaranteed resource info as an app tag
+	guaranteedResource := utils.GetNamespaceGuaranteedFromAnnotation(namespaceObj)
+	if guaranteedResource != nil && !common.IsZero(guaranteedResource) {
+		if guaranteed, err := json.Marshal(guaranteedResource); err == nil {
+			tags[siCommon.AppTagNamespaceResourceGuaranteed] = string(guaranteed)
+		}
+	}
+	return tags
+}
+
 func (ctx *Context) deletePriorityClass(obj interface{}) {
 	log.Log(log.ShimContext).Debug("priorityClass deleted")
 	var priorityClass *schedulingv1.PriorityClass
@@ -415,13 +818,18 @@ func (ctx *Context) triggerReloadConfig(index int, configMap *v1.ConfigMap) {
 		log.Log(log.ShimContext).Error("Unable to update configmap, ignoring changes", zap.Error(err))
 		return
 	}
+	ctx.updateCoreConfig()
+}
 
+// updateCoreConfig sends the configuration from the cached configmaps to the core, must be called holding the lock
+func (ctx *Context) updateCoreConfig() {
 	confMap := schedulerconf.FlattenConfigMaps(ctx.configMaps)
 
-	conf = ctx.apiProvider.GetAPIs().GetConf()
+	conf := ctx.apiProvider.GetAPIs().GetConf()
 	log.Log(log.ShimContext).Info("reloading scheduler configuration")
-	config := utils.GetCoreSchedulerConfigFromConfigMap(confMap)
+	config := ctx.GetCoreSchedulerConfig(confMap)
 	extraConfig := utils.GetExtraConfigFromConfigMap(confMap)
+	ctx.queueSelectors.update(config)
 
 	request := &si.UpdateConfigurationRequest{
 		RmID:        conf.ClusterID,
@@ -441,6 +849,19 @@ func (ctx *Context) EventsToRegister() []framework.ClusterEvent {
 
 // evaluate given predicates based on current context
 func (ctx *Context) IsPodFitNode(name, node string, allocate bool) error {
+	pod, targetNode, err := ctx.runPredicates(name, node, allocate)
+	if err != nil {
+		return err
+	}
+	// extenders are called outside the locks as they make remote calls
+	if extender, err := ctx.runExtenders(pod, targetNode); err != nil {
+		metrics.IncSchedulingFailure(metrics.ShimPredicate, extender)
+		return err
+	}
+	return nil
+}
+
+func (ctx *Context) runPredicates(name, node string, allocate bool) (*v1.Pod, *v1.Node, error) {
 	ctx.lock.RLock()
 	defer ctx.lock.RUnlock()
 	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
@@ -449,11 +870,98 @@ func (ctx *Context) IsPodFitNode(name, node string, allocate bool) error {
 			// need to lock cache here as predicates need a stable view into the cache
 			ctx.schedulerCache.LockForReads()
 			defer ctx.schedulerCache.UnlockForReads()
-			_, err := ctx.predManager.Predicates(pod, targetNode, allocate)
-			return err
+			plugin, err := ctx.predManager.Predicates(pod, targetNode, allocate)
+			if err == nil {
+				if err = ctx.checkQueueNodeSelector(pod, targetNode.Node()); err != nil {
+					plugin = queueNodeSelectorPredicate
+				} else if err = ctx.checkPodClaims(pod, targetNode.Node()); err != nil {
+					plugin = resourceClaimsPredicate
+				}
+			}
+			if err != nil {
+				metrics.IncSchedulingFailure(metrics.ShimPredicate, plugin)
+			}
+			return pod, targetNode.Node(), err
 		}
 	}
-	return fmt.Errorf("predicates were not running because pod or node was not found in cache")
+	return nil, nil, fmt.Errorf("predicates were not running because pod or node was not found in cache")
+}
+
+// checkQueueNodeSelector checks that the node matches the node selector of the queue of the pod's application.
+// Must be called while holding the context lock.
+func (ctx *Context) checkQueueNodeSelector(pod *v1.Pod, node *v1.Node) error {
+	app := ctx.getApplication(utils.GetApplicationIDFromPod(pod))
+	if app == nil {
+		return nil
+	}
+	selector := ctx.queueSelectors.get(app.GetQueue())
+	if selector == nil || selector.Matches(labels.Set(node.Labels)) {
+		return nil
+	}
+	return fmt.Errorf("node %s does not match the node selector %q of queue %s", node.Name, selector.String(), app.GetQueue())
+}
+
+// checkQueueNodeAvailability emits a warning event for the pod if no node matches the node selector of the queue
+// of the application, the pod cannot be scheduled until a matching node is added. The event is only emitted for
+// the first pod of the application.
+func (ctx *Context) checkQueueNodeAvailability(app *Application, pod *v1.Pod) {
+	selector := ctx.queueSelectors.get(app.GetQueue())
+	if selector == nil || pod == nil || utils.IsAssignedPod(pod) {
+		return
+	}
+	if ctx.queueSelectors.hasMatchingNode(selector, ctx.hasNodeMatching) || !app.setQueueNodesUnavailable() {
+		return
+	}
+	log.Log(log.ShimContext).Warn("no node matches the node selector of the queue",
+		zap.String("appID", app.applicationID),
+		zap.String("queue", app.GetQueue()),
+		zap.Stringer("selector", selector))
+	events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, "QueueNodeSelectorUnsatisfiable", "QueueNodeSelectorUnsatisfiable",
+		"no node matches the node selector %q of queue %s", selector.String(), app.GetQueue())
+}
+
+// hasNodeMatching returns true if a node in the scheduler cache matches the selector
+func (ctx *Context) hasNodeMatching(selector labels.Selector) bool {
+	ctx.schedulerCache.LockForReads()
+	defer ctx.schedulerCache.UnlockForReads()
+	for _, nodeInfo := range ctx.schedulerCache.GetNodesInfo() {
+		if node := nodeInfo.Node(); node != nil && selector.Matches(labels.Set(node.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// runExtenders calls the filter of the scheduler extenders that are interested in the pod, in the same way the
+// default scheduler does. An error is returned if the node does not pass the filter, or if an extender that is not
+// ignorable fails. The name of the extender that rejected the node is returned with the error.
+func (ctx *Context) runExtenders(pod *v1.Pod, node *v1.Node) (string, error) {
+	ctx.lock.RLock()
+	extenders := ctx.extenders
+	ctx.lock.RUnlock()
+	for _, extender := range extenders {
+		if !extender.IsInterested(pod) {
+			continue
+		}
+		feasible, failed, failedAndUnresolvable, err := extender.Filter(pod, []*v1.Node{node})
+		if err != nil {
+			if extender.IsIgnorable() {
+				log.Log(log.ShimContext).Info("Skipping extender as it returned error and is ignorable",
+					zap.String("extender", extender.Name()),
+					zap.Error(err))
+				continue
+			}
+			return extender.Name(), fmt.Errorf("extender %s failed: %w", extender.Name(), err)
+		}
+		if len(feasible) == 0 {
+			reason, ok := failedAndUnresolvable[node.Name]
+			if !ok {
+				reason = failed[node.Name]
+			}
+			return extender.Name(), fmt.Errorf("node %s rejected by extender %s: %s", node.Name, extender.Name(), reason)
+		}
+	}
+	return "", nil
 }
 
 func (ctx *Context) IsPodFitNodeViaPreemption(name, node string, allocations []string, startIndex int) (index int, ok bool) {
@@ -467,6 +975,10 @@ func (ctx *Context) IsPodFitNodeViaPreemption(name, node string, allocations []s
 	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
 		// if pod exists in cache, try to run predicates
 		if targetNode := ctx.schedulerCache.GetNode(node); targetNode != nil {
+			// preemption cannot make a node usable that the queue is not allowed to use
+			if ctx.checkQueueNodeSelector(pod, targetNode.Node()) != nil {
+				return -1, false
+			}
 			// need to lock cache here as predicates need a stable view into the cache
 			ctx.schedulerCache.LockForReads()
 			defer ctx.schedulerCache.UnlockForReads()
@@ -484,6 +996,18 @@ func (ctx *Context) IsPodFitNodeViaPreemption(name, node string, allocations []s
 
 			// check predicates for a match
 			if index, ok := ctx.predManager.PreemptionPredicates(pod, targetNode, victims, startIndex); ok {
+				// do not allow the preemption if the victims are protected by a PodDisruptionBudget,
+				// the core will then look for alternative victims
+				if pdbInformer := ctx.apiProvider.GetAPIs().PDBInformer; pdbInformer != nil {
+					if violations := getPDBViolations(pdbInformer.Lister(), victims[:index+1]); len(violations) > 0 {
+						log.Log(log.ShimContext).Info("preemption would violate PodDisruptionBudget",
+							zap.String("podName", pod.Name),
+							zap.String("nodeID", node),
+							zap.Int("violations", len(violations)),
+							zap.String("firstViolation", violations[0].Namespace+"/"+violations[0].Name))
+						return -1, false
+					}
+				}
 				return index, ok
 			}
 		}
@@ -579,6 +1103,10 @@ func (ctx *Context) AssumePod(name string, node string) error {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
 	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
+		// a bound pod is allocated again after an in-place resize: it already runs on the node
+		if pod.Spec.NodeName != "" && pod.Spec.NodeName == node {
+			return nil
+		}
 		// when add assumed pod, we make a copy of the pod to avoid
 		// modifying its original reference. otherwise, it may have
 		// race when some other go-routines accessing it in parallel.
@@ -622,9 +1150,15 @@ func (ctx *Context) AssumePod(name string, node string) error {
 						zap.Error(err))
 					return err
 				}
-				allBound, err = ctx.apiProvider.GetAPIs().VolumeBinder.AssumePodVolumes(pod, node, volumes)
-				if err != nil {
-					return err
+				if ctx.pluginMode {
+					// the VolumeBinding plugin of the default scheduler assumes the volumes in Reserve and binds them
+					// in PreBind, assuming them here as well would leave assumptions behind that are never bound
+					allBound = volumes == nil || (len(volumes.StaticBindings) == 0 && len(volumes.DynamicProvisions) == 0)
+				} else {
+					allBound, err = ctx.apiProvider.GetAPIs().VolumeBinder.AssumePodVolumes(pod, node, volumes)
+					if err != nil {
+						return err
+					}
 				}
 			}
 			// assign the node name for pod
@@ -716,6 +1250,12 @@ func (ctx *Context) NotifyTaskComplete(appID, taskID string) {
 	}
 }
 
+func (ctx *Context) NotifyTaskResourceUpdate(appID, taskID string, pod *v1.Pod) {
+	if task := ctx.getTask(appID, taskID); task != nil {
+		task.updateResource(pod)
+	}
+}
+
 // update application tags in the AddApplicationRequest based on the namespace annotation
 // adds the following tags to the request based on annotations (if exist):
 //   - namespace.resourcequota
@@ -725,20 +1265,9 @@ func (ctx *Context) updateApplicationTags(request *interfaces.AddApplicationRequ
 	if namespaceObj == nil {
 		return
 	}
-	// add resource quota info as an app tag
-	resourceQuota := utils.GetNamespaceQuotaFromAnnotation(namespaceObj)
-	if resourceQuota != nil && !common.IsZero(resourceQuota) {
-		if quota, err := json.Marshal(resourceQuota); err == nil {
-			request.Metadata.Tags[siCommon.AppTagNamespaceResourceQuota] = string(quota)
-		}
-	}
-
-	// add guaranteed resource info as an app tag
-	guaranteedResource := utils.GetNamespaceGuaranteedFromAnnotation(namespaceObj)
-	if guaranteedResource != nil && !common.IsZero(guaranteedResource) {
-		if guaranteed, err := json.Marshal(guaranteedResource); err == nil {
-			request.Metadata.Tags[siCommon.AppTagNamespaceResourceGuaranteed] = string(guaranteed)
-		}
+	// add resource quota and guaranteed resource info as app tags
+	for key, value := range getNamespaceQuotaTags(namespaceObj) {
+		request.Metadata.Tags[key] = value
 	}
 
 	// add parent queue info as an app tag
@@ -746,6 +1275,11 @@ func (ctx *Context) updateApplicationTags(request *interfaces.AddApplicationRequ
 	if parentQueue != "" {
 		request.Metadata.Tags[constants.AppTagNamespaceParentQueue] = parentQueue
 	}
+
+	// the partition set on the pod overrides the partition of the namespace
+	if request.Metadata.Partition == "" {
+		request.Metadata.Partition = utils.GetNameSpaceAnnotationValue(namespaceObj, constants.LabelPartition)
+	}
 }
 
 // returns the namespace object from the namespace's name
@@ -791,7 +1325,10 @@ func (ctx *Context) AddApplication(request *interfaces.AddApplicationRequest) in
 		request.Metadata.Groups,
 		request.Metadata.Tags,
 		ctx.apiProvider.GetAPIs().SchedulerAPI)
-	app.setTaskGroups(request.Metadata.TaskGroups)
+	if request.Metadata.Partition != "" {
+		app.partition = request.Metadata.Partition
+	}
+	app.setTaskGroups(ctx.sizeTaskGroups(request.Metadata))
 	app.setTaskGroupsDefinition(request.Metadata.Tags[constants.AnnotationTaskGroups])
 	app.setSchedulingParamsDefinition(request.Metadata.Tags[constants.AnnotationSchedulingPolicyParam])
 	if request.Metadata.CreationTime != 0 {
@@ -802,6 +1339,11 @@ func (ctx *Context) AddApplication(request *interfaces.AddApplicationRequest) in
 		app.setSchedulingStyle(request.Metadata.SchedulingPolicyParameters.GetGangSchedulingStyle())
 	}
 	app.setPlaceholderOwnerReferences(request.Metadata.OwnerReferences)
+	app.setStrictFIFO(request.Metadata.StrictFIFO)
+	app.setMaxRunDuration(request.Metadata.MaxRunDuration)
+	app.eventLog = ctx.eventLog
+	app.eventLog.record(app.applicationID, "Submitted", "", "Application %s submitted to queue %s by user %s",
+		app.applicationID, app.queue, app.user)
 
 	// add into cache
 	ctx.applications[app.applicationID] = app
@@ -812,16 +1354,7 @@ func (ctx *Context) AddApplication(request *interfaces.AddApplicationRequest) in
 }
 
 func (ctx *Context) IsPreemptSelfAllowed(priorityClassName string) bool {
-	priorityClass := ctx.schedulerCache.GetPriorityClass(priorityClassName)
-	if priorityClass == nil {
-		return true
-	}
-	if value, ok := priorityClass.Annotations[constants.AnnotationAllowPreemption]; ok {
-		if value == constants.False {
-			return false
-		}
-	}
-	return true
+	return utils.IsPreemptSelfAllowed(ctx.schedulerCache.GetPriorityClass(priorityClassName))
 }
 
 func (ctx *Context) GetApplication(appID string) interfaces.ManagedApp {
@@ -849,10 +1382,16 @@ func (ctx *Context) RemoveApplication(appID string) error {
 		}
 		// send the update request to scheduler core
 		rr := common.CreateUpdateRequestForRemoveApplication(app.applicationID, app.partition)
-		if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateApplication(rr); err != nil {
+		if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateApplication(rr); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 			log.Log(log.ShimContext).Error("failed to send remove application request to core", zap.Error(err))
 		}
 		delete(ctx.applications, appID)
+		ctx.failedNodes.remove(appID)
+		ctx.sizing.finish(appID)
+		ctx.eventLog.remove(appID)
+		if app.isService() {
+			metrics.RemoveServiceApplication(app.GetTags()[constants.AppTagNamespace], appID)
+		}
 		log.Log(log.ShimContext).Info("app removed",
 			zap.String("appID", appID))
 
@@ -863,12 +1402,74 @@ func (ctx *Context) RemoveApplication(appID string) error {
 
 func (ctx *Context) RemoveApplicationInternal(appID string) {
 	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
-	if _, exist := ctx.applications[appID]; !exist {
+	app, exist := ctx.applications[appID]
+	if !exist {
+		ctx.lock.Unlock()
 		log.Log(log.ShimContext).Debug("Attempted to remove non-existent application", zap.String("appID", appID))
 		return
 	}
 	delete(ctx.applications, appID)
+	ctx.lock.Unlock()
+
+	if app.isService() {
+		metrics.RemoveServiceApplication(app.GetTags()[constants.AppTagNamespace], appID)
+		// the application added again under the same ID keeps the state tracked for it
+		if ctx.resubmitServiceApplication(app) {
+			return
+		}
+	}
+	ctx.failedNodes.remove(appID)
+	ctx.sizing.finish(appID)
+	ctx.eventLog.remove(appID)
+}
+
+// resubmitServiceApplication adds a service application that was completed by the core back into the shim.
+// The core completes an application without allocations and asks after a timeout. Pods of a service that
+// arrive in the meantime, e.g. during a rolling update, would otherwise be left without an application.
+// Only tasks that have not been sent to the core yet are moved to the new application. Returns true if the
+// application was added again: the failed nodes and the event log of the application are kept, the placeholder
+// sizing run is handed over to the new application.
+func (ctx *Context) resubmitServiceApplication(app *Application) bool {
+	app.lock.RLock()
+	tasks := make([]interfaces.TaskMetadata, 0)
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state == TaskStates().New || state == TaskStates().Pending {
+			tasks = append(tasks, interfaces.TaskMetadata{
+				ApplicationID: app.applicationID,
+				TaskID:        task.taskID,
+				Pod:           task.pod,
+				Placeholder:   task.placeholder,
+				TaskGroupName: task.taskGroupName,
+			})
+		}
+	}
+	request := &interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID:   app.applicationID,
+			QueueName:       app.queue,
+			Partition:       app.partition,
+			User:            app.user,
+			Groups:          app.groups,
+			Tags:            utils.MergeMaps(app.tags, nil),
+			OwnerReferences: app.placeholderOwnerReferences,
+		},
+	}
+	app.lock.RUnlock()
+	if len(tasks) == 0 {
+		return false
+	}
+
+	log.Log(log.ShimContext).Info("service application completed with pending pods, resubmitting",
+		zap.String("appID", request.Metadata.ApplicationID),
+		zap.Int("numOfTasks", len(tasks)))
+	run := ctx.sizing.take(app.applicationID)
+	ctx.AddApplication(request)
+	ctx.sizing.resume(app.applicationID, run)
+	for _, taskMeta := range tasks {
+		ctx.AddTask(&interfaces.AddTaskRequest{Metadata: taskMeta})
+	}
+	return true
 }
 
 // this implements ApplicationManagementProtocol
@@ -893,13 +1494,27 @@ func (ctx *Context) AddTask(request *interfaces.AddTaskRequest) interfaces.Manag
 						}
 					}
 				}
-				task := NewFromTaskMeta(request.Metadata.TaskID, app, ctx, request.Metadata, originator)
+				// a recreated originating pod takes over from the previous one instead of orphaning the application
+				failover := !originator && app.isOriginatorReplacement(request.Metadata.Pod)
+				task := NewFromTaskMeta(request.Metadata.TaskID, app, ctx, request.Metadata, originator || failover)
 				app.addTask(task)
+				if app.isMaxRunDurationExceeded() {
+					app.terminateTasks([]*Task{task})
+				}
+				if !request.Metadata.Placeholder && request.Metadata.TaskGroupName != "" {
+					ctx.sizing.observe(app.applicationID, request.Metadata.TaskGroupName, request.Metadata.Pod)
+				}
+				ctx.checkQueueNodeAvailability(app, request.Metadata.Pod)
 				log.Log(log.ShimContext).Info("task added",
 					zap.String("appID", app.applicationID),
 					zap.String("taskID", task.taskID),
 					zap.String("taskState", task.GetTaskState()))
-				if originator {
+				if failover {
+					app.replaceOriginatingTask(task)
+					log.Log(log.ShimContext).Info("app request originating pod replaced",
+						zap.String("appID", app.applicationID),
+						zap.String("original task", task.GetTaskID()))
+				} else if originator {
 					if app.GetOriginatingTask() != nil {
 						log.Log(log.ShimContext).Error("Inconsistent state - found another originator task for an application",
 							zap.String("taskId", task.GetTaskID()))
@@ -926,6 +1541,18 @@ func (ctx *Context) RemoveTask(appID, taskID string) {
 		return
 	}
 	app.removeTask(taskID)
+	if ctx.journal != nil {
+		ctx.journal.release(taskID)
+	}
+}
+
+// asksSent passes the result of sending a batch of asks to the core back to the tasks
+func (ctx *Context) asksSent(asks []*si.AllocationAsk, err error) {
+	for _, ask := range asks {
+		if task := ctx.getTask(ask.ApplicationID, ask.AllocationKey); task != nil {
+			task.askSent(err)
+		}
+	}
 }
 
 func (ctx *Context) getTask(appID string, taskID string) *Task {
@@ -1043,11 +1670,11 @@ func (ctx *Context) HandleContainerStateUpdate(request *si.UpdateContainerSchedu
 					Type:    v1.PodScheduled,
 					Status:  v1.ConditionFalse,
 					Reason:  "SchedulingSkipped",
-					Message: request.Reason,
+					Message: utils.FormatSchedulingReason(schedulingReasonCode(task, request), request.Reason),
 				}) {
 				events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
 					v1.EventTypeNormal, "PodUnschedulable", "PodUnschedulable",
-					"Task %s is skipped from scheduling because the queue quota has been exceed", task.alias)
+					"Task %s is skipped from scheduling because the queue quota has been exceed%s", task.alias, ctx.getRemediation(task))
 			}
 		case si.UpdateContainerSchedulingStateRequest_FAILED:
 			task.SetTaskSchedulingState(interfaces.TaskSchedFailed)
@@ -1057,11 +1684,11 @@ func (ctx *Context) HandleContainerStateUpdate(request *si.UpdateContainerSchedu
 					Type:    v1.PodScheduled,
 					Status:  v1.ConditionFalse,
 					Reason:  v1.PodReasonUnschedulable,
-					Message: request.Reason,
+					Message: utils.FormatSchedulingReason(schedulingReasonCode(task, request), request.Reason),
 				}) {
 				events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
 					v1.EventTypeNormal, "PodUnschedulable", "PodUnschedulable",
-					"Task %s is pending for the requested resources become available", task.alias)
+					"Task %s is pending for the requested resources become available%s", task.alias, ctx.getRemediation(task))
 			}
 		default:
 			log.Log(log.ShimContext).Warn("no handler for container scheduling state",
@@ -1070,6 +1697,25 @@ func (ctx *Context) HandleContainerStateUpdate(request *si.UpdateContainerSchedu
 	}
 }
 
+// schedulingReasonCode maps the scheduling state update from the core to the scheduling reason code set on the pod
+// condition. The core only reports a free form reason: the codes that cannot be derived from the state or the task
+// are matched on the reason text.
+func schedulingReasonCode(task *Task, request *si.UpdateContainerSchedulingStateRequest) string {
+	reason := strings.ToLower(request.Reason)
+	switch {
+	case strings.Contains(reason, "queue") && (strings.Contains(reason, "stopped") || strings.Contains(reason, "draining")):
+		return constants.SchedulingReasonQueueStopped
+	case strings.Contains(reason, "pressure"):
+		return constants.SchedulingReasonNodePressure
+	case request.State == si.UpdateContainerSchedulingStateRequest_SKIPPED:
+		return constants.SchedulingReasonQuotaExceeded
+	case task.placeholder || (task.taskGroupName != "" && task.application.GetApplicationState() == ApplicationStates().Reserving):
+		return constants.SchedulingReasonGangWaiting
+	default:
+		return constants.SchedulingReasonPredicateFailed
+	}
+}
+
 func (ctx *Context) ApplicationEventHandler() func(obj interface{}) {
 	return func(obj interface{}) {
 		if event, ok := obj.(events.ApplicationEvent); ok {
@@ -1142,7 +1788,8 @@ func (ctx *Context) GetStateDump() (string, error) {
 	log.Log(log.ShimContext).Info("State dump requested")
 
 	dump := map[string]interface{}{
-		"cache": ctx.schedulerCache.GetSchedulerCacheDao(),
+		"cache":        ctx.schedulerCache.GetSchedulerCacheDao(),
+		"applications": ctx.getApplicationDaos(),
 	}
 
 	bytes, err := json.Marshal(dump)
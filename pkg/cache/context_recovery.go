@@ -70,7 +70,7 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 
 	// add all known nodes to cache, waiting for recover
 	for _, node := range allNodes {
-		ctx.nodes.addAndReportNode(node, false)
+		ctx.nodes.addAndReportNode(ctx.claimClasses.applyCapacity(utils.ApplyNodeOvercommit(node)), false)
 	}
 
 	pods, err := ctx.apiProvider.GetAPIs().PodInformer.Lister().List(labels.Everything())
@@ -79,6 +79,7 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 	}
 
 	nodeOccupiedResources := make(map[string]*si.Resource)
+	journalTasks := make(map[string]bool)
 	for _, pod := range pods {
 		// only handle assigned pods
 		if !utils.IsAssignedPod(pod) {
@@ -91,7 +92,13 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 		ykPod := utils.GetApplicationIDFromPod(pod) != ""
 		switch {
 		case ykPod:
-			if existingAlloc := getExistingAllocation(mgr, pod); existingAlloc != nil {
+			existingAlloc := ctx.getJournalAllocation(pod)
+			if existingAlloc != nil {
+				journalTasks[existingAlloc.AllocationKey] = true
+			} else {
+				existingAlloc = getExistingAllocation(mgr, pod)
+			}
+			if existingAlloc != nil {
 				log.Log(log.ShimContext).Debug("Adding resources for existing pod",
 					zap.String("appID", existingAlloc.ApplicationID),
 					zap.String("podUID", string(pod.UID)),
@@ -114,11 +121,15 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 			// and it has a node assigned, that means the scheduler
 			// has already allocated the pod onto a node
 			// we should report this occupied resource to scheduler-core
+			podResource := common.GetPodResource(pod)
+			ctx.nodes.cache.AddPod(pod)
+			if ctx.nodes.addExemptPod(pod, podResource) {
+				continue
+			}
 			occupiedResource := nodeOccupiedResources[pod.Spec.NodeName]
 			if occupiedResource == nil {
 				occupiedResource = common.NewResourceBuilder().Build()
 			}
-			podResource := common.GetPodResource(pod)
 			log.Log(log.ShimContext).Debug("Adding resources for occupied pod",
 				zap.String("podUID", string(pod.UID)),
 				zap.String("podName", fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)),
@@ -126,7 +137,6 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 				zap.Stringer("resources", podResource))
 			occupiedResource = common.Add(occupiedResource, podResource)
 			nodeOccupiedResources[pod.Spec.NodeName] = occupiedResource
-			ctx.nodes.cache.AddPod(pod)
 		default:
 			log.Log(log.ShimContext).Debug("Skipping terminated pod",
 				zap.String("podUID", string(pod.UID)),
@@ -134,6 +144,11 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 		}
 	}
 
+	// bindings of pods that are gone or have moved are not needed anymore
+	if ctx.journal != nil {
+		ctx.journal.retain(journalTasks)
+	}
+
 	// why we need to calculate the occupied resources here? why not add an event-handler
 	// in node_coordinator#addPod?
 	// this is because the occupied resources must be calculated and counted before the
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,15 +34,20 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	k8sEvents "k8s.io/client-go/tools/events"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
 
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/common/test"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/conf/queuegen"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
@@ -140,6 +146,48 @@ func TestUpdateNodes(t *testing.T) {
 	assert.Equal(t, int64(4000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.CPU].Value)
 }
 
+func TestUpdateNodesOvercommit(t *testing.T) {
+	ctx := initContextForTest()
+
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:        "host0001",
+			Namespace:   "default",
+			UID:         "uid_0001",
+			Annotations: map[string]string{constants.AnnotationCPUOvercommit: "1.5"},
+		},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceMemory: *resource.NewQuantity(1024*1000*1000, resource.DecimalSI),
+				v1.ResourceCPU:    *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		},
+	}
+	ctx.addNode(node)
+	assert.Equal(t, int64(3000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.CPU].Value)
+	assert.Equal(t, int64(1024*1000*1000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.Memory].Value)
+	assert.Equal(t, int64(3000), ctx.schedulerCache.GetNode("host0001").Allocatable.MilliCPU)
+	// the informer object is not modified
+	assert.Equal(t, int64(2000), node.Status.Allocatable.Cpu().MilliValue())
+
+	// changing the ratios updates the capacity
+	updated := node.DeepCopy()
+	updated.Annotations = map[string]string{
+		constants.AnnotationCPUOvercommit:    "2",
+		constants.AnnotationMemoryOvercommit: "1.25",
+	}
+	ctx.updateNode(node, updated)
+	assert.Equal(t, int64(4000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.CPU].Value)
+	assert.Equal(t, int64(1280*1000*1000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.Memory].Value)
+	assert.Equal(t, int64(4000), ctx.schedulerCache.GetNode("host0001").Allocatable.MilliCPU)
+
+	// removing the ratios restores the allocatable resources
+	node.Annotations = nil
+	ctx.updateNode(updated, node)
+	assert.Equal(t, int64(2000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.CPU].Value)
+	assert.Equal(t, int64(1024*1000*1000), ctx.nodes.getNode("host0001").capacity.Resources[siCommon.Memory].Value)
+}
+
 func TestDeleteNodes(t *testing.T) {
 	ctx := initContextForTest()
 
@@ -168,6 +216,180 @@ func TestDeleteNodes(t *testing.T) {
 	assert.Equal(t, true, ctx.nodes.getNode("host0001") == nil)
 }
 
+func TestDeleteNodeWaitForPods(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	defer setNodeDeletionModeForTest(conf.NodeDeletionModeWait, time.Hour)()
+	actions := recordNodeActionsForTest(apiProvider)
+
+	node := nodeForTest("host0001")
+	ctx.addNode(node)
+	pod := newPodHelper("pod1", "default", "pod-uid-1", "host0001", "app01", v1.PodRunning)
+	ctx.addPodToCache(pod)
+
+	// node is drained and kept until the pod is removed
+	ctx.deleteNode(node)
+	assert.Assert(t, ctx.schedulerCache.GetNode("host0001") != nil, "node removed while pods are running")
+	assert.Assert(t, ctx.nodes.getNode("host0001") != nil, "node removed while pods are running")
+	assert.Equal(t, actions.last(), si.NodeInfo_DRAIN_NODE)
+
+	// second delete event for the same node is ignored
+	ctx.deleteNode(node)
+	assert.Equal(t, len(ctx.deletingNodes), 1)
+
+	ctx.removePodFromCache(pod)
+	assert.Assert(t, ctx.schedulerCache.GetNode("host0001") == nil, "node not removed after last pod")
+	assert.Assert(t, ctx.nodes.getNode("host0001") == nil, "node not removed after last pod")
+	assert.Equal(t, actions.last(), si.NodeInfo_DECOMISSION)
+	assert.Equal(t, len(ctx.deletingNodes), 0)
+}
+
+func TestDeleteNodeWaitGracePeriod(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	defer setNodeDeletionModeForTest(conf.NodeDeletionModeWait, 50*time.Millisecond)()
+	actions := recordNodeActionsForTest(apiProvider)
+
+	node := nodeForTest("host0001")
+	ctx.addNode(node)
+	ctx.addPodToCache(newPodHelper("pod1", "default", "pod-uid-1", "host0001", "app01", v1.PodRunning))
+
+	ctx.deleteNode(node)
+	assert.Equal(t, actions.last(), si.NodeInfo_DRAIN_NODE)
+	err := utils.WaitForCondition(func() bool {
+		return ctx.nodes.getNode("host0001") == nil
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "node not removed after grace period")
+	assert.Equal(t, actions.last(), si.NodeInfo_DECOMISSION)
+}
+
+func TestDeleteNodeWaitNodeReAdded(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	defer setNodeDeletionModeForTest(conf.NodeDeletionModeWait, time.Hour)()
+	actions := recordNodeActionsForTest(apiProvider)
+
+	node := nodeForTest("host0001")
+	ctx.addNode(node)
+	ctx.addPodToCache(newPodHelper("pod1", "default", "pod-uid-1", "host0001", "app01", v1.PodRunning))
+
+	ctx.deleteNode(node)
+	assert.Equal(t, actions.last(), si.NodeInfo_DRAIN_NODE)
+	ctx.addNode(node)
+	assert.Assert(t, actions.contains(si.NodeInfo_DRAIN_TO_SCHEDULABLE), "node not restored")
+	assert.Equal(t, len(ctx.deletingNodes), 0)
+	assert.Assert(t, ctx.nodes.getNode("host0001") != nil, "node removed")
+}
+
+func TestDeleteNodeMigrate(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	defer setNodeDeletionModeForTest(conf.NodeDeletionModeMigrate, time.Hour)()
+	actions := recordNodeActionsForTest(apiProvider)
+	deleted := make(chan string, 10)
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deleted <- pod.Name
+		return nil
+	})
+
+	node := nodeForTest("host0001")
+	ctx.addNode(node)
+	controlled := newPodHelper("pod1", "default", "pod-uid-1", "host0001", "app01", v1.PodRunning)
+	controller := true
+	controlled.OwnerReferences = []apis.OwnerReference{
+		{Kind: "ReplicaSet", Name: "rs1", UID: "rs-uid-1", Controller: &controller},
+	}
+	ctx.addPodToCache(controlled)
+	ctx.addPodToCache(newPodHelper("pod2", "default", "pod-uid-2", "host0001", "app01", v1.PodRunning))
+	job := newPodHelper("pod3", "default", "pod-uid-3", "host0001", "app01", v1.PodRunning)
+	job.OwnerReferences = []apis.OwnerReference{
+		{Kind: "Job", Name: "job1", UID: "job-uid-1", Controller: &controller},
+	}
+	ctx.addPodToCache(job)
+
+	ctx.deleteNode(node)
+	assert.Assert(t, ctx.nodes.getNode("host0001") == nil, "node not removed")
+	assert.Equal(t, actions.last(), si.NodeInfo_DECOMISSION)
+	select {
+	case name := <-deleted:
+		assert.Equal(t, name, "pod1", "wrong pod migrated")
+	case <-time.After(time.Second):
+		t.Fatal("controlled pod not deleted")
+	}
+	select {
+	case name := <-deleted:
+		t.Fatalf("unexpected pod deleted: %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the replacement created by the controller is marked in the core
+	replacement := controlled.DeepCopy()
+	replacement.Name = "pod4"
+	asks := []*si.AllocationAsk{{AllocationKey: "pod-uid-4"}}
+	ctx.addMigrationHint(replacement, asks)
+	assert.Equal(t, asks[0].Tags[constants.TagMigratedFromNode], "host0001")
+}
+
+type nodeActionRecorder struct {
+	actions []si.NodeInfo_ActionFromRM
+	sync.Mutex
+}
+
+func (r *nodeActionRecorder) last() si.NodeInfo_ActionFromRM {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.actions) == 0 {
+		return si.NodeInfo_UNKNOWN_ACTION_FROM_RM
+	}
+	return r.actions[len(r.actions)-1]
+}
+
+func (r *nodeActionRecorder) contains(action si.NodeInfo_ActionFromRM) bool {
+	r.Lock()
+	defer r.Unlock()
+	for _, a := range r.actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func recordNodeActionsForTest(apiProvider *client.MockedAPIProvider) *nodeActionRecorder {
+	recorder := &nodeActionRecorder{}
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		recorder.Lock()
+		defer recorder.Unlock()
+		for _, node := range request.Nodes {
+			recorder.actions = append(recorder.actions, node.Action)
+		}
+		return nil
+	})
+	return recorder
+}
+
+func nodeForTest(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid_" + name),
+		},
+	}
+}
+
+// setNodeDeletionModeForTest changes the node deletion config and returns a func that restores the previous values
+func setNodeDeletionModeForTest(mode string, gracePeriod time.Duration) func() {
+	schedulerConf := conf.GetSchedulerConf()
+	schedulerConf.Lock()
+	defer schedulerConf.Unlock()
+	oldMode, oldGracePeriod := schedulerConf.NodeDeletionMode, schedulerConf.NodeDeletionGracePeriod
+	schedulerConf.NodeDeletionMode = mode
+	schedulerConf.NodeDeletionGracePeriod = gracePeriod
+	return func() {
+		schedulerConf.Lock()
+		defer schedulerConf.Unlock()
+		schedulerConf.NodeDeletionMode = oldMode
+		schedulerConf.NodeDeletionGracePeriod = oldGracePeriod
+	}
+}
+
 func TestAddApplications(t *testing.T) {
 	context := initContextForTest()
 
@@ -334,6 +556,46 @@ func TestRemoveApplicationInternal(t *testing.T) {
 	assert.Equal(t, ok, false)
 }
 
+func TestRemoveApplicationInternalService(t *testing.T) {
+	context := initContextForTest()
+	appID := "service-app"
+	tags := map[string]string{
+		constants.AppTagNamespace:              "ns1",
+		constants.AnnotationApplicationProfile: constants.ApplicationProfileService,
+	}
+	app := NewApplication(appID, "root.a", "testuser", testGroups, tags, newMockSchedulerAPI())
+	context.applications[appID] = app
+	newPod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "pod-new", Namespace: "ns1", UID: "UID-NEW"}}
+	app.addTask(NewTask("UID-NEW", app, context, newPod))
+	donePod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "pod-done", Namespace: "ns1", UID: "UID-DONE"}}
+	done := NewTask("UID-DONE", app, context, donePod)
+	done.sm.SetState(TaskStates().Completed)
+	app.addTask(done)
+	context.failedNodes.record(appID, "node-1", time.Now(), time.Hour)
+	context.sizing.start(appID, "ns1/Deployment/web")
+
+	// completed in the core with a task that was not sent yet: the application is added back with its state
+	context.RemoveApplicationInternal(appID)
+	resubmitted, ok := context.applications[appID]
+	assert.Assert(t, ok, "service application not resubmitted")
+	assert.Assert(t, resubmitted != app, "application should have been replaced")
+	assert.Assert(t, resubmitted.isService(), "resubmitted application lost the service profile")
+	assert.Equal(t, resubmitted.GetQueue(), "root.a")
+	assert.Equal(t, len(resubmitted.GetNewTasks()), 1)
+	_, err := resubmitted.GetTask("UID-NEW")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, context.failedNodes.recent(appID, time.Hour, time.Now()), []string{"node-1"})
+	assert.Assert(t, context.sizing.running[appID] != nil, "sizing run not handed over")
+
+	// nothing left to schedule: the application is removed and its state cleaned up
+	resubmitted.taskMap["UID-NEW"].sm.SetState(TaskStates().Completed)
+	context.RemoveApplicationInternal(appID)
+	_, ok = context.applications[appID]
+	assert.Assert(t, !ok, "service application without pending tasks not removed")
+	assert.Equal(t, len(context.failedNodes.recent(appID, time.Hour, time.Now())), 0)
+	assert.Assert(t, context.sizing.running[appID] == nil, "sizing run not finished")
+}
+
 func TestFilterPods(t *testing.T) {
 	context := initContextForTest()
 	pod1 := &v1.Pod{
@@ -479,6 +741,309 @@ func TestUpdatePodInCache(t *testing.T) {
 	}
 }
 
+func TestUpdatePodInCacheKubeletRejected(t *testing.T) {
+	context := initContextForTest()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "yunikorn-test-00001",
+			UID:  "UID-00001",
+		},
+		Spec: v1.PodSpec{SchedulerName: "yunikorn", NodeName: "node-1"},
+	}
+	rejected := pod.DeepCopy()
+	rejected.Status = v1.PodStatus{
+		Phase:   v1.PodFailed,
+		Reason:  "OutOfmemory",
+		Message: "Pod was rejected: Node didn't have enough resource: memory",
+	}
+	before, err := metrics.GetSchedulingFailures(metrics.KubeletAdmission, "OutOfmemory")
+	assert.NilError(t, err)
+
+	context.addPodToCache(pod)
+	context.updatePodInCache(pod, rejected)
+	_, ok := context.schedulerCache.GetPod("UID-00001")
+	assert.Check(t, !ok, "rejected pod still found in cache")
+	// an update of an already rejected pod is not counted again
+	context.updatePodInCache(rejected, rejected)
+
+	after, err := metrics.GetSchedulingFailures(metrics.KubeletAdmission, "OutOfmemory")
+	assert.NilError(t, err)
+	assert.Equal(t, after-before, 1)
+}
+
+func TestUpdatePodInCacheKubeletRejectedOwner(t *testing.T) {
+	context := initContextForTest()
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	controller := true
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "yunikorn-test-00001",
+			Namespace: "default",
+			UID:       "UID-00001",
+			OwnerReferences: []apis.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs-1", UID: "UID-RS-1", Controller: &controller},
+			},
+		},
+		Spec: v1.PodSpec{SchedulerName: "yunikorn", NodeName: "node-1"},
+	}
+	rejected := pod.DeepCopy()
+	rejected.Status = v1.PodStatus{
+		Phase:   v1.PodFailed,
+		Reason:  "OutOfcpu",
+		Message: "Pod was rejected: Node didn't have enough resource: cpu",
+	}
+
+	context.updatePodInCache(pod, rejected)
+	// one event for the pod and one for the owner
+	assert.Equal(t, len(recorder.Events), 2)
+	event := <-recorder.Events
+	assert.Assert(t, strings.Contains(event, "PodRejectedByKubelet"), "unexpected event: %s", event)
+	event = <-recorder.Events
+	assert.Assert(t, strings.Contains(event, "reason=OutOfcpu"), "unexpected owner event: %s", event)
+
+	// placeholders are not reported to the owner
+	placeholder := rejected.DeepCopy()
+	placeholder.Annotations = map[string]string{constants.AnnotationPlaceholderFlag: "true"}
+	context.updatePodInCache(pod, placeholder)
+	assert.Equal(t, len(recorder.Events), 1)
+	<-recorder.Events
+}
+
+type mockExtender struct {
+	name      string
+	interest  bool
+	ignorable bool
+	err       error
+	reject    bool
+}
+
+func (m *mockExtender) Name() string {
+	return m.name
+}
+
+func (m *mockExtender) Filter(_ *v1.Pod, nodes []*v1.Node) ([]*v1.Node, extenderv1.FailedNodesMap, extenderv1.FailedNodesMap, error) {
+	if m.err != nil {
+		return nil, nil, nil, m.err
+	}
+	if m.reject {
+		failed := extenderv1.FailedNodesMap{}
+		for _, node := range nodes {
+			failed[node.Name] = "network bandwidth exhausted"
+		}
+		return nil, failed, nil, nil
+	}
+	return nodes, nil, nil, nil
+}
+
+func (m *mockExtender) Prioritize(_ *v1.Pod, _ []*v1.Node) (*extenderv1.HostPriorityList, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockExtender) Bind(_ *v1.Binding) error {
+	return nil
+}
+
+func (m *mockExtender) IsBinder() bool {
+	return false
+}
+
+func (m *mockExtender) IsInterested(_ *v1.Pod) bool {
+	return m.interest
+}
+
+func (m *mockExtender) ProcessPreemption(_ *v1.Pod, victims map[string]*extenderv1.Victims, _ framework.NodeInfoLister) (map[string]*extenderv1.Victims, error) {
+	return victims, nil
+}
+
+func (m *mockExtender) SupportsPreemption() bool {
+	return false
+}
+
+func (m *mockExtender) IsIgnorable() bool {
+	return m.ignorable
+}
+
+func TestIsPodFitNodeExtenders(t *testing.T) {
+	context := initContextForTest()
+	context.schedulerCache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: "node-1", UID: "uid-node-1"},
+	})
+	context.addPodToCache(&v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "yunikorn-test-00001", UID: "UID-00001"},
+		Spec:       v1.PodSpec{SchedulerName: "yunikorn"},
+	})
+	assert.NilError(t, context.IsPodFitNode("UID-00001", "node-1", false))
+
+	tests := map[string]struct {
+		extender *mockExtender
+		fit      bool
+	}{
+		"not interested": {&mockExtender{name: "ext", reject: true}, true},
+		"accepted":       {&mockExtender{name: "ext", interest: true}, true},
+		"rejected":       {&mockExtender{name: "ext", interest: true, reject: true}, false},
+		"failed":         {&mockExtender{name: "ext", interest: true, err: fmt.Errorf("unavailable")}, false},
+		"ignorable":      {&mockExtender{name: "ext", interest: true, ignorable: true, err: fmt.Errorf("unavailable")}, true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			context.SetExtenders([]framework.Extender{tc.extender})
+			err := context.IsPodFitNode("UID-00001", "node-1", false)
+			if tc.fit {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "extender ext")
+			}
+		})
+	}
+}
+
+func TestIsPodFitNodeQueueNodeSelector(t *testing.T) {
+	context := initContextForTest()
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	context.queueSelectors.update(queueNodeSelectorConfig)
+	context.schedulerCache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: "node-1", UID: "uid-node-1", Labels: map[string]string{"pool": "teamA"}},
+	})
+	context.schedulerCache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: "node-2", UID: "uid-node-2", Labels: map[string]string{"pool": "teamB"}},
+	})
+	for _, queue := range []string{"root.teamA", "root.open", "root.dynamic.child"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: queue,
+				QueueName:     queue,
+				User:          "test-user",
+			},
+		})
+		pod := &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:   "pod-" + queue,
+				UID:    types.UID(queue),
+				Labels: map[string]string{constants.LabelApplicationID: queue},
+			},
+			Spec: v1.PodSpec{SchedulerName: "yunikorn"},
+		}
+		context.addPodToCache(pod)
+		context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: queue,
+				TaskID:        queue,
+				Pod:           pod,
+			},
+		})
+	}
+
+	assert.NilError(t, context.IsPodFitNode("root.teamA", "node-1", false))
+	assert.ErrorContains(t, context.IsPodFitNode("root.teamA", "node-2", false), "does not match the node selector")
+	assert.NilError(t, context.IsPodFitNode("root.open", "node-1", false))
+	assert.NilError(t, context.IsPodFitNode("root.open", "node-2", false))
+
+	// only the pod in the queue without matching nodes gets an event
+	assert.Equal(t, len(recorder.Events), 1)
+	event := <-recorder.Events
+	assert.Assert(t, strings.Contains(event, "QueueNodeSelectorUnsatisfiable"), "unexpected event: %s", event)
+	assert.Assert(t, strings.Contains(event, "root.dynamic.child"), "unexpected event: %s", event)
+
+	// the event is only emitted once per application
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:   "pod-root.dynamic.child-2",
+			UID:    "root.dynamic.child-2",
+			Labels: map[string]string{constants.LabelApplicationID: "root.dynamic.child"},
+		},
+		Spec: v1.PodSpec{SchedulerName: "yunikorn"},
+	}
+	context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: "root.dynamic.child",
+			TaskID:        "root.dynamic.child-2",
+			Pod:           pod,
+		},
+	})
+	assert.Equal(t, len(recorder.Events), 0)
+
+	// a node added to the cluster is found for the selector
+	selector := context.queueSelectors.get("root.dynamic.child")
+	assert.Assert(t, !context.queueSelectors.hasMatchingNode(selector, context.hasNodeMatching))
+	context.addNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: "node-3", UID: "uid-node-3", Labels: map[string]string{"pool": "dynamic"}},
+	})
+	assert.Assert(t, context.queueSelectors.hasMatchingNode(selector, context.hasNodeMatching))
+}
+
+func TestFailedNodesHint(t *testing.T) {
+	context := initContextForTest()
+	for _, name := range []string{"node-1", "node-2", "node-3"} {
+		context.schedulerCache.AddNode(&v1.Node{
+			ObjectMeta: apis.ObjectMeta{Name: name, UID: types.UID("uid-" + name)},
+		})
+	}
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app-avoid",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	newPod := func(name, nodeName string, annotations map[string]string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:        name,
+				UID:         types.UID(name),
+				Labels:      map[string]string{constants.LabelApplicationID: "app-avoid"},
+				Annotations: annotations,
+			},
+			Spec:   v1.PodSpec{SchedulerName: "yunikorn", NodeName: nodeName},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	failPod := func(name, nodeName string) {
+		pod := newPod(name, nodeName, map[string]string{constants.AnnotationAvoidFailedNodes: "true"})
+		failed := pod.DeepCopy()
+		failed.Status.Phase = v1.PodFailed
+		context.updatePodInCache(pod, failed)
+	}
+	for _, pod := range []*v1.Pod{
+		newPod("avoid", "", map[string]string{constants.AnnotationAvoidFailedNodes: "true"}),
+		newPod("plain", "", nil),
+	} {
+		pod.Status.Phase = v1.PodPending
+		context.addPodToCache(pod)
+		context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app-avoid",
+				TaskID:        pod.Name,
+				Pod:           pod,
+			},
+		})
+	}
+
+	hint := func(name string) string {
+		pod, ok := context.schedulerCache.GetPod(name)
+		assert.Assert(t, ok, "pod not found")
+		asks := []*si.AllocationAsk{{AllocationKey: name}}
+		context.addFailedNodesHint(pod, asks)
+		return asks[0].Tags[constants.TagPreferredNodeAntiAffinity]
+	}
+
+	failPod("failed-1", "node-1")
+	assert.Equal(t, hint("avoid"), "node-1")
+	// pods without the annotation are not affected
+	assert.Equal(t, hint("plain"), "")
+	failPod("failed-2", "node-2")
+	assert.Equal(t, hint("avoid"), "node-1,node-2")
+
+	// the avoidance is a preference, the predicates do not reject the failed nodes
+	assert.NilError(t, context.IsPodFitNode("avoid", "node-1", false))
+	assert.NilError(t, context.IsPodFitNode("avoid", "node-2", false))
+
+	// failures are dropped with the application
+	context.RemoveApplicationInternal("app-avoid")
+	assert.Equal(t, len(context.failedNodes.recent("app-avoid", time.Hour, time.Now())), 0)
+}
+
 func TestRemovePodFromCache(t *testing.T) {
 	context := initContextForTest()
 
@@ -589,6 +1154,66 @@ func TestAddTask(t *testing.T) {
 	assert.Equal(t, len(context.applications["app00001"].GetNewTasks()), 2)
 }
 
+func TestAddTaskOriginatorFailover(t *testing.T) {
+	context := initContextForTest()
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+
+	isController := true
+	controller := apis.OwnerReference{Kind: "SparkApplication", Name: "spark", UID: "uid-controller", Controller: &isController}
+	driver := func(name, uid string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: apis.ObjectMeta{
+			Name:            name,
+			Namespace:       "ns1",
+			UID:             types.UID(uid),
+			OwnerReferences: []apis.OwnerReference{controller},
+		}}
+	}
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID:   "app00001",
+			QueueName:       "root.a",
+			User:            "test-user",
+			Tags:            map[string]string{constants.AppTagNamespace: "ns1"},
+			OwnerReferences: []apis.OwnerReference{{Kind: "Pod", Name: "driver", UID: "uid-1"}},
+		},
+	})
+	app := context.applications["app00001"]
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{ApplicationID: "app00001", TaskID: "uid-1", Pod: driver("driver", "uid-1")},
+	})
+	assert.Equal(t, app.GetOriginatingTask(), task)
+
+	// same controller but the originating task is still running: not a replacement
+	task = context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{ApplicationID: "app00001", TaskID: "uid-2", Pod: driver("executor", "uid-2")},
+	})
+	assert.Assert(t, !task.(*Task).IsOriginator())
+	assert.Equal(t, app.GetOriginatingTask().GetTaskID(), "uid-1")
+
+	// recreated with the same name: the application is bound to the new pod
+	task = context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{ApplicationID: "app00001", TaskID: "uid-3", Pod: driver("driver", "uid-3")},
+	})
+	assert.Assert(t, task.(*Task).IsOriginator())
+	assert.Equal(t, app.GetOriginatingTask(), task)
+	refs := app.getPlaceholderOwnerReferences()
+	assert.Equal(t, len(refs), 1)
+	assert.Equal(t, refs[0].Name, "driver")
+	assert.Equal(t, refs[0].UID, types.UID("uid-3"))
+	assert.Equal(t, len(recorder.Events), 1)
+	event := <-recorder.Events
+	assert.Assert(t, strings.Contains(event, "OriginatorReplaced"), "unexpected event: %s", event)
+
+	// recreated by the controller with a new name after the originating task terminated
+	task.(*Task).sm.SetState(TaskStates().Completed)
+	task = context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{ApplicationID: "app00001", TaskID: "uid-4", Pod: driver("driver-2", "uid-4")},
+	})
+	assert.Equal(t, app.GetOriginatingTask(), task)
+	assert.Equal(t, app.getPlaceholderOwnerReferences()[0].UID, types.UID("uid-4"))
+}
+
 func TestRecoverTask(t *testing.T) {
 	context := initContextForTest()
 
@@ -1218,6 +1843,157 @@ func TestAddApplicationsWithTags(t *testing.T) {
 	}
 }
 
+func TestAddApplicationPartition(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name:        "partitioned",
+			Annotations: map[string]string{constants.LabelPartition: "gpu"},
+		},
+	})
+
+	addApp := func(appID, namespace, partition string) *Application {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     "root.a",
+				Partition:     partition,
+				User:          "test-user",
+				Tags: map[string]string{
+					constants.AppTagNamespace: namespace,
+				},
+			},
+		})
+		app, ok := context.GetApplication(appID).(*Application)
+		assert.Assert(t, ok, "application %s not added", appID)
+		return app
+	}
+	assert.Equal(t, addApp("app00001", "default", "").partition, constants.DefaultPartition)
+	assert.Equal(t, addApp("app00002", "default", "batch").partition, "batch")
+	assert.Equal(t, addApp("app00003", "partitioned", "").partition, "gpu")
+	// the partition of the pod overrides the partition of the namespace
+	assert.Equal(t, addApp("app00004", "partitioned", "batch").partition, "batch")
+}
+
+func TestUpdateNamespaceQuota(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	requests := make([]*si.ApplicationRequest, 0)
+	apiProvider.MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		requests = append(requests, request)
+		return nil
+	})
+	oldNs := &v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				constants.NamespaceQuota: "{\"cpu\": \"1\", \"memory\": \"256M\"}",
+			},
+		},
+	}
+	for _, app := range []struct{ id, ns string }{{"app00001", "test"}, {"app00002", "other"}} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: app.id,
+				QueueName:     "root.a",
+				User:          "test-user",
+				Tags:          map[string]string{constants.AppTagNamespace: app.ns},
+			},
+		})
+	}
+	app1, ok := context.GetApplication("app00001").(*Application)
+	assert.Assert(t, ok)
+	app1.setNamespaceQuotaTags(getNamespaceQuotaTags(oldNs))
+	app1.sm.SetState(ApplicationStates().Running)
+	oldTags := app1.GetTags()
+
+	// unrelated annotation change: tags untouched
+	newNs := oldNs.DeepCopy()
+	newNs.Annotations["other"] = "value"
+	context.updateNamespace(oldNs, newNs)
+	assert.Equal(t, app1.GetTags()[siCommon.AppTagNamespaceResourceQuota], oldTags[siCommon.AppTagNamespaceResourceQuota])
+
+	// quota and guaranteed updated: only the app in the namespace changes
+	newNs = oldNs.DeepCopy()
+	newNs.Annotations[constants.NamespaceQuota] = "{\"cpu\": \"2\", \"memory\": \"512M\"}"
+	newNs.Annotations[constants.NamespaceGuaranteed] = "{\"cpu\": \"1\"}"
+	context.updateNamespace(oldNs, newNs)
+	quota := si.Resource{}
+	err := json.Unmarshal([]byte(app1.GetTags()[siCommon.AppTagNamespaceResourceQuota]), &quota)
+	assert.NilError(t, err)
+	assert.Equal(t, quota.Resources[siCommon.CPU].Value, int64(2000))
+	assert.Equal(t, quota.Resources[siCommon.Memory].Value, int64(512*1000*1000))
+	assert.Assert(t, app1.GetTags()[siCommon.AppTagNamespaceResourceGuaranteed] != "")
+	assert.Equal(t, app1.GetTags()[constants.AppTagNamespace], "test")
+	// the previous map is not modified
+	assert.Equal(t, oldTags[siCommon.AppTagNamespaceResourceQuota], getNamespaceQuotaTags(oldNs)[siCommon.AppTagNamespaceResourceQuota])
+	app2 := context.GetApplication("app00002").(*Application)
+	assert.Equal(t, app2.GetTags()[siCommon.AppTagNamespaceResourceQuota], "")
+	// no applications are added to or removed from the core to update the queue
+	assert.Equal(t, len(requests), 0, "unexpected core updates")
+
+	// quota removed
+	context.updateNamespace(newNs, &v1.Namespace{ObjectMeta: apis.ObjectMeta{Name: "test"}})
+	_, ok = app1.GetTags()[siCommon.AppTagNamespaceResourceQuota]
+	assert.Assert(t, !ok, "quota tag not removed")
+	_, ok = app1.GetTags()[siCommon.AppTagNamespaceResourceGuaranteed]
+	assert.Assert(t, !ok, "guaranteed tag not removed")
+
+	// not a namespace: no panic
+	context.updateNamespace(nil, newNs)
+}
+
+func TestAssumePodVolumes(t *testing.T) {
+	for _, pluginMode := range []bool{false, true} {
+		t.Run(fmt.Sprintf("pluginMode=%t", pluginMode), func(t *testing.T) {
+			context, apiProvider := initContextAndAPIProviderForTest()
+			context.SetPluginMode(pluginMode)
+			binder := volumebinding.NewFakeVolumeBinder(&volumebinding.FakeVolumeBinderConfig{AllBound: false})
+			apiProvider.GetAPIs().VolumeBinder = binder
+
+			context.addNode(&v1.Node{
+				ObjectMeta: apis.ObjectMeta{
+					Name: "host0001",
+					UID:  "uid_0001",
+				},
+			})
+			pod := newPodHelper("pod1", "default", "pod-uid-1", "", "app-1", v1.PodPending)
+			context.addPodToCache(pod)
+
+			err := context.AssumePod("pod-uid-1", "host0001")
+			assert.NilError(t, err)
+			assumed, ok := context.schedulerCache.GetPod("pod-uid-1")
+			assert.Assert(t, ok, "pod not found in cache")
+			assert.Equal(t, assumed.Spec.NodeName, "host0001", "pod not assumed on node")
+			// in plugin mode the volumes are assumed and bound by the VolumeBinding plugin of the default scheduler
+			assert.Equal(t, binder.AssumeCalled, !pluginMode)
+			assert.Equal(t, context.schedulerCache.ArePodVolumesAllBound("pod-uid-1"), pluginMode)
+		})
+	}
+}
+
+func TestAssumeBoundPod(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	binder := volumebinding.NewFakeVolumeBinder(&volumebinding.FakeVolumeBinderConfig{AllBound: false})
+	apiProvider.GetAPIs().VolumeBinder = binder
+	context.addNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "uid_0001",
+		},
+	})
+	pod := newPodHelper("pod1", "default", "pod-uid-1", "host0001", "app-1", v1.PodRunning)
+	context.addPodToCache(pod)
+
+	// a resized pod is allocated again on the node it runs on
+	err := context.AssumePod("pod-uid-1", "host0001")
+	assert.NilError(t, err)
+	assert.Assert(t, !binder.AssumeCalled, "volumes of bound pod assumed")
+}
+
 func TestPendingPodAllocations(t *testing.T) {
 	context := initContextForTest()
 	context.SetPluginMode(true)
@@ -1364,6 +2140,43 @@ func TestGetStateDump(t *testing.T) {
 	assert.Equal(t, string(pod1.UID), uid, "wrong uid")
 }
 
+func TestGetSnapshot(t *testing.T) {
+	context := initContextForTest()
+	app1 := NewApplication("app00001", "root.a", "testuser", testGroups, map[string]string{"namespace": "default"}, newMockSchedulerAPI())
+	app2 := NewApplication("app00002", "root.b", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.applications["app00002"] = app2
+	context.applications["app00001"] = app1
+	pod1 := newPodHelper("pod-1", "default", "UID-00001", "", "app00001", v1.PodPending)
+	pod2 := newPodHelper("pod-2", "default", "UID-00002", "node-1", "app00001", v1.PodRunning)
+	task1 := NewTask("task01", app1, context, pod1)
+	task1.sm.SetState(TaskStates().Pending)
+	app1.taskMap["task01"] = task1
+	task2 := NewTask("task02", app1, context, pod2)
+	task2.sm.SetState(TaskStates().Bound)
+	task2.nodeName = "node-1"
+	app1.taskMap["task02"] = task2
+	context.addPodToCache(pod1)
+
+	snapshot := context.GetSnapshot()
+	assert.Equal(t, len(snapshot.Applications), 2)
+	assert.Equal(t, snapshot.Applications[0].ApplicationID, "app00001")
+	assert.Equal(t, snapshot.Applications[0].Queue, "root.a")
+	assert.Equal(t, snapshot.Applications[0].State, ApplicationStates().New)
+	assert.Equal(t, snapshot.Applications[0].Tags["namespace"], "default")
+	assert.Equal(t, len(snapshot.Applications[0].Tasks), 2)
+	assert.Equal(t, snapshot.Applications[1].ApplicationID, "app00002")
+	assert.Equal(t, len(snapshot.Applications[1].Tasks), 0)
+	assert.DeepEqual(t, snapshot.PendingPods, []string{"default/pod-1"})
+	assert.Equal(t, len(snapshot.Cache.Pods), 1)
+
+	for _, task := range snapshot.Applications[0].Tasks {
+		if task.TaskID == "task02" {
+			assert.Equal(t, task.State, TaskStates().Bound)
+			assert.Equal(t, task.NodeName, "node-1")
+		}
+	}
+}
+
 func TestFilterPriorityClasses(t *testing.T) {
 	context := initContextForTest()
 	policy := v1.PreemptLowerPriority
@@ -1420,10 +2233,12 @@ func TestUpdatePriorityClass(t *testing.T) {
 	}
 
 	context.addPriorityClass(pc)
+	assert.Assert(t, context.IsPreemptSelfAllowed("pc-test"), "preempt lower priority should allow preemption")
 	context.updatePriorityClass(pc, pc2)
 	result := context.schedulerCache.GetPriorityClass("pc-test")
 	assert.Assert(t, result != nil)
 	assert.Equal(t, result.Value, int32(200))
+	assert.Assert(t, context.IsPreemptSelfAllowed("pc-test"), "preempt never must not opt out of preemption")
 }
 
 func TestDeletePriorityClass(t *testing.T) {
@@ -1497,3 +2312,112 @@ func TestCtxUpdatePodCondition(t *testing.T) {
 	updated = context.updatePodCondition(task, &condition)
 	assert.Equal(t, true, updated)
 }
+
+func TestSchedulingReasonCode(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, context, &v1.Pod{})
+	placeholder := NewTask("task02", app, context, &v1.Pod{})
+	placeholder.placeholder = true
+	member := NewTask("task03", app, context, &v1.Pod{})
+	member.taskGroupName = "tg-01"
+
+	failed := si.UpdateContainerSchedulingStateRequest_FAILED
+	skipped := si.UpdateContainerSchedulingStateRequest_SKIPPED
+	tests := []struct {
+		name   string
+		task   *Task
+		state  si.UpdateContainerSchedulingStateRequest_SchedulingState
+		reason string
+		code   string
+	}{
+		{"skipped", task, skipped, "queue quota exceeded", constants.SchedulingReasonQuotaExceeded},
+		{"failed", task, failed, "request is waiting for cluster resources become available", constants.SchedulingReasonPredicateFailed},
+		{"placeholder", placeholder, failed, "request is waiting for cluster resources become available", constants.SchedulingReasonGangWaiting},
+		{"gang member not reserving", member, failed, "request is waiting for cluster resources become available", constants.SchedulingReasonPredicateFailed},
+		{"queue stopped", task, skipped, "Queue root.a is Stopped", constants.SchedulingReasonQueueStopped},
+		{"queue draining", task, failed, "queue root.a is draining", constants.SchedulingReasonQueueStopped},
+		{"node pressure", task, failed, "all nodes are under memory pressure", constants.SchedulingReasonNodePressure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &si.UpdateContainerSchedulingStateRequest{State: tt.state, Reason: tt.reason}
+			assert.Equal(t, schedulingReasonCode(tt.task, request), tt.code)
+		})
+	}
+
+	app.sm.SetState(ApplicationStates().Reserving)
+	request := &si.UpdateContainerSchedulingStateRequest{State: failed, Reason: "waiting"}
+	assert.Equal(t, schedulingReasonCode(member, request), constants.SchedulingReasonGangWaiting)
+	assert.Equal(t, schedulingReasonCode(task, request), constants.SchedulingReasonPredicateFailed)
+}
+
+func TestNamespaceQueueReload(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	apiProvider.GetAPIs().GetConf().EnableConfigHotRefresh = true
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.NamespaceQueues = true
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	var configs []string
+	apiProvider.MockSchedulerAPIUpdateConfigFn(func(request *si.UpdateConfigurationRequest) error {
+		configs = append(configs, request.Config)
+		return nil
+	})
+	nsLister, ok := apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+
+	ns := &v1.Namespace{ObjectMeta: apis.ObjectMeta{
+		Name:        "ns1",
+		Annotations: map[string]string{constants.AnnotationParentQueue: "root.dev"},
+	}}
+	nsLister.Add(ns)
+	// events before the first config are covered by listing the namespaces
+	context.addNamespace(ns)
+	assert.Equal(t, len(configs), 0)
+	config := context.GetCoreSchedulerConfig(nil)
+	assert.Assert(t, strings.Contains(config, "ns1"), "namespace queue missing: %s", config)
+
+	// unchanged parent: no reload
+	context.updateNamespace(ns, ns)
+	assert.Equal(t, len(configs), 0)
+
+	// new namespace with a parent queue
+	ns2 := ns.DeepCopy()
+	ns2.Name = "ns2"
+	nsLister.Add(ns2)
+	context.addNamespace(ns2)
+	assert.Equal(t, len(configs), 1)
+	assert.Assert(t, strings.Contains(configs[0], "ns2"), "namespace queue missing: %s", configs[0])
+
+	// deleted namespace removes the queue
+	nsLister.Delete(ns2.Name)
+	context.deleteNamespace(cache.DeletedFinalStateUnknown{Key: ns2.Name, Obj: ns2})
+	assert.Equal(t, len(configs), 2)
+	assert.Assert(t, !strings.Contains(configs[1], "ns2"), "namespace queue not removed: %s", configs[1])
+	assert.Assert(t, strings.Contains(configs[1], "ns1"), "namespace queue missing: %s", configs[1])
+
+	// disabled: no reload
+	testConf.NamespaceQueues = false
+	context.addNamespace(ns2)
+	assert.Equal(t, len(configs), 2)
+}
+
+func BenchmarkUpdateCoreConfig(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("queues=%d", size), func(b *testing.B) {
+			content, err := queuegen.GenerateYAML(queuegen.Spec{Queues: size, Depth: 5, Seed: 1})
+			assert.NilError(b, err)
+			context := initContextForTest()
+			context.configMaps = []*v1.ConfigMap{nil, {Data: map[string]string{"queues.yaml": content}}}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				context.lock.Lock()
+				context.updateCoreConfig()
+				context.lock.Unlock()
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/kubernetes/pkg/controller/daemon"
+	daemonutil "k8s.io/kubernetes/pkg/controller/daemon/util"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// nodeReservation is the resource reserved on a node for each DaemonSet that has no pod on the node yet
+type nodeReservation struct {
+	daemonSets map[types.UID]*si.Resource
+	timer      *time.Timer
+}
+
+// daemonSetReservations reserves resources on new nodes for the DaemonSet pods that are expected to be placed
+// on the node. A new node is reported with a lower available capacity to the core until the DaemonSet pods land,
+// which prevents allocations on the fresh node that would leave no room for the DaemonSet pods.
+// The reservation of a DaemonSet is released when its pod for the node lands, all remaining reservations of a
+// node are released when the timeout expires.
+type daemonSetReservations struct {
+	dsLister  appslisters.DaemonSetLister
+	podLister corelisters.PodLister
+	timeout   time.Duration
+	expired   func(nodeName string, resource *si.Resource) // called with the released resources on expiry
+	nodes     map[string]*nodeReservation
+	sync.Mutex
+}
+
+// newDaemonSetReservations creates the reservations based on the scheduler configuration.
+// Returns nil if reservations are disabled, which is the case if the timeout is not a positive value.
+func newDaemonSetReservations(dsLister appslisters.DaemonSetLister, podLister corelisters.PodLister, timeout time.Duration,
+	expired func(nodeName string, resource *si.Resource)) *daemonSetReservations {
+	if timeout <= 0 || dsLister == nil || podLister == nil {
+		return nil
+	}
+	return &daemonSetReservations{
+		dsLister:  dsLister,
+		podLister: podLister,
+		timeout:   timeout,
+		expired:   expired,
+		nodes:     make(map[string]*nodeReservation),
+	}
+}
+
+// reserve calculates the resources requested by the DaemonSet pods that should run on the node but have not
+// been created yet, and tracks them as reserved for the node. Returns the total reserved, nil if none.
+func (r *daemonSetReservations) reserve(node *v1.Node) *si.Resource {
+	daemonSets, err := r.dsLister.List(labels.Everything())
+	if err != nil {
+		log.Log(log.ShimCacheNode).Warn("failed to list DaemonSets, no resources reserved on node",
+			zap.String("nodeName", node.Name),
+			zap.Error(err))
+		return nil
+	}
+	reserved := make(map[types.UID]*si.Resource)
+	var total *si.Resource
+	for _, ds := range daemonSets {
+		if shouldRun, _ := daemon.NodeShouldRunDaemonPod(node, ds); !shouldRun || r.hasPodOnNode(ds, node.Name) {
+			continue
+		}
+		resource := common.GetPodResource(daemon.NewPod(ds, node.Name))
+		reserved[ds.UID] = resource
+		total = common.Add(total, resource)
+	}
+	if len(reserved) == 0 {
+		return nil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.releaseNodeLocked(node.Name)
+	nodeName := node.Name
+	r.nodes[nodeName] = &nodeReservation{
+		daemonSets: reserved,
+		timer: time.AfterFunc(r.timeout, func() {
+			if resource := r.releaseNode(nodeName); resource != nil && r.expired != nil {
+				log.Log(log.ShimCacheNode).Info("DaemonSet pods did not land in time, releasing reserved resources",
+					zap.String("nodeName", nodeName),
+					zap.Stringer("resource", resource))
+				r.expired(nodeName, resource)
+			}
+		}),
+	}
+	log.Log(log.ShimCacheNode).Info("reserved resources on new node for DaemonSet pods",
+		zap.String("nodeName", nodeName),
+		zap.Int("numOfDaemonSets", len(reserved)),
+		zap.Stringer("resource", total))
+	return total
+}
+
+// hasPodOnNode returns true if a pod of the DaemonSet already exists for the node
+func (r *daemonSetReservations) hasPodOnNode(ds *appsv1.DaemonSet, nodeName string) bool {
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return false
+	}
+	pods, err := r.podLister.Pods(ds.Namespace).List(selector)
+	if err != nil {
+		return false
+	}
+	for _, pod := range pods {
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.UID == ds.UID && getDaemonPodNode(pod) == nodeName {
+			return true
+		}
+	}
+	return false
+}
+
+// release drops the reservation of the DaemonSet on the node. Returns the released resource, nil if the
+// DaemonSet has no reservation on the node.
+func (r *daemonSetReservations) release(nodeName string, dsUID types.UID) *si.Resource {
+	r.Lock()
+	defer r.Unlock()
+	reservation, ok := r.nodes[nodeName]
+	if !ok {
+		return nil
+	}
+	resource, ok := reservation.daemonSets[dsUID]
+	if !ok {
+		return nil
+	}
+	delete(reservation.daemonSets, dsUID)
+	if len(reservation.daemonSets) == 0 {
+		r.releaseNodeLocked(nodeName)
+	}
+	return resource
+}
+
+// releaseNode drops all reservations of the node. Returns the total released resource, nil if none.
+func (r *daemonSetReservations) releaseNode(nodeName string) *si.Resource {
+	r.Lock()
+	defer r.Unlock()
+	return r.releaseNodeLocked(nodeName)
+}
+
+// releaseNodeLocked drops all reservations of the node and stops the timer.
+// Must be called while holding the lock.
+func (r *daemonSetReservations) releaseNodeLocked(nodeName string) *si.Resource {
+	reservation, ok := r.nodes[nodeName]
+	if !ok {
+		return nil
+	}
+	reservation.timer.Stop()
+	delete(r.nodes, nodeName)
+	var total *si.Resource
+	for _, resource := range reservation.daemonSets {
+		total = common.Add(total, resource)
+	}
+	return total
+}
+
+// getDaemonPodNode returns the node the DaemonSet pod is bound to, or created for by the DaemonSet controller
+func getDaemonPodNode(pod *v1.Pod) string {
+	if pod.Spec.NodeName != "" {
+		return pod.Spec.NodeName
+	}
+	nodeName, err := daemonutil.GetTargetNodeName(pod)
+	if err != nil {
+		return ""
+	}
+	return nodeName
+}
+
+// filterDaemonSetPods selects the pods controlled by a DaemonSet
+func filterDaemonSetPods(obj interface{}) bool {
+	pod, err := utils.Convert2Pod(obj)
+	if err != nil {
+		return false
+	}
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == constants.DaemonSetType
+}
+
+// addDaemonSetPod releases the reservation of the DaemonSet once its pod lands on the node. A pod scheduled by
+// YuniKorn lands when it is created, the core accounts for it from then on. Any other pod lands when it is bound
+// and reported as occupied by the node coordinator.
+func (ctx *Context) addDaemonSetPod(obj interface{}) {
+	pod, err := utils.Convert2Pod(obj)
+	if err != nil {
+		return
+	}
+	if utils.GetApplicationIDFromPod(pod) == "" && pod.Spec.NodeName == "" {
+		return
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+	// the node could still be registering, the reservation is released on a later update of the pod
+	nodeName := getDaemonPodNode(pod)
+	if ctx.nodes.getNode(nodeName) == nil {
+		return
+	}
+	if resource := ctx.dsReservations.release(nodeName, owner.UID); resource != nil {
+		log.Log(log.ShimCacheNode).Info("DaemonSet pod landed, releasing reserved resources",
+			zap.String("nodeName", nodeName),
+			zap.String("podName", pod.Name),
+			zap.Stringer("resource", resource))
+		ctx.nodes.updateNodeOccupiedResources(nodeName, resource, SubOccupiedResource)
+	}
+}
+
+func (ctx *Context) updateDaemonSetPod(_, newObj interface{}) {
+	ctx.addDaemonSetPod(newObj)
+}
+
+// releaseDaemonSetReservations is called when the reservations of the node expire
+func (ctx *Context) releaseDaemonSetReservations(nodeName string, resource *si.Resource) {
+	ctx.nodes.updateNodeOccupiedResources(nodeName, resource, SubOccupiedResource)
+}
@@ -0,0 +1,191 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func newTestDaemonSet(name string, uid types.UID, nodeSelector map[string]string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: apis.ObjectMeta{Name: name, Namespace: "kube-system", UID: uid},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &apis.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: apis.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec: v1.PodSpec{
+					NodeSelector: nodeSelector,
+					Containers: []v1.Container{{
+						Name: "agent",
+						Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("100m"),
+							v1.ResourceMemory: resource.MustParse("100M"),
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func newTestDaemonPod(ds *appsv1.DaemonSet, nodeName string) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:            ds.Name + "-" + nodeName,
+			Namespace:       ds.Namespace,
+			UID:             types.UID(ds.Name + "-" + nodeName),
+			Labels:          map[string]string{"app": ds.Name},
+			OwnerReferences: []apis.OwnerReference{{Kind: constants.DaemonSetType, Name: ds.Name, UID: ds.UID, Controller: &controller}},
+		},
+		Spec: v1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func newTestReservations(t *testing.T, timeout time.Duration, expired func(string, *si.Resource), daemonSets []*appsv1.DaemonSet, pods []*v1.Pod) *daemonSetReservations {
+	dsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, ds := range daemonSets {
+		assert.NilError(t, dsIndexer.Add(ds))
+	}
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		assert.NilError(t, podIndexer.Add(pod))
+	}
+	return newDaemonSetReservations(appslisters.NewDaemonSetLister(dsIndexer), corelisters.NewPodLister(podIndexer), timeout, expired)
+}
+
+func newReservationTestNode(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: name, UID: types.UID("uid-" + name), Labels: map[string]string{"role": "worker"}},
+		Spec: v1.NodeSpec{Taints: []v1.Taint{
+			// tolerated by all DaemonSet pods
+			{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule},
+		}},
+		Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("4G"),
+		}},
+	}
+}
+
+func TestNewDaemonSetReservationsDisabled(t *testing.T) {
+	assert.Assert(t, newTestReservations(t, 0, nil, nil, nil) == nil, "reservations created with zero timeout")
+	assert.Assert(t, newTestReservations(t, -time.Second, nil, nil, nil) == nil, "reservations created with negative timeout")
+	assert.Assert(t, newDaemonSetReservations(nil, nil, time.Second, nil) == nil, "reservations created without listers")
+	assert.Assert(t, newTestReservations(t, time.Second, nil, nil, nil) != nil, "reservations not created")
+}
+
+func TestReserveDaemonSetResources(t *testing.T) {
+	matching := newTestDaemonSet("matching", "uid-matching", nil)
+	selected := newTestDaemonSet("selected", "uid-selected", map[string]string{"role": "worker"})
+	otherNodes := newTestDaemonSet("other-nodes", "uid-other-nodes", map[string]string{"role": "gpu"})
+	running := newTestDaemonSet("running", "uid-running", nil)
+	r := newTestReservations(t, time.Hour, nil,
+		[]*appsv1.DaemonSet{matching, selected, otherNodes, running},
+		[]*v1.Pod{newTestDaemonPod(running, "node-1")})
+
+	reserved := r.reserve(newReservationTestNode("node-1"))
+	assert.Assert(t, reserved != nil, "no resources reserved")
+	assert.Equal(t, reserved.Resources[siCommon.CPU].Value, int64(200))
+	assert.Equal(t, reserved.Resources[siCommon.Memory].Value, int64(200*1000*1000))
+	assert.Equal(t, reserved.Resources["pods"].Value, int64(2))
+
+	// the running DaemonSet has no pod on node-2 yet
+	assert.Assert(t, r.reserve(newReservationTestNode("node-2")) != nil)
+	tainted := newReservationTestNode("node-3")
+	tainted.Spec.Taints = append(tainted.Spec.Taints, v1.Taint{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule})
+	assert.Assert(t, r.reserve(tainted) == nil, "resources reserved on node with untolerated taint")
+
+	released := r.release("node-1", matching.UID)
+	assert.Assert(t, released != nil, "reservation not released")
+	assert.Equal(t, released.Resources[siCommon.CPU].Value, int64(100))
+	assert.Assert(t, r.release("node-1", matching.UID) == nil, "reservation released twice")
+	assert.Assert(t, r.release("node-1", running.UID) == nil, "reservation released for running DaemonSet")
+	assert.Assert(t, r.release("unknown", matching.UID) == nil, "reservation released for unknown node")
+	assert.Assert(t, r.release("node-1", selected.UID) != nil, "reservation not released")
+	assert.Equal(t, len(r.nodes), 1, "node reservation not cleaned up")
+
+	released = r.releaseNode("node-2")
+	assert.Equal(t, released.Resources[siCommon.CPU].Value, int64(300))
+	assert.Equal(t, len(r.nodes), 0, "node reservation not cleaned up")
+}
+
+func TestDaemonSetReservationsExpiry(t *testing.T) {
+	expired := make(chan *si.Resource, 1)
+	r := newTestReservations(t, 10*time.Millisecond, func(nodeName string, resource *si.Resource) {
+		assert.Equal(t, nodeName, "node-1")
+		expired <- resource
+	}, []*appsv1.DaemonSet{newTestDaemonSet("matching", "uid-matching", nil)}, nil)
+
+	assert.Assert(t, r.reserve(newReservationTestNode("node-1")) != nil)
+	select {
+	case resource := <-expired:
+		assert.Equal(t, resource.Resources[siCommon.CPU].Value, int64(100))
+	case <-time.After(time.Second):
+		t.Fatal("reservation did not expire")
+	}
+	r.Lock()
+	defer r.Unlock()
+	assert.Equal(t, len(r.nodes), 0, "node reservation not cleaned up")
+}
+
+func TestAddNodeWithDaemonSetReservation(t *testing.T) {
+	ctx := initContextForTest()
+	ds := newTestDaemonSet("matching", "uid-matching", nil)
+	ctx.dsReservations = newTestReservations(t, time.Hour, ctx.releaseDaemonSetReservations, []*appsv1.DaemonSet{ds}, nil)
+
+	ctx.addNode(newReservationTestNode("node-1"))
+	node := ctx.nodes.getNode("node-1")
+	assert.Assert(t, node != nil, "node not added")
+	_, occupied, _ := node.snapshotState()
+	assert.Equal(t, occupied.Resources[siCommon.CPU].Value, int64(100))
+
+	// a pending pod that is not scheduled by YuniKorn has not landed
+	pod := newTestDaemonPod(ds, "node-1")
+	pod.Spec.NodeName = ""
+	assert.Assert(t, filterDaemonSetPods(pod))
+	ctx.addDaemonSetPod(pod)
+	_, occupied, _ = node.snapshotState()
+	assert.Equal(t, occupied.Resources[siCommon.CPU].Value, int64(100))
+
+	ctx.updateDaemonSetPod(pod, newTestDaemonPod(ds, "node-1"))
+	_, occupied, _ = node.snapshotState()
+	assert.Assert(t, common.IsZero(occupied), "reservation not released")
+
+	// an existing node is not reserved again
+	ctx.addNode(newReservationTestNode("node-1"))
+	_, occupied, _ = node.snapshotState()
+	assert.Assert(t, common.IsZero(occupied), "existing node reserved")
+}
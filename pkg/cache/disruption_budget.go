@@ -0,0 +1,73 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// getPDBViolations returns the victims that cannot be preempted without violating a PodDisruptionBudget.
+// The victims are processed in order, each victim uses up one of the disruptions allowed by all budgets that
+// select it. Victims that are already counted as disrupted by a budget do not use up a disruption.
+func getPDBViolations(lister policylisters.PodDisruptionBudgetLister, victims []*v1.Pod) []*v1.Pod {
+	var violations []*v1.Pod
+	allowed := make(map[string]int32)
+	for _, victim := range victims {
+		if victim == nil {
+			continue
+		}
+		pdbs, err := lister.PodDisruptionBudgets(victim.Namespace).List(labels.Everything())
+		if err != nil {
+			log.Log(log.ShimContext).Warn("failed to list PodDisruptionBudgets",
+				zap.String("namespace", victim.Namespace),
+				zap.Error(err))
+			continue
+		}
+		violated := false
+		for _, pdb := range pdbs {
+			selector, err := apis.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil || selector.Empty() || !selector.Matches(labels.Set(victim.Labels)) {
+				continue
+			}
+			if _, disrupted := pdb.Status.DisruptedPods[victim.Name]; disrupted {
+				continue
+			}
+			key := pdb.Namespace + "/" + pdb.Name
+			remaining, ok := allowed[key]
+			if !ok {
+				remaining = pdb.Status.DisruptionsAllowed
+			}
+			remaining--
+			allowed[key] = remaining
+			if remaining < 0 {
+				violated = true
+			}
+		}
+		if violated {
+			violations = append(violations, victim)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,82 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/test"
+)
+
+func TestGetPDBViolations(t *testing.T) {
+	informer, ok := test.NewMockPDBInformer().(*test.MockPDBInformer)
+	assert.Assert(t, ok, "unexpected informer type")
+	assert.NilError(t, informer.Add(newPDB("ns1", "web", map[string]string{"app": "web"}, 1, nil)))
+	assert.NilError(t, informer.Add(newPDB("ns1", "db", map[string]string{"app": "db"}, 0, []string{"db-0"})))
+	assert.NilError(t, informer.Add(newPDB("ns2", "web", map[string]string{"app": "web"}, 0, nil)))
+	lister := informer.Lister()
+
+	web0 := newVictim("ns1", "web-0", "web")
+	web1 := newVictim("ns1", "web-1", "web")
+	db0 := newVictim("ns1", "db-0", "db")
+	db1 := newVictim("ns1", "db-1", "db")
+	other := newVictim("ns1", "other", "other")
+	web2 := newVictim("ns2", "web-2", "web")
+
+	assert.Equal(t, len(getPDBViolations(lister, nil)), 0)
+	assert.Equal(t, len(getPDBViolations(lister, []*v1.Pod{nil, other})), 0, "unprotected pod")
+	assert.Equal(t, len(getPDBViolations(lister, []*v1.Pod{web0})), 0, "disruption allowed")
+	assert.DeepEqual(t, getPDBViolations(lister, []*v1.Pod{web0, web1}), []*v1.Pod{web1})
+	assert.Equal(t, len(getPDBViolations(lister, []*v1.Pod{db0})), 0, "already disrupted")
+	assert.DeepEqual(t, getPDBViolations(lister, []*v1.Pod{db0, db1}), []*v1.Pod{db1})
+	// budgets are per namespace
+	assert.DeepEqual(t, getPDBViolations(lister, []*v1.Pod{web0, web2}), []*v1.Pod{web2})
+}
+
+func newPDB(namespace, name string, selector map[string]string, allowed int32, disrupted []string) *policyv1.PodDisruptionBudget {
+	disruptedPods := make(map[string]apis.Time)
+	for _, pod := range disrupted {
+		disruptedPods[pod] = apis.Now()
+	}
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: apis.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &apis.LabelSelector{MatchLabels: selector},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: allowed,
+			DisruptedPods:      disruptedPods,
+		},
+	}
+}
+
+func newVictim(namespace, name, app string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{"app": app},
+		},
+	}
+}
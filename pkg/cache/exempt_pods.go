@@ -0,0 +1,103 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// isOccupiedExempt returns true if the resources of a pod not scheduled by YuniKorn must not be reported to the
+// core as occupied. A pod is exempt if the exempt annotation is set or its labels match the configured selector.
+// Mirror pods are exempt if excluded by the configuration.
+func isOccupiedExempt(pod *v1.Pod) bool {
+	if utils.GetPodAnnotationValue(pod, constants.AnnotationOccupiedExempt) == constants.True {
+		return true
+	}
+	if utils.IsMirrorPod(pod) && conf.GetSchedulerConf().GetExcludeMirrorPods() {
+		return true
+	}
+	value := conf.GetSchedulerConf().GetForeignPodExemptSelector()
+	if value == "" {
+		return false
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		log.Log(log.ShimCacheNode).Warn("invalid foreign pod exempt selector",
+			zap.String("selector", value),
+			zap.Error(err))
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+// addExemptPod tracks the pod if it is exempt from the occupied resource reporting.
+// Returns false if the pod is not exempt and the resources must be reported as occupied.
+// The decision is made once when the pod is assigned: a later change of the labels or the annotations
+// of the pod does not change it, which keeps the occupied resources of the node consistent.
+func (nc *schedulerNodes) addExemptPod(pod *v1.Pod, resource *si.Resource) bool {
+	if !isOccupiedExempt(pod) {
+		return false
+	}
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	if _, ok := nc.exemptPods[pod.UID]; !ok {
+		log.Log(log.ShimCacheNode).Info("pod is exempt from occupied resources",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("nodeName", pod.Spec.NodeName),
+			zap.Stringer("resource", resource))
+		nc.exemptPods[pod.UID] = resource
+		metrics.AddExemptForeignPod(getResourceValues(resource))
+	}
+	return true
+}
+
+// removeExemptPod stops tracking the exempt pod.
+// Returns false if the pod was not exempt and the resources must be released from the occupied resources.
+func (nc *schedulerNodes) removeExemptPod(pod *v1.Pod) bool {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	resource, ok := nc.exemptPods[pod.UID]
+	if !ok {
+		return false
+	}
+	delete(nc.exemptPods, pod.UID)
+	metrics.RemoveExemptForeignPod(getResourceValues(resource))
+	return true
+}
+
+func getResourceValues(resource *si.Resource) map[string]int64 {
+	values := make(map[string]int64)
+	if resource == nil {
+		return values
+	}
+	for name, quantity := range resource.Resources {
+		values[name] = quantity.GetValue()
+	}
+	return values
+}
@@ -0,0 +1,145 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// failedNodes tracks the nodes on which pods of an application failed, with the time of the last failure.
+// Only failures of pods that have the AnnotationAvoidFailedNodes annotation set are tracked.
+type failedNodes struct {
+	failures map[string]map[string]time.Time // appID -> node name -> time of the last failure
+	lock     sync.RWMutex
+}
+
+func newFailedNodes() *failedNodes {
+	return &failedNodes{
+		failures: make(map[string]map[string]time.Time),
+	}
+}
+
+// record adds the failure of a pod of the application on the node. Failures of the application that are older
+// than the window are dropped.
+func (f *failedNodes) record(appID, nodeName string, at time.Time, window time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	nodes, ok := f.failures[appID]
+	if !ok {
+		nodes = make(map[string]time.Time)
+		f.failures[appID] = nodes
+	}
+	for name, failedAt := range nodes {
+		if at.Sub(failedAt) > window {
+			delete(nodes, name)
+		}
+	}
+	nodes[nodeName] = at
+}
+
+// recent returns the sorted names of the nodes with a failure of the application within the window
+func (f *failedNodes) recent(appID string, window time.Duration, now time.Time) []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	var names []string
+	for name, at := range f.failures[appID] {
+		if now.Sub(at) <= window {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// remove drops the failures of the application
+func (f *failedNodes) remove(appID string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.failures, appID)
+}
+
+// failedNodeAvoidanceWindow returns how long the nodes with a failed pod of the application are avoided for the pod,
+// zero if the pod does not avoid failed nodes.
+func failedNodeAvoidanceWindow(pod *v1.Pod) time.Duration {
+	value := utils.GetPodAnnotationValue(pod, constants.AnnotationAvoidFailedNodes)
+	if value == "" {
+		return 0
+	}
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		if !enabled {
+			return 0
+		}
+		return conf.GetSchedulerConf().GetFailedNodeAvoidanceWindow()
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window < 0 {
+		log.Log(log.ShimContext).Warn("invalid failed node avoidance annotation, using the configured window",
+			zap.String("podName", pod.Name),
+			zap.String("value", value))
+		return conf.GetSchedulerConf().GetFailedNodeAvoidanceWindow()
+	}
+	return window
+}
+
+// recordPodFailure tracks the node of a failed pod that avoids failed nodes
+func (ctx *Context) recordPodFailure(pod *v1.Pod) {
+	appID := utils.GetApplicationIDFromPod(pod)
+	window := failedNodeAvoidanceWindow(pod)
+	if appID == "" || pod.Spec.NodeName == "" || window == 0 {
+		return
+	}
+	log.Log(log.ShimContext).Info("pod failed, avoiding the node for the application",
+		zap.String("appID", appID),
+		zap.String("podName", pod.Name),
+		zap.String("nodeName", pod.Spec.NodeName))
+	ctx.failedNodes.record(appID, pod.Spec.NodeName, time.Now(), window)
+}
+
+// addFailedNodesHint adds the nodes on which a pod of the application failed within the avoidance window to the
+// asks as a placement hint. The hint is a preferred node anti-affinity: the core allocates the ask on one of the
+// nodes if no other node fits, the nodes are not filtered out by the predicates.
+func (ctx *Context) addFailedNodesHint(pod *v1.Pod, asks []*si.AllocationAsk) {
+	window := failedNodeAvoidanceWindow(pod)
+	if window == 0 {
+		return
+	}
+	nodes := ctx.failedNodes.recent(utils.GetApplicationIDFromPod(pod), window, time.Now())
+	if len(nodes) == 0 {
+		return
+	}
+	for _, ask := range asks {
+		if ask.Tags == nil {
+			ask.Tags = make(map[string]string)
+		}
+		ask.Tags[constants.TagPreferredNodeAntiAffinity] = strings.Join(nodes, ",")
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func TestFailedNodes(t *testing.T) {
+	nodes := newFailedNodes()
+	now := time.Now()
+	assert.Equal(t, len(nodes.recent("app-1", time.Minute, now)), 0, "unknown application has failed nodes")
+
+	nodes.record("app-1", "node-1", now.Add(-2*time.Minute), 5*time.Minute)
+	nodes.record("app-1", "node-2", now.Add(-30*time.Second), 5*time.Minute)
+	nodes.record("app-2", "node-1", now, 5*time.Minute)
+	assert.DeepEqual(t, nodes.recent("app-1", time.Minute, now), []string{"node-2"})
+	assert.DeepEqual(t, nodes.recent("app-1", 5*time.Minute, now), []string{"node-1", "node-2"})
+
+	// a new failure resets the time
+	nodes.record("app-1", "node-2", now, 5*time.Minute)
+	assert.DeepEqual(t, nodes.recent("app-1", time.Minute, now.Add(45*time.Second)), []string{"node-2"})
+	assert.Equal(t, len(nodes.recent("app-1", time.Minute, now.Add(2*time.Minute))), 0)
+
+	// failures outside the window are dropped when a failure is recorded
+	nodes.record("app-1", "node-3", now.Add(4*time.Minute), time.Minute)
+	assert.Equal(t, len(nodes.failures["app-1"]), 1, "expired failures should have been dropped")
+
+	nodes.remove("app-1")
+	nodes.remove("app-2")
+	assert.Equal(t, len(nodes.failures), 0)
+}
+
+func TestFailedNodeAvoidanceWindow(t *testing.T) {
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.FailedNodeAvoidanceWindow = 3 * time.Minute
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	tests := []struct {
+		value  string
+		window time.Duration
+	}{
+		{"", 0},
+		{"false", 0},
+		{"true", 3 * time.Minute},
+		{"15m", 15 * time.Minute},
+		{"0s", 0},
+		{"-1m", 3 * time.Minute},
+		{"xyz", 3 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "pod-1"}}
+			if tt.value != "" {
+				pod.Annotations = map[string]string{constants.AnnotationAvoidFailedNodes: tt.value}
+			}
+			assert.Equal(t, failedNodeAvoidanceWindow(pod), tt.window)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const podRejectedByKubeletReason = "PodRejectedByKubelet"
+
+// handleKubeletRejection processes a pod that the kubelet refused to admit after it was bound, e.g. OutOfcpu,
+// OutOfmemory or UnexpectedAdmissionError. The pod is terminal: the allocation is released by the normal
+// task completion flow. A placeholder is recreated if configured, for other pods the owning controller is
+// notified so the rejection is visible on the workload object.
+func (ctx *Context) handleKubeletRejection(pod *v1.Pod) {
+	log.Log(log.ShimContext).Info("pod rejected by kubelet",
+		zap.String("podName", pod.Name),
+		zap.String("nodeName", pod.Spec.NodeName),
+		zap.String("reason", pod.Status.Reason))
+	metrics.IncSchedulingFailure(metrics.KubeletAdmission, pod.Status.Reason)
+	events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, podRejectedByKubeletReason, metrics.KubeletAdmission.String(),
+		"Pod %s/%s was rejected by the kubelet on node %s: %s", pod.Namespace, pod.Name, pod.Spec.NodeName, pod.Status.Reason)
+
+	if utils.GetPlaceholderFlagFromPodSpec(pod) {
+		if conf.GetSchedulerConf().IsRecreateRejectedPlaceholders() {
+			ctx.recreatePlaceholder(pod)
+		}
+		return
+	}
+	notifyPodOwner(pod)
+}
+
+// recreatePlaceholder replaces a rejected placeholder with a new one for the same task group.
+// The rejected pod cannot be reused: it is removed and the replacement gets a generated name.
+func (ctx *Context) recreatePlaceholder(pod *v1.Pod) {
+	appID := utils.GetApplicationIDFromPod(pod)
+	app, ok := ctx.GetApplication(appID).(*Application)
+	if !ok || app == nil {
+		log.Log(log.ShimContext).Debug("application not found, placeholder not recreated",
+			zap.String("appID", appID),
+			zap.String("podName", pod.Name))
+		return
+	}
+	states := ApplicationStates()
+	if state := app.GetApplicationState(); state != states.Reserving && state != states.Running {
+		log.Log(log.ShimContext).Info("application no longer needs placeholders, placeholder not recreated",
+			zap.String("appID", appID),
+			zap.String("podName", pod.Name),
+			zap.String("state", state))
+		return
+	}
+	if mgr := getPlaceholderManager(); mgr != nil {
+		if err := mgr.recreatePlaceholder(app, pod); err != nil {
+			log.Log(log.ShimContext).Warn("failed to recreate rejected placeholder",
+				zap.String("appID", appID),
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+		}
+	}
+}
+
+// notifyPodOwner records the rejection on the controller of the pod, if any, so that the owner of the
+// workload can act on it without inspecting the individual pods.
+func notifyPodOwner(pod *v1.Pod) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+	ref := &v1.ObjectReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		Namespace:  pod.Namespace,
+		UID:        owner.UID,
+	}
+	events.GetRecorder().Eventf(ref, pod.DeepCopy(), v1.EventTypeWarning, podRejectedByKubeletReason, metrics.KubeletAdmission.String(),
+		"Pod %s was rejected by the kubelet on node %s: reason=%s, message=%s", pod.Name, pod.Spec.NodeName, pod.Status.Reason, pod.Status.Message)
+}
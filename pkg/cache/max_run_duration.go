@@ -0,0 +1,111 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+func (app *Application) setMaxRunDuration(maxRunDuration time.Duration) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	app.maxRunDuration = maxRunDuration
+}
+
+func (app *Application) isMaxRunDurationExceeded() bool {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.maxRunExceeded
+}
+
+// startMaxRunTimer starts the timer for the max run duration when the application starts running.
+// Called from the state machine while holding the application lock.
+func (app *Application) startMaxRunTimer() {
+	if app.maxRunDuration <= 0 || app.maxRunTimer != nil {
+		return
+	}
+	remaining := app.maxRunDuration - time.Since(app.getRunStartTime())
+	log.Log(log.ShimCacheApplication).Info("application has a max run duration",
+		zap.String("appID", app.applicationID),
+		zap.Duration("maxRunDuration", app.maxRunDuration),
+		zap.Duration("remaining", remaining))
+	app.maxRunTimer = time.AfterFunc(remaining, app.onMaxRunDurationExceeded)
+}
+
+// stopMaxRunTimer stops the timer once the application has finished.
+// Called from the state machine while holding the application lock.
+func (app *Application) stopMaxRunTimer() {
+	if app.maxRunTimer != nil {
+		app.maxRunTimer.Stop()
+	}
+}
+
+// getRunStartTime returns the time the application started running. After a restart of the scheduler the application
+// has been running since the earliest start of its pods, otherwise it starts now.
+func (app *Application) getRunStartTime() time.Time {
+	start := time.Now()
+	for _, task := range app.taskMap {
+		if pod := task.GetTaskPod(); pod != nil && pod.Status.StartTime != nil && pod.Status.StartTime.Time.Before(start) {
+			start = pod.Status.StartTime.Time
+		}
+	}
+	return start
+}
+
+// onMaxRunDurationExceeded terminates all pods of the application. The allocations of the pods are released when
+// the deletion of the pods is processed.
+func (app *Application) onMaxRunDurationExceeded() {
+	app.lock.Lock()
+	app.maxRunExceeded = true
+	tasks := make([]*Task, 0, len(app.taskMap))
+	for _, task := range app.taskMap {
+		if !task.isTerminated() {
+			tasks = append(tasks, task)
+		}
+	}
+	app.publishAppEvent(v1.EventTypeWarning, "MaxRunDurationExceeded",
+		"Application %s exceeded its max run duration of %s, terminating %d pods", app.applicationID, app.maxRunDuration, len(tasks))
+	app.lock.Unlock()
+
+	log.Log(log.ShimCacheApplication).Info("application exceeded its max run duration, terminating pods",
+		zap.String("appID", app.applicationID),
+		zap.Int("numOfPods", len(tasks)))
+	app.terminateTasks(tasks)
+}
+
+// terminateTasks deletes the pods of the tasks of an application that exceeded its max run duration
+func (app *Application) terminateTasks(tasks []*Task) {
+	for _, task := range tasks {
+		pod := task.GetTaskPod()
+		events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, "MaxRunDurationExceeded", "MaxRunDurationExceeded",
+			"Application %s exceeded its max run duration, pod is terminated", app.applicationID)
+		if err := task.DeleteTaskPod(pod); err != nil {
+			log.Log(log.ShimCacheApplication).Warn("failed to delete pod of application that exceeded its max run duration",
+				zap.String("appID", app.applicationID),
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+)
+
+func TestMaxRunDuration(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	deleted := make(chan string, 10)
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deleted <- pod.Name
+		return nil
+	})
+	expectDeleted := func(names ...string) {
+		got := make(map[string]bool)
+		for range names {
+			select {
+			case name := <-deleted:
+				got[name] = true
+			case <-time.After(time.Second):
+				t.Fatalf("pods not deleted, got %v", got)
+			}
+		}
+		for _, name := range names {
+			assert.Assert(t, got[name], "pod %s not deleted", name)
+		}
+	}
+	addTask := func(appID, name string, started time.Time) {
+		pod := newPodHelper(name, "default", name, "", appID, v1.PodRunning)
+		if !started.IsZero() {
+			pod.Status.StartTime = &apis.Time{Time: started}
+		}
+		ctx.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{ApplicationID: appID, TaskID: name, Pod: pod},
+		})
+	}
+
+	// application without a max run duration
+	app := ctx.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{ApplicationID: "app-unlimited", QueueName: "root.a", User: "user"},
+	}).(*Application)
+	addTask("app-unlimited", "pod-0", time.Now().Add(-time.Hour))
+	app.lock.Lock()
+	app.startMaxRunTimer()
+	app.lock.Unlock()
+	assert.Assert(t, app.maxRunTimer == nil, "timer started without max run duration")
+
+	// recovered application that has been running longer than its max run duration
+	app = ctx.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{ApplicationID: "app-limited", QueueName: "root.a", User: "user",
+			MaxRunDuration: time.Hour},
+	}).(*Application)
+	addTask("app-limited", "pod-1", time.Now().Add(-2*time.Hour))
+	addTask("app-limited", "pod-2", time.Time{})
+	assert.Assert(t, !app.isMaxRunDurationExceeded())
+	app.lock.Lock()
+	start := app.getRunStartTime()
+	app.startMaxRunTimer()
+	app.lock.Unlock()
+	assert.Assert(t, time.Since(start) > time.Hour, "run start time should be the earliest pod start")
+	expectDeleted("pod-1", "pod-2")
+	assert.Assert(t, app.isMaxRunDurationExceeded())
+
+	// pods added after the max run duration is exceeded are deleted immediately
+	addTask("app-limited", "pod-3", time.Time{})
+	expectDeleted("pod-3")
+	select {
+	case name := <-deleted:
+		t.Fatalf("unexpected pod deleted: %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMaxRunDurationStop(t *testing.T) {
+	app := NewApplication("app-1", "root.a", "user", nil, map[string]string{}, nil)
+	app.setMaxRunDuration(50 * time.Millisecond)
+	app.lock.Lock()
+	app.startMaxRunTimer()
+	timer := app.maxRunTimer
+	app.startMaxRunTimer()
+	assert.Equal(t, app.maxRunTimer, timer, "timer restarted")
+	app.stopMaxRunTimer()
+	app.lock.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	assert.Assert(t, !app.isMaxRunDurationExceeded(), "stopped timer fired")
+}
@@ -0,0 +1,196 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// namespaceQueues generates the queues for namespaces annotated with a parent queue. A queue named after the
+// namespace is added below the parent queue in the default partition, missing parent queues are created.
+// Queues that are part of the configuration are never changed. The generated queues match the placement of
+// the tag rule with the namespace.parentqueue tag as parent, and are removed again when the namespace is deleted.
+// The quota and guaranteed annotations of the namespace are set as the max and guaranteed resources of the
+// generated queue, a change of the annotations updates the queue in the core through the configuration.
+// The generated queues are enabled by default: they are the only queues the shim can keep in sync with the
+// annotations, the core only sets the max resource of a dynamic queue when an application is added to it.
+type namespaceQueues struct {
+	applied map[string]namespaceQueue // queue of each namespace in the last configuration, nil before the first one
+	lock    sync.Mutex
+}
+
+// namespaceQueue is the generated queue of a namespace, the zero value is a namespace without a queue.
+type namespaceQueue struct {
+	parent     string
+	max        map[string]string
+	guaranteed map[string]string
+}
+
+func newNamespaceQueues() *namespaceQueues {
+	return &namespaceQueues{}
+}
+
+// changed returns true if the queue of the namespace differs from the last applied configuration.
+// Changes before the first configuration was applied are ignored: the namespaces are listed when it is applied.
+func (n *namespaceQueues) changed(namespace string, queue namespaceQueue) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.applied != nil && !reflect.DeepEqual(n.applied[namespace], queue)
+}
+
+// apply adds the queues for the namespaces to the scheduler configuration and returns the updated configuration.
+// An empty configuration is replaced by the default configuration of the core if queues are added.
+// The configuration is returned unchanged if it cannot be parsed: the core reports the error.
+func (n *namespaceQueues) apply(config string, namespaces []*v1.Namespace) string {
+	queues := make(map[string]namespaceQueue)
+	for _, namespace := range namespaces {
+		if queue := getNamespaceQueue(namespace); queue.parent != "" {
+			queues[namespace.Name] = queue
+		}
+	}
+	n.lock.Lock()
+	n.applied = queues
+	n.lock.Unlock()
+	if len(queues) == 0 {
+		return config
+	}
+
+	base := config
+	if base == "" {
+		base = configs.DefaultSchedulerConfig
+	}
+	schedulerConfig := &configs.SchedulerConfig{}
+	if err := yaml.Unmarshal([]byte(base), schedulerConfig); err != nil {
+		log.Log(log.ShimContext).Warn("failed to parse queue configuration, namespace queues not added",
+			zap.Error(err))
+		return config
+	}
+	var partition *configs.PartitionConfig
+	for i := range schedulerConfig.Partitions {
+		if strings.EqualFold(schedulerConfig.Partitions[i].Name, constants.DefaultPartition) {
+			partition = &schedulerConfig.Partitions[i]
+			break
+		}
+	}
+	if partition == nil {
+		log.Log(log.ShimContext).Warn("default partition not configured, namespace queues not added")
+		return config
+	}
+
+	// sort for a stable configuration: the core only reloads if the configuration changes
+	names := make([]string, 0, len(queues))
+	for name := range queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		queue := queues[name]
+		path := strings.Split(queue.parent, ".")
+		if !strings.EqualFold(path[0], configs.RootQueue) {
+			path = append([]string{configs.RootQueue}, path...)
+		}
+		resources := configs.Resources{Max: queue.max, Guaranteed: queue.guaranteed}
+		partition.Queues = addNamespaceQueue(partition.Queues, append(path, name), resources)
+	}
+
+	updated, err := yaml.Marshal(schedulerConfig)
+	if err != nil {
+		log.Log(log.ShimContext).Warn("failed to serialise queue configuration, namespace queues not added",
+			zap.Error(err))
+		return config
+	}
+	log.Log(log.ShimContext).Debug("namespace queues added to configuration",
+		zap.Strings("namespaces", names))
+	return string(updated)
+}
+
+// addNamespaceQueue adds the queue hierarchy in path to the queues. Existing queues are matched case-insensitive
+// and are left unchanged, new queues in the middle of the path are parent queues. The resources are set on the
+// last queue of the path if it is added.
+func addNamespaceQueue(queues []configs.QueueConfig, path []string, resources configs.Resources) []configs.QueueConfig {
+	if len(path) == 0 {
+		return queues
+	}
+	for i := range queues {
+		if strings.EqualFold(queues[i].Name, path[0]) {
+			queues[i].Queues = addNamespaceQueue(queues[i].Queues, path[1:], resources)
+			return queues
+		}
+	}
+	queue := configs.QueueConfig{
+		Name:   path[0],
+		Parent: len(path) > 1,
+	}
+	if len(path) == 1 {
+		queue.Resources = resources
+	}
+	queue.Queues = addNamespaceQueue(nil, path[1:], resources)
+	return append(queues, queue)
+}
+
+// getNamespaceQueue returns the queue generated for the namespace, the zero value if the namespace has no parent queue
+func getNamespaceQueue(namespace *v1.Namespace) namespaceQueue {
+	parent := getNamespaceParentQueue(namespace)
+	if parent == "" {
+		return namespaceQueue{}
+	}
+	return namespaceQueue{
+		parent:     parent,
+		max:        getConfigResources(utils.GetNamespaceQuotaFromAnnotation(namespace)),
+		guaranteed: getConfigResources(utils.GetNamespaceGuaranteedFromAnnotation(namespace)),
+	}
+}
+
+// getConfigResources converts the resource into the quantities of the queue configuration, nil if it is empty.
+// The vcore quantity of the configuration is in cores, the resource is in millicores.
+func getConfigResources(resource *si.Resource) map[string]string {
+	if resource == nil || common.IsZero(resource) {
+		return nil
+	}
+	quantities := make(map[string]string, len(resource.Resources))
+	for name, quantity := range resource.Resources {
+		if name == siCommon.CPU {
+			quantities[name] = strconv.FormatInt(quantity.Value, 10) + "m"
+		} else {
+			quantities[name] = strconv.FormatInt(quantity.Value, 10)
+		}
+	}
+	return quantities
+}
+
+// getNamespaceParentQueue returns the parent queue from the namespace annotation, trimmed of separators
+func getNamespaceParentQueue(namespace *v1.Namespace) string {
+	return strings.Trim(strings.TrimSpace(utils.GetNameSpaceAnnotationValue(namespace, constants.AnnotationParentQueue)), ".")
+}
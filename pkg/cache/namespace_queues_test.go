@@ -0,0 +1,143 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+const namespaceQueuesConfig = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: dev
+            submitacl: '*'
+            queues:
+              - name: existing
+                maxapplications: 5
+`
+
+func newNamespaceWithParent(name, parent string) *v1.Namespace {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if parent != "" {
+		namespace.Annotations = map[string]string{constants.AnnotationParentQueue: parent}
+	}
+	return namespace
+}
+
+func getConfigQueue(t *testing.T, queues []configs.QueueConfig, path ...string) *configs.QueueConfig {
+	for i := range queues {
+		if queues[i].Name == path[0] {
+			if len(path) == 1 {
+				return &queues[i]
+			}
+			return getConfigQueue(t, queues[i].Queues, path[1:]...)
+		}
+	}
+	t.Fatalf("queue %v not found", path)
+	return nil
+}
+
+func TestNamespaceQueuesApply(t *testing.T) {
+	nsQueues := newNamespaceQueues()
+	assert.Assert(t, !nsQueues.changed("ns1", namespaceQueue{parent: "root.dev"}), "changes before the first apply must be ignored")
+
+	// no annotated namespaces: config unchanged
+	assert.Equal(t, nsQueues.apply(namespaceQueuesConfig, []*v1.Namespace{newNamespaceWithParent("plain", "")}), namespaceQueuesConfig)
+	assert.Assert(t, nsQueues.changed("ns1", namespaceQueue{parent: "root.dev"}))
+	assert.Assert(t, !nsQueues.changed("plain", namespaceQueue{}))
+
+	namespaces := []*v1.Namespace{
+		newNamespaceWithParent("ns1", "root.dev"),
+		newNamespaceWithParent("ns2", "team.prod"),
+		newNamespaceWithParent("existing", "root.DEV"),
+		newNamespaceWithParent("plain", ""),
+	}
+	config := nsQueues.apply(namespaceQueuesConfig, namespaces)
+	schedulerConfig, err := configs.ParseAndValidateConfig([]byte(config))
+	assert.NilError(t, err, "generated config must be valid")
+	root := schedulerConfig.Partitions[0].Queues
+	dev := getConfigQueue(t, root, "root", "dev")
+	assert.Equal(t, dev.SubmitACL, "*")
+	assert.Equal(t, len(dev.Queues), 2)
+	// configured queues are not changed
+	assert.Equal(t, getConfigQueue(t, root, "root", "dev", "existing").MaxApplications, uint64(5))
+	assert.Assert(t, !getConfigQueue(t, root, "root", "dev", "ns1").Parent)
+	// missing parents are created, the root prefix is added if needed
+	assert.Assert(t, getConfigQueue(t, root, "root", "team").Parent)
+	assert.Assert(t, getConfigQueue(t, root, "root", "team", "prod").Parent)
+	assert.Assert(t, !getConfigQueue(t, root, "root", "team", "prod", "ns2").Parent)
+
+	assert.Assert(t, !nsQueues.changed("ns1", namespaceQueue{parent: "root.dev"}))
+	assert.Assert(t, nsQueues.changed("ns1", namespaceQueue{parent: "root.other"}))
+	assert.Assert(t, nsQueues.changed("ns1", namespaceQueue{}), "deleted namespace must be detected")
+	assert.Assert(t, nsQueues.changed("ns3", namespaceQueue{parent: "root.dev"}))
+
+	// the output is stable
+	assert.Equal(t, nsQueues.apply(namespaceQueuesConfig, namespaces), config)
+}
+
+func TestNamespaceQueuesApplyDefaultConfig(t *testing.T) {
+	nsQueues := newNamespaceQueues()
+	config := nsQueues.apply("", []*v1.Namespace{newNamespaceWithParent("ns1", "root.dev")})
+	schedulerConfig, err := configs.ParseAndValidateConfig([]byte(config))
+	assert.NilError(t, err, "generated config must be valid")
+	// the placement rules of the default config are kept
+	assert.Equal(t, len(schedulerConfig.Partitions[0].PlacementRules), 1)
+	getConfigQueue(t, schedulerConfig.Partitions[0].Queues, "root", "dev", "ns1")
+
+	// invalid config is passed on unchanged
+	assert.Equal(t, nsQueues.apply("partitions: [", []*v1.Namespace{newNamespaceWithParent("ns1", "root.dev")}), "partitions: [")
+}
+
+func TestNamespaceQueuesQuota(t *testing.T) {
+	nsQueues := newNamespaceQueues()
+	namespace := newNamespaceWithParent("ns1", "root.dev")
+	namespace.Annotations[constants.NamespaceQuota] = "{\"cpu\": \"1500m\", \"memory\": \"1G\"}"
+	namespace.Annotations[constants.NamespaceGuaranteed] = "{\"cpu\": \"1\"}"
+	existing := newNamespaceWithParent("existing", "root.dev")
+	existing.Annotations[constants.NamespaceQuota] = "{\"cpu\": \"1\"}"
+	config := nsQueues.apply(namespaceQueuesConfig, []*v1.Namespace{namespace, existing})
+	schedulerConfig, err := configs.ParseAndValidateConfig([]byte(config))
+	assert.NilError(t, err, "generated config must be valid")
+	ns1 := getConfigQueue(t, schedulerConfig.Partitions[0].Queues, "root", "dev", "ns1")
+	assert.DeepEqual(t, ns1.Resources.Max, map[string]string{"vcore": "1500m", "memory": "1000000000"})
+	assert.DeepEqual(t, ns1.Resources.Guaranteed, map[string]string{"vcore": "1000m"})
+	// configured queues are not changed
+	assert.Equal(t, len(getConfigQueue(t, schedulerConfig.Partitions[0].Queues, "root", "dev", "existing").Resources.Max), 0)
+
+	// a quota change or removal changes the queue
+	assert.Assert(t, !nsQueues.changed("ns1", getNamespaceQueue(namespace)))
+	updated := namespace.DeepCopy()
+	updated.Annotations[constants.NamespaceQuota] = "{\"cpu\": \"2\"}"
+	assert.Assert(t, nsQueues.changed("ns1", getNamespaceQueue(updated)))
+	delete(updated.Annotations, constants.NamespaceQuota)
+	delete(updated.Annotations, constants.NamespaceGuaranteed)
+	assert.Assert(t, nsQueues.changed("ns1", getNamespaceQueue(updated)))
+	assert.Equal(t, nsQueues.changed("ns1", namespaceQueue{parent: "root.dev"}), nsQueues.changed("ns1", getNamespaceQueue(updated)))
+}
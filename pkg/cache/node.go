@@ -20,13 +20,16 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
 
+	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
@@ -38,6 +41,7 @@ type SchedulerNode struct {
 	name         string
 	uid          string
 	labels       map[string]string
+	partition    string
 	schedulable  bool
 	schedulerAPI api.SchedulerAPI
 	fsm          *fsm.FSM
@@ -46,6 +50,7 @@ type SchedulerNode struct {
 	capacity            *si.Resource
 	occupied            *si.Resource
 	ready               bool
+	weight              string
 	existingAllocations []*si.Allocation
 
 	lock *sync.RWMutex
@@ -57,6 +62,7 @@ func newSchedulerNode(nodeName string, nodeUID string, nodeLabels map[string]str
 		name:         nodeName,
 		uid:          nodeUID,
 		labels:       nodeLabels,
+		partition:    utils.GetNodePartition(nodeLabels),
 		capacity:     nodeResource,
 		occupied:     common.NewResourceBuilder().Build(),
 		schedulerAPI: schedulerAPI,
@@ -68,6 +74,21 @@ func newSchedulerNode(nodeName string, nodeUID string, nodeLabels map[string]str
 	return schedulerNode
 }
 
+func (n *SchedulerNode) getWeight() string {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.weight
+}
+
+func (n *SchedulerNode) setWeight(weight string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	log.Log(log.ShimCacheNode).Debug("set node weight",
+		zap.String("nodeID", n.name),
+		zap.String("weight", weight))
+	n.weight = weight
+}
+
 func (n *SchedulerNode) snapshotState() (capacity *si.Resource, occupied *si.Resource, ready bool) {
 	n.lock.RLock()
 	defer n.lock.RUnlock()
@@ -149,10 +170,10 @@ func (n *SchedulerNode) handleNodeRecovery() {
 		zap.String("nodeID", n.name),
 		zap.Bool("schedulable", n.schedulable))
 
-	nodeRequest := common.CreateUpdateRequestForNewNode(n.name, n.labels, n.capacity, n.occupied, n.existingAllocations, n.ready)
+	nodeRequest := common.CreateUpdateRequestForNewNode(n.name, n.partition, n.weight, n.labels, n.capacity, n.occupied, n.existingAllocations, n.ready)
 
 	// send node request to scheduler-core
-	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil {
+	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		log.Log(log.ShimCacheNode).Error("failed to send UpdateNode request",
 			zap.Any("request", nodeRequest))
 	}
@@ -162,10 +183,10 @@ func (n *SchedulerNode) handleDrainNode() {
 	log.Log(log.ShimCacheNode).Info("node enters draining mode",
 		zap.String("nodeID", n.name))
 
-	nodeRequest := common.CreateUpdateRequestForDeleteOrRestoreNode(n.name, si.NodeInfo_DRAIN_NODE)
+	nodeRequest := common.CreateUpdateRequestForDeleteOrRestoreNode(n.name, n.partition, si.NodeInfo_DRAIN_NODE)
 
 	// send request to scheduler-core
-	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil {
+	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		log.Log(log.ShimCacheNode).Error("failed to send UpdateNode request",
 			zap.Any("request", nodeRequest))
 	}
@@ -175,10 +196,10 @@ func (n *SchedulerNode) handleRestoreNode() {
 	log.Log(log.ShimCacheNode).Info("restore node from draining mode",
 		zap.String("nodeID", n.name))
 
-	nodeRequest := common.CreateUpdateRequestForDeleteOrRestoreNode(n.name, si.NodeInfo_DRAIN_TO_SCHEDULABLE)
+	nodeRequest := common.CreateUpdateRequestForDeleteOrRestoreNode(n.name, n.partition, si.NodeInfo_DRAIN_TO_SCHEDULABLE)
 
 	// send request to scheduler-core
-	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil {
+	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		log.Log(log.ShimCacheNode).Error("failed to send UpdateNode request",
 			zap.Any("request", nodeRequest))
 	}
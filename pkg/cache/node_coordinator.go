@@ -37,6 +37,8 @@ import (
 //  2. when a pod is terminated, sub the occupied node resource
 //  3. when a pod is deleted, sub the occupied node resource
 //
+// pods that are exempt from the occupied resource reporting are tracked but not reported.
+//
 // each of these updates will trigger a node UPDATE action to update the occupied
 // resource in the scheduler-core.
 type nodeResourceCoordinator struct {
@@ -84,7 +86,9 @@ func (c *nodeResourceCoordinator) updatePod(old, new interface{}) {
 		// if pod is running but not scheduled by us,
 		// we need to notify scheduler-core to re-sync the node resource
 		podResource := common.GetPodResource(newPod)
-		c.nodes.updateNodeOccupiedResources(newPod.Spec.NodeName, podResource, AddOccupiedResource)
+		if !c.nodes.addExemptPod(newPod, podResource) {
+			c.nodes.updateNodeOccupiedResources(newPod.Spec.NodeName, podResource, AddOccupiedResource)
+		}
 		c.nodes.cache.AddPod(newPod)
 		return
 	}
@@ -100,8 +104,10 @@ func (c *nodeResourceCoordinator) updatePod(old, new interface{}) {
 			zap.String("podStatusCurrent", string(newPod.Status.Phase)))
 		// this means pod is terminated
 		// we need sub the occupied resource and re-sync with the scheduler-core
-		podResource := common.GetPodResource(newPod)
-		c.nodes.updateNodeOccupiedResources(newPod.Spec.NodeName, podResource, SubOccupiedResource)
+		if !c.nodes.removeExemptPod(newPod) {
+			podResource := common.GetPodResource(newPod)
+			c.nodes.updateNodeOccupiedResources(newPod.Spec.NodeName, podResource, SubOccupiedResource)
+		}
 		c.nodes.cache.RemovePod(newPod)
 		return
 	}
@@ -134,7 +140,9 @@ func (c *nodeResourceCoordinator) deletePod(obj interface{}) {
 		zap.String("namespace", pod.Namespace),
 		zap.String("podName", pod.Name))
 
-	podResource := common.GetPodResource(pod)
-	c.nodes.updateNodeOccupiedResources(pod.Spec.NodeName, podResource, SubOccupiedResource)
+	if !c.nodes.removeExemptPod(pod) {
+		podResource := common.GetPodResource(pod)
+		c.nodes.updateNodeOccupiedResources(pod.Spec.NodeName, podResource, SubOccupiedResource)
+	}
 	c.nodes.cache.RemovePod(pod)
 }
@@ -25,7 +25,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
@@ -343,3 +346,111 @@ func TestNodeCoordinatorFilterPods(t *testing.T) {
 	assert.Check(t, coordinator.filterPods(pod2), "non-yunikorn-managed pod was filtered")
 	assert.Check(t, !coordinator.filterPods(pod3), "yunikorn-managed pod was allowed")
 }
+
+func TestUpdatePodOccupiedExempt(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.ForeignPodExemptSelector = "ci=runner"
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+
+	mockedSchedulerApi := newMockSchedulerAPI()
+	nodes := newSchedulerNodes(mockedSchedulerApi, NewTestSchedulerCache())
+	nodes.addNode(utils.NodeForTest(Host1, "10G", "10"))
+	coordinator := newNodeResourceCoordinator(nodes)
+	mockedSchedulerApi.UpdateNodeFn = func(request *si.NodeRequest) error {
+		t.Fatalf("update should not run for exempt pods")
+		return nil
+	}
+	pods, err := metrics.GetExemptForeignPods()
+	assert.NilError(t, err)
+
+	// annotated pod is assigned and terminated
+	pod1 := utils.PodForTest("pod1", "1G", "500m")
+	pod1.Annotations = map[string]string{constants.AnnotationOccupiedExempt: constants.True}
+	pod1.Status.Phase = v1.PodPending
+	pod2 := pod1.DeepCopy()
+	pod2.Spec.NodeName = Host1
+	coordinator.updatePod(pod1, pod2)
+	current, err := metrics.GetExemptForeignPods()
+	assert.NilError(t, err)
+	assert.Equal(t, current, pods+1)
+	pod1 = pod2.DeepCopy()
+	pod2.Status.Phase = v1.PodSucceeded
+	coordinator.updatePod(pod1, pod2)
+	current, err = metrics.GetExemptForeignPods()
+	assert.NilError(t, err)
+	assert.Equal(t, current, pods)
+
+	// pod matching the selector is assigned and deleted, the exemption is kept when the labels change
+	pod1 = utils.PodForTest("pod2", "1G", "500m")
+	pod1.UID = "UID-pod2"
+	pod1.Labels = map[string]string{"ci": "runner"}
+	pod1.Status.Phase = v1.PodPending
+	pod2 = pod1.DeepCopy()
+	pod2.Spec.NodeName = Host1
+	coordinator.updatePod(pod1, pod2)
+	current, err = metrics.GetExemptForeignPods()
+	assert.NilError(t, err)
+	assert.Equal(t, current, pods+1)
+	pod2.Labels = map[string]string{}
+	coordinator.deletePod(pod2)
+	current, err = metrics.GetExemptForeignPods()
+	assert.NilError(t, err)
+	assert.Equal(t, current, pods)
+
+	// pod that is not exempt is reported
+	pod1 = utils.PodForTest("pod3", "1G", "500m")
+	pod1.UID = "UID-pod3"
+	pod1.Status.Phase = v1.PodPending
+	pod2 = pod1.DeepCopy()
+	pod2.Spec.NodeName = Host1
+	executed := false
+	mockedSchedulerApi.UpdateNodeFn = func(request *si.NodeRequest) error {
+		executed = true
+		assert.Equal(t, request.Nodes[0].OccupiedResource.Resources[siCommon.CPU].Value, int64(500))
+		return nil
+	}
+	coordinator.updatePod(pod1, pod2)
+	assert.Assert(t, executed)
+}
+
+func TestUpdateMirrorPodOccupied(t *testing.T) {
+	mockedSchedulerApi := newMockSchedulerAPI()
+	nodes := newSchedulerNodes(mockedSchedulerApi, NewTestSchedulerCache())
+	nodes.addNode(utils.NodeForTest(Host1, "10G", "10"))
+	coordinator := newNodeResourceCoordinator(nodes)
+	executed := false
+	mockedSchedulerApi.UpdateNodeFn = func(request *si.NodeRequest) error {
+		executed = true
+		assert.Equal(t, request.Nodes[0].OccupiedResource.Resources[siCommon.CPU].Value, int64(500))
+		return nil
+	}
+
+	// mirror pods are reported as occupied by default
+	pod1 := utils.PodForTest("pod1", "1G", "500m")
+	pod1.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "hash"}
+	pod1.Status.Phase = v1.PodPending
+	pod2 := pod1.DeepCopy()
+	pod2.Spec.NodeName = Host1
+	coordinator.updatePod(pod1, pod2)
+	assert.Assert(t, executed)
+
+	// excluded mirror pods are not reported
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.ExcludeMirrorPods = true
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+	mockedSchedulerApi.UpdateNodeFn = func(request *si.NodeRequest) error {
+		t.Fatalf("update should not run for excluded mirror pods")
+		return nil
+	}
+	pod1 = utils.PodForTest("pod2", "1G", "500m")
+	pod1.UID = "UID-pod2"
+	pod1.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "hash"}
+	pod1.Status.Phase = v1.PodPending
+	pod2 = pod1.DeepCopy()
+	pod2.Spec.NodeName = Host1
+	coordinator.updatePod(pod1, pod2)
+}
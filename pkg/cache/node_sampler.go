@@ -0,0 +1,105 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// nodeSampler collects the nodes with a changed capacity, occupied resource or ready flag.
+// Instead of sending an update to the core for each pod or node event, the state of all changed nodes is sampled
+// at a fixed interval and sent to the core in a single UpdateNode call. Multiple changes of the same node within
+// the interval are coalesced into one update with the latest state.
+type nodeSampler struct {
+	changed map[string]struct{} // names of the nodes changed since the last sample
+	sync.Mutex
+}
+
+func newNodeSampler() *nodeSampler {
+	return &nodeSampler{
+		changed: make(map[string]struct{}),
+	}
+}
+
+// mark records that the state of the node has changed.
+func (s *nodeSampler) mark(name string) {
+	s.Lock()
+	defer s.Unlock()
+	s.changed[name] = struct{}{}
+}
+
+// take returns the names of the changed nodes and resets the list.
+func (s *nodeSampler) take() []string {
+	s.Lock()
+	defer s.Unlock()
+	names := make([]string, 0, len(s.changed))
+	for name := range s.changed {
+		names = append(names, name)
+	}
+	s.changed = make(map[string]struct{})
+	return names
+}
+
+// reportSampledNodes sends the current state of all nodes changed since the last sample to the core.
+// Nodes that have been removed in the meantime are skipped, the removal has already been reported.
+func (nc *schedulerNodes) reportSampledNodes() {
+	if nc.sampler == nil {
+		return
+	}
+	names := nc.sampler.take()
+	if len(names) == 0 {
+		return
+	}
+	nodes := make([]*si.NodeInfo, 0, len(names))
+	for _, name := range names {
+		schedulerNode := nc.getNode(name)
+		if schedulerNode == nil {
+			continue
+		}
+		capacity, occupied, ready := schedulerNode.snapshotState()
+		request := common.CreateUpdateRequestForUpdatedNode(name, schedulerNode.partition, schedulerNode.getWeight(), capacity, occupied, ready)
+		nodes = append(nodes, request.Nodes...)
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	log.Log(log.ShimCacheNode).Debug("report sampled node updates", zap.Int("numOfNodes", len(nodes)))
+	request := &si.NodeRequest{
+		Nodes: nodes,
+		RmID:  conf.GetSchedulerConf().ClusterID,
+	}
+	if err := nc.proxy.UpdateNode(request); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+		log.Log(log.ShimCacheNode).Info("hitting error while handling UpdateNode", zap.Error(err))
+	}
+}
+
+// ReportSampledNodes sends the state of the nodes changed since the last call to the core.
+// Called periodically if node sampling is enabled.
+func (ctx *Context) ReportSampledNodes() {
+	ctx.nodes.reportSampledNodes()
+}
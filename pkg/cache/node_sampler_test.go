@@ -0,0 +1,113 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/test"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func newSamplerTestNode(name string, cpu int64) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: name,
+			UID:  types.UID("uid_" + name),
+		},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU: *resource.NewQuantity(cpu, resource.DecimalSI),
+			},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func TestNodeSamplerTake(t *testing.T) {
+	sampler := newNodeSampler()
+	assert.Equal(t, len(sampler.take()), 0)
+	sampler.mark("node-1")
+	sampler.mark("node-2")
+	sampler.mark("node-1")
+	assert.Equal(t, len(sampler.take()), 2)
+	assert.Equal(t, len(sampler.take()), 0, "changed nodes not reset")
+}
+
+func TestReportSampledNodes(t *testing.T) {
+	api := test.NewSchedulerAPIMock()
+	var requests []*si.NodeRequest
+	api.UpdateNodeFunction(func(request *si.NodeRequest) error {
+		requests = append(requests, request)
+		return nil
+	})
+	nodes := newSchedulerNodes(api, NewTestSchedulerCache())
+	nodes.sampler = newNodeSampler()
+	nodes.addAndReportNode(newSamplerTestNode("node-1", 10), false)
+	nodes.addAndReportNode(newSamplerTestNode("node-2", 10), false)
+	nodes.addAndReportNode(newSamplerTestNode("node-3", 10), false)
+
+	// nothing changed
+	nodes.reportSampledNodes()
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(0))
+
+	// changes are coalesced per node and not reported immediately
+	podResource := common.NewResourceBuilder().AddResource(siCommon.CPU, 1000).Build()
+	for i := 0; i < 3; i++ {
+		nodes.updateNodeOccupiedResources("node-1", podResource, AddOccupiedResource)
+	}
+	nodes.updateNodeOccupiedResources("node-2", podResource, AddOccupiedResource)
+	nodes.updateNodeOccupiedResources("node-2", podResource, SubOccupiedResource)
+	nodes.updateNode(newSamplerTestNode("node-3", 10), newSamplerTestNode("node-3", 20))
+	nodes.updateNodeOccupiedResources("unknown", podResource, AddOccupiedResource)
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(0))
+
+	// one request with the latest state of each changed node
+	nodes.reportSampledNodes()
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(1))
+	assert.Equal(t, len(requests[0].Nodes), 3)
+	for _, node := range requests[0].Nodes {
+		assert.Equal(t, node.Action, si.NodeInfo_UPDATE)
+		switch node.NodeID {
+		case "node-1":
+			assert.Equal(t, node.OccupiedResource.Resources[siCommon.CPU].Value, int64(3000))
+		case "node-2":
+			assert.Equal(t, node.OccupiedResource.Resources[siCommon.CPU].Value, int64(0))
+		case "node-3":
+			assert.Equal(t, node.SchedulableResource.Resources[siCommon.CPU].Value, int64(20000))
+		default:
+			t.Fatalf("unexpected node %s", node.NodeID)
+		}
+	}
+
+	// a removed node is not reported
+	nodes.updateNodeOccupiedResources("node-1", podResource, SubOccupiedResource)
+	nodes.deleteNode(newSamplerTestNode("node-1", 10))
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(2), "delete not reported immediately")
+	nodes.reportSampledNodes()
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(2))
+}
@@ -19,16 +19,22 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/apache/yunikorn-k8shim/pkg/cache/external"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/eventstream"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -43,18 +49,21 @@ const (
 
 // scheduler nodes maintain cluster nodes and their status for the scheduler
 type schedulerNodes struct {
-	proxy    api.SchedulerAPI
-	nodesMap map[string]*SchedulerNode
-	cache    *external.SchedulerCache
-	lock     *sync.RWMutex
+	proxy      api.SchedulerAPI
+	nodesMap   map[string]*SchedulerNode
+	cache      *external.SchedulerCache
+	exemptPods map[types.UID]*si.Resource // foreign pods not reported as occupied
+	sampler    *nodeSampler               // collects node updates for periodic reporting, nil if disabled
+	lock       *sync.RWMutex
 }
 
 func newSchedulerNodes(schedulerAPI api.SchedulerAPI, cache *external.SchedulerCache) *schedulerNodes {
 	return &schedulerNodes{
-		proxy:    schedulerAPI,
-		nodesMap: make(map[string]*SchedulerNode),
-		cache:    cache,
-		lock:     &sync.RWMutex{},
+		proxy:      schedulerAPI,
+		nodesMap:   make(map[string]*SchedulerNode),
+		cache:      cache,
+		exemptPods: make(map[types.UID]*si.Resource),
+		lock:       &sync.RWMutex{},
 	}
 }
 
@@ -94,6 +103,20 @@ func (nc *schedulerNodes) addNode(node *v1.Node) {
 	nc.addAndReportNode(node, true)
 }
 
+// addReservedNode adds the node with the reserved resources as occupied. The reservation is part of the
+// registration of the node in the core, the node is never available with its full capacity.
+func (nc *schedulerNodes) addReservedNode(node *v1.Node, reserved *si.Resource) {
+	if common.IsZero(reserved) {
+		nc.addNode(node)
+		return
+	}
+	nc.addAndReportNode(node, false)
+	if schedulerNode := nc.getNode(node.Name); schedulerNode != nil {
+		schedulerNode.updateOccupiedResource(reserved, AddOccupiedResource)
+		triggerEvent(schedulerNode, SchedulerNodeStates().New, RecoverNode)
+	}
+}
+
 func (nc *schedulerNodes) addAndReportNode(node *v1.Node, reportNode bool) {
 	nc.lock.Lock()
 	defer nc.lock.Unlock()
@@ -108,7 +131,10 @@ func (nc *schedulerNodes) addAndReportNode(node *v1.Node, reportNode bool) {
 		ready := hasReadyCondition(node)
 		newNode := newSchedulerNode(node.Name, string(node.UID), node.Labels,
 			common.GetNodeResource(&node.Status), nc.proxy, !node.Spec.Unschedulable, ready)
+		newNode.weight = utils.GetNodeWeight(node)
 		nc.nodesMap[node.Name] = newNode
+		eventstream.Publish(eventstream.TopicNode, node.Name, newNode.partition,
+			"Node %s added, schedulable: %t, ready: %t", node.Name, !node.Spec.Unschedulable, ready)
 	}
 
 	// once node is added to scheduler, first thing is to recover its state
@@ -128,11 +154,15 @@ func (nc *schedulerNodes) updateNodeOccupiedResources(name string, resource *si.
 
 	if schedulerNode := nc.getNode(name); schedulerNode != nil {
 		capacity, occupied, ready := schedulerNode.updateOccupiedResource(resource, opt)
-		request := common.CreateUpdateRequestForUpdatedNode(name, capacity, occupied, ready)
+		if nc.sampler != nil {
+			nc.sampler.mark(name)
+			return
+		}
+		request := common.CreateUpdateRequestForUpdatedNode(name, schedulerNode.partition, schedulerNode.getWeight(), capacity, occupied, ready)
 		log.Log(log.ShimCacheNode).Info("report occupied resources updates",
 			zap.String("node", schedulerNode.name),
 			zap.Any("request", request))
-		if err := nc.proxy.UpdateNode(request); err != nil {
+		if err := nc.proxy.UpdateNode(request); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 			log.Log(log.ShimCacheNode).Info("hitting error while handling UpdateNode", zap.Error(err))
 		}
 	}
@@ -151,6 +181,14 @@ func (nc *schedulerNodes) updateNode(oldNode, newNode *v1.Node) {
 	nc.lock.Lock()
 	defer nc.lock.Unlock()
 
+	// moving a node between partitions is not supported, it would need to be removed with all its allocations
+	if partition := utils.GetNodePartition(newNode.Labels); partition != cachedNode.partition {
+		log.Log(log.ShimCacheNode).Warn("node partition label changed, node stays in its current partition",
+			zap.String("nodeName", newNode.Name),
+			zap.String("partition", cachedNode.partition),
+			zap.String("newPartition", partition))
+	}
+
 	// cordon or restore node
 	if (!oldNode.Spec.Unschedulable) && newNode.Spec.Unschedulable {
 		triggerEvent(cachedNode, SchedulerNodeStates().Healthy, DrainNode)
@@ -159,10 +197,12 @@ func (nc *schedulerNodes) updateNode(oldNode, newNode *v1.Node) {
 	}
 
 	ready := hasReadyCondition(newNode)
+	weight := utils.GetNodeWeight(newNode)
 	capacityUpdated := equals(oldNode, newNode)
 	readyUpdated := cachedNode.ready == ready
+	weightUpdated := cachedNode.getWeight() == weight
 
-	if capacityUpdated && readyUpdated {
+	if capacityUpdated && readyUpdated && weightUpdated {
 		return
 	}
 
@@ -176,13 +216,25 @@ func (nc *schedulerNodes) updateNode(oldNode, newNode *v1.Node) {
 		cachedNode.setReadyStatus(ready)
 	}
 
+	// Has node weight updated?
+	if !weightUpdated {
+		cachedNode.setWeight(weight)
+	}
+
 	log.Log(log.ShimCacheNode).Info("Node's ready status flag", zap.String("Node name", newNode.Name),
 		zap.Bool("ready", ready))
+	eventstream.Publish(eventstream.TopicNode, newNode.Name, cachedNode.partition,
+		"Node %s updated, schedulable: %t, ready: %t, weight: %s", newNode.Name, !newNode.Spec.Unschedulable, ready, weight)
+
+	if nc.sampler != nil {
+		nc.sampler.mark(newNode.Name)
+		return
+	}
 
 	capacity, occupied, ready := cachedNode.snapshotState()
-	request := common.CreateUpdateRequestForUpdatedNode(newNode.Name, capacity, occupied, ready)
+	request := common.CreateUpdateRequestForUpdatedNode(newNode.Name, cachedNode.partition, weight, capacity, occupied, ready)
 	log.Log(log.ShimCacheNode).Info("report updated nodes to scheduler", zap.Any("request", request))
-	if err := nc.proxy.UpdateNode(request); err != nil {
+	if err := nc.proxy.UpdateNode(request); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		log.Log(log.ShimCacheNode).Info("hitting error while handling UpdateNode", zap.Error(err))
 	}
 }
@@ -191,11 +243,38 @@ func (nc *schedulerNodes) deleteNode(node *v1.Node) {
 	nc.lock.Lock()
 	defer nc.lock.Unlock()
 
+	partition := utils.GetNodePartition(node.Labels)
+	if cachedNode, ok := nc.nodesMap[node.Name]; ok {
+		partition = cachedNode.partition
+	}
 	delete(nc.nodesMap, node.Name)
+	eventstream.Publish(eventstream.TopicNode, node.Name, partition, "Node %s removed", node.Name)
+
+	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name, partition, si.NodeInfo_DECOMISSION)
+	log.Log(log.ShimCacheNode).Info("report updated nodes to scheduler", zap.Any("request", request.String()))
+	if err := nc.proxy.UpdateNode(request); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+		log.Log(log.ShimCacheNode).Error("hitting error while handling UpdateNode", zap.Error(err))
+	}
+}
+
+// drainNode stops the scheduling of new allocations on the node, existing allocations are not affected.
+func (nc *schedulerNodes) drainNode(nodeName string) {
+	nc.reportNodeAction(nodeName, si.NodeInfo_DRAIN_NODE)
+}
 
-	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name, si.NodeInfo_DECOMISSION)
+// restoreNode makes a drained node schedulable again.
+func (nc *schedulerNodes) restoreNode(nodeName string) {
+	nc.reportNodeAction(nodeName, si.NodeInfo_DRAIN_TO_SCHEDULABLE)
+}
+
+func (nc *schedulerNodes) reportNodeAction(nodeName string, action si.NodeInfo_ActionFromRM) {
+	partition := constants.DefaultPartition
+	if node := nc.getNode(nodeName); node != nil {
+		partition = node.partition
+	}
+	request := common.CreateUpdateRequestForDeleteOrRestoreNode(nodeName, partition, action)
 	log.Log(log.ShimCacheNode).Info("report updated nodes to scheduler", zap.Any("request", request.String()))
-	if err := nc.proxy.UpdateNode(request); err != nil {
+	if err := nc.proxy.UpdateNode(request); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		log.Log(log.ShimCacheNode).Error("hitting error while handling UpdateNode", zap.Error(err))
 	}
 }
@@ -20,6 +20,7 @@ package cache
 
 import (
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 
 	"github.com/apache/yunikorn-k8shim/pkg/cache/external"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/test"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
@@ -77,6 +79,125 @@ func TestAddNode(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestNodePartition(t *testing.T) {
+	api := test.NewSchedulerAPIMock()
+	var partitions []string
+	var lock sync.Mutex
+	api.UpdateNodeFunction(func(request *si.NodeRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, node := range request.Nodes {
+			partitions = append(partitions, node.Attributes[siCommon.NodePartition])
+		}
+		return nil
+	})
+
+	nodes := newSchedulerNodes(api, NewTestSchedulerCache())
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeNode, nodes.schedulerNodeEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	resourceList := make(map[v1.ResourceName]resource.Quantity)
+	resourceList[v1.ResourceName("memory")] = *resource.NewQuantity(1024*1000*1000, resource.DecimalSI)
+	resourceList[v1.ResourceName("cpu")] = *resource.NewQuantity(10, resource.DecimalSI)
+	var node = v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "host0001",
+			Namespace: "default",
+			UID:       "uid_0001",
+			Labels:    map[string]string{constants.LabelPartition: "gpu"},
+		},
+		Status: v1.NodeStatus{
+			Allocatable: resourceList,
+		},
+	}
+
+	nodes.addNode(&node)
+	err := utils.WaitForCondition(func() bool {
+		return api.GetUpdateNodeCount() == 1
+	}, 100*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, nodes.getNode("host0001").partition, "gpu")
+
+	// the node stays in its partition when the label changes
+	updatedNode := node.DeepCopy()
+	updatedNode.Labels = map[string]string{constants.LabelPartition: "other"}
+	nodes.updateNode(&node, updatedNode)
+	nodes.drainNode("host0001")
+	nodes.deleteNode(updatedNode)
+	err = utils.WaitForCondition(func() bool {
+		return api.GetUpdateNodeCount() == 3
+	}, 100*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.DeepEqual(t, partitions, []string{"gpu", "gpu", "gpu"})
+}
+
+func TestNodeWeight(t *testing.T) {
+	api := test.NewSchedulerAPIMock()
+	var weights []string
+	var lock sync.Mutex
+	api.UpdateNodeFunction(func(request *si.NodeRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, node := range request.Nodes {
+			weights = append(weights, node.Attributes[constants.NodeWeightAttribute])
+		}
+		return nil
+	})
+
+	nodes := newSchedulerNodes(api, NewTestSchedulerCache())
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeNode, nodes.schedulerNodeEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	resourceList := make(map[v1.ResourceName]resource.Quantity)
+	resourceList[v1.ResourceName("memory")] = *resource.NewQuantity(1024*1000*1000, resource.DecimalSI)
+	resourceList[v1.ResourceName("cpu")] = *resource.NewQuantity(10, resource.DecimalSI)
+	var node = v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "host0001",
+			Namespace: "default",
+			UID:       "uid_0001",
+			Labels:    map[string]string{constants.NodeWeight: "5"},
+		},
+		Status: v1.NodeStatus{
+			Allocatable: resourceList,
+		},
+	}
+
+	nodes.addNode(&node)
+	err := utils.WaitForCondition(func() bool {
+		return api.GetUpdateNodeCount() == 1
+	}, 100*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, nodes.getNode("host0001").getWeight(), "5")
+
+	// the annotation overrides the label
+	annotated := node.DeepCopy()
+	annotated.Annotations = map[string]string{constants.NodeWeight: "7.50"}
+	nodes.updateNode(&node, annotated)
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(2))
+	assert.Equal(t, nodes.getNode("host0001").getWeight(), "7.5")
+
+	// no change, nothing is reported
+	nodes.updateNode(annotated, annotated.DeepCopy())
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(2))
+
+	// an invalid weight is ignored
+	invalid := node.DeepCopy()
+	invalid.Labels = map[string]string{constants.NodeWeight: "-1"}
+	nodes.updateNode(annotated, invalid)
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(3))
+	assert.Equal(t, nodes.getNode("host0001").getWeight(), "")
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.DeepEqual(t, weights, []string{"5", "7.5", ""})
+}
+
 func TestUpdateNode(t *testing.T) {
 	api := test.NewSchedulerAPIMock()
 
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 
+	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
 
 // MUST: run the placeholder pod as non-root user
@@ -48,6 +50,11 @@ type Placeholder struct {
 }
 
 func newPlaceholder(placeholderName string, app *Application, taskGroup v1alpha1.TaskGroup) *Placeholder {
+	// Each placeholder gets its own copy of the scheduling constraints of the task group:
+	// the pod spec must not share the node selector, tolerations or affinity with the
+	// task group definition or with the other placeholders of the same group.
+	constraints := taskGroup.DeepCopy()
+
 	// Here the owner reference is always the originator pod
 	ownerRefs := app.getPlaceholderOwnerReferences()
 	annotations := utils.MergeMaps(taskGroup.Annotations, map[string]string{
@@ -79,15 +86,23 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup v1alpha1
 		}
 	}
 
+	labels := utils.MergeMaps(taskGroup.Labels, map[string]string{
+		constants.LabelApplicationID:   app.GetApplicationID(),
+		constants.LabelQueueName:       app.GetQueue(),
+		constants.LabelPlaceholderFlag: "true",
+	})
+
+	// Keep all placeholders of the task group in the same topology domain
+	if taskGroup.TopologyKey != "" {
+		labels[constants.LabelTaskGroupName] = taskGroup.Name
+		constraints.Affinity = addTopologyAffinity(constraints.Affinity, app.GetApplicationID(), taskGroup)
+	}
+
 	placeholderPod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      placeholderName,
-			Namespace: app.tags[constants.AppTagNamespace],
-			Labels: utils.MergeMaps(taskGroup.Labels, map[string]string{
-				constants.LabelApplicationID:   app.GetApplicationID(),
-				constants.LabelQueueName:       app.GetQueue(),
-				constants.LabelPlaceholderFlag: "true",
-			}),
+			Name:            placeholderName,
+			Namespace:       app.tags[constants.AppTagNamespace],
+			Labels:          labels,
 			Annotations:     annotations,
 			OwnerReferences: ownerRefs,
 		},
@@ -109,12 +124,16 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup v1alpha1
 			},
 			RestartPolicy: constants.PlaceholderPodRestartPolicy,
 			SchedulerName: constants.SchedulerName,
-			NodeSelector:  taskGroup.NodeSelector,
-			Tolerations:   taskGroup.Tolerations,
-			Affinity:      taskGroup.Affinity,
+			NodeSelector:  constraints.NodeSelector,
+			Tolerations:   constraints.Tolerations,
+			Affinity:      constraints.Affinity,
 		},
 	}
 
+	if taskGroup.PlaceholderTemplate != "" {
+		applyPlaceholderTemplate(placeholderPod, app.GetApplicationID(), taskGroup)
+	}
+
 	return &Placeholder{
 		appID:         app.GetApplicationID(),
 		taskGroupName: taskGroup.Name,
@@ -122,6 +141,59 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup v1alpha1
 	}
 }
 
+// applyPlaceholderTemplate overrides the pod spec of the placeholder with the placeholder template referenced by the
+// task group. The placeholder keeps the defaults if the template is not defined in the scheduler configuration.
+func applyPlaceholderTemplate(pod *v1.Pod, appID string, taskGroup v1alpha1.TaskGroup) {
+	template, ok := conf.GetSchedulerConf().GetPlaceholderTemplate(taskGroup.PlaceholderTemplate)
+	if !ok {
+		log.Log(log.ShimCacheApplication).Warn("placeholder template not found, using default placeholder spec",
+			zap.String("appID", appID),
+			zap.String("taskGroup", taskGroup.Name),
+			zap.String("template", taskGroup.PlaceholderTemplate))
+		return
+	}
+	container := &pod.Spec.Containers[0]
+	if template.Image != "" {
+		container.Image = template.Image
+	}
+	if template.SecurityContext != nil {
+		pod.Spec.SecurityContext = template.SecurityContext.DeepCopy()
+	}
+	if template.ContainerSecurityContext != nil {
+		container.SecurityContext = template.ContainerSecurityContext.DeepCopy()
+	}
+	if template.RuntimeClassName != "" {
+		runtimeClassName := template.RuntimeClassName
+		pod.Spec.RuntimeClassName = &runtimeClassName
+	}
+	if template.PriorityClassName != "" {
+		pod.Spec.PriorityClassName = template.PriorityClassName
+	}
+}
+
+// addTopologyAffinity adds a required pod affinity term that selects the placeholders of the task group
+// in the topology domain of the task group. The first placeholder matches its own term and can be placed
+// in any domain, all others follow it.
+func addTopologyAffinity(affinity *v1.Affinity, appID string, taskGroup v1alpha1.TaskGroup) *v1.Affinity {
+	if affinity == nil {
+		affinity = &v1.Affinity{}
+	}
+	if affinity.PodAffinity == nil {
+		affinity.PodAffinity = &v1.PodAffinity{}
+	}
+	affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, v1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					constants.LabelApplicationID: appID,
+					constants.LabelTaskGroupName: taskGroup.Name,
+				},
+			},
+			TopologyKey: taskGroup.TopologyKey,
+		})
+	return affinity
+}
+
 func (p *Placeholder) String() string {
 	return fmt.Sprintf("appID: %s, taskGroup: %s, podName: %s/%s",
 		p.appID, p.taskGroupName, p.pod.Namespace, p.pod.Name)
@@ -19,6 +19,7 @@
 package cache
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,9 +27,15 @@ import (
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
 
@@ -39,7 +46,12 @@ type PlaceholderManager struct {
 	// when the placeholder manager is unable to delete a pod,
 	// this pod becomes to be an "orphan" pod. We add them to a map
 	// and keep retrying deleting them in order to avoid wasting resources.
-	orphanPods  map[string]*v1.Pod
+	orphanPods map[string]*v1.Pod
+	// placeholder pods found without their application in the shim cache, with the time they were first found.
+	// These pods are deleted once they have been orphaned for longer than the configured TTL.
+	orphanSince map[types.UID]time.Time
+	// checks if the application exists in the shim cache, orphaned placeholders are not collected if not set
+	appExists   func(appID string) bool
 	stopChan    chan struct{}
 	running     atomic.Value
 	cleanupTime time.Duration
@@ -61,6 +73,7 @@ func NewPlaceholderManager(clients *client.Clients) *PlaceholderManager {
 		clients:     clients,
 		running:     r,
 		orphanPods:  make(map[string]*v1.Pod),
+		orphanSince: make(map[types.UID]time.Time),
 		stopChan:    make(chan struct{}),
 		cleanupTime: 5 * time.Second,
 	}
@@ -82,6 +95,18 @@ func (mgr *PlaceholderManager) createAppPlaceholders(app *Application) error {
 		existingPlaceHolders[phTasks.GetTaskPod().GetName()] = struct{}{}
 	}
 
+	// an application exceeding the gang limits of the namespace must not reserve resources
+	if err := mgr.checkGangLimits(app); err != nil {
+		log.Log(log.ShimCachePlaceholder).Warn("not creating placeholders for application",
+			zap.String("appID", app.GetApplicationID()),
+			zap.Error(err))
+		if app.originatingTask != nil {
+			events.GetRecorder().Eventf(app.originatingTask.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "GangLimitExceeded", "GangLimitExceeded",
+				"Placeholders are not created: %s", err.Error())
+		}
+		return err
+	}
+
 	// iterate all task groups, create placeholders for all the min members
 	for _, tg := range app.getTaskGroups() {
 		for i := int32(0); i < tg.MinMember; i++ {
@@ -108,6 +133,69 @@ func (mgr *PlaceholderManager) createAppPlaceholders(app *Application) error {
 	return nil
 }
 
+// checkGangLimits returns an error if the task groups of the application exceed the maximum gang size or
+// placeholder resources set on the namespace of the application.
+func (mgr *PlaceholderManager) checkGangLimits(app *Application) error {
+	if mgr.clients.NamespaceInformer == nil {
+		return nil
+	}
+	namespace := app.tags[constants.AppTagNamespace]
+	if namespace == "" {
+		return nil
+	}
+	namespaceObj, err := mgr.clients.NamespaceInformer.Lister().Get(namespace)
+	if err != nil || namespaceObj == nil {
+		return nil
+	}
+	maxGangSize := utils.GetNamespaceMaxGangSize(namespaceObj)
+	maxResource := utils.GetNamespaceMaxPlaceholderResource(namespaceObj)
+	if maxGangSize == 0 && maxResource == nil {
+		return nil
+	}
+	return utils.CheckGangLimits(app.getTaskGroups(), maxGangSize, maxResource)
+}
+
+// recreatePlaceholder replaces a placeholder pod that has failed and will never run, e.g. rejected by the kubelet.
+// The failed pod is removed, the replacement is generated from the current task group definition.
+func (mgr *PlaceholderManager) recreatePlaceholder(app *Application, pod *v1.Pod) error {
+	mgr.Lock()
+	defer mgr.Unlock()
+
+	tgName := utils.GetTaskGroupFromPodSpec(pod)
+	var taskGroup *v1alpha1.TaskGroup
+	for _, tg := range app.getTaskGroups() {
+		if tg.Name == tgName {
+			taskGroup = tg.DeepCopy()
+			break
+		}
+	}
+	if taskGroup == nil {
+		return fmt.Errorf("task group %s not found for application %s", tgName, app.GetApplicationID())
+	}
+
+	if err := mgr.clients.KubeClient.Delete(pod); err != nil {
+		log.Log(log.ShimCachePlaceholder).Warn("failed to remove failed placeholder pod",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		if !strings.Contains(err.Error(), "not found") {
+			mgr.orphanPods[string(pod.UID)] = pod
+		}
+	}
+
+	placeholder := newPlaceholder(pod.Name, app, *taskGroup)
+	// the failed pod might still exist: let the API server generate a unique name
+	placeholder.pod.Name = ""
+	placeholder.pod.GenerateName = pod.Name + "-"
+	created, err := mgr.clients.KubeClient.Create(placeholder.pod)
+	if err != nil {
+		return err
+	}
+	log.Log(log.ShimCachePlaceholder).Info("placeholder recreated",
+		zap.String("failedPod", pod.Name),
+		zap.String("placeholder", created.Name))
+	return nil
+}
+
 // clean up all the placeholders for an application
 func (mgr *PlaceholderManager) cleanUp(app *Application) {
 	mgr.Lock()
@@ -145,6 +233,76 @@ func (mgr *PlaceholderManager) cleanOrphanPlaceholders() {
 	}
 }
 
+// SetApplicationLookup sets the function used to check if the application of a placeholder pod exists in the
+// shim cache. Orphaned placeholder pods are only collected after the lookup is set, which must not happen before
+// the recovery completed: the applications of all placeholders are missing until then.
+func (mgr *PlaceholderManager) SetApplicationLookup(appExists func(appID string) bool) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	mgr.appExists = appExists
+}
+
+// collectOrphanPlaceholders deletes the placeholder pods of applications that do not exist in the shim cache, e.g.
+// after a restart or a failure of the application. The pods are deleted when they are still orphaned after the
+// configured TTL, which gives the recovery time to add the application back. A zero TTL disables the collection.
+func (mgr *PlaceholderManager) collectOrphanPlaceholders() {
+	mgr.RLock()
+	appExists := mgr.appExists
+	mgr.RUnlock()
+	ttl := conf.GetSchedulerConf().GetPlaceholderOrphanTTL()
+	if appExists == nil || ttl <= 0 {
+		return
+	}
+
+	pods, err := mgr.clients.PodInformer.Lister().List(labels.SelectorFromSet(labels.Set{constants.LabelPlaceholderFlag: constants.True}))
+	if err != nil {
+		log.Log(log.ShimCachePlaceholder).Warn("failed to list placeholder pods", zap.Error(err))
+		return
+	}
+	// check the applications before locking: the lookup locks the shim cache
+	var orphans []*v1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if appID := utils.GetApplicationIDFromPod(pod); appID != "" && !appExists(appID) {
+			orphans = append(orphans, pod)
+		}
+	}
+
+	mgr.Lock()
+	defer mgr.Unlock()
+	now := time.Now()
+	found := make(map[types.UID]time.Time, len(orphans))
+	for _, pod := range orphans {
+		since, ok := mgr.orphanSince[pod.UID]
+		if !ok {
+			log.Log(log.ShimCachePlaceholder).Info("found placeholder pod without application",
+				zap.String("podName", pod.Name),
+				zap.String("appID", utils.GetApplicationIDFromPod(pod)),
+				zap.Duration("ttl", ttl))
+			since = now
+		}
+		found[pod.UID] = since
+		if now.Sub(since) < ttl {
+			continue
+		}
+		log.Log(log.ShimCachePlaceholder).Info("deleting orphaned placeholder pod",
+			zap.String("podName", pod.Name),
+			zap.String("appID", utils.GetApplicationIDFromPod(pod)))
+		if err = mgr.clients.KubeClient.Delete(pod); err != nil {
+			log.Log(log.ShimCachePlaceholder).Warn("failed to delete orphaned placeholder pod",
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+			if !strings.Contains(err.Error(), "not found") {
+				mgr.orphanPods[string(pod.UID)] = pod
+			}
+		}
+	}
+	// pods that are gone or got their application back are forgotten
+	mgr.orphanSince = found
+}
+
 func (mgr *PlaceholderManager) Start() {
 	if mgr.isRunning() {
 		log.Log(log.ShimCachePlaceholder).Info("PlaceholderManager is already started")
@@ -162,6 +320,7 @@ func (mgr *PlaceholderManager) Start() {
 				return
 			case <-time.After(mgr.getCleanupTime()):
 				mgr.cleanOrphanPlaceholders()
+				mgr.collectOrphanPlaceholders()
 			}
 		}
 	}()
@@ -28,10 +28,13 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/test"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 )
 
 const (
@@ -73,6 +76,52 @@ func TestCreateAppPlaceholders(t *testing.T) {
 	assert.Error(t, err, "failed to create pod tg-test-group-2-app01-15")
 }
 
+func TestCreateAppPlaceholdersGangLimits(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	created := 0
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		created++
+		return pod, nil
+	})
+	nsLister, ok := mockedAPIProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+	placeholderMgr = NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+
+	// the application has 30 members requesting 25 cpu
+	nsLister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{
+		Name: namespace,
+		Annotations: map[string]string{
+			constants.NamespaceMaxGangSize: "29",
+		},
+	}})
+	err := placeholderMgr.createAppPlaceholders(app)
+	assert.Error(t, err, "gang size 30 exceeds the maximum gang size 29 of the namespace")
+	assert.Equal(t, created, 0, "placeholders created for application exceeding gang size")
+
+	nsLister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{
+		Name: namespace,
+		Annotations: map[string]string{
+			constants.NamespaceMaxGangSize:            "30",
+			constants.NamespaceMaxPlaceholderResource: `{"cpu": "20"}`,
+		},
+	}})
+	err = placeholderMgr.createAppPlaceholders(app)
+	assert.Error(t, err, "placeholder resource vcore of 25000 exceeds the maximum 20000 of the namespace")
+	assert.Equal(t, created, 0, "placeholders created for application exceeding placeholder resources")
+
+	nsLister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{
+		Name: namespace,
+		Annotations: map[string]string{
+			constants.NamespaceMaxGangSize:            "30",
+			constants.NamespaceMaxPlaceholderResource: `{"cpu": "25"}`,
+		},
+	}})
+	err = placeholderMgr.createAppPlaceholders(app)
+	assert.NilError(t, err)
+	assert.Equal(t, created, 30)
+}
+
 func TestCreateAppPlaceholdersWithExistingPods(t *testing.T) {
 	createdPods := make(map[string]*v1.Pod)
 	mockedAPIProvider := client.NewMockedAPIProvider(false)
@@ -91,6 +140,57 @@ func TestCreateAppPlaceholdersWithExistingPods(t *testing.T) {
 	assert.Equal(t, (*v1.Pod)(nil), createdPods["tg-test-group-1-app02-0"], "Pod should not have been created")
 }
 
+func TestRecreatePlaceholder(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	createdPods := make([]*v1.Pod, 0)
+	deletedPods := make([]string, 0)
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		createdPods = append(createdPods, pod)
+		return pod, nil
+	})
+	mockedAPIProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deletedPods = append(deletedPods, pod.Name)
+		return nil
+	})
+	placeholderMgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+
+	failed := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "tg-test-group-1-app01-0",
+			UID:  "UID-01",
+			Annotations: map[string]string{
+				constants.AnnotationPlaceholderFlag: "true",
+				constants.AnnotationTaskGroupName:   "test-group-1",
+			},
+		},
+	}
+	err := placeholderMgr.recreatePlaceholder(app, failed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, deletedPods, []string{"tg-test-group-1-app01-0"})
+	assert.Equal(t, len(createdPods), 1)
+	assert.Equal(t, createdPods[0].Name, "")
+	assert.Equal(t, createdPods[0].GenerateName, "tg-test-group-1-app01-0-")
+	assert.Equal(t, createdPods[0].Annotations[constants.AnnotationTaskGroupName], "test-group-1")
+	assert.Equal(t, createdPods[0].Spec.Containers[0].Resources.Requests.Cpu().String(), "500m")
+
+	// unknown task group
+	failed.Annotations[constants.AnnotationTaskGroupName] = "unknown"
+	err = placeholderMgr.recreatePlaceholder(app, failed)
+	assert.ErrorContains(t, err, "task group unknown not found")
+	assert.Equal(t, len(createdPods), 1)
+
+	// delete failure keeps the pod as an orphan, the replacement is still created
+	failed.Annotations[constants.AnnotationTaskGroupName] = "test-group-2"
+	mockedAPIProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		return fmt.Errorf("server error")
+	})
+	err = placeholderMgr.recreatePlaceholder(app, failed)
+	assert.NilError(t, err)
+	assert.Equal(t, len(createdPods), 2)
+	assert.Equal(t, placeholderMgr.getOrphanPodsLength(), 1)
+}
+
 func createAndCheckPlaceholderCreate(mockedAPIProvider *client.MockedAPIProvider, app *Application, t *testing.T) map[string]*v1.Pod {
 	createdPods := make(map[string]*v1.Pod)
 	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
@@ -298,6 +398,126 @@ func TestCleanOrphanPlaceholders(t *testing.T) {
 	assert.Equal(t, len(placeholderMgr.orphanPods), 0)
 }
 
+func TestCollectOrphanPlaceholders(t *testing.T) {
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.PlaceholderOrphanTTL = time.Minute
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	newPlaceholder := func(name, uid, app string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name: name,
+				UID:  types.UID(uid),
+				Labels: map[string]string{
+					constants.LabelApplicationID:   app,
+					constants.LabelPlaceholderFlag: constants.True,
+				},
+			},
+			Spec: v1.PodSpec{
+				SchedulerName: constants.SchedulerName,
+			},
+		}
+	}
+	orphan := newPlaceholder("ph-01", "UID-01", "app-unknown")
+	owned := newPlaceholder("ph-02", "UID-02", appID)
+	deletePod := make([]string, 0)
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mockedAPIProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deletePod = append(deletePod, pod.Name)
+		return nil
+	})
+	mockedAPIProvider.GetPodListerMock().AddPod(orphan)
+	mockedAPIProvider.GetPodListerMock().AddPod(owned)
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+
+	// no lookup set: nothing is collected
+	mgr.collectOrphanPlaceholders()
+	assert.Equal(t, len(mgr.orphanSince), 0)
+
+	mgr.SetApplicationLookup(func(id string) bool {
+		return id == appID
+	})
+	// first pass only records the orphan
+	mgr.collectOrphanPlaceholders()
+	assert.Equal(t, len(mgr.orphanSince), 1)
+	_, ok := mgr.orphanSince[orphan.UID]
+	assert.Assert(t, ok, "orphaned placeholder should be recorded")
+	assert.Equal(t, len(deletePod), 0)
+
+	// once the TTL has passed the orphan is deleted
+	mgr.orphanSince[orphan.UID] = time.Now().Add(-2 * time.Minute)
+	mgr.collectOrphanPlaceholders()
+	assert.DeepEqual(t, deletePod, []string{"ph-01"})
+
+	// a disabled TTL stops the collection
+	testConf.PlaceholderOrphanTTL = 0
+	deletePod = deletePod[:0]
+	mgr.orphanSince[orphan.UID] = time.Now().Add(-2 * time.Minute)
+	mgr.collectOrphanPlaceholders()
+	assert.Equal(t, len(deletePod), 0)
+
+	// pods that are no longer orphaned are forgotten
+	testConf.PlaceholderOrphanTTL = time.Minute
+	mockedAPIProvider.GetPodListerMock().DeletePod(orphan)
+	mgr.collectOrphanPlaceholders()
+	assert.Equal(t, len(mgr.orphanSince), 0)
+	assert.Equal(t, len(deletePod), 0)
+}
+
+func TestCollectOrphanPlaceholdersRestart(t *testing.T) {
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.PlaceholderOrphanTTL = time.Millisecond
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	newPlaceholder := func(name, uid, app string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name: name,
+				UID:  types.UID(uid),
+				Labels: map[string]string{
+					constants.LabelApplicationID:   app,
+					constants.LabelPlaceholderFlag: constants.True,
+				},
+			},
+			Spec: v1.PodSpec{
+				SchedulerName: constants.SchedulerName,
+			},
+		}
+	}
+	deletePod := make([]string, 0)
+	mockedAPIProvider := client.NewMockedAPIProvider(false)
+	mockedAPIProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deletePod = append(deletePod, pod.Name)
+		return nil
+	})
+	mockedAPIProvider.GetPodListerMock().AddPod(newPlaceholder("ph-01", "UID-01", "app-recovered"))
+	mockedAPIProvider.GetPodListerMock().AddPod(newPlaceholder("ph-02", "UID-02", "app-unknown"))
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+
+	// after a restart no application exists until the recovery completed: nothing is collected
+	for i := 0; i < 3; i++ {
+		mgr.collectOrphanPlaceholders()
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.Equal(t, len(mgr.orphanSince), 0)
+	assert.Equal(t, len(deletePod), 0)
+
+	// the recovery completed: the TTL of the orphan starts now, the placeholder of the recovered app is kept
+	mgr.SetApplicationLookup(func(id string) bool {
+		return id == "app-recovered"
+	})
+	mgr.collectOrphanPlaceholders()
+	assert.Equal(t, len(mgr.orphanSince), 1)
+	assert.Equal(t, len(deletePod), 0)
+	time.Sleep(2 * time.Millisecond)
+	mgr.collectOrphanPlaceholders()
+	assert.DeepEqual(t, deletePod, []string{"ph-02"})
+}
+
 func TestPlaceholderManagerStartStop(t *testing.T) {
 	mockedAPIProvider := client.NewMockedAPIProvider(false)
 	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
@@ -0,0 +1,288 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	// maxSizingHistory limits the number of workloads for which the requests of the last run are kept
+	maxSizingHistory = 10000
+	// maxOwnerPods limits the number of pods walked to find the workload, e.g. Spark executor -> driver -> SparkApplication
+	maxOwnerPods = 2
+)
+
+// taskGroupSizes are the largest requests of the members per task group name
+type taskGroupSizes map[string]*si.Resource
+
+// sizingRun tracks the requests of the members of a running application
+type sizingRun struct {
+	workload string
+	sizes    taskGroupSizes
+}
+
+// placeholderSizing records the requests of the task group members of an application. When the application is
+// removed the requests are kept for the workload that created it, e.g. a Job or a SparkApplication, and are used
+// to size the task groups of the next run of the same workload.
+type placeholderSizing struct {
+	running map[string]*sizingRun     // appID -> requests of the running application
+	history map[string]taskGroupSizes // workload -> requests of the last completed run
+	order   []string                  // workloads in the history, oldest first
+	lock    sync.Mutex
+}
+
+func newPlaceholderSizing() *placeholderSizing {
+	return &placeholderSizing{
+		running: make(map[string]*sizingRun),
+		history: make(map[string]taskGroupSizes),
+	}
+}
+
+// start begins recording the requests of the application created by the workload
+func (p *placeholderSizing) start(appID, workload string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.running[appID] = &sizingRun{
+		workload: workload,
+		sizes:    make(taskGroupSizes),
+	}
+}
+
+// observe records the requests of a member of the task group, the largest request per resource is kept.
+// Members without requests are ignored.
+func (p *placeholderSizing) observe(appID, taskGroup string, pod *v1.Pod) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	run, ok := p.running[appID]
+	if !ok || pod == nil {
+		return
+	}
+	requests := memberResource(common.GetPodResource(pod))
+	if len(requests.Resources) == 0 {
+		return
+	}
+	run.sizes[taskGroup] = maxResource(run.sizes[taskGroup], requests)
+}
+
+// finish stops recording the requests of the application and stores them for the workload.
+// A run without observed members does not replace the requests of an earlier run.
+func (p *placeholderSizing) finish(appID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	run, ok := p.running[appID]
+	if !ok {
+		return
+	}
+	delete(p.running, appID)
+	if len(run.sizes) == 0 {
+		return
+	}
+	if _, ok = p.history[run.workload]; !ok {
+		if len(p.order) >= maxSizingHistory {
+			delete(p.history, p.order[0])
+			p.order = p.order[1:]
+		}
+		p.order = append(p.order, run.workload)
+	}
+	p.history[run.workload] = run.sizes
+}
+
+// take stops recording the requests of the application without storing them for the workload.
+// Returns the recorded run, nil if the application is not recorded.
+func (p *placeholderSizing) take(appID string) *sizingRun {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	run := p.running[appID]
+	delete(p.running, appID)
+	return run
+}
+
+// resume continues recording the requests of the application with a run returned by take.
+// A nil run is ignored.
+func (p *placeholderSizing) resume(appID string, run *sizingRun) {
+	if run == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.running[appID] = run
+}
+
+// lookup returns the requests of the last completed run of the workload, nil if there is none
+func (p *placeholderSizing) lookup(workload string) taskGroupSizes {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.history[workload]
+}
+
+// sizeTaskGroups compares the task groups of a new application with the requests of the members of the last run
+// of the same workload. Depending on the configured mode a difference is reported on the originating pod, or the
+// task group is resized to the requests of the previous run. Returns the task groups to use for the application.
+func (ctx *Context) sizeTaskGroups(metadata interfaces.ApplicationMetadata) []v1alpha1.TaskGroup {
+	mode := conf.GetSchedulerConf().GetPlaceholderSizing()
+	if mode == conf.PlaceholderSizingDisabled || len(metadata.TaskGroups) == 0 {
+		return metadata.TaskGroups
+	}
+	workload, pod := ctx.getWorkload(metadata.Tags[constants.AppTagNamespace], metadata.OwnerReferences)
+	if workload == "" {
+		return metadata.TaskGroups
+	}
+	ctx.sizing.start(metadata.ApplicationID, workload)
+	sizes := ctx.sizing.lookup(workload)
+	if sizes == nil {
+		return metadata.TaskGroups
+	}
+
+	taskGroups := make([]v1alpha1.TaskGroup, len(metadata.TaskGroups))
+	for i, taskGroup := range metadata.TaskGroups {
+		taskGroups[i] = taskGroup
+		observed, ok := sizes[taskGroup.Name]
+		if !ok || common.Equals(memberResource(common.GetTGResource(taskGroup.MinResource, 1)), observed) {
+			continue
+		}
+		observedResource := toTaskGroupResource(observed)
+		log.Log(log.ShimContext).Info("task group size differs from the requests of the previous run",
+			zap.String("appID", metadata.ApplicationID),
+			zap.String("workload", workload),
+			zap.String("taskGroup", taskGroup.Name),
+			zap.String("minResource", formatTaskGroupResource(taskGroup.MinResource)),
+			zap.String("previousRun", formatTaskGroupResource(observedResource)),
+			zap.String("mode", mode))
+		if mode == conf.PlaceholderSizingCorrect {
+			taskGroups[i].MinResource = observedResource
+			events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeNormal, "PlaceholderSizingCorrected", "PlaceholderSizingCorrected",
+				"task group %s resized from %s to %s, the requests of the members of the previous run",
+				taskGroup.Name, formatTaskGroupResource(taskGroup.MinResource), formatTaskGroupResource(observedResource))
+			continue
+		}
+		events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeNormal, "PlaceholderSizing", "PlaceholderSizing",
+			"task group %s requests %s, the members of the previous run requested %s",
+			taskGroup.Name, formatTaskGroupResource(taskGroup.MinResource), formatTaskGroupResource(observedResource))
+	}
+	return taskGroups
+}
+
+// getWorkload returns the workload that created the application as <namespace>/<kind>/<name> and the originating
+// pod. The controller of the originating pod is the workload, the owner pod is followed if the controller is a pod
+// itself, e.g. the driver of a Spark executor. Returns an empty string if the pod has no controller.
+func (ctx *Context) getWorkload(namespace string, ownerReferences []metav1.OwnerReference) (string, *v1.Pod) {
+	var name string
+	for _, ref := range ownerReferences {
+		if ref.Kind == "Pod" {
+			name = ref.Name
+			break
+		}
+	}
+	if name == "" {
+		return "", nil
+	}
+	podLister := ctx.apiProvider.GetAPIs().PodInformer.Lister()
+	var originator *v1.Pod
+	for i := 0; i < maxOwnerPods; i++ {
+		pod, err := podLister.Pods(namespace).Get(name)
+		if err != nil {
+			log.Log(log.ShimContext).Debug("unable to get pod to find the workload",
+				zap.String("namespace", namespace),
+				zap.String("name", name),
+				zap.Error(err))
+			return "", nil
+		}
+		if originator == nil {
+			originator = pod
+		}
+		controller := metav1.GetControllerOf(pod)
+		if controller == nil {
+			return "", nil
+		}
+		if controller.Kind != "Pod" {
+			return fmt.Sprintf("%s/%s/%s", namespace, controller.Kind, controller.Name), originator
+		}
+		name = controller.Name
+	}
+	return "", nil
+}
+
+// memberResource removes the pod count from the resource of a single member
+func memberResource(res *si.Resource) *si.Resource {
+	delete(res.Resources, "pods")
+	return res
+}
+
+// maxResource returns the largest value of each resource in left and right
+func maxResource(left, right *si.Resource) *si.Resource {
+	result := &si.Resource{Resources: make(map[string]*si.Quantity)}
+	for _, res := range []*si.Resource{left, right} {
+		for name, quantity := range res.GetResources() {
+			if current, ok := result.Resources[name]; !ok || quantity.GetValue() > current.GetValue() {
+				result.Resources[name] = &si.Quantity{Value: quantity.GetValue()}
+			}
+		}
+	}
+	return result
+}
+
+// toTaskGroupResource converts the requests into the resources of a task group
+func toTaskGroupResource(res *si.Resource) map[string]resource.Quantity {
+	result := make(map[string]resource.Quantity, len(res.GetResources()))
+	for name, quantity := range res.GetResources() {
+		switch name {
+		case siCommon.CPU:
+			result[v1.ResourceCPU.String()] = *resource.NewMilliQuantity(quantity.GetValue(), resource.DecimalSI)
+		case siCommon.Memory, v1.ResourceEphemeralStorage.String():
+			result[name] = *resource.NewQuantity(quantity.GetValue(), resource.BinarySI)
+		default:
+			result[name] = *resource.NewQuantity(quantity.GetValue(), resource.DecimalSI)
+		}
+	}
+	return result
+}
+
+// formatTaskGroupResource returns the resources sorted by name, e.g. "cpu=500m, memory=1Gi"
+func formatTaskGroupResource(res map[string]resource.Quantity) string {
+	names := make([]string, 0, len(res))
+	for name := range res {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		quantity := res[name]
+		values[i] = name + "=" + quantity.String()
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,212 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func newSizingTestPod(name string, owner apis.OwnerReference, cpu, memory string) *v1.Pod {
+	controller := true
+	owner.Controller = &controller
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			UID:             types.UID("UID-" + name),
+			OwnerReferences: []apis.OwnerReference{owner},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "container",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse(cpu),
+						v1.ResourceMemory: resource.MustParse(memory),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func newSizingTestRequest(appID string, owner *v1.Pod) *interfaces.AddApplicationRequest {
+	controller := false
+	return &interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     "root.a",
+			User:          "user",
+			Tags:          map[string]string{constants.AppTagNamespace: "default"},
+			TaskGroups: []v1alpha1.TaskGroup{{
+				Name:      "workers",
+				MinMember: 2,
+				MinResource: map[string]resource.Quantity{
+					"cpu":    resource.MustParse("2"),
+					"memory": resource.MustParse("4Gi"),
+				},
+			}},
+			OwnerReferences: []apis.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       owner.Name,
+				UID:        owner.UID,
+				Controller: &controller,
+			}},
+		},
+	}
+}
+
+func TestPlaceholderSizingHistory(t *testing.T) {
+	sizing := newPlaceholderSizing()
+	job := apis.OwnerReference{Kind: "Job", Name: "job"}
+
+	// not started
+	sizing.observe("app-1", "workers", newSizingTestPod("pod-1", job, "1", "1Gi"))
+	sizing.finish("app-1")
+	assert.Assert(t, sizing.lookup("default/Job/job") == nil, "unexpected history")
+
+	// the largest request per resource is kept
+	sizing.start("app-1", "default/Job/job")
+	sizing.observe("app-1", "workers", newSizingTestPod("pod-1", job, "1", "2Gi"))
+	sizing.observe("app-1", "workers", newSizingTestPod("pod-2", job, "500m", "3Gi"))
+	sizing.observe("app-1", "workers", &v1.Pod{})
+	assert.Assert(t, sizing.lookup("default/Job/job") == nil, "history set before the run finished")
+	sizing.finish("app-1")
+	sizes := sizing.lookup("default/Job/job")
+	assert.Equal(t, len(sizes), 1)
+	assert.Equal(t, formatTaskGroupResource(toTaskGroupResource(sizes["workers"])), "cpu=1, memory=3Gi")
+
+	// a run without members keeps the previous run
+	sizing.start("app-2", "default/Job/job")
+	sizing.finish("app-2")
+	assert.Equal(t, len(sizing.lookup("default/Job/job")), 1)
+
+	// the history is limited, the oldest workload is removed
+	for i := 0; i < maxSizingHistory; i++ {
+		appID := "app-" + strconv.Itoa(i)
+		sizing.start(appID, "default/Job/job-"+strconv.Itoa(i))
+		sizing.observe(appID, "workers", newSizingTestPod("pod", job, "1", "1Gi"))
+		sizing.finish(appID)
+	}
+	assert.Equal(t, len(sizing.history), maxSizingHistory)
+	assert.Assert(t, sizing.lookup("default/Job/job") == nil, "oldest workload not removed")
+	assert.Equal(t, len(sizing.running), 0)
+}
+
+func TestSizeTaskGroups(t *testing.T) {
+	var eventCount int32
+	recorder := events.NewMockedRecorder()
+	recorder.OnEventf = func() {
+		atomic.AddInt32(&eventCount, 1)
+	}
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.PlaceholderSizing = conf.PlaceholderSizingSuggest
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	job := apis.OwnerReference{APIVersion: "batch/v1", Kind: "Job", Name: "job"}
+	driver := newSizingTestPod("driver", job, "1", "1Gi")
+	apiProvider.GetPodListerMock().AddPod(driver)
+
+	// first run: nothing to compare with, the members are recorded
+	request := newSizingTestRequest("app-1", driver)
+	app, ok := context.AddApplication(request).(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, formatTaskGroupResource(app.getTaskGroups()[0].MinResource), "cpu=2, memory=4Gi")
+	for i := 0; i < 2; i++ {
+		pod := newSizingTestPod("worker-"+strconv.Itoa(i), job, "1", "2Gi")
+		context.AddTask(&interfaces.AddTaskRequest{Metadata: interfaces.TaskMetadata{
+			ApplicationID: "app-1",
+			TaskID:        string(pod.UID),
+			Pod:           pod,
+			TaskGroupName: "workers",
+		}})
+	}
+	context.RemoveApplicationInternal("app-1")
+	assert.Equal(t, atomic.LoadInt32(&eventCount), int32(0))
+
+	// second run: the difference is reported, the task group is not changed
+	app, ok = context.AddApplication(newSizingTestRequest("app-2", driver)).(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, formatTaskGroupResource(app.getTaskGroups()[0].MinResource), "cpu=2, memory=4Gi")
+	assert.Equal(t, atomic.LoadInt32(&eventCount), int32(1))
+	context.RemoveApplicationInternal("app-2")
+
+	// third run: the task group is resized, the request is not modified
+	testConf.PlaceholderSizing = conf.PlaceholderSizingCorrect
+	request = newSizingTestRequest("app-3", driver)
+	app, ok = context.AddApplication(request).(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, formatTaskGroupResource(app.getTaskGroups()[0].MinResource), "cpu=1, memory=2Gi")
+	assert.Equal(t, formatTaskGroupResource(request.Metadata.TaskGroups[0].MinResource), "cpu=2, memory=4Gi")
+	assert.Equal(t, atomic.LoadInt32(&eventCount), int32(2))
+	context.RemoveApplicationInternal("app-3")
+
+	// a pod without a controller is not a workload
+	bare := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "bare", Namespace: "default", UID: "UID-bare"}}
+	apiProvider.GetPodListerMock().AddPod(bare)
+	app, ok = context.AddApplication(newSizingTestRequest("app-4", bare)).(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, formatTaskGroupResource(app.getTaskGroups()[0].MinResource), "cpu=2, memory=4Gi")
+	assert.Equal(t, len(context.sizing.running), 0)
+}
+
+func TestGetWorkload(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	spark := apis.OwnerReference{APIVersion: "sparkoperator.k8s.io/v1beta2", Kind: "SparkApplication", Name: "spark"}
+	driver := newSizingTestPod("driver", spark, "1", "1Gi")
+	executor := newSizingTestPod("executor", apis.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: "driver"}, "1", "1Gi")
+	apiProvider.GetPodListerMock().AddPod(driver)
+	apiProvider.GetPodListerMock().AddPod(executor)
+
+	workload, pod := context.getWorkload("default", newSizingTestRequest("app", driver).Metadata.OwnerReferences)
+	assert.Equal(t, workload, "default/SparkApplication/spark")
+	assert.Equal(t, pod.Name, "driver")
+	// the driver of the executor is followed
+	workload, pod = context.getWorkload("default", newSizingTestRequest("app", executor).Metadata.OwnerReferences)
+	assert.Equal(t, workload, "default/SparkApplication/spark")
+	assert.Equal(t, pod.Name, "executor")
+	// unknown pod
+	workload, pod = context.getWorkload("other", newSizingTestRequest("app", driver).Metadata.OwnerReferences)
+	assert.Equal(t, workload, "")
+	assert.Assert(t, pod == nil)
+	// no owner pod
+	workload, _ = context.getWorkload("default", nil)
+	assert.Equal(t, workload, "")
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 )
 
@@ -128,6 +129,144 @@ func TestNewPlaceholderWithLabelsAndAnnotations(t *testing.T) {
 	assert.NilError(t, err, "taskGroupsDef unmarshal failed")
 }
 
+func TestNewPlaceholderReservedLabelsAndAnnotations(t *testing.T) {
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{constants.AppTagNamespace: "test"}, mockedSchedulerAPI)
+	app.setTaskGroups([]v1alpha1.TaskGroup{
+		{
+			Name:      "test-group-1",
+			MinMember: 1,
+			MinResource: map[string]resource.Quantity{
+				"cpu": resource.MustParse("500m"),
+			},
+			// the task group cannot override the values the scheduler relies on
+			Labels: map[string]string{
+				constants.LabelApplicationID:   "other-app",
+				constants.LabelQueueName:       "root.other",
+				constants.LabelPlaceholderFlag: "false",
+			},
+			Annotations: map[string]string{
+				constants.AnnotationPlaceholderFlag: "false",
+				constants.AnnotationTaskGroupName:   "other-group",
+			},
+		},
+	})
+
+	holder := newPlaceholder("ph-name", app, app.taskGroups[0])
+	assert.Equal(t, holder.pod.Labels[constants.LabelApplicationID], "app01")
+	assert.Equal(t, holder.pod.Labels[constants.LabelQueueName], "root.default")
+	assert.Equal(t, holder.pod.Labels[constants.LabelPlaceholderFlag], "true")
+	assert.Equal(t, holder.pod.Annotations[constants.AnnotationPlaceholderFlag], "true")
+	assert.Equal(t, holder.pod.Annotations[constants.AnnotationTaskGroupName], "test-group-1")
+}
+
+func TestNewPlaceholderWithTopologyKey(t *testing.T) {
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{constants.AppTagNamespace: "test"}, mockedSchedulerAPI)
+	nodeAffinity := &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{
+				MatchExpressions: []v1.NodeSelectorRequirement{{Key: "gpu", Operator: v1.NodeSelectorOpExists}},
+			}},
+		},
+	}
+	app.setTaskGroups([]v1alpha1.TaskGroup{
+		{
+			Name:      "workers",
+			MinMember: 4,
+			MinResource: map[string]resource.Quantity{
+				"cpu": resource.MustParse("500m"),
+			},
+			Affinity:    &v1.Affinity{NodeAffinity: nodeAffinity},
+			TopologyKey: "topology.kubernetes.io/zone",
+		},
+		{
+			Name:      "driver",
+			MinMember: 1,
+			MinResource: map[string]resource.Quantity{
+				"cpu": resource.MustParse("500m"),
+			},
+		},
+	})
+
+	holder := newPlaceholder("ph-name", app, app.taskGroups[0])
+	assert.Equal(t, holder.pod.Labels[constants.LabelTaskGroupName], "workers")
+	affinity := holder.pod.Spec.Affinity
+	assert.DeepEqual(t, affinity.NodeAffinity, nodeAffinity)
+	assert.Equal(t, len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution), 1)
+	term := affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, term.TopologyKey, "topology.kubernetes.io/zone")
+	assert.DeepEqual(t, term.LabelSelector.MatchLabels, map[string]string{
+		constants.LabelApplicationID: "app01",
+		constants.LabelTaskGroupName: "workers",
+	})
+	// the task group definition is not changed
+	assert.Assert(t, app.taskGroups[0].Affinity.PodAffinity == nil, "task group affinity modified")
+
+	// no topology key: no pod affinity
+	holder = newPlaceholder("ph-name", app, app.taskGroups[1])
+	_, ok := holder.pod.Labels[constants.LabelTaskGroupName]
+	assert.Assert(t, !ok, "unexpected task group label")
+	assert.Assert(t, holder.pod.Spec.Affinity == nil, "unexpected affinity")
+}
+
+func TestNewPlaceholderWithTemplate(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.PlaceholderTemplates = `{"restricted": {
+		"image": "registry.local/pause:3.9",
+		"securityContext": {"runAsNonRoot": true, "seccompProfile": {"type": "RuntimeDefault"}},
+		"containerSecurityContext": {"allowPrivilegeEscalation": false, "capabilities": {"drop": ["ALL"]}},
+		"runtimeClassName": "gvisor",
+		"priorityClassName": "batch-low"}}`
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{constants.AppTagNamespace: "test"}, mockedSchedulerAPI)
+	app.setTaskGroups([]v1alpha1.TaskGroup{
+		{
+			Name:      "restricted",
+			MinMember: 1,
+			MinResource: map[string]resource.Quantity{
+				"cpu": resource.MustParse("500m"),
+			},
+			PlaceholderTemplate: "restricted",
+		},
+		{
+			Name:      "unknown",
+			MinMember: 1,
+			MinResource: map[string]resource.Quantity{
+				"cpu": resource.MustParse("500m"),
+			},
+			PlaceholderTemplate: "unknown",
+		},
+	})
+
+	holder := newPlaceholder("ph-name", app, app.taskGroups[0])
+	spec := holder.pod.Spec
+	assert.Equal(t, spec.Containers[0].Image, "registry.local/pause:3.9")
+	assert.Equal(t, *spec.SecurityContext.RunAsNonRoot, true)
+	assert.Assert(t, spec.SecurityContext.RunAsUser == nil, "default security context not replaced")
+	assert.Equal(t, spec.SecurityContext.SeccompProfile.Type, v1.SeccompProfileTypeRuntimeDefault)
+	assert.Equal(t, *spec.Containers[0].SecurityContext.AllowPrivilegeEscalation, false)
+	assert.DeepEqual(t, spec.Containers[0].SecurityContext.Capabilities.Drop, []v1.Capability{"ALL"})
+	assert.Equal(t, *spec.RuntimeClassName, "gvisor")
+	assert.Equal(t, spec.PriorityClassName, "batch-low")
+
+	// unknown template keeps the defaults
+	holder = newPlaceholder("ph-name", app, app.taskGroups[1])
+	spec = holder.pod.Spec
+	assert.Equal(t, spec.Containers[0].Image, conf.GetSchedulerConf().PlaceHolderImage)
+	assert.Equal(t, *spec.SecurityContext.RunAsUser, runAsUser)
+	assert.Assert(t, spec.Containers[0].SecurityContext == nil)
+	assert.Assert(t, spec.RuntimeClassName == nil)
+	assert.Equal(t, spec.PriorityClassName, "")
+}
+
 func TestNewPlaceholderWithNodeSelectors(t *testing.T) {
 	const (
 		appID     = "app01"
@@ -244,6 +383,66 @@ func TestNewPlaceholderWithAffinity(t *testing.T) {
 	assert.Equal(t, term[0].LabelSelector.MatchExpressions[0].Values[0], "securityscan")
 }
 
+func TestNewPlaceholderWithMixedTaskGroups(t *testing.T) {
+	const (
+		appID     = "app01"
+		queue     = "root.default"
+		namespace = "test"
+	)
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	app := NewApplication(appID, queue,
+		"bob", testGroups, map[string]string{constants.AppTagNamespace: namespace}, mockedSchedulerAPI)
+	app.setTaskGroups([]v1alpha1.TaskGroup{
+		{
+			Name:      "cpu-group",
+			MinMember: 2,
+			MinResource: map[string]resource.Quantity{
+				"cpu":    resource.MustParse("500m"),
+				"memory": resource.MustParse("1024M"),
+			},
+			NodeSelector: map[string]string{
+				"nodeType": "cpu",
+			},
+		},
+		{
+			Name:      "gpu-group",
+			MinMember: 2,
+			MinResource: map[string]resource.Quantity{
+				"cpu":            resource.MustParse("500m"),
+				"nvidia.com/gpu": resource.MustParse("1"),
+			},
+			NodeSelector: map[string]string{
+				"nodeType": "gpu",
+			},
+			Tolerations: []v1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Operator: v1.TolerationOpExists,
+					Effect:   v1.TaintEffectNoSchedule,
+				},
+			},
+		},
+	})
+
+	cpuHolder := newPlaceholder("ph-cpu", app, app.taskGroups[0])
+	assert.Equal(t, cpuHolder.pod.Spec.NodeSelector["nodeType"], "cpu")
+	assert.Equal(t, len(cpuHolder.pod.Spec.Tolerations), 0)
+
+	gpuHolder1 := newPlaceholder("ph-gpu-1", app, app.taskGroups[1])
+	gpuHolder2 := newPlaceholder("ph-gpu-2", app, app.taskGroups[1])
+	assert.Equal(t, gpuHolder1.pod.Spec.NodeSelector["nodeType"], "gpu")
+	assert.Equal(t, len(gpuHolder1.pod.Spec.Tolerations), 1)
+	assert.Equal(t, gpuHolder1.pod.Spec.Tolerations[0].Key, "nvidia.com/gpu")
+
+	// changing the spec of one placeholder must not leak into the task group or other placeholders
+	gpuHolder1.pod.Spec.NodeSelector["nodeType"] = "changed"
+	gpuHolder1.pod.Spec.Tolerations[0].Key = "changed"
+	assert.Equal(t, app.taskGroups[1].NodeSelector["nodeType"], "gpu")
+	assert.Equal(t, app.taskGroups[1].Tolerations[0].Key, "nvidia.com/gpu")
+	assert.Equal(t, gpuHolder2.pod.Spec.NodeSelector["nodeType"], "gpu")
+	assert.Equal(t, gpuHolder2.pod.Spec.Tolerations[0].Key, "nvidia.com/gpu")
+}
+
 func TestNewPlaceholderTaskGroupsDefinition(t *testing.T) {
 	mockedSchedulerAPI := newMockSchedulerAPI()
 	taskGroup := []v1alpha1.TaskGroup{
@@ -0,0 +1,115 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// migrationHintTTL limits the time a migrated pod waits for its replacement, later pods of the controller are
+// not treated as a replacement.
+const migrationHintTTL = 10 * time.Minute
+
+// podMigrations tracks the pods that were deleted from a deleted node per controller. The next pods created by
+// the controller replace them: their asks carry the deleted node as a hint for the core.
+type podMigrations struct {
+	pending map[types.UID][]podMigration // controller UID -> migrated pods not replaced yet, oldest first
+	lock    sync.Mutex
+}
+
+// podMigration is a pod deleted from a deleted node, waiting for its replacement
+type podMigration struct {
+	nodeName string
+	at       time.Time
+}
+
+func newPodMigrations() *podMigrations {
+	return &podMigrations{
+		pending: make(map[types.UID][]podMigration),
+	}
+}
+
+// record adds a pod of the controller that was deleted from the node. Controllers of which all migrations
+// expired are dropped.
+func (m *podMigrations) record(owner types.UID, nodeName string, at time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for uid, migrations := range m.pending {
+		if at.Sub(migrations[len(migrations)-1].at) > migrationHintTTL {
+			delete(m.pending, uid)
+		}
+	}
+	m.pending[owner] = append(m.pending[owner], podMigration{nodeName: nodeName, at: at})
+}
+
+// take returns the node of the oldest migrated pod of the controller that is not replaced yet, and marks it as
+// replaced. Migrations older than migrationHintTTL are dropped. Returns false if no pod of the controller waits
+// for a replacement.
+func (m *podMigrations) take(owner types.UID, now time.Time) (string, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	migrations := m.pending[owner]
+	for len(migrations) > 0 && now.Sub(migrations[0].at) > migrationHintTTL {
+		migrations = migrations[1:]
+	}
+	if len(migrations) == 0 {
+		delete(m.pending, owner)
+		return "", false
+	}
+	nodeName := migrations[0].nodeName
+	if len(migrations) == 1 {
+		delete(m.pending, owner)
+	} else {
+		m.pending[owner] = migrations[1:]
+	}
+	return nodeName, true
+}
+
+// addMigrationHint marks the asks of a pod that replaces a pod migrated from a deleted node. The core is told
+// that the ask replaces a running pod, the node it ran on is no longer available.
+func (ctx *Context) addMigrationHint(pod *v1.Pod, asks []*si.AllocationAsk) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+	nodeName, ok := ctx.migrations.take(owner.UID, time.Now())
+	if !ok {
+		return
+	}
+	log.Log(log.ShimContext).Info("pod replaces a pod migrated from a deleted node",
+		zap.String("podName", pod.Name),
+		zap.String("namespace", pod.Namespace),
+		zap.String("nodeName", nodeName))
+	for _, ask := range asks {
+		if ask.Tags == nil {
+			ask.Tags = make(map[string]string)
+		}
+		ask.Tags[constants.TagMigratedFromNode] = nodeName
+	}
+}
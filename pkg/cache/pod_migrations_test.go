@@ -0,0 +1,88 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestPodMigrations(t *testing.T) {
+	migrations := newPodMigrations()
+	now := time.Now()
+	_, ok := migrations.take("rs-1", now)
+	assert.Assert(t, !ok, "unknown controller has migrated pods")
+
+	migrations.record("rs-1", "node-1", now.Add(-2*migrationHintTTL))
+	migrations.record("rs-1", "node-2", now.Add(-time.Minute))
+	migrations.record("rs-1", "node-3", now)
+
+	// expired migrations are dropped, the oldest remaining migration is replaced first
+	nodeName, ok := migrations.take("rs-1", now)
+	assert.Assert(t, ok, "migrated pod not found")
+	assert.Equal(t, nodeName, "node-2")
+	nodeName, ok = migrations.take("rs-1", now)
+	assert.Assert(t, ok, "migrated pod not found")
+	assert.Equal(t, nodeName, "node-3")
+	_, ok = migrations.take("rs-1", now)
+	assert.Assert(t, !ok, "replaced pod still migrating")
+	assert.Equal(t, len(migrations.pending), 0)
+
+	// controllers without a pending migration are dropped
+	migrations.record("rs-2", "node-1", now.Add(-2*migrationHintTTL))
+	migrations.record("rs-3", "node-1", now)
+	_, ok = migrations.pending["rs-2"]
+	assert.Assert(t, !ok, "expired migration not dropped")
+}
+
+func TestMigrationHint(t *testing.T) {
+	context := initContextForTest()
+	controller := true
+	newPod := func(name string, owner apis.OwnerReference) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:            name,
+				OwnerReferences: []apis.OwnerReference{owner},
+			},
+		}
+	}
+	rs := apis.OwnerReference{Kind: "ReplicaSet", Name: "rs1", UID: "rs-uid-1", Controller: &controller}
+	context.migrations.record(rs.UID, "node-1", time.Now())
+
+	// only the first pod of the controller replaces the migrated pod
+	asks := []*si.AllocationAsk{{AllocationKey: "ask-1"}}
+	context.addMigrationHint(newPod("pod-1", rs), asks)
+	assert.Equal(t, asks[0].Tags[constants.TagMigratedFromNode], "node-1")
+	asks = []*si.AllocationAsk{{AllocationKey: "ask-2"}}
+	context.addMigrationHint(newPod("pod-2", rs), asks)
+	_, ok := asks[0].Tags[constants.TagMigratedFromNode]
+	assert.Assert(t, !ok, "second pod marked as replacement")
+
+	// pods without a controller are never a replacement
+	asks = []*si.AllocationAsk{{AllocationKey: "ask-3"}}
+	context.addMigrationHint(&v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "pod-3"}}, asks)
+	assert.Assert(t, asks[0].Tags == nil, "pod without controller marked as replacement")
+}
@@ -0,0 +1,298 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// PodGroupSyncInterval is the interval at which the PodGroups of the gang applications are brought up to date
+	PodGroupSyncInterval = 10 * time.Second
+
+	podGroupNamePrefix    = "yunikorn-"
+	podGroupManagedBy     = "app.kubernetes.io/managed-by"
+	podGroupManagedByName = "yunikorn"
+
+	// phases of the PodGroup as defined by the coscheduling plugin
+	podGroupPending    = "Pending"
+	podGroupScheduling = "Scheduling"
+	podGroupRunning    = "Running"
+	podGroupFinished   = "Finished"
+	podGroupFailed     = "Failed"
+)
+
+// podGroupResource is the PodGroup CRD of the coscheduling plugin from the kubernetes-sigs/scheduler-plugins project,
+// the same CRD the admission controller translates into task groups.
+var podGroupResource = schema.GroupVersionResource{
+	Group:    "scheduling.x-k8s.io",
+	Version:  "v1alpha1",
+	Resource: "podgroups",
+}
+
+// podGroupSync mirrors the gang applications as PodGroup objects, so tools that understand PodGroups can show the
+// state of the gangs scheduled by YuniKorn. PodGroups not created by the shim are never changed.
+type podGroupSync struct {
+	client dynamic.Interface
+	// emitted is the last PodGroup written for an application, keyed by application ID
+	emitted map[string]*unstructured.Unstructured
+	// missing is set after the CRD was found to be absent, to log the problem once
+	missing bool
+	lock    sync.Mutex
+}
+
+func newPodGroupSync(client dynamic.Interface) *podGroupSync {
+	return &podGroupSync{
+		client:  client,
+		emitted: make(map[string]*unstructured.Unstructured),
+	}
+}
+
+// podGroupName returns the name of the PodGroup of the application, or an empty string if the application ID
+// cannot be turned into a valid object name.
+func podGroupName(appID string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(podGroupNamePrefix+appID))
+	name = strings.TrimRight(name, "-.")
+	if len(validation.IsDNS1123Subdomain(name)) > 0 {
+		return ""
+	}
+	return name
+}
+
+// podGroupPhase translates the state of the application into the phase of the PodGroup
+func podGroupPhase(state string) string {
+	states := ApplicationStates()
+	switch state {
+	case states.Reserving, states.Resuming:
+		return podGroupScheduling
+	case states.Running:
+		return podGroupRunning
+	case states.Completed:
+		return podGroupFinished
+	case states.Failing, states.Failed, states.Rejected, states.Killing, states.Killed:
+		return podGroupFailed
+	default:
+		return podGroupPending
+	}
+}
+
+// desiredPodGroup builds the PodGroup of the application. Returns nil if the application is not a gang, the gang
+// is already defined by a PodGroup of the user, or the PodGroup cannot be named or placed in a namespace.
+func desiredPodGroup(app *Application) *unstructured.Unstructured {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	if len(app.taskGroups) == 0 {
+		return nil
+	}
+	if app.originatingTask != nil {
+		if pod := app.originatingTask.GetTaskPod(); pod != nil && pod.Labels[constants.LabelPodGroup] != "" {
+			return nil
+		}
+	}
+	namespace := app.tags[constants.AppTagNamespace]
+	name := podGroupName(app.applicationID)
+	if namespace == "" || name == "" {
+		return nil
+	}
+	var minMember int64
+	minResources := make(map[string]interface{})
+	total := make(v1.ResourceList)
+	for _, tg := range app.taskGroups {
+		minMember += int64(tg.MinMember)
+		for resName, value := range tg.MinResource {
+			quantity := *resource.NewMilliQuantity(value.MilliValue()*int64(tg.MinMember), value.Format)
+			if current, ok := total[v1.ResourceName(resName)]; ok {
+				quantity.Add(current)
+			}
+			total[v1.ResourceName(resName)] = quantity
+		}
+	}
+	for resName, quantity := range total {
+		minResources[string(resName)] = quantity.String()
+	}
+	var running, succeeded, failed int64
+	for _, task := range app.taskMap {
+		if task.IsPlaceholder() {
+			continue
+		}
+		switch task.GetTaskPod().Status.Phase {
+		case v1.PodRunning:
+			running++
+		case v1.PodSucceeded:
+			succeeded++
+		case v1.PodFailed:
+			failed++
+		}
+	}
+	podGroup := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"minMember":    minMember,
+				"minResources": minResources,
+			},
+			"status": map[string]interface{}{
+				"phase":     podGroupPhase(app.sm.Current()),
+				"running":   running,
+				"succeeded": succeeded,
+				"failed":    failed,
+			},
+		},
+	}
+	podGroup.SetAPIVersion(podGroupResource.GroupVersion().String())
+	podGroup.SetKind("PodGroup")
+	podGroup.SetNamespace(namespace)
+	podGroup.SetName(name)
+	labels := map[string]string{podGroupManagedBy: podGroupManagedByName}
+	if len(validation.IsValidLabelValue(app.applicationID)) == 0 {
+		labels[constants.LabelApplicationID] = app.applicationID
+	}
+	podGroup.SetLabels(labels)
+	if refs := app.placeholderOwnerReferences; len(refs) > 0 {
+		podGroup.SetOwnerReferences(refs)
+	}
+	return podGroup
+}
+
+// sync creates, updates or removes the PodGroups to match the applications
+func (p *podGroupSync) sync(apps []*Application) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	current := make(map[string]bool)
+	for _, app := range apps {
+		desired := desiredPodGroup(app)
+		if desired == nil {
+			continue
+		}
+		current[app.applicationID] = true
+		if emitted, ok := p.emitted[app.applicationID]; ok && reflect.DeepEqual(emitted.Object, desired.Object) {
+			continue
+		}
+		if !p.write(desired) {
+			continue
+		}
+		p.emitted[app.applicationID] = desired
+	}
+	for appID, emitted := range p.emitted {
+		if current[appID] {
+			continue
+		}
+		err := p.client.Resource(podGroupResource).Namespace(emitted.GetNamespace()).Delete(context.Background(), emitted.GetName(), apis.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Log(log.ShimCacheApplication).Warn("failed to delete PodGroup",
+				zap.String("appID", appID),
+				zap.String("podGroup", emitted.GetName()),
+				zap.Error(err))
+			continue
+		}
+		delete(p.emitted, appID)
+	}
+}
+
+// write creates or updates the PodGroup, returns true if the PodGroup is up to date
+func (p *podGroupSync) write(desired *unstructured.Unstructured) bool {
+	client := p.client.Resource(podGroupResource).Namespace(desired.GetNamespace())
+	existing, err := client.Get(context.Background(), desired.GetName(), apis.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		existing, err = client.Create(context.Background(), desired, apis.CreateOptions{})
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				if !p.missing {
+					log.Log(log.ShimCacheApplication).Warn("PodGroup CRD is not installed, PodGroups are not created",
+						zap.String("resource", podGroupResource.String()))
+					p.missing = true
+				}
+				return false
+			}
+			log.Log(log.ShimCacheApplication).Warn("failed to create PodGroup",
+				zap.String("podGroup", desired.GetName()),
+				zap.Error(err))
+			return false
+		}
+		p.missing = false
+	case err != nil:
+		log.Log(log.ShimCacheApplication).Warn("failed to get PodGroup",
+			zap.String("podGroup", desired.GetName()),
+			zap.Error(err))
+		return false
+	default:
+		if existing.GetLabels()[podGroupManagedBy] != podGroupManagedByName {
+			log.Log(log.ShimCacheApplication).Debug("PodGroup not managed by YuniKorn, skipping",
+				zap.String("namespace", desired.GetNamespace()),
+				zap.String("podGroup", desired.GetName()))
+			return false
+		}
+		existing.SetLabels(desired.GetLabels())
+		existing.SetOwnerReferences(desired.GetOwnerReferences())
+		existing.Object["spec"] = desired.Object["spec"]
+		existing, err = client.Update(context.Background(), existing, apis.UpdateOptions{})
+		if err != nil {
+			log.Log(log.ShimCacheApplication).Warn("failed to update PodGroup",
+				zap.String("podGroup", desired.GetName()),
+				zap.Error(err))
+			return false
+		}
+	}
+	// the status is a subresource and is not set by a create or update of the object
+	existing.Object["status"] = desired.Object["status"]
+	if _, err = client.UpdateStatus(context.Background(), existing, apis.UpdateOptions{}); err != nil {
+		log.Log(log.ShimCacheApplication).Warn("failed to update PodGroup status",
+			zap.String("podGroup", desired.GetName()),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// SyncPodGroups brings the PodGroups of the gang applications up to date.
+// Called periodically if the PodGroup sync is enabled.
+func (ctx *Context) SyncPodGroups() {
+	if ctx.podGroups == nil {
+		return
+	}
+	ctx.lock.RLock()
+	apps := make([]*Application, 0, len(ctx.applications))
+	for _, app := range ctx.applications {
+		apps = append(apps, app)
+	}
+	ctx.lock.RUnlock()
+	ctx.podGroups.sync(apps)
+}
@@ -0,0 +1,131 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+func newPodGroupTestApp(appID string, taskGroups []v1alpha1.TaskGroup) *Application {
+	app := NewApplication(appID, "root.a", "testuser", testGroups,
+		map[string]string{constants.AppTagNamespace: "default"}, newMockSchedulerAPI())
+	app.setTaskGroups(taskGroups)
+	return app
+}
+
+func getPodGroup(t *testing.T, client *fake.FakeDynamicClient, name string) *unstructured.Unstructured {
+	podGroup, err := client.Resource(podGroupResource).Namespace("default").Get(context.Background(), name, apis.GetOptions{})
+	assert.NilError(t, err)
+	return podGroup
+}
+
+func TestPodGroupName(t *testing.T) {
+	assert.Equal(t, podGroupName("app-1"), "yunikorn-app-1")
+	assert.Equal(t, podGroupName("Spark_App.01"), "yunikorn-spark-app.01")
+	assert.Equal(t, podGroupName("app_"), "yunikorn-app")
+}
+
+func TestPodGroupPhase(t *testing.T) {
+	states := ApplicationStates()
+	assert.Equal(t, podGroupPhase(states.New), podGroupPending)
+	assert.Equal(t, podGroupPhase(states.Accepted), podGroupPending)
+	assert.Equal(t, podGroupPhase(states.Reserving), podGroupScheduling)
+	assert.Equal(t, podGroupPhase(states.Running), podGroupRunning)
+	assert.Equal(t, podGroupPhase(states.Completed), podGroupFinished)
+	assert.Equal(t, podGroupPhase(states.Failed), podGroupFailed)
+	assert.Equal(t, podGroupPhase(states.Killed), podGroupFailed)
+}
+
+func TestPodGroupSync(t *testing.T) {
+	dynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podGroupResource: "PodGroupList"})
+	sync := newPodGroupSync(dynamicClient)
+
+	gang := newPodGroupTestApp("app-1", []v1alpha1.TaskGroup{
+		{Name: "driver", MinMember: 1, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("500m"), "memory": resource.MustParse("1Gi")}},
+		{Name: "executor", MinMember: 2, MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")}},
+	})
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "pod-1", Namespace: "default", UID: "uid-1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	gang.addTask(NewTask("uid-1", gang, nil, pod))
+	plain := newPodGroupTestApp("app-2", nil)
+
+	// only the gang is mirrored
+	sync.sync([]*Application{gang, plain})
+	podGroup := getPodGroup(t, dynamicClient, "yunikorn-app-1")
+	assert.Equal(t, podGroup.GetLabels()[podGroupManagedBy], podGroupManagedByName)
+	assert.Equal(t, podGroup.GetLabels()[constants.LabelApplicationID], "app-1")
+	minMember, _, _ := unstructured.NestedInt64(podGroup.Object, "spec", "minMember")
+	assert.Equal(t, minMember, int64(3))
+	minResources, _, _ := unstructured.NestedStringMap(podGroup.Object, "spec", "minResources")
+	assert.DeepEqual(t, minResources, map[string]string{"cpu": "2500m", "memory": "1Gi"})
+	phase, _, _ := unstructured.NestedString(podGroup.Object, "status", "phase")
+	assert.Equal(t, phase, podGroupPending)
+	running, _, _ := unstructured.NestedInt64(podGroup.Object, "status", "running")
+	assert.Equal(t, running, int64(1))
+	list, err := dynamicClient.Resource(podGroupResource).Namespace("default").List(context.Background(), apis.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(list.Items), 1)
+
+	// a state change updates the status
+	gang.sm.SetState(ApplicationStates().Running)
+	sync.sync([]*Application{gang, plain})
+	phase, _, _ = unstructured.NestedString(getPodGroup(t, dynamicClient, "yunikorn-app-1").Object, "status", "phase")
+	assert.Equal(t, phase, podGroupRunning)
+
+	// a PodGroup of the user is never changed
+	userGroup := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"minMember": int64(5)},
+	}}
+	userGroup.SetAPIVersion(podGroupResource.GroupVersion().String())
+	userGroup.SetKind("PodGroup")
+	userGroup.SetNamespace("default")
+	userGroup.SetName("yunikorn-app-3")
+	_, err = dynamicClient.Resource(podGroupResource).Namespace("default").Create(context.Background(), userGroup, apis.CreateOptions{})
+	assert.NilError(t, err)
+	other := newPodGroupTestApp("app-3", []v1alpha1.TaskGroup{{Name: "tg", MinMember: 1}})
+	sync.sync([]*Application{gang, other})
+	minMember, _, _ = unstructured.NestedInt64(getPodGroup(t, dynamicClient, "yunikorn-app-3").Object, "spec", "minMember")
+	assert.Equal(t, minMember, int64(5))
+	_, ok := sync.emitted["app-3"]
+	assert.Assert(t, !ok)
+
+	// the PodGroup is removed with the application
+	sync.sync([]*Application{other})
+	list, err = dynamicClient.Resource(podGroupResource).Namespace("default").List(context.Background(), apis.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(list.Items), 1)
+	assert.Equal(t, list.Items[0].GetName(), "yunikorn-app-3")
+	assert.Equal(t, len(sync.emitted), 0)
+}
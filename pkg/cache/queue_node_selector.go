@@ -0,0 +1,168 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// queueNodeSelectorPredicate is used as the plugin name when a node is rejected by the node selector of a queue
+const queueNodeSelectorPredicate = "QueueNodeSelector"
+
+// queueNodeSelectors tracks the node selectors set on queues via the QueuePropertyNodeSelector property.
+// The property is inherited by child queues unless the child sets its own selector. Dynamic queues use the
+// selector from the child template of their parent, or the selector of the parent if the template has none.
+// Queue names are stored in lower case as the core handles queue names case-insensitive.
+type queueNodeSelectors struct {
+	selectors    map[string]labels.Selector // effective selector of each configured queue, nil if not restricted
+	templates    map[string]labels.Selector // selector from the child template of a parent queue
+	availability map[string]bool            // selector -> a node matches, cleared when the nodes change
+	generation   uint64                     // incremented when the nodes change
+	lock         sync.RWMutex
+}
+
+func newQueueNodeSelectors() *queueNodeSelectors {
+	return &queueNodeSelectors{
+		selectors:    make(map[string]labels.Selector),
+		templates:    make(map[string]labels.Selector),
+		availability: make(map[string]bool),
+	}
+}
+
+// update replaces the selectors with the ones from the queue configuration of the default partition.
+// An empty configuration removes all selectors, an invalid configuration leaves the selectors unchanged.
+func (q *queueNodeSelectors) update(config string) {
+	selectors := make(map[string]labels.Selector)
+	templates := make(map[string]labels.Selector)
+	if config != "" {
+		schedulerConfig, err := configs.ParseAndValidateConfig([]byte(config))
+		if err != nil {
+			log.Log(log.ShimContext).Warn("failed to parse queue configuration, queue node selectors not updated",
+				zap.Error(err))
+			return
+		}
+		for _, partition := range schedulerConfig.Partitions {
+			if partition.Name != constants.DefaultPartition {
+				continue
+			}
+			for i := range partition.Queues {
+				addQueueNodeSelectors(selectors, templates, &partition.Queues[i], "", nil)
+			}
+		}
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.selectors = selectors
+	q.templates = templates
+}
+
+func addQueueNodeSelectors(selectors, templates map[string]labels.Selector, queue *configs.QueueConfig, parentPath string, parentSelector labels.Selector) {
+	path := strings.ToLower(queue.Name)
+	if parentPath != "" {
+		path = parentPath + "." + path
+	}
+	selector := parentSelector
+	if value, ok := queue.Properties[constants.QueuePropertyNodeSelector]; ok {
+		selector = parseQueueNodeSelector(path, value)
+	}
+	// configured queues are always added, a nil selector means the nodes are not restricted
+	selectors[path] = selector
+	if value, ok := queue.ChildTemplate.Properties[constants.QueuePropertyNodeSelector]; ok {
+		if template := parseQueueNodeSelector(path, value); template != nil {
+			templates[path] = template
+		}
+	}
+	for i := range queue.Queues {
+		addQueueNodeSelectors(selectors, templates, &queue.Queues[i], path, selector)
+	}
+}
+
+// parseQueueNodeSelector parses the property value, an empty or invalid value does not restrict the nodes
+func parseQueueNodeSelector(queueName, value string) labels.Selector {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		log.Log(log.ShimContext).Error("invalid node selector on queue, nodes are not restricted",
+			zap.String("queue", queueName),
+			zap.String("selector", value),
+			zap.Error(err))
+		return nil
+	}
+	return selector
+}
+
+// get returns the node selector for the queue, nil if the nodes are not restricted
+func (q *queueNodeSelectors) get(queueName string) labels.Selector {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	path := strings.ToLower(queueName)
+	if selector, ok := q.selectors[path]; ok {
+		return selector
+	}
+	// not a configured queue: find the closest configured parent
+	for i := strings.LastIndex(path, "."); i > 0; i = strings.LastIndex(path, ".") {
+		path = path[:i]
+		if template, ok := q.templates[path]; ok {
+			return template
+		}
+		if selector, ok := q.selectors[path]; ok {
+			return selector
+		}
+	}
+	return nil
+}
+
+// hasMatchingNode returns true if a node matches the selector. The result is cached per selector until the nodes
+// change, the match function is only called on a cache miss and without holding the lock.
+func (q *queueNodeSelectors) hasMatchingNode(selector labels.Selector, match func(selector labels.Selector) bool) bool {
+	key := selector.String()
+	q.lock.RLock()
+	available, ok := q.availability[key]
+	generation := q.generation
+	q.lock.RUnlock()
+	if ok {
+		return available
+	}
+	available = match(selector)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	// the nodes changed while matching: the result is not cached
+	if q.generation == generation {
+		q.availability[key] = available
+	}
+	return available
+}
+
+// nodesChanged drops the cached availability of the selectors, must be called when a node is added, updated or removed
+func (q *queueNodeSelectors) nodesChanged() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.generation++
+	q.availability = make(map[string]bool)
+}
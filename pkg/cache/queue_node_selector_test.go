@@ -0,0 +1,120 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const queueNodeSelectorConfig = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: teamA
+            properties:
+              yunikorn.apache.org/node-selector: pool=teamA
+            queues:
+              - name: dev
+              - name: prod
+                properties:
+                  yunikorn.apache.org/node-selector: pool in (teamA,shared)
+          - name: dynamic
+            parent: true
+            childtemplate:
+              properties:
+                yunikorn.apache.org/node-selector: pool=dynamic
+          - name: invalid
+            properties:
+              yunikorn.apache.org/node-selector: "pool in teamA"
+          - name: open
+`
+
+func TestQueueNodeSelectors(t *testing.T) {
+	selectors := newQueueNodeSelectors()
+	selectors.update(queueNodeSelectorConfig)
+
+	tests := []struct {
+		queue    string
+		expected string
+	}{
+		{"root", ""},
+		{"root.open", ""},
+		{"root.unknown", ""},
+		{"root.teamA", "pool=teamA"},
+		{"ROOT.TEAMA", "pool=teamA"},
+		{"root.teamA.dev", "pool=teamA"},
+		{"root.teamA.prod", "pool in (shared,teamA)"},
+		{"root.teamA.dynamic", "pool=teamA"},
+		{"root.dynamic", ""},
+		{"root.dynamic.child", "pool=dynamic"},
+		{"root.invalid", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.queue, func(t *testing.T) {
+			selector := selectors.get(tc.queue)
+			if tc.expected == "" {
+				assert.Assert(t, selector == nil, "unexpected selector %v", selector)
+				return
+			}
+			assert.Assert(t, selector != nil, "expected selector")
+			assert.Equal(t, selector.String(), tc.expected)
+		})
+	}
+
+	// invalid configuration keeps the selectors
+	selectors.update("partitions: [")
+	assert.Assert(t, selectors.get("root.teamA") != nil, "selectors removed on invalid config")
+	// empty configuration removes all selectors
+	selectors.update("")
+	assert.Assert(t, selectors.get("root.teamA") == nil, "selectors not removed on empty config")
+}
+
+func TestQueueNodeSelectorsAvailability(t *testing.T) {
+	selectors := newQueueNodeSelectors()
+	selector, err := labels.Parse("pool=teamA")
+	assert.NilError(t, err)
+	calls := 0
+	available := false
+	match := func(labels.Selector) bool {
+		calls++
+		return available
+	}
+
+	// the result is cached until the nodes change
+	assert.Assert(t, !selectors.hasMatchingNode(selector, match))
+	assert.Assert(t, !selectors.hasMatchingNode(selector, match))
+	assert.Equal(t, calls, 1)
+	available = true
+	selectors.nodesChanged()
+	assert.Assert(t, selectors.hasMatchingNode(selector, match))
+	assert.Equal(t, calls, 2)
+
+	// a change of the nodes while matching is not overwritten by the result
+	selectors.nodesChanged()
+	assert.Assert(t, selectors.hasMatchingNode(selector, func(labels.Selector) bool {
+		selectors.nodesChanged()
+		return true
+	}))
+	assert.Equal(t, len(selectors.availability), 0)
+}
@@ -0,0 +1,188 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	// PreemptionWindowUpdateInterval is the interval at which preemptions that left the windows are expired
+	PreemptionWindowUpdateInterval = time.Minute
+	// unknownPreemptorQueue is reported if the ask that triggered the preemption cannot be found
+	unknownPreemptorQueue = "unknown"
+	// preemptorAskMarker precedes the allocation key of the ask that triggered the preemption in the release message
+	preemptorAskMarker = "ask: "
+)
+
+// preemptionWindow is an observation window with the name used as the metric label
+type preemptionWindow struct {
+	name     string
+	duration time.Duration
+}
+
+// preemptionWindowSizes are the observation windows of the preemptions, the last one is the longest
+var preemptionWindowSizes = []preemptionWindow{
+	{name: "1h", duration: time.Hour},
+	{name: "24h", duration: 24 * time.Hour},
+}
+
+// preemptionRecord is the preemption of a single allocation
+type preemptionRecord struct {
+	time      time.Time
+	preemptor string
+	victim    string
+	resource  *si.Resource
+}
+
+type preemptionPair struct {
+	preemptor string
+	victim    string
+}
+
+// preemptionWindows tracks which queues preempted allocations of which other queues within the observation windows.
+// A queue that keeps preempting the same victim queue points to guarantees that do not match the usage.
+type preemptionWindows struct {
+	records []preemptionRecord // ordered by time
+	lock    sync.Mutex
+}
+
+func newPreemptionWindows() *preemptionWindows {
+	return &preemptionWindows{}
+}
+
+// add records a preemption and updates the metrics
+func (p *preemptionWindows) add(now time.Time, preemptor, victim string, resource *si.Resource) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.records = append(p.records, preemptionRecord{
+		time:      now,
+		preemptor: preemptor,
+		victim:    victim,
+		resource:  resource,
+	})
+	p.updateInternal(now)
+}
+
+// update expires the preemptions that left the longest window and updates the metrics
+func (p *preemptionWindows) update(now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.updateInternal(now)
+}
+
+func (p *preemptionWindows) updateInternal(now time.Time) {
+	longest := preemptionWindowSizes[len(preemptionWindowSizes)-1].duration
+	expired := 0
+	for expired < len(p.records) && now.Sub(p.records[expired].time) > longest {
+		expired++
+	}
+	p.records = p.records[expired:]
+
+	metrics.ResetPreemptionWindows()
+	for _, window := range preemptionWindowSizes {
+		counts := make(map[preemptionPair]int)
+		resources := make(map[preemptionPair]map[string]int64)
+		for _, record := range p.records {
+			if now.Sub(record.time) > window.duration {
+				continue
+			}
+			pair := preemptionPair{preemptor: record.preemptor, victim: record.victim}
+			counts[pair]++
+			if resources[pair] == nil {
+				resources[pair] = make(map[string]int64)
+			}
+			if record.resource != nil {
+				for name, quantity := range record.resource.Resources {
+					resources[pair][name] += quantity.GetValue()
+				}
+			}
+		}
+		for pair, count := range counts {
+			metrics.SetPreemptionWindowCount(window.name, pair.preemptor, pair.victim, count)
+			for name, value := range resources[pair] {
+				metrics.SetPreemptionWindowResource(window.name, pair.preemptor, pair.victim, name, value)
+			}
+		}
+	}
+}
+
+// getPreemptorAllocationKey returns the allocation key of the ask that triggered the preemption from the message of
+// the release, or an empty string if the message does not name the ask.
+func getPreemptorAllocationKey(message string) string {
+	index := strings.LastIndex(message, preemptorAskMarker)
+	if index < 0 {
+		return ""
+	}
+	return strings.TrimSpace(message[index+len(preemptorAskMarker):])
+}
+
+// RecordPreemption records the preemption of the released allocation in the observation windows of the queues.
+// Must be called before the release is processed by the application, as the task is found by its allocation.
+func (ctx *Context) RecordPreemption(release *si.AllocationRelease) {
+	ctx.lock.RLock()
+	victimApp, ok := ctx.applications[release.ApplicationID]
+	preemptorKey := getPreemptorAllocationKey(release.Message)
+	preemptorQueue := unknownPreemptorQueue
+	if preemptorKey != "" {
+		for _, app := range ctx.applications {
+			if _, err := app.GetTask(preemptorKey); err == nil {
+				preemptorQueue = app.GetQueue()
+				break
+			}
+		}
+	}
+	ctx.lock.RUnlock()
+	if !ok {
+		log.Log(log.ShimContext).Debug("application of preempted allocation not found",
+			zap.String("appID", release.ApplicationID),
+			zap.String("allocationUUID", release.UUID))
+		return
+	}
+	var resource *si.Resource
+	victimApp.lock.RLock()
+	for _, task := range victimApp.taskMap {
+		if task.allocationUUID == release.UUID {
+			resource = task.resource
+			break
+		}
+	}
+	victimQueue := victimApp.queue
+	victimApp.lock.RUnlock()
+	log.Log(log.ShimContext).Info("allocation preempted",
+		zap.String("appID", release.ApplicationID),
+		zap.String("allocationUUID", release.UUID),
+		zap.String("victimQueue", victimQueue),
+		zap.String("preemptorQueue", preemptorQueue))
+	ctx.preemptions.add(time.Now(), preemptorQueue, victimQueue, resource)
+}
+
+// UpdatePreemptionWindows expires the preemptions that left the observation windows.
+// Called periodically.
+func (ctx *Context) UpdatePreemptionWindows() {
+	ctx.preemptions.update(time.Now())
+}
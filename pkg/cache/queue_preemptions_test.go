@@ -0,0 +1,130 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestGetPreemptorAllocationKey(t *testing.T) {
+	assert.Equal(t, getPreemptorAllocationKey("preempting allocations to free up resources to run ask: uid-1"), "uid-1")
+	assert.Equal(t, getPreemptorAllocationKey("preempting allocations to free up resources to run daemon set ask: uid-2"), "uid-2")
+	assert.Equal(t, getPreemptorAllocationKey("released by the scheduler"), "")
+	assert.Equal(t, getPreemptorAllocationKey(""), "")
+}
+
+func TestPreemptionWindows(t *testing.T) {
+	now := time.Now()
+	res := common.NewResourceBuilder().AddResource(siCommon.CPU, 1000).AddResource(siCommon.Memory, 1024).Build()
+	windows := newPreemptionWindows()
+	windows.add(now.Add(-2*time.Hour), "root.a", "root.b", res)
+	windows.add(now, "root.a", "root.b", res)
+	windows.add(now, "root.c", "root.b", nil)
+
+	count, err := metrics.GetPreemptionWindowCount("1h", "root.a", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+	value, err := metrics.GetPreemptionWindowResource("1h", "root.a", "root.b", siCommon.CPU)
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(1000))
+	count, err = metrics.GetPreemptionWindowCount("24h", "root.a", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+	value, err = metrics.GetPreemptionWindowResource("24h", "root.a", "root.b", siCommon.Memory)
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(2048))
+	count, err = metrics.GetPreemptionWindowCount("1h", "root.c", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+
+	// preemptions leave the windows over time and are dropped after the longest window
+	windows.update(now.Add(2 * time.Hour))
+	count, err = metrics.GetPreemptionWindowCount("1h", "root.a", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0)
+	count, err = metrics.GetPreemptionWindowCount("24h", "root.a", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, len(windows.records), 3)
+	windows.update(now.Add(23 * time.Hour))
+	assert.Equal(t, len(windows.records), 2)
+	count, err = metrics.GetPreemptionWindowCount("24h", "root.a", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+	windows.update(now.Add(25 * time.Hour))
+	assert.Equal(t, len(windows.records), 0)
+	count, err = metrics.GetPreemptionWindowCount("24h", "root.c", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0)
+}
+
+func TestRecordPreemption(t *testing.T) {
+	ctx := initContextForTest()
+	victimApp := NewApplication("app-victim", "root.victim", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	preemptorApp := NewApplication("app-preemptor", "root.preemptor", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	ctx.applications[victimApp.applicationID] = victimApp
+	ctx.applications[preemptorApp.applicationID] = preemptorApp
+
+	victimPod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "victim", Namespace: "default", UID: "uid-victim"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+		}}}},
+	}
+	victim := NewTask("uid-victim", victimApp, ctx, victimPod)
+	victim.allocationUUID = "alloc-victim"
+	victimApp.addTask(victim)
+	preemptorPod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "preemptor", Namespace: "default", UID: "uid-preemptor"}}
+	preemptorApp.addTask(NewTask("uid-preemptor", preemptorApp, ctx, preemptorPod))
+
+	ctx.RecordPreemption(&si.AllocationRelease{
+		ApplicationID:   "app-victim",
+		UUID:            "alloc-victim",
+		TerminationType: si.TerminationType_PREEMPTED_BY_SCHEDULER,
+		Message:         "preempting allocations to free up resources to run ask: uid-preemptor",
+	})
+	count, err := metrics.GetPreemptionWindowCount("1h", "root.preemptor", "root.victim")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+	value, err := metrics.GetPreemptionWindowResource("1h", "root.preemptor", "root.victim", siCommon.CPU)
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(500))
+
+	// the preemptor cannot always be found
+	ctx.RecordPreemption(&si.AllocationRelease{
+		ApplicationID:   "app-victim",
+		UUID:            "alloc-victim",
+		TerminationType: si.TerminationType_PREEMPTED_BY_SCHEDULER,
+		Message:         "preempting allocations to free up resources to run ask: uid-unknown",
+	})
+	count, err = metrics.GetPreemptionWindowCount("1h", unknownPreemptorQueue, "root.victim")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+}
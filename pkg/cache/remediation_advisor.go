@@ -0,0 +1,329 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	// advisorQueueCacheTTL is how long the queues of a partition are reused before they are retrieved again
+	advisorQueueCacheTTL = 10 * time.Second
+	// advisorRequestTimeout limits the time spent retrieving the queues, the event is published without suggestions
+	advisorRequestTimeout = 2 * time.Second
+	// maxSuggestedQueues is the maximum number of alternative queues in a suggestion
+	maxSuggestedQueues = 2
+
+	queueStatusActive = "Active"
+)
+
+// QueueSource provides the queue hierarchy of a partition with the configured and used resources of each queue.
+// It is implemented by the headroom client of the core REST API.
+type QueueSource interface {
+	GetQueues(ctx context.Context, partition string) (*dao.PartitionQueueDAOInfo, error)
+}
+
+type cachedQueues struct {
+	root    *dao.PartitionQueueDAOInfo
+	fetched time.Time
+}
+
+// remediationAdvisor explains why a pod waits for resources and suggests what the user can change. The limits and
+// usage of the queues come from the core, the queue node selectors of the shim restrict the alternative queues to
+// those placing the pod on the same nodes.
+type remediationAdvisor struct {
+	source    QueueSource
+	selectors *queueNodeSelectors
+	queues    map[string]*cachedQueues // by partition
+	lock      sync.Mutex
+}
+
+func newRemediationAdvisor(source QueueSource, selectors *queueNodeSelectors) *remediationAdvisor {
+	return &remediationAdvisor{
+		source:    source,
+		selectors: selectors,
+		queues:    make(map[string]*cachedQueues),
+	}
+}
+
+// getQueues returns the queues of the partition, cached for a short time as events are published in bursts
+func (a *remediationAdvisor) getQueues(partition string, now time.Time) (*dao.PartitionQueueDAOInfo, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if cached, ok := a.queues[partition]; ok && now.Sub(cached.fetched) < advisorQueueCacheTTL {
+		return cached.root, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), advisorRequestTimeout)
+	defer cancel()
+	root, err := a.source.GetQueues(ctx, partition)
+	if err != nil {
+		return nil, err
+	}
+	a.queues[partition] = &cachedQueues{root: root, fetched: now}
+	return root, nil
+}
+
+// suggest returns the remediation for a pod with the request waiting in the queue, with the number of applications
+// in the queue that wait longer. Returns an empty string if there is nothing to suggest.
+func (a *remediationAdvisor) suggest(partition, queueName string, request *si.Resource, ahead int) string {
+	root, err := a.getQueues(partition, time.Now())
+	if err != nil {
+		log.Log(log.ShimContext).Debug("queues not available for remediation suggestions",
+			zap.String("partition", partition),
+			zap.Error(err))
+		return ""
+	}
+	path := findQueuePath(root, strings.ToLower(queueName))
+	if path == nil {
+		return ""
+	}
+	requested := make(map[string]int64)
+	if request != nil {
+		for name, quantity := range request.Resources {
+			if quantity.GetValue() > 0 {
+				requested[name] = quantity.GetValue()
+			}
+		}
+	}
+
+	queue := path[len(path)-1].QueueName
+	reasons := getQueueLimitsReached(path, requested)
+	limited := len(reasons) > 0
+	switch {
+	case ahead == 1:
+		reasons = append(reasons, fmt.Sprintf("1 application ahead in queue %s", queue))
+	case ahead > 1:
+		reasons = append(reasons, fmt.Sprintf("%d applications ahead in queue %s", ahead, queue))
+	}
+	// other queues only help if the queue itself is the limit
+	if limited {
+		if alternatives := a.getAlternativeQueues(root, queue, requested); len(alternatives) > 0 {
+			reasons = append(reasons, fmt.Sprintf("consider queue %s which has headroom", strings.Join(alternatives, " or ")))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// getQueueLimitsReached returns a reason for each limit of the queue or its parents that prevents the request from
+// being allocated. For each resource the queue with the least headroom is reported.
+func getQueueLimitsReached(path []*dao.PartitionQueueDAOInfo, requested map[string]int64) []string {
+	reasons := make([]string, 0)
+	names := make([]string, 0, len(requested))
+	for name := range requested {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var limiting *dao.PartitionQueueDAOInfo
+		var available int64
+		for _, queue := range path {
+			max, ok := queue.MaxResource[name]
+			if !ok {
+				continue
+			}
+			if free := max - queue.AllocatedResource[name]; limiting == nil || free < available {
+				limiting = queue
+				available = free
+			}
+		}
+		if limiting != nil && available < requested[name] {
+			reasons = append(reasons, fmt.Sprintf("queue %s max %s %s reached", limiting.QueueName, name,
+				formatResourceValue(name, limiting.MaxResource[name])))
+		}
+	}
+	for _, queue := range path {
+		if queue.MaxRunningApps > 0 && queue.RunningApps >= queue.MaxRunningApps {
+			reasons = append(reasons, fmt.Sprintf("queue %s max running applications %d reached", queue.QueueName, queue.MaxRunningApps))
+		}
+	}
+	return reasons
+}
+
+// getAlternativeQueues returns the configured leaf queues that have the headroom for the request and place pods on
+// the same nodes as the queue, the queues with the most headroom first
+func (a *remediationAdvisor) getAlternativeQueues(root *dao.PartitionQueueDAOInfo, queueName string, requested map[string]int64) []string {
+	nodeSelector := selectorString(a.selectors.get(queueName))
+	type candidate struct {
+		name string
+		fit  float64
+	}
+	candidates := make([]candidate, 0)
+	var walk func(queue *dao.PartitionQueueDAOInfo, path []*dao.PartitionQueueDAOInfo)
+	walk = func(queue *dao.PartitionQueueDAOInfo, path []*dao.PartitionQueueDAOInfo) {
+		path = append(path, queue)
+		if queue.Status != "" && queue.Status != queueStatusActive {
+			return
+		}
+		for i := range queue.Children {
+			walk(&queue.Children[i], path)
+		}
+		if !queue.IsLeaf || !queue.IsManaged || strings.EqualFold(queue.QueueName, queueName) {
+			return
+		}
+		if selectorString(a.selectors.get(queue.QueueName)) != nodeSelector {
+			return
+		}
+		if fit, ok := getQueueFit(path, requested); ok {
+			candidates = append(candidates, candidate{name: queue.QueueName, fit: fit})
+		}
+	}
+	walk(root, nil)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].fit != candidates[j].fit {
+			return candidates[i].fit > candidates[j].fit
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	names := make([]string, 0, maxSuggestedQueues)
+	for i := 0; i < len(candidates) && i < maxSuggestedQueues; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// getQueueFit returns how many times the request fits in the headroom of the queue and its parents, and false if
+// the request does not fit or a queue on the path cannot run more applications
+func getQueueFit(path []*dao.PartitionQueueDAOInfo, requested map[string]int64) (float64, bool) {
+	fit := -1.0
+	for _, queue := range path {
+		if queue.MaxRunningApps > 0 && queue.RunningApps >= queue.MaxRunningApps {
+			return 0, false
+		}
+		for name, value := range requested {
+			max, ok := queue.MaxResource[name]
+			if !ok {
+				continue
+			}
+			available := max - queue.AllocatedResource[name] - queue.PendingResource[name]
+			if available < value {
+				return 0, false
+			}
+			if times := float64(available) / float64(value); fit < 0 || times < fit {
+				fit = times
+			}
+		}
+	}
+	// an unlimited queue fits the request any number of times
+	if fit < 0 {
+		fit = float64(^uint32(0))
+	}
+	return fit, true
+}
+
+// findQueuePath returns the queues from the root to the queue, or nil if the queue does not exist
+func findQueuePath(root *dao.PartitionQueueDAOInfo, queueName string) []*dao.PartitionQueueDAOInfo {
+	path := make([]*dao.PartitionQueueDAOInfo, 0)
+	queue := root
+	for queue != nil {
+		path = append(path, queue)
+		name := strings.ToLower(queue.QueueName)
+		if name == queueName {
+			return path
+		}
+		var next *dao.PartitionQueueDAOInfo
+		for i := range queue.Children {
+			child := strings.ToLower(queue.Children[i].QueueName)
+			if child == queueName || strings.HasPrefix(queueName, child+".") {
+				next = &queue.Children[i]
+				break
+			}
+		}
+		queue = next
+	}
+	return nil
+}
+
+// formatResourceValue formats the value of the resource in the units used in the pod spec
+func formatResourceValue(name string, value int64) string {
+	switch name {
+	case siCommon.Memory:
+		return resource.NewQuantity(value, resource.BinarySI).String()
+	case siCommon.CPU:
+		return resource.NewMilliQuantity(value, resource.DecimalSI).String()
+	default:
+		return strconv.FormatInt(value, 10)
+	}
+}
+
+// selectorString returns the selector as a string, a queue without a selector places pods on any node
+func selectorString(selector labels.Selector) string {
+	if selector == nil {
+		return ""
+	}
+	return selector.String()
+}
+
+// SetQueueSource enables the remediation suggestions in the events of pods waiting for resources.
+// The suggestions are only added if enabled in the configuration.
+func (ctx *Context) SetQueueSource(source QueueSource) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.advisor = newRemediationAdvisor(source, ctx.queueSelectors)
+}
+
+// getRemediation returns the suggestion for the waiting task to append to an event message, or an empty string
+func (ctx *Context) getRemediation(task *Task) string {
+	ctx.lock.RLock()
+	advisor := ctx.advisor
+	ctx.lock.RUnlock()
+	if advisor == nil || !schedulerconf.GetSchedulerConf().IsEventSuggestions() || task.application == nil {
+		return ""
+	}
+	app := task.application
+	suggestion := advisor.suggest(app.partition, app.GetQueue(), task.resource, ctx.countApplicationsAhead(app, task))
+	if suggestion == "" {
+		return ""
+	}
+	return ": " + suggestion
+}
+
+// countApplicationsAhead returns the number of other applications in the queue of the application with tasks that
+// wait for resources and were created before the task
+func (ctx *Context) countApplicationsAhead(app *Application, task *Task) int {
+	created := task.pod.CreationTimestamp
+	queue := app.GetQueue()
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	ahead := 0
+	for _, other := range ctx.applications {
+		if other == app || !strings.EqualFold(other.GetQueue(), queue) {
+			continue
+		}
+		if other.hasTaskWaitingSince(created) {
+			ahead++
+		}
+	}
+	return ahead
+}
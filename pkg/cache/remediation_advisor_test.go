@@ -0,0 +1,229 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+)
+
+const gib = 1024 * 1024 * 1024
+
+type mockQueueSource struct {
+	root  *dao.PartitionQueueDAOInfo
+	err   error
+	calls int
+}
+
+func (m *mockQueueSource) GetQueues(_ context.Context, _ string) (*dao.PartitionQueueDAOInfo, error) {
+	m.calls++
+	return m.root, m.err
+}
+
+func advisorQueuesForTest() *dao.PartitionQueueDAOInfo {
+	return &dao.PartitionQueueDAOInfo{
+		QueueName:         "root",
+		Status:            "Active",
+		MaxResource:       map[string]int64{siCommon.Memory: 100 * gib},
+		AllocatedResource: map[string]int64{siCommon.Memory: 40 * gib},
+		IsManaged:         true,
+		Children: []dao.PartitionQueueDAOInfo{
+			{
+				QueueName:         "root.sandbox2",
+				Status:            "Active",
+				MaxResource:       map[string]int64{siCommon.Memory: 10 * gib},
+				AllocatedResource: map[string]int64{siCommon.Memory: 10 * gib},
+				IsLeaf:            true,
+				IsManaged:         true,
+			},
+			{
+				QueueName:         "root.small",
+				Status:            "Active",
+				MaxResource:       map[string]int64{siCommon.Memory: 4 * gib},
+				AllocatedResource: map[string]int64{siCommon.Memory: 2 * gib},
+				IsLeaf:            true,
+				IsManaged:         true,
+			},
+			{
+				QueueName:         "root.large",
+				Status:            "Active",
+				MaxResource:       map[string]int64{siCommon.Memory: 20 * gib},
+				AllocatedResource: map[string]int64{siCommon.Memory: 2 * gib},
+				IsLeaf:            true,
+				IsManaged:         true,
+			},
+			{
+				QueueName: "root.stopped",
+				Status:    "Stopped",
+				IsLeaf:    true,
+				IsManaged: true,
+			},
+			{
+				QueueName: "root.dynamic",
+				Status:    "Active",
+				IsLeaf:    true,
+			},
+			{
+				QueueName: "root.gpu",
+				Status:    "Active",
+				IsLeaf:    true,
+				IsManaged: true,
+			},
+			{
+				QueueName:      "root.apps",
+				Status:         "Active",
+				MaxRunningApps: 2,
+				RunningApps:    2,
+				IsLeaf:         true,
+				IsManaged:      true,
+			},
+		},
+	}
+}
+
+const advisorQueueConfig = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: sandbox2
+          - name: small
+          - name: large
+          - name: stopped
+          - name: gpu
+            properties:
+              yunikorn.apache.org/node-selector: pool=gpu
+          - name: apps
+`
+
+func newAdvisorForTest(source QueueSource) *remediationAdvisor {
+	selectors := newQueueNodeSelectors()
+	selectors.update(advisorQueueConfig)
+	return newRemediationAdvisor(source, selectors)
+}
+
+func TestRemediationAdvisorSuggest(t *testing.T) {
+	source := &mockQueueSource{root: advisorQueuesForTest()}
+	advisor := newAdvisorForTest(source)
+	request := common.NewResourceBuilder().AddResource(siCommon.Memory, 2*gib).AddResource(siCommon.CPU, 500).Build()
+
+	// the queue is full: the queues with headroom on the same nodes are suggested, the one with most headroom first
+	assert.Equal(t, advisor.suggest("default", "root.sandbox2", request, 3),
+		"queue root.sandbox2 max memory 10Gi reached; 3 applications ahead in queue root.sandbox2; "+
+			"consider queue root.large or root.small which has headroom")
+	assert.Equal(t, advisor.suggest("default", "ROOT.SANDBOX2", request, 0),
+		"queue root.sandbox2 max memory 10Gi reached; consider queue root.large or root.small which has headroom")
+
+	// the queue has headroom: nothing to suggest but the applications ahead
+	assert.Equal(t, advisor.suggest("default", "root.large", request, 0), "")
+	assert.Equal(t, advisor.suggest("default", "root.large", request, 1), "1 application ahead in queue root.large")
+
+	// a request that does not fit in any other queue
+	request = common.NewResourceBuilder().AddResource(siCommon.Memory, 30*gib).Build()
+	assert.Equal(t, advisor.suggest("default", "root.large", request, 0), "queue root.large max memory 20Gi reached")
+
+	// the limit of running applications
+	assert.Equal(t, advisor.suggest("default", "root.apps", nil, 0),
+		"queue root.apps max running applications 2 reached; consider queue root.large or root.sandbox2 which has headroom")
+
+	// unknown queue
+	assert.Equal(t, advisor.suggest("default", "root.unknown", request, 0), "")
+
+	// the queues are retrieved once within the cache period
+	assert.Equal(t, source.calls, 1)
+}
+
+func TestRemediationAdvisorQueueCache(t *testing.T) {
+	source := &mockQueueSource{err: errors.New("core not reachable")}
+	advisor := newAdvisorForTest(source)
+	assert.Equal(t, advisor.suggest("default", "root.sandbox2", nil, 1), "")
+
+	// errors are not cached
+	source.root = advisorQueuesForTest()
+	source.err = nil
+	now := time.Now()
+	_, err := advisor.getQueues("default", now)
+	assert.NilError(t, err)
+	assert.Equal(t, source.calls, 2)
+	_, err = advisor.getQueues("default", now.Add(time.Second))
+	assert.NilError(t, err)
+	assert.Equal(t, source.calls, 2)
+	_, err = advisor.getQueues("default", now.Add(advisorQueueCacheTTL))
+	assert.NilError(t, err)
+	assert.Equal(t, source.calls, 3)
+}
+
+func TestFormatResourceValue(t *testing.T) {
+	assert.Equal(t, formatResourceValue(siCommon.Memory, 10*gib), "10Gi")
+	assert.Equal(t, formatResourceValue(siCommon.CPU, 1500), "1500m")
+	assert.Equal(t, formatResourceValue(siCommon.CPU, 2000), "2")
+	assert.Equal(t, formatResourceValue("pods", 10), "10")
+}
+
+func TestGetRemediation(t *testing.T) {
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.EventSuggestions = true
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	context := initContextForTest()
+	context.queueSelectors.update(advisorQueueConfig)
+	created := apis.Now()
+	newWaitingApp := func(appID string, podCreated apis.Time) (*Application, *Task) {
+		app := NewApplication(appID, "root.sandbox2", "user", testGroups, map[string]string{}, newMockSchedulerAPI())
+		pod := &v1.Pod{ObjectMeta: apis.ObjectMeta{Name: appID, Namespace: "default", UID: types.UID("uid-" + appID), CreationTimestamp: podCreated}}
+		task := NewTask("uid-"+appID, app, context, pod)
+		task.resource = common.NewResourceBuilder().AddResource(siCommon.Memory, 2*gib).Build()
+		task.sm.SetState(TaskStates().Scheduling)
+		app.addTask(task)
+		context.applications[appID] = app
+		return app, task
+	}
+	_, task := newWaitingApp("app-1", created)
+	newWaitingApp("app-2", apis.NewTime(created.Add(-time.Minute)))
+	newWaitingApp("app-3", apis.NewTime(created.Add(-time.Minute)))
+	newWaitingApp("app-4", apis.NewTime(created.Add(time.Minute)))
+
+	// no suggestions without a queue source
+	assert.Equal(t, context.getRemediation(task), "")
+
+	context.SetQueueSource(&mockQueueSource{root: advisorQueuesForTest()})
+	assert.Equal(t, context.countApplicationsAhead(task.application, task), 2)
+	assert.Equal(t, context.getRemediation(task),
+		": queue root.sandbox2 max memory 10Gi reached; 2 applications ahead in queue root.sandbox2; "+
+			"consider queue root.large or root.small which has headroom")
+
+	// disabled in the configuration
+	testConf.EventSuggestions = false
+	assert.Equal(t, context.getRemediation(task), "")
+}
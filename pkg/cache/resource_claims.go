@@ -0,0 +1,393 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// resourceClaimsPredicate is used as the plugin name when a node cannot be used for the ResourceClaims of a pod
+const resourceClaimsPredicate = "ResourceClaims"
+
+// claimNodeCapacity is the capacity of each claim resource type reported on the nodes. The count does not limit
+// the claims on a node, whether a node can be used for a claim is checked by the predicates.
+const claimNodeCapacity = math.MaxInt32
+
+// errClaimNotAllocated is returned while a ResourceClaim of the pod has not been allocated by its driver
+var errClaimNotAllocated = errors.New("resource claim is not allocated")
+
+// errClaimUnsuitable is returned if a ResourceClaim of the pod cannot be used by the pod on the node
+var errClaimUnsuitable = errors.New("resource claim cannot be used")
+
+// claimClasses tracks the resource classes of the claims of the pods. The nodes report the claim resource type of
+// each class: the core only reserves a node for an ask, or preempts allocations on it, if the node defines all
+// the resource types of the ask.
+type claimClasses struct {
+	classes map[string]bool
+	lock    sync.RWMutex
+}
+
+func newClaimClasses() *claimClasses {
+	return &claimClasses{
+		classes: make(map[string]bool),
+	}
+}
+
+// add adds the resource class, returns true if the class was not known yet
+func (c *claimClasses) add(className string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.classes[className] {
+		return false
+	}
+	c.classes[className] = true
+	return true
+}
+
+// applyCapacity returns the node with the claim resource types of the known classes added to the allocatable
+// resources. The node is returned unchanged if no classes are known, otherwise a copy is modified.
+func (c *claimClasses) applyCapacity(node *v1.Node) *v1.Node {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if len(c.classes) == 0 {
+		return node
+	}
+	result := node.DeepCopy()
+	if result.Status.Allocatable == nil {
+		result.Status.Allocatable = make(v1.ResourceList)
+	}
+	for className := range c.classes {
+		result.Status.Allocatable[v1.ResourceName(constants.ResourceClaimPrefix+className)] = *resource.NewQuantity(claimNodeCapacity, resource.DecimalSI)
+	}
+	return result
+}
+
+// reportClaimClasses updates the nodes with the claim resource types of all known resource classes
+func (ctx *Context) reportClaimClasses() {
+	ctx.schedulerCache.LockForReads()
+	nodes := make([]*v1.Node, 0)
+	for _, nodeInfo := range ctx.schedulerCache.GetNodesInfo() {
+		if node := nodeInfo.Node(); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	ctx.schedulerCache.UnlockForReads()
+	for _, node := range nodes {
+		updated := ctx.claimClasses.applyCapacity(node)
+		ctx.schedulerCache.UpdateNode(updated)
+		ctx.nodes.updateNode(node, updated)
+	}
+}
+
+// podClaimName returns the name of the ResourceClaim referenced by the pod. Claims created from a template are
+// named after the pod and the claim in the pod spec, like the resource claim controller does.
+func podClaimName(pod *v1.Pod, podClaim *v1.PodResourceClaim) string {
+	if podClaim.Source.ResourceClaimName != nil {
+		return *podClaim.Source.ResourceClaimName
+	}
+	return pod.Name + "-" + podClaim.Name
+}
+
+// getPodClaimResource returns the resource that accounts for the DRA ResourceClaims of the pod, one for the
+// resource class of each claim. Claims that cannot be resolved, like a template that does not exist, are not counted.
+// Returns nil if the pod has no claims or dynamic resource allocation is not enabled.
+func (ctx *Context) getPodClaimResource(pod *v1.Pod) *si.Resource {
+	if len(pod.Spec.ResourceClaims) == 0 || ctx.apiProvider == nil || ctx.apiProvider.GetAPIs().ResourceClaimInformer == nil {
+		return nil
+	}
+	counts := make(map[string]int64)
+	for i := range pod.Spec.ResourceClaims {
+		className, err := ctx.getClaimClassName(pod, &pod.Spec.ResourceClaims[i])
+		if err != nil {
+			log.Log(log.ShimContext).Debug("resource claim not found, claim not counted",
+				zap.String("podName", pod.Name),
+				zap.String("claim", pod.Spec.ResourceClaims[i].Name),
+				zap.Error(err))
+			continue
+		}
+		counts[constants.ResourceClaimPrefix+className]++
+		if ctx.claimClasses.add(className) {
+			go ctx.reportClaimClasses()
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	builder := common.NewResourceBuilder()
+	for name, count := range counts {
+		builder.AddResource(name, count)
+	}
+	return builder.Build()
+}
+
+// getClaimClassName returns the resource class of the claim, from the template if the claim is created for the pod
+func (ctx *Context) getClaimClassName(pod *v1.Pod, podClaim *v1.PodResourceClaim) (string, error) {
+	apis := ctx.apiProvider.GetAPIs()
+	if name := podClaim.Source.ResourceClaimTemplateName; name != nil {
+		template, err := apis.ResourceClaimTemplateInformer.Lister().ResourceClaimTemplates(pod.Namespace).Get(*name)
+		if err != nil {
+			return "", err
+		}
+		return template.Spec.Spec.ResourceClassName, nil
+	}
+	claim, err := apis.ResourceClaimInformer.Lister().ResourceClaims(pod.Namespace).Get(podClaimName(pod, podClaim))
+	if err != nil {
+		return "", err
+	}
+	return claim.Spec.ResourceClassName, nil
+}
+
+// checkPodClaims checks that the ResourceClaims of the pod can be used on the node: an allocated claim must be
+// available on the node and not in use by another pod, the drivers of claims that are not allocated must not have
+// reported the node as unsuitable. Claims that do not exist yet are not checked.
+// Must be called while holding the context lock.
+func (ctx *Context) checkPodClaims(pod *v1.Pod, node *v1.Node) error {
+	apis := ctx.apiProvider.GetAPIs()
+	if len(pod.Spec.ResourceClaims) == 0 || apis.ResourceClaimInformer == nil {
+		return nil
+	}
+	var schedulingCtx *resourcev1alpha2.PodSchedulingContext
+	if apis.PodSchedulingContextInformer != nil {
+		if sc, err := apis.PodSchedulingContextInformer.Lister().PodSchedulingContexts(pod.Namespace).Get(pod.Name); err == nil && metav1.IsControlledBy(sc, pod) {
+			schedulingCtx = sc
+		}
+	}
+	for i := range pod.Spec.ResourceClaims {
+		podClaim := &pod.Spec.ResourceClaims[i]
+		if isUnsuitableNode(schedulingCtx, podClaim.Name, node.Name) {
+			return fmt.Errorf("%w: resource claim %s is not available on node %s", errClaimUnsuitable, podClaimName(pod, podClaim), node.Name)
+		}
+		claim, err := apis.ResourceClaimInformer.Lister().ResourceClaims(pod.Namespace).Get(podClaimName(pod, podClaim))
+		if err != nil {
+			continue
+		}
+		if err = checkClaimNode(claim, pod, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isUnsuitableNode returns true if the driver of the claim reported the node as unsuitable in the scheduling context
+func isUnsuitableNode(schedulingCtx *resourcev1alpha2.PodSchedulingContext, podClaimName, nodeName string) bool {
+	if schedulingCtx == nil {
+		return false
+	}
+	for _, status := range schedulingCtx.Status.ResourceClaims {
+		if status.Name != podClaimName {
+			continue
+		}
+		for _, unsuitable := range status.UnsuitableNodes {
+			if unsuitable == nodeName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkClaimNode checks that the claim, if it is allocated, can be used by the pod on the node
+func checkClaimNode(claim *resourcev1alpha2.ResourceClaim, pod *v1.Pod, node *v1.Node) error {
+	allocation := claim.Status.Allocation
+	if allocation == nil || claim.Status.DeallocationRequested {
+		return nil
+	}
+	if allocation.AvailableOnNodes != nil && node != nil {
+		selector, err := nodeaffinity.NewNodeSelector(allocation.AvailableOnNodes)
+		if err != nil {
+			return err
+		}
+		if !selector.Match(node) {
+			return fmt.Errorf("%w: resource claim %s is not available on node %s", errClaimUnsuitable, claim.Name, node.Name)
+		}
+	}
+	for _, consumer := range claim.Status.ReservedFor {
+		if consumer.UID == pod.UID {
+			return nil
+		}
+	}
+	if len(claim.Status.ReservedFor) > 0 && !allocation.Shareable {
+		return fmt.Errorf("%w: resource claim %s is in use by another consumer", errClaimUnsuitable, claim.Name)
+	}
+	if len(claim.Status.ReservedFor) >= resourcev1alpha2.ResourceClaimReservedForMaxSize {
+		return fmt.Errorf("%w: resource claim %s has reached the maximum number of consumers", errClaimUnsuitable, claim.Name)
+	}
+	return nil
+}
+
+// reservePodClaims reserves the ResourceClaims of the pod for the pod, the kubelet only prepares reserved claims.
+// Claims allocated in WaitForFirstConsumer mode are not allocated by the driver until a node has been selected:
+// the node is published in the PodSchedulingContext of the pod and errClaimNotAllocated is returned until the
+// driver has allocated all claims, errClaimUnsuitable is returned if a claim cannot be used on the node.
+// The reservations are removed by the resource claim controller once the pod is gone.
+func (ctx *Context) reservePodClaims(pod *v1.Pod, nodeName string) error {
+	if len(pod.Spec.ResourceClaims) == 0 || ctx.apiProvider.GetAPIs().ResourceClaimInformer == nil {
+		return nil
+	}
+	var node *v1.Node
+	if nodeInfo := ctx.schedulerCache.GetNode(nodeName); nodeInfo != nil {
+		node = nodeInfo.Node()
+	}
+	clientSet := ctx.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	var pending, selectNode bool
+	for i := range pod.Spec.ResourceClaims {
+		podClaim := &pod.Spec.ResourceClaims[i]
+		name := podClaimName(pod, podClaim)
+		claim, err := clientSet.ResourceV1alpha2().ResourceClaims(pod.Namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if podClaim.Source.ResourceClaimTemplateName != nil && !metav1.IsControlledBy(claim, pod) {
+			return fmt.Errorf("resource claim %s was not created for pod %s", name, pod.Name)
+		}
+		if claim.Status.Allocation == nil || claim.Status.DeallocationRequested {
+			pending = true
+			selectNode = selectNode || claim.Spec.AllocationMode == resourcev1alpha2.AllocationModeWaitForFirstConsumer
+			continue
+		}
+		if err = reserveClaim(clientSet, claim, pod, node); err != nil {
+			return err
+		}
+	}
+	if selectNode {
+		if err := selectSchedulingNode(clientSet, pod, nodeName); err != nil {
+			return err
+		}
+	}
+	if pending {
+		return errClaimNotAllocated
+	}
+	return nil
+}
+
+// reserveClaim adds the pod to the consumers of the allocated claim, if the claim can be used on the node
+func reserveClaim(clientSet kubernetes.Interface, claim *resourcev1alpha2.ResourceClaim, pod *v1.Pod, node *v1.Node) error {
+	for _, consumer := range claim.Status.ReservedFor {
+		if consumer.UID == pod.UID {
+			return nil
+		}
+	}
+	if err := checkClaimNode(claim, pod, node); err != nil {
+		return err
+	}
+	claim = claim.DeepCopy()
+	claim.Status.ReservedFor = append(claim.Status.ReservedFor, resourcev1alpha2.ResourceClaimConsumerReference{
+		Resource: "pods",
+		Name:     pod.Name,
+		UID:      pod.UID,
+	})
+	_, err := clientSet.ResourceV1alpha2().ResourceClaims(claim.Namespace).UpdateStatus(context.Background(), claim, metav1.UpdateOptions{})
+	return err
+}
+
+// selectSchedulingNode sets the selected node in the PodSchedulingContext of the pod, which triggers the allocation
+// of the WaitForFirstConsumer claims of the pod by their drivers. errClaimUnsuitable is returned once a driver
+// reported the node as unsuitable for a claim of the pod.
+func selectSchedulingNode(clientSet kubernetes.Interface, pod *v1.Pod, nodeName string) error {
+	contexts := clientSet.ResourceV1alpha2().PodSchedulingContexts(pod.Namespace)
+	schedulingCtx, err := contexts.Get(context.Background(), pod.Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		schedulingCtx = &resourcev1alpha2.PodSchedulingContext{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            pod.Name,
+				Namespace:       pod.Namespace,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(pod, v1.SchemeGroupVersion.WithKind("Pod"))},
+			},
+			Spec: resourcev1alpha2.PodSchedulingContextSpec{
+				SelectedNode:   nodeName,
+				PotentialNodes: []string{nodeName},
+			},
+		}
+		_, err = contexts.Create(context.Background(), schedulingCtx, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(schedulingCtx, pod) {
+		return fmt.Errorf("pod scheduling context %s was not created for pod %s", schedulingCtx.Name, pod.Name)
+	}
+	for i := range pod.Spec.ResourceClaims {
+		podClaim := &pod.Spec.ResourceClaims[i]
+		if isUnsuitableNode(schedulingCtx, podClaim.Name, nodeName) {
+			return fmt.Errorf("%w: resource claim %s cannot be allocated on node %s", errClaimUnsuitable, podClaimName(pod, podClaim), nodeName)
+		}
+	}
+	if schedulingCtx.Spec.SelectedNode == nodeName {
+		return nil
+	}
+	schedulingCtx = schedulingCtx.DeepCopy()
+	schedulingCtx.Spec.SelectedNode = nodeName
+	schedulingCtx.Spec.PotentialNodes = []string{nodeName}
+	_, err = contexts.Update(context.Background(), schedulingCtx, metav1.UpdateOptions{})
+	return err
+}
+
+// reservePodClaimsWithRetry reserves the ResourceClaims of the pod of the task, waiting for the drivers to allocate
+// the claims up to the volume bind timeout. The task lock must be held by the caller: the lock is released while
+// waiting. The wait stops with errBindCancelled if the task is no longer allocated.
+func (task *Task) reservePodClaimsWithRetry() error {
+	deadline := time.Now().Add(conf.GetSchedulerConf().VolumeBindTimeout)
+	backoff := conf.GetSchedulerConf().GetBindRetryBackoff()
+	for {
+		err := task.context.reservePodClaims(task.pod, task.nodeName)
+		if err == nil || (!errors.Is(err, errClaimNotAllocated) && !isRetryableBindError(err)) {
+			return err
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		log.Log(log.ShimCacheTask).Debug("waiting for resource claims of pod",
+			zap.String("podName", task.pod.Name),
+			zap.String("nodeName", task.nodeName),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		task.lock.Unlock()
+		time.Sleep(backoff)
+		task.lock.Lock()
+		if state := task.sm.Current(); state != TaskStates().Allocated {
+			return fmt.Errorf("%w: state %s", errBindCancelled, state)
+		}
+		backoff *= 2
+		if backoff > maxBindRetryBackoff {
+			backoff = maxBindRetryBackoff
+		}
+	}
+}
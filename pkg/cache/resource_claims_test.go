@@ -0,0 +1,223 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+const gpuClass = "gpu.example.com"
+
+// initContextWithClaimsForTest enables the DRA informers of the test context, the objects are added to the
+// informer caches as well as the fake client set
+func initContextWithClaimsForTest(t *testing.T, objects ...interface{}) *Context {
+	ctx := initContextForTest()
+	clientSet := ctx.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	factory := informers.NewSharedInformerFactory(clientSet, 0)
+	claimInformer := factory.Resource().V1alpha2().ResourceClaims()
+	templateInformer := factory.Resource().V1alpha2().ResourceClaimTemplates()
+	schedulingCtxInformer := factory.Resource().V1alpha2().PodSchedulingContexts()
+	ctx.apiProvider.GetAPIs().ResourceClaimInformer = claimInformer
+	ctx.apiProvider.GetAPIs().ResourceClaimTemplateInformer = templateInformer
+	ctx.apiProvider.GetAPIs().PodSchedulingContextInformer = schedulingCtxInformer
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *resourcev1alpha2.ResourceClaim:
+			assert.NilError(t, claimInformer.Informer().GetIndexer().Add(o))
+			_, err := clientSet.ResourceV1alpha2().ResourceClaims(o.Namespace).Create(context.Background(), o, apis.CreateOptions{})
+			assert.NilError(t, err)
+		case *resourcev1alpha2.ResourceClaimTemplate:
+			assert.NilError(t, templateInformer.Informer().GetIndexer().Add(o))
+		case *resourcev1alpha2.PodSchedulingContext:
+			assert.NilError(t, schedulingCtxInformer.Informer().GetIndexer().Add(o))
+			_, err := clientSet.ResourceV1alpha2().PodSchedulingContexts(o.Namespace).Create(context.Background(), o, apis.CreateOptions{})
+			assert.NilError(t, err)
+		}
+	}
+	return ctx
+}
+
+func newClaimForTest(name string, allocated bool) *resourcev1alpha2.ResourceClaim {
+	claim := &resourcev1alpha2.ResourceClaim{
+		ObjectMeta: apis.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: resourcev1alpha2.ResourceClaimSpec{
+			ResourceClassName: gpuClass,
+			AllocationMode:    resourcev1alpha2.AllocationModeWaitForFirstConsumer,
+		},
+	}
+	if allocated {
+		claim.Status.Allocation = &resourcev1alpha2.AllocationResult{}
+	}
+	return claim
+}
+
+func newClaimPodForTest(claimNames ...string) *v1.Pod {
+	pod := newPodHelper("pod-1", "default", "uid-1", "", "app-1", v1.PodPending)
+	for i := range claimNames {
+		pod.Spec.ResourceClaims = append(pod.Spec.ResourceClaims, v1.PodResourceClaim{
+			Name:   "claim",
+			Source: v1.ClaimSource{ResourceClaimName: &claimNames[i]},
+		})
+	}
+	return pod
+}
+
+func TestGetPodClaimResource(t *testing.T) {
+	templateName := "gpu-template"
+	template := &resourcev1alpha2.ResourceClaimTemplate{
+		ObjectMeta: apis.ObjectMeta{Name: templateName, Namespace: "default"},
+		Spec: resourcev1alpha2.ResourceClaimTemplateSpec{
+			Spec: resourcev1alpha2.ResourceClaimSpec{ResourceClassName: gpuClass},
+		},
+	}
+	pod := newClaimPodForTest("gpu-claim", "unknown-claim")
+	pod.Spec.ResourceClaims = append(pod.Spec.ResourceClaims, v1.PodResourceClaim{
+		Name:   "template",
+		Source: v1.ClaimSource{ResourceClaimTemplateName: &templateName},
+	})
+
+	// informers not set: claims are not counted
+	ctx := initContextForTest()
+	assert.Assert(t, ctx.getPodClaimResource(pod) == nil, "claims should not be counted without DRA")
+
+	ctx = initContextWithClaimsForTest(t, newClaimForTest("gpu-claim", false), template)
+	res := ctx.getPodClaimResource(pod)
+	assert.Assert(t, res != nil, "claims should be counted")
+	assert.Equal(t, len(res.Resources), 1, "unexpected resource types")
+	assert.Equal(t, res.Resources[constants.ResourceClaimPrefix+gpuClass].Value, int64(2), "unknown claim should not be counted")
+	assert.Assert(t, ctx.getPodClaimResource(newClaimPodForTest()) == nil, "pod without claims")
+	assert.Assert(t, !ctx.claimClasses.add(gpuClass), "class of the claims should be known")
+}
+
+func TestClaimClasses(t *testing.T) {
+	classes := newClaimClasses()
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: "node-1"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		},
+	}
+	assert.Equal(t, classes.applyCapacity(node), node, "node without classes should not be changed")
+
+	assert.Assert(t, classes.add(gpuClass), "class should be added")
+	assert.Assert(t, !classes.add(gpuClass), "class should be added once")
+	updated := classes.applyCapacity(node)
+	assert.Equal(t, len(node.Status.Allocatable), 1, "original node should not be changed")
+	quantity := updated.Status.Allocatable[v1.ResourceName(constants.ResourceClaimPrefix+gpuClass)]
+	assert.Equal(t, quantity.Value(), int64(claimNodeCapacity))
+	assert.Equal(t, updated.Status.Allocatable.Cpu().MilliValue(), int64(1000))
+}
+
+func TestCheckPodClaims(t *testing.T) {
+	node := &v1.Node{ObjectMeta: apis.ObjectMeta{Name: "node-1", Labels: map[string]string{"gpu": "true"}}}
+	other := &v1.Node{ObjectMeta: apis.ObjectMeta{Name: "node-2"}}
+
+	// claim allocated on the nodes with a GPU
+	allocated := newClaimForTest("gpu-claim", true)
+	allocated.Status.Allocation.AvailableOnNodes = &v1.NodeSelector{
+		NodeSelectorTerms: []v1.NodeSelectorTerm{{
+			MatchExpressions: []v1.NodeSelectorRequirement{{Key: "gpu", Operator: v1.NodeSelectorOpIn, Values: []string{"true"}}},
+		}},
+	}
+	ctx := initContextWithClaimsForTest(t, allocated)
+	pod := newClaimPodForTest("gpu-claim")
+	assert.NilError(t, ctx.checkPodClaims(pod, node))
+	err := ctx.checkPodClaims(pod, other)
+	assert.Assert(t, errors.Is(err, errClaimUnsuitable), "unexpected error: %v", err)
+	assert.NilError(t, ctx.checkPodClaims(newClaimPodForTest("missing"), other), "missing claim should not be checked")
+
+	// claim reserved by another pod
+	reserved := newClaimForTest("reserved-claim", true)
+	reserved.Status.ReservedFor = []resourcev1alpha2.ResourceClaimConsumerReference{{Resource: "pods", Name: "pod-2", UID: "uid-2"}}
+	ctx = initContextWithClaimsForTest(t, reserved)
+	err = ctx.checkPodClaims(newClaimPodForTest("reserved-claim"), node)
+	assert.Assert(t, errors.Is(err, errClaimUnsuitable), "unexpected error: %v", err)
+
+	// driver reported the node as unsuitable for the claim that is not allocated
+	pod = newClaimPodForTest("pending-claim")
+	schedulingCtx := &resourcev1alpha2.PodSchedulingContext{
+		ObjectMeta: apis.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			OwnerReferences: []apis.OwnerReference{*apis.NewControllerRef(pod, v1.SchemeGroupVersion.WithKind("Pod"))},
+		},
+		Spec: resourcev1alpha2.PodSchedulingContextSpec{SelectedNode: "node-1"},
+		Status: resourcev1alpha2.PodSchedulingContextStatus{
+			ResourceClaims: []resourcev1alpha2.ResourceClaimSchedulingStatus{{Name: "claim", UnsuitableNodes: []string{"node-1"}}},
+		},
+	}
+	ctx = initContextWithClaimsForTest(t, newClaimForTest("pending-claim", false), schedulingCtx)
+	err = ctx.checkPodClaims(pod, node)
+	assert.Assert(t, errors.Is(err, errClaimUnsuitable), "unexpected error: %v", err)
+	assert.NilError(t, ctx.checkPodClaims(pod, other))
+
+	// the node is not used to allocate the claim
+	err = ctx.reservePodClaims(pod, "node-1")
+	assert.Assert(t, errors.Is(err, errClaimUnsuitable), "unexpected error: %v", err)
+	err = ctx.reservePodClaims(pod, "node-2")
+	assert.Assert(t, errors.Is(err, errClaimNotAllocated), "unexpected error: %v", err)
+}
+
+func TestReservePodClaims(t *testing.T) {
+	// allocated claim is reserved for the pod once
+	ctx := initContextWithClaimsForTest(t, newClaimForTest("gpu-claim", true))
+	pod := newClaimPodForTest("gpu-claim")
+	clientSet := ctx.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	assert.NilError(t, ctx.reservePodClaims(pod, "node-1"))
+	assert.NilError(t, ctx.reservePodClaims(pod, "node-1"))
+	claim, err := clientSet.ResourceV1alpha2().ResourceClaims("default").Get(context.Background(), "gpu-claim", apis.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(claim.Status.ReservedFor), 1, "claim should be reserved once")
+	assert.Equal(t, claim.Status.ReservedFor[0].UID, pod.UID, "claim reserved for wrong consumer")
+
+	// claim reserved by another pod cannot be shared
+	other := newClaimPodForTest("gpu-claim")
+	other.UID = types.UID("uid-2")
+	assert.ErrorContains(t, ctx.reservePodClaims(other, "node-1"), "in use by another consumer")
+
+	// unallocated claim selects the node in the scheduling context
+	ctx = initContextWithClaimsForTest(t, newClaimForTest("pending-claim", false))
+	pod = newClaimPodForTest("pending-claim")
+	clientSet = ctx.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	err = ctx.reservePodClaims(pod, "node-1")
+	assert.Assert(t, errors.Is(err, errClaimNotAllocated), "unexpected error: %v", err)
+	schedulingCtx, err := clientSet.ResourceV1alpha2().PodSchedulingContexts("default").Get(context.Background(), pod.Name, apis.GetOptions{})
+	assert.NilError(t, err, "scheduling context not created")
+	assert.Equal(t, schedulingCtx.Spec.SelectedNode, "node-1")
+	err = ctx.reservePodClaims(pod, "node-2")
+	assert.Assert(t, errors.Is(err, errClaimNotAllocated), "unexpected error: %v", err)
+	schedulingCtx, err = clientSet.ResourceV1alpha2().PodSchedulingContexts("default").Get(context.Background(), pod.Name, apis.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, schedulingCtx.Spec.SelectedNode, "node-2", "selected node not updated")
+
+	// missing claim
+	assert.ErrorContains(t, ctx.reservePodClaims(newClaimPodForTest("missing"), "node-1"), "not found")
+}
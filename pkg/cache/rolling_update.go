@@ -0,0 +1,84 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"math"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// isRollingUpdatePod returns true if the pod replaces a pod of a service application during a rolling update.
+// All ReplicaSets of a Deployment share the application, the update is in progress while a pod of the
+// application created from a different pod template is still bound. The surge pods of the update are part
+// of the same application as the pods they replace and are accounted against the same queue quota.
+func (app *Application) isRollingUpdatePod(pod *v1.Pod) bool {
+	if !app.isService() {
+		return false
+	}
+	hash := utils.GetPodLabelValue(pod, appsv1.DefaultDeploymentUniqueLabelKey)
+	if hash == "" {
+		return false
+	}
+	for _, task := range app.GetBoundTasks() {
+		boundHash := utils.GetPodLabelValue(task.GetTaskPod(), appsv1.DefaultDeploymentUniqueLabelKey)
+		if boundHash != "" && boundHash != hash {
+			return true
+		}
+	}
+	return false
+}
+
+// markRollingUpdate flags the task if its pod replaces a pod of the application during a rolling update.
+// The application marks the task before it is submitted: the submit runs with the task lock held and must not
+// take the application lock, the application takes the task locks while holding its own lock.
+func (app *Application) markRollingUpdate(task *Task) {
+	if conf.GetSchedulerConf().GetRollingUpdatePriorityBoost() <= 0 {
+		return
+	}
+	task.setRollingUpdate(app.isRollingUpdatePod(task.GetTaskPod()))
+}
+
+// getRollingUpdatePriority returns the priority of the ask for the task. The configured boost is added to
+// the priority of pods that replace the pods of a service during a rolling update. In a full queue the
+// replacement pods are scheduled before the pods of new applications. This prevents a deadlock where the
+// rolling update cannot progress because its surge pods never fit.
+// The boost only changes the order in the queue: surge pods are accounted against the queue quota like any
+// other pod, the quota is not raised for the duration of the update. Must be called with the task lock held.
+func (task *Task) getRollingUpdatePriority(priority int32) int32 {
+	boost := conf.GetSchedulerConf().GetRollingUpdatePriorityBoost()
+	if boost <= 0 || !task.rollingUpdate {
+		return priority
+	}
+	log.Log(log.ShimCacheTask).Info("boosting priority of rolling update pod",
+		zap.String("appID", task.applicationID),
+		zap.String("podName", task.alias),
+		zap.Int32("priority", priority),
+		zap.Int("boost", boost))
+	if int64(priority)+int64(boost) > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return priority + int32(boost)
+}
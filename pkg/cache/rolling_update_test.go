@@ -0,0 +1,101 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"math"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func newRollingUpdatePod(name, hash string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("UID-" + name),
+			Labels:    map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+		},
+	}
+}
+
+func TestIsRollingUpdatePod(t *testing.T) {
+	mockedContext := initContextForTest()
+	serviceTags := map[string]string{constants.AnnotationApplicationProfile: constants.ApplicationProfileService}
+
+	// batch application
+	app := NewApplication("app01", "root.default", "bob", testGroups, map[string]string{}, newMockSchedulerAPI())
+	bound := NewTask("task01", app, mockedContext, newRollingUpdatePod("pod-old", "old"))
+	bound.sm.SetState(TaskStates().Bound)
+	app.addTask(bound)
+	assert.Assert(t, !app.isRollingUpdatePod(newRollingUpdatePod("pod-new", "new")), "batch application pod should not be a rolling update pod")
+
+	// service application
+	app = NewApplication("app02", "root.default", "bob", testGroups, serviceTags, newMockSchedulerAPI())
+	assert.Assert(t, !app.isRollingUpdatePod(newRollingUpdatePod("pod-new", "new")), "no bound pods: not a rolling update pod")
+	pending := NewTask("task01", app, mockedContext, newRollingUpdatePod("pod-pending", "old"))
+	app.addTask(pending)
+	assert.Assert(t, !app.isRollingUpdatePod(newRollingUpdatePod("pod-new", "new")), "pending pods only: not a rolling update pod")
+	bound = NewTask("task02", app, mockedContext, newRollingUpdatePod("pod-old", "old"))
+	bound.sm.SetState(TaskStates().Bound)
+	app.addTask(bound)
+	assert.Assert(t, !app.isRollingUpdatePod(newRollingUpdatePod("pod-scale", "old")), "same template: not a rolling update pod")
+	assert.Assert(t, !app.isRollingUpdatePod(&v1.Pod{}), "pod without template hash should not be a rolling update pod")
+	assert.Assert(t, app.isRollingUpdatePod(newRollingUpdatePod("pod-new", "new")), "new template: rolling update pod")
+}
+
+func TestGetRollingUpdatePriority(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	defer conf.SetSchedulerConf(schedulerConf)
+
+	mockedContext := initContextForTest()
+	app := NewApplication("app01", "root.default", "bob", testGroups,
+		map[string]string{constants.AnnotationApplicationProfile: constants.ApplicationProfileService}, newMockSchedulerAPI())
+	bound := NewTask("task01", app, mockedContext, newRollingUpdatePod("pod-old", "old"))
+	bound.sm.SetState(TaskStates().Bound)
+	app.addTask(bound)
+	task := NewTask("task02", app, mockedContext, newRollingUpdatePod("pod-new", "new"))
+	app.addTask(task)
+	scale := NewTask("task03", app, mockedContext, newRollingUpdatePod("pod-scale", "old"))
+	app.addTask(scale)
+
+	// disabled by default
+	app.markRollingUpdate(task)
+	assert.Assert(t, !task.rollingUpdate, "task should not be marked when the boost is disabled")
+	assert.Equal(t, task.getRollingUpdatePriority(10), int32(10))
+
+	testConf := schedulerConf.Clone()
+	testConf.RollingUpdatePriorityBoost = 100
+	conf.SetSchedulerConf(testConf)
+	assert.Equal(t, task.getRollingUpdatePriority(10), int32(10), "unmarked task should not be boosted")
+	app.markRollingUpdate(task)
+	app.markRollingUpdate(scale)
+	assert.Assert(t, task.rollingUpdate, "replacement task should be marked")
+	assert.Equal(t, task.getRollingUpdatePriority(10), int32(110))
+	assert.Equal(t, task.getRollingUpdatePriority(math.MaxInt32-10), int32(math.MaxInt32))
+	assert.Equal(t, scale.getRollingUpdatePriority(10), int32(10))
+}
@@ -0,0 +1,204 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// shadowDecision is the simulated decision for a pending pod of the default scheduler
+type shadowDecision struct {
+	queue string
+	nodes map[string]bool // nodes the pod would fit on
+}
+
+// shadowScheduler simulates the decisions for the pods of the default scheduler without binding anything. When a
+// pending pod is seen the queue it would be submitted to and the nodes it would fit on are recorded, the node the
+// default scheduler binds the pod to is compared with the recorded nodes. The results are only exported as metrics,
+// which gives operators evidence of the behaviour before the pods are switched to YuniKorn.
+// The queue is the queue the shim would submit the application to, the placement rules of the core may still
+// change it. A pod fits a node if it passes the predicates that do not depend on resources and its requests fit in
+// the capacity of the node that is not occupied by other pods.
+type shadowScheduler struct {
+	ctx       *Context
+	decisions map[types.UID]*shadowDecision // pending pods
+	lock      sync.Mutex
+}
+
+func newShadowScheduler(ctx *Context) *shadowScheduler {
+	return &shadowScheduler{
+		ctx:       ctx,
+		decisions: make(map[types.UID]*shadowDecision),
+	}
+}
+
+// filterPods selects the pods of the default scheduler
+func (s *shadowScheduler) filterPods(obj interface{}) bool {
+	switch obj := obj.(type) {
+	case *v1.Pod:
+		return obj.Spec.SchedulerName == v1.DefaultSchedulerName
+	case k8sCache.DeletedFinalStateUnknown:
+		return s.filterPods(obj.Obj)
+	default:
+		return false
+	}
+}
+
+func (s *shadowScheduler) addPod(obj interface{}) {
+	pod, err := utils.Convert2Pod(obj)
+	if err != nil {
+		log.Log(log.ShimContext).Error("failed to evaluate pod in shadow mode", zap.Error(err))
+		return
+	}
+	// pods that are already assigned when they are first seen cannot be compared
+	if !utils.IsAssignedPod(pod) && !utils.IsPodTerminated(pod) {
+		s.evaluate(pod)
+	}
+}
+
+func (s *shadowScheduler) updatePod(oldObj, newObj interface{}) {
+	oldPod, err := utils.Convert2Pod(oldObj)
+	if err != nil {
+		log.Log(log.ShimContext).Error("failed to evaluate pod in shadow mode", zap.Error(err))
+		return
+	}
+	newPod, err := utils.Convert2Pod(newObj)
+	if err != nil {
+		log.Log(log.ShimContext).Error("failed to evaluate pod in shadow mode", zap.Error(err))
+		return
+	}
+	if !utils.IsAssignedPod(oldPod) && utils.IsAssignedPod(newPod) {
+		s.placed(newPod)
+	}
+}
+
+func (s *shadowScheduler) deletePod(obj interface{}) {
+	var pod *v1.Pod
+	switch t := obj.(type) {
+	case *v1.Pod:
+		pod = t
+	case k8sCache.DeletedFinalStateUnknown:
+		var ok bool
+		if pod, ok = t.Obj.(*v1.Pod); !ok {
+			return
+		}
+	default:
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.decisions, pod.UID)
+}
+
+// evaluate records the simulated decision for the pending pod
+func (s *shadowScheduler) evaluate(pod *v1.Pod) {
+	decision := &shadowDecision{
+		queue: utils.GetQueueNameFromPod(pod),
+		nodes: s.ctx.shadowFitNodes(pod),
+	}
+	result := metrics.ShadowFit
+	if len(decision.nodes) == 0 {
+		result = metrics.ShadowNoFit
+	}
+	metrics.IncShadowPod(result, decision.queue)
+	log.Log(log.ShimContext).Debug("pod evaluated in shadow mode",
+		zap.String("namespace", pod.Namespace),
+		zap.String("podName", pod.Name),
+		zap.String("queue", decision.queue),
+		zap.Int("fitNodes", len(decision.nodes)))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.decisions[pod.UID] = decision
+}
+
+// placed compares the node the pod is bound to with the simulated decision
+func (s *shadowScheduler) placed(pod *v1.Pod) {
+	s.lock.Lock()
+	decision, ok := s.decisions[pod.UID]
+	delete(s.decisions, pod.UID)
+	s.lock.Unlock()
+	if !ok {
+		return
+	}
+	result := metrics.ShadowAgree
+	if !decision.nodes[pod.Spec.NodeName] {
+		result = metrics.ShadowDisagree
+		log.Log(log.ShimContext).Info("default scheduler placed pod on a node it would not fit on in shadow mode",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("nodeName", pod.Spec.NodeName),
+			zap.Int("fitNodes", len(decision.nodes)))
+	}
+	metrics.IncShadowPlacement(result)
+}
+
+// shadowFitNodes returns the ready nodes the pod fits on, using the predicates that do not depend on the resources
+// of the node and the capacity of the node that is not occupied.
+func (ctx *Context) shadowFitNodes(pod *v1.Pod) map[string]bool {
+	request := common.GetPodResource(pod)
+	ctx.nodes.lock.RLock()
+	nodes := make([]*SchedulerNode, 0, len(ctx.nodes.nodesMap))
+	for _, node := range ctx.nodes.nodesMap {
+		nodes = append(nodes, node)
+	}
+	ctx.nodes.lock.RUnlock()
+
+	result := make(map[string]bool)
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	for _, node := range nodes {
+		capacity, occupied, ready := node.snapshotState()
+		if !ready || !fitsIn(request, common.Sub(capacity, occupied)) {
+			continue
+		}
+		nodeInfo := ctx.schedulerCache.GetNode(node.name)
+		if nodeInfo == nil {
+			continue
+		}
+		ctx.schedulerCache.LockForReads()
+		_, err := ctx.predManager.Predicates(pod, nodeInfo, false)
+		ctx.schedulerCache.UnlockForReads()
+		if err == nil {
+			result[node.name] = true
+		}
+	}
+	return result
+}
+
+// fitsIn returns true if each requested resource is available
+func fitsIn(request, available *si.Resource) bool {
+	for name, quantity := range request.GetResources() {
+		if quantity.GetValue() > available.GetResources()[name].GetValue() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+)
+
+func shadowNodeForTest(name, cpu string, taints []v1.Taint) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: name, UID: types.UID("uid-" + name)},
+		Spec:       v1.NodeSpec{Taints: taints},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func shadowPodForTest(name, cpu, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid-" + name),
+			Labels:    map[string]string{constants.LabelQueueName: "root.shadow"},
+		},
+		Spec: v1.PodSpec{
+			SchedulerName: v1.DefaultSchedulerName,
+			NodeName:      nodeName,
+			Containers: []v1.Container{{
+				Name: "main",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+				},
+			}},
+		},
+	}
+}
+
+func shadowMetric(t *testing.T, get func(string) (int, error), label string) int {
+	value, err := get(label)
+	assert.NilError(t, err)
+	return value
+}
+
+func TestShadowSchedulerFilterPods(t *testing.T) {
+	shadow := newShadowScheduler(initContextForTest())
+	pod := shadowPodForTest("pod-1", "1", "")
+	assert.Assert(t, shadow.filterPods(pod), "default scheduler pod not selected")
+	assert.Assert(t, shadow.filterPods(k8sCache.DeletedFinalStateUnknown{Obj: pod}), "deleted pod not selected")
+	pod.Spec.SchedulerName = constants.SchedulerName
+	assert.Assert(t, !shadow.filterPods(pod), "yunikorn pod selected")
+	assert.Assert(t, !shadow.filterPods(&v1.Node{}), "node selected")
+}
+
+func TestShadowSchedulerDecisions(t *testing.T) {
+	ctx := initContextForTest()
+	ctx.addNode(shadowNodeForTest("node-1", "2", nil))
+	ctx.addNode(shadowNodeForTest("node-2", "500m", nil))
+	ctx.addNode(shadowNodeForTest("node-3", "4", []v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}}))
+	shadow := newShadowScheduler(ctx)
+
+	fit := shadowMetric(t, metrics.GetShadowPods, metrics.ShadowFit)
+	noFit := shadowMetric(t, metrics.GetShadowPods, metrics.ShadowNoFit)
+	queue := shadowMetric(t, metrics.GetShadowQueuePods, "root.shadow")
+	agree := shadowMetric(t, metrics.GetShadowPlacements, metrics.ShadowAgree)
+	disagree := shadowMetric(t, metrics.GetShadowPlacements, metrics.ShadowDisagree)
+
+	// only the node with enough resources and without the taint fits
+	pending := shadowPodForTest("pod-1", "1", "")
+	shadow.addPod(pending)
+	assert.DeepEqual(t, shadow.decisions[pending.UID].nodes, map[string]bool{"node-1": true})
+	assert.Equal(t, shadow.decisions[pending.UID].queue, "root.shadow")
+	shadow.updatePod(pending, shadowPodForTest("pod-1", "1", "node-1"))
+	assert.Equal(t, len(shadow.decisions), 0)
+
+	pending = shadowPodForTest("pod-2", "1", "")
+	shadow.addPod(pending)
+	shadow.updatePod(pending, shadowPodForTest("pod-2", "1", "node-3"))
+
+	// a pod that does not fit anywhere
+	pending = shadowPodForTest("pod-3", "8", "")
+	shadow.addPod(pending)
+	assert.Equal(t, len(shadow.decisions[pending.UID].nodes), 0)
+	shadow.deletePod(k8sCache.DeletedFinalStateUnknown{Obj: pending})
+	assert.Equal(t, len(shadow.decisions), 0)
+
+	// pods that are assigned when first seen are not compared
+	assigned := shadowPodForTest("pod-4", "1", "node-1")
+	shadow.addPod(assigned)
+	shadow.updatePod(assigned, assigned)
+	assert.Equal(t, len(shadow.decisions), 0)
+
+	assert.Equal(t, shadowMetric(t, metrics.GetShadowPods, metrics.ShadowFit)-fit, 2)
+	assert.Equal(t, shadowMetric(t, metrics.GetShadowPods, metrics.ShadowNoFit)-noFit, 1)
+	assert.Equal(t, shadowMetric(t, metrics.GetShadowQueuePods, "root.shadow")-queue, 3)
+	assert.Equal(t, shadowMetric(t, metrics.GetShadowPlacements, metrics.ShadowAgree)-agree, 1)
+	assert.Equal(t, shadowMetric(t, metrics.GetShadowPlacements, metrics.ShadowDisagree)-disagree, 1)
+}
@@ -0,0 +1,137 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"time"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache/external"
+)
+
+// SnapshotDao is the internal state of the shim at a point in time
+type SnapshotDao struct {
+	Timestamp    time.Time                  `json:"timestamp"`
+	Applications []ApplicationDao           `json:"applications"`
+	PendingPods  []string                   `json:"pendingPods"`
+	Cache        external.SchedulerCacheDao `json:"cache"`
+}
+
+type ApplicationDao struct {
+	ApplicationID string            `json:"applicationID"`
+	Queue         string            `json:"queue"`
+	Partition     string            `json:"partition"`
+	User          string            `json:"user"`
+	State         string            `json:"state"`
+	Tags          map[string]string `json:"tags"`
+	Tasks         []TaskDao         `json:"tasks"`
+}
+
+type TaskDao struct {
+	TaskID         string    `json:"taskID"`
+	Alias          string    `json:"alias"`
+	State          string    `json:"state"`
+	NodeName       string    `json:"nodeName,omitempty"`
+	AllocationUUID string    `json:"allocationUUID,omitempty"`
+	TaskGroupName  string    `json:"taskGroupName,omitempty"`
+	Placeholder    bool      `json:"placeholder"`
+	Originator     bool      `json:"originator"`
+	CreateTime     time.Time `json:"createTime"`
+}
+
+// GetSnapshot returns the applications, tasks and the scheduler cache of the shim.
+// Pending pods are the pods of the tasks that are waiting for an allocation from the core.
+func (ctx *Context) GetSnapshot() SnapshotDao {
+	applications := ctx.getApplicationDaos()
+	pendingPods := make([]string, 0)
+	for _, app := range applications {
+		for _, task := range app.Tasks {
+			switch task.State {
+			case TaskStates().New, TaskStates().Pending, TaskStates().Scheduling:
+				pendingPods = append(pendingPods, task.Alias)
+			}
+		}
+	}
+	sort.Strings(pendingPods)
+	return SnapshotDao{
+		Timestamp:    time.Now(),
+		Applications: applications,
+		PendingPods:  pendingPods,
+		Cache:        ctx.schedulerCache.GetSchedulerCacheDao(),
+	}
+}
+
+func (ctx *Context) getApplicationDaos() []ApplicationDao {
+	ctx.lock.RLock()
+	apps := make([]*Application, 0, len(ctx.applications))
+	for _, app := range ctx.applications {
+		apps = append(apps, app)
+	}
+	ctx.lock.RUnlock()
+
+	result := make([]ApplicationDao, 0, len(apps))
+	for _, app := range apps {
+		result = append(result, app.getApplicationDao())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ApplicationID < result[j].ApplicationID
+	})
+	return result
+}
+
+func (app *Application) getApplicationDao() ApplicationDao {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	tasks := make([]TaskDao, 0, len(app.taskMap))
+	for _, task := range app.taskMap {
+		tasks = append(tasks, task.getTaskDao())
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreateTime.Before(tasks[j].CreateTime)
+	})
+	tags := make(map[string]string, len(app.tags))
+	for key, value := range app.tags {
+		tags[key] = value
+	}
+	return ApplicationDao{
+		ApplicationID: app.applicationID,
+		Queue:         app.queue,
+		Partition:     app.partition,
+		User:          app.user,
+		State:         app.sm.Current(),
+		Tags:          tags,
+		Tasks:         tasks,
+	}
+}
+
+func (task *Task) getTaskDao() TaskDao {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return TaskDao{
+		TaskID:         task.taskID,
+		Alias:          task.alias,
+		State:          task.sm.Current(),
+		NodeName:       task.nodeName,
+		AllocationUUID: task.allocationUUID,
+		TaskGroupName:  task.taskGroupName,
+		Placeholder:    task.placeholder,
+		Originator:     task.originator,
+		CreateTime:     task.createTime,
+	}
+}
@@ -0,0 +1,205 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	schedulerconf "github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// StuckApplicationCheckInterval is the interval at which the applications are checked for progress
+const StuckApplicationCheckInterval = time.Minute
+
+// appProgress is the state and the number of allocated tasks of an application when it last made progress
+type appProgress struct {
+	state     string
+	allocated int
+	since     time.Time
+	reported  bool
+}
+
+// stuckApps tracks the progress of the applications that wait for resources in the Accepted or Reserving state.
+// An application is stuck if the state and the number of allocated tasks do not change within the timeout.
+// A stuck application is reported once, it is reported again after it made progress and got stuck again.
+type stuckApps struct {
+	progress map[string]*appProgress
+	lock     sync.Mutex
+}
+
+func newStuckApps() *stuckApps {
+	return &stuckApps{
+		progress: make(map[string]*appProgress),
+	}
+}
+
+// update records the progress of the applications and returns the applications that became stuck
+func (s *stuckApps) update(apps []*Application, now time.Time, timeout time.Duration) []*Application {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stuck := make([]*Application, 0)
+	waiting := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		state := app.GetApplicationState()
+		if state != ApplicationStates().Accepted && state != ApplicationStates().Reserving {
+			continue
+		}
+		waiting[app.applicationID] = true
+		allocated := len(app.GetAllocatedTasks()) + len(app.GetBoundTasks())
+		progress, ok := s.progress[app.applicationID]
+		if !ok || progress.state != state || progress.allocated != allocated {
+			s.progress[app.applicationID] = &appProgress{state: state, allocated: allocated, since: now}
+			continue
+		}
+		if !progress.reported && now.Sub(progress.since) >= timeout {
+			progress.reported = true
+			stuck = append(stuck, app)
+		}
+	}
+	for appID := range s.progress {
+		if !waiting[appID] {
+			delete(s.progress, appID)
+		}
+	}
+	return stuck
+}
+
+// CheckStuckApplications reports the applications that stay in the Accepted or Reserving state without allocation
+// progress for longer than the configured timeout. A warning event that explains why the application is waiting is
+// added to the originating pod. If enabled, the application is reset: a reserving application gives up the gang
+// reservation and continues without placeholders, an accepted application retries the transition out of Accepted.
+func (ctx *Context) CheckStuckApplications() {
+	conf := schedulerconf.GetSchedulerConf()
+	timeout := conf.GetStuckApplicationTimeout()
+	if timeout <= 0 {
+		return
+	}
+	ctx.lock.RLock()
+	apps := make([]*Application, 0, len(ctx.applications))
+	for _, app := range ctx.applications {
+		apps = append(apps, app)
+	}
+	ctx.lock.RUnlock()
+
+	for _, app := range ctx.stuckApps.update(apps, time.Now(), timeout) {
+		state := app.GetApplicationState()
+		reason := getStuckReason(app)
+		log.Log(log.ShimContext).Warn("application made no progress",
+			zap.String("appID", app.applicationID),
+			zap.String("state", state),
+			zap.Duration("timeout", timeout),
+			zap.String("reason", reason))
+		if task := app.GetOriginatingTask(); task != nil {
+			events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "ApplicationStuck", "ApplicationStuck",
+				"Application %s made no progress in state %s for %s: %s", app.applicationID, state, timeout, reason)
+		}
+		if conf.IsStuckApplicationReset() {
+			resetStuckApplication(app, state)
+		}
+	}
+}
+
+// getStuckReason explains why the application is waiting, based on the state of the application and the
+// scheduling reason codes of the pods that are not allocated yet
+func getStuckReason(app *Application) string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	codes := make(map[string]bool)
+	for _, task := range app.taskMap {
+		state := task.GetTaskState()
+		if state != TaskStates().New && state != TaskStates().Pending && state != TaskStates().Scheduling {
+			continue
+		}
+		for i := range task.pod.Status.Conditions {
+			condition := &task.pod.Status.Conditions[i]
+			if condition.Type == v1.PodScheduled {
+				if code := utils.GetSchedulingReasonCode(condition); code != "" {
+					codes[code] = true
+				}
+			}
+		}
+	}
+
+	reasons := make([]string, 0)
+	if codes[constants.SchedulingReasonQuotaExceeded] {
+		reasons = append(reasons, fmt.Sprintf("queue %s is full", app.queue))
+	}
+	if codes[constants.SchedulingReasonQueueStopped] {
+		reasons = append(reasons, fmt.Sprintf("queue %s is stopped", app.queue))
+	}
+	if app.sm.Current() == ApplicationStates().Reserving {
+		required := int32(0)
+		for _, tg := range app.taskGroups {
+			required += tg.MinMember
+		}
+		allocated := 0
+		for _, task := range app.getPlaceHolderTasks() {
+			if state := task.GetTaskState(); state == TaskStates().Allocated || state == TaskStates().Bound {
+				allocated++
+			}
+		}
+		reason := fmt.Sprintf("waiting for placeholders, %d of %d allocated", allocated, required)
+		if app.placeholderTimeoutInSec > 0 {
+			reason += fmt.Sprintf(", placeholders time out after %ds", app.placeholderTimeoutInSec)
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 0 {
+		other := make([]string, 0, len(codes))
+		for code := range codes {
+			other = append(other, code)
+		}
+		sort.Strings(other)
+		if len(other) == 0 {
+			reasons = append(reasons, "no allocation for the pending pods")
+		} else {
+			reasons = append(reasons, "no allocation for the pending pods: "+strings.Join(other, ", "))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// resetStuckApplication moves the application out of the state it is stuck in
+func resetStuckApplication(app *Application, state string) {
+	log.Log(log.ShimContext).Info("resetting stuck application",
+		zap.String("appID", app.applicationID),
+		zap.String("state", state))
+	switch state {
+	case ApplicationStates().Reserving:
+		// same as a failed placeholder creation: release the placeholders and run without the reservation
+		go func() {
+			getPlaceholderManager().cleanUp(app)
+			dispatcher.Dispatch(NewRunApplicationEvent(app.applicationID))
+		}()
+	case ApplicationStates().Accepted:
+		app.postAppAccepted()
+	}
+}
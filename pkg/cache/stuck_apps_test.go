@@ -0,0 +1,153 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+func newStuckTestPod(name string, reasonCode string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: name,
+			UID:  types.UID("UID-" + name),
+		},
+	}
+	if reasonCode != "" {
+		pod.Status.Conditions = []v1.PodCondition{{
+			Type:    v1.PodScheduled,
+			Status:  v1.ConditionFalse,
+			Reason:  v1.PodReasonUnschedulable,
+			Message: utils.FormatSchedulingReason(reasonCode, "test"),
+		}}
+	}
+	return pod
+}
+
+func TestStuckAppsUpdate(t *testing.T) {
+	context := initContextForTest()
+	app1 := NewApplication("app-1", "root.a", "user", testGroups, map[string]string{}, nil)
+	app1.sm.SetState(ApplicationStates().Accepted)
+	app2 := NewApplication("app-2", "root.a", "user", testGroups, map[string]string{}, nil)
+	app2.sm.SetState(ApplicationStates().Running)
+	apps := []*Application{app1, app2}
+
+	stuck := newStuckApps()
+	now := time.Now()
+	assert.Equal(t, len(stuck.update(apps, now, time.Minute)), 0)
+	assert.Equal(t, len(stuck.progress), 1, "only waiting applications are tracked")
+	assert.Equal(t, len(stuck.update(apps, now.Add(30*time.Second), time.Minute)), 0)
+	result := stuck.update(apps, now.Add(time.Minute), time.Minute)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result[0], app1)
+	// reported once
+	assert.Equal(t, len(stuck.update(apps, now.Add(2*time.Minute), time.Minute)), 0)
+
+	// progress resets the timer
+	task := NewTask("task-1", app1, context, newStuckTestPod("pod-1", ""))
+	task.sm.SetState(TaskStates().Allocated)
+	app1.addTask(task)
+	assert.Equal(t, len(stuck.update(apps, now.Add(3*time.Minute), time.Minute)), 0)
+	assert.Equal(t, len(stuck.update(apps, now.Add(3*time.Minute+59*time.Second), time.Minute)), 0)
+	assert.Equal(t, len(stuck.update(apps, now.Add(4*time.Minute), time.Minute)), 1)
+
+	// state change resets the timer, applications that are no longer waiting are dropped
+	app1.sm.SetState(ApplicationStates().Reserving)
+	assert.Equal(t, len(stuck.update(apps, now.Add(5*time.Minute), time.Minute)), 0)
+	app1.sm.SetState(ApplicationStates().Running)
+	assert.Equal(t, len(stuck.update(apps, now.Add(6*time.Minute), time.Minute)), 0)
+	assert.Equal(t, len(stuck.progress), 0)
+}
+
+func TestGetStuckReason(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication("app-1", "root.a", "user", testGroups, map[string]string{}, nil)
+	app.sm.SetState(ApplicationStates().Accepted)
+	assert.Equal(t, getStuckReason(app), "no allocation for the pending pods")
+
+	task := NewTask("task-1", app, context, newStuckTestPod("pod-1", constants.SchedulingReasonPredicateFailed))
+	task.sm.SetState(TaskStates().Pending)
+	app.addTask(task)
+	assert.Equal(t, getStuckReason(app), "no allocation for the pending pods: PREDICATE_FAILED")
+
+	task = NewTask("task-2", app, context, newStuckTestPod("pod-2", constants.SchedulingReasonQuotaExceeded))
+	task.sm.SetState(TaskStates().Scheduling)
+	app.addTask(task)
+	assert.Equal(t, getStuckReason(app), "queue root.a is full")
+
+	// allocated tasks are ignored
+	task.sm.SetState(TaskStates().Allocated)
+	app.taskGroups = []v1alpha1.TaskGroup{{Name: "tg", MinMember: 2}}
+	app.placeholderTimeoutInSec = 60
+	placeholder := NewTaskPlaceholder("ph-1", app, context, newStuckTestPod("ph-1", ""))
+	placeholder.sm.SetState(TaskStates().Bound)
+	app.addTask(placeholder)
+	app.sm.SetState(ApplicationStates().Reserving)
+	assert.Equal(t, getStuckReason(app), "waiting for placeholders, 1 of 2 allocated, placeholders time out after 60s")
+}
+
+func TestCheckStuckApplications(t *testing.T) {
+	var eventCount int32
+	recorder := events.NewMockedRecorder()
+	recorder.OnEventf = func() {
+		atomic.AddInt32(&eventCount, 1)
+	}
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	orig := conf.GetSchedulerConf()
+	testConf := orig.Clone()
+	testConf.StuckApplicationTimeout = 0
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(orig)
+
+	context := initContextForTest()
+	app := NewApplication("app-1", "root.a", "user", testGroups, map[string]string{}, nil)
+	app.sm.SetState(ApplicationStates().Accepted)
+	task := NewTask("task-1", app, context, newStuckTestPod("pod-1", ""))
+	app.addTask(task)
+	app.setOriginatingTask(task)
+	context.applications[app.applicationID] = app
+
+	// disabled
+	context.CheckStuckApplications()
+	assert.Equal(t, len(context.stuckApps.progress), 0)
+
+	testConf.StuckApplicationTimeout = time.Nanosecond
+	context.CheckStuckApplications()
+	assert.Equal(t, atomic.LoadInt32(&eventCount), int32(0))
+	time.Sleep(time.Millisecond)
+	context.CheckStuckApplications()
+	assert.Equal(t, atomic.LoadInt32(&eventCount), int32(1))
+	context.CheckStuckApplications()
+	assert.Equal(t, atomic.LoadInt32(&eventCount), int32(1), "stuck application must be reported once")
+}
@@ -20,6 +20,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -31,15 +32,22 @@ import (
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
 	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/eventstream"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
+// resizeTimeout is the time the core has to allocate the resized resource of a bound task on its node.
+var resizeTimeout = 2 * time.Minute
+
 type Task struct {
 	taskID          string
 	alias           string
@@ -52,11 +60,16 @@ type Task struct {
 	context         *Context
 	nodeName        string
 	createTime      time.Time
+	stateTime       time.Time // time the task entered the current state
 	taskGroupName   string
 	placeholder     bool
 	terminationType string
 	pluginMode      bool
 	originator      bool
+	strictFIFO      bool        // submitted directly by the application to keep the creation order
+	rollingUpdate   bool        // replaces a pod of a service during a rolling update
+	resizing        bool        // waiting for the core to allocate the resized resource of the bound task
+	resizeTimer     *time.Timer // releases the resized ask if the core does not allocate it in time
 	schedulingState interfaces.TaskSchedulingState
 	sm              *fsm.FSM
 	lock            *sync.RWMutex
@@ -91,6 +104,9 @@ func createTaskInternal(tid string, app *Application, resource *si.Resource,
 	var pluginMode bool
 	if ctx != nil {
 		pluginMode = ctx.IsPluginMode()
+		if claims := ctx.getPodClaimResource(pod); claims != nil {
+			resource = common.Add(resource, claims)
+		}
 	}
 	task := &Task{
 		taskID:          tid,
@@ -101,6 +117,7 @@ func createTaskInternal(tid string, app *Application, resource *si.Resource,
 		podStatus:       *pod.Status.DeepCopy(),
 		resource:        resource,
 		createTime:      pod.GetCreationTimestamp().Time,
+		stateTime:       time.Now(),
 		placeholder:     placeholder,
 		taskGroupName:   taskGroupName,
 		pluginMode:      pluginMode,
@@ -301,18 +318,52 @@ func (task *Task) handleSubmitTaskEvent() {
 	rr := common.CreateAllocationRequestForTask(
 		task.applicationID,
 		task.taskID,
+		task.application.partition,
 		task.resource,
 		task.placeholder,
 		task.taskGroupName,
 		task.pod,
 		task.originator,
 		preemptionPolicy)
+	for _, ask := range rr.Asks {
+		ask.Priority = task.getRollingUpdatePriority(ask.Priority)
+	}
+	task.context.addFailedNodesHint(task.pod, rr.Asks)
+	task.context.addMigrationHint(task.pod, rr.Asks)
+	if task.context.askBatcher != nil {
+		// the events are published by askSent once the batch is sent
+		log.Log(log.ShimCacheTask).Debug("queue update request", zap.Stringer("request", rr))
+		task.context.askBatcher.add(rr)
+		return
+	}
 	log.Log(log.ShimCacheTask).Debug("send update request", zap.Stringer("request", rr))
-	if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(rr); err != nil {
+	// a buffered request is sent once the communication with the core is restored
+	if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(rr); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 		log.Log(log.ShimCacheTask).Debug("failed to send scheduling request to scheduler", zap.Error(err))
 		return
 	}
+	task.publishSchedulingEvents()
+}
+
+// askSent is called by the ask batcher once the ask of the task is sent to the core. The task is rejected if
+// the ask could not be sent.
+func (task *Task) askSent(err error) {
+	if err != nil {
+		dispatcher.Dispatch(NewRejectTaskEvent(task.applicationID, task.taskID,
+			fmt.Sprintf("failed to send the ask to the scheduler: %s", err.Error())))
+		return
+	}
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	if task.sm.Current() != TaskStates().Scheduling {
+		return
+	}
+	task.publishSchedulingEvents()
+}
 
+// publishSchedulingEvents publishes the events of a task that is waiting for an allocation in the core.
+// Must be called while holding the task lock.
+func (task *Task) publishSchedulingEvents() {
 	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil, v1.EventTypeNormal, "Scheduling", "Scheduling",
 		"%s is queued and waiting for allocation", task.alias)
 	// if this task belongs to a task group, that means the app has gang scheduling enabled
@@ -324,79 +375,295 @@ func (task *Task) handleSubmitTaskEvent() {
 	}
 }
 
+// updateResource updates the resource of a bound task after its pod was resized in place.
+// The resource of a task that is not bound is not changed: the pod can only be resized
+// by the kubelet once it is running. The allocation is replaced in the core without holding the task lock.
+func (task *Task) updateResource(pod *v1.Pod) {
+	// the claims and the preemption policy are looked up in the context before the task is locked
+	resource := common.GetPodResource(pod)
+	if task.context != nil {
+		if claims := task.context.getPodClaimResource(pod); claims != nil {
+			resource = common.Add(resource, claims)
+		}
+	}
+	preemptionPolicy := &si.PreemptionPolicy{
+		AllowPreemptSelf:  task.isPreemptSelfAllowed(),
+		AllowPreemptOther: task.isPreemptOtherAllowed(),
+	}
+
+	rr, updated := task.setResource(resource, preemptionPolicy)
+	if !updated {
+		return
+	}
+	if rr != nil {
+		task.reallocate(rr)
+	}
+	events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeNormal, "TaskResized", "TaskResized",
+		"Task %s is resized to %s", task.alias, resource.String())
+}
+
+// setResource sets the resource of the bound task and returns the request that replaces the allocation of the
+// task in the core, nil if the task has no allocation to replace. Returns false if the resource is not updated.
+func (task *Task) setResource(resource *si.Resource, preemptionPolicy *si.PreemptionPolicy) (*si.AllocationRequest, bool) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	state := task.sm.Current()
+	if state != TaskStates().Bound {
+		log.Log(log.ShimCacheTask).Debug("ignoring resource update for task that is not bound",
+			zap.String("taskID", task.taskID),
+			zap.String("state", state))
+		return nil, false
+	}
+	if common.Equals(task.resource, resource) {
+		return nil, false
+	}
+	log.Log(log.ShimCacheTask).Info("task resource updated",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.String("allocationUUID", task.allocationUUID),
+		zap.Stringer("oldResource", task.resource),
+		zap.Stringer("newResource", resource))
+	if task.application.isService() {
+		task.reportServiceResource(task.resource, -1)
+		task.reportServiceResource(resource, 1)
+	}
+	task.resource = resource
+	if task.allocationUUID == "" || task.context.apiProvider.GetAPIs().SchedulerAPI == nil {
+		return nil, true
+	}
+	// The scheduler interface has no message to update an existing allocation: a new ask that requires
+	// the node of the task is sent while the current allocation is kept. The pod stays accounted for in the
+	// queue until the new allocation replaces the current one in beforeTaskAllocated. If the node cannot fit
+	// the new size the ask is rejected (beforeTaskRejected) or released after resizeTimeout.
+	rr := common.CreateAllocationRequestForTask(
+		task.applicationID,
+		task.taskID,
+		task.application.partition,
+		task.resource,
+		false,
+		task.taskGroupName,
+		task.pod,
+		task.originator,
+		preemptionPolicy)
+	for _, ask := range rr.Asks {
+		ask.Tags[siCommon.DomainYuniKorn+siCommon.KeyRequiredNode] = task.nodeName
+	}
+	log.Log(log.ShimCacheTask).Info("requesting core allocation for resized task",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.String("allocationUUID", task.allocationUUID),
+		zap.String("nodeName", task.nodeName))
+	task.stopResizing()
+	task.resizing = true
+	task.resizeTimer = time.AfterFunc(resizeTimeout, task.onResizeTimeout)
+	return rr, true
+}
+
+// stopResizing stops waiting for the allocation of the resized task. Must be called holding the task lock.
+func (task *Task) stopResizing() {
+	task.resizing = false
+	if task.resizeTimer != nil {
+		task.resizeTimer.Stop()
+		task.resizeTimer = nil
+	}
+}
+
+// onResizeTimeout releases the ask of a resized task that was not allocated in time.
+// The task keeps its current allocation.
+func (task *Task) onResizeTimeout() {
+	task.lock.Lock()
+	if !task.resizing {
+		task.lock.Unlock()
+		return
+	}
+	task.stopResizing()
+	task.lock.Unlock()
+	log.Log(log.ShimCacheTask).Warn("resized task not allocated in time, releasing the resized ask",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.Duration("timeout", resizeTimeout))
+	task.releaseResizedAsk()
+}
+
+// releaseResizedAsk releases the ask of the resized task in the core. Must be called without holding the task lock.
+func (task *Task) releaseResizedAsk() {
+	if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(
+		common.CreateReleaseAskRequestForTask(task.applicationID, task.taskID, task.application.partition)); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+		log.Log(log.ShimCacheTask).Warn("failed to release ask of resized task", zap.Error(err))
+	}
+}
+
+// reallocate sends the ask for the resized task to the core.
+// Must be called without holding the task lock. If the task was released while the request was sent, the
+// release might have reached the core before the new ask: the ask is released again.
+func (task *Task) reallocate(rr *si.AllocationRequest) {
+	err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(rr)
+	if err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+		log.Log(log.ShimCacheTask).Warn("failed to send ask of resized task",
+			zap.String("taskID", task.taskID),
+			zap.Error(err))
+		task.lock.Lock()
+		task.stopResizing()
+		task.lock.Unlock()
+		return
+	}
+	task.lock.RLock()
+	released := task.sm.Current() != TaskStates().Bound
+	task.lock.RUnlock()
+	if released {
+		log.Log(log.ShimCacheTask).Info("task released while resizing, releasing the resized ask",
+			zap.String("appID", task.applicationID),
+			zap.String("taskID", task.taskID))
+		task.releaseResizedAsk()
+	}
+}
+
 // this is called after task reaches PENDING state,
 // submit the resource asks from this task to the scheduler core
 func (task *Task) postTaskPending() {
+	if task.strictFIFO {
+		return
+	}
 	dispatcher.Dispatch(NewSubmitTaskEvent(task.applicationID, task.taskID))
 }
 
+func (task *Task) setStrictFIFO(strictFIFO bool) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.strictFIFO = strictFIFO
+}
+
+func (task *Task) setRollingUpdate(rollingUpdate bool) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.rollingUpdate = rollingUpdate
+}
+
 // postTaskAllocated is called after task reaches ALLOCATED state.
 // This routine binds the pod to the allocated node.
 // It calls K8s api to bind a pod to the assigned node, this may need some time,
 // so we do a delay binding, background process, to avoid blocking main process.
 // The result of the binding is tracked and failures are properly handled.
+// Transient bind failures are retried with a backoff, see bindPodWithRetry.
 // If successful, we move task to next state BOUND, otherwise we fail the task
 func (task *Task) postTaskAllocated() {
 	go func() {
-		// we need to obtain task's lock first,
-		// this ensures no other threads modifying task state at the time being
-		task.lock.Lock()
-		defer task.lock.Unlock()
+		if !task.prepareBind() {
+			return
+		}
+		// the task lock is not held while binding: the retries can take a while
+		bindStart := time.Now()
+		err := task.bindPodWithRetry()
+		task.completeBind(err, bindStart)
+	}()
+}
 
-		// plugin mode means we delegate this work to the default scheduler
-		if task.pluginMode {
-			log.Log(log.ShimCacheTask).Debug("allocating pod",
-				zap.String("podName", task.pod.Name),
-				zap.String("podUID", string(task.pod.UID)))
+// prepareBind binds the volumes and reserves the resource claims of the pod before the pod is bound.
+// Returns true if the pod must be bound, false if the task is handled by the default scheduler or has failed.
+func (task *Task) prepareBind() bool {
+	// we need to obtain task's lock first,
+	// this ensures no other threads modifying task state at the time being
+	task.lock.Lock()
+	defer task.lock.Unlock()
 
-			task.context.AddPendingPodAllocation(string(task.pod.UID), task.nodeName)
+	// plugin mode means we delegate this work to the default scheduler
+	if task.pluginMode {
+		log.Log(log.ShimCacheTask).Debug("allocating pod",
+			zap.String("podName", task.pod.Name),
+			zap.String("podUID", string(task.pod.UID)))
 
-			dispatcher.Dispatch(NewBindTaskEvent(task.applicationID, task.taskID))
-			events.GetRecorder().Eventf(task.pod.DeepCopy(),
-				nil, v1.EventTypeNormal, "QuotaApproved", "QuotaApproved",
-				"Pod %s is ready for scheduling on node %s", task.alias, task.nodeName)
-		} else {
-			// post a message to indicate the pod gets its allocation
+		task.context.AddPendingPodAllocation(string(task.pod.UID), task.nodeName)
+
+		dispatcher.Dispatch(NewBindTaskEvent(task.applicationID, task.taskID))
+		events.GetRecorder().Eventf(task.pod.DeepCopy(),
+			nil, v1.EventTypeNormal, "QuotaApproved", "QuotaApproved",
+			"Pod %s is ready for scheduling on node %s", task.alias, task.nodeName)
+		task.schedulingState = interfaces.TaskSchedAllocated
+		return false
+	}
+
+	// post a message to indicate the pod gets its allocation
+	events.GetRecorder().Eventf(task.pod.DeepCopy(),
+		nil, v1.EventTypeNormal, "Scheduled", "Scheduled",
+		"Successfully assigned %s to node %s", task.alias, task.nodeName)
+
+	// before binding pod to node, first bind volumes to pod
+	log.Log(log.ShimCacheTask).Debug("bind pod volumes",
+		zap.String("podName", task.pod.Name),
+		zap.String("podUID", string(task.pod.UID)))
+	if task.context.apiProvider.GetAPIs().VolumeBinder != nil {
+		if err := task.context.bindPodVolumes(task.pod); err != nil {
+			errorMessage := fmt.Sprintf("bind volumes to pod failed, name: %s, %s", task.alias, err.Error())
+			dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
+			metrics.IncSchedulingFailure(metrics.APIBind, "PodVolumesBindFailure")
 			events.GetRecorder().Eventf(task.pod.DeepCopy(),
-				nil, v1.EventTypeNormal, "Scheduled", "Scheduled",
-				"Successfully assigned %s to node %s", task.alias, task.nodeName)
+				nil, v1.EventTypeWarning, "PodVolumesBindFailure", metrics.APIBind.String(), errorMessage)
+			return false
+		}
+	}
 
-			// before binding pod to node, first bind volumes to pod
-			log.Log(log.ShimCacheTask).Debug("bind pod volumes",
+	if err := task.reservePodClaimsWithRetry(); err != nil {
+		if errors.Is(err, errBindCancelled) {
+			log.Log(log.ShimCacheTask).Info("pod bind cancelled",
 				zap.String("podName", task.pod.Name),
-				zap.String("podUID", string(task.pod.UID)))
-			if task.context.apiProvider.GetAPIs().VolumeBinder != nil {
-				if err := task.context.bindPodVolumes(task.pod); err != nil {
-					errorMessage := fmt.Sprintf("bind volumes to pod failed, name: %s, %s", task.alias, err.Error())
-					dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
-					events.GetRecorder().Eventf(task.pod.DeepCopy(),
-						nil, v1.EventTypeWarning, "PodVolumesBindFailure", "PodVolumesBindFailure", errorMessage)
-					return
-				}
-			}
-
-			log.Log(log.ShimCacheTask).Debug("bind pod",
+				zap.Error(err))
+			return false
+		}
+		errorMessage := fmt.Sprintf("reserve resource claims of pod failed, name: %s, %s", task.alias, err.Error())
+		metrics.IncSchedulingFailure(metrics.APIBind, "PodResourceClaimsReserveFailure")
+		events.GetRecorder().Eventf(task.pod.DeepCopy(),
+			nil, v1.EventTypeWarning, "PodResourceClaimsReserveFailure", metrics.APIBind.String(), errorMessage)
+		// the claims cannot be used on the node, or were not allocated in time: the pod is not bound and the
+		// task is scheduled again, the predicates skip the node if the claims cannot be used on it
+		if errors.Is(err, errClaimUnsuitable) || errors.Is(err, errClaimNotAllocated) {
+			log.Log(log.ShimCacheTask).Info("resource claims cannot be used on node, scheduling pod again",
 				zap.String("podName", task.pod.Name),
-				zap.String("podUID", string(task.pod.UID)))
-
-			if err := task.context.apiProvider.GetAPIs().KubeClient.Bind(task.pod, task.nodeName); err != nil {
-				errorMessage := fmt.Sprintf("bind pod to node failed, name: %s, %s", task.alias, err.Error())
-				log.Log(log.ShimCacheTask).Error(errorMessage)
-				dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
-				events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
-					v1.EventTypeWarning, "PodBindFailure", "PodBindFailure", errorMessage)
-				return
-			}
-
-			log.Log(log.ShimCacheTask).Info("successfully bound pod", zap.String("podName", task.pod.Name))
-			dispatcher.Dispatch(NewBindTaskEvent(task.applicationID, task.taskID))
-			events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
-				v1.EventTypeNormal, "PodBindSuccessful", "PodBindSuccessful",
-				"Pod %s is successfully bound to node %s", task.alias, task.nodeName)
+				zap.String("nodeName", task.nodeName),
+				zap.Error(err))
+			dispatcher.Dispatch(NewSimpleTaskEvent(task.applicationID, task.taskID, RescheduleTask))
+			return false
 		}
+		dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
+		return false
+	}
 
-		task.schedulingState = interfaces.TaskSchedAllocated
-	}()
+	log.Log(log.ShimCacheTask).Debug("bind pod",
+		zap.String("podName", task.pod.Name),
+		zap.String("podUID", string(task.pod.UID)))
+	return true
+}
+
+// completeBind handles the result of the pod bind: the task moves to BOUND if the bind succeeded, otherwise
+// the task fails. The state is checked under the task lock: nothing is done if the task is no longer allocated,
+// e.g. the bind was cancelled or the pod was deleted while binding.
+func (task *Task) completeBind(err error, bindStart time.Time) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+
+	if state := task.sm.Current(); state != TaskStates().Allocated {
+		log.Log(log.ShimCacheTask).Info("task is no longer allocated, ignoring bind result",
+			zap.String("podName", task.pod.Name),
+			zap.String("state", state),
+			zap.Error(err))
+		return
+	}
+	if err != nil {
+		errorMessage := fmt.Sprintf("bind pod to node failed, name: %s, %s", task.alias, err.Error())
+		log.Log(log.ShimCacheTask).Error(errorMessage)
+		dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
+		metrics.IncSchedulingFailure(metrics.APIBind, "PodBindFailure")
+		events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
+			v1.EventTypeWarning, "PodBindFailure", metrics.APIBind.String(), errorMessage)
+		return
+	}
+
+	metrics.ObservePodBindLatency(time.Since(bindStart))
+	log.Log(log.ShimCacheTask).Info("successfully bound pod", zap.String("podName", task.pod.Name))
+	dispatcher.Dispatch(NewBindTaskEvent(task.applicationID, task.taskID))
+	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
+		v1.EventTypeNormal, "PodBindSuccessful", "PodBindSuccessful",
+		"Pod %s is successfully bound to node %s", task.alias, task.nodeName)
+	task.schedulingState = interfaces.TaskSchedAllocated
 }
 
 // beforeTaskAllocated is called before handling the TaskAllocated event.
@@ -407,6 +674,38 @@ func (task *Task) postTaskAllocated() {
 // event, we need to explicitly release this allocation because it is no
 // longer valid.
 func (task *Task) beforeTaskAllocated(eventSrc string, allocUUID string, nodeID string) {
+	// A bound task is only allocated again after it was resized, the pod keeps running on its node.
+	// The new allocation replaces the current one, which is released. Any other allocation cannot be used
+	// and is released.
+	if eventSrc == TaskStates().Bound {
+		if task.resizing && nodeID == task.nodeName {
+			log.Log(log.ShimCacheTask).Info("resized task allocated on its node",
+				zap.String("taskID", task.taskID),
+				zap.String("allocUUID", allocUUID),
+				zap.String("releasedUUID", task.allocationUUID),
+				zap.String("allocatedNode", nodeID))
+			task.stopResizing()
+			releasedUUID := task.allocationUUID
+			task.allocationUUID = allocUUID
+			if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(
+				common.CreateReleaseAllocationRequestForTask(task.applicationID, releasedUUID, task.application.partition,
+					si.TerminationType_STOPPED_BY_RM.String())); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+				log.Log(log.ShimCacheTask).Warn("failed to release allocation of resized task", zap.Error(err))
+			}
+			return
+		}
+		log.Log(log.ShimCacheTask).Warn("unexpected allocation for bound task, releasing the allocation",
+			zap.String("taskID", task.taskID),
+			zap.String("allocUUID", allocUUID),
+			zap.String("allocatedNode", nodeID),
+			zap.String("boundNode", task.nodeName))
+		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(
+			common.CreateReleaseAllocationRequestForTask(task.applicationID, allocUUID, task.application.partition,
+				si.TerminationType_STOPPED_BY_RM.String())); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+			log.Log(log.ShimCacheTask).Warn("failed to release unexpected allocation", zap.Error(err))
+		}
+		return
+	}
 	// task is allocated on a node with a UUID set the details in the task here to allow referencing later.
 	task.allocationUUID = allocUUID
 	task.nodeName = nodeID
@@ -422,6 +721,48 @@ func (task *Task) beforeTaskAllocated(eventSrc string, allocUUID string, nodeID
 	}
 }
 
+// beforeTaskRejected is called before handling the TaskRejected event. Only a bound task waiting for the
+// allocation of its resized resource is rejected without leaving the Bound state: the current allocation is
+// kept in the core and the pod stays accounted for with its previous resource.
+func (task *Task) beforeTaskRejected(eventSrc string, reason string) {
+	if eventSrc != TaskStates().Bound {
+		return
+	}
+	if !task.resizing {
+		log.Log(log.ShimCacheTask).Warn("unexpected rejection of bound task",
+			zap.String("taskID", task.taskID),
+			zap.String("reason", reason))
+		return
+	}
+	log.Log(log.ShimCacheTask).Warn("resized task rejected by the core",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.String("nodeName", task.nodeName),
+		zap.String("reason", reason))
+	task.stopResizing()
+	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil, v1.EventTypeWarning, "TaskResizeRejected", "TaskResizeRejected",
+		"Resize of task %s was rejected by the scheduler: %s", task.alias, reason)
+}
+
+// beforeTaskReschedule is called before an allocated task that could not be bound is scheduled again.
+// The allocation is released in the core, a new ask is sent once the task is pending again.
+func (task *Task) beforeTaskReschedule() {
+	log.Log(log.ShimCacheTask).Info("releasing allocation of task to schedule it again",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.String("allocationUUID", task.allocationUUID),
+		zap.String("nodeName", task.nodeName))
+	if task.allocationUUID != "" && task.context.apiProvider.GetAPIs().SchedulerAPI != nil {
+		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(
+			common.CreateReleaseAllocationRequestForTask(task.applicationID, task.allocationUUID, task.application.partition,
+				si.TerminationType_STOPPED_BY_RM.String())); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
+			log.Log(log.ShimCacheTask).Warn("failed to release allocation of task", zap.Error(err))
+		}
+	}
+	task.allocationUUID = ""
+	task.nodeName = ""
+}
+
 func (task *Task) postTaskBound() {
 	if task.pluginMode {
 		// When the pod is actively scheduled by YuniKorn, it can be  moved to the default-scheduler's
@@ -441,6 +782,25 @@ func (task *Task) postTaskBound() {
 		}
 	}
 
+	if task.application.isService() {
+		task.reportServiceResource(task.resource, 1)
+	}
+	task.propagateAppTagLabels()
+
+	eventstream.Publish(eventstream.TopicAllocation, task.taskID, task.nodeName,
+		"Task %s of application %s is bound to node %s", task.alias, task.applicationID, task.nodeName)
+
+	if task.context.journal != nil {
+		task.context.journal.bind(&journalEntry{
+			TaskID:        task.taskID,
+			ApplicationID: task.applicationID,
+			Partition:     task.application.partition,
+			NodeID:        task.nodeName,
+			TaskGroupName: task.taskGroupName,
+			Placeholder:   task.placeholder,
+		})
+	}
+
 	if task.placeholder {
 		log.Log(log.ShimCacheTask).Info("placeholder is bound",
 			zap.String("appID", task.applicationID),
@@ -450,16 +810,41 @@ func (task *Task) postTaskBound() {
 	}
 }
 
-func (task *Task) postTaskRejected() {
+// leaveTaskBound removes the resource of the task from the service application usage
+func (task *Task) leaveTaskBound() {
+	if task.application.isService() {
+		task.reportServiceResource(task.resource, -1)
+	}
+}
+
+// reportServiceResource adds or removes the resource to the usage of the service application
+func (task *Task) reportServiceResource(resource *si.Resource, sign int64) {
+	if resource == nil {
+		return
+	}
+	for name, quantity := range resource.Resources {
+		metrics.AddServiceApplicationResource(task.pod.Namespace, task.applicationID, name, sign*quantity.GetValue())
+	}
+}
+
+// observeStateTransition records the transition and the time the task spent in the source state
+func (task *Task) observeStateTransition(src, dst string) {
+	now := time.Now()
+	metrics.ObserveTaskStateTransition(src, dst, now.Sub(task.stateTime))
+	task.stateTime = now
+}
+
+func (task *Task) postTaskRejected(reason string) {
 	// currently, once task is rejected by scheduler, we directly move task to failed state.
 	// so this function simply triggers the state transition when it is rejected.
 	// but further, we can introduce retry mechanism if necessary.
 	dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID,
-		fmt.Sprintf("task %s failed because it is rejected by scheduler", task.alias)))
+		fmt.Sprintf("task %s failed because it is rejected by scheduler: %s", task.alias, reason)))
 
+	metrics.IncSchedulingFailure(metrics.CoreAllocation, "TaskRejected")
 	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
-		v1.EventTypeWarning, "TaskRejected", "TaskRejected",
-		"Task %s is rejected by the scheduler", task.alias)
+		v1.EventTypeWarning, "TaskRejected", metrics.CoreAllocation.String(),
+		"Task %s is rejected by the scheduler: %s", task.alias, reason)
 }
 
 // beforeTaskFail releases the allocation or ask from scheduler core
@@ -492,6 +877,9 @@ func (task *Task) beforeTaskCompleted() {
 
 // releaseAllocation sends the release request for the Allocation or the AllocationAsk to the core.
 func (task *Task) releaseAllocation() {
+	if task.context.journal != nil {
+		task.context.journal.release(task.taskID)
+	}
 	// scheduler api might be nil in some tests
 	if task.context.apiProvider.GetAPIs().SchedulerAPI != nil {
 		log.Log(log.ShimCacheTask).Debug("prepare to send release request",
@@ -509,6 +897,11 @@ func (task *Task) releaseAllocation() {
 		s := TaskStates()
 		switch task.GetTaskState() {
 		case s.New, s.Pending, s.Scheduling, s.Rejected:
+			// the ask might not have been sent yet: drop it, the release is still sent to be safe.
+			// An ask that is being sent is released by the batcher, the release must not overtake the ask.
+			if task.context.askBatcher != nil && task.context.askBatcher.remove(task.applicationID, task.taskID) {
+				return
+			}
 			releaseRequest = common.CreateReleaseAskRequestForTask(
 				task.applicationID, task.taskID, task.application.partition)
 		default:
@@ -522,6 +915,12 @@ func (task *Task) releaseAllocation() {
 			}
 			releaseRequest = common.CreateReleaseAllocationRequestForTask(
 				task.applicationID, task.allocationUUID, task.application.partition, task.terminationType)
+			// the ask of a resized task is waiting for its allocation in the core
+			if task.resizing {
+				askRelease := common.CreateReleaseAskRequestForTask(task.applicationID, task.taskID, task.application.partition)
+				releaseRequest.Releases.AllocationAsksToRelease = askRelease.Releases.AllocationAsksToRelease
+				task.stopResizing()
+			}
 		}
 
 		if releaseRequest.Releases != nil {
@@ -529,7 +928,7 @@ func (task *Task) releaseAllocation() {
 				zap.Int("numOfAsksToRelease", len(releaseRequest.Releases.AllocationAsksToRelease)),
 				zap.Int("numOfAllocationsToRelease", len(releaseRequest.Releases.AllocationsToRelease)))
 		}
-		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(releaseRequest); err != nil {
+		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(releaseRequest); err != nil && !errors.Is(err, client.ErrCoreRequestBuffered) {
 			log.Log(log.ShimCacheTask).Debug("failed to send scheduling request to scheduler", zap.Error(err))
 		}
 	}
@@ -0,0 +1,410 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	// TaskJournalSyncInterval is the interval at which the task journal is compacted or written to its ConfigMap
+	TaskJournalSyncInterval = 5 * time.Second
+	// taskJournalConfigMapPrefix selects a ConfigMap in the scheduler namespace as the journal store
+	taskJournalConfigMapPrefix = "configmap:"
+	// taskJournalConfigMapKey is the key of the compressed journal in the binary data of the ConfigMap
+	taskJournalConfigMapKey = "journal.gz"
+	// taskJournalCompactThreshold is the minimum number of appended records before a file journal is compacted
+	taskJournalCompactThreshold = 10000
+
+	journalOpBind    = "bind"
+	journalOpRelease = "release"
+)
+
+// journalEntry is the binding of a task to a node, with everything needed to recreate the allocation of the
+// task during recovery without deriving the application metadata from the pod.
+type journalEntry struct {
+	TaskID        string `json:"taskID"`
+	ApplicationID string `json:"applicationID"`
+	Partition     string `json:"partition,omitempty"`
+	NodeID        string `json:"nodeID"`
+	TaskGroupName string `json:"taskGroupName,omitempty"`
+	Placeholder   bool   `json:"placeholder,omitempty"`
+}
+
+// journalRecord is a single change of the journal, a binding or the release of a task
+type journalRecord struct {
+	Op    string        `json:"op"`
+	Entry *journalEntry `json:"entry,omitempty"`
+	// TaskID is set for a release
+	TaskID string `json:"taskID,omitempty"`
+}
+
+// journalStore persists the journal. The journal is written as a snapshot of all bindings, a store that
+// supports appends also receives every change when it happens.
+type journalStore interface {
+	load() ([]journalRecord, error)
+	save(entries []*journalEntry) error
+}
+
+// journalAppender is implemented by a store that writes each change ahead of the next snapshot
+type journalAppender interface {
+	append(record journalRecord) error
+}
+
+// taskJournal records the node each task is bound to. After a restart of the shim the journal is used to
+// recover the allocations of the pods without asking the application managers to rebuild the metadata.
+// The journal is a hint: an entry is only used if the pod is still assigned to the same node.
+type taskJournal struct {
+	entries map[string]*journalEntry // taskID -> binding
+	store   journalStore
+	pending int // changes since the last snapshot
+	lock    sync.Mutex
+}
+
+// newTaskJournal creates the journal for the configured location and loads the existing bindings.
+// Returns nil if the journal is not configured. A journal that cannot be loaded starts empty.
+func newTaskJournal(location, namespace string, clientSet kubernetes.Interface) *taskJournal {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return nil
+	}
+	var store journalStore
+	if strings.HasPrefix(location, taskJournalConfigMapPrefix) {
+		store = &configMapJournalStore{
+			clientSet: clientSet,
+			namespace: namespace,
+			name:      strings.TrimPrefix(location, taskJournalConfigMapPrefix),
+		}
+	} else {
+		store = &fileJournalStore{path: location}
+	}
+	journal := &taskJournal{
+		entries: make(map[string]*journalEntry),
+		store:   store,
+	}
+	records, err := store.load()
+	if err != nil {
+		log.Log(log.ShimContext).Warn("unable to load task journal, starting empty",
+			zap.String("location", location),
+			zap.Error(err))
+	}
+	for _, record := range records {
+		journal.apply(record)
+	}
+	log.Log(log.ShimContext).Info("task journal loaded",
+		zap.String("location", location),
+		zap.Int("bindings", len(journal.entries)))
+	return journal
+}
+
+// bind records the node the task is bound to
+func (j *taskJournal) bind(entry *journalEntry) {
+	j.write(journalRecord{Op: journalOpBind, Entry: entry})
+}
+
+// release removes the binding of the task
+func (j *taskJournal) release(taskID string) {
+	j.lock.Lock()
+	_, ok := j.entries[taskID]
+	j.lock.Unlock()
+	if ok {
+		j.write(journalRecord{Op: journalOpRelease, TaskID: taskID})
+	}
+}
+
+func (j *taskJournal) write(record journalRecord) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.apply(record)
+	j.pending++
+	if appender, ok := j.store.(journalAppender); ok {
+		if err := appender.append(record); err != nil {
+			log.Log(log.ShimContext).Warn("unable to write to task journal", zap.Error(err))
+		}
+	}
+}
+
+// apply changes the bindings, must be called while holding the lock or before the journal is shared
+func (j *taskJournal) apply(record journalRecord) {
+	switch record.Op {
+	case journalOpBind:
+		if record.Entry != nil && record.Entry.TaskID != "" {
+			j.entries[record.Entry.TaskID] = record.Entry
+		}
+	case journalOpRelease:
+		delete(j.entries, record.TaskID)
+	}
+}
+
+// getAllocation returns the allocation of a pod recorded in the journal, nil if the pod is not in the journal
+// or is assigned to a different node than recorded.
+func (j *taskJournal) getAllocation(pod *v1.Pod) *si.Allocation {
+	j.lock.Lock()
+	entry, ok := j.entries[string(pod.UID)]
+	j.lock.Unlock()
+	if !ok || entry.NodeID != pod.Spec.NodeName {
+		return nil
+	}
+	partition := entry.Partition
+	if partition == "" {
+		partition = constants.DefaultPartition
+	}
+	return &si.Allocation{
+		AllocationKey:    entry.TaskID,
+		UUID:             entry.TaskID,
+		ResourcePerAlloc: common.GetPodResource(pod),
+		NodeID:           entry.NodeID,
+		ApplicationID:    entry.ApplicationID,
+		Placeholder:      entry.Placeholder,
+		TaskGroupName:    entry.TaskGroupName,
+		PartitionName:    partition,
+	}
+}
+
+// retain removes the bindings of all tasks that are not in the list, e.g. pods deleted while the shim was down
+func (j *taskJournal) retain(taskIDs map[string]bool) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	for taskID := range j.entries {
+		if !taskIDs[taskID] {
+			delete(j.entries, taskID)
+			j.pending++
+		}
+	}
+}
+
+// sync writes a snapshot of the bindings if there are changes. A store that receives every change is only
+// compacted once the appended changes exceed the threshold and outnumber the bindings.
+func (j *taskJournal) sync() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.pending == 0 {
+		return
+	}
+	if _, ok := j.store.(journalAppender); ok && (j.pending < taskJournalCompactThreshold || j.pending < len(j.entries)) {
+		return
+	}
+	entries := make([]*journalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+	if err := j.store.save(entries); err != nil {
+		log.Log(log.ShimContext).Warn("unable to save task journal", zap.Error(err))
+		return
+	}
+	j.pending = 0
+}
+
+// getJournalAllocation returns the allocation of the pod from the task journal, nil if the journal is disabled
+// or has no binding for the pod
+func (ctx *Context) getJournalAllocation(pod *v1.Pod) *si.Allocation {
+	if ctx.journal == nil || utils.IsPodTerminated(pod) {
+		return nil
+	}
+	return ctx.journal.getAllocation(pod)
+}
+
+// SyncTaskJournal writes the changes of the task journal to its store.
+// Called periodically if the journal is configured.
+func (ctx *Context) SyncTaskJournal() {
+	if ctx.journal != nil {
+		ctx.journal.sync()
+	}
+}
+
+// encodeJournal writes the bindings as one bind record per line
+func encodeJournal(w io.Writer, entries []*journalEntry) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := encoder.Encode(journalRecord{Op: journalOpBind, Entry: entry}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeJournal reads the records, a truncated last line from an interrupted write is ignored
+func decodeJournal(r io.Reader) ([]journalRecord, error) {
+	var records []journalRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			log.Log(log.ShimContext).Warn("skipping invalid task journal record", zap.Error(err))
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// fileJournalStore appends every change to a file, the file is replaced by a snapshot when it is compacted
+type fileJournalStore struct {
+	path string
+	file *os.File
+}
+
+func (s *fileJournalStore) load() ([]journalRecord, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decodeJournal(file)
+}
+
+func (s *fileJournalStore) append(record journalRecord) error {
+	if s.file == nil {
+		file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		s.file = file
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// save writes the snapshot to a temporary file which replaces the journal, new changes are appended to it
+func (s *fileJournalStore) save(entries []*journalEntry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	writer := bufio.NewWriter(tmp)
+	if err = encodeJournal(writer, entries); err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	return nil
+}
+
+// configMapJournalStore keeps a compressed snapshot of the bindings in a ConfigMap
+type configMapJournalStore struct {
+	clientSet kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func (s *configMapJournalStore) load() ([]journalRecord, error) {
+	configMap, err := s.clientSet.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), s.name, apis.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, ok := configMap.BinaryData[taskJournalConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return decodeJournal(reader)
+}
+
+func (s *configMapJournalStore) save(entries []*journalEntry) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := encodeJournal(writer, entries); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	configMaps := s.clientSet.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := configMaps.Get(context.Background(), s.name, apis.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = configMaps.Create(context.Background(), &v1.ConfigMap{
+			ObjectMeta: apis.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+			},
+			BinaryData: map[string][]byte{taskJournalConfigMapKey: buf.Bytes()},
+		}, apis.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	configMap = configMap.DeepCopy()
+	if configMap.BinaryData == nil {
+		configMap.BinaryData = make(map[string][]byte)
+	}
+	configMap.BinaryData[taskJournalConfigMapKey] = buf.Bytes()
+	if _, err = configMaps.Update(context.Background(), configMap, apis.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update task journal ConfigMap %s/%s: %w", s.namespace, s.name, err)
+	}
+	return nil
+}
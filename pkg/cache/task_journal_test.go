@@ -0,0 +1,150 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+func newJournalPod(uid, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "pod-" + uid,
+			Namespace: "default",
+			UID:       types.UID(uid),
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func TestTaskJournalDisabled(t *testing.T) {
+	assert.Assert(t, newTaskJournal("", "default", nil) == nil)
+	assert.Assert(t, newTaskJournal("  ", "default", nil) == nil)
+}
+
+func TestTaskJournalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	journal := newTaskJournal(path, "default", nil)
+	assert.Assert(t, journal != nil)
+	assert.Equal(t, len(journal.entries), 0)
+
+	journal.bind(&journalEntry{TaskID: "uid-1", ApplicationID: "app-1", NodeID: "node-1", TaskGroupName: "tg", Placeholder: true})
+	journal.bind(&journalEntry{TaskID: "uid-2", ApplicationID: "app-1", Partition: "gpu", NodeID: "node-2"})
+	journal.bind(&journalEntry{TaskID: "uid-3", ApplicationID: "app-2", NodeID: "node-1"})
+	journal.release("uid-3")
+	journal.release("unknown")
+	assert.Equal(t, journal.pending, 4)
+
+	// every change is appended, a new journal replays them
+	reloaded := newTaskJournal(path, "default", nil)
+	assert.Equal(t, len(reloaded.entries), 2)
+	assert.DeepEqual(t, reloaded.entries["uid-1"], journal.entries["uid-1"])
+	assert.DeepEqual(t, reloaded.entries["uid-2"], journal.entries["uid-2"])
+
+	// below the compaction threshold the file is left as is
+	journal.sync()
+	assert.Equal(t, journal.pending, 4)
+
+	// compaction replaces the file with the bindings and keeps appending afterwards
+	journal.pending = taskJournalCompactThreshold
+	journal.sync()
+	assert.Equal(t, journal.pending, 0)
+	records, err := (&fileJournalStore{path: path}).load()
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 2)
+	journal.release("uid-2")
+	reloaded = newTaskJournal(path, "default", nil)
+	assert.Equal(t, len(reloaded.entries), 1)
+	assert.Assert(t, reloaded.entries["uid-1"] != nil)
+
+	// a truncated record from an interrupted write is skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.NilError(t, err)
+	_, err = file.WriteString(`{"op":"bind","entry":{"taskID":"uid-`)
+	assert.NilError(t, err)
+	assert.NilError(t, file.Close())
+	reloaded = newTaskJournal(path, "default", nil)
+	assert.Equal(t, len(reloaded.entries), 1)
+}
+
+func TestTaskJournalConfigMap(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	journal := newTaskJournal("configmap:yunikorn-journal", "yunikorn", clientSet)
+	assert.Assert(t, journal != nil)
+	assert.Equal(t, len(journal.entries), 0)
+
+	// nothing is written until the journal is synced
+	journal.bind(&journalEntry{TaskID: "uid-1", ApplicationID: "app-1", NodeID: "node-1"})
+	assert.Equal(t, len(newTaskJournal("configmap:yunikorn-journal", "yunikorn", clientSet).entries), 0)
+
+	// the first sync creates the ConfigMap, later syncs update it
+	journal.sync()
+	assert.Equal(t, journal.pending, 0)
+	reloaded := newTaskJournal("configmap:yunikorn-journal", "yunikorn", clientSet)
+	assert.Equal(t, len(reloaded.entries), 1)
+	assert.DeepEqual(t, reloaded.entries["uid-1"], journal.entries["uid-1"])
+
+	journal.bind(&journalEntry{TaskID: "uid-2", ApplicationID: "app-1", NodeID: "node-2"})
+	journal.release("uid-1")
+	journal.sync()
+	reloaded = newTaskJournal("configmap:yunikorn-journal", "yunikorn", clientSet)
+	assert.Equal(t, len(reloaded.entries), 1)
+	assert.Assert(t, reloaded.entries["uid-2"] != nil)
+}
+
+func TestTaskJournalAllocation(t *testing.T) {
+	journal := newTaskJournal(filepath.Join(t.TempDir(), "journal"), "default", nil)
+	journal.bind(&journalEntry{TaskID: "uid-1", ApplicationID: "app-1", NodeID: "node-1", TaskGroupName: "tg", Placeholder: true})
+	journal.bind(&journalEntry{TaskID: "uid-2", ApplicationID: "app-2", Partition: "gpu", NodeID: "node-2"})
+
+	alloc := journal.getAllocation(newJournalPod("uid-1", "node-1"))
+	assert.Assert(t, alloc != nil)
+	assert.Equal(t, alloc.AllocationKey, "uid-1")
+	assert.Equal(t, alloc.UUID, "uid-1")
+	assert.Equal(t, alloc.ApplicationID, "app-1")
+	assert.Equal(t, alloc.NodeID, "node-1")
+	assert.Equal(t, alloc.TaskGroupName, "tg")
+	assert.Equal(t, alloc.PartitionName, constants.DefaultPartition)
+	assert.Assert(t, alloc.Placeholder)
+
+	alloc = journal.getAllocation(newJournalPod("uid-2", "node-2"))
+	assert.Assert(t, alloc != nil)
+	assert.Equal(t, alloc.PartitionName, "gpu")
+
+	// pod moved to a different node or unknown pod
+	assert.Assert(t, journal.getAllocation(newJournalPod("uid-2", "node-1")) == nil)
+	assert.Assert(t, journal.getAllocation(newJournalPod("uid-3", "node-1")) == nil)
+
+	// only the pods that still exist are retained
+	journal.retain(map[string]bool{"uid-1": true})
+	assert.Equal(t, len(journal.entries), 1)
+	assert.Assert(t, journal.getAllocation(newJournalPod("uid-2", "node-2")) == nil)
+}
@@ -27,6 +27,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/eventstream"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
 
@@ -47,10 +48,11 @@ const (
 	TaskFail
 	KillTask
 	TaskKilled
+	RescheduleTask
 )
 
 func (ae TaskEventType) String() string {
-	return [...]string{"InitTask", "SubmitTask", "TaskAllocated", "TaskRejected", "TaskBound", "CompleteTask", "TaskFail", "KillTask", "TaskKilled"}[ae]
+	return [...]string{"InitTask", "SubmitTask", "TaskAllocated", "TaskRejected", "TaskBound", "CompleteTask", "TaskFail", "KillTask", "TaskKilled", "RescheduleTask"}[ae]
 }
 
 // ------------------------
@@ -338,11 +340,21 @@ func newTaskState() *fsm.FSM {
 				Src:  []string{states.Completed},
 				Dst:  states.Completed,
 			},
+			{
+				Name: TaskAllocated.String(),
+				Src:  []string{states.Bound},
+				Dst:  states.Bound,
+			},
 			{
 				Name: TaskBound.String(),
 				Src:  []string{states.Allocated},
 				Dst:  states.Bound,
 			},
+			{
+				Name: RescheduleTask.String(),
+				Src:  []string{states.Allocated},
+				Dst:  states.Pending,
+			},
 			{
 				Name: CompleteTask.String(),
 				Src:  states.Any,
@@ -363,6 +375,11 @@ func newTaskState() *fsm.FSM {
 				Src:  []string{states.New, states.Pending, states.Scheduling},
 				Dst:  states.Rejected,
 			},
+			{
+				Name: TaskRejected.String(),
+				Src:  []string{states.Bound},
+				Dst:  states.Bound,
+			},
 			{
 				Name: TaskFail.String(),
 				Src:  []string{states.Rejected, states.Allocated},
@@ -384,6 +401,9 @@ func newTaskState() *fsm.FSM {
 					zap.String("source", event.Src),
 					zap.String("destination", event.Dst),
 					zap.String("event", event.Event))
+				task.observeStateTransition(event.Src, event.Dst)
+				eventstream.Publish(eventstream.TopicTask, task.taskID, task.applicationID,
+					"Task %s state changed from %s to %s on %s", task.alias, event.Src, event.Dst, event.Event)
 			},
 			states.Pending: func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
@@ -395,7 +415,15 @@ func newTaskState() *fsm.FSM {
 			},
 			states.Rejected: func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
-				task.postTaskRejected()
+				eventArgs := make([]string, 1)
+				reason := ""
+				if err := events.GetEventArgsAsStrings(eventArgs, event.Args[1].([]interface{})); err != nil {
+					log.Log(log.ShimFSM).Error("failed to parse event arg", zap.Error(err))
+					reason = err.Error()
+				} else {
+					reason = eventArgs[0]
+				}
+				task.postTaskRejected(reason)
 			},
 			states.Failed: func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
@@ -413,6 +441,10 @@ func newTaskState() *fsm.FSM {
 				task := event.Args[0].(*Task) //nolint:errcheck
 				task.postTaskBound()
 			},
+			leaveHook(states.Bound): func(_ context.Context, event *fsm.Event) {
+				task := event.Args[0].(*Task) //nolint:errcheck
+				task.leaveTaskBound()
+			},
 			beforeHook(TaskFail): func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
 				task.beforeTaskFail()
@@ -429,6 +461,19 @@ func newTaskState() *fsm.FSM {
 				nodeID := eventArgs[1]
 				task.beforeTaskAllocated(event.Src, allocUUID, nodeID)
 			},
+			beforeHook(TaskRejected): func(_ context.Context, event *fsm.Event) {
+				task := event.Args[0].(*Task) //nolint:errcheck
+				eventArgs := make([]string, 1)
+				if err := events.GetEventArgsAsStrings(eventArgs, event.Args[1].([]interface{})); err != nil {
+					log.Log(log.ShimFSM).Error("failed to parse event arg", zap.Error(err))
+					return
+				}
+				task.beforeTaskRejected(event.Src, eventArgs[0])
+			},
+			beforeHook(RescheduleTask): func(_ context.Context, event *fsm.Event) {
+				task := event.Args[0].(*Task) //nolint:errcheck
+				task.beforeTaskReschedule()
+			},
 			beforeHook(CompleteTask): func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
 				task.beforeTaskCompleted()
@@ -444,3 +489,7 @@ func newTaskState() *fsm.FSM {
 func beforeHook(event TaskEventType) string {
 	return fmt.Sprintf("before_%s", event)
 }
+
+func leaveHook(state string) string {
+	return fmt.Sprintf("leave_%s", state)
+}
@@ -19,6 +19,9 @@
 package cache
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -36,7 +39,11 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
@@ -230,6 +237,105 @@ func TestReleaseTaskAllocation(t *testing.T) {
 	assert.Equal(t, mockedApiProvider.GetSchedulerAPIUpdateAllocationCount(), int32(2))
 }
 
+func TestHandleSubmitTaskEventBatched(t *testing.T) {
+	mockedContext, mockedAPIProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeTask, mockedContext.TaskEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	var lock sync.Mutex
+	var sendErr error
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(request.Asks) > 0 {
+			return sendErr
+		}
+		return nil
+	})
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(events.NewMockedRecorder())
+	published := 0
+	getPublished := func() int {
+		for {
+			select {
+			case event := <-recorder.Events:
+				if strings.Contains(event, "Scheduling") && strings.Contains(event, "pod-task01") {
+					published++
+				}
+			default:
+				return published
+			}
+		}
+	}
+	mockedContext.askBatcher = newAskBatcher(mockedAPIProvider.GetAPIs().SchedulerAPI, 20*time.Millisecond, 100, mockedContext.asksSent)
+
+	app := NewApplication("app01", "root.default", "bob", testGroups, map[string]string{}, mockedAPIProvider.GetAPIs().SchedulerAPI)
+	mockedContext.addApplication(app)
+	newPendingTask := func(taskID string) *Task {
+		pod := newPodHelper("pod-"+taskID, "default", "UID-"+taskID, "", app.applicationID, v1.PodPending)
+		task := NewTask(taskID, app, mockedContext, pod)
+		app.addTask(task)
+		task.sm.SetState(TaskStates().Pending)
+		return task
+	}
+
+	// the Scheduling event is published once the batch is sent
+	task1 := newPendingTask("task01")
+	assert.NilError(t, task1.handle(NewSubmitTaskEvent(app.applicationID, task1.taskID)))
+	assert.Equal(t, task1.GetTaskState(), TaskStates().Scheduling)
+	assert.Equal(t, getPublished(), 0, "event published before the ask was sent")
+	err := utils.WaitForCondition(func() bool {
+		return getPublished() == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "event not published after the ask was sent")
+
+	// the task is rejected once the retries of the batch are exhausted
+	lock.Lock()
+	sendErr = errors.New("core failure")
+	lock.Unlock()
+	task2 := newPendingTask("task02")
+	assert.NilError(t, task2.handle(NewSubmitTaskEvent(app.applicationID, task2.taskID)))
+	err = utils.WaitForCondition(func() bool {
+		return task2.GetTaskState() == TaskStates().Failed
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "task not failed after the ask could not be sent")
+}
+
+func TestSubmitTaskPartition(t *testing.T) {
+	mockedContext := initContextForTest()
+	mockedApiProvider, ok := mockedContext.apiProvider.(*client.MockedAPIProvider)
+	assert.Assert(t, ok, "expecting MockedAPIProvider")
+	pod := &v1.Pod{
+		TypeMeta: apis.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-resource-test-00001",
+			UID:  "UID-00001",
+		},
+	}
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, mockedApiProvider.GetAPIs().SchedulerAPI)
+	app.partition = "gpu"
+	task := NewTask("task01", app, mockedContext, pod)
+
+	var partition string
+	mockedApiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		assert.Equal(t, len(request.Asks), 1)
+		partition = request.Asks[0].PartitionName
+		return nil
+	})
+	err := task.handle(NewSimpleTaskEvent(task.applicationID, task.taskID, InitTask))
+	assert.NilError(t, err, "failed to handle InitTask event")
+	err = task.handle(NewSubmitTaskEvent(app.applicationID, task.taskID))
+	assert.NilError(t, err, "failed to handle SubmitTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Scheduling)
+	assert.Equal(t, partition, "gpu", "ask not submitted to the application partition")
+}
+
 func TestReleaseTaskAsk(t *testing.T) {
 	mockedSchedulerApi := newMockSchedulerAPI()
 	mockedContext := initContextForTest()
@@ -741,3 +847,349 @@ func TestUpdatePodCondition(t *testing.T) {
 	assert.Equal(t, v1.PodPending, podCopy.Status.Phase)
 	assert.Equal(t, v1.PodReasonUnschedulable, podCopy.Status.Conditions[0].Reason)
 }
+
+func TestServiceTaskResource(t *testing.T) {
+	mockedContext := initContextForTest()
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "pod-service-test-00001",
+			Namespace: "ns-service",
+			UID:       "UID-00001",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "container-01",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+				},
+			}},
+		},
+	}
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("2")
+
+	app := NewApplication("service-app01", "root.default", "bob", testGroups,
+		map[string]string{constants.AnnotationApplicationProfile: constants.ApplicationProfileService}, mockedSchedulerAPI)
+	assert.Assert(t, app.isService(), "application should have the service profile")
+	task := NewTask("task01", app, mockedContext, pod)
+	app.addTask(task)
+
+	task.sm.SetState(TaskStates().Allocated)
+	err := task.handle(NewBindTaskEvent(app.applicationID, task.taskID))
+	assert.NilError(t, err, "failed to handle BindTask event")
+	value, err := metrics.GetServiceApplicationResource("ns-service", "service-app01", siCommon.CPU)
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(1000))
+
+	task.updateResource(resized)
+	value, err = metrics.GetServiceApplicationResource("ns-service", "service-app01", siCommon.CPU)
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(2000))
+
+	err = task.handle(NewSimpleTaskEvent(app.applicationID, task.taskID, CompleteTask))
+	assert.NilError(t, err, "failed to handle CompleteTask event")
+	value, err = metrics.GetServiceApplicationResource("ns-service", "service-app01", siCommon.CPU)
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(0))
+}
+
+func TestUpdateResource(t *testing.T) {
+	mockedContext, mockedAPIProvider := initContextAndAPIProviderForTest()
+	mockedSchedulerAPI := newMockSchedulerAPI()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-resize-test-00001",
+			UID:  "UID-00001",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "container-01",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+				},
+			}},
+		},
+	}
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("2")
+
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, mockedSchedulerAPI)
+	task := NewTask("task01", app, mockedContext, pod)
+	app.addTask(task)
+
+	// a task that is not allocated keeps the resource of its ask
+	task.sm.SetState(TaskStates().Scheduling)
+	task.updateResource(resized)
+	assert.Equal(t, task.resource.Resources[siCommon.CPU].GetValue(), int64(1000))
+
+	// the allocation of a bound task is replaced in the core
+	var request *si.AllocationRequest
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		request = rr
+		return nil
+	})
+	task.sm.SetState(TaskStates().Bound)
+	task.allocationUUID = "uuid-1"
+	task.nodeName = "node-1"
+	assert.Equal(t, app.GetAllocatedResource().Resources[siCommon.CPU].GetValue(), int64(1000))
+	task.updateResource(resized)
+	assert.Equal(t, task.resource.Resources[siCommon.CPU].GetValue(), int64(2000))
+	assert.Equal(t, app.GetAllocatedResource().Resources[siCommon.CPU].GetValue(), int64(2000))
+	assert.Assert(t, request != nil, "resize not sent to the core")
+	assert.Equal(t, len(request.Asks), 1)
+	assert.Equal(t, request.Asks[0].AllocationKey, "task01")
+	assert.Equal(t, request.Asks[0].ResourceAsk.Resources[siCommon.CPU].GetValue(), int64(2000))
+	assert.Equal(t, request.Asks[0].Tags[siCommon.DomainYuniKorn+siCommon.KeyRequiredNode], "node-1")
+	assert.Assert(t, request.Releases == nil, "allocation of resized task released before the new allocation")
+	assert.Assert(t, task.resizing, "task not waiting for the resized allocation")
+
+	// the new allocation on the node replaces the allocation of the task, the old allocation is released
+	request = nil
+	err := task.handle(NewAllocateTaskEvent(app.applicationID, task.taskID, "uuid-2", "node-1"))
+	assert.NilError(t, err, "failed to handle AllocateTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+	assert.Equal(t, task.allocationUUID, "uuid-2")
+	assert.Assert(t, !task.resizing, "task still waiting for the resized allocation")
+	assert.Assert(t, task.resizeTimer == nil, "resize timer not stopped")
+	assert.Assert(t, request != nil, "replaced allocation not released")
+	assert.Equal(t, len(request.Releases.AllocationsToRelease), 1)
+	assert.Equal(t, request.Releases.AllocationsToRelease[0].UUID, "uuid-1")
+
+	// any other allocation of a bound task is released
+	request = nil
+	err = task.handle(NewAllocateTaskEvent(app.applicationID, task.taskID, "uuid-3", "node-1"))
+	assert.NilError(t, err, "failed to handle AllocateTask event")
+	assert.Equal(t, task.allocationUUID, "uuid-2")
+	assert.Assert(t, request != nil, "unexpected allocation not released")
+	assert.Equal(t, request.Releases.AllocationsToRelease[0].UUID, "uuid-3")
+}
+
+// newResizedBoundTask returns a bound task with an allocation on node-1 and the pod of the task resized to 2 cores
+func newResizedBoundTask(mockedContext *Context) (*Task, *v1.Pod) {
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-resize-test-00001",
+			UID:  "UID-00001",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "container-01",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+				},
+			}},
+		},
+	}
+	resized := pod.DeepCopy()
+	resized.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("2")
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, mockedContext, pod)
+	app.addTask(task)
+	task.sm.SetState(TaskStates().Bound)
+	task.allocationUUID = "uuid-1"
+	task.nodeName = "node-1"
+	return task, resized
+}
+
+func TestUpdateResourceRejected(t *testing.T) {
+	mockedContext, mockedAPIProvider := initContextAndAPIProviderForTest()
+	requests := make([]*si.AllocationRequest, 0)
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		requests = append(requests, rr)
+		return nil
+	})
+	task, resized := newResizedBoundTask(mockedContext)
+	task.updateResource(resized)
+	assert.Equal(t, len(requests), 1, "resize not sent to the core")
+	assert.Assert(t, task.resizing, "task not waiting for the resized allocation")
+
+	// the resized ask is rejected: the task stays bound on its node
+	err := task.handle(NewRejectTaskEvent(task.applicationID, task.taskID, "node full"))
+	assert.NilError(t, err, "failed to handle RejectTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+	assert.Assert(t, !task.resizing, "task still waiting for the resized allocation")
+	assert.Assert(t, task.resizeTimer == nil, "resize timer not stopped")
+	assert.Equal(t, task.nodeName, "node-1")
+	assert.Equal(t, task.allocationUUID, "uuid-1")
+	assert.Equal(t, len(requests), 1, "unexpected core update")
+
+	// a rejection without a resize does not change the task
+	err = task.handle(NewRejectTaskEvent(task.applicationID, task.taskID, "unexpected"))
+	assert.NilError(t, err, "failed to handle RejectTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+
+	// a resize that cannot be sent is not waiting for an allocation
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		return fmt.Errorf("core unavailable")
+	})
+	resized.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("3")
+	task.updateResource(resized)
+	assert.Equal(t, task.resource.Resources[siCommon.CPU].GetValue(), int64(3000))
+	assert.Assert(t, !task.resizing, "task waiting for a resize that was not sent")
+	assert.Assert(t, task.resizeTimer == nil, "resize timer not stopped")
+}
+
+func TestUpdateResourceTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { resizeTimeout = timeout }(resizeTimeout)
+	resizeTimeout = 50 * time.Millisecond
+	mockedContext, mockedAPIProvider := initContextAndAPIProviderForTest()
+	var lock sync.Mutex
+	requests := make([]*si.AllocationRequest, 0)
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, rr)
+		return nil
+	})
+	task, resized := newResizedBoundTask(mockedContext)
+	task.updateResource(resized)
+
+	// the resized ask is never allocated: it is released and the task keeps its allocation
+	err := utils.WaitForCondition(func() bool {
+		task.lock.RLock()
+		defer task.lock.RUnlock()
+		return !task.resizing
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "resize did not time out")
+	err = utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(requests) == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "resized ask not released")
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, len(requests[1].Releases.AllocationAsksToRelease), 1)
+	assert.Equal(t, requests[1].Releases.AllocationAsksToRelease[0].AllocationKey, "task01")
+	assert.Equal(t, len(requests[1].Releases.AllocationsToRelease), 0)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+	assert.Equal(t, task.allocationUUID, "uuid-1")
+}
+
+func TestUpdateResourceReleased(t *testing.T) {
+	mockedContext, mockedAPIProvider := initContextAndAPIProviderForTest()
+	requests := make([]*si.AllocationRequest, 0)
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		requests = append(requests, rr)
+		return nil
+	})
+
+	// the pod is deleted after the resize was sent: the allocation and the resized ask are released
+	task, resized := newResizedBoundTask(mockedContext)
+	task.updateResource(resized)
+	assert.Equal(t, len(requests), 1, "resize not sent to the core")
+	err := task.handle(NewSimpleTaskEvent(task.applicationID, task.taskID, CompleteTask))
+	assert.NilError(t, err, "failed to handle CompleteTask event")
+	assert.Assert(t, !task.resizing, "completed task still waiting for the resized allocation")
+	assert.Equal(t, len(requests), 2, "task not released")
+	assert.Equal(t, requests[1].Releases.AllocationsToRelease[0].UUID, "uuid-1")
+	assert.Equal(t, len(requests[1].Releases.AllocationAsksToRelease), 1)
+	assert.Equal(t, requests[1].Releases.AllocationAsksToRelease[0].AllocationKey, "task01")
+
+	// the pod is deleted while the resize is sent: the task is not locked while sending, and the resized ask
+	// that might reach the core after the release is released again
+	task, resized = newResizedBoundTask(mockedContext)
+	requests = requests[:0]
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		requests = append(requests, rr)
+		if len(rr.Asks) != 0 {
+			assert.Assert(t, task.lock.TryLock(), "task locked while sending the resize to the core")
+			task.sm.SetState(TaskStates().Completed)
+			task.stopResizing()
+			task.lock.Unlock()
+		}
+		return nil
+	})
+	task.updateResource(resized)
+	assert.Equal(t, len(requests), 2, "unexpected core updates")
+	assert.Equal(t, requests[0].Asks[0].AllocationKey, "task01")
+	assert.Assert(t, requests[0].Releases == nil, "allocation released with the resized ask")
+	assert.Equal(t, len(requests[1].Releases.AllocationAsksToRelease), 1)
+	assert.Equal(t, requests[1].Releases.AllocationAsksToRelease[0].AllocationKey, "task01")
+
+	// an allocation for the resized ask that was processed anyway is released
+	requests = requests[:0]
+	err = task.handle(NewAllocateTaskEvent(task.applicationID, task.taskID, "uuid-2", "node-1"))
+	assert.NilError(t, err, "failed to handle AllocateTask event")
+	assert.Equal(t, len(requests), 1, "allocation of completed task not released")
+}
+
+func TestRescheduleTask(t *testing.T) {
+	mockedContext, mockedAPIProvider := initContextAndAPIProviderForTest()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-reschedule-test-00001",
+			UID:  "UID-00001",
+		},
+	}
+	app := NewApplication("app01", "root.default",
+		"bob", testGroups, map[string]string{}, mockedAPIProvider.GetAPIs().SchedulerAPI)
+	task := NewTask("task01", app, mockedContext, pod)
+
+	var request *si.AllocationRequest
+	mockedAPIProvider.MockSchedulerAPIUpdateAllocationFn(func(rr *si.AllocationRequest) error {
+		if rr.Releases != nil {
+			request = rr
+		}
+		return nil
+	})
+	// only an allocated task can be scheduled again
+	task.sm.SetState(TaskStates().Bound)
+	assert.Assert(t, !task.canHandle(NewSimpleTaskEvent(app.applicationID, task.taskID, RescheduleTask)))
+
+	// the allocation is released and the task is pending again
+	task.sm.SetState(TaskStates().Allocated)
+	task.allocationUUID = "uuid-1"
+	task.nodeName = "node-1"
+	err := task.handle(NewSimpleTaskEvent(app.applicationID, task.taskID, RescheduleTask))
+	assert.NilError(t, err, "failed to handle RescheduleTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Pending)
+	assert.Equal(t, task.allocationUUID, "")
+	assert.Equal(t, task.nodeName, "")
+	assert.Assert(t, request != nil, "allocation not released")
+	assert.Equal(t, len(request.Releases.AllocationsToRelease), 1)
+	assert.Equal(t, request.Releases.AllocationsToRelease[0].UUID, "uuid-1")
+	assert.Equal(t, request.Releases.AllocationsToRelease[0].TerminationType, si.TerminationType_STOPPED_BY_RM)
+}
+
+func TestTaskStateTransitionMetrics(t *testing.T) {
+	mockedContext := initContextForTest()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-metrics-test-00001",
+			UID:  "UID-00001",
+		},
+	}
+	app := NewApplication("app01", "root.default", "bob", testGroups, map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, mockedContext, pod)
+
+	transitions, err := metrics.GetTaskStateTransitions(TaskStates().New, TaskStates().Pending)
+	assert.NilError(t, err)
+	durations, err := metrics.GetTaskStateDurationCount(TaskStates().New)
+	assert.NilError(t, err)
+	err = task.handle(NewSimpleTaskEvent(task.applicationID, task.taskID, InitTask))
+	assert.NilError(t, err, "failed to handle InitTask event")
+	count, err := metrics.GetTaskStateTransitions(TaskStates().New, TaskStates().Pending)
+	assert.NilError(t, err)
+	assert.Equal(t, count, transitions+1)
+	count, err = metrics.GetTaskStateDurationCount(TaskStates().New)
+	assert.NilError(t, err)
+	assert.Equal(t, count, durations+1)
+
+	transitions, err = metrics.GetTaskStateTransitions(TaskStates().Pending, TaskStates().Scheduling)
+	assert.NilError(t, err)
+	err = task.handle(NewSubmitTaskEvent(task.applicationID, task.taskID))
+	assert.NilError(t, err, "failed to handle SubmitTask event")
+	count, err = metrics.GetTaskStateTransitions(TaskStates().Pending, TaskStates().Scheduling)
+	assert.NilError(t, err)
+	assert.Equal(t, count, transitions+1)
+}
@@ -22,10 +22,13 @@ import (
 	"fmt"
 
 	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
 
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 
 	"github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -58,6 +61,14 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 
 		// update cache
 		if err := callback.context.AssumePod(alloc.AllocationKey, alloc.NodeID); err != nil {
+			// the shim does not agree with the node selected by the core
+			metrics.IncSchedulingFailure(metrics.ShimPredicate, "PodAssumeFailure")
+			if app := callback.context.GetApplication(alloc.ApplicationID); app != nil {
+				if task, taskErr := app.GetTask(alloc.AllocationKey); taskErr == nil {
+					events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "PodAssumeFailure", metrics.ShimPredicate.String(),
+						"Failed to assume pod on node %s: %v", alloc.NodeID, err)
+				}
+			}
 			return err
 		}
 		if app := callback.context.GetApplication(alloc.ApplicationID); app != nil {
@@ -72,8 +83,8 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 			zap.String("allocationKey", reject.AllocationKey))
 		if app := callback.context.GetApplication(reject.ApplicationID); app != nil {
 			dispatcher.Dispatch(cache.NewRejectTaskEvent(app.GetApplicationID(), reject.AllocationKey,
-				fmt.Sprintf("task %s from application %s is rejected by scheduler",
-					reject.AllocationKey, reject.ApplicationID)))
+				fmt.Sprintf("task %s from application %s is rejected by scheduler: %s",
+					reject.AllocationKey, reject.ApplicationID, reject.Reason)))
 		}
 	}
 
@@ -84,6 +95,11 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 		// update cache
 		callback.context.ForgetPod(release.GetAllocationKey())
 
+		// record the preemption while the task still references the allocation
+		if release.TerminationType == si.TerminationType_PREEMPTED_BY_SCHEDULER {
+			callback.context.RecordPreemption(release)
+		}
+
 		// TerminationType 0 mean STOPPED_BY_RM
 		if release.TerminationType != si.TerminationType_STOPPED_BY_RM {
 			// send release app allocation to application states machine
@@ -23,10 +23,12 @@ import (
 	"time"
 
 	"go.uber.org/zap"
-	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/informers"
+	appsInformerV1 "k8s.io/client-go/informers/apps/v1"
+	policyInformerV1 "k8s.io/client-go/informers/policy/v1"
+	resourceInformerV1alpha2 "k8s.io/client-go/informers/resource/v1alpha2"
+	storageInformerV1 "k8s.io/client-go/informers/storage/v1"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/kubernetes/pkg/features"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
 
 	appclient "github.com/apache/yunikorn-k8shim/pkg/client/clientset/versioned"
@@ -40,7 +42,7 @@ import (
 
 type Type int
 
-var informerTypes = [...]string{"Pod", "Node", "ConfigMap", "Storage", "PV", "PVC", "Application", "PriorityClass"}
+var informerTypes = [...]string{"Pod", "Node", "ConfigMap", "Storage", "PV", "PVC", "Application", "PriorityClass", "Namespace"}
 
 const (
 	PodInformerHandlers Type = iota
@@ -51,6 +53,7 @@ const (
 	PVCInformerHandlers
 	ApplicationInformerHandlers
 	PriorityClassInformerHandlers
+	NamespaceInformerHandlers
 )
 
 func (t Type) String() string {
@@ -100,12 +103,21 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 	namespaceInformer := informerFactory.Core().V1().Namespaces()
 	priorityClassInformer := informerFactory.Scheduling().V1().PriorityClasses()
 
+	// pods with WaitForFirstConsumer claims are only placed on nodes for which the CSI driver reports enough
+	// capacity if the check is enabled, the informers are shared with the VolumeBinding predicate and must be
+	// running for the check
 	var capacityCheck volumebinding.CapacityCheck
-	if utilfeature.DefaultFeatureGate.Enabled(features.CSIStorageCapacity) {
+	var csiDriverInformer storageInformerV1.CSIDriverInformer = nil
+	var csiStorageCapacityInformer storageInformerV1.CSIStorageCapacityInformer = nil
+	var runCSINodeInformer storageInformerV1.CSINodeInformer = nil
+	if configs.StorageCapacityCheck {
+		csiDriverInformer = informerFactory.Storage().V1().CSIDrivers()
+		csiStorageCapacityInformer = informerFactory.Storage().V1().CSIStorageCapacities()
 		capacityCheck = volumebinding.CapacityCheck{
-			CSIDriverInformer:          informerFactory.Storage().V1().CSIDrivers(),
-			CSIStorageCapacityInformer: informerFactory.Storage().V1().CSIStorageCapacities(),
+			CSIDriverInformer:          csiDriverInformer,
+			CSIStorageCapacityInformer: csiStorageCapacityInformer,
 		}
+		runCSINodeInformer = csiNodeInformer
 	}
 
 	var appClient *appclient.Clientset = nil
@@ -116,6 +128,28 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 		applicationInformer = appinformers.NewSharedInformerFactory(appClient, time.Minute*1).Apache().V1alpha1().Applications()
 	}
 
+	// the resource.k8s.io API is alpha and must be enabled in the cluster, the informers would never sync otherwise
+	var resourceClaimInformer resourceInformerV1alpha2.ResourceClaimInformer = nil
+	var resourceClaimTemplateInformer resourceInformerV1alpha2.ResourceClaimTemplateInformer = nil
+	var podSchedulingContextInformer resourceInformerV1alpha2.PodSchedulingContextInformer = nil
+	if configs.DynamicResourceAllocation {
+		resourceClaimInformer = informerFactory.Resource().V1alpha2().ResourceClaims()
+		resourceClaimTemplateInformer = informerFactory.Resource().V1alpha2().ResourceClaimTemplates()
+		podSchedulingContextInformer = informerFactory.Resource().V1alpha2().PodSchedulingContexts()
+	}
+
+	// the PodDisruptionBudgets are only watched if they are checked before preempting pods
+	var pdbInformer policyInformerV1.PodDisruptionBudgetInformer = nil
+	if configs.HonorDisruptionBudgets {
+		pdbInformer = informerFactory.Policy().V1().PodDisruptionBudgets()
+	}
+
+	// the DaemonSets are only watched if resources are reserved for their pods on new nodes
+	var daemonSetInformer appsInformerV1.DaemonSetInformer = nil
+	if configs.DaemonSetReservationTimeout > 0 {
+		daemonSetInformer = informerFactory.Apps().V1().DaemonSets()
+	}
+
 	// create a volume binder (needs the informers)
 	volumeBinder := volumebinding.NewVolumeBinder(
 		kubeClient.GetClientSet(),
@@ -130,21 +164,29 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 
 	return &APIFactory{
 		clients: &Clients{
-			conf:                  configs,
-			KubeClient:            kubeClient,
-			AppClient:             appClient,
-			SchedulerAPI:          scheduler,
-			InformerFactory:       informerFactory,
-			PodInformer:           podInformer,
-			NodeInformer:          nodeInformer,
-			ConfigMapInformer:     configMapInformer,
-			PVInformer:            pvInformer,
-			PVCInformer:           pvcInformer,
-			NamespaceInformer:     namespaceInformer,
-			StorageInformer:       storageInformer,
-			PriorityClassInformer: priorityClassInformer,
-			VolumeBinder:          volumeBinder,
-			AppInformer:           applicationInformer,
+			conf:                          configs,
+			KubeClient:                    kubeClient,
+			AppClient:                     appClient,
+			SchedulerAPI:                  scheduler,
+			InformerFactory:               informerFactory,
+			PodInformer:                   podInformer,
+			NodeInformer:                  nodeInformer,
+			ConfigMapInformer:             configMapInformer,
+			PVInformer:                    pvInformer,
+			PVCInformer:                   pvcInformer,
+			NamespaceInformer:             namespaceInformer,
+			StorageInformer:               storageInformer,
+			CSINodeInformer:               runCSINodeInformer,
+			CSIDriverInformer:             csiDriverInformer,
+			CSIStorageCapacityInformer:    csiStorageCapacityInformer,
+			PriorityClassInformer:         priorityClassInformer,
+			PDBInformer:                   pdbInformer,
+			VolumeBinder:                  volumeBinder,
+			AppInformer:                   applicationInformer,
+			ResourceClaimInformer:         resourceClaimInformer,
+			ResourceClaimTemplateInformer: resourceClaimTemplateInformer,
+			PodSchedulingContextInformer:  podSchedulingContextInformer,
+			DaemonSetInformer:             daemonSetInformer,
 		},
 		testMode: testMode,
 		stopChan: make(chan struct{}),
@@ -213,6 +255,9 @@ func (s *APIFactory) addEventHandlers(
 	case PriorityClassInformerHandlers:
 		s.GetAPIs().PriorityClassInformer.Informer().
 			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	case NamespaceInformerHandlers:
+		s.GetAPIs().NamespaceInformer.Informer().
+			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
 	}
 }
 
@@ -93,6 +93,7 @@ func NewMockedAPIProvider(showError bool) *MockedAPIProvider {
 			AppInformer:           test.NewAppInformerMock(),
 			NamespaceInformer:     test.NewMockNamespaceInformer(false),
 			PriorityClassInformer: test.NewMockPriorityClassInformer(),
+			PDBInformer:           test.NewMockPDBInformer(),
 			InformerFactory:       informers.NewSharedInformerFactory(k8fake.NewSimpleClientset(), time.Second*60),
 		},
 		events:       make(chan informerEvent),
@@ -141,6 +142,12 @@ func (m *MockedAPIProvider) GetSchedulerAPIUpdateNodeCount() int32 {
 	return int32(0)
 }
 
+func (m *MockedAPIProvider) MockSchedulerAPIUpdateConfigFn(ufn func(request *si.UpdateConfigurationRequest) error) {
+	if mock, ok := m.clients.SchedulerAPI.(*test.SchedulerAPIMock); ok {
+		mock.UpdateConfigFunction(ufn)
+	}
+}
+
 func (m *MockedAPIProvider) GetSchedulerAPIRegisterCount() int32 {
 	if mock, ok := m.clients.SchedulerAPI.(*test.SchedulerAPIMock); ok {
 		return mock.GetRegisterCount()
@@ -172,6 +179,12 @@ func (m *MockedAPIProvider) MockUpdateStatusFn(cfn func(pod *v1.Pod) (*v1.Pod, e
 	}
 }
 
+func (m *MockedAPIProvider) MockUpdatePodFn(ufn func(pod *v1.Pod, podMutator func(pod *v1.Pod)) (*v1.Pod, error)) {
+	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
+		mock.updateFn = ufn
+	}
+}
+
 func (m *MockedAPIProvider) MockGetFn(cfn func(podName string) (*v1.Pod, error)) {
 	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
 		mock.getFn = cfn
@@ -19,6 +19,7 @@
 package client
 
 import (
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
@@ -26,7 +27,10 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/client/informers/externalversions/yunikorn.apache.org/v1alpha1"
 
 	"k8s.io/client-go/informers"
+	appsInformerV1 "k8s.io/client-go/informers/apps/v1"
 	coreInformerV1 "k8s.io/client-go/informers/core/v1"
+	policyInformerV1 "k8s.io/client-go/informers/policy/v1"
+	resourceInformerV1alpha2 "k8s.io/client-go/informers/resource/v1alpha2"
 	schedulingInformerV1 "k8s.io/client-go/informers/scheduling/v1"
 	storageInformerV1 "k8s.io/client-go/informers/storage/v1"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
@@ -37,6 +41,10 @@ import (
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 )
 
+// ErrCoreRequestBuffered is returned by the SchedulerAPI for a request that is not sent to the core yet: the
+// communication with the core is interrupted and the request is sent in order once it is restored
+var ErrCoreRequestBuffered = errors.New("communication with the core interrupted, request buffered")
+
 // clients encapsulates a set of useful client APIs
 // that can be shared by callers when talking to K8s api-server,
 // or the scheduler core.
@@ -53,15 +61,27 @@ type Clients struct {
 	InformerFactory informers.SharedInformerFactory
 
 	// resource informers
-	PodInformer           coreInformerV1.PodInformer
-	NodeInformer          coreInformerV1.NodeInformer
-	ConfigMapInformer     coreInformerV1.ConfigMapInformer
-	PVInformer            coreInformerV1.PersistentVolumeInformer
-	PVCInformer           coreInformerV1.PersistentVolumeClaimInformer
-	StorageInformer       storageInformerV1.StorageClassInformer
-	NamespaceInformer     coreInformerV1.NamespaceInformer
-	PriorityClassInformer schedulingInformerV1.PriorityClassInformer
-	AppInformer           v1alpha1.ApplicationInformer
+	PodInformer       coreInformerV1.PodInformer
+	NodeInformer      coreInformerV1.NodeInformer
+	ConfigMapInformer coreInformerV1.ConfigMapInformer
+	PVInformer        coreInformerV1.PersistentVolumeInformer
+	PVCInformer       coreInformerV1.PersistentVolumeClaimInformer
+	StorageInformer   storageInformerV1.StorageClassInformer
+	// CSI informers used by the volume binder, only set if the storage capacity reported by CSI drivers is checked
+	CSINodeInformer            storageInformerV1.CSINodeInformer
+	CSIDriverInformer          storageInformerV1.CSIDriverInformer
+	CSIStorageCapacityInformer storageInformerV1.CSIStorageCapacityInformer
+	NamespaceInformer          coreInformerV1.NamespaceInformer
+	PriorityClassInformer      schedulingInformerV1.PriorityClassInformer
+	AppInformer                v1alpha1.ApplicationInformer
+	// PodDisruptionBudget informer, only set if the budgets are checked before preempting pods
+	PDBInformer policyInformerV1.PodDisruptionBudgetInformer
+	// DRA informers, only set if dynamic resource allocation is enabled
+	ResourceClaimInformer         resourceInformerV1alpha2.ResourceClaimInformer
+	ResourceClaimTemplateInformer resourceInformerV1alpha2.ResourceClaimTemplateInformer
+	PodSchedulingContextInformer  resourceInformerV1alpha2.PodSchedulingContextInformer
+	// DaemonSet informer, only set if resources are reserved for DaemonSet pods on new nodes
+	DaemonSetInformer appsInformerV1.DaemonSetInformer
 
 	// volume binder handles PV/PVC related operations
 	VolumeBinder volumebinding.SchedulerVolumeBinder
@@ -80,10 +100,18 @@ func (c *Clients) WaitForSync() {
 			c.PVCInformer.Informer().HasSynced() &&
 			c.PVInformer.Informer().HasSynced() &&
 			c.StorageInformer.Informer().HasSynced() &&
+			(c.CSINodeInformer == nil || c.CSINodeInformer.Informer().HasSynced()) &&
+			(c.CSIDriverInformer == nil || c.CSIDriverInformer.Informer().HasSynced()) &&
+			(c.CSIStorageCapacityInformer == nil || c.CSIStorageCapacityInformer.Informer().HasSynced()) &&
 			c.ConfigMapInformer.Informer().HasSynced() &&
 			c.NamespaceInformer.Informer().HasSynced() &&
 			c.PriorityClassInformer.Informer().HasSynced() &&
-			(c.AppInformer == nil || c.AppInformer.Informer().HasSynced()) {
+			(c.PDBInformer == nil || c.PDBInformer.Informer().HasSynced()) &&
+			(c.AppInformer == nil || c.AppInformer.Informer().HasSynced()) &&
+			(c.ResourceClaimInformer == nil || c.ResourceClaimInformer.Informer().HasSynced()) &&
+			(c.ResourceClaimTemplateInformer == nil || c.ResourceClaimTemplateInformer.Informer().HasSynced()) &&
+			(c.PodSchedulingContextInformer == nil || c.PodSchedulingContextInformer.Informer().HasSynced()) &&
+			(c.DaemonSetInformer == nil || c.DaemonSetInformer.Informer().HasSynced()) {
 			return
 		}
 		time.Sleep(time.Second)
@@ -101,10 +129,34 @@ func (c *Clients) Run(stopCh <-chan struct{}) {
 	go c.PVInformer.Informer().Run(stopCh)
 	go c.PVCInformer.Informer().Run(stopCh)
 	go c.StorageInformer.Informer().Run(stopCh)
+	if c.CSINodeInformer != nil {
+		go c.CSINodeInformer.Informer().Run(stopCh)
+	}
+	if c.CSIDriverInformer != nil {
+		go c.CSIDriverInformer.Informer().Run(stopCh)
+	}
+	if c.CSIStorageCapacityInformer != nil {
+		go c.CSIStorageCapacityInformer.Informer().Run(stopCh)
+	}
 	go c.ConfigMapInformer.Informer().Run(stopCh)
 	go c.NamespaceInformer.Informer().Run(stopCh)
 	go c.PriorityClassInformer.Informer().Run(stopCh)
+	if c.PDBInformer != nil {
+		go c.PDBInformer.Informer().Run(stopCh)
+	}
 	if c.AppInformer != nil {
 		go c.AppInformer.Informer().Run(stopCh)
 	}
+	if c.ResourceClaimInformer != nil {
+		go c.ResourceClaimInformer.Informer().Run(stopCh)
+	}
+	if c.ResourceClaimTemplateInformer != nil {
+		go c.ResourceClaimTemplateInformer.Informer().Run(stopCh)
+	}
+	if c.PodSchedulingContextInformer != nil {
+		go c.PodSchedulingContextInformer.Informer().Run(stopCh)
+	}
+	if c.DaemonSetInformer != nil {
+		go c.DaemonSetInformer.Informer().Run(stopCh)
+	}
 }
@@ -55,6 +55,16 @@ func NewKubeClient(kc string) KubeClient {
 	return newSchedulerKubeClient(kc)
 }
 
+// NewEventsKubeClient creates the client used to publish events, with a rate limit of its own.
+func NewEventsKubeClient(kc string) KubeClient {
+	return newEventsKubeClient(kc)
+}
+
+// NewRateLimitedKubeClient creates a client with the rate limit, the name identifies the client in the logs.
+func NewRateLimitedKubeClient(kc string, name string, limit RateLimit) KubeClient {
+	return newRateLimitedKubeClient(kc, name, limit)
+}
+
 func NewBootstrapKubeClient(kc string) KubeClient {
 	return newBootstrapSchedulerKubeClient(kc)
 }
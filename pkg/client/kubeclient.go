@@ -54,10 +54,34 @@ func newBootstrapSchedulerKubeClient(kc string) SchedulerKubeClient {
 
 func newSchedulerKubeClient(kc string) SchedulerKubeClient {
 	schedulerConf := conf.GetSchedulerConf()
+	return newRateLimitedKubeClient(kc, "scheduler", RateLimit{
+		QPS:      float32(schedulerConf.KubeQPS),
+		Burst:    schedulerConf.KubeBurst,
+		Adaptive: schedulerConf.KubeAdaptiveRateLimit,
+	})
+}
+
+// newEventsKubeClient creates the client used to publish events, the events are rate limited separately from the
+// other requests of the scheduler to keep them from delaying the binds.
+func newEventsKubeClient(kc string) SchedulerKubeClient {
+	schedulerConf := conf.GetSchedulerConf()
+	limit := RateLimit{
+		QPS:      float32(schedulerConf.KubeEventsQPS),
+		Burst:    schedulerConf.KubeEventsBurst,
+		Adaptive: schedulerConf.KubeAdaptiveRateLimit,
+	}
+	if limit.QPS <= 0 {
+		limit.QPS = float32(schedulerConf.KubeQPS)
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = schedulerConf.KubeBurst
+	}
+	return newRateLimitedKubeClient(kc, "events", limit)
+}
 
+func newRateLimitedKubeClient(kc string, name string, limit RateLimit) SchedulerKubeClient {
 	config := CreateRestConfigOrDie(kc)
-	config.QPS = float32(schedulerConf.KubeQPS)
-	config.Burst = schedulerConf.KubeBurst
+	limit.apply(name, config)
 	configuredClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		log.Log(log.ShimClient).Fatal("failed to get Clientset", zap.Error(err))
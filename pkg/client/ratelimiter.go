@@ -0,0 +1,169 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/rest"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// a burst of 429 responses to concurrent requests only lowers the QPS once
+	adaptiveBackoffInterval = time.Second
+	// the QPS is raised again after this interval without 429 responses
+	adaptiveRecoveryInterval = 10 * time.Second
+	adaptiveBackoffFactor    = 0.5
+	adaptiveRecoveryFactor   = 1.25
+	// the QPS is never lowered below this fraction of the configured QPS
+	adaptiveMinQPSFraction = 0.05
+)
+
+// RateLimit configures the client side rate limiting of the requests of a client to the API server.
+type RateLimit struct {
+	QPS   float32
+	Burst int
+	// Adaptive lowers the QPS while the API server throttles the client with 429 Too Many Requests responses
+	Adaptive bool
+}
+
+// apply sets the rate limit on the REST config of the named client
+func (l RateLimit) apply(name string, config *rest.Config) {
+	config.QPS = l.QPS
+	config.Burst = l.Burst
+	if !l.Adaptive || l.QPS <= 0 {
+		return
+	}
+	limiter := newAdaptiveRateLimiter(name, l.QPS, l.Burst)
+	config.RateLimiter = limiter
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &throttleDetector{next: rt, limiter: limiter}
+	})
+}
+
+// adaptiveRateLimiter is a token bucket rate limiter that halves its QPS when the API server responds with a
+// 429, and slowly recovers to the configured QPS once the API server stops throttling the client.
+type adaptiveRateLimiter struct {
+	name       string
+	limiter    *rate.Limiter
+	maxQPS     float64
+	minQPS     float64
+	lastChange time.Time
+	throttled  bool
+	lock       sync.Mutex
+}
+
+func newAdaptiveRateLimiter(name string, qps float32, burst int) *adaptiveRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	minQPS := float64(qps) * adaptiveMinQPSFraction
+	if minQPS < 1 {
+		minQPS = 1
+	}
+	return &adaptiveRateLimiter{
+		name:    name,
+		limiter: rate.NewLimiter(rate.Limit(qps), burst),
+		maxQPS:  float64(qps),
+		minQPS:  minQPS,
+	}
+}
+
+func (l *adaptiveRateLimiter) TryAccept() bool {
+	l.recover(time.Now())
+	return l.limiter.Allow()
+}
+
+func (l *adaptiveRateLimiter) Accept() {
+	_ = l.Wait(context.Background())
+}
+
+func (l *adaptiveRateLimiter) Wait(ctx context.Context) error {
+	l.recover(time.Now())
+	return l.limiter.Wait(ctx)
+}
+
+func (l *adaptiveRateLimiter) Stop() {}
+
+func (l *adaptiveRateLimiter) QPS() float32 {
+	return float32(l.limiter.Limit())
+}
+
+// backOff lowers the QPS after a 429 response
+func (l *adaptiveRateLimiter) backOff(now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.throttled && now.Sub(l.lastChange) < adaptiveBackoffInterval {
+		return
+	}
+	current := float64(l.limiter.Limit())
+	qps := current * adaptiveBackoffFactor
+	if qps < l.minQPS {
+		qps = l.minQPS
+	}
+	l.throttled = true
+	l.lastChange = now
+	if qps == current {
+		return
+	}
+	l.limiter.SetLimitAt(now, rate.Limit(qps))
+	log.Log(log.ShimClient).Warn("API server is throttling the client, lowering the QPS",
+		zap.String("client", l.name),
+		zap.Float64("qps", qps))
+}
+
+// recover raises the QPS towards the configured QPS if the API server did not throttle the client recently
+func (l *adaptiveRateLimiter) recover(now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.throttled || now.Sub(l.lastChange) < adaptiveRecoveryInterval {
+		return
+	}
+	qps := float64(l.limiter.Limit()) * adaptiveRecoveryFactor
+	if qps >= l.maxQPS {
+		qps = l.maxQPS
+		l.throttled = false
+		log.Log(log.ShimClient).Info("API server stopped throttling the client, QPS restored",
+			zap.String("client", l.name),
+			zap.Float64("qps", qps))
+	}
+	l.lastChange = now
+	l.limiter.SetLimitAt(now, rate.Limit(qps))
+}
+
+// throttleDetector reports the 429 responses of the API server to the rate limiter of the client
+type throttleDetector struct {
+	next    http.RoundTripper
+	limiter *adaptiveRateLimiter
+}
+
+func (t *throttleDetector) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.limiter.backOff(time.Now())
+	}
+	return resp, err
+}
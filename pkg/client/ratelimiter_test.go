@@ -0,0 +1,108 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/client-go/rest"
+)
+
+func TestRateLimitApply(t *testing.T) {
+	config := &rest.Config{}
+	RateLimit{QPS: 100, Burst: 200}.apply("test", config)
+	assert.Equal(t, config.QPS, float32(100))
+	assert.Equal(t, config.Burst, 200)
+	assert.Assert(t, config.RateLimiter == nil, "static rate limit should use the client-go limiter")
+	assert.Assert(t, config.WrapTransport == nil, "unexpected transport wrapper")
+
+	config = &rest.Config{}
+	RateLimit{QPS: 100, Burst: 200, Adaptive: true}.apply("test", config)
+	assert.Assert(t, config.RateLimiter != nil, "adaptive rate limiter not set")
+	assert.Equal(t, config.RateLimiter.QPS(), float32(100))
+	assert.Assert(t, config.WrapTransport != nil, "throttle detector not set")
+}
+
+func TestAdaptiveRateLimiter(t *testing.T) {
+	limiter := newAdaptiveRateLimiter("test", 100, 10)
+	now := time.Now()
+
+	// recovering without throttling leaves the QPS unchanged
+	limiter.recover(now.Add(time.Minute))
+	assert.Equal(t, limiter.QPS(), float32(100))
+
+	limiter.backOff(now)
+	assert.Equal(t, limiter.QPS(), float32(50))
+	// concurrent 429 responses only lower the QPS once
+	limiter.backOff(now.Add(adaptiveBackoffInterval / 2))
+	assert.Equal(t, limiter.QPS(), float32(50))
+	now = now.Add(adaptiveBackoffInterval)
+	limiter.backOff(now)
+	assert.Equal(t, limiter.QPS(), float32(25))
+
+	// the QPS is not lowered below the minimum
+	for i := 0; i < 10; i++ {
+		now = now.Add(adaptiveBackoffInterval)
+		limiter.backOff(now)
+	}
+	assert.Equal(t, limiter.QPS(), float32(5))
+
+	// no recovery before the interval has passed
+	limiter.recover(now.Add(adaptiveRecoveryInterval / 2))
+	assert.Equal(t, limiter.QPS(), float32(5))
+	now = now.Add(adaptiveRecoveryInterval)
+	limiter.recover(now)
+	assert.Equal(t, limiter.QPS(), float32(6.25))
+
+	// recovery stops at the configured QPS
+	for i := 0; i < 20; i++ {
+		now = now.Add(adaptiveRecoveryInterval)
+		limiter.recover(now)
+	}
+	assert.Equal(t, limiter.QPS(), float32(100))
+	assert.Assert(t, !limiter.throttled, "limiter should not be throttled after recovery")
+}
+
+func TestThrottleDetector(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	limiter := newAdaptiveRateLimiter("test", 100, 10)
+	rt := &throttleDetector{next: http.DefaultTransport, limiter: limiter}
+	get := func() {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		assert.NilError(t, err)
+		resp, err := rt.RoundTrip(req)
+		assert.NilError(t, err)
+		assert.NilError(t, resp.Body.Close())
+	}
+
+	get()
+	assert.Equal(t, limiter.QPS(), float32(100))
+	status = http.StatusTooManyRequests
+	get()
+	assert.Equal(t, limiter.QPS(), float32(50))
+}
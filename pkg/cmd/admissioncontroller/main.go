@@ -27,8 +27,13 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 
 	"github.com/apache/yunikorn-k8shim/pkg/admission"
 	"github.com/apache/yunikorn-k8shim/pkg/admission/conf"
@@ -37,10 +42,13 @@ import (
 )
 
 const (
-	HTTPPort        = 9089
-	healthURL       = "/health"
-	mutateURL       = "/mutate"
-	validateConfURL = "/validate-conf"
+	HTTPPort = 9089
+
+	// leader election of the replicas, the leader manages the CA certificates and webhooks
+	leaseName          = "yunikorn-admission-controller"
+	leaseDuration      = 15 * time.Second
+	leaseRenewDeadline = 10 * time.Second
+	leaseRetryPeriod   = 2 * time.Second
 )
 
 type WebHook struct {
@@ -58,12 +66,38 @@ func main() {
 	}
 
 	amConf := conf.NewAdmissionControllerConf(configMaps)
-	kubeClient := client.NewKubeClient(amConf.GetKubeConfig())
+	kubeClient := client.NewRateLimitedKubeClient(amConf.GetKubeConfig(), "admission", client.RateLimit{
+		QPS:      float32(amConf.GetKubeQPS()),
+		Burst:    amConf.GetKubeBurst(),
+		Adaptive: amConf.GetKubeAdaptiveRateLimit(),
+	})
 
 	informers := admission.NewInformers(kubeClient, amConf.GetNamespace())
+	if amConf.GetPodGroupEnable() {
+		if err = informers.AddPodGroupInformer(kubeClient); err != nil {
+			log.Log(log.Admission).Fatal("Failed to create PodGroup informer", zap.Error(err))
+		}
+	}
+	if amConf.GetNodeCapacityCheck() {
+		informers.AddNodeInformer(kubeClient)
+	}
+	if len(amConf.GetProcessOwnerKinds()) > 0 || len(amConf.GetBypassOwnerKinds()) > 0 {
+		if err = informers.AddOwnerInformers(kubeClient); err != nil {
+			log.Log(log.Admission).Fatal("Failed to create owner informers", zap.Error(err))
+		}
+	}
+	if amConf.GetRejectAppIDCollisions() {
+		if err = informers.AddPodInformer(kubeClient); err != nil {
+			log.Log(log.Admission).Fatal("Failed to create pod informer", zap.Error(err))
+		}
+	}
 	amConf.RegisterHandlers(informers.ConfigMap)
 	pcCache := admission.NewPriorityClassCache(informers.PriorityClass)
 	nsCache := admission.NewNamespaceCache(informers.Namespace)
+	pgCache := admission.NewPodGroupCache(informers.PodGroup)
+	ownerCache := admission.NewOwnerCache(informers.Owners, informers.OwnerClient)
+	nodeCache := admission.NewNodeCache(informers.Node)
+	appIDCache := admission.NewAppIDCache(informers.Pods)
 	informers.Start()
 
 	wm, err := admission.NewWebhookManager(amConf)
@@ -71,7 +105,18 @@ func main() {
 		log.Log(log.Admission).Fatal("Failed to initialize webhook manager", zap.Error(err))
 	}
 
-	ac := admission.InitAdmissionController(amConf, pcCache, nsCache)
+	leaderCtx, stopLeaderElection := context.WithCancel(context.Background())
+	defer stopLeaderElection()
+	if amConf.GetLeaderElectionEnable() {
+		wm.SetLeader(false)
+		go RunLeaderElection(leaderCtx, kubeClient.GetClientSet(), amConf.GetNamespace(), wm)
+	}
+	// all replicas track the failure policy of the mutating webhook, only the leader updates it
+	go wm.ManageFailurePolicy(leaderCtx.Done())
+
+	ac := admission.InitAdmissionController(amConf, pcCache, nsCache, pgCache, ownerCache, nodeCache)
+	ac.SetAppIDCache(appIDCache)
+	ac.SetUserGroupsConfigMapLister(informers.ConfigMap.Lister())
 
 	webhook := CreateWebhook(ac, HTTPPort)
 	certs := UpdateWebhookConfiguration(wm)
@@ -90,6 +135,9 @@ func main() {
 			webhook.Startup(certs)
 			WaitForCertExpiration(wm, signalChan)
 		default: // terminate
+			// do not leave pod creation blocked on a replica that is gone
+			wm.ReleaseFailurePolicy()
+			stopLeaderElection()
 			informers.Stop()
 			webhook.Shutdown()
 			os.Exit(0)
@@ -104,6 +152,47 @@ func WaitForCertExpiration(wm admission.WebhookManager, ch chan os.Signal) {
 	}()
 }
 
+// RunLeaderElection keeps the replica in the election until the context is cancelled.
+// The webhook manager is notified when the replica gains or loses the leadership.
+func RunLeaderElection(ctx context.Context, clientSet kubernetes.Interface, namespace string, wm admission.WebhookManager) {
+	identity, err := os.Hostname()
+	if err != nil {
+		log.Log(log.Admission).Fatal("Unable to get the identity for leader election", zap.Error(err))
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaseName,
+			Namespace: namespace,
+		},
+		Client: clientSet.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+	config := leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   leaseRenewDeadline,
+		RetryPeriod:     leaseRetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            leaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(_ context.Context) {
+				log.Log(log.Admission).Info("elected as leader", zap.String("identity", identity))
+				wm.SetLeader(true)
+			},
+			OnStoppedLeading: func() {
+				log.Log(log.Admission).Info("lost leadership", zap.String("identity", identity))
+				wm.SetLeader(false)
+			},
+		},
+	}
+	// an election ends when the leadership is lost, rejoin until stopped
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, config)
+	}
+}
+
 func UpdateWebhookConfiguration(wm admission.WebhookManager) *tls.Certificate {
 	err := wm.LoadCACertificates()
 	if err != nil {
@@ -134,17 +223,12 @@ func (wh *WebHook) Startup(certs *tls.Certificate) {
 	wh.Lock()
 	defer wh.Unlock()
 
-	mux := http.NewServeMux()
-	mux.HandleFunc(healthURL, wh.ac.Health)
-	mux.HandleFunc(mutateURL, wh.ac.Serve)
-	mux.HandleFunc(validateConfURL, wh.ac.Serve)
-
 	wh.server = &http.Server{
 		Addr: fmt.Sprintf(":%v", wh.port),
 		TLSConfig: &tls.Config{
 			MinVersion:   tls.VersionTLS12,
 			Certificates: []tls.Certificate{*certs}},
-		Handler: mux,
+		Handler: wh.ac.NewServeMux(),
 	}
 
 	go func() {
@@ -159,7 +243,7 @@ func (wh *WebHook) Startup(certs *tls.Certificate) {
 
 	log.Log(log.Admission).Info("the admission controller started",
 		zap.Int("port", HTTPPort),
-		zap.Strings("listeningOn", []string{healthURL, mutateURL, validateConfURL}))
+		zap.Strings("listeningOn", admission.ServedURLs()))
 }
 
 func (wh *WebHook) Shutdown() {
@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/yunikorn-k8shim/pkg/admission/loadtest"
+)
+
+// Load test for the admission controller webhook.
+// Without a target an in process webhook is started for each of the configuration sizes.
+// With a target, e.g. a port-forwarded in-cluster webhook, the requests are sent to that URL.
+func main() {
+	target := flag.String("target", "", "mutate URL of the webhook, e.g. https://localhost:9089/mutate, empty runs a local webhook")
+	insecure := flag.Bool("insecure", false, "skip the TLS certificate verification of the target")
+	rate := flag.Int("rate", 1000, "requests per second")
+	duration := flag.Duration("duration", 10*time.Second, "duration of each run")
+	workers := flag.Int("workers", 64, "number of concurrent requests")
+	sizes := flag.String("config-sizes", "0,10,100,1000", "comma separated configuration sizes to test with the local webhook")
+	flag.Parse()
+
+	cfg := loadtest.Config{
+		Target:   *target,
+		Rate:     *rate,
+		Duration: *duration,
+		Workers:  *workers,
+	}
+	if *target != "" {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = *workers
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: *insecure} //nolint:gosec
+		result, err := loadtest.Run(context.Background(), cfg, &http.Client{Transport: transport, Timeout: 10 * time.Second})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("target=%s %s\n", *target, result)
+		return
+	}
+
+	for _, value := range strings.Split(*sizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			log.Fatalf("invalid configuration size %q: %v", value, err)
+		}
+		server := loadtest.NewLocalServer(size)
+		cfg.Target = loadtest.LocalTarget(server)
+		client := server.Client()
+		if transport, ok := client.Transport.(*http.Transport); ok {
+			transport.MaxIdleConnsPerHost = *workers
+		}
+		result, err := loadtest.Run(context.Background(), cfg, client)
+		server.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("config-size=%d %s\n", size, result)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/apache/yunikorn-k8shim/pkg/conf/queuegen"
+)
+
+// Generator for large queue configurations.
+// By default a single queues.yaml is written. With -benchmark a configuration is generated for each of the sizes
+// and the time to parse and validate it is reported. With -validate an existing queues.yaml is measured instead.
+func main() {
+	queues := flag.Int("queues", 1000, "number of queues below the root queue")
+	depth := flag.Int("depth", 5, "maximum depth of the queue hierarchy below the root queue")
+	seed := flag.Int64("seed", 1, "seed of the random generator, the same seed generates the same configuration")
+	output := flag.String("output", "", "file to write the generated configuration to, empty writes to stdout")
+	benchmark := flag.Bool("benchmark", false, "measure the processing time of generated configurations instead of writing one")
+	sizes := flag.String("sizes", "100,1000,10000", "comma separated number of queues for the benchmark")
+	validate := flag.String("validate", "", "queues.yaml file to validate and measure instead of generating a configuration")
+	iterations := flag.Int("iterations", 10, "number of times each configuration is processed when measuring")
+	flag.Parse()
+
+	switch {
+	case *validate != "":
+		content, err := os.ReadFile(*validate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		result, err := queuegen.Measure(string(content), *iterations)
+		if err != nil {
+			log.Fatalf("invalid configuration %s: %v", *validate, err)
+		}
+		fmt.Printf("file=%s %s\n", *validate, result)
+	case *benchmark:
+		for _, value := range strings.Split(*sizes, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				log.Fatalf("invalid size %q: %v", value, err)
+			}
+			content, err := queuegen.GenerateYAML(queuegen.Spec{Queues: size, Depth: *depth, Seed: *seed})
+			if err != nil {
+				log.Fatal(err)
+			}
+			result, err := queuegen.Measure(content, *iterations)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s\n", result)
+		}
+	default:
+		content, err := queuegen.GenerateYAML(queuegen.Spec{Queues: *queues, Depth: *depth, Seed: *seed})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *output == "" {
+			fmt.Print(content)
+			return
+		}
+		if err = os.WriteFile(*output, []byte(content), 0o600); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/restproxy"
+)
+
+// Import of a state export, retrieved from the snapshot endpoint with format=export, into a development cluster.
+// The nodes, priority classes and pods of the export are created in the cluster, the queue configuration of the
+// core is written to a file to be applied to the scheduler configuration of the development cluster.
+func main() {
+	file := flag.String("file", "", "state export to import, e.g. yunikorn-state-20230601-120000.json.gz")
+	kubeConfig := flag.String("kubeconfig", os.Getenv("KUBECONFIG"), "kubeconfig of the development cluster")
+	configOut := flag.String("config-out", "core-config.json", "file to write the core configuration to, empty skips the configuration")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("missing state export file")
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	export, err := restproxy.ReadStateExport(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *configOut != "" && export.Snapshot.CoreConfig != nil {
+		if err = os.WriteFile(*configOut, export.Snapshot.CoreConfig, 0o600); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("core configuration written to %s\n", *configOut)
+	}
+	objects := export.ImportObjects()
+	kubeClient := client.NewKubeClient(*kubeConfig)
+	if err = objects.Apply(context.Background(), kubeClient.GetClientSet()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("imported %d nodes, %d priority classes and %d pods exported at %s\n",
+		len(objects.Nodes), len(objects.PriorityClasses), len(objects.Pods), export.Snapshot.Shim.Timestamp)
+}
@@ -28,6 +28,24 @@ const DefaultNodeAttributeRackNameKey = "si.io/rackname"
 const DefaultNodeInstanceTypeNodeLabelKey = "node.kubernetes.io/instance-type"
 const DefaultRackName = "/rack-default"
 
+// LabelPartition set on a Node registers the node with the named partition in the core, nodes without the label
+// are registered with the default partition. Set as label or annotation on a Pod, or as annotation on the namespace
+// of the Pod, it selects the partition of the application.
+const LabelPartition = "yunikorn.apache.org/partition"
+
+// NodeWeight set as annotation or label on a Node is the weight of the node reported to the core, used by node
+// sorting policies to rank nodes. The annotation takes precedence over the label.
+const NodeWeight = "yunikorn.apache.org/node-weight"
+
+// NodeWeightAttribute the node attribute the weight of the node is reported in
+const NodeWeightAttribute = "si/node-weight"
+
+// AnnotationCPUOvercommit and AnnotationMemoryOvercommit set on a Node are the ratios the allocatable resources of
+// the node are multiplied by before the node is used for scheduling. The kubelet is not aware of the ratios, it
+// still admits pods against the unmodified allocatable resources.
+const AnnotationCPUOvercommit = "yunikorn.apache.org/cpu-overcommit"
+const AnnotationMemoryOvercommit = "yunikorn.apache.org/memory-overcommit"
+
 // Application
 const LabelApp = "app"
 const LabelApplicationID = "applicationId"
@@ -45,10 +63,18 @@ const DefaultAppNamespace = "default"
 const DefaultUserLabel = "yunikorn.apache.org/username"
 const DefaultUser = "nobody"
 
+// LabelAppTagPrefix prefixes the labels of the application tags that are copied onto the pods of the application
+const LabelAppTagPrefix = "tag.yunikorn.apache.org/"
+
+// QueuePropertyNodeSelector queue property with a label selector, restricts the nodes the applications in the queue can use
+const QueuePropertyNodeSelector = "yunikorn.apache.org/node-selector"
+
 // Spark
 const SparkLabelAppID = "spark-app-selector"
 const SparkLabelRole = "spark-role"
 const SparkLabelRoleDriver = "driver"
+const SparkLabelRoleExecutor = "executor"
+const SparkOperatorLabelAppName = "sparkoperator.k8s.io/app-name"
 
 // Configuration
 const ConfigMapName = "yunikorn-configs"
@@ -68,6 +94,7 @@ const PlaceholderPodRestartPolicy = "Never"
 const LabelPlaceholderFlag = "placeholder"
 const AnnotationPlaceholderFlag = "yunikorn.apache.org/placeholder"
 const AnnotationTaskGroupName = "yunikorn.apache.org/task-group-name"
+const LabelTaskGroupName = "taskGroupName"
 const AnnotationTaskGroups = "yunikorn.apache.org/task-groups"
 const AnnotationSchedulingPolicyParam = "yunikorn.apache.org/schedulingPolicyParameters"
 const SchedulingPolicyTimeoutParam = "placeholderTimeoutInSeconds"
@@ -75,11 +102,37 @@ const SchedulingPolicyParamDelimiter = " "
 const SchedulingPolicyStyleParam = "gangSchedulingStyle"
 const SchedulingPolicyStyleParamDefault = "Soft"
 
+// Coscheduling PodGroup from the scheduler-plugins project
+const LabelPodGroup = "scheduling.x-k8s.io/pod-group"
+
 var SchedulingPolicyStyleParamValues = map[string]string{"Hard": "Hard", "Soft": "Soft"}
 
+// ResourceClaimPrefix is the prefix of the resource type used to account DRA ResourceClaims in the allocation ask:
+// each claim of a pod adds one to "resource.k8s.io/{resourceClassName}". Nodes report the type with a capacity that
+// does not limit the claims, the claims are only limited by the queue quotas. Task groups of pods with claims must
+// include the type in the minResource.
+const ResourceClaimPrefix = "resource.k8s.io/"
+
 const ApplicationInsufficientResourcesFailure = "ResourceReservationTimeout"
 const ApplicationRejectedFailure = "ApplicationRejected"
 
+// Scheduling reason codes, set by the shim as prefix of the message of the PodScheduled condition of a pod that
+// could not be scheduled: "<code>: <message>". The reason of the condition itself is left unchanged as the cluster
+// autoscaler depends on it.
+const SchedulingReasonQuotaExceeded = "QUOTA_EXCEEDED"
+const SchedulingReasonGangWaiting = "GANG_WAITING"
+const SchedulingReasonPredicateFailed = "PREDICATE_FAILED"
+const SchedulingReasonQueueStopped = "QUEUE_STOPPED"
+const SchedulingReasonNodePressure = "NODE_PRESSURE"
+
+var SchedulingReasonCodes = []string{
+	SchedulingReasonQuotaExceeded,
+	SchedulingReasonGangWaiting,
+	SchedulingReasonPredicateFailed,
+	SchedulingReasonQueueStopped,
+	SchedulingReasonNodePressure,
+}
+
 // namespace.max.* (Retaining for backwards compatibility. Need to be removed in next major release)
 const CPUQuota = "yunikorn.apache.org/namespace.max.cpu"
 const MemQuota = "yunikorn.apache.org/namespace.max.memory"
@@ -90,12 +143,54 @@ const NamespaceQuota = "yunikorn.apache.org/namespace.quota"
 // NamespaceGuaranteed Namespace Guaranteed
 const NamespaceGuaranteed = "yunikorn.apache.org/namespace.guaranteed"
 
+// NamespaceMaxGangSize maximum number of members of all task groups of an application in the namespace
+const NamespaceMaxGangSize = "yunikorn.apache.org/namespace.maxGangSize"
+
+// NamespaceMaxPlaceholderResource maximum resources of all placeholders of an application in the namespace
+const NamespaceMaxPlaceholderResource = "yunikorn.apache.org/namespace.maxPlaceholderResource"
+
 // AnnotationAllowPreemption set on PriorityClass, opt out of preemption for pods with this priority class
 const AnnotationAllowPreemption = "yunikorn.apache.org/allow-preemption"
 
+// AnnotationStrictFIFO set on Pod to "true" submits the tasks of the application to the core in pod creation order
+const AnnotationStrictFIFO = "yunikorn.apache.org/strict-fifo"
+
+// AnnotationApplicationProfile set on Pod selects the behaviour of the shim for the application.
+// batch (default): the application completes once all its pods have finished
+// service: long-running workload, like a Deployment, that survives rolling updates of the controller
+const AnnotationApplicationProfile = "yunikorn.apache.org/app-profile"
+const ApplicationProfileBatch = "batch"
+const ApplicationProfileService = "service"
+
+// AnnotationAvoidFailedNodes set on Pod keeps the pods of the application away from the nodes on which a pod of the
+// application failed recently. The value is either "true", using the window configured in the scheduler, or the window
+// as a duration, e.g. "15m". The nodes are passed to the core as a placement hint, they are not excluded.
+const AnnotationAvoidFailedNodes = "yunikorn.apache.org/avoid-failed-nodes"
+
+// TagPreferredNodeAntiAffinity is set on the ask of a pod that avoids failed nodes, the value is the comma separated
+// list of nodes on which a pod of the application failed recently. The core prefers other nodes for the ask.
+const TagPreferredNodeAntiAffinity = "yunikorn.apache.org/preferred-node-anti-affinity"
+
+// TagMigratedFromNode is set on the ask of a pod that replaces a pod deleted from a deleted node in the migrate
+// node deletion mode, the value is the name of the deleted node.
+const TagMigratedFromNode = "yunikorn.apache.org/migrated-from-node"
+
+// AnnotationAppMaxRunDuration set on Pod limits the wall-clock time the application runs, e.g. "2h". The time starts
+// when the application starts running. Once it elapses all pods of the application are deleted, pods created for
+// the application afterwards are deleted as soon as they are added.
+const AnnotationAppMaxRunDuration = "yunikorn.apache.org/app-max-run-duration"
+
 // AnnotationIgnoreApplication set on Pod prevents by admission controller, prevents YuniKorn from honoring application ID
 const AnnotationIgnoreApplication = "yunikorn.apache.org/ignore-application"
 
+// AnnotationDuplicatePodBackoff set on Pod by the admission controller if the controller of the pod created more
+// identical pods within a minute than the configured threshold. The value is the suggested backoff, e.g. "8s".
+const AnnotationDuplicatePodBackoff = "yunikorn.apache.org/duplicate-pod-backoff"
+
+// AnnotationOccupiedExempt set on a Pod not scheduled by YuniKorn to "true" excludes the resources of the pod from
+// the occupied resources reported to the core
+const AnnotationOccupiedExempt = "yunikorn.apache.org/occupied-exempt"
+
 // AnnotationGenerateAppID adds application ID to workloads in the namespace even if not set in the admission config.
 // Overrides the regexp behaviour if set, checked before the regexp is evaluated.
 // true: add an application ID label
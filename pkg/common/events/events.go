@@ -48,6 +48,9 @@ type ApplicationEvent interface {
 // ApplicationStatusEvent updates the status in the application CRD
 // ------------------------
 type ApplicationStatusEvent interface {
+	// application ID of the CRD
+	GetApplicationID() string
+
 	GetState() string
 }
 
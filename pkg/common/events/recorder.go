@@ -41,7 +41,7 @@ func GetRecorder() events.EventRecorder {
 		// in test mode we should skip this and just use a fake recorder instead.
 		configs := conf.GetSchedulerConf()
 		if !configs.IsTestMode() {
-			k8sClient := client.NewKubeClient(configs.KubeConfig)
+			k8sClient := client.NewEventsKubeClient(configs.KubeConfig)
 			eventBroadcaster := events.NewBroadcaster(&events.EventSinkImpl{
 				Interface: k8sClient.GetClientSet().EventsV1()})
 			eventBroadcaster.StartRecordingToSink(make(<-chan struct{}))
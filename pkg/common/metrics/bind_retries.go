@@ -0,0 +1,55 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var bindRetries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "bind_retries_total",
+		Help:      "Total number of pod bind retries after a transient API server failure, by failure reason.",
+	}, []string{"reason"})
+
+func init() {
+	if err := prometheus.Register(bindRetries); err != nil {
+		log.Log(log.Shim).Warn("failed to register bind retry metrics", zap.Error(err))
+	}
+}
+
+// IncBindRetry counts a retry of a pod bind, the reason is the status reason of the failed request
+func IncBindRetry(reason string) {
+	bindRetries.WithLabelValues(reason).Inc()
+}
+
+// GetBindRetries returns the number of bind retries counted for the reason
+func GetBindRetries(reason string) (int, error) {
+	metric := &dto.Metric{}
+	if err := bindRetries.WithLabelValues(reason).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Counter.GetValue()), nil
+}
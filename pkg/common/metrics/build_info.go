@@ -0,0 +1,64 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var (
+	buildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: ShimSubsystem,
+			Name:      "build_info",
+			Help:      "Build information of the shim, the value is always 1.",
+		}, []string{"version", "core_version", "deployment_mode", "go_version"})
+	featureEnabled = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: ShimSubsystem,
+			Name:      "feature_enabled",
+			Help:      "Features of the shim: 1 enabled, 0 disabled.",
+		}, []string{"feature"})
+)
+
+func init() {
+	for _, collector := range []prometheus.Collector{buildInfo, featureEnabled} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register build info metrics", zap.Error(err))
+		}
+	}
+}
+
+// SetBuildInfo publishes the build information and the state of the features
+func SetBuildInfo(version, coreVersion, deploymentMode, goVersion string, features map[string]bool) {
+	buildInfo.Reset()
+	buildInfo.WithLabelValues(version, coreVersion, deploymentMode, goVersion).Set(1)
+	for name, enabled := range features {
+		value := 0.0
+		if enabled {
+			value = 1
+		}
+		featureEnabled.WithLabelValues(name).Set(value)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// values of the circuit breaker state gauge
+const (
+	CircuitBreakerClosed   = 0
+	CircuitBreakerOpen     = 1
+	CircuitBreakerHalfOpen = 2
+)
+
+var (
+	coreBreakerState = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: ShimSubsystem,
+			Name:      "core_circuit_breaker_state",
+			Help:      "State of the circuit breaker for the communication with the core: 0 closed, 1 open, 2 half-open.",
+		})
+	coreBreakerBuffered = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: ShimSubsystem,
+			Name:      "core_circuit_breaker_buffered_requests",
+			Help:      "Number of requests buffered while the circuit breaker is not closed.",
+		})
+	coreBreakerDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: ShimSubsystem,
+			Name:      "core_circuit_breaker_dropped_requests_total",
+			Help:      "Total number of requests dropped because the circuit breaker buffer was full.",
+		})
+)
+
+func init() {
+	for _, collector := range []prometheus.Collector{coreBreakerState, coreBreakerBuffered, coreBreakerDropped} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register circuit breaker metrics", zap.Error(err))
+		}
+	}
+}
+
+// SetCoreCircuitBreakerState sets the state of the circuit breaker, one of the CircuitBreaker constants
+func SetCoreCircuitBreakerState(state int) {
+	coreBreakerState.Set(float64(state))
+}
+
+// SetCoreCircuitBreakerBuffered sets the number of requests buffered by the circuit breaker
+func SetCoreCircuitBreakerBuffered(count int) {
+	coreBreakerBuffered.Set(float64(count))
+}
+
+// IncCoreCircuitBreakerDropped counts a request dropped by the circuit breaker
+func IncCoreCircuitBreakerDropped() {
+	coreBreakerDropped.Inc()
+}
+
+// GetCoreCircuitBreakerDropped returns the number of requests dropped by the circuit breaker
+func GetCoreCircuitBreakerDropped() (int, error) {
+	metric := &dto.Metric{}
+	if err := coreBreakerDropped.Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Counter.GetValue()), nil
+}
@@ -0,0 +1,60 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var dispatcherQueueDepth = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "dispatcher_queue_depth",
+		Help:      "Number of events waiting in the dispatcher, by lane.",
+	}, []string{"lane"})
+
+func init() {
+	if err := prometheus.Register(dispatcherQueueDepth); err != nil {
+		log.Log(log.Shim).Warn("failed to register dispatcher metrics", zap.Error(err))
+	}
+}
+
+// IncDispatcherQueueDepth counts an event added to the lane of the dispatcher
+func IncDispatcherQueueDepth(lane string) {
+	dispatcherQueueDepth.WithLabelValues(lane).Inc()
+}
+
+// DecDispatcherQueueDepth counts an event taken from the lane of the dispatcher
+func DecDispatcherQueueDepth(lane string) {
+	dispatcherQueueDepth.WithLabelValues(lane).Dec()
+}
+
+// GetDispatcherQueueDepth returns the number of events waiting in the lane of the dispatcher
+func GetDispatcherQueueDepth(lane string) (int, error) {
+	metric := &dto.Metric{}
+	if err := dispatcherQueueDepth.WithLabelValues(lane).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Gauge.GetValue()), nil
+}
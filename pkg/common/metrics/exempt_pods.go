@@ -0,0 +1,85 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var exemptPods = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "exempt_foreign_pods",
+		Help:      "Number of running pods not scheduled by YuniKorn that are exempt from the occupied resource reporting.",
+	})
+
+var exemptPodResource = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "exempt_foreign_pod_resource",
+		Help:      "Resources of the running pods not scheduled by YuniKorn that are exempt from the occupied resource reporting, by resource type.",
+	}, []string{"resource"})
+
+func init() {
+	for _, collector := range []prometheus.Collector{exemptPods, exemptPodResource} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register exempt foreign pod metrics", zap.Error(err))
+		}
+	}
+}
+
+// AddExemptForeignPod adds an exempt pod and its resources to the exempted totals
+func AddExemptForeignPod(resources map[string]int64) {
+	exemptPods.Inc()
+	for name, value := range resources {
+		exemptPodResource.WithLabelValues(name).Add(float64(value))
+	}
+}
+
+// RemoveExemptForeignPod removes an exempt pod and its resources from the exempted totals
+func RemoveExemptForeignPod(resources map[string]int64) {
+	exemptPods.Dec()
+	for name, value := range resources {
+		exemptPodResource.WithLabelValues(name).Sub(float64(value))
+	}
+}
+
+// GetExemptForeignPods returns the current number of exempt pods
+func GetExemptForeignPods() (int, error) {
+	metric := &dto.Metric{}
+	if err := exemptPods.Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Gauge.GetValue()), nil
+}
+
+// GetExemptForeignPodResource returns the current exempted total of the resource
+func GetExemptForeignPodResource(resource string) (int64, error) {
+	metric := &dto.Metric{}
+	if err := exemptPodResource.WithLabelValues(resource).Write(metric); err != nil {
+		return -1, err
+	}
+	return int64(metric.Gauge.GetValue()), nil
+}
@@ -0,0 +1,53 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExemptForeignPods(t *testing.T) {
+	exemptPods.Set(0)
+	exemptPodResource.Reset()
+	AddExemptForeignPod(map[string]int64{"vcore": 1000, "memory": 1024})
+	AddExemptForeignPod(map[string]int64{"vcore": 500})
+
+	pods, err := GetExemptForeignPods()
+	assert.NilError(t, err)
+	assert.Equal(t, pods, 2)
+	value, err := GetExemptForeignPodResource("vcore")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(1500))
+	value, err = GetExemptForeignPodResource("memory")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(1024))
+
+	RemoveExemptForeignPod(map[string]int64{"vcore": 1000, "memory": 1024})
+	pods, err = GetExemptForeignPods()
+	assert.NilError(t, err)
+	assert.Equal(t, pods, 1)
+	value, err = GetExemptForeignPodResource("vcore")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(500))
+	value, err = GetExemptForeignPodResource("memory")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(0))
+}
@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// Namespace for all metrics of the scheduler, shared with the core
+	Namespace = "yunikorn"
+	// ShimSubsystem - subsystem name used by the shim
+	ShimSubsystem = "k8shim"
+)
+
+// FailureDomain identifies the component in which placing a pod failed.
+// The domain is used as the metric label and as the action of the pod event that reports the failure.
+type FailureDomain string
+
+const (
+	// CoreAllocation the scheduler core rejected the allocation request
+	CoreAllocation FailureDomain = "CoreAllocation"
+	// ShimPredicate the node selected by the core did not pass the predicates in the shim
+	ShimPredicate FailureDomain = "ShimPredicate"
+	// APIBind binding the volumes or the pod to the node failed in the API server
+	APIBind FailureDomain = "APIBind"
+	// KubeletAdmission the kubelet rejected the pod after it was bound to the node
+	KubeletAdmission FailureDomain = "KubeletAdmission"
+)
+
+func (d FailureDomain) String() string {
+	return string(d)
+}
+
+var schedulingFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "scheduling_failures_total",
+		Help:      "Total number of failures placing pods, by failure domain and reason.",
+	}, []string{"domain", "reason"})
+
+func init() {
+	// the core serves the default registry on its metrics endpoint
+	if err := prometheus.Register(schedulingFailures); err != nil {
+		log.Log(log.Shim).Warn("failed to register scheduling failure metrics", zap.Error(err))
+	}
+}
+
+// IncSchedulingFailure counts a failure in the domain. The reason must come from a bounded set, like an event
+// reason or a plugin name, as each reason creates a new time series.
+func IncSchedulingFailure(domain FailureDomain, reason string) {
+	schedulingFailures.WithLabelValues(domain.String(), reason).Inc()
+}
+
+// GetSchedulingFailures returns the number of failures counted for the domain and reason
+func GetSchedulingFailures(domain FailureDomain, reason string) (int, error) {
+	metric := &dto.Metric{}
+	if err := schedulingFailures.WithLabelValues(domain.String(), reason).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Counter.GetValue()), nil
+}
@@ -0,0 +1,44 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestIncSchedulingFailure(t *testing.T) {
+	schedulingFailures.Reset()
+	IncSchedulingFailure(APIBind, "PodBindFailure")
+	IncSchedulingFailure(APIBind, "PodBindFailure")
+	IncSchedulingFailure(KubeletAdmission, "OutOfcpu")
+
+	assert.Equal(t, testutil.ToFloat64(schedulingFailures.WithLabelValues("APIBind", "PodBindFailure")), 2.0)
+	assert.Equal(t, testutil.ToFloat64(schedulingFailures.WithLabelValues("KubeletAdmission", "OutOfcpu")), 1.0)
+	assert.Equal(t, testutil.CollectAndCount(schedulingFailures), 2)
+
+	count, err := GetSchedulingFailures(APIBind, "PodBindFailure")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+	count, err = GetSchedulingFailures(CoreAllocation, "TaskRejected")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0)
+}
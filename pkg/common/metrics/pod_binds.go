@@ -0,0 +1,89 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	BindSucceeded = "success"
+	BindFailed    = "failure"
+)
+
+var podBinds = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "pod_binds_total",
+		Help:      "Total number of pod bind calls to the API server, by result. The rate is the bind throughput.",
+	}, []string{"result"})
+
+var bindsInFlight = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "binds_in_flight",
+		Help:      "Number of pod binds waiting for a bind worker or being sent to the API server.",
+	})
+
+func init() {
+	for _, collector := range []prometheus.Collector{podBinds, bindsInFlight} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register pod bind metrics", zap.Error(err))
+		}
+	}
+}
+
+// IncPodBind counts a pod bind call to the API server, the result is either BindSucceeded or BindFailed
+func IncPodBind(result string) {
+	podBinds.WithLabelValues(result).Inc()
+}
+
+// IncBindsInFlight counts a bind waiting for a bind worker
+func IncBindsInFlight() {
+	bindsInFlight.Inc()
+}
+
+// DecBindsInFlight counts a bind that has been sent to the API server
+func DecBindsInFlight() {
+	bindsInFlight.Dec()
+}
+
+// GetPodBinds returns the number of pod bind calls counted for the result
+func GetPodBinds(result string) (int, error) {
+	metric := &dto.Metric{}
+	if err := podBinds.WithLabelValues(result).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Counter.GetValue()), nil
+}
+
+// GetBindsInFlight returns the number of binds waiting for a bind worker or being sent to the API server
+func GetBindsInFlight() (int, error) {
+	metric := &dto.Metric{}
+	if err := bindsInFlight.Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Gauge.GetValue()), nil
+}
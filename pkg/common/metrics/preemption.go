@@ -0,0 +1,86 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var preemptionWindowResource = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "queue_preemption_window_resource",
+		Help:      "Resources preempted from the victim queue for the preemptor queue within the observation window, by resource type.",
+	}, []string{"window", "preemptor_queue", "victim_queue", "resource"})
+
+var preemptionWindowCount = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "queue_preemption_window_count",
+		Help:      "Number of allocations preempted from the victim queue for the preemptor queue within the observation window.",
+	}, []string{"window", "preemptor_queue", "victim_queue"})
+
+func init() {
+	for _, collector := range []prometheus.Collector{preemptionWindowResource, preemptionWindowCount} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register preemption window metrics", zap.Error(err))
+		}
+	}
+}
+
+// ResetPreemptionWindows removes all series of the preemption windows, pairs of queues without preemptions in a
+// window are not reported
+func ResetPreemptionWindows() {
+	preemptionWindowResource.Reset()
+	preemptionWindowCount.Reset()
+}
+
+// SetPreemptionWindowResource sets the resource preempted from the victim queue for the preemptor queue in the window
+func SetPreemptionWindowResource(window, preemptor, victim, resource string, value int64) {
+	preemptionWindowResource.WithLabelValues(window, preemptor, victim, resource).Set(float64(value))
+}
+
+// SetPreemptionWindowCount sets the number of allocations preempted from the victim queue for the preemptor queue in the window
+func SetPreemptionWindowCount(window, preemptor, victim string, count int) {
+	preemptionWindowCount.WithLabelValues(window, preemptor, victim).Set(float64(count))
+}
+
+// GetPreemptionWindowResource returns the resource preempted from the victim queue for the preemptor queue in the window
+func GetPreemptionWindowResource(window, preemptor, victim, resource string) (int64, error) {
+	metric := &dto.Metric{}
+	if err := preemptionWindowResource.WithLabelValues(window, preemptor, victim, resource).Write(metric); err != nil {
+		return -1, err
+	}
+	return int64(metric.Gauge.GetValue()), nil
+}
+
+// GetPreemptionWindowCount returns the number of allocations preempted from the victim queue for the preemptor queue in the window
+func GetPreemptionWindowCount(window, preemptor, victim string) (int, error) {
+	metric := &dto.Metric{}
+	if err := preemptionWindowCount.WithLabelValues(window, preemptor, victim).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Gauge.GetValue()), nil
+}
@@ -0,0 +1,46 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestPreemptionWindows(t *testing.T) {
+	ResetPreemptionWindows()
+	SetPreemptionWindowResource("1h", "root.a", "root.b", "vcore", 2000)
+	SetPreemptionWindowResource("1h", "root.a", "root.b", "memory", 1024)
+	SetPreemptionWindowCount("1h", "root.a", "root.b", 2)
+	assert.Equal(t, testutil.CollectAndCount(preemptionWindowResource), 2)
+	assert.Equal(t, testutil.CollectAndCount(preemptionWindowCount), 1)
+
+	value, err := GetPreemptionWindowResource("1h", "root.a", "root.b", "vcore")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(2000))
+	count, err := GetPreemptionWindowCount("1h", "root.a", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+
+	ResetPreemptionWindows()
+	assert.Equal(t, testutil.CollectAndCount(preemptionWindowResource), 0)
+	assert.Equal(t, testutil.CollectAndCount(preemptionWindowCount), 0)
+}
@@ -0,0 +1,60 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var serviceAppResource = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "service_application_resource",
+		Help:      "Resources of the bound pods of applications with the service profile, by resource type.",
+	}, []string{"namespace", "application", "resource"})
+
+func init() {
+	if err := prometheus.Register(serviceAppResource); err != nil {
+		log.Log(log.Shim).Warn("failed to register service application metrics", zap.Error(err))
+	}
+}
+
+// AddServiceApplicationResource adds the value to the resource of the service application, a negative value removes it
+func AddServiceApplicationResource(namespace, appID, resource string, value int64) {
+	serviceAppResource.WithLabelValues(namespace, appID, resource).Add(float64(value))
+}
+
+// RemoveServiceApplication removes all the resource series of the service application
+func RemoveServiceApplication(namespace, appID string) {
+	serviceAppResource.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "application": appID})
+}
+
+// GetServiceApplicationResource returns the current value of the resource of the service application
+func GetServiceApplicationResource(namespace, appID, resource string) (int64, error) {
+	metric := &dto.Metric{}
+	if err := serviceAppResource.WithLabelValues(namespace, appID, resource).Write(metric); err != nil {
+		return -1, err
+	}
+	return int64(metric.Gauge.GetValue()), nil
+}
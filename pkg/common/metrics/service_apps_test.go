@@ -0,0 +1,47 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+)
+
+func TestServiceApplicationResource(t *testing.T) {
+	serviceAppResource.Reset()
+	AddServiceApplicationResource("ns1", "app1", "vcore", 1000)
+	AddServiceApplicationResource("ns1", "app1", "vcore", 500)
+	AddServiceApplicationResource("ns1", "app1", "pods", 1)
+	AddServiceApplicationResource("ns2", "app2", "vcore", 100)
+	assert.Equal(t, testutil.CollectAndCount(serviceAppResource), 3)
+
+	value, err := GetServiceApplicationResource("ns1", "app1", "vcore")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(1500))
+	AddServiceApplicationResource("ns1", "app1", "vcore", -1000)
+	value, err = GetServiceApplicationResource("ns1", "app1", "vcore")
+	assert.NilError(t, err)
+	assert.Equal(t, value, int64(500))
+
+	RemoveServiceApplication("ns1", "app1")
+	assert.Equal(t, testutil.CollectAndCount(serviceAppResource), 1)
+	assert.Equal(t, testutil.ToFloat64(serviceAppResource.WithLabelValues("ns2", "app2", "vcore")), 100.0)
+}
@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// ShadowFit the pod would fit on at least one node
+	ShadowFit = "fit"
+	// ShadowNoFit the pod would not fit on any node
+	ShadowNoFit = "no_fit"
+	// ShadowAgree the node chosen by the other scheduler is a node the pod would fit on
+	ShadowAgree = "agree"
+	// ShadowDisagree the node chosen by the other scheduler is not a node the pod would fit on
+	ShadowDisagree = "disagree"
+)
+
+var shadowPods = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "shadow_pods_total",
+		Help:      "Total number of pending pods of the default scheduler evaluated in shadow mode, by the simulated result.",
+	}, []string{"result"})
+
+var shadowQueuePods = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "shadow_queue_pods_total",
+		Help:      "Total number of pods of the default scheduler evaluated in shadow mode, by the queue the pod would be submitted to.",
+	}, []string{"queue"})
+
+var shadowPlacements = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "shadow_placements_total",
+		Help:      "Total number of pods bound by the default scheduler in shadow mode, by the agreement with the simulated result.",
+	}, []string{"result"})
+
+func init() {
+	for _, collector := range []prometheus.Collector{shadowPods, shadowQueuePods, shadowPlacements} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register shadow mode metrics", zap.Error(err))
+		}
+	}
+}
+
+// IncShadowPod counts a pod evaluated in shadow mode with the simulated result and the queue
+func IncShadowPod(result, queue string) {
+	shadowPods.WithLabelValues(result).Inc()
+	shadowQueuePods.WithLabelValues(queue).Inc()
+}
+
+// IncShadowPlacement counts a pod bound by the default scheduler with the agreement of the simulated result
+func IncShadowPlacement(result string) {
+	shadowPlacements.WithLabelValues(result).Inc()
+}
+
+// GetShadowPods returns the number of pods evaluated in shadow mode with the simulated result
+func GetShadowPods(result string) (int, error) {
+	return getCounter(shadowPods.WithLabelValues(result))
+}
+
+// GetShadowQueuePods returns the number of pods evaluated in shadow mode for the queue
+func GetShadowQueuePods(queue string) (int, error) {
+	return getCounter(shadowQueuePods.WithLabelValues(queue))
+}
+
+// GetShadowPlacements returns the number of pods bound by the default scheduler with the agreement
+func GetShadowPlacements(result string) (int, error) {
+	return getCounter(shadowPlacements.WithLabelValues(result))
+}
+
+func getCounter(counter prometheus.Counter) (int, error) {
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Counter.GetValue()), nil
+}
@@ -0,0 +1,44 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestShadowMetrics(t *testing.T) {
+	IncShadowPod(ShadowFit, "root.a")
+	IncShadowPod(ShadowNoFit, "root.a")
+	IncShadowPlacement(ShadowDisagree)
+
+	count, err := GetShadowPods(ShadowFit)
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+	count, err = GetShadowQueuePods("root.a")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+	count, err = GetShadowPlacements(ShadowDisagree)
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+	count, err = GetShadowPlacements(ShadowAgree)
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0)
+}
@@ -0,0 +1,105 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+var taskStateTransitions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "task_state_transitions_total",
+		Help:      "Total number of task state transitions, by source and destination state.",
+	}, []string{"from", "to"})
+
+var taskStateDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "task_state_duration_seconds",
+		Help:      "Time a task spent in a state before moving to the next state, by state.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 12), // 1ms - ~70min
+	}, []string{"state"})
+
+var podBindLatency = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: ShimSubsystem,
+		Name:      "pod_bind_latency_seconds",
+		Help:      "Latency of the successful pod binds in the API server, including the retries.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15), // 1ms - ~16s
+	})
+
+func init() {
+	for _, collector := range []prometheus.Collector{taskStateTransitions, taskStateDuration, podBindLatency} {
+		if err := prometheus.Register(collector); err != nil {
+			log.Log(log.Shim).Warn("failed to register task state metrics", zap.Error(err))
+		}
+	}
+}
+
+// ObserveTaskStateTransition counts the transition and records the time the task spent in the source state
+func ObserveTaskStateTransition(from, to string, duration time.Duration) {
+	taskStateTransitions.WithLabelValues(from, to).Inc()
+	taskStateDuration.WithLabelValues(from).Observe(duration.Seconds())
+}
+
+// ObservePodBindLatency records the latency of a successful pod bind
+func ObservePodBindLatency(duration time.Duration) {
+	podBindLatency.Observe(duration.Seconds())
+}
+
+// GetTaskStateTransitions returns the number of transitions counted from the source to the destination state
+func GetTaskStateTransitions(from, to string) (int, error) {
+	metric := &dto.Metric{}
+	if err := taskStateTransitions.WithLabelValues(from, to).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Counter.GetValue()), nil
+}
+
+// GetTaskStateDurationCount returns the number of durations recorded for the state
+func GetTaskStateDurationCount(state string) (int, error) {
+	metric := &dto.Metric{}
+	histogram, err := taskStateDuration.GetMetricWithLabelValues(state)
+	if err != nil {
+		return -1, err
+	}
+	if err = histogram.(prometheus.Metric).Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Histogram.GetSampleCount()), nil
+}
+
+// GetPodBindLatencyCount returns the number of pod bind latencies recorded
+func GetPodBindLatencyCount() (int, error) {
+	metric := &dto.Metric{}
+	if err := podBindLatency.Write(metric); err != nil {
+		return -1, err
+	}
+	return int(metric.Histogram.GetSampleCount()), nil
+}
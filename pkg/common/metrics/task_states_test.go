@@ -0,0 +1,60 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTaskStateTransitions(t *testing.T) {
+	taskStateTransitions.Reset()
+	taskStateDuration.Reset()
+	ObserveTaskStateTransition("New", "Pending", time.Millisecond)
+	ObserveTaskStateTransition("New", "Pending", time.Second)
+	ObserveTaskStateTransition("Pending", "Scheduling", time.Millisecond)
+
+	count, err := GetTaskStateTransitions("New", "Pending")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+	count, err = GetTaskStateTransitions("Pending", "Scheduling")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+	count, err = GetTaskStateTransitions("Scheduling", "Allocated")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 0)
+
+	count, err = GetTaskStateDurationCount("New")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 2)
+	count, err = GetTaskStateDurationCount("Pending")
+	assert.NilError(t, err)
+	assert.Equal(t, count, 1)
+}
+
+func TestPodBindLatency(t *testing.T) {
+	before, err := GetPodBindLatencyCount()
+	assert.NilError(t, err)
+	ObservePodBindLatency(10 * time.Millisecond)
+	count, err := GetPodBindLatencyCount()
+	assert.NilError(t, err)
+	assert.Equal(t, count, before+1)
+}
@@ -63,7 +63,7 @@ func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
 	}
 
 	for _, c := range pod.Spec.Containers {
-		resourceList := c.Resources.Requests
+		resourceList := getContainerRequests(pod, c)
 		containerResource := getResource(resourceList)
 		podResource = Add(podResource, containerResource)
 	}
@@ -93,6 +93,40 @@ func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
 	return podResource
 }
 
+// getContainerRequests returns the requests of a container taking an in-place resize into account.
+// The spec of a resized pod is updated immediately, the kubelet tracks the admitted requests in the
+// container status. While the resize is not admitted the admitted requests are used, while the resize
+// is in progress the maximum of both is used.
+func getContainerRequests(pod *v1.Pod, container v1.Container) v1.ResourceList {
+	var allocated v1.ResourceList
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container.Name {
+			allocated = status.AllocatedResources
+			break
+		}
+	}
+	if allocated == nil {
+		return container.Resources.Requests
+	}
+	switch pod.Status.Resize {
+	case v1.PodResizeStatusProposed, v1.PodResizeStatusDeferred, v1.PodResizeStatusInfeasible:
+		return allocated
+	case v1.PodResizeStatusInProgress:
+		requests := container.Resources.Requests.DeepCopy()
+		if requests == nil {
+			requests = v1.ResourceList{}
+		}
+		for name, value := range allocated {
+			if request, ok := requests[name]; !ok || value.Cmp(request) > 0 {
+				requests[name] = value
+			}
+		}
+		return requests
+	default:
+		return container.Resources.Requests
+	}
+}
+
 func checkInitContainerRequest(pod *v1.Pod, containersResources *si.Resource) {
 	for _, c := range pod.Spec.InitContainers {
 		resourceList := c.Resources.Requests
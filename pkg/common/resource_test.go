@@ -282,6 +282,55 @@ func TestParsePodResource(t *testing.T) {
 	assert.Equal(t, res.Resources["pods"].GetValue(), int64(1))
 }
 
+func TestPodResourceInPlaceResize(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-resize-test-00001",
+			UID:  "UID-00001",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "container-01",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("2"),
+						v1.ResourceMemory: resource.MustParse("500M"),
+					},
+				},
+			}},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name: "container-01",
+				AllocatedResources: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1"),
+					v1.ResourceMemory: resource.MustParse("1G"),
+				},
+			}},
+		},
+	}
+	tests := []struct {
+		resize v1.PodResizeStatus
+		cpu    int64
+		memory int64
+	}{
+		{"", 2000, 500 * 1000 * 1000},
+		{v1.PodResizeStatusProposed, 1000, 1000 * 1000 * 1000},
+		{v1.PodResizeStatusDeferred, 1000, 1000 * 1000 * 1000},
+		{v1.PodResizeStatusInfeasible, 1000, 1000 * 1000 * 1000},
+		{v1.PodResizeStatusInProgress, 2000, 1000 * 1000 * 1000},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.resize), func(t *testing.T) {
+			pod.Status.Resize = tt.resize
+			res := GetPodResource(pod)
+			assert.Equal(t, res.Resources[siCommon.CPU].GetValue(), tt.cpu)
+			assert.Equal(t, res.Resources[siCommon.Memory].GetValue(), tt.memory)
+			assert.Equal(t, res.Resources["pods"].GetValue(), int64(1))
+		})
+	}
+}
+
 func TestBestEffortPod(t *testing.T) {
 	resources := make(map[v1.ResourceName]resource.Quantity)
 	containers := make([]v1.Container, 0)
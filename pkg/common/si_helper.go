@@ -76,11 +76,12 @@ func CreatePriorityForTask(pod *v1.Pod) int32 {
 	return 0
 }
 
-func CreateAllocationRequestForTask(appID, taskID string, resource *si.Resource, placeholder bool, taskGroupName string, pod *v1.Pod, originator bool, preemptionPolicy *si.PreemptionPolicy) *si.AllocationRequest {
+func CreateAllocationRequestForTask(appID, taskID, partition string, resource *si.Resource, placeholder bool, taskGroupName string, pod *v1.Pod, originator bool, preemptionPolicy *si.PreemptionPolicy) *si.AllocationRequest {
 	ask := si.AllocationAsk{
 		AllocationKey:    taskID,
 		ResourceAsk:      resource,
 		ApplicationID:    appID,
+		PartitionName:    partition,
 		MaxAllocations:   1,
 		Tags:             CreateTagsForTask(pod),
 		Placeholder:      placeholder,
@@ -143,7 +144,7 @@ func CreateReleaseAllocationRequestForTask(appID, allocUUID, partition, terminat
 }
 
 // CreateUpdateRequestForNewNode builds a NodeRequest for new node addition and restoring existing node
-func CreateUpdateRequestForNewNode(nodeID string, nodeLabels map[string]string, capacity *si.Resource, occupied *si.Resource,
+func CreateUpdateRequestForNewNode(nodeID, partition, weight string, nodeLabels map[string]string, capacity *si.Resource, occupied *si.Resource,
 	existingAllocations []*si.Allocation, ready bool) *si.NodeRequest {
 	// Use node's name as the NodeID, this is because when bind pod to node,
 	// name of node is required but uid is optional.
@@ -167,6 +168,10 @@ func CreateUpdateRequestForNewNode(nodeID string, nodeLabels map[string]string,
 
 	// Add instanceType to Attributes map
 	nodeInfo.Attributes[common.InstanceType] = nodeLabels[conf.GetSchedulerConf().InstanceTypeNodeLabelKey]
+	nodeInfo.Attributes[common.NodePartition] = partition
+	if weight != "" {
+		nodeInfo.Attributes[constants.NodeWeightAttribute] = weight
+	}
 
 	nodes := make([]*si.NodeInfo, 1)
 	nodes[0] = nodeInfo
@@ -177,18 +182,22 @@ func CreateUpdateRequestForNewNode(nodeID string, nodeLabels map[string]string,
 }
 
 // CreateUpdateRequestForUpdatedNode builds a NodeRequest for any node updates like capacity,
-// ready status flag etc
-func CreateUpdateRequestForUpdatedNode(nodeID string, capacity *si.Resource, occupied *si.Resource,
+// ready status flag, weight etc
+func CreateUpdateRequestForUpdatedNode(nodeID, partition, weight string, capacity *si.Resource, occupied *si.Resource,
 	ready bool) *si.NodeRequest {
 	nodeInfo := &si.NodeInfo{
 		NodeID: nodeID,
 		Attributes: map[string]string{
 			common.NodeReadyAttribute: strconv.FormatBool(ready),
+			common.NodePartition:      partition,
 		},
 		SchedulableResource: capacity,
 		OccupiedResource:    occupied,
 		Action:              si.NodeInfo_UPDATE,
 	}
+	if weight != "" {
+		nodeInfo.Attributes[constants.NodeWeightAttribute] = weight
+	}
 
 	nodes := make([]*si.NodeInfo, 1)
 	nodes[0] = nodeInfo
@@ -200,10 +209,13 @@ func CreateUpdateRequestForUpdatedNode(nodeID string, capacity *si.Resource, occ
 
 // CreateUpdateRequestForDeleteOrRestoreNode builds a NodeRequest for Node actions like drain,
 // decommissioning & restore
-func CreateUpdateRequestForDeleteOrRestoreNode(nodeID string, action si.NodeInfo_ActionFromRM) *si.NodeRequest {
+func CreateUpdateRequestForDeleteOrRestoreNode(nodeID, partition string, action si.NodeInfo_ActionFromRM) *si.NodeRequest {
 	deletedNodes := make([]*si.NodeInfo, 1)
 	nodeInfo := &si.NodeInfo{
 		NodeID: nodeID,
+		Attributes: map[string]string{
+			common.NodePartition: partition,
+		},
 		Action: action,
 	}
 
@@ -94,7 +94,7 @@ func TestCreateUpdateRequestForTask(t *testing.T) {
 		AllowPreemptOther: true,
 	}
 
-	updateRequest := CreateAllocationRequestForTask("appId1", "taskId1", res, false, "", pod, false, preemptionPolicy)
+	updateRequest := CreateAllocationRequestForTask("appId1", "taskId1", "default", res, false, "", pod, false, preemptionPolicy)
 	asks := updateRequest.Asks
 	assert.Equal(t, len(asks), 1)
 	allocAsk := asks[0]
@@ -222,12 +222,12 @@ func TestCreateUpdateRequestForNewNode(t *testing.T) {
 		"label2":                           "key2",
 		"node.kubernetes.io/instance-type": "HighMem",
 	}
-	request := CreateUpdateRequestForNewNode(nodeID, nodeLabels, capacity, occupied, existingAllocations, ready)
+	request := CreateUpdateRequestForNewNode(nodeID, "part", "", nodeLabels, capacity, occupied, existingAllocations, ready)
 	assert.Equal(t, len(request.Nodes), 1)
 	assert.Equal(t, request.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request.Nodes[0].SchedulableResource, capacity)
 	assert.Equal(t, request.Nodes[0].OccupiedResource, occupied)
-	assert.Equal(t, len(request.Nodes[0].Attributes), 7)
+	assert.Equal(t, len(request.Nodes[0].Attributes), 8)
 	assert.Equal(t, request.Nodes[0].Attributes[constants.DefaultNodeAttributeHostNameKey], nodeID)
 	assert.Equal(t, request.Nodes[0].Attributes[constants.DefaultNodeAttributeRackNameKey], constants.DefaultRackName)
 	assert.Equal(t, request.Nodes[0].Attributes[common.NodeReadyAttribute], strconv.FormatBool(ready))
@@ -239,37 +239,49 @@ func TestCreateUpdateRequestForNewNode(t *testing.T) {
 
 	// Make sure include the instanceType
 	assert.Equal(t, request.Nodes[0].Attributes[common.InstanceType], "HighMem")
+	assert.Equal(t, request.Nodes[0].Attributes[common.NodePartition], "part")
+
+	// the weight is only added if set
+	request = CreateUpdateRequestForNewNode(nodeID, "part", "2.5", nodeLabels, capacity, occupied, existingAllocations, ready)
+	assert.Equal(t, len(request.Nodes[0].Attributes), 9)
+	assert.Equal(t, request.Nodes[0].Attributes[constants.NodeWeightAttribute], "2.5")
 }
 
 func TestCreateUpdateRequestForUpdatedNode(t *testing.T) {
 	capacity := NewResourceBuilder().AddResource(common.Memory, 200).AddResource(common.CPU, 2).Build()
 	occupied := NewResourceBuilder().AddResource(common.Memory, 50).AddResource(common.CPU, 1).Build()
 	ready := true
-	request := CreateUpdateRequestForUpdatedNode(nodeID, capacity, occupied, ready)
+	request := CreateUpdateRequestForUpdatedNode(nodeID, "part", "", capacity, occupied, ready)
 	assert.Equal(t, len(request.Nodes), 1)
 	assert.Equal(t, request.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request.Nodes[0].SchedulableResource, capacity)
 	assert.Equal(t, request.Nodes[0].OccupiedResource, occupied)
-	assert.Equal(t, len(request.Nodes[0].Attributes), 1)
+	assert.Equal(t, len(request.Nodes[0].Attributes), 2)
 	assert.Equal(t, request.Nodes[0].Attributes[common.NodeReadyAttribute], strconv.FormatBool(ready))
+	assert.Equal(t, request.Nodes[0].Attributes[common.NodePartition], "part")
+
+	request = CreateUpdateRequestForUpdatedNode(nodeID, "part", "10", capacity, occupied, ready)
+	assert.Equal(t, len(request.Nodes[0].Attributes), 3)
+	assert.Equal(t, request.Nodes[0].Attributes[constants.NodeWeightAttribute], "10")
 }
 
 func TestCreateUpdateRequestForDeleteNode(t *testing.T) {
 	action := si.NodeInfo_DECOMISSION
 	// asserting against this empty map ensures core doesn't have any issues
-	request := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, action)
+	request := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, "part", action)
 	assert.Equal(t, len(request.Nodes), 1)
 	assert.Equal(t, request.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request.Nodes[0].Action, action)
+	assert.Equal(t, request.Nodes[0].Attributes[common.NodePartition], "part")
 
 	action1 := si.NodeInfo_DRAIN_NODE
-	request1 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, action1)
+	request1 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, "part", action1)
 	assert.Equal(t, len(request1.Nodes), 1)
 	assert.Equal(t, request1.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request1.Nodes[0].Action, action1)
 
 	action2 := si.NodeInfo_DRAIN_TO_SCHEDULABLE
-	request2 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, action2)
+	request2 := CreateUpdateRequestForDeleteOrRestoreNode(nodeID, "part", action2)
 	assert.Equal(t, len(request2.Nodes), 1)
 	assert.Equal(t, request2.Nodes[0].NodeID, nodeID)
 	assert.Equal(t, request2.Nodes[0].Action, action2)
@@ -300,7 +312,7 @@ func TestCreateAllocationRequestForTask(t *testing.T) {
 		AllowPreemptOther: true,
 	}
 
-	updateRequest := CreateAllocationRequestForTask("appId1", "taskId1", res, false, "", pod, false, preemptionPolicy)
+	updateRequest := CreateAllocationRequestForTask("appId1", "taskId1", "default", res, false, "", pod, false, preemptionPolicy)
 	asks := updateRequest.Asks
 	assert.Equal(t, len(asks), 1)
 	allocAsk := asks[0]
@@ -308,6 +320,7 @@ func TestCreateAllocationRequestForTask(t *testing.T) {
 		t.Fatal("ask cannot be nil")
 	}
 	assert.Equal(t, allocAsk.Priority, int32(0))
+	assert.Equal(t, allocAsk.PartitionName, "default")
 	assert.Assert(t, allocAsk.PreemptionPolicy != nil)
 	assert.Equal(t, allocAsk.PreemptionPolicy.AllowPreemptSelf, false)
 	assert.Equal(t, allocAsk.PreemptionPolicy.AllowPreemptOther, true)
@@ -333,7 +346,7 @@ func TestCreateAllocationRequestForTask(t *testing.T) {
 		AllowPreemptOther: false,
 	}
 
-	updateRequest1 := CreateAllocationRequestForTask("appId1", "taskId1", res, false, "", pod1, false, preemptionPolicy1)
+	updateRequest1 := CreateAllocationRequestForTask("appId1", "taskId1", "gpu", res, false, "", pod1, false, preemptionPolicy1)
 	asks1 := updateRequest1.Asks
 	assert.Equal(t, len(asks1), 1)
 	allocAsk1 := asks1[0]
@@ -346,4 +359,5 @@ func TestCreateAllocationRequestForTask(t *testing.T) {
 	tags := allocAsk1.Tags
 	assert.Equal(t, tags[common.DomainK8s+common.GroupMeta+"podName"], podName1)
 	assert.Equal(t, allocAsk1.Priority, int32(100))
+	assert.Equal(t, allocAsk1.PartitionName, "gpu")
 }
@@ -39,13 +39,20 @@ func NewMockNamespaceLister(errIfNotFound bool) listersV1.NamespaceLister {
 }
 
 func (nsl *MockNamespaceLister) List(labels.Selector) (ret []*v1.Namespace, err error) {
-	return nil, nil
+	for _, ns := range nsl.namespaces {
+		ret = append(ret, ns)
+	}
+	return ret, nil
 }
 
 func (nsl *MockNamespaceLister) Add(ns *v1.Namespace) {
 	nsl.namespaces[ns.Name] = ns
 }
 
+func (nsl *MockNamespaceLister) Delete(name string) {
+	delete(nsl.namespaces, name)
+}
+
 func (nsl *MockNamespaceLister) Get(name string) (*v1.Namespace, error) {
 	ns, ok := nsl.namespaces[name]
 	if !ok {
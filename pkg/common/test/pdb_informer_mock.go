@@ -0,0 +1,52 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package test
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	informersV1 "k8s.io/client-go/informers/policy/v1"
+	listersV1 "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MockPDBInformer serves PodDisruptionBudgets from an in-memory indexer, use Add to populate it
+type MockPDBInformer struct {
+	indexer cache.Indexer
+	lister  listersV1.PodDisruptionBudgetLister
+}
+
+func NewMockPDBInformer() informersV1.PodDisruptionBudgetInformer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	return &MockPDBInformer{
+		indexer: indexer,
+		lister:  listersV1.NewPodDisruptionBudgetLister(indexer),
+	}
+}
+
+func (pi *MockPDBInformer) Informer() cache.SharedIndexInformer {
+	return nil
+}
+
+func (pi *MockPDBInformer) Lister() listersV1.PodDisruptionBudgetLister {
+	return pi.lister
+}
+
+func (pi *MockPDBInformer) Add(pdb *policyv1.PodDisruptionBudget) error {
+	return pi.indexer.Add(pdb)
+}
@@ -21,7 +21,9 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +34,7 @@ import (
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	podv1 "k8s.io/kubernetes/pkg/api/v1/pod"
 
 	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
@@ -69,6 +72,25 @@ func Convert2PriorityClass(obj interface{}) *schedulingv1.PriorityClass {
 	return nil
 }
 
+func Convert2Namespace(obj interface{}) *v1.Namespace {
+	if namespace, ok := obj.(*v1.Namespace); ok {
+		return namespace
+	}
+	log.Log(log.ShimUtils).Warn("cannot convert to *v1.Namespace", zap.Stringer("type", reflect.TypeOf(obj)))
+	return nil
+}
+
+// IsPreemptSelfAllowed returns true if pods with the priority class can be preempted.
+// Only returns false if the allow-preemption annotation on the PriorityClass is set to false. The preemption
+// policy of the PriorityClass does not opt out: it controls if the pods preempt other pods, not if they can be
+// preempted themselves.
+func IsPreemptSelfAllowed(priorityClass *schedulingv1.PriorityClass) bool {
+	if priorityClass == nil {
+		return true
+	}
+	return priorityClass.Annotations[constants.AnnotationAllowPreemption] != constants.False
+}
+
 func NeedRecovery(pod *v1.Pod) bool {
 	// pod requires recovery needs to satisfy both conditions
 	// 1. Pod is scheduled by us
@@ -89,6 +111,26 @@ func IsPodTerminated(pod *v1.Pod) bool {
 	return pod.Status.Phase == v1.PodFailed || pod.Status.Phase == v1.PodSucceeded
 }
 
+// kubeletRejectedMessagePrefix is the prefix of the status message the kubelet sets when it rejects a pod on admission
+const kubeletRejectedMessagePrefix = "Pod was rejected: "
+
+// IsPodRejectedByKubelet returns true if the kubelet on the assigned node refused to admit the pod
+func IsPodRejectedByKubelet(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodFailed && strings.HasPrefix(pod.Status.Message, kubeletRejectedMessagePrefix)
+}
+
+// IsServiceApplicationPod returns true if the pod belongs to an application with the service profile
+func IsServiceApplicationPod(pod *v1.Pod) bool {
+	return strings.EqualFold(GetPodAnnotationValue(pod, constants.AnnotationApplicationProfile), constants.ApplicationProfileService)
+}
+
+// IsMirrorPod returns true if the pod is the mirror pod of a static pod, created by the kubelet to show the
+// static pod in the API server. The kubelet runs the static pod on its node, it is never scheduled.
+func IsMirrorPod(pod *v1.Pod) bool {
+	_, ok := pod.Annotations[v1.MirrorPodAnnotationKey]
+	return ok
+}
+
 // assignedPod selects pods that are assigned (scheduled and running).
 func IsAssignedPod(pod *v1.Pod) bool {
 	return len(pod.Spec.NodeName) != 0
@@ -104,6 +146,92 @@ func GetQueueNameFromPod(pod *v1.Pod) string {
 	return queueName
 }
 
+// GetPartitionFromPod returns the partition set via the label or annotation on the pod, or an empty string if not set.
+func GetPartitionFromPod(pod *v1.Pod) string {
+	if partition := GetPodLabelValue(pod, constants.LabelPartition); partition != "" {
+		return partition
+	}
+	return GetPodAnnotationValue(pod, constants.LabelPartition)
+}
+
+// GetNodePartition returns the partition a node with the given labels belongs to.
+func GetNodePartition(nodeLabels map[string]string) string {
+	if partition := nodeLabels[constants.LabelPartition]; partition != "" {
+		return partition
+	}
+	return constants.DefaultPartition
+}
+
+// GetNodeWeight returns the weight set on the node, an empty string if the weight is not set or invalid.
+// The weight must be a non-negative number, it is returned in its canonical form.
+func GetNodeWeight(node *v1.Node) string {
+	weight, ok := node.Annotations[constants.NodeWeight]
+	if !ok {
+		weight, ok = node.Labels[constants.NodeWeight]
+	}
+	if !ok {
+		return ""
+	}
+	value, err := strconv.ParseFloat(weight, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		log.Log(log.ShimUtils).Warn("ignoring invalid node weight",
+			zap.String("nodeName", node.Name),
+			zap.String("weight", weight))
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// nodeOvercommitAnnotations maps the resources that can be overcommitted to the annotation that sets the ratio
+var nodeOvercommitAnnotations = map[v1.ResourceName]string{
+	v1.ResourceCPU:    constants.AnnotationCPUOvercommit,
+	v1.ResourceMemory: constants.AnnotationMemoryOvercommit,
+}
+
+// GetNodeOvercommit returns the overcommit ratios set on the node per resource. Invalid ratios are ignored, a ratio
+// must be a positive number.
+func GetNodeOvercommit(node *v1.Node) map[v1.ResourceName]float64 {
+	ratios := make(map[v1.ResourceName]float64)
+	for name, annotation := range nodeOvercommitAnnotations {
+		ratio, ok := node.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(ratio, 64)
+		if err != nil || value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+			log.Log(log.ShimUtils).Warn("ignoring invalid node overcommit ratio",
+				zap.String("nodeName", node.Name),
+				zap.String("annotation", annotation),
+				zap.String("ratio", ratio))
+			continue
+		}
+		ratios[name] = value
+	}
+	return ratios
+}
+
+// ApplyNodeOvercommit returns the node with the allocatable resources multiplied by the overcommit ratios set on
+// the node. The node is returned unchanged if no ratios are set, otherwise a copy is modified.
+func ApplyNodeOvercommit(node *v1.Node) *v1.Node {
+	ratios := GetNodeOvercommit(node)
+	if len(ratios) == 0 {
+		return node
+	}
+	result := node.DeepCopy()
+	for name, ratio := range ratios {
+		quantity, ok := result.Status.Allocatable[name]
+		if !ok {
+			continue
+		}
+		if name == v1.ResourceCPU {
+			result.Status.Allocatable[name] = *resource.NewMilliQuantity(int64(float64(quantity.MilliValue())*ratio), quantity.Format)
+		} else {
+			result.Status.Allocatable[name] = *resource.NewQuantity(int64(float64(quantity.Value())*ratio), quantity.Format)
+		}
+	}
+	return result
+}
+
 // GetApplicationIDFromPod returns the applicationID (if present) from a Pod or an empty string if not present.
 // If an applicationID is present, the Pod is managed by YuniKorn. Otherwise, it is managed by an external scheduler.
 func GetApplicationIDFromPod(pod *v1.Pod) string {
@@ -111,6 +239,10 @@ func GetApplicationIDFromPod(pod *v1.Pod) string {
 	if strings.Compare(pod.Spec.SchedulerName, constants.SchedulerName) != 0 {
 		return ""
 	}
+	// static pods are run by the kubelet, the mirror pods are never part of an application
+	if IsMirrorPod(pod) {
+		return ""
+	}
 	// if pod was tagged with ignore-application, return
 	if value := GetPodAnnotationValue(pod, constants.AnnotationIgnoreApplication); value != "" {
 		ignore, err := strconv.ParseBool(value)
@@ -142,6 +274,29 @@ func PodUnderCondition(pod *v1.Pod, condition *v1.PodCondition) bool {
 	return current != nil && current.Status == condition.Status && current.Reason == condition.Reason
 }
 
+// FormatSchedulingReason returns the message for a pod condition tagged with the scheduling reason code.
+func FormatSchedulingReason(code, message string) string {
+	if message == "" {
+		return code
+	}
+	return code + ": " + message
+}
+
+// GetSchedulingReasonCode returns the scheduling reason code set by the shim in the message of the pod condition,
+// an empty string is returned if the message does not carry a known code.
+func GetSchedulingReasonCode(condition *v1.PodCondition) string {
+	if condition == nil {
+		return ""
+	}
+	code, _, _ := strings.Cut(condition.Message, ":")
+	for _, known := range constants.SchedulingReasonCodes {
+		if code == known {
+			return code
+		}
+	}
+	return ""
+}
+
 // get namespace guaranteed resource from namespace annotation
 func GetNamespaceGuaranteedFromAnnotation(namespaceObj *v1.Namespace) *si.Resource {
 	// retrieve guaranteed resource info from annotations
@@ -190,6 +345,69 @@ func GetNamespaceQuotaFromAnnotation(namespaceObj *v1.Namespace) *si.Resource {
 	}
 }
 
+// GetNamespaceMaxGangSize returns the maximum gang size set on the namespace, 0 if not set or invalid.
+func GetNamespaceMaxGangSize(namespaceObj *v1.Namespace) int32 {
+	maxGangSize := GetNameSpaceAnnotationValue(namespaceObj, constants.NamespaceMaxGangSize)
+	if maxGangSize == "" {
+		return 0
+	}
+	size, err := strconv.ParseInt(maxGangSize, 10, 32)
+	if err != nil || size < 0 {
+		log.Log(log.ShimUtils).Warn("Unable to process namespace.maxGangSize annotation",
+			zap.String("namespace", namespaceObj.Name),
+			zap.String("namespace.maxGangSize is", maxGangSize))
+		return 0
+	}
+	return int32(size)
+}
+
+// GetNamespaceMaxPlaceholderResource returns the maximum placeholder resources set on the namespace, nil if not set or invalid.
+func GetNamespaceMaxPlaceholderResource(namespaceObj *v1.Namespace) *si.Resource {
+	maxResource := GetNameSpaceAnnotationValue(namespaceObj, constants.NamespaceMaxPlaceholderResource)
+	if maxResource == "" {
+		return nil
+	}
+	var maxResourceMap map[string]string
+	err := json.Unmarshal([]byte(maxResource), &maxResourceMap)
+	if err != nil {
+		log.Log(log.ShimUtils).Warn("Unable to process namespace.maxPlaceholderResource annotation",
+			zap.String("namespace", namespaceObj.Name),
+			zap.String("namespace.maxPlaceholderResource is", maxResource))
+		return nil
+	}
+	return common.GetResource(maxResourceMap)
+}
+
+// CheckGangLimits returns an error if the task groups request more members than the maximum gang size or more
+// placeholder resources than the maximum. A maximum gang size of 0 and a nil maximum resource are not checked,
+// resource types not in the maximum resource are not limited.
+func CheckGangLimits(taskGroups []v1alpha1.TaskGroup, maxGangSize int32, maxResource *si.Resource) error {
+	var gangSize int64
+	total := common.NewResourceBuilder().Build()
+	for _, tg := range taskGroups {
+		gangSize += int64(tg.MinMember)
+		total = common.Add(total, common.GetTGResource(tg.MinResource, int64(tg.MinMember)))
+	}
+	if maxGangSize > 0 && gangSize > int64(maxGangSize) {
+		return fmt.Errorf("gang size %d exceeds the maximum gang size %d of the namespace", gangSize, maxGangSize)
+	}
+	if maxResource == nil {
+		return nil
+	}
+	names := make([]string, 0, len(maxResource.Resources))
+	for name := range maxResource.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		limit := maxResource.Resources[name].GetValue()
+		if requested := total.Resources[name].GetValue(); requested > limit {
+			return fmt.Errorf("placeholder resource %s of %d exceeds the maximum %d of the namespace", name, requested, limit)
+		}
+	}
+	return nil
+}
+
 type K8sResource struct {
 	ResourceName v1.ResourceName
 	Value        int64
@@ -356,6 +574,22 @@ func GetPodAnnotationValue(pod *v1.Pod, annotationKey string) string {
 	return ""
 }
 
+// GetAppMaxRunDuration returns the maximum run duration of the application of the pod, zero if it is not set.
+func GetAppMaxRunDuration(pod *v1.Pod) (time.Duration, error) {
+	value := GetPodAnnotationValue(pod, constants.AnnotationAppMaxRunDuration)
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("max run duration must be positive: %s", value)
+	}
+	return duration, nil
+}
+
 func GetNameSpaceAnnotationValue(namespace *v1.Namespace, annotationKey string) string {
 	if value, ok := namespace.Annotations[annotationKey]; ok {
 		return value
@@ -401,11 +635,17 @@ func GetTaskGroupsFromAnnotation(pod *v1.Pod) ([]v1alpha1.TaskGroup, error) {
 		return nil, err
 	}
 	// json.Unmarchal won't return error if name or MinMember is empty, but will return error if MinResource is empty or error format.
+	names := make(map[string]bool, len(taskGroups))
 	for _, taskGroup := range taskGroups {
 		if taskGroup.Name == "" {
 			return nil, fmt.Errorf("can't get taskGroup Name from pod annotation, %s",
 				taskGroupInfo)
 		}
+		if names[taskGroup.Name] {
+			return nil, fmt.Errorf("duplicate taskGroup Name %s in pod annotation, %s",
+				taskGroup.Name, taskGroupInfo)
+		}
+		names[taskGroup.Name] = true
 		if taskGroup.MinResource == nil {
 			return nil, fmt.Errorf("can't get taskGroup MinResource from pod annotation, %s",
 				taskGroupInfo)
@@ -418,6 +658,37 @@ func GetTaskGroupsFromAnnotation(pod *v1.Pod) ([]v1alpha1.TaskGroup, error) {
 			return nil, fmt.Errorf("minMember cannot be negative, %s",
 				taskGroupInfo)
 		}
+		// labels and annotations are copied to the placeholders, catch errors before creating the placeholders fails
+		if err = validateTaskGroupMetadata(taskGroup); err != nil {
+			return nil, err
+		}
 	}
 	return taskGroups, nil
 }
+
+// validateTaskGroupMetadata checks that the labels, annotations and topology key of the task group are valid for a pod
+func validateTaskGroupMetadata(taskGroup v1alpha1.TaskGroup) error {
+	for key, value := range taskGroup.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("taskGroup %s has invalid label key %q: %s", taskGroup.Name, key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("taskGroup %s has invalid value for label %q: %s", taskGroup.Name, key, strings.Join(errs, "; "))
+		}
+	}
+	for key := range taskGroup.Annotations {
+		if errs := validation.IsQualifiedName(strings.ToLower(key)); len(errs) > 0 {
+			return fmt.Errorf("taskGroup %s has invalid annotation key %q: %s", taskGroup.Name, key, strings.Join(errs, "; "))
+		}
+	}
+	// the placeholders are labelled with the task group name when a topology key is set
+	if taskGroup.TopologyKey != "" {
+		if errs := validation.IsQualifiedName(taskGroup.TopologyKey); len(errs) > 0 {
+			return fmt.Errorf("taskGroup %s has invalid topologyKey %q: %s", taskGroup.Name, taskGroup.TopologyKey, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(taskGroup.Name); len(errs) > 0 {
+			return fmt.Errorf("taskGroup name %q cannot be used with a topologyKey: %s", taskGroup.Name, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
@@ -63,6 +64,29 @@ func TestIsAssignedPod(t *testing.T) {
 	assert.Equal(t, assigned, false)
 }
 
+func TestIsServiceApplicationPod(t *testing.T) {
+	assert.Assert(t, !IsServiceApplicationPod(&v1.Pod{}), "pod without profile")
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{constants.AnnotationApplicationProfile: constants.ApplicationProfileBatch},
+	}}
+	assert.Assert(t, !IsServiceApplicationPod(pod), "batch profile")
+	pod.Annotations[constants.AnnotationApplicationProfile] = "Service"
+	assert.Assert(t, IsServiceApplicationPod(pod), "service profile")
+}
+
+func TestIsPodRejectedByKubelet(t *testing.T) {
+	assert.Assert(t, !IsPodRejectedByKubelet(&v1.Pod{}), "pending pod")
+	assert.Assert(t, !IsPodRejectedByKubelet(&v1.Pod{
+		Status: v1.PodStatus{Phase: v1.PodFailed, Message: "container failed"},
+	}), "failed pod")
+	assert.Assert(t, !IsPodRejectedByKubelet(&v1.Pod{
+		Status: v1.PodStatus{Phase: v1.PodRunning, Message: "Pod was rejected: no"},
+	}), "running pod")
+	assert.Assert(t, IsPodRejectedByKubelet(&v1.Pod{
+		Status: v1.PodStatus{Phase: v1.PodFailed, Reason: "OutOfcpu", Message: "Pod was rejected: Node didn't have enough resource: cpu"},
+	}), "rejected pod")
+}
+
 func TestGetNamespaceQuotaFromAnnotation(t *testing.T) {
 	testCases := []struct {
 		namespace        *v1.Namespace
@@ -288,6 +312,77 @@ func TestGetNamespaceGuaranteedFromAnnotation(t *testing.T) {
 	}
 }
 
+func TestGetNamespaceGangLimitsFromAnnotation(t *testing.T) {
+	testCases := map[string]struct {
+		annotations      map[string]string
+		expectedSize     int32
+		expectedResource *si.Resource
+	}{
+		"not set": {nil, 0, nil},
+		"gang size": {map[string]string{
+			constants.NamespaceMaxGangSize: "100",
+		}, 100, nil},
+		"invalid gang size": {map[string]string{
+			constants.NamespaceMaxGangSize: "many",
+		}, 0, nil},
+		"negative gang size": {map[string]string{
+			constants.NamespaceMaxGangSize: "-1",
+		}, 0, nil},
+		"placeholder resource": {map[string]string{
+			constants.NamespaceMaxPlaceholderResource: "{\"cpu\": \"10\", \"memory\": \"1G\", \"nvidia.com/gpu\": \"2\"}",
+		}, 0, common.NewResourceBuilder().
+			AddResource(siCommon.CPU, 10000).
+			AddResource(siCommon.Memory, 1000*1000*1000).
+			AddResource("nvidia.com/gpu", 2).
+			Build()},
+		"invalid placeholder resource": {map[string]string{
+			constants.NamespaceMaxPlaceholderResource: "cpu=10",
+		}, 0, nil},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: tc.annotations}}
+			assert.Equal(t, GetNamespaceMaxGangSize(ns), tc.expectedSize)
+			assert.Assert(t, common.Equals(GetNamespaceMaxPlaceholderResource(ns), tc.expectedResource))
+		})
+	}
+}
+
+func TestCheckGangLimits(t *testing.T) {
+	taskGroups := []v1alpha1.TaskGroup{
+		{
+			Name:      "tg-1",
+			MinMember: 3,
+			MinResource: map[string]resource.Quantity{
+				"cpu":    resource.MustParse("500m"),
+				"memory": resource.MustParse("1G"),
+			},
+		},
+		{
+			Name:      "tg-2",
+			MinMember: 1,
+			MinResource: map[string]resource.Quantity{
+				"cpu":            resource.MustParse("1"),
+				"nvidia.com/gpu": resource.MustParse("4"),
+			},
+		},
+	}
+	maxResource := func(res map[string]string) *si.Resource {
+		return common.GetResource(res)
+	}
+	assert.NilError(t, CheckGangLimits(taskGroups, 0, nil))
+	assert.NilError(t, CheckGangLimits(nil, 1, maxResource(map[string]string{"cpu": "1"})))
+	assert.NilError(t, CheckGangLimits(taskGroups, 4, nil))
+	assert.Error(t, CheckGangLimits(taskGroups, 3, nil), "gang size 4 exceeds the maximum gang size 3 of the namespace")
+	assert.NilError(t, CheckGangLimits(taskGroups, 0, maxResource(map[string]string{"cpu": "2500m", "memory": "3G", "nvidia.com/gpu": "4"})))
+	// resources not in the maximum are not limited
+	assert.NilError(t, CheckGangLimits(taskGroups, 0, maxResource(map[string]string{"memory": "3G"})))
+	assert.Error(t, CheckGangLimits(taskGroups, 0, maxResource(map[string]string{"memory": "2G", "cpu": "2"})),
+		"placeholder resource memory of 3000000000 exceeds the maximum 2000000000 of the namespace")
+	assert.Error(t, CheckGangLimits(taskGroups, 0, maxResource(map[string]string{"nvidia.com/gpu": "2"})),
+		"placeholder resource nvidia.com/gpu of 4 exceeds the maximum 2 of the namespace")
+}
+
 func TestGetNamespaceQuotaFromAnnotationUsingNewAndOldAnnotations(t *testing.T) {
 	testCases := []struct {
 		namespace        *v1.Namespace
@@ -492,6 +587,18 @@ func TestPodUnderCondition(t *testing.T) {
 	assert.Equal(t, PodUnderCondition(pod, condition), false)
 }
 
+func TestSchedulingReasonCode(t *testing.T) {
+	message := FormatSchedulingReason(constants.SchedulingReasonQuotaExceeded, "queue quota exceeded")
+	assert.Equal(t, message, "QUOTA_EXCEEDED: queue quota exceeded")
+	assert.Equal(t, FormatSchedulingReason(constants.SchedulingReasonGangWaiting, ""), constants.SchedulingReasonGangWaiting)
+
+	assert.Equal(t, GetSchedulingReasonCode(nil), "")
+	assert.Equal(t, GetSchedulingReasonCode(&v1.PodCondition{Message: message}), constants.SchedulingReasonQuotaExceeded)
+	assert.Equal(t, GetSchedulingReasonCode(&v1.PodCondition{Message: constants.SchedulingReasonGangWaiting}), constants.SchedulingReasonGangWaiting)
+	assert.Equal(t, GetSchedulingReasonCode(&v1.PodCondition{Message: "0/3 nodes are available: insufficient cpu"}), "")
+	assert.Equal(t, GetSchedulingReasonCode(&v1.PodCondition{Message: "UNKNOWN: message"}), "")
+}
+
 func TestGetApplicationIDFromPod(t *testing.T) {
 	appIDInLabel := "labelAppID"
 	appIDInAnnotation := "annotationAppID"
@@ -514,6 +621,13 @@ func TestGetApplicationIDFromPod(t *testing.T) {
 			},
 			Spec: v1.PodSpec{SchedulerName: "default"},
 		}, ""},
+		{"Mirror pod", &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{constants.LabelApplicationID: appIDInLabel},
+				Annotations: map[string]string{v1.MirrorPodAnnotationKey: "hash"},
+			},
+			Spec: v1.PodSpec{SchedulerName: constants.SchedulerName},
+		}, ""},
 		{"AppID defined in annotation", &v1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				Annotations: map[string]string{constants.AnnotationApplicationID: appIDInAnnotation},
@@ -762,6 +876,73 @@ func TestGetQueueNameFromPod(t *testing.T) {
 	}
 }
 
+func TestGetPartitionFromPod(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{}}
+	assert.Equal(t, GetPartitionFromPod(pod), "")
+	pod.Annotations = map[string]string{constants.LabelPartition: "annotation"}
+	assert.Equal(t, GetPartitionFromPod(pod), "annotation")
+	pod.Labels = map[string]string{constants.LabelPartition: "label"}
+	assert.Equal(t, GetPartitionFromPod(pod), "label")
+}
+
+func TestGetNodePartition(t *testing.T) {
+	assert.Equal(t, GetNodePartition(nil), constants.DefaultPartition)
+	assert.Equal(t, GetNodePartition(map[string]string{"label": "value"}), constants.DefaultPartition)
+	assert.Equal(t, GetNodePartition(map[string]string{constants.LabelPartition: ""}), constants.DefaultPartition)
+	assert.Equal(t, GetNodePartition(map[string]string{constants.LabelPartition: "gpu"}), "gpu")
+}
+
+func TestGetNodeWeight(t *testing.T) {
+	node := func(labels, annotations map[string]string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: labels, Annotations: annotations}}
+	}
+	assert.Equal(t, GetNodeWeight(node(nil, nil)), "")
+	assert.Equal(t, GetNodeWeight(node(map[string]string{constants.NodeWeight: "3"}, nil)), "3")
+	assert.Equal(t, GetNodeWeight(node(nil, map[string]string{constants.NodeWeight: "0.50"})), "0.5")
+	assert.Equal(t, GetNodeWeight(node(map[string]string{constants.NodeWeight: "3"}, map[string]string{constants.NodeWeight: "4"})), "4")
+	assert.Equal(t, GetNodeWeight(node(map[string]string{constants.NodeWeight: "heavy"}, nil)), "")
+	assert.Equal(t, GetNodeWeight(node(map[string]string{constants.NodeWeight: "-2"}, nil)), "")
+	assert.Equal(t, GetNodeWeight(node(map[string]string{constants.NodeWeight: "Inf"}, nil)), "")
+}
+
+func TestGetNodeOvercommit(t *testing.T) {
+	node := func(annotations map[string]string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Annotations: annotations}}
+	}
+	assert.Equal(t, len(GetNodeOvercommit(node(nil))), 0)
+	assert.DeepEqual(t, GetNodeOvercommit(node(map[string]string{
+		constants.AnnotationCPUOvercommit:    "1.5",
+		constants.AnnotationMemoryOvercommit: "1.1",
+	})), map[v1.ResourceName]float64{v1.ResourceCPU: 1.5, v1.ResourceMemory: 1.1})
+	for _, ratio := range []string{"high", "0", "-1.5", "NaN", "Inf"} {
+		assert.Equal(t, len(GetNodeOvercommit(node(map[string]string{constants.AnnotationCPUOvercommit: ratio}))), 0, "ratio %s not ignored", ratio)
+	}
+}
+
+func TestApplyNodeOvercommit(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("3500m"),
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+	assert.Equal(t, ApplyNodeOvercommit(node), node, "node without ratios copied")
+
+	node.Annotations = map[string]string{
+		constants.AnnotationCPUOvercommit:    "2",
+		constants.AnnotationMemoryOvercommit: "1.5",
+	}
+	result := ApplyNodeOvercommit(node)
+	assert.Equal(t, result.Status.Allocatable.Cpu().MilliValue(), int64(7000))
+	assert.Equal(t, result.Status.Allocatable.Memory().Value(), int64(12*1024*1024*1024))
+	assert.Equal(t, result.Status.Allocatable.Pods().Value(), int64(110))
+	assert.Equal(t, node.Status.Allocatable.Cpu().MilliValue(), int64(3500), "original node modified")
+}
+
 func TestNeedRecovery(t *testing.T) {
 	const fakeNodeID = "fake-node"
 	testCases := []struct {
@@ -870,6 +1051,36 @@ func TestGetTaskGroupFromPodSpec(t *testing.T) {
 	assert.Equal(t, GetTaskGroupFromPodSpec(pod), "")
 }
 
+func TestGetAppMaxRunDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		err      string
+	}{
+		{"", 0, ""},
+		{"90m", 90 * time.Minute, ""},
+		{"1h30s", time.Hour + 30*time.Second, ""},
+		{"0s", 0, "must be positive"},
+		{"-1h", 0, "must be positive"},
+		{"two hours", 0, "invalid duration"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			pod := &v1.Pod{}
+			if tc.value != "" {
+				pod.Annotations = map[string]string{constants.AnnotationAppMaxRunDuration: tc.value}
+			}
+			duration, err := GetAppMaxRunDuration(pod)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, duration, tc.expected)
+		})
+	}
+}
+
 func TestGetPlaceholderFlagFromPodSpec(t *testing.T) {
 	testCases := []struct {
 		description             string
@@ -1044,6 +1255,24 @@ func TestGetTaskGroupFromAnnotation(t *testing.T) {
 			}
 		}
 	]`
+	// duplicate name
+	testGroupErr7 := `
+	[
+		{
+			"name": "test-group-err-7",
+			"minMember": 1,
+			"minResource": {
+				"cpu": 1
+			}
+		},
+		{
+			"name": "test-group-err-7",
+			"minMember": 2,
+			"minResource": {
+				"cpu": 2
+			}
+		}
+	]`
 	// Insert task group info to pod annotation
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1085,6 +1314,10 @@ func TestGetTaskGroupFromAnnotation(t *testing.T) {
 	taskGroupErr6, err := GetTaskGroupsFromAnnotation(pod)
 	assert.Assert(t, taskGroupErr6 == nil)
 	assert.Assert(t, err != nil)
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: testGroupErr7}
+	taskGroupErr7, err := GetTaskGroupsFromAnnotation(pod)
+	assert.Assert(t, taskGroupErr7 == nil)
+	assert.ErrorContains(t, err, "duplicate taskGroup Name test-group-err-7")
 	// Correct case
 	pod.Annotations = map[string]string{constants.AnnotationTaskGroups: testGroup}
 	taskGroups, err := GetTaskGroupsFromAnnotation(pod)
@@ -1121,6 +1354,38 @@ func TestGetTaskGroupFromAnnotation(t *testing.T) {
 	assert.Equal(t, taskGroups2[0].MinResource["memory"], resource.MustParse("1Gi"))
 }
 
+func TestGetTaskGroupsFromAnnotationMetadata(t *testing.T) {
+	tests := map[string]struct {
+		metadata string
+		err      string
+	}{
+		"valid":                  {`"labels": {"app.kubernetes.io/name": "spark"}, "annotations": {"example.com/Cost-Center": "a b"}`, ""},
+		"invalid label key":      {`"labels": {"not a key": "value"}`, "invalid label key"},
+		"invalid label value":    {`"labels": {"key": "not a value"}`, "invalid value for label"},
+		"invalid annotation key": {`"annotations": {"/key": "value"}`, "invalid annotation key"},
+		"topology key":           {`"topologyKey": "topology.kubernetes.io/zone"`, ""},
+		"invalid topology key":   {`"topologyKey": "zone/"`, "invalid topologyKey"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.AnnotationTaskGroups: fmt.Sprintf(
+						`[{"name": "tg", "minMember": 1, "minResource": {"cpu": 1}, %s}]`, tc.metadata)},
+				},
+			}
+			taskGroups, err := GetTaskGroupsFromAnnotation(pod)
+			if tc.err == "" {
+				assert.NilError(t, err)
+				assert.Equal(t, len(taskGroups), 1)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+				assert.Assert(t, taskGroups == nil)
+			}
+		})
+	}
+}
+
 func TestGetCoreSchedulerConfigFromConfigMapNil(t *testing.T) {
 	assert.Equal(t, "", GetCoreSchedulerConfigFromConfigMap(nil))
 }
@@ -1167,6 +1432,46 @@ func TestGetExtraConfigFromConfigMap(t *testing.T) {
 	assert.Equal(t, "value", value, "wrong value")
 }
 
+func TestConvert2Namespace(t *testing.T) {
+	assert.Assert(t, Convert2Namespace(nil) == nil)
+	assert.Assert(t, Convert2Namespace("foo") == nil)
+	assert.Assert(t, Convert2Namespace(v1.Namespace{}) == nil)
+
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	result := Convert2Namespace(ns)
+	assert.Assert(t, result != nil)
+	assert.Equal(t, result.Name, "test")
+}
+
+func TestIsPreemptSelfAllowed(t *testing.T) {
+	preemptNever := v1.PreemptNever
+	preemptLower := v1.PreemptLowerPriority
+	tests := map[string]struct {
+		annotations map[string]string
+		policy      *v1.PreemptionPolicy
+		expect      bool
+	}{
+		"nil annotations":       {nil, nil, true},
+		"empty annotations":     {map[string]string{}, nil, true},
+		"invalid value":         {map[string]string{constants.AnnotationAllowPreemption: "value"}, nil, true},
+		"valid value":           {map[string]string{constants.AnnotationAllowPreemption: "false"}, nil, false},
+		"policy never":          {nil, &preemptNever, true},
+		"policy lower priority": {nil, &preemptLower, true},
+		"false with never":      {map[string]string{constants.AnnotationAllowPreemption: "false"}, &preemptNever, false},
+		"true with never":       {map[string]string{constants.AnnotationAllowPreemption: "true"}, &preemptNever, true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pc := &schedulingv1.PriorityClass{
+				ObjectMeta:       metav1.ObjectMeta{Annotations: test.annotations},
+				PreemptionPolicy: test.policy,
+			}
+			assert.Equal(t, IsPreemptSelfAllowed(pc), test.expect, "value incorrect")
+		})
+	}
+	assert.Assert(t, IsPreemptSelfAllowed(nil), "nil priority class")
+}
+
 func TestConvert2PriorityClass(t *testing.T) {
 	assert.Assert(t, Convert2PriorityClass(nil) == nil)
 	assert.Assert(t, Convert2PriorityClass("foo") == nil)
@@ -0,0 +1,332 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package queuegen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+)
+
+// names used as the base for the generated queue names, a sequence number makes each name unique
+var queueNames = []string{
+	"analytics", "batch", "data", "engineering", "etl", "finance", "inference", "marketing",
+	"ml", "platform", "reporting", "research", "sales", "search", "streaming", "training",
+}
+
+// Spec defines the shape of the generated queue configuration
+type Spec struct {
+	// Queues is the number of queues generated below the root queue
+	Queues int
+	// Depth is the maximum number of levels below the root queue
+	Depth int
+	// Seed for the random generator, the same spec always generates the same configuration
+	Seed int64
+}
+
+// Stats describes a generated or parsed queue configuration
+type Stats struct {
+	Queues int
+	Leaves int
+	Depth  int
+	Bytes  int
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("queues=%d leaves=%d depth=%d size=%dB", s.Queues, s.Leaves, s.Depth, s.Bytes)
+}
+
+// Result contains the time it took to process a queue configuration
+type Result struct {
+	Stats
+	Iterations int
+	P50        time.Duration
+	Max        time.Duration
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%s iterations=%d p50=%v max=%v", r.Stats, r.Iterations, r.P50, r.Max)
+}
+
+type queue struct {
+	name     string
+	level    int
+	seq      int
+	children []*queue
+}
+
+// resource values are kept in MiB and millicores to divide them without loss
+type limits struct {
+	memory int64
+	vcore  int64
+}
+
+func (l limits) conf() map[string]string {
+	return map[string]string{
+		"memory": fmt.Sprintf("%dMi", l.memory),
+		"vcore":  fmt.Sprintf("%dm", l.vcore),
+	}
+}
+
+// Generate returns a queue configuration with a single default partition that passes the validation of the core.
+// The hierarchy is filled breadth first with a varying number of children, the resources, maximum applications
+// and limits of each queue stay within those of the parent.
+func Generate(spec Spec) (*configs.SchedulerConfig, error) {
+	if spec.Queues <= 0 || spec.Depth <= 0 {
+		return nil, fmt.Errorf("queues and depth must be positive: %+v", spec)
+	}
+	r := rand.New(rand.NewSource(spec.Seed)) //nolint:gosec
+	root := buildHierarchy(r, spec)
+
+	rootConf := configs.QueueConfig{
+		Name:   configs.RootQueue,
+		Parent: true,
+	}
+	for _, child := range root.children {
+		top := limits{
+			memory: int64(256+r.Intn(768)) * 1024,
+			vcore:  int64(64+r.Intn(192)) * 1000,
+		}
+		guaranteed := limits{memory: top.memory / 4, vcore: top.vcore / 4}
+		rootConf.Queues = append(rootConf.Queues, toQueueConfig(r, child, top, guaranteed, uint64(500+r.Intn(1500))))
+	}
+	partition := configs.PartitionConfig{
+		Name:   constants.DefaultPartition,
+		Queues: []configs.QueueConfig{rootConf},
+		PlacementRules: []configs.PlacementRule{
+			{Name: "provided", Create: false},
+		},
+	}
+	// dynamic queues for the users below the first parent queue
+	for _, child := range rootConf.Queues {
+		if child.Parent {
+			partition.PlacementRules = append(partition.PlacementRules, configs.PlacementRule{
+				Name:   "user",
+				Create: true,
+				Parent: &configs.PlacementRule{Name: "fixed", Value: configs.RootQueue + "." + child.Name},
+			})
+			break
+		}
+	}
+	return &configs.SchedulerConfig{Partitions: []configs.PartitionConfig{partition}}, nil
+}
+
+// GenerateYAML returns the generated queue configuration as the content of the queues.yaml
+func GenerateYAML(spec Spec) (string, error) {
+	config, err := Generate(spec)
+	if err != nil {
+		return "", err
+	}
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// buildHierarchy creates the queue tree breadth first. The number of children is random around the fan-out that
+// fills the requested depth, if the tree is complete before all queues are created the remaining queues are
+// spread over the parents that are not at the maximum depth.
+func buildHierarchy(r *rand.Rand, spec Spec) *queue {
+	fanout := 1
+	for capacity(fanout, spec.Depth) < spec.Queues {
+		fanout++
+	}
+	root := &queue{}
+	all := []*queue{root}
+	created := 0
+	for i := 0; created < spec.Queues; i = (i + 1) % len(all) {
+		parent := all[i]
+		if parent.level >= spec.Depth {
+			continue
+		}
+		count := 1
+		// the first pass over the tree sets the shape, later passes only add single queues
+		if len(parent.children) == 0 {
+			count = 1 + r.Intn(2*fanout)
+		}
+		for j := 0; j < count && created < spec.Queues; j++ {
+			created++
+			child := &queue{
+				name:  fmt.Sprintf("%s-%d", queueNames[r.Intn(len(queueNames))], created),
+				level: parent.level + 1,
+				seq:   created,
+			}
+			parent.children = append(parent.children, child)
+			all = append(all, child)
+		}
+	}
+	return root
+}
+
+// capacity returns the number of queues in a complete tree with the fan-out and depth
+func capacity(fanout, depth int) int {
+	total := 0
+	level := 1
+	for i := 0; i < depth; i++ {
+		level *= fanout
+		total += level
+		if total > 1<<30 {
+			break
+		}
+	}
+	return total
+}
+
+// toQueueConfig converts the queue and its children. The maximum is at least half of the maximum of the parent and
+// twice the guaranteed resources, the guaranteed resources of the parent are divided over the children.
+// The sequence number of the queue is used to vary the properties, ACLs and limits of the queues.
+func toQueueConfig(r *rand.Rand, q *queue, max, guaranteed limits, maxApps uint64) configs.QueueConfig {
+	seq := q.seq
+	conf := configs.QueueConfig{
+		Name:            q.name,
+		Parent:          len(q.children) > 0,
+		MaxApplications: maxApps,
+		Properties:      make(map[string]string),
+		Resources: configs.Resources{
+			Max:        max.conf(),
+			Guaranteed: guaranteed.conf(),
+		},
+	}
+	if !conf.Parent {
+		if seq%2 == 0 {
+			conf.Properties[configs.ApplicationSortPolicy] = "fifo"
+		} else {
+			conf.Properties[configs.ApplicationSortPolicy] = "fair"
+		}
+		conf.SubmitACL = fmt.Sprintf("user-%d,user-%d group-%d", seq, seq+1, seq%10)
+		// limits are only set on leaf queues: a limit of a parent would have to cover the same users of all children
+		if seq%3 == 0 {
+			conf.Limits = []configs.Limit{{
+				Limit:           fmt.Sprintf("team %d", seq),
+				Groups:          []string{fmt.Sprintf("team-%d", seq)},
+				MaxApplications: maxApps/2 + 1,
+				MaxResources:    limits{memory: max.memory / 2, vcore: max.vcore / 2}.conf(),
+			}}
+		}
+		// not every leaf has guaranteed resources
+		if seq%5 == 0 {
+			conf.Resources.Guaranteed = nil
+		}
+		return conf
+	}
+
+	conf.AdminACL = fmt.Sprintf("admin-%d", q.level)
+	if seq%4 == 0 {
+		conf.Properties[configs.PriorityOffset] = fmt.Sprintf("%d", r.Intn(100))
+	}
+	if q.level == 1 && seq%2 == 0 {
+		conf.Properties[constants.QueuePropertyNodeSelector] = fmt.Sprintf("pool=%s", q.name)
+	}
+	if seq%3 == 0 {
+		conf.ChildTemplate = configs.ChildTemplate{
+			MaxApplications: maxApps/4 + 1,
+			Resources: configs.Resources{
+				Max: limits{memory: max.memory / 4, vcore: max.vcore / 4}.conf(),
+			},
+		}
+	}
+	n := int64(len(q.children))
+	for _, child := range q.children {
+		childGuaranteed := limits{memory: guaranteed.memory / n, vcore: guaranteed.vcore / n}
+		childMax := limits{
+			memory: maxInt64(max.memory/2+r.Int63n(max.memory/2+1), 2*childGuaranteed.memory),
+			vcore:  maxInt64(max.vcore/2+r.Int63n(max.vcore/2+1), 2*childGuaranteed.vcore),
+		}
+		childApps := maxApps/2 + uint64(r.Int63n(int64(maxApps/2)+1))
+		if childApps == 0 {
+			childApps = 1
+		}
+		conf.Queues = append(conf.Queues, toQueueConfig(r, child, childMax, childGuaranteed, childApps))
+	}
+	return conf
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Measure parses and validates the queue configuration the given number of times, as the shim and the core do on
+// each configuration reload, and returns the statistics of the configuration with the processing time.
+func Measure(content string, iterations int) (*Result, error) {
+	if iterations <= 0 {
+		return nil, fmt.Errorf("iterations must be positive: %d", iterations)
+	}
+	durations := make([]time.Duration, 0, iterations)
+	var config *configs.SchedulerConfig
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		var err error
+		config, err = configs.ParseAndValidateConfig([]byte(content))
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, time.Since(start))
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	stats := GetStats(config)
+	stats.Bytes = len(content)
+	return &Result{
+		Stats:      stats,
+		Iterations: iterations,
+		P50:        durations[(len(durations)-1)/2],
+		Max:        durations[len(durations)-1],
+	}, nil
+}
+
+// GetStats returns the number of queues, leaf queues and the depth of the default partition, the root queue
+// is not counted.
+func GetStats(config *configs.SchedulerConfig) Stats {
+	stats := Stats{}
+	for _, partition := range config.Partitions {
+		if !strings.EqualFold(partition.Name, constants.DefaultPartition) {
+			continue
+		}
+		for _, root := range partition.Queues {
+			for _, child := range root.Queues {
+				addStats(&stats, child, 1)
+			}
+		}
+	}
+	return stats
+}
+
+func addStats(stats *Stats, q configs.QueueConfig, level int) {
+	stats.Queues++
+	if level > stats.Depth {
+		stats.Depth = level
+	}
+	if len(q.Queues) == 0 {
+		stats.Leaves++
+		return
+	}
+	for _, child := range q.Queues {
+		addStats(stats, child, level+1)
+	}
+}
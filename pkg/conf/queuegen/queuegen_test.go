@@ -0,0 +1,76 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package queuegen
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGenerateValid(t *testing.T) {
+	tests := map[string]Spec{
+		"single queue": {Queues: 1, Depth: 1},
+		"flat":         {Queues: 200, Depth: 1, Seed: 1},
+		"deep":         {Queues: 50, Depth: 20, Seed: 2},
+		"large":        {Queues: 5000, Depth: 6, Seed: 3},
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			content, err := GenerateYAML(spec)
+			assert.NilError(t, err)
+			result, err := Measure(content, 1)
+			assert.NilError(t, err, "generated configuration is not valid")
+			assert.Equal(t, result.Queues, spec.Queues)
+			assert.Assert(t, result.Depth <= spec.Depth, "depth %d exceeds %d", result.Depth, spec.Depth)
+			assert.Assert(t, result.Leaves > 0)
+			assert.Equal(t, result.Bytes, len(content))
+		})
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	spec := Spec{Queues: 300, Depth: 4, Seed: 42}
+	first, err := GenerateYAML(spec)
+	assert.NilError(t, err)
+	second, err := GenerateYAML(spec)
+	assert.NilError(t, err)
+	assert.Equal(t, first, second)
+	spec.Seed = 43
+	third, err := GenerateYAML(spec)
+	assert.NilError(t, err)
+	assert.Assert(t, first != third, "different seeds generated the same configuration")
+}
+
+func TestGenerateFillsDepth(t *testing.T) {
+	config, err := Generate(Spec{Queues: 1000, Depth: 5, Seed: 7})
+	assert.NilError(t, err)
+	assert.Equal(t, GetStats(config).Depth, 5)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate(Spec{Queues: 0, Depth: 1})
+	assert.ErrorContains(t, err, "must be positive")
+	_, err = Generate(Spec{Queues: 10, Depth: 0})
+	assert.ErrorContains(t, err, "must be positive")
+	_, err = Measure("partitions: []", 0)
+	assert.ErrorContains(t, err, "iterations must be positive")
+	_, err = Measure("partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: a\n          - name: A\n", 1)
+	assert.ErrorContains(t, err, "duplicate child name")
+}
@@ -53,35 +53,147 @@ const (
 	PrefixKubernetes = "kubernetes."
 
 	// service
-	CMSvcClusterID                    = PrefixService + "clusterId"
-	CMSvcPolicyGroup                  = PrefixService + "policyGroup"
-	CMSvcSchedulingInterval           = PrefixService + "schedulingInterval"
-	CMSvcVolumeBindTimeout            = PrefixService + "volumeBindTimeout"
-	CMSvcEventChannelCapacity         = PrefixService + "eventChannelCapacity"
-	CMSvcDispatchTimeout              = PrefixService + "dispatchTimeout"
-	CMSvcOperatorPlugins              = PrefixService + "operatorPlugins"
-	CMSvcDisableGangScheduling        = PrefixService + "disableGangScheduling"
-	CMSvcEnableConfigHotRefresh       = PrefixService + "enableConfigHotRefresh"
-	CMSvcPlaceholderImage             = PrefixService + "placeholderImage"
-	CMSvcNodeInstanceTypeNodeLabelKey = PrefixService + "nodeInstanceTypeNodeLabelKey"
+	CMSvcClusterID                     = PrefixService + "clusterId"
+	CMSvcPolicyGroup                   = PrefixService + "policyGroup"
+	CMSvcSchedulingInterval            = PrefixService + "schedulingInterval"
+	CMSvcVolumeBindTimeout             = PrefixService + "volumeBindTimeout"
+	CMSvcEventChannelCapacity          = PrefixService + "eventChannelCapacity"
+	CMSvcDispatchTimeout               = PrefixService + "dispatchTimeout"
+	CMSvcOperatorPlugins               = PrefixService + "operatorPlugins"
+	CMSvcDisableGangScheduling         = PrefixService + "disableGangScheduling"
+	CMSvcEnableConfigHotRefresh        = PrefixService + "enableConfigHotRefresh"
+	CMSvcPlaceholderImage              = PrefixService + "placeholderImage"
+	CMSvcNodeInstanceTypeNodeLabelKey  = PrefixService + "nodeInstanceTypeNodeLabelKey"
+	CMSvcAskBatchInterval              = PrefixService + "askBatchInterval"
+	CMSvcAskBatchSize                  = PrefixService + "askBatchSize"
+	CMSvcNodeDeletionMode              = PrefixService + "nodeDeletionMode"
+	CMSvcNodeDeletionGracePeriod       = PrefixService + "nodeDeletionGracePeriod"
+	CMSvcRESTProxyAddress              = PrefixService + "restProxyAddress"
+	CMSvcAppGCTTL                      = PrefixService + "appGCTTL"
+	CMSvcAppGCMaxCompletedPerNamespace = PrefixService + "appGCMaxCompletedPerNamespace"
+	CMSvcAppGCMaxCompleted             = PrefixService + "appGCMaxCompleted"
+	CMSvcCoreBreakerThreshold          = PrefixService + "coreCircuitBreakerThreshold"
+	CMSvcCoreBreakerCooldown           = PrefixService + "coreCircuitBreakerCooldown"
+	CMSvcCoreBreakerBufferSize         = PrefixService + "coreCircuitBreakerBufferSize"
+	CMSvcRecreateRejectedPlaceholders  = PrefixService + "recreateRejectedPlaceholders"
+	CMSvcBindMaxRetries                = PrefixService + "bindMaxRetries"
+	CMSvcBindRetryBackoff              = PrefixService + "bindRetryBackoff"
+	CMSvcRollingUpdatePriorityBoost    = PrefixService + "rollingUpdatePriorityBoost"
+	CMSvcForeignPodExemptSelector      = PrefixService + "foreignPodExemptSelector"
+	CMSvcPlaceholderOrphanTTL          = PrefixService + "placeholderOrphanTTL"
+	CMSvcFailedNodeAvoidanceWindow     = PrefixService + "failedNodeAvoidanceWindow"
+	CMSvcNamespaceQueues               = PrefixService + "namespaceQueues"
+	CMSvcStuckApplicationTimeout       = PrefixService + "stuckApplicationTimeout"
+	CMSvcStuckApplicationReset         = PrefixService + "stuckApplicationReset"
+	CMSvcPlaceholderSizing             = PrefixService + "placeholderSizing"
+	CMSvcNodeSampleInterval            = PrefixService + "nodeSampleInterval"
+	CMSvcTaskJournal                   = PrefixService + "taskJournal"
+	CMSvcPodGroupSync                  = PrefixService + "podGroupSync"
+	CMSvcEventSuggestions              = PrefixService + "eventSuggestions"
+	CMSvcAppEventLogSize               = PrefixService + "appEventLogSize"
+	CMSvcAppEventLogDir                = PrefixService + "appEventLogDir"
+	CMSvcShadowMode                    = PrefixService + "shadowMode"
+	CMSvcEventStreamAddress            = PrefixService + "eventStreamAddress"
+	CMSvcHealthProbeAddress            = PrefixService + "healthProbeAddress"
+	CMSvcExcludeMirrorPods             = PrefixService + "excludeMirrorPods"
+	CMSvcPlaceholderTemplates          = PrefixService + "placeholderTemplates"
+	CMSvcDispatcherWorkers             = PrefixService + "dispatcherWorkers"
+	CMSvcDiagnostics                   = PrefixService + "diagnostics"
+	CMSvcDiagnosticsToken              = PrefixService + "diagnosticsToken"
+	CMSvcDynamicResourceAllocation     = PrefixService + "dynamicResourceAllocation"
+	CMSvcBindWorkers                   = PrefixService + "bindWorkers"
+	CMSvcAppTagLabels                  = PrefixService + "appTagLabels"
+	CMSvcDaemonSetReservationTimeout   = PrefixService + "daemonSetReservationTimeout"
+	CMSvcHonorDisruptionBudgets        = PrefixService + "honorDisruptionBudgets"
+	CMSvcStorageCapacityCheck          = PrefixService + "storageCapacityCheck"
 
 	// kubernetes
 	CMKubeQPS   = PrefixKubernetes + "qps"
 	CMKubeBurst = PrefixKubernetes + "burst"
+	// the events client inherits the QPS and burst of the scheduler client if not set
+	CMKubeEventsQPS         = PrefixKubernetes + "events.qps"
+	CMKubeEventsBurst       = PrefixKubernetes + "events.burst"
+	CMKubeAdaptiveRateLimit = PrefixKubernetes + "adaptiveRateLimit"
 
 	// defaults
-	DefaultNamespace              = "default"
-	DefaultClusterID              = "mycluster"
-	DefaultPolicyGroup            = "queues"
-	DefaultSchedulingInterval     = time.Second
-	DefaultVolumeBindTimeout      = 10 * time.Second
-	DefaultEventChannelCapacity   = 1024 * 1024
-	DefaultDispatchTimeout        = 300 * time.Second
-	DefaultOperatorPlugins        = "general"
-	DefaultDisableGangScheduling  = false
-	DefaultEnableConfigHotRefresh = true
-	DefaultKubeQPS                = 1000
-	DefaultKubeBurst              = 1000
+	DefaultNamespace                     = "default"
+	DefaultClusterID                     = "mycluster"
+	DefaultPolicyGroup                   = "queues"
+	DefaultSchedulingInterval            = time.Second
+	DefaultVolumeBindTimeout             = 10 * time.Second
+	DefaultEventChannelCapacity          = 1024 * 1024
+	DefaultDispatchTimeout               = 300 * time.Second
+	DefaultOperatorPlugins               = "general"
+	DefaultDisableGangScheduling         = false
+	DefaultEnableConfigHotRefresh        = true
+	DefaultAskBatchInterval              = time.Duration(0)
+	DefaultAskBatchSize                  = 500
+	DefaultNodeDeletionMode              = NodeDeletionModeRelease
+	DefaultNodeDeletionGracePeriod       = 5 * time.Minute
+	DefaultRESTProxyAddress              = ""
+	DefaultAppGCTTL                      = time.Duration(0)
+	DefaultAppGCMaxCompletedPerNamespace = 0
+	DefaultAppGCMaxCompleted             = 0
+	DefaultCoreBreakerThreshold          = 0
+	DefaultCoreBreakerCooldown           = 10 * time.Second
+	DefaultCoreBreakerBufferSize         = 10000
+	DefaultRecreateRejectedPlaceholders  = false
+	DefaultBindMaxRetries                = 3
+	DefaultBindRetryBackoff              = 100 * time.Millisecond
+	DefaultRollingUpdatePriorityBoost    = 0
+	DefaultForeignPodExemptSelector      = ""
+	DefaultPlaceholderOrphanTTL          = 5 * time.Minute
+	DefaultFailedNodeAvoidanceWindow     = 10 * time.Minute
+	DefaultNamespaceQueues               = true
+	DefaultStuckApplicationTimeout       = 30 * time.Minute
+	DefaultStuckApplicationReset         = false
+	DefaultPlaceholderSizing             = PlaceholderSizingDisabled
+	DefaultNodeSampleInterval            = time.Duration(0)
+	DefaultTaskJournal                   = ""
+	DefaultPodGroupSync                  = false
+	DefaultEventSuggestions              = false
+	DefaultAppEventLogSize               = 0
+	DefaultAppEventLogDir                = ""
+	DefaultShadowMode                    = false
+	DefaultEventStreamAddress            = ""
+	DefaultHealthProbeAddress            = ""
+	DefaultExcludeMirrorPods             = false
+	DefaultPlaceholderTemplates          = ""
+	DefaultDispatcherWorkers             = 1
+	DefaultDiagnostics                   = false
+	DefaultDynamicResourceAllocation     = false
+	DefaultBindWorkers                   = 16
+	DefaultAppTagLabels                  = ""
+	DefaultDaemonSetReservationTimeout   = time.Duration(0)
+	DefaultHonorDisruptionBudgets        = false
+	DefaultStorageCapacityCheck          = false
+	DefaultKubeQPS                       = 1000
+	DefaultKubeBurst                     = 1000
+	DefaultKubeEventsQPS                 = 0
+	DefaultKubeEventsBurst               = 0
+	DefaultKubeAdaptiveRateLimit         = false
+)
+
+// node deletion modes, define what happens to the allocations on a node that is deleted while pods are still running
+const (
+	// NodeDeletionModeRelease decommissions the node immediately, releasing all allocations on the node
+	NodeDeletionModeRelease = "release"
+	// NodeDeletionModeWait stops scheduling on the node and decommissions it once all pods have been removed,
+	// or when the grace period expires
+	NodeDeletionModeWait = "wait"
+	// NodeDeletionModeMigrate decommissions the node immediately and deletes the pods that are managed by a
+	// controller other than a Job, the controller recreates the pods which are then scheduled on the remaining nodes
+	NodeDeletionModeMigrate = "migrate"
+)
+
+// placeholder sizing modes, define how the requests of previous runs of a workload are used to size the task groups
+const (
+	// PlaceholderSizingDisabled does not track the requests of previous runs
+	PlaceholderSizingDisabled = "disabled"
+	// PlaceholderSizingSuggest reports a task group size that differs from the requests of the previous run
+	PlaceholderSizingSuggest = "suggest"
+	// PlaceholderSizingCorrect replaces the task group size with the requests of the previous run
+	PlaceholderSizingCorrect = "correct"
 )
 
 var (
@@ -101,25 +213,70 @@ var confHolder atomic.Value
 var kubeLoggerOnce sync.Once
 
 type SchedulerConf struct {
-	SchedulerName            string        `json:"schedulerName"`
-	ClusterID                string        `json:"clusterId"`
-	ClusterVersion           string        `json:"clusterVersion"`
-	PolicyGroup              string        `json:"policyGroup"`
-	Interval                 time.Duration `json:"schedulingIntervalSecond"`
-	KubeConfig               string        `json:"absoluteKubeConfigFilePath"`
-	VolumeBindTimeout        time.Duration `json:"volumeBindTimeout"`
-	TestMode                 bool          `json:"testMode"`
-	EventChannelCapacity     int           `json:"eventChannelCapacity"`
-	DispatchTimeout          time.Duration `json:"dispatchTimeout"`
-	KubeQPS                  int           `json:"kubeQPS"`
-	KubeBurst                int           `json:"kubeBurst"`
-	OperatorPlugins          string        `json:"operatorPlugins"`
-	EnableConfigHotRefresh   bool          `json:"enableConfigHotRefresh"`
-	DisableGangScheduling    bool          `json:"disableGangScheduling"`
-	UserLabelKey             string        `json:"userLabelKey"`
-	PlaceHolderImage         string        `json:"placeHolderImage"`
-	InstanceTypeNodeLabelKey string        `json:"instanceTypeNodeLabelKey"`
-	Namespace                string        `json:"namespace"`
+	SchedulerName                 string        `json:"schedulerName"`
+	ClusterID                     string        `json:"clusterId"`
+	ClusterVersion                string        `json:"clusterVersion"`
+	PolicyGroup                   string        `json:"policyGroup"`
+	Interval                      time.Duration `json:"schedulingIntervalSecond"`
+	KubeConfig                    string        `json:"absoluteKubeConfigFilePath"`
+	VolumeBindTimeout             time.Duration `json:"volumeBindTimeout"`
+	TestMode                      bool          `json:"testMode"`
+	EventChannelCapacity          int           `json:"eventChannelCapacity"`
+	DispatchTimeout               time.Duration `json:"dispatchTimeout"`
+	KubeQPS                       int           `json:"kubeQPS"`
+	KubeBurst                     int           `json:"kubeBurst"`
+	KubeEventsQPS                 int           `json:"kubeEventsQPS"`
+	KubeEventsBurst               int           `json:"kubeEventsBurst"`
+	KubeAdaptiveRateLimit         bool          `json:"kubeAdaptiveRateLimit"`
+	OperatorPlugins               string        `json:"operatorPlugins"`
+	EnableConfigHotRefresh        bool          `json:"enableConfigHotRefresh"`
+	DisableGangScheduling         bool          `json:"disableGangScheduling"`
+	UserLabelKey                  string        `json:"userLabelKey"`
+	PlaceHolderImage              string        `json:"placeHolderImage"`
+	InstanceTypeNodeLabelKey      string        `json:"instanceTypeNodeLabelKey"`
+	Namespace                     string        `json:"namespace"`
+	AskBatchInterval              time.Duration `json:"askBatchInterval"`
+	AskBatchSize                  int           `json:"askBatchSize"`
+	NodeDeletionMode              string        `json:"nodeDeletionMode"`
+	NodeDeletionGracePeriod       time.Duration `json:"nodeDeletionGracePeriod"`
+	RESTProxyAddress              string        `json:"restProxyAddress"`
+	AppGCTTL                      time.Duration `json:"appGCTTL"`
+	AppGCMaxCompletedPerNamespace int           `json:"appGCMaxCompletedPerNamespace"`
+	AppGCMaxCompleted             int           `json:"appGCMaxCompleted"`
+	CoreBreakerThreshold          int           `json:"coreCircuitBreakerThreshold"`
+	CoreBreakerCooldown           time.Duration `json:"coreCircuitBreakerCooldown"`
+	CoreBreakerBufferSize         int           `json:"coreCircuitBreakerBufferSize"`
+	RecreateRejectedPlaceholders  bool          `json:"recreateRejectedPlaceholders"`
+	BindMaxRetries                int           `json:"bindMaxRetries"`
+	BindRetryBackoff              time.Duration `json:"bindRetryBackoff"`
+	RollingUpdatePriorityBoost    int           `json:"rollingUpdatePriorityBoost"`
+	ForeignPodExemptSelector      string        `json:"foreignPodExemptSelector"`
+	PlaceholderOrphanTTL          time.Duration `json:"placeholderOrphanTTL"`
+	FailedNodeAvoidanceWindow     time.Duration `json:"failedNodeAvoidanceWindow"`
+	NamespaceQueues               bool          `json:"namespaceQueues"`
+	StuckApplicationTimeout       time.Duration `json:"stuckApplicationTimeout"`
+	StuckApplicationReset         bool          `json:"stuckApplicationReset"`
+	PlaceholderSizing             string        `json:"placeholderSizing"`
+	NodeSampleInterval            time.Duration `json:"nodeSampleInterval"`
+	TaskJournal                   string        `json:"taskJournal"`
+	PodGroupSync                  bool          `json:"podGroupSync"`
+	EventSuggestions              bool          `json:"eventSuggestions"`
+	AppEventLogSize               int           `json:"appEventLogSize"`
+	AppEventLogDir                string        `json:"appEventLogDir"`
+	ShadowMode                    bool          `json:"shadowMode"`
+	EventStreamAddress            string        `json:"eventStreamAddress"`
+	HealthProbeAddress            string        `json:"healthProbeAddress"`
+	ExcludeMirrorPods             bool          `json:"excludeMirrorPods"`
+	PlaceholderTemplates          string        `json:"placeholderTemplates"`
+	DispatcherWorkers             int           `json:"dispatcherWorkers"`
+	Diagnostics                   bool          `json:"diagnostics"`
+	DiagnosticsToken              string        `json:"-"` // never dumped, the token grants access to the diagnostics endpoints
+	DynamicResourceAllocation     bool          `json:"dynamicResourceAllocation"`
+	BindWorkers                   int           `json:"bindWorkers"`
+	AppTagLabels                  string        `json:"appTagLabels"`
+	DaemonSetReservationTimeout   time.Duration `json:"daemonSetReservationTimeout"`
+	HonorDisruptionBudgets        bool          `json:"honorDisruptionBudgets"`
+	StorageCapacityCheck          bool          `json:"storageCapacityCheck"`
 	sync.RWMutex
 }
 
@@ -128,25 +285,70 @@ func (conf *SchedulerConf) Clone() *SchedulerConf {
 	defer conf.RUnlock()
 
 	return &SchedulerConf{
-		SchedulerName:            conf.SchedulerName,
-		ClusterID:                conf.ClusterID,
-		ClusterVersion:           conf.ClusterVersion,
-		PolicyGroup:              conf.PolicyGroup,
-		Interval:                 conf.Interval,
-		KubeConfig:               conf.KubeConfig,
-		VolumeBindTimeout:        conf.VolumeBindTimeout,
-		TestMode:                 conf.TestMode,
-		EventChannelCapacity:     conf.EventChannelCapacity,
-		DispatchTimeout:          conf.DispatchTimeout,
-		KubeQPS:                  conf.KubeQPS,
-		KubeBurst:                conf.KubeBurst,
-		OperatorPlugins:          conf.OperatorPlugins,
-		EnableConfigHotRefresh:   conf.EnableConfigHotRefresh,
-		DisableGangScheduling:    conf.DisableGangScheduling,
-		UserLabelKey:             conf.UserLabelKey,
-		PlaceHolderImage:         conf.PlaceHolderImage,
-		InstanceTypeNodeLabelKey: conf.InstanceTypeNodeLabelKey,
-		Namespace:                conf.Namespace,
+		SchedulerName:                 conf.SchedulerName,
+		ClusterID:                     conf.ClusterID,
+		ClusterVersion:                conf.ClusterVersion,
+		PolicyGroup:                   conf.PolicyGroup,
+		Interval:                      conf.Interval,
+		KubeConfig:                    conf.KubeConfig,
+		VolumeBindTimeout:             conf.VolumeBindTimeout,
+		TestMode:                      conf.TestMode,
+		EventChannelCapacity:          conf.EventChannelCapacity,
+		DispatchTimeout:               conf.DispatchTimeout,
+		KubeQPS:                       conf.KubeQPS,
+		KubeBurst:                     conf.KubeBurst,
+		KubeEventsQPS:                 conf.KubeEventsQPS,
+		KubeEventsBurst:               conf.KubeEventsBurst,
+		KubeAdaptiveRateLimit:         conf.KubeAdaptiveRateLimit,
+		OperatorPlugins:               conf.OperatorPlugins,
+		EnableConfigHotRefresh:        conf.EnableConfigHotRefresh,
+		DisableGangScheduling:         conf.DisableGangScheduling,
+		UserLabelKey:                  conf.UserLabelKey,
+		PlaceHolderImage:              conf.PlaceHolderImage,
+		InstanceTypeNodeLabelKey:      conf.InstanceTypeNodeLabelKey,
+		Namespace:                     conf.Namespace,
+		AskBatchInterval:              conf.AskBatchInterval,
+		AskBatchSize:                  conf.AskBatchSize,
+		NodeDeletionMode:              conf.NodeDeletionMode,
+		NodeDeletionGracePeriod:       conf.NodeDeletionGracePeriod,
+		RESTProxyAddress:              conf.RESTProxyAddress,
+		AppGCTTL:                      conf.AppGCTTL,
+		AppGCMaxCompletedPerNamespace: conf.AppGCMaxCompletedPerNamespace,
+		AppGCMaxCompleted:             conf.AppGCMaxCompleted,
+		CoreBreakerThreshold:          conf.CoreBreakerThreshold,
+		CoreBreakerCooldown:           conf.CoreBreakerCooldown,
+		CoreBreakerBufferSize:         conf.CoreBreakerBufferSize,
+		RecreateRejectedPlaceholders:  conf.RecreateRejectedPlaceholders,
+		BindMaxRetries:                conf.BindMaxRetries,
+		BindRetryBackoff:              conf.BindRetryBackoff,
+		RollingUpdatePriorityBoost:    conf.RollingUpdatePriorityBoost,
+		ForeignPodExemptSelector:      conf.ForeignPodExemptSelector,
+		PlaceholderOrphanTTL:          conf.PlaceholderOrphanTTL,
+		FailedNodeAvoidanceWindow:     conf.FailedNodeAvoidanceWindow,
+		NamespaceQueues:               conf.NamespaceQueues,
+		StuckApplicationTimeout:       conf.StuckApplicationTimeout,
+		StuckApplicationReset:         conf.StuckApplicationReset,
+		PlaceholderSizing:             conf.PlaceholderSizing,
+		NodeSampleInterval:            conf.NodeSampleInterval,
+		TaskJournal:                   conf.TaskJournal,
+		PodGroupSync:                  conf.PodGroupSync,
+		EventSuggestions:              conf.EventSuggestions,
+		AppEventLogSize:               conf.AppEventLogSize,
+		AppEventLogDir:                conf.AppEventLogDir,
+		ShadowMode:                    conf.ShadowMode,
+		EventStreamAddress:            conf.EventStreamAddress,
+		HealthProbeAddress:            conf.HealthProbeAddress,
+		ExcludeMirrorPods:             conf.ExcludeMirrorPods,
+		PlaceholderTemplates:          conf.PlaceholderTemplates,
+		DispatcherWorkers:             conf.DispatcherWorkers,
+		Diagnostics:                   conf.Diagnostics,
+		DiagnosticsToken:              conf.DiagnosticsToken,
+		DynamicResourceAllocation:     conf.DynamicResourceAllocation,
+		BindWorkers:                   conf.BindWorkers,
+		AppTagLabels:                  conf.AppTagLabels,
+		DaemonSetReservationTimeout:   conf.DaemonSetReservationTimeout,
+		HonorDisruptionBudgets:        conf.HonorDisruptionBudgets,
+		StorageCapacityCheck:          conf.StorageCapacityCheck,
 	}
 }
 
@@ -200,10 +402,35 @@ func handleNonReloadableConfig(old *SchedulerConf, new *SchedulerConf) {
 	checkNonReloadableDuration(CMSvcDispatchTimeout, &old.DispatchTimeout, &new.DispatchTimeout)
 	checkNonReloadableInt(CMKubeQPS, &old.KubeQPS, &new.KubeQPS)
 	checkNonReloadableInt(CMKubeBurst, &old.KubeBurst, &new.KubeBurst)
+	checkNonReloadableInt(CMKubeEventsQPS, &old.KubeEventsQPS, &new.KubeEventsQPS)
+	checkNonReloadableInt(CMKubeEventsBurst, &old.KubeEventsBurst, &new.KubeEventsBurst)
+	checkNonReloadableBool(CMKubeAdaptiveRateLimit, &old.KubeAdaptiveRateLimit, &new.KubeAdaptiveRateLimit)
 	checkNonReloadableString(CMSvcOperatorPlugins, &old.OperatorPlugins, &new.OperatorPlugins)
 	checkNonReloadableBool(CMSvcDisableGangScheduling, &old.DisableGangScheduling, &new.DisableGangScheduling)
 	checkNonReloadableString(CMSvcPlaceholderImage, &old.PlaceHolderImage, &new.PlaceHolderImage)
 	checkNonReloadableString(CMSvcNodeInstanceTypeNodeLabelKey, &old.InstanceTypeNodeLabelKey, &new.InstanceTypeNodeLabelKey)
+	checkNonReloadableDuration(CMSvcAskBatchInterval, &old.AskBatchInterval, &new.AskBatchInterval)
+	checkNonReloadableInt(CMSvcAskBatchSize, &old.AskBatchSize, &new.AskBatchSize)
+	checkNonReloadableString(CMSvcRESTProxyAddress, &old.RESTProxyAddress, &new.RESTProxyAddress)
+	checkNonReloadableInt(CMSvcCoreBreakerThreshold, &old.CoreBreakerThreshold, &new.CoreBreakerThreshold)
+	checkNonReloadableDuration(CMSvcCoreBreakerCooldown, &old.CoreBreakerCooldown, &new.CoreBreakerCooldown)
+	checkNonReloadableInt(CMSvcCoreBreakerBufferSize, &old.CoreBreakerBufferSize, &new.CoreBreakerBufferSize)
+	checkNonReloadableString(CMSvcForeignPodExemptSelector, &old.ForeignPodExemptSelector, &new.ForeignPodExemptSelector)
+	checkNonReloadableDuration(CMSvcNodeSampleInterval, &old.NodeSampleInterval, &new.NodeSampleInterval)
+	checkNonReloadableString(CMSvcTaskJournal, &old.TaskJournal, &new.TaskJournal)
+	checkNonReloadableBool(CMSvcPodGroupSync, &old.PodGroupSync, &new.PodGroupSync)
+	checkNonReloadableInt(CMSvcAppEventLogSize, &old.AppEventLogSize, &new.AppEventLogSize)
+	checkNonReloadableString(CMSvcAppEventLogDir, &old.AppEventLogDir, &new.AppEventLogDir)
+	checkNonReloadableBool(CMSvcShadowMode, &old.ShadowMode, &new.ShadowMode)
+	checkNonReloadableString(CMSvcEventStreamAddress, &old.EventStreamAddress, &new.EventStreamAddress)
+	checkNonReloadableString(CMSvcHealthProbeAddress, &old.HealthProbeAddress, &new.HealthProbeAddress)
+	checkNonReloadableBool(CMSvcExcludeMirrorPods, &old.ExcludeMirrorPods, &new.ExcludeMirrorPods)
+	checkNonReloadableInt(CMSvcDispatcherWorkers, &old.DispatcherWorkers, &new.DispatcherWorkers)
+	checkNonReloadableBool(CMSvcDynamicResourceAllocation, &old.DynamicResourceAllocation, &new.DynamicResourceAllocation)
+	checkNonReloadableInt(CMSvcBindWorkers, &old.BindWorkers, &new.BindWorkers)
+	checkNonReloadableDuration(CMSvcDaemonSetReservationTimeout, &old.DaemonSetReservationTimeout, &new.DaemonSetReservationTimeout)
+	checkNonReloadableBool(CMSvcHonorDisruptionBudgets, &old.HonorDisruptionBudgets, &new.HonorDisruptionBudgets)
+	checkNonReloadableBool(CMSvcStorageCapacityCheck, &old.StorageCapacityCheck, &new.StorageCapacityCheck)
 }
 
 const warningNonReloadable = "ignoring non-reloadable configuration change (restart required to update)"
@@ -288,6 +515,252 @@ func (conf *SchedulerConf) IsOperatorPluginEnabled(name string) bool {
 	return false
 }
 
+// GetNodeDeletionMode returns the configured node deletion mode.
+// Unknown values fall back to the default mode.
+func (conf *SchedulerConf) GetNodeDeletionMode() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	mode := strings.ToLower(conf.NodeDeletionMode)
+	switch mode {
+	case NodeDeletionModeRelease, NodeDeletionModeWait, NodeDeletionModeMigrate:
+		return mode
+	default:
+		log.Log(log.ShimConfig).Warn("unknown node deletion mode, using default",
+			zap.String("mode", conf.NodeDeletionMode),
+			zap.String("default", DefaultNodeDeletionMode))
+		return DefaultNodeDeletionMode
+	}
+}
+
+func (conf *SchedulerConf) GetNodeDeletionGracePeriod() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.NodeDeletionGracePeriod
+}
+
+func (conf *SchedulerConf) IsRecreateRejectedPlaceholders() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.RecreateRejectedPlaceholders
+}
+
+func (conf *SchedulerConf) GetBindMaxRetries() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.BindMaxRetries
+}
+
+func (conf *SchedulerConf) GetBindRetryBackoff() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.BindRetryBackoff
+}
+
+// GetRollingUpdatePriorityBoost returns the priority added to the pods that replace the pods of a service during a
+// rolling update. The boost changes the scheduling order only, the surge pods are still limited by the queue quota.
+func (conf *SchedulerConf) GetRollingUpdatePriorityBoost() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.RollingUpdatePriorityBoost
+}
+
+func (conf *SchedulerConf) GetForeignPodExemptSelector() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.ForeignPodExemptSelector
+}
+
+func (conf *SchedulerConf) GetExcludeMirrorPods() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.ExcludeMirrorPods
+}
+
+func (conf *SchedulerConf) GetDispatcherWorkers() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.DispatcherWorkers
+}
+
+func (conf *SchedulerConf) IsDiagnosticsEnabled() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.Diagnostics
+}
+
+func (conf *SchedulerConf) GetDiagnosticsToken() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.DiagnosticsToken
+}
+
+func (conf *SchedulerConf) IsDynamicResourceAllocationEnabled() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.DynamicResourceAllocation
+}
+
+// GetAppTagLabels returns the application tags that are copied onto the pods of the application as labels.
+// The queue of the application is copied if the list contains "queue".
+func (conf *SchedulerConf) GetAppTagLabels() []string {
+	conf.RLock()
+	defer conf.RUnlock()
+	var tags []string
+	for _, tag := range strings.Split(conf.AppTagLabels, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (conf *SchedulerConf) GetPlaceholderOrphanTTL() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.PlaceholderOrphanTTL
+}
+
+func (conf *SchedulerConf) GetFailedNodeAvoidanceWindow() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.FailedNodeAvoidanceWindow
+}
+
+func (conf *SchedulerConf) IsNamespaceQueues() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.NamespaceQueues
+}
+
+func (conf *SchedulerConf) GetStuckApplicationTimeout() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.StuckApplicationTimeout
+}
+
+func (conf *SchedulerConf) IsStuckApplicationReset() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.StuckApplicationReset
+}
+
+// GetPlaceholderSizing returns the configured placeholder sizing mode.
+// Unknown values fall back to the default mode.
+func (conf *SchedulerConf) GetPlaceholderSizing() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	mode := strings.ToLower(conf.PlaceholderSizing)
+	switch mode {
+	case PlaceholderSizingDisabled, PlaceholderSizingSuggest, PlaceholderSizingCorrect:
+		return mode
+	default:
+		log.Log(log.ShimConfig).Warn("unknown placeholder sizing mode, using default",
+			zap.String("mode", conf.PlaceholderSizing),
+			zap.String("default", DefaultPlaceholderSizing))
+		return DefaultPlaceholderSizing
+	}
+}
+
+// PlaceholderTemplate defines the pod spec of the placeholders of a task group that references the template by name.
+// Unset fields keep the placeholder defaults.
+type PlaceholderTemplate struct {
+	Image                    string                 `json:"image,omitempty"`
+	SecurityContext          *v1.PodSecurityContext `json:"securityContext,omitempty"`
+	ContainerSecurityContext *v1.SecurityContext    `json:"containerSecurityContext,omitempty"`
+	RuntimeClassName         string                 `json:"runtimeClassName,omitempty"`
+	PriorityClassName        string                 `json:"priorityClassName,omitempty"`
+}
+
+// ParsePlaceholderTemplates parses the placeholder templates, a JSON object keyed by the template name.
+func ParsePlaceholderTemplates(value string) (map[string]PlaceholderTemplate, error) {
+	if value == "" {
+		return nil, nil
+	}
+	templates := make(map[string]PlaceholderTemplate)
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&templates); err != nil {
+		return nil, fmt.Errorf("invalid placeholder templates: %w", err)
+	}
+	return templates, nil
+}
+
+// GetPlaceholderTemplate returns the placeholder template with the given name, false if the template is not defined.
+func (conf *SchedulerConf) GetPlaceholderTemplate(name string) (*PlaceholderTemplate, bool) {
+	conf.RLock()
+	defer conf.RUnlock()
+	// the templates are validated when the configuration is parsed
+	templates, err := ParsePlaceholderTemplates(conf.PlaceholderTemplates)
+	if err != nil {
+		return nil, false
+	}
+	template, ok := templates[name]
+	if !ok {
+		return nil, false
+	}
+	return &template, true
+}
+
+func (conf *SchedulerConf) GetNodeSampleInterval() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.NodeSampleInterval
+}
+
+func (conf *SchedulerConf) GetTaskJournal() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.TaskJournal
+}
+
+func (conf *SchedulerConf) IsPodGroupSync() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.PodGroupSync
+}
+
+func (conf *SchedulerConf) IsEventSuggestions() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.EventSuggestions
+}
+
+func (conf *SchedulerConf) GetAppEventLogSize() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.AppEventLogSize
+}
+
+func (conf *SchedulerConf) GetAppEventLogDir() string {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.AppEventLogDir
+}
+
+func (conf *SchedulerConf) IsShadowMode() bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.ShadowMode
+}
+
+func (conf *SchedulerConf) GetAppGCTTL() time.Duration {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.AppGCTTL
+}
+
+func (conf *SchedulerConf) GetAppGCMaxCompletedPerNamespace() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.AppGCMaxCompletedPerNamespace
+}
+
+func (conf *SchedulerConf) GetAppGCMaxCompleted() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.AppGCMaxCompleted
+}
+
 func GetSchedulerNamespace() string {
 	if value, ok := os.LookupEnv(EnvNamespace); ok {
 		return value
@@ -314,25 +787,70 @@ func GetDefaultKubeConfigPath() string {
 // CreateDefaultConfig creates and returns a configuration representing all default values
 func CreateDefaultConfig() *SchedulerConf {
 	return &SchedulerConf{
-		SchedulerName:            constants.SchedulerName,
-		Namespace:                GetSchedulerNamespace(),
-		ClusterID:                DefaultClusterID,
-		ClusterVersion:           buildVersion,
-		PolicyGroup:              DefaultPolicyGroup,
-		Interval:                 DefaultSchedulingInterval,
-		KubeConfig:               GetDefaultKubeConfigPath(),
-		VolumeBindTimeout:        DefaultVolumeBindTimeout,
-		TestMode:                 false,
-		EventChannelCapacity:     DefaultEventChannelCapacity,
-		DispatchTimeout:          DefaultDispatchTimeout,
-		KubeQPS:                  DefaultKubeQPS,
-		KubeBurst:                DefaultKubeBurst,
-		OperatorPlugins:          DefaultOperatorPlugins,
-		EnableConfigHotRefresh:   DefaultEnableConfigHotRefresh,
-		DisableGangScheduling:    DefaultDisableGangScheduling,
-		UserLabelKey:             constants.DefaultUserLabel,
-		PlaceHolderImage:         constants.PlaceholderContainerImage,
-		InstanceTypeNodeLabelKey: constants.DefaultNodeInstanceTypeNodeLabelKey,
+		SchedulerName:                 constants.SchedulerName,
+		Namespace:                     GetSchedulerNamespace(),
+		ClusterID:                     DefaultClusterID,
+		ClusterVersion:                buildVersion,
+		PolicyGroup:                   DefaultPolicyGroup,
+		Interval:                      DefaultSchedulingInterval,
+		KubeConfig:                    GetDefaultKubeConfigPath(),
+		VolumeBindTimeout:             DefaultVolumeBindTimeout,
+		TestMode:                      false,
+		EventChannelCapacity:          DefaultEventChannelCapacity,
+		DispatchTimeout:               DefaultDispatchTimeout,
+		KubeQPS:                       DefaultKubeQPS,
+		KubeBurst:                     DefaultKubeBurst,
+		KubeEventsQPS:                 DefaultKubeEventsQPS,
+		KubeEventsBurst:               DefaultKubeEventsBurst,
+		KubeAdaptiveRateLimit:         DefaultKubeAdaptiveRateLimit,
+		OperatorPlugins:               DefaultOperatorPlugins,
+		EnableConfigHotRefresh:        DefaultEnableConfigHotRefresh,
+		DisableGangScheduling:         DefaultDisableGangScheduling,
+		UserLabelKey:                  constants.DefaultUserLabel,
+		PlaceHolderImage:              constants.PlaceholderContainerImage,
+		InstanceTypeNodeLabelKey:      constants.DefaultNodeInstanceTypeNodeLabelKey,
+		AskBatchInterval:              DefaultAskBatchInterval,
+		AskBatchSize:                  DefaultAskBatchSize,
+		NodeDeletionMode:              DefaultNodeDeletionMode,
+		NodeDeletionGracePeriod:       DefaultNodeDeletionGracePeriod,
+		RESTProxyAddress:              DefaultRESTProxyAddress,
+		AppGCTTL:                      DefaultAppGCTTL,
+		AppGCMaxCompletedPerNamespace: DefaultAppGCMaxCompletedPerNamespace,
+		AppGCMaxCompleted:             DefaultAppGCMaxCompleted,
+		CoreBreakerThreshold:          DefaultCoreBreakerThreshold,
+		CoreBreakerCooldown:           DefaultCoreBreakerCooldown,
+		CoreBreakerBufferSize:         DefaultCoreBreakerBufferSize,
+		RecreateRejectedPlaceholders:  DefaultRecreateRejectedPlaceholders,
+		BindMaxRetries:                DefaultBindMaxRetries,
+		BindRetryBackoff:              DefaultBindRetryBackoff,
+		RollingUpdatePriorityBoost:    DefaultRollingUpdatePriorityBoost,
+		ForeignPodExemptSelector:      DefaultForeignPodExemptSelector,
+		PlaceholderOrphanTTL:          DefaultPlaceholderOrphanTTL,
+		FailedNodeAvoidanceWindow:     DefaultFailedNodeAvoidanceWindow,
+		NamespaceQueues:               DefaultNamespaceQueues,
+		StuckApplicationTimeout:       DefaultStuckApplicationTimeout,
+		StuckApplicationReset:         DefaultStuckApplicationReset,
+		PlaceholderSizing:             DefaultPlaceholderSizing,
+		NodeSampleInterval:            DefaultNodeSampleInterval,
+		TaskJournal:                   DefaultTaskJournal,
+		PodGroupSync:                  DefaultPodGroupSync,
+		EventSuggestions:              DefaultEventSuggestions,
+		AppEventLogSize:               DefaultAppEventLogSize,
+		AppEventLogDir:                DefaultAppEventLogDir,
+		ShadowMode:                    DefaultShadowMode,
+		EventStreamAddress:            DefaultEventStreamAddress,
+		HealthProbeAddress:            DefaultHealthProbeAddress,
+		ExcludeMirrorPods:             DefaultExcludeMirrorPods,
+		PlaceholderTemplates:          DefaultPlaceholderTemplates,
+		DispatcherWorkers:             DefaultDispatcherWorkers,
+		Diagnostics:                   DefaultDiagnostics,
+		DiagnosticsToken:              "",
+		DynamicResourceAllocation:     DefaultDynamicResourceAllocation,
+		BindWorkers:                   DefaultBindWorkers,
+		AppTagLabels:                  DefaultAppTagLabels,
+		DaemonSetReservationTimeout:   DefaultDaemonSetReservationTimeout,
+		HonorDisruptionBudgets:        DefaultHonorDisruptionBudgets,
+		StorageCapacityCheck:          DefaultStorageCapacityCheck,
 	}
 }
 
@@ -358,10 +876,59 @@ func parseConfig(config map[string]string, prev *SchedulerConf) (*SchedulerConf,
 	parser.boolVar(&conf.EnableConfigHotRefresh, CMSvcEnableConfigHotRefresh)
 	parser.stringVar(&conf.PlaceHolderImage, CMSvcPlaceholderImage)
 	parser.stringVar(&conf.InstanceTypeNodeLabelKey, CMSvcNodeInstanceTypeNodeLabelKey)
+	parser.durationVar(&conf.AskBatchInterval, CMSvcAskBatchInterval)
+	parser.intVar(&conf.AskBatchSize, CMSvcAskBatchSize)
+	parser.stringVar(&conf.NodeDeletionMode, CMSvcNodeDeletionMode)
+	parser.durationVar(&conf.NodeDeletionGracePeriod, CMSvcNodeDeletionGracePeriod)
+	parser.stringVar(&conf.RESTProxyAddress, CMSvcRESTProxyAddress)
+	parser.durationVar(&conf.AppGCTTL, CMSvcAppGCTTL)
+	parser.intVar(&conf.AppGCMaxCompletedPerNamespace, CMSvcAppGCMaxCompletedPerNamespace)
+	parser.intVar(&conf.AppGCMaxCompleted, CMSvcAppGCMaxCompleted)
+	parser.intVar(&conf.CoreBreakerThreshold, CMSvcCoreBreakerThreshold)
+	parser.durationVar(&conf.CoreBreakerCooldown, CMSvcCoreBreakerCooldown)
+	parser.intVar(&conf.CoreBreakerBufferSize, CMSvcCoreBreakerBufferSize)
+	parser.boolVar(&conf.RecreateRejectedPlaceholders, CMSvcRecreateRejectedPlaceholders)
+	parser.intVar(&conf.BindMaxRetries, CMSvcBindMaxRetries)
+	parser.durationVar(&conf.BindRetryBackoff, CMSvcBindRetryBackoff)
+	parser.intVar(&conf.RollingUpdatePriorityBoost, CMSvcRollingUpdatePriorityBoost)
+	parser.stringVar(&conf.ForeignPodExemptSelector, CMSvcForeignPodExemptSelector)
+	parser.durationVar(&conf.PlaceholderOrphanTTL, CMSvcPlaceholderOrphanTTL)
+	parser.durationVar(&conf.FailedNodeAvoidanceWindow, CMSvcFailedNodeAvoidanceWindow)
+	parser.boolVar(&conf.NamespaceQueues, CMSvcNamespaceQueues)
+	parser.durationVar(&conf.StuckApplicationTimeout, CMSvcStuckApplicationTimeout)
+	parser.boolVar(&conf.StuckApplicationReset, CMSvcStuckApplicationReset)
+	parser.stringVar(&conf.PlaceholderSizing, CMSvcPlaceholderSizing)
+	parser.durationVar(&conf.NodeSampleInterval, CMSvcNodeSampleInterval)
+	parser.stringVar(&conf.TaskJournal, CMSvcTaskJournal)
+	parser.boolVar(&conf.PodGroupSync, CMSvcPodGroupSync)
+	parser.boolVar(&conf.EventSuggestions, CMSvcEventSuggestions)
+	parser.intVar(&conf.AppEventLogSize, CMSvcAppEventLogSize)
+	parser.stringVar(&conf.AppEventLogDir, CMSvcAppEventLogDir)
+	parser.boolVar(&conf.ShadowMode, CMSvcShadowMode)
+	parser.stringVar(&conf.EventStreamAddress, CMSvcEventStreamAddress)
+	parser.stringVar(&conf.HealthProbeAddress, CMSvcHealthProbeAddress)
+	parser.boolVar(&conf.ExcludeMirrorPods, CMSvcExcludeMirrorPods)
+	parser.stringVar(&conf.PlaceholderTemplates, CMSvcPlaceholderTemplates)
+	if _, err := ParsePlaceholderTemplates(conf.PlaceholderTemplates); err != nil {
+		log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", CMSvcPlaceholderTemplates), zap.Error(err))
+		parser.errors = append(parser.errors, err)
+	}
+	parser.intVar(&conf.DispatcherWorkers, CMSvcDispatcherWorkers)
+	parser.boolVar(&conf.Diagnostics, CMSvcDiagnostics)
+	parser.stringVar(&conf.DiagnosticsToken, CMSvcDiagnosticsToken)
+	parser.boolVar(&conf.DynamicResourceAllocation, CMSvcDynamicResourceAllocation)
+	parser.intVar(&conf.BindWorkers, CMSvcBindWorkers)
+	parser.stringVar(&conf.AppTagLabels, CMSvcAppTagLabels)
+	parser.durationVar(&conf.DaemonSetReservationTimeout, CMSvcDaemonSetReservationTimeout)
+	parser.boolVar(&conf.HonorDisruptionBudgets, CMSvcHonorDisruptionBudgets)
+	parser.boolVar(&conf.StorageCapacityCheck, CMSvcStorageCapacityCheck)
 
 	// kubernetes
 	parser.intVar(&conf.KubeQPS, CMKubeQPS)
 	parser.intVar(&conf.KubeBurst, CMKubeBurst)
+	parser.intVar(&conf.KubeEventsQPS, CMKubeEventsQPS)
+	parser.intVar(&conf.KubeEventsBurst, CMKubeEventsBurst)
+	parser.boolVar(&conf.KubeAdaptiveRateLimit, CMKubeAdaptiveRateLimit)
 
 	if len(parser.errors) > 0 {
 		return nil, parser.errors
@@ -89,6 +89,51 @@ func TestParseConfigMap(t *testing.T) {
 		{CMSvcEnableConfigHotRefresh, "EnableConfigHotRefresh", false},
 		{CMSvcPlaceholderImage, "PlaceHolderImage", "test-image"},
 		{CMSvcNodeInstanceTypeNodeLabelKey, "InstanceTypeNodeLabelKey", "node.kubernetes.io/instance-type"},
+		{CMSvcAskBatchInterval, "AskBatchInterval", 50 * time.Millisecond},
+		{CMSvcAskBatchSize, "AskBatchSize", 100},
+		{CMSvcNodeDeletionMode, "NodeDeletionMode", "wait"},
+		{CMSvcNodeDeletionGracePeriod, "NodeDeletionGracePeriod", 2 * time.Minute},
+		{CMSvcRESTProxyAddress, "RESTProxyAddress", ":9081"},
+		{CMSvcAppGCTTL, "AppGCTTL", 2 * time.Hour},
+		{CMSvcAppGCMaxCompletedPerNamespace, "AppGCMaxCompletedPerNamespace", 100},
+		{CMSvcAppGCMaxCompleted, "AppGCMaxCompleted", 1000},
+		{CMSvcCoreBreakerThreshold, "CoreBreakerThreshold", 3},
+		{CMSvcCoreBreakerCooldown, "CoreBreakerCooldown", 30 * time.Second},
+		{CMSvcCoreBreakerBufferSize, "CoreBreakerBufferSize", 100},
+		{CMSvcRecreateRejectedPlaceholders, "RecreateRejectedPlaceholders", true},
+		{CMSvcBindMaxRetries, "BindMaxRetries", 5},
+		{CMSvcBindRetryBackoff, "BindRetryBackoff", 2 * time.Second},
+		{CMSvcRollingUpdatePriorityBoost, "RollingUpdatePriorityBoost", 100},
+		{CMSvcForeignPodExemptSelector, "ForeignPodExemptSelector", "ci=runner"},
+		{CMSvcPlaceholderOrphanTTL, "PlaceholderOrphanTTL", 2 * time.Minute},
+		{CMSvcFailedNodeAvoidanceWindow, "FailedNodeAvoidanceWindow", 5 * time.Minute},
+		{CMSvcNamespaceQueues, "NamespaceQueues", true},
+		{CMSvcStuckApplicationTimeout, "StuckApplicationTimeout", 5 * time.Minute},
+		{CMSvcStuckApplicationReset, "StuckApplicationReset", true},
+		{CMSvcPlaceholderSizing, "PlaceholderSizing", "suggest"},
+		{CMSvcNodeSampleInterval, "NodeSampleInterval", 5 * time.Second},
+		{CMSvcTaskJournal, "TaskJournal", "configmap:yunikorn-journal"},
+		{CMSvcPodGroupSync, "PodGroupSync", true},
+		{CMSvcEventSuggestions, "EventSuggestions", true},
+		{CMSvcAppEventLogSize, "AppEventLogSize", 200},
+		{CMSvcAppEventLogDir, "AppEventLogDir", "/var/lib/yunikorn/events"},
+		{CMSvcShadowMode, "ShadowMode", true},
+		{CMSvcEventStreamAddress, "EventStreamAddress", ":9082"},
+		{CMSvcHealthProbeAddress, "HealthProbeAddress", ":9084"},
+		{CMKubeEventsQPS, "KubeEventsQPS", 4567},
+		{CMKubeEventsBurst, "KubeEventsBurst", 5678},
+		{CMKubeAdaptiveRateLimit, "KubeAdaptiveRateLimit", true},
+		{CMSvcExcludeMirrorPods, "ExcludeMirrorPods", true},
+		{CMSvcPlaceholderTemplates, "PlaceholderTemplates", "{}"},
+		{CMSvcDispatcherWorkers, "DispatcherWorkers", 4},
+		{CMSvcDiagnostics, "Diagnostics", true},
+		{CMSvcDiagnosticsToken, "DiagnosticsToken", "secret"},
+		{CMSvcDynamicResourceAllocation, "DynamicResourceAllocation", true},
+		{CMSvcBindWorkers, "BindWorkers", 8},
+		{CMSvcAppTagLabels, "AppTagLabels", "namespace,queue"},
+		{CMSvcDaemonSetReservationTimeout, "DaemonSetReservationTimeout", 2 * time.Minute},
+		{CMSvcHonorDisruptionBudgets, "HonorDisruptionBudgets", true},
+		{CMSvcStorageCapacityCheck, "StorageCapacityCheck", true},
 		{CMKubeQPS, "KubeQPS", 2345},
 		{CMKubeBurst, "KubeBurst", 3456},
 	}
@@ -121,6 +166,51 @@ func TestUpdateConfigMapNonReloadable(t *testing.T) {
 		{CMSvcDisableGangScheduling, "DisableGangScheduling", true, false},
 		{CMSvcPlaceholderImage, "PlaceHolderImage", "test-image", false},
 		{CMSvcNodeInstanceTypeNodeLabelKey, "InstanceTypeNodeLabelKey", "node.kubernetes.io/instance-type", false},
+		{CMSvcAskBatchInterval, "AskBatchInterval", 50 * time.Millisecond, false},
+		{CMSvcAskBatchSize, "AskBatchSize", 100, false},
+		{CMSvcNodeDeletionMode, "NodeDeletionMode", "wait", true},
+		{CMSvcNodeDeletionGracePeriod, "NodeDeletionGracePeriod", 2 * time.Minute, true},
+		{CMSvcRESTProxyAddress, "RESTProxyAddress", ":9081", false},
+		{CMSvcAppGCTTL, "AppGCTTL", 2 * time.Hour, true},
+		{CMSvcAppGCMaxCompletedPerNamespace, "AppGCMaxCompletedPerNamespace", 100, true},
+		{CMSvcAppGCMaxCompleted, "AppGCMaxCompleted", 1000, true},
+		{CMSvcCoreBreakerThreshold, "CoreBreakerThreshold", 3, false},
+		{CMSvcCoreBreakerCooldown, "CoreBreakerCooldown", 30 * time.Second, false},
+		{CMSvcCoreBreakerBufferSize, "CoreBreakerBufferSize", 100, false},
+		{CMSvcRecreateRejectedPlaceholders, "RecreateRejectedPlaceholders", true, true},
+		{CMSvcBindMaxRetries, "BindMaxRetries", 5, true},
+		{CMSvcBindRetryBackoff, "BindRetryBackoff", 2 * time.Second, true},
+		{CMSvcRollingUpdatePriorityBoost, "RollingUpdatePriorityBoost", 100, true},
+		{CMSvcForeignPodExemptSelector, "ForeignPodExemptSelector", "ci=runner", false},
+		{CMSvcPlaceholderOrphanTTL, "PlaceholderOrphanTTL", 2 * time.Minute, true},
+		{CMSvcFailedNodeAvoidanceWindow, "FailedNodeAvoidanceWindow", 5 * time.Minute, true},
+		{CMSvcNamespaceQueues, "NamespaceQueues", true, true},
+		{CMSvcStuckApplicationTimeout, "StuckApplicationTimeout", 5 * time.Minute, true},
+		{CMSvcStuckApplicationReset, "StuckApplicationReset", true, true},
+		{CMSvcPlaceholderSizing, "PlaceholderSizing", "suggest", true},
+		{CMSvcNodeSampleInterval, "NodeSampleInterval", 5 * time.Second, false},
+		{CMSvcTaskJournal, "TaskJournal", "configmap:yunikorn-journal", false},
+		{CMSvcPodGroupSync, "PodGroupSync", true, false},
+		{CMSvcEventSuggestions, "EventSuggestions", true, true},
+		{CMSvcAppEventLogSize, "AppEventLogSize", 200, false},
+		{CMSvcAppEventLogDir, "AppEventLogDir", "/var/lib/yunikorn/events", false},
+		{CMSvcShadowMode, "ShadowMode", true, false},
+		{CMSvcEventStreamAddress, "EventStreamAddress", ":9082", false},
+		{CMSvcHealthProbeAddress, "HealthProbeAddress", ":9084", false},
+		{CMKubeEventsQPS, "KubeEventsQPS", 4567, false},
+		{CMKubeEventsBurst, "KubeEventsBurst", 5678, false},
+		{CMKubeAdaptiveRateLimit, "KubeAdaptiveRateLimit", true, false},
+		{CMSvcExcludeMirrorPods, "ExcludeMirrorPods", true, false},
+		{CMSvcPlaceholderTemplates, "PlaceholderTemplates", "{}", true},
+		{CMSvcDispatcherWorkers, "DispatcherWorkers", 4, false},
+		{CMSvcDiagnostics, "Diagnostics", true, true},
+		{CMSvcDiagnosticsToken, "DiagnosticsToken", "secret", true},
+		{CMSvcDynamicResourceAllocation, "DynamicResourceAllocation", true, false},
+		{CMSvcBindWorkers, "BindWorkers", 8, false},
+		{CMSvcAppTagLabels, "AppTagLabels", "namespace,queue", true},
+		{CMSvcDaemonSetReservationTimeout, "DaemonSetReservationTimeout", 2 * time.Minute, false},
+		{CMSvcHonorDisruptionBudgets, "HonorDisruptionBudgets", true, false},
+		{CMSvcStorageCapacityCheck, "StorageCapacityCheck", true, false},
 		{CMKubeQPS, "KubeQPS", 2345, false},
 		{CMKubeBurst, "KubeBurst", 3456, false},
 	}
@@ -179,6 +269,87 @@ func TestParseConfigMapWithInvalidDuration(t *testing.T) {
 	assert.ErrorContains(t, errs[0], "invalid duration", "wrong error type")
 }
 
+func TestGetNodeDeletionMode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"release", NodeDeletionModeRelease},
+		{"wait", NodeDeletionModeWait},
+		{"Migrate", NodeDeletionModeMigrate},
+		{"", DefaultNodeDeletionMode},
+		{"unknown", DefaultNodeDeletionMode},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			conf, errs := parseConfig(map[string]string{CMSvcNodeDeletionMode: tc.value}, CreateDefaultConfig())
+			assert.Assert(t, errs == nil, errs)
+			assert.Equal(t, conf.GetNodeDeletionMode(), tc.expected)
+		})
+	}
+}
+
+func TestGetPlaceholderSizing(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"disabled", PlaceholderSizingDisabled},
+		{"suggest", PlaceholderSizingSuggest},
+		{"Correct", PlaceholderSizingCorrect},
+		{"", DefaultPlaceholderSizing},
+		{"unknown", DefaultPlaceholderSizing},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			conf, errs := parseConfig(map[string]string{CMSvcPlaceholderSizing: tc.value}, CreateDefaultConfig())
+			assert.Assert(t, errs == nil, errs)
+			assert.Equal(t, conf.GetPlaceholderSizing(), tc.expected)
+		})
+	}
+}
+
+func TestGetAppTagLabels(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{"", nil},
+		{"namespace", []string{"namespace"}},
+		{" namespace, queue ,,", []string{"namespace", "queue"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			conf, errs := parseConfig(map[string]string{CMSvcAppTagLabels: tc.value}, CreateDefaultConfig())
+			assert.Assert(t, errs == nil, errs)
+			assert.DeepEqual(t, conf.GetAppTagLabels(), tc.expected)
+		})
+	}
+}
+
+func TestGetPlaceholderTemplate(t *testing.T) {
+	conf, errs := parseConfig(map[string]string{
+		CMSvcPlaceholderTemplates: `{"restricted": {"image": "pause:3.9", "runtimeClassName": "gvisor"}}`,
+	}, CreateDefaultConfig())
+	assert.Assert(t, errs == nil, errs)
+	template, ok := conf.GetPlaceholderTemplate("restricted")
+	assert.Assert(t, ok, "template not found")
+	assert.Equal(t, template.Image, "pause:3.9")
+	assert.Equal(t, template.RuntimeClassName, "gvisor")
+	_, ok = conf.GetPlaceholderTemplate("unknown")
+	assert.Assert(t, !ok, "unknown template found")
+
+	// unknown fields and invalid JSON are rejected
+	conf, errs = parseConfig(map[string]string{
+		CMSvcPlaceholderTemplates: `{"restricted": {"img": "pause:3.9"}}`,
+	}, CreateDefaultConfig())
+	assert.Assert(t, conf == nil, "conf exists")
+	assert.Equal(t, 1, len(errs), "wrong error count")
+	assert.ErrorContains(t, errs[0], "unknown field", "wrong error type")
+	_, errs = parseConfig(map[string]string{CMSvcPlaceholderTemplates: "x"}, CreateDefaultConfig())
+	assert.Equal(t, 1, len(errs), "wrong error count")
+}
+
 // get a configuration value by field name
 func getConfValue(t *testing.T, conf *SchedulerConf, name string) interface{} {
 	val := reflect.ValueOf(conf).Elem().FieldByName(name)
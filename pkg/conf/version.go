@@ -0,0 +1,92 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"runtime/debug"
+)
+
+// deployment modes of the shim
+const (
+	DeploymentModeStandard = "standard"
+	DeploymentModePlugin   = "plugin"
+)
+
+const coreModulePath = "github.com/apache/yunikorn-core"
+
+// VersionInfo describes a deployed shim: the build, the embedded core and the features enabled in the
+// configuration. Feature names are stable and can be used to inventory the capabilities of a deployment.
+type VersionInfo struct {
+	Version        string          `json:"version"`
+	BuildDate      string          `json:"buildDate"`
+	GoVersion      string          `json:"goVersion"`
+	Arch           string          `json:"arch"`
+	DeploymentMode string          `json:"deploymentMode"`
+	CoreVersion    string          `json:"coreVersion"`
+	CoreSHA        string          `json:"coreSHA"`
+	SISHA          string          `json:"siSHA"`
+	ShimSHA        string          `json:"shimSHA"`
+	Features       map[string]bool `json:"features"`
+}
+
+// GetVersionInfo returns the version information based on the build info and the current configuration
+func GetVersionInfo() *VersionInfo {
+	conf := GetSchedulerConf()
+	mode := DeploymentModeStandard
+	if isPluginVersion == "true" {
+		mode = DeploymentModePlugin
+	}
+	return &VersionInfo{
+		Version:        buildVersion,
+		BuildDate:      buildDate,
+		GoVersion:      goVersion,
+		Arch:           arch,
+		DeploymentMode: mode,
+		CoreVersion:    getCoreVersion(),
+		CoreSHA:        coreSHA,
+		SISHA:          siSHA,
+		ShimSHA:        shimSHA,
+		Features: map[string]bool{
+			"gangScheduling":     !conf.DisableGangScheduling,
+			"configHotRefresh":   conf.EnableConfigHotRefresh,
+			"askBatching":        conf.AskBatchInterval > 0,
+			"coreCircuitBreaker": conf.CoreBreakerThreshold > 0,
+			"restProxy":          conf.RESTProxyAddress != "",
+			"eventStream":        conf.EventStreamAddress != "",
+			"diagnostics":        conf.Diagnostics && conf.RESTProxyAddress != "",
+		},
+	}
+}
+
+// getCoreVersion returns the module version of the embedded scheduler core, falls back to the core SHA
+// that is set at build time if the module information is not available.
+func getCoreVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path != coreModulePath {
+				continue
+			}
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return coreSHA
+}
@@ -0,0 +1,51 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestGetVersionInfo(t *testing.T) {
+	err := UpdateConfigMaps([]*v1.ConfigMap{{Data: map[string]string{
+		CMSvcDisableGangScheduling: "true",
+		CMSvcAskBatchInterval:      "100ms",
+	}}}, true)
+	assert.NilError(t, err, "UpdateConfigMap failed")
+	defer func() {
+		assert.NilError(t, UpdateConfigMaps([]*v1.ConfigMap{nil}, true), "UpdateConfigMap reset failed")
+	}()
+
+	info := GetVersionInfo()
+	assert.Equal(t, info.Version, buildVersion)
+	assert.Equal(t, info.DeploymentMode, DeploymentModeStandard)
+	assert.Equal(t, info.Features["gangScheduling"], false)
+	assert.Equal(t, info.Features["askBatching"], true)
+	assert.Equal(t, info.Features["restProxy"], false)
+	assert.Equal(t, info.Features["eventStream"], false)
+	assert.Equal(t, info.Features["diagnostics"], false)
+
+	old := isPluginVersion
+	isPluginVersion = "true"
+	defer func() { isPluginVersion = old }()
+	assert.Equal(t, GetVersionInfo().DeploymentMode, DeploymentModePlugin)
+}
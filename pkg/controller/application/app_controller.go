@@ -24,31 +24,40 @@ import (
 	"strings"
 	"time"
 
-	"github.com/apache/yunikorn-k8shim/pkg/client"
-
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	appv1 "github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/appmgmt/interfaces"
 	shimcache "github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 type AppManager struct {
 	amProtocol interfaces.ApplicationManagementProtocol
 	//controller *Controller
 	apiProvider client.APIProvider
+	stopCh      chan struct{}
 }
 
 const appIDDelimiter = "-"
 
+// statusSyncInterval defines how often the allocated resources and pending asks are synced back to the CRDs
+const statusSyncInterval = 10 * time.Second
+
 func NewAppManager(amProtocol interfaces.ApplicationManagementProtocol, apiProvider client.APIProvider) *AppManager {
 	return &AppManager{
 		amProtocol:  amProtocol,
 		apiProvider: apiProvider,
+		stopCh:      make(chan struct{}),
 	}
 }
 
@@ -67,14 +76,47 @@ func (appMgr *AppManager) Start() error {
 	appMgr.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
 		Type:     client.ApplicationInformerHandlers,
 		AddFn:    appMgr.addApp,
+		UpdateFn: appMgr.updateApp,
 		DeleteFn: appMgr.deleteApp,
 	})
+	go appMgr.syncStatusLoop()
+	go appMgr.gcLoop()
 	return nil
 }
 
 // this implements AppManagementService interface
 func (appMgr *AppManager) Stop() {
-	//noop
+	close(appMgr.stopCh)
+}
+
+// syncStatusLoop periodically reports the allocated resources and pending asks of the applications back on the CRDs.
+// State changes are reported immediately via HandleApplicationStateUpdate, resource usage changes without an event.
+func (appMgr *AppManager) syncStatusLoop() {
+	ticker := time.NewTicker(statusSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			appMgr.syncStatus()
+		case <-appMgr.stopCh:
+			return
+		}
+	}
+}
+
+func (appMgr *AppManager) syncStatus() {
+	appCRDs, err := appMgr.apiProvider.GetAPIs().AppInformer.Lister().List(labels.Everything())
+	if err != nil {
+		log.Log(log.ShimAppMgmt).Warn("Failed to list app CRDs for status sync", zap.Error(err))
+		return
+	}
+	for _, appCRD := range appCRDs {
+		app := appMgr.getShimApplication(constructAppID(appCRD.Name, appCRD.Namespace))
+		if app == nil || len(appCRD.Status.AppStatus) == 0 {
+			continue
+		}
+		appMgr.updateAppCRDStatus(appCRD, appCRD.Status.AppStatus)
+	}
 }
 
 // handle the updates from the scheduler and sync the status change
@@ -87,12 +129,18 @@ func (appMgr *AppManager) HandleApplicationStateUpdate() func(obj interface{}) {
 					log.Log(log.ShimAppMgmt).Info("Status Change callback received",
 						zap.String("app id", appID),
 						zap.String("new status", shimEvent.GetState()))
-					var app = appMgr.amProtocol.GetApplication(appID).(*shimcache.Application)
+					app := appMgr.getShimApplication(appID)
+					if app == nil {
+						log.Log(log.ShimAppMgmt).Warn("Application not found for status update",
+							zap.String("application ID", appID))
+						return
+					}
 					appName, err := getNameFromAppID(appID)
 					if err != nil {
 						log.Log(log.ShimAppMgmt).Warn("Failed to handle status update",
 							zap.String("application ID", appID),
 							zap.Error(err))
+						return
 					}
 					appCRD, err := appMgr.apiProvider.GetAPIs().AppInformer.Lister().Applications(app.GetTags()[constants.AppTagNamespace]).Get(appName)
 					if err != nil {
@@ -137,6 +185,45 @@ func (appMgr *AppManager) deleteApp(obj interface{}) {
 		zap.String("Name", appID))
 }
 
+/*
+Apply spec changes of the CRD to the application in the scheduler. Updates that do not change the spec, like
+the status written by the shim, are ignored: an application that finished and was removed is not added again.
+*/
+func (appMgr *AppManager) updateApp(oldObj, newObj interface{}) {
+	oldCRD, ok := oldObj.(*appv1.Application)
+	if !ok {
+		log.Log(log.ShimAppMgmt).Error("old obj is not an Application")
+		return
+	}
+	newCRD, ok := newObj.(*appv1.Application)
+	if !ok {
+		log.Log(log.ShimAppMgmt).Error("new obj is not an Application")
+		return
+	}
+	if equality.Semantic.DeepEqual(oldCRD.Spec, newCRD.Spec) {
+		return
+	}
+	appID := constructAppID(newCRD.Name, newCRD.Namespace)
+	app := appMgr.getShimApplication(appID)
+	if app == nil {
+		log.Log(log.ShimAppMgmt).Debug("App CRD spec changed for unknown application, ignoring",
+			zap.String("appID", appID))
+		return
+	}
+	if oldCRD.Spec.Queue != newCRD.Spec.Queue {
+		if err := app.SetQueue(newCRD.Spec.Queue); err != nil {
+			log.Log(log.ShimAppMgmt).Warn("App CRD queue change not applied",
+				zap.String("appID", appID),
+				zap.String("queue", newCRD.Spec.Queue),
+				zap.Error(err))
+			return
+		}
+		log.Log(log.ShimAppMgmt).Info("App CRD queue changed",
+			zap.String("appID", appID),
+			zap.String("queue", newCRD.Spec.Queue))
+	}
+}
+
 /*
 Add application to scheduler
 */
@@ -165,12 +252,24 @@ func (appMgr *AppManager) updateAppCRDStatus(appCRD *appv1.Application, status a
 		log.Log(log.ShimAppMgmt).Error("AppCRD is nil, there is nothing to update")
 		return
 	}
-	appCopy := appCRD.DeepCopy()
-	appCopy.Status = appv1.ApplicationStatus{
-		AppStatus:  status,
-		Message:    "app CRD status change",
-		LastUpdate: v1.NewTime(time.Now()),
+	newStatus := appv1.ApplicationStatus{
+		AppStatus: status,
+		Message:   "app CRD status change",
+	}
+	if app := appMgr.getShimApplication(constructAppID(appCRD.Name, appCRD.Namespace)); app != nil {
+		newStatus.AllocatedResource = convertResourceToQuantities(app.GetAllocatedResource())
+		newStatus.PendingAsks = int32(app.GetPendingAskCount())
 	}
+	// skip the update if nothing changed, the LastUpdate is only changed when the content changes
+	if appCRD.Status.AppStatus == newStatus.AppStatus &&
+		appCRD.Status.Message == newStatus.Message &&
+		appCRD.Status.PendingAsks == newStatus.PendingAsks &&
+		equality.Semantic.DeepEqual(appCRD.Status.AllocatedResource, newStatus.AllocatedResource) {
+		return
+	}
+	newStatus.LastUpdate = v1.NewTime(time.Now())
+	appCopy := appCRD.DeepCopy()
+	appCopy.Status = newStatus
 	_, err := appMgr.apiProvider.GetAPIs().AppClient.ApacheV1alpha1().Applications(appCRD.Namespace).UpdateStatus(context.Background(), appCopy, v1.UpdateOptions{})
 	if err != nil {
 		log.Log(log.ShimAppMgmt).Error("Failed to update application CRD",
@@ -179,6 +278,13 @@ func (appMgr *AppManager) updateAppCRDStatus(appCRD *appv1.Application, status a
 	}
 }
 
+func (appMgr *AppManager) getShimApplication(appID string) *shimcache.Application {
+	if app, ok := appMgr.amProtocol.GetApplication(appID).(*shimcache.Application); ok {
+		return app
+	}
+	return nil
+}
+
 func (appMgr *AppManager) getAppMetadata(app *appv1.Application) (interfaces.ApplicationMetadata, bool) {
 	appID := constructAppID(app.Name, app.Namespace)
 
@@ -239,3 +345,23 @@ func convertShimAppStateToAppCRDState(status string) appv1.ApplicationStateType
 		return undefinedState
 	}
 }
+
+// convertResourceToQuantities converts the scheduler resource into the quantities shown on the CRD.
+// The vcore resource is reported as cpu in milli cores, memory and other resources are reported as is.
+func convertResourceToQuantities(res *si.Resource) map[string]resource.Quantity {
+	if res == nil || len(res.Resources) == 0 {
+		return nil
+	}
+	quantities := make(map[string]resource.Quantity, len(res.Resources))
+	for name, value := range res.Resources {
+		switch name {
+		case siCommon.CPU:
+			quantities["cpu"] = *resource.NewMilliQuantity(value.Value, resource.DecimalSI)
+		case siCommon.Memory:
+			quantities["memory"] = *resource.NewQuantity(value.Value, resource.BinarySI)
+		default:
+			quantities[name] = *resource.NewQuantity(value.Value, resource.DecimalSI)
+		}
+	}
+	return quantities
+}
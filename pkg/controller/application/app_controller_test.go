@@ -28,7 +28,9 @@ import (
 	appv1 "github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/cache"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 )
 
 const defaultName = "example"
@@ -154,6 +156,91 @@ func TestHandleApplicationStateUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateApp(t *testing.T) {
+	am := NewAppManager(cache.NewMockedAMProtocol(), client.NewMockedAPIProvider(false))
+	app := createApp(defaultName, defaultNamespace, defaultQueue)
+	appID := constructAppID(defaultName, defaultNamespace)
+	am.addApp(&app)
+	assert.Assert(t, am.amProtocol.GetApplication(appID) != nil)
+
+	// queue changed before the app is submitted: the app moves
+	updated := app.DeepCopy()
+	updated.Spec.Queue = "root.other"
+	am.updateApp(&app, updated)
+	managedApp := am.amProtocol.GetApplication(appID)
+	assert.Equal(t, managedApp.GetQueue(), "root.other")
+
+	// queue changed after the app is submitted: the core cannot move the app
+	shimApp := am.getShimApplication(appID)
+	shimApp.SetState(cache.ApplicationStates().Running)
+	moved := updated.DeepCopy()
+	moved.Spec.Queue = "root.moved"
+	am.updateApp(updated, moved)
+	assert.Equal(t, managedApp.GetQueue(), "root.other")
+
+	// status only update of a removed app, e.g. the final state written by the shim: not added again
+	assert.NilError(t, am.amProtocol.RemoveApplication(appID))
+	status := moved.DeepCopy()
+	status.Status.AppStatus = appv1.CompletedState
+	am.updateApp(moved, status)
+	assert.Assert(t, am.amProtocol.GetApplication(appID) == nil, "removed app added back")
+	// spec change of a removed app: not added again
+	changed := status.DeepCopy()
+	changed.Spec.Queue = defaultQueue
+	am.updateApp(status, changed)
+	assert.Assert(t, am.amProtocol.GetApplication(appID) == nil, "removed app added back")
+
+	// not an application: no panic
+	am.updateApp(nil, "not an app")
+	am.updateApp(&app, "not an app")
+}
+
+func TestUpdateAppCRDStatus(t *testing.T) {
+	am := NewAppManager(cache.NewMockedAMProtocol(), client.NewMockedAPIProvider(false))
+	app := createApp(defaultName, defaultNamespace, defaultQueue)
+	appClient := am.apiProvider.GetAPIs().AppClient.ApacheV1alpha1().Applications(defaultNamespace)
+	_, err := appClient.Create(context.Background(), &app, apis.CreateOptions{})
+	assert.NilError(t, err)
+	am.addApp(&app)
+
+	saved, err := appClient.Get(context.Background(), defaultName, apis.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, saved.Status.AppStatus, appv1.NewApplicationState)
+	assert.Equal(t, saved.Status.PendingAsks, int32(0))
+	assert.Assert(t, saved.Status.AllocatedResource == nil)
+	lastUpdate := saved.Status.LastUpdate
+
+	// same status: no update
+	am.updateAppCRDStatus(saved, appv1.NewApplicationState)
+	unchanged, err := appClient.Get(context.Background(), defaultName, apis.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, unchanged.Status.LastUpdate, lastUpdate)
+
+	am.updateAppCRDStatus(saved, appv1.RunningState)
+	updated, err := appClient.Get(context.Background(), defaultName, apis.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, updated.Status.AppStatus, appv1.RunningState)
+}
+
+func TestConvertResourceToQuantities(t *testing.T) {
+	assert.Assert(t, convertResourceToQuantities(nil) == nil)
+	assert.Assert(t, convertResourceToQuantities(common.NewResourceBuilder().Build()) == nil)
+
+	res := common.NewResourceBuilder().
+		AddResource(siCommon.CPU, 1500).
+		AddResource(siCommon.Memory, 1024*1024*1024).
+		AddResource("nvidia.com/gpu", 2).
+		Build()
+	quantities := convertResourceToQuantities(res)
+	assert.Equal(t, len(quantities), 3)
+	cpu := quantities["cpu"]
+	assert.Equal(t, cpu.String(), "1500m")
+	memory := quantities["memory"]
+	assert.Equal(t, memory.String(), "1Gi")
+	gpu := quantities["nvidia.com/gpu"]
+	assert.Equal(t, gpu.Value(), int64(2))
+}
+
 func createApp(name string, namespace string, queue string) appv1.Application {
 	app := appv1.Application{
 		TypeMeta: apis.TypeMeta{
@@ -0,0 +1,170 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	appv1 "github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// gcInterval defines how often the garbage collection policy is applied to the finished application CRDs
+const gcInterval = time.Minute
+
+// gcPolicy defines when the CRDs of finished applications are removed. Removing the CRD also removes the
+// application from the shim and the core. A zero value disables that part of the policy.
+type gcPolicy struct {
+	// ttl is the time a finished application is kept after its last status change
+	ttl time.Duration
+	// maxCompletedPerNamespace is the number of finished applications kept in each namespace
+	maxCompletedPerNamespace int
+	// maxCompleted is the number of finished applications kept in the cluster
+	maxCompleted int
+}
+
+func getGCPolicy() gcPolicy {
+	schedulerConf := conf.GetSchedulerConf()
+	return gcPolicy{
+		ttl:                      schedulerConf.GetAppGCTTL(),
+		maxCompletedPerNamespace: schedulerConf.GetAppGCMaxCompletedPerNamespace(),
+		maxCompleted:             schedulerConf.GetAppGCMaxCompleted(),
+	}
+}
+
+func (p gcPolicy) enabled() bool {
+	return p.ttl > 0 || p.maxCompletedPerNamespace > 0 || p.maxCompleted > 0
+}
+
+func (appMgr *AppManager) gcLoop() {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			appMgr.collectGarbage(getGCPolicy(), time.Now())
+		case <-appMgr.stopCh:
+			return
+		}
+	}
+}
+
+// collectGarbage deletes the CRDs of the finished applications selected by the policy
+func (appMgr *AppManager) collectGarbage(policy gcPolicy, now time.Time) {
+	if !policy.enabled() {
+		return
+	}
+	appCRDs, err := appMgr.apiProvider.GetAPIs().AppInformer.Lister().List(labels.Everything())
+	if err != nil {
+		log.Log(log.ShimAppMgmt).Warn("Failed to list app CRDs for garbage collection", zap.Error(err))
+		return
+	}
+	collect := selectForCollection(appCRDs, policy, now)
+	if len(collect) == 0 {
+		return
+	}
+	deleted := 0
+	for _, appCRD := range collect {
+		err = appMgr.apiProvider.GetAPIs().AppClient.ApacheV1alpha1().Applications(appCRD.Namespace).Delete(context.Background(), appCRD.Name, v1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Log(log.ShimAppMgmt).Warn("Failed to delete finished application CRD",
+				zap.String("namespace", appCRD.Namespace),
+				zap.String("name", appCRD.Name),
+				zap.Error(err))
+			continue
+		}
+		deleted++
+	}
+	log.Log(log.ShimAppMgmt).Info("Garbage collected finished application CRDs",
+		zap.Int("selected", len(collect)),
+		zap.Int("deleted", deleted))
+}
+
+// selectForCollection returns the finished applications that are removed under the policy, oldest first.
+// Applications past the TTL are always collected. From the remaining applications the oldest ones are collected
+// until each namespace is within the namespace limit, and then until the cluster is within the total limit.
+func selectForCollection(appCRDs []*appv1.Application, policy gcPolicy, now time.Time) []*appv1.Application {
+	finished := make([]*appv1.Application, 0)
+	for _, appCRD := range appCRDs {
+		if isFinished(appCRD.Status.AppStatus) {
+			finished = append(finished, appCRD)
+		}
+	}
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finishTime(finished[i]).Before(finishTime(finished[j]))
+	})
+
+	collect := make([]*appv1.Application, 0)
+	kept := make([]*appv1.Application, 0, len(finished))
+	for _, appCRD := range finished {
+		if policy.ttl > 0 && now.Sub(finishTime(appCRD)) >= policy.ttl {
+			collect = append(collect, appCRD)
+			continue
+		}
+		kept = append(kept, appCRD)
+	}
+
+	if policy.maxCompletedPerNamespace > 0 {
+		perNamespace := make(map[string]int)
+		for _, appCRD := range kept {
+			perNamespace[appCRD.Namespace]++
+		}
+		remaining := kept[:0]
+		for _, appCRD := range kept {
+			if perNamespace[appCRD.Namespace] > policy.maxCompletedPerNamespace {
+				perNamespace[appCRD.Namespace]--
+				collect = append(collect, appCRD)
+				continue
+			}
+			remaining = append(remaining, appCRD)
+		}
+		kept = remaining
+	}
+
+	if policy.maxCompleted > 0 && len(kept) > policy.maxCompleted {
+		collect = append(collect, kept[:len(kept)-policy.maxCompleted]...)
+	}
+	return collect
+}
+
+func isFinished(state appv1.ApplicationStateType) bool {
+	switch state {
+	case appv1.CompletedState, appv1.KilledState, appv1.RejectedState:
+		return true
+	default:
+		return false
+	}
+}
+
+// finishTime returns the time of the last status change, the status does not change after the application finished.
+// CRDs without a recorded status change fall back to the creation time.
+func finishTime(appCRD *appv1.Application) time.Time {
+	if !appCRD.Status.LastUpdate.IsZero() {
+		return appCRD.Status.LastUpdate.Time
+	}
+	return appCRD.CreationTimestamp.Time
+}
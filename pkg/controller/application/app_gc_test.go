@@ -0,0 +1,94 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package application
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+)
+
+func createFinishedApp(name, namespace string, state appv1.ApplicationStateType, lastUpdate time.Time) *appv1.Application {
+	app := createApp(name, namespace, defaultQueue)
+	app.Status.AppStatus = state
+	app.Status.LastUpdate = apis.NewTime(lastUpdate)
+	return &app
+}
+
+func names(apps []*appv1.Application) []string {
+	result := make([]string, len(apps))
+	for i, app := range apps {
+		result[i] = app.Namespace + "/" + app.Name
+	}
+	return result
+}
+
+func TestGCPolicyEnabled(t *testing.T) {
+	assert.Assert(t, !gcPolicy{}.enabled(), "empty policy should be disabled")
+	assert.Assert(t, gcPolicy{ttl: time.Hour}.enabled(), "ttl policy should be enabled")
+	assert.Assert(t, gcPolicy{maxCompletedPerNamespace: 1}.enabled(), "namespace policy should be enabled")
+	assert.Assert(t, gcPolicy{maxCompleted: 1}.enabled(), "total policy should be enabled")
+}
+
+func TestSelectForCollection(t *testing.T) {
+	now := time.Now()
+	apps := []*appv1.Application{
+		createFinishedApp("running", "ns1", appv1.RunningState, now.Add(-5*time.Hour)),
+		createFinishedApp("old", "ns1", appv1.CompletedState, now.Add(-3*time.Hour)),
+		createFinishedApp("killed", "ns2", appv1.KilledState, now.Add(-2*time.Hour)),
+		createFinishedApp("rejected", "ns1", appv1.RejectedState, now.Add(-time.Hour)),
+		createFinishedApp("recent", "ns1", appv1.CompletedState, now.Add(-time.Minute)),
+		createFinishedApp("new", "ns2", appv1.CompletedState, now),
+	}
+
+	tests := []struct {
+		name     string
+		policy   gcPolicy
+		expected []string
+	}{
+		{"disabled", gcPolicy{}, []string{}},
+		{"ttl", gcPolicy{ttl: 90 * time.Minute}, []string{"ns1/old", "ns2/killed"}},
+		{"per namespace", gcPolicy{maxCompletedPerNamespace: 1}, []string{"ns1/old", "ns2/killed", "ns1/rejected"}},
+		{"total", gcPolicy{maxCompleted: 2}, []string{"ns1/old", "ns2/killed", "ns1/rejected"}},
+		{"ttl and per namespace", gcPolicy{ttl: 150 * time.Minute, maxCompletedPerNamespace: 1}, []string{"ns1/old", "ns2/killed", "ns1/rejected"}},
+		{"all", gcPolicy{ttl: 150 * time.Minute, maxCompletedPerNamespace: 2, maxCompleted: 2}, []string{"ns1/old", "ns2/killed", "ns1/rejected"}},
+		{"all limit total", gcPolicy{ttl: 150 * time.Minute, maxCompletedPerNamespace: 2, maxCompleted: 1}, []string{"ns1/old", "ns2/killed", "ns1/rejected", "ns1/recent"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			collect := selectForCollection(apps, tc.policy, now)
+			assert.DeepEqual(t, names(collect), tc.expected)
+		})
+	}
+}
+
+func TestFinishTime(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	app := createApp(defaultName, defaultNamespace, defaultQueue)
+	app.CreationTimestamp = apis.NewTime(created)
+	assert.Equal(t, finishTime(&app), created)
+
+	updated := created.Add(time.Minute)
+	app.Status.LastUpdate = apis.NewTime(updated)
+	assert.Equal(t, finishTime(&app), updated)
+}
@@ -30,7 +30,9 @@ import (
 	"gotest.tools/v3/assert"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 )
 
 // app event for testing
@@ -149,7 +151,7 @@ func TestDispatcherStartStop(t *testing.T) {
 func TestEventWillNotBeLostWhenEventChannelIsFull(t *testing.T) {
 	createDispatcher()
 	defer createDispatcher()
-	dispatcher.eventChan = make(chan events.SchedulingEvent, 1)
+	dispatcher.workers[0].eventChan = make(chan events.SchedulingEvent, 1)
 
 	// thread safe
 	recorder := &appEventsRecorder{
@@ -199,7 +201,7 @@ func TestDispatchTimeout(t *testing.T) {
 	createDispatcher()
 	defer createDispatcher()
 	// reset event channel with small capacity for testing
-	dispatcher.eventChan = make(chan events.SchedulingEvent, 1)
+	dispatcher.workers[0].eventChan = make(chan events.SchedulingEvent, 1)
 	AsyncDispatchCheckInterval = 100 * time.Millisecond
 	DispatchTimeout = 500 * time.Millisecond
 
@@ -259,7 +261,7 @@ func TestExceedAsyncDispatchLimit(t *testing.T) {
 	defer createDispatcher()
 
 	// reset event channel with small capacity for testing
-	dispatcher.eventChan = make(chan events.SchedulingEvent, 1)
+	dispatcher.workers[0].eventChan = make(chan events.SchedulingEvent, 1)
 	AsyncDispatchLimit = 1
 	// pretend to be an time-consuming event-handler
 	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
@@ -289,6 +291,279 @@ func TestExceedAsyncDispatchLimit(t *testing.T) {
 	}
 }
 
+// task event for testing
+type TestTaskEvent struct {
+	appID     string
+	taskID    string
+	eventType string
+}
+
+func (t TestTaskEvent) GetApplicationID() string {
+	return t.appID
+}
+
+func (t TestTaskEvent) GetTaskID() string {
+	return t.taskID
+}
+
+func (t TestTaskEvent) GetEvent() string {
+	return t.eventType
+}
+
+func (t TestTaskEvent) GetArgs() []interface{} {
+	return nil
+}
+
+// Test that waiting events releasing resources are handled before other waiting events,
+// and that a flood of those events does not starve the other events.
+func TestPriorityLane(t *testing.T) {
+	createDispatcher()
+	defer createDispatcher()
+
+	handled := make([]string, 0)
+	lock := sync.Mutex{}
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, obj.(TestAppEvent).appID)
+	})
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, obj.(TestTaskEvent).taskID)
+	})
+
+	// queue the events before the dispatcher is started
+	w := dispatcher.workers[0]
+	for _, appID := range []string{"app-1", "app-2"} {
+		event := TestAppEvent{appID: appID, eventType: RunApplication}
+		lane, eventChan := w.getLane(event)
+		assert.Equal(t, lane, LaneNormal)
+		eventChan <- event
+	}
+	for i := 0; i < PriorityBurst+2; i++ {
+		event := TestTaskEvent{appID: "app-0", taskID: fmt.Sprintf("task-%d", i), eventType: "CompleteTask"}
+		lane, eventChan := w.getLane(event)
+		assert.Equal(t, lane, LanePriority)
+		eventChan <- event
+	}
+
+	Start()
+	dispatcher.drain()
+	Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, len(handled), PriorityBurst+4)
+	for i := 0; i < PriorityBurst; i++ {
+		assert.Equal(t, handled[i], fmt.Sprintf("task-%d", i))
+	}
+	assert.Equal(t, handled[PriorityBurst], "app-1", "normal lane starved")
+	assert.Equal(t, handled[PriorityBurst+1], fmt.Sprintf("task-%d", PriorityBurst))
+	assert.Equal(t, handled[PriorityBurst+2], fmt.Sprintf("task-%d", PriorityBurst+1))
+	assert.Equal(t, handled[PriorityBurst+3], "app-2")
+}
+
+// Test that the events of a task are handled in order: a release event of a task is not moved in front of
+// an earlier event of the same application that is still waiting.
+func TestPriorityLaneKeepsOrder(t *testing.T) {
+	createDispatcher()
+	defer createDispatcher()
+
+	handled := make([]string, 0)
+	lock := sync.Mutex{}
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		event := obj.(TestTaskEvent)
+		handled = append(handled, event.taskID+"/"+event.eventType)
+	})
+
+	// queue the events before the dispatcher is started
+	w := dispatcher.workers[0]
+	for _, event := range []TestTaskEvent{
+		{appID: "app-1", taskID: "task-1", eventType: "SubmitTask"},
+		{appID: "app-1", taskID: "task-1", eventType: "CompleteTask"},
+		{appID: "app-2", taskID: "task-2", eventType: "CompleteTask"},
+	} {
+		lane, eventChan := w.getLane(event)
+		eventChan <- event
+		metrics.IncDispatcherQueueDepth(lane)
+	}
+	assert.Equal(t, len(w.eventChan), 2, "release of task-1 must wait behind its submit")
+	assert.Equal(t, len(w.priorityChan), 1)
+
+	Start()
+	dispatcher.drain()
+	Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.DeepEqual(t, handled, []string{"task-2/CompleteTask", "task-1/SubmitTask", "task-1/CompleteTask"})
+	assert.Equal(t, len(w.pending), 0)
+}
+
+// Test that the later events of an application follow a waiting release event into the priority lane:
+// a normal event handled after a burst of priority events must not overtake it.
+func TestPriorityLaneFollowers(t *testing.T) {
+	createDispatcher()
+	defer createDispatcher()
+
+	handled := make([]string, 0)
+	lock := sync.Mutex{}
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		event := obj.(TestTaskEvent)
+		handled = append(handled, event.taskID+"/"+event.eventType)
+	})
+
+	// queue the events before the dispatcher is started
+	w := dispatcher.workers[0]
+	queue := func(event TestTaskEvent, expected string) {
+		lane, eventChan := w.getLane(event)
+		assert.Equal(t, lane, expected, "unexpected lane for %s/%s", event.taskID, event.eventType)
+		eventChan <- event
+		metrics.IncDispatcherQueueDepth(lane)
+	}
+	for i := 0; i < PriorityBurst; i++ {
+		queue(TestTaskEvent{appID: "app-0", taskID: fmt.Sprintf("task-%d", i), eventType: "CompleteTask"}, LanePriority)
+	}
+	queue(TestTaskEvent{appID: "app-1", taskID: "task-1", eventType: "CompleteTask"}, LanePriority)
+	queue(TestTaskEvent{appID: "app-1", taskID: "task-2", eventType: "SubmitTask"}, LanePriority)
+	queue(TestTaskEvent{appID: "app-2", taskID: "task-3", eventType: "SubmitTask"}, LaneNormal)
+
+	Start()
+	dispatcher.drain()
+	Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, len(handled), PriorityBurst+3)
+	assert.DeepEqual(t, handled[PriorityBurst:], []string{"task-3/SubmitTask", "task-1/CompleteTask", "task-2/SubmitTask"})
+	assert.Equal(t, len(w.pending), 0)
+	assert.Equal(t, len(w.priority), 0)
+}
+
+// Test that the status events of an application are handled by the worker of the application
+func TestAppStatusEventWorker(t *testing.T) {
+	defer createDispatcher()
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.DispatcherWorkers = 4
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+	createDispatcher()
+
+	workers := make(map[*worker]bool)
+	for i := 0; i < 20; i++ {
+		appID := fmt.Sprintf("app-%d", i)
+		statusEvent := TestAppStatusEvent{appID: appID, state: "Running"}
+		assert.Equal(t, getKey(statusEvent), appID)
+		w := dispatcher.getWorker(statusEvent)
+		assert.Equal(t, w, dispatcher.getWorker(TestAppEvent{appID: appID, eventType: RunApplication}))
+		workers[w] = true
+	}
+	assert.Assert(t, len(workers) > 1, "status events of all applications are handled by one worker")
+}
+
+type TestAppStatusEvent struct {
+	appID string
+	state string
+}
+
+func (t TestAppStatusEvent) GetApplicationID() string {
+	return t.appID
+}
+
+func (t TestAppStatusEvent) GetState() string {
+	return t.state
+}
+
+func (t TestAppStatusEvent) GetEvent() string {
+	return "ApplicationStatusChange"
+}
+
+func (t TestAppStatusEvent) GetArgs() []interface{} {
+	return nil
+}
+
+func TestDispatchQueueDepth(t *testing.T) {
+	createDispatcher()
+	defer createDispatcher()
+
+	block := make(chan bool)
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		<-block
+	})
+	priority, err := metrics.GetDispatcherQueueDepth(LanePriority)
+	assert.NilError(t, err)
+	normal, err := metrics.GetDispatcherQueueDepth(LaneNormal)
+	assert.NilError(t, err)
+
+	Start()
+	// the first event is blocked in the handler, the others are waiting
+	Dispatch(TestTaskEvent{appID: "app-1", taskID: "task-1", eventType: "SubmitTask"})
+	err = utils.WaitForCondition(func() bool {
+		depth, err := metrics.GetDispatcherQueueDepth(LaneNormal)
+		return err == nil && depth == normal
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	Dispatch(TestTaskEvent{appID: "app-1", taskID: "task-2", eventType: "SubmitTask"})
+	Dispatch(TestTaskEvent{appID: "app-2", taskID: "task-3", eventType: "KillTask"})
+	depth, err := metrics.GetDispatcherQueueDepth(LaneNormal)
+	assert.NilError(t, err)
+	assert.Equal(t, depth, normal+1)
+	depth, err = metrics.GetDispatcherQueueDepth(LanePriority)
+	assert.NilError(t, err)
+	assert.Equal(t, depth, priority+1)
+
+	close(block)
+	dispatcher.drain()
+	Stop()
+	depth, err = metrics.GetDispatcherQueueDepth(LanePriority)
+	assert.NilError(t, err)
+	assert.Equal(t, depth, priority)
+	depth, err = metrics.GetDispatcherQueueDepth(LaneNormal)
+	assert.NilError(t, err)
+	assert.Equal(t, depth, normal)
+}
+
+func TestDispatcherWorkers(t *testing.T) {
+	defer createDispatcher()
+	schedulerConf := conf.GetSchedulerConf()
+	testConf := schedulerConf.Clone()
+	testConf.DispatcherWorkers = 4
+	conf.SetSchedulerConf(testConf)
+	defer conf.SetSchedulerConf(schedulerConf)
+	createDispatcher()
+	assert.Equal(t, len(dispatcher.workers), 4)
+
+	// the events of an app are handled by one worker
+	appEvent := TestAppEvent{appID: "app-1", eventType: RunApplication}
+	taskEvent := TestTaskEvent{appID: "app-1", taskID: "task-1", eventType: "CompleteTask"}
+	assert.Equal(t, dispatcher.getWorker(appEvent), dispatcher.getWorker(taskEvent))
+
+	recorder := &appEventsRecorder{
+		apps: make([]string, 0),
+		lock: &sync.RWMutex{},
+	}
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		recorder.addApp(obj.(TestAppEvent).appID)
+	})
+	Start()
+	numEvents := 100
+	for i := 0; i < numEvents; i++ {
+		Dispatch(TestAppEvent{appID: fmt.Sprintf("app-%d", i), eventType: RunApplication})
+	}
+	err := utils.WaitForCondition(func() bool {
+		return recorder.size() == numEvents
+	}, 10*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err)
+	Stop()
+	assert.Equal(t, dispatcher.isRunning(), false)
+}
+
 func createDispatcher() {
 	once.Do(func() {}) // run nop, so that functions like RegisterEventHandler() won't run initDispatcher() again
 	initDispatcher()
@@ -20,6 +20,7 @@ package dispatcher
 
 import (
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 )
@@ -44,30 +46,82 @@ const (
 	EventTypeAppStatus
 )
 
+const (
+	LanePriority = "priority"
+	LaneNormal   = "normal"
+)
+
 var (
 	AsyncDispatchLimit         int32
 	AsyncDispatchCheckInterval = 3 * time.Second
 	DispatchTimeout            time.Duration
 	asyncDispatchCount         int32 = 0
+	// PriorityBurst is the number of consecutive events of the priority lane a worker handles before it handles
+	// a waiting event of the normal lane, a flood of priority events never starves the normal lane.
+	PriorityBurst = 16
 )
 
+// priorityEvents are the events that release resources: the events of deleted pods must not wait behind a flood
+// of new pods during a mass submission. The names match the task and application events of the cache.
+// An event is only added to the priority lane if no earlier event of the same app or node is waiting in the
+// normal lane, and all later events of the app or node follow it into the priority lane while it is waiting:
+// the state machines rely on the events of an object being handled in order.
+var priorityEvents = map[string]bool{
+	"CompleteTask":            true,
+	"KillTask":                true,
+	"TaskKilled":              true,
+	"AppTaskCompleted":        true,
+	"ReleaseAppAllocation":    true,
+	"ReleaseAppAllocationAsk": true,
+	"KillApplication":         true,
+	"KilledApplication":       true,
+}
+
 // central dispatcher that dispatches scheduling events.
 type Dispatcher struct {
-	eventChan chan events.SchedulingEvent
-	stopChan  chan struct{}
-	handlers  map[EventType]func(interface{})
-	running   atomic.Value
-	lock      sync.RWMutex
+	workers  []*worker
+	stopChan chan struct{}
+	handlers map[EventType]func(interface{})
+	running  atomic.Value
+	active   int32
+	lock     sync.RWMutex
+	// handling is held shared while the events of an app or task are handled and exclusively for all other
+	// events: node and scheduler events are never handled at the same time as any other event.
+	handling sync.RWMutex
+}
+
+// worker handles the events of its share of the applications and nodes. The events of an application or node are
+// always handled by the same worker, in the order they were dispatched.
+type worker struct {
+	priorityChan chan events.SchedulingEvent
+	eventChan    chan events.SchedulingEvent
+	pending      map[string]int // app or node ID -> number of events waiting in the normal lane
+	priority     map[string]int // app or node ID -> number of events waiting in the priority lane
+	lock         sync.Mutex
 }
 
 func initDispatcher() {
 	eventChannelCapacity := conf.GetSchedulerConf().EventChannelCapacity
+	numWorkers := conf.GetSchedulerConf().GetDispatcherWorkers()
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	laneCapacity := eventChannelCapacity / numWorkers
+	workers := make([]*worker, numWorkers)
+	for i := range workers {
+		workers[i] = &worker{
+			priorityChan: make(chan events.SchedulingEvent, laneCapacity),
+			eventChan:    make(chan events.SchedulingEvent, laneCapacity),
+			pending:      make(map[string]int),
+			priority:     make(map[string]int),
+		}
+	}
 	dispatcher = &Dispatcher{
-		eventChan: make(chan events.SchedulingEvent, eventChannelCapacity),
-		handlers:  make(map[EventType]func(interface{})),
-		stopChan:  make(chan struct{}),
-		running:   atomic.Value{},
-		lock:      sync.RWMutex{},
+		workers:  workers,
+		handlers: make(map[EventType]func(interface{})),
+		stopChan: make(chan struct{}),
+		running:  atomic.Value{},
+		lock:     sync.RWMutex{},
 	}
 	dispatcher.setRunning(false)
 	DispatchTimeout = conf.GetSchedulerConf().DispatchTimeout
@@ -77,6 +131,7 @@ func initDispatcher() {
 	}
 	log.Log(log.ShimDispatcher).Info("Init dispatcher",
 		zap.Int("EventChannelCapacity", eventChannelCapacity),
+		zap.Int("Workers", numWorkers),
 		zap.Int32("AsyncDispatchLimit", AsyncDispatchLimit),
 		zap.Float64("DispatchTimeoutInSeconds", DispatchTimeout.Seconds()))
 }
@@ -105,8 +160,8 @@ func getDispatcher() *Dispatcher {
 
 // dispatches scheduler events to actual app/task handler,
 // each app/task has its own state machine and maintain their own states.
-// currently all events share same channel, so they are dispatched
-// one by one in order.
+// the events of an app or node are dispatched one by one in order, events
+// releasing resources are dispatched before waiting events of other apps or nodes.
 func Dispatch(event events.SchedulingEvent) {
 	// currently if dispatch fails, we simply log the error
 	// we may revisit this later, e.g add retry here
@@ -128,18 +183,93 @@ func (p *Dispatcher) dispatch(event events.SchedulingEvent) error {
 	if !p.isRunning() {
 		return fmt.Errorf("dispatcher is not running")
 	}
+	w := p.getWorker(event)
+	// the lane is chosen and the event added under the lock: a later event of the same object must see it
+	w.lock.Lock()
+	lane, eventChan := w.getLane(event)
+	metrics.IncDispatcherQueueDepth(lane)
 	select {
-	case p.eventChan <- event:
+	case eventChan <- event:
+		w.lock.Unlock()
 		return nil
 	default:
-		p.asyncDispatch(event)
+		w.lock.Unlock()
+		p.asyncDispatch(w, event, lane, eventChan)
 		return nil
 	}
 }
 
+// getKey returns the ID of the app or node of the event, empty for all other events.
+func getKey(event events.SchedulingEvent) string {
+	switch v := event.(type) {
+	case events.ApplicationStatusEvent:
+		return v.GetApplicationID()
+	case events.TaskEvent:
+		return v.GetApplicationID()
+	case events.ApplicationEvent:
+		return v.GetApplicationID()
+	case events.SchedulerNodeEvent:
+		return v.GetNodeID()
+	}
+	return ""
+}
+
+// getWorker returns the worker of the app or node of the event, all other events are handled by the first worker.
+func (p *Dispatcher) getWorker(event events.SchedulingEvent) *worker {
+	if len(p.workers) == 1 {
+		return p.workers[0]
+	}
+	key := getKey(event)
+	if key == "" {
+		return p.workers[0]
+	}
+	hash := fnv.New32a()
+	//nolint:errcheck
+	_, _ = hash.Write([]byte(key))
+	return p.workers[hash.Sum32()%uint32(len(p.workers))]
+}
+
+// getLane returns the lane of the worker the event is added to. A priority event of an app or node that still
+// has events waiting in the normal lane is added to the normal lane behind them. Any event of an app or node that
+// has events waiting in the priority lane is added to the priority lane behind them: the events of an app or node
+// are only ever waiting in one lane.
+// Must be called holding the worker lock.
+func (w *worker) getLane(event events.SchedulingEvent) (string, chan events.SchedulingEvent) {
+	key := getKey(event)
+	named, ok := event.(interface{ GetEvent() string })
+	if (key != "" && w.priority[key] > 0) || (ok && priorityEvents[named.GetEvent()] && w.pending[key] == 0) {
+		if key != "" {
+			w.priority[key]++
+		}
+		return LanePriority, w.priorityChan
+	}
+	if key != "" {
+		w.pending[key]++
+	}
+	return LaneNormal, w.eventChan
+}
+
+// removed updates the events waiting in the lane after the event was taken from the lane or dropped.
+func (w *worker) removed(lane string, event events.SchedulingEvent) {
+	metrics.DecDispatcherQueueDepth(lane)
+	key := getKey(event)
+	if key == "" {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	waiting := w.pending
+	if lane == LanePriority {
+		waiting = w.priority
+	}
+	if waiting[key]--; waiting[key] <= 0 {
+		delete(waiting, key)
+	}
+}
+
 // async-dispatch try to enqueue the event in every 3 seconds util timeout,
 // it's only called when event channel is full.
-func (p *Dispatcher) asyncDispatch(event events.SchedulingEvent) {
+func (p *Dispatcher) asyncDispatch(w *worker, event events.SchedulingEvent, lane string, eventChan chan events.SchedulingEvent) {
 	count := atomic.AddInt32(&asyncDispatchCount, 1)
 	log.Log(log.ShimDispatcher).Warn("event channel is full, transition to async-dispatch mode",
 		zap.Int32("asyncDispatchCount", count))
@@ -151,14 +281,17 @@ func (p *Dispatcher) asyncDispatch(event events.SchedulingEvent) {
 		for p.isRunning() {
 			select {
 			case <-stop:
+				w.removed(lane, event)
 				return
-			case p.eventChan <- event:
+			case eventChan <- event:
 				return
 			case <-time.After(AsyncDispatchCheckInterval):
 				elapseTime := time.Since(beginTime)
 				if elapseTime >= DispatchTimeout {
 					log.Log(log.ShimDispatcher).Error("dispatch timeout",
+						zap.String("lane", lane),
 						zap.Float64("elapseSeconds", elapseTime.Seconds()))
+					w.removed(lane, event)
 					return
 				}
 				log.Log(log.ShimDispatcher).Warn("event channel is full, keep waiting...",
@@ -169,48 +302,107 @@ func (p *Dispatcher) asyncDispatch(event events.SchedulingEvent) {
 }
 
 func (p *Dispatcher) drain() {
-	for len(p.eventChan) > 0 {
+	for remaining := p.queued(); remaining > 0; remaining = p.queued() {
 		log.Log(log.ShimDispatcher).Info("wait dispatcher to drain",
-			zap.Int("remaining events", len(p.eventChan)))
+			zap.Int("remaining events", remaining))
 		time.Sleep(1 * time.Second)
 	}
 	log.Log(log.ShimDispatcher).Info("dispatcher is draining out")
 }
 
-func Start() {
-	log.Log(log.ShimDispatcher).Info("starting the dispatcher")
-	if getDispatcher().isRunning() {
-		log.Log(log.ShimDispatcher).Info("dispatcher is already running")
-		return
+// queued returns the number of events waiting in the lanes of all workers.
+func (p *Dispatcher) queued() int {
+	count := 0
+	for _, w := range p.workers {
+		count += len(w.priorityChan) + len(w.eventChan)
 	}
-	getDispatcher().stopChan = make(chan struct{})
-	go func() {
-		for {
+	return count
+}
+
+// run handles the events of the worker until the dispatcher is stopped. Waiting events of the priority lane are
+// handled first, after PriorityBurst consecutive priority events a waiting event of the normal lane is handled.
+func (p *Dispatcher) run(w *worker, stop chan struct{}) {
+	defer func() {
+		if atomic.AddInt32(&p.active, -1) == 0 {
+			p.setRunning(false)
+		}
+	}()
+	burst := 0
+	for {
+		if burst < PriorityBurst {
 			select {
-			case event := <-getDispatcher().eventChan:
-				switch v := event.(type) {
-				case events.ApplicationStatusEvent:
-					getEventHandler(EventTypeAppStatus)(v)
-				case events.TaskEvent:
-					getEventHandler(EventTypeTask)(v)
-				case events.ApplicationEvent:
-					getEventHandler(EventTypeApp)(v)
-				case events.SchedulerNodeEvent:
-					getEventHandler(EventTypeNode)(v)
-				case events.SchedulerEvent:
-					getEventHandler(EventTypeScheduler)(v)
-				default:
-					log.Log(log.ShimDispatcher).Fatal("unsupported event",
-						zap.Any("event", v))
-				}
-			case <-getDispatcher().stopChan:
+			case <-stop:
 				log.Log(log.ShimDispatcher).Info("shutting down event channel")
-				getDispatcher().setRunning(false)
 				return
+			case event := <-w.priorityChan:
+				burst++
+				p.handleEvent(w, LanePriority, event)
+				continue
+			default:
+			}
+		} else {
+			select {
+			case event := <-w.eventChan:
+				burst = 0
+				p.handleEvent(w, LaneNormal, event)
+				continue
+			default:
 			}
 		}
-	}()
-	getDispatcher().setRunning(true)
+		burst = 0
+		select {
+		case event := <-w.priorityChan:
+			burst++
+			p.handleEvent(w, LanePriority, event)
+		case event := <-w.eventChan:
+			p.handleEvent(w, LaneNormal, event)
+		case <-stop:
+			log.Log(log.ShimDispatcher).Info("shutting down event channel")
+			return
+		}
+	}
+}
+
+func (p *Dispatcher) handleEvent(w *worker, lane string, event events.SchedulingEvent) {
+	w.removed(lane, event)
+	switch event.(type) {
+	case events.TaskEvent, events.ApplicationEvent, events.ApplicationStatusEvent:
+		p.handling.RLock()
+		defer p.handling.RUnlock()
+	default:
+		p.handling.Lock()
+		defer p.handling.Unlock()
+	}
+	switch v := event.(type) {
+	case events.ApplicationStatusEvent:
+		getEventHandler(EventTypeAppStatus)(v)
+	case events.TaskEvent:
+		getEventHandler(EventTypeTask)(v)
+	case events.ApplicationEvent:
+		getEventHandler(EventTypeApp)(v)
+	case events.SchedulerNodeEvent:
+		getEventHandler(EventTypeNode)(v)
+	case events.SchedulerEvent:
+		getEventHandler(EventTypeScheduler)(v)
+	default:
+		log.Log(log.ShimDispatcher).Fatal("unsupported event",
+			zap.Any("event", v))
+	}
+}
+
+func Start() {
+	log.Log(log.ShimDispatcher).Info("starting the dispatcher")
+	if getDispatcher().isRunning() {
+		log.Log(log.ShimDispatcher).Info("dispatcher is already running")
+		return
+	}
+	p := getDispatcher()
+	p.stopChan = make(chan struct{})
+	atomic.StoreInt32(&p.active, int32(len(p.workers)))
+	for _, w := range p.workers {
+		go p.run(w, p.stopChan)
+	}
+	p.setRunning(true)
 }
 
 // stop the dispatcher and wait at most 5 seconds gracefully
@@ -0,0 +1,189 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventstream
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// TopicTask state changes of tasks
+	TopicTask = "task"
+	// TopicAllocation tasks bound to a node
+	TopicAllocation = "allocation"
+	// TopicPreemption tasks preempted by the scheduler
+	TopicPreemption = "preemption"
+	// TopicNode nodes added, updated or removed
+	TopicNode = "node"
+
+	// DefaultBufferSize is the number of events buffered for a subscriber that did not set a size
+	DefaultBufferSize = 1000
+	// MaxBufferSize is the maximum number of events buffered for a subscriber
+	MaxBufferSize = 10000
+)
+
+// Topics lists all topics that can be subscribed to
+var Topics = []string{TopicTask, TopicAllocation, TopicPreemption, TopicNode}
+
+// Event is a single event published to the subscribers of the topic
+type Event struct {
+	Topic       string    `json:"topic"`
+	ObjectID    string    `json:"objectID"`
+	ReferenceID string    `json:"referenceID,omitempty"`
+	Message     string    `json:"message"`
+	Timestamp   time.Time `json:"timestamp"`
+	// Dropped is the number of events dropped for the subscriber since the previous event it received
+	Dropped uint64 `json:"dropped,omitempty"`
+}
+
+// Subscription receives the events of the subscribed topics. The events are buffered, a subscriber that does not
+// keep up loses the events that do not fit in the buffer instead of slowing down the scheduler.
+type Subscription struct {
+	topics  map[string]bool
+	events  chan *Event
+	dropped atomic.Uint64
+}
+
+// Events returns the channel the events are delivered on, the channel is closed when the subscription ends
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// TakeDropped returns the number of events dropped since the last call
+func (s *Subscription) TakeDropped() uint64 {
+	return s.dropped.Swap(0)
+}
+
+func (s *Subscription) offer(event *Event) {
+	select {
+	case s.events <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Broker publishes events to the subscriptions of the topic
+type Broker struct {
+	subscriptions map[*Subscription]bool
+	topics        map[string]int // topic -> number of subscriptions
+	lock          sync.RWMutex
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscriptions: make(map[*Subscription]bool),
+		topics:        make(map[string]int),
+	}
+}
+
+// Subscribe creates a subscription for the topics, all topics if none are given. The buffer size is limited to
+// MaxBufferSize, the DefaultBufferSize is used if the size is not positive.
+func (b *Broker) Subscribe(topics []string, bufferSize int) (*Subscription, error) {
+	if len(topics) == 0 {
+		topics = Topics
+	}
+	sub := &Subscription{topics: make(map[string]bool)}
+	for _, topic := range topics {
+		if !isTopic(topic) {
+			return nil, fmt.Errorf("unknown topic %s", topic)
+		}
+		sub.topics[topic] = true
+	}
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	if bufferSize > MaxBufferSize {
+		bufferSize = MaxBufferSize
+	}
+	sub.events = make(chan *Event, bufferSize)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.subscriptions[sub] = true
+	for topic := range sub.topics {
+		b.topics[topic]++
+	}
+	return sub, nil
+}
+
+// Unsubscribe ends the subscription and closes its channel
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.subscriptions[sub] {
+		return
+	}
+	delete(b.subscriptions, sub)
+	for topic := range sub.topics {
+		b.topics[topic]--
+	}
+	close(sub.events)
+}
+
+// HasSubscribers returns true if the topic has at least one subscription
+func (b *Broker) HasSubscribers(topic string) bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.topics[topic] > 0
+}
+
+// Publish delivers the event to the subscriptions of its topic without blocking
+func (b *Broker) Publish(event *Event) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for sub := range b.subscriptions {
+		if sub.topics[event.Topic] {
+			sub.offer(event)
+		}
+	}
+}
+
+func isTopic(topic string) bool {
+	for _, t := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+var broker = NewBroker()
+
+// GetBroker returns the broker the shim publishes its events to
+func GetBroker() *Broker {
+	return broker
+}
+
+// Publish publishes an event to the subscribers of the topic. The message is only formatted if the topic has
+// subscribers, which keeps publishing cheap while nobody is listening.
+func Publish(topic, objectID, referenceID, messageFmt string, args ...interface{}) {
+	if !broker.HasSubscribers(topic) {
+		return
+	}
+	broker.Publish(&Event{
+		Topic:       topic,
+		ObjectID:    objectID,
+		ReferenceID: referenceID,
+		Message:     fmt.Sprintf(messageFmt, args...),
+		Timestamp:   time.Now(),
+	})
+}
@@ -0,0 +1,92 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventstream
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestBrokerSubscribe(t *testing.T) {
+	broker := NewBroker()
+	_, err := broker.Subscribe([]string{"unknown"}, 0)
+	assert.ErrorContains(t, err, "unknown topic unknown")
+	assert.Assert(t, !broker.HasSubscribers(TopicTask))
+
+	all, err := broker.Subscribe(nil, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, cap(all.events), DefaultBufferSize)
+	nodes, err := broker.Subscribe([]string{TopicNode}, MaxBufferSize+1)
+	assert.NilError(t, err)
+	assert.Equal(t, cap(nodes.events), MaxBufferSize)
+	for _, topic := range Topics {
+		assert.Assert(t, broker.HasSubscribers(topic), "topic %s", topic)
+	}
+
+	broker.Publish(&Event{Topic: TopicTask, ObjectID: "task-1"})
+	broker.Publish(&Event{Topic: TopicNode, ObjectID: "node-1"})
+	assert.Equal(t, len(all.events), 2)
+	assert.Equal(t, len(nodes.events), 1)
+	assert.Equal(t, (<-nodes.Events()).ObjectID, "node-1")
+
+	broker.Unsubscribe(all)
+	broker.Unsubscribe(all)
+	assert.Assert(t, !broker.HasSubscribers(TopicTask))
+	assert.Assert(t, broker.HasSubscribers(TopicNode))
+	// buffered events are still delivered after the subscription ended
+	assert.Equal(t, (<-all.Events()).ObjectID, "task-1")
+	assert.Equal(t, (<-all.Events()).ObjectID, "node-1")
+	_, ok := <-all.Events()
+	assert.Assert(t, !ok, "channel should be closed")
+}
+
+func TestBrokerDropped(t *testing.T) {
+	broker := NewBroker()
+	sub, err := broker.Subscribe([]string{TopicAllocation}, 2)
+	assert.NilError(t, err)
+	for i := 0; i < 5; i++ {
+		broker.Publish(&Event{Topic: TopicAllocation})
+	}
+	assert.Equal(t, len(sub.events), 2)
+	assert.Equal(t, sub.TakeDropped(), uint64(3))
+	assert.Equal(t, sub.TakeDropped(), uint64(0))
+}
+
+func TestPublish(t *testing.T) {
+	old := broker
+	broker = NewBroker()
+	defer func() { broker = old }()
+
+	// nothing is published without subscribers
+	Publish(TopicTask, "task-1", "app-1", "Task %s", "task-1")
+	sub, err := GetBroker().Subscribe([]string{TopicTask}, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(sub.events), 0)
+
+	Publish(TopicTask, "task-1", "app-1", "Task %s", "task-1")
+	Publish(TopicNode, "node-1", "default", "Node %s", "node-1")
+	assert.Equal(t, len(sub.events), 1)
+	event := <-sub.Events()
+	assert.Equal(t, event.Topic, TopicTask)
+	assert.Equal(t, event.ObjectID, "task-1")
+	assert.Equal(t, event.ReferenceID, "app-1")
+	assert.Equal(t, event.Message, "Task task-1")
+	assert.Assert(t, !event.Timestamp.IsZero())
+}
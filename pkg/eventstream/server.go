@@ -0,0 +1,159 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventstream
+
+import (
+	"encoding/json"
+	"net"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	// ServiceName is the name of the gRPC service
+	ServiceName = "yunikorn.shim.EventStream"
+	// SubscribeMethod is the full name of the server streaming subscribe method
+	SubscribeMethod = "/" + ServiceName + "/Subscribe"
+	// CodecName is the content subtype clients must use, messages are encoded as JSON
+	CodecName = "json"
+)
+
+// SubscribeRequest is sent by the client to open the stream
+type SubscribeRequest struct {
+	// Topics to receive the events of, all topics if empty
+	Topics []string `json:"topics,omitempty"`
+	// BufferSize is the number of events buffered for the client before events are dropped
+	BufferSize int `json:"bufferSize,omitempty"`
+}
+
+// jsonCodec encodes the messages of the stream as JSON, there are no generated protobuf messages for the stream
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// ServiceDesc describes the event stream service, clients use it to open the stream with grpc.ClientConn.NewStream
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       subscribeHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+type streamServer struct {
+	broker *Broker
+}
+
+func subscribeHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(*streamServer).subscribe(stream)
+}
+
+// subscribe sends the events of the requested topics until the client goes away. Events are never sent from the
+// publishing goroutine: a slow client fills its buffer and the events that do not fit are counted as dropped.
+func (s *streamServer) subscribe(stream grpc.ServerStream) error {
+	req := &SubscribeRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	sub, err := s.broker.Subscribe(req.Topics, req.BufferSize)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer s.broker.Unsubscribe(sub)
+	log.Log(log.ShimEventStream).Info("event stream subscriber connected",
+		zap.Strings("topics", req.Topics))
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Log(log.ShimEventStream).Info("event stream subscriber disconnected")
+			return nil
+		case event := <-sub.Events():
+			if dropped := sub.TakeDropped(); dropped > 0 {
+				copied := *event
+				copied.Dropped = dropped
+				event = &copied
+			}
+			if err := stream.SendMsg(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Server serves the event stream to external consumers
+type Server struct {
+	address string
+	server  *grpc.Server
+}
+
+// NewServer creates a server for the events published to the broker
+func NewServer(address string, broker *Broker) *Server {
+	server := grpc.NewServer()
+	server.RegisterService(&ServiceDesc, &streamServer{broker: broker})
+	return &Server{
+		address: address,
+		server:  server,
+	}
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	s.serve(listener)
+	return nil
+}
+
+func (s *Server) serve(listener net.Listener) {
+	log.Log(log.ShimEventStream).Info("starting event stream", zap.String("address", listener.Addr().String()))
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			log.Log(log.ShimEventStream).Error("event stream failed", zap.Error(err))
+		}
+	}()
+}
+
+func (s *Server) Stop() {
+	s.server.Stop()
+}
@@ -0,0 +1,77 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventstream
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+func openStream(t *testing.T, ctx context.Context, address string, req *SubscribeRequest) grpc.ClientStream {
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	stream, err := conn.NewStream(ctx, &ServiceDesc.Streams[0], SubscribeMethod, grpc.CallContentSubtype(CodecName))
+	assert.NilError(t, err)
+	assert.NilError(t, stream.SendMsg(req))
+	assert.NilError(t, stream.CloseSend())
+	return stream
+}
+
+func TestServerSubscribe(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.NilError(t, err)
+	broker := NewBroker()
+	server := NewServer(listener.Addr().String(), broker)
+	server.serve(listener)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// unknown topics are rejected
+	stream := openStream(t, ctx, listener.Addr().String(), &SubscribeRequest{Topics: []string{"unknown"}})
+	err = stream.RecvMsg(&Event{})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+
+	stream = openStream(t, ctx, listener.Addr().String(), &SubscribeRequest{Topics: []string{TopicNode}, BufferSize: 1})
+	// wait for the subscription to be registered before publishing
+	for !broker.HasSubscribers(TopicNode) {
+		select {
+		case <-ctx.Done():
+			t.Fatal("subscription not registered")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	broker.Publish(&Event{Topic: TopicTask, ObjectID: "task-1"})
+	broker.Publish(&Event{Topic: TopicNode, ObjectID: "node-1", Message: "Node node-1 added"})
+	event := &Event{}
+	assert.NilError(t, stream.RecvMsg(event))
+	assert.Equal(t, event.Topic, TopicNode)
+	assert.Equal(t, event.ObjectID, "node-1")
+	assert.Equal(t, event.Message, "Node node-1 added")
+}
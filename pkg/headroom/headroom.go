@@ -0,0 +1,139 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package headroom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+const queuesPath = "/ws/v1/partition/%s/queues"
+
+// QueueHeadroom is the resource that can still be requested in a queue before it hits a limit.
+// Resources that are not limited by the queue or any of its parents are not listed.
+type QueueHeadroom struct {
+	Partition string           `json:"partition"`
+	QueueName string           `json:"queueName"`
+	Headroom  map[string]int64 `json:"headroom"`
+}
+
+// Client retrieves the queue hierarchy from the scheduler core REST API to calculate the headroom.
+// It allows external components, like job submission gateways, to apply backpressure before they
+// create objects in Kubernetes.
+type Client struct {
+	coreURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a headroom client for the core REST API at the given URL
+func NewClient(coreURL string) *Client {
+	return &Client{
+		coreURL:    strings.TrimSuffix(coreURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetQueueHeadroom returns the headroom of the queue in the partition. The queue name must be the fully qualified
+// queue path, e.g. root.a.b.
+func (c *Client) GetQueueHeadroom(ctx context.Context, partition, queueName string) (*QueueHeadroom, error) {
+	root, err := c.GetQueues(ctx, partition)
+	if err != nil {
+		return nil, err
+	}
+	headroom, err := Calculate(root, queueName)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueHeadroom{
+		Partition: partition,
+		QueueName: queueName,
+		Headroom:  headroom,
+	}, nil
+}
+
+// GetQueues returns the queue hierarchy of the partition with the configured and used resources of each queue
+func (c *Client) GetQueues(ctx context.Context, partition string) (*dao.PartitionQueueDAOInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.coreURL+fmt.Sprintf(queuesPath, url.PathEscape(partition)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve queues for partition %s: status %d", partition, resp.StatusCode)
+	}
+	root := &dao.PartitionQueueDAOInfo{}
+	if err = json.NewDecoder(resp.Body).Decode(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Calculate returns the headroom of the queue in the hierarchy. The headroom of a queue is the max resource minus
+// the allocated and pending resources, pending resources include the reservations. The smallest headroom of the queue
+// and all its parents is returned for each resource type. A negative headroom is returned as zero.
+func Calculate(root *dao.PartitionQueueDAOInfo, queueName string) (map[string]int64, error) {
+	path := make([]*dao.PartitionQueueDAOInfo, 0)
+	queue := root
+	for queue != nil {
+		path = append(path, queue)
+		if queue.QueueName == queueName {
+			break
+		}
+		queue = findChild(queue, queueName)
+	}
+	if queue == nil {
+		return nil, fmt.Errorf("queue %s not found", queueName)
+	}
+	headroom := make(map[string]int64)
+	for _, q := range path {
+		for name, max := range q.MaxResource {
+			available := max - q.AllocatedResource[name] - q.PendingResource[name]
+			if available < 0 {
+				available = 0
+			}
+			if current, ok := headroom[name]; !ok || available < current {
+				headroom[name] = available
+			}
+		}
+	}
+	return headroom, nil
+}
+
+// findChild returns the child of the queue that is the queue or one of its parents
+func findChild(queue *dao.PartitionQueueDAOInfo, queueName string) *dao.PartitionQueueDAOInfo {
+	for i := range queue.Children {
+		child := &queue.Children[i]
+		if child.QueueName == queueName || strings.HasPrefix(queueName, child.QueueName+".") {
+			return child
+		}
+	}
+	return nil
+}
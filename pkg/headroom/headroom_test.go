@@ -0,0 +1,105 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package headroom
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+func queuesForTest() *dao.PartitionQueueDAOInfo {
+	return &dao.PartitionQueueDAOInfo{
+		QueueName:         "root",
+		MaxResource:       map[string]int64{"memory": 1000, "vcore": 100},
+		AllocatedResource: map[string]int64{"memory": 400, "vcore": 20},
+		PendingResource:   map[string]int64{"memory": 100},
+		Children: []dao.PartitionQueueDAOInfo{
+			{
+				QueueName:         "root.a",
+				MaxResource:       map[string]int64{"vcore": 50},
+				AllocatedResource: map[string]int64{"memory": 300, "vcore": 20},
+				PendingResource:   map[string]int64{"vcore": 10},
+				Children: []dao.PartitionQueueDAOInfo{
+					{
+						QueueName:         "root.a.full",
+						MaxResource:       map[string]int64{"memory": 200},
+						AllocatedResource: map[string]int64{"memory": 250},
+					},
+				},
+			},
+			{
+				QueueName:         "root.ab",
+				AllocatedResource: map[string]int64{"memory": 100},
+			},
+		},
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		queue    string
+		expected map[string]int64
+	}{
+		{"root", "root", map[string]int64{"memory": 500, "vcore": 80}},
+		{"limited by child max", "root.a", map[string]int64{"memory": 500, "vcore": 20}},
+		{"over limit", "root.a.full", map[string]int64{"memory": 0, "vcore": 20}},
+		{"limited by root only", "root.ab", map[string]int64{"memory": 500, "vcore": 80}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			headroom, err := Calculate(queuesForTest(), tc.queue)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, headroom, tc.expected)
+		})
+	}
+
+	_, err := Calculate(queuesForTest(), "root.unknown")
+	assert.ErrorContains(t, err, "not found")
+	_, err = Calculate(queuesForTest(), "root.a.b")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestGetQueueHeadroom(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws/v1/partition/default/queues" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(queuesForTest()))
+	}))
+	defer core.Close()
+
+	client := NewClient(core.URL)
+	headroom, err := client.GetQueueHeadroom(context.Background(), "default", "root.a")
+	assert.NilError(t, err)
+	assert.Equal(t, headroom.Partition, "default")
+	assert.Equal(t, headroom.QueueName, "root.a")
+	assert.DeepEqual(t, headroom.Headroom, map[string]int64{"memory": 500, "vcore": 20})
+
+	_, err = client.GetQueueHeadroom(context.Background(), "unknown", "root.a")
+	assert.ErrorContains(t, err, "status 404")
+}
@@ -81,6 +81,8 @@ var (
 	ShimSchedulerPlugin      = &LoggerHandle{id: 25, name: "shim.scheduler.plugin"}
 	ShimPredicates           = &LoggerHandle{id: 26, name: "shim.predicates"}
 	ShimFramework            = &LoggerHandle{id: 27, name: "shim.framework"}
+	ShimRESTProxy            = &LoggerHandle{id: 28, name: "shim.restproxy"}
+	ShimEventStream          = &LoggerHandle{id: 29, name: "shim.eventstream"}
 )
 
 // this tracks all the known logger handles, used to preallocate the real logger instances when configuration changes
@@ -90,17 +92,27 @@ var loggers = []*LoggerHandle{
 	ShimAppMgmt, ShimAppMgmtGeneral, ShimAppMgmtSparkOperator, ShimContext, ShimFSM,
 	ShimCacheApplication, ShimCacheNode, ShimCacheTask, ShimCacheExternal, ShimCachePlaceholder,
 	ShimRMCallback, ShimClient, ShimResources, ShimUtils, ShimConfig, ShimDispatcher,
-	ShimScheduler, ShimSchedulerPlugin, ShimPredicates, ShimFramework,
+	ShimScheduler, ShimSchedulerPlugin, ShimPredicates, ShimFramework, ShimRESTProxy,
+	ShimEventStream,
 }
 
 // structure to hold all current logger configuration state
 type loggerConfig struct {
-	loggers []*zap.Logger
+	loggers  []*zap.Logger
+	levelMap map[string]zapcore.Level
 }
 
 // tracks the currently used set of loggers; replaced completely whenever configuration changes
 var currentLoggerConfig = atomic.Pointer[loggerConfig]{}
 
+// the last configuration passed in via UpdateLoggingConfig and the runtime overrides set via SetLoggerLevel,
+// the overrides take precedence over the configuration until they are reset
+var (
+	configLock     sync.Mutex
+	loggingConfig  map[string]string
+	levelOverrides = make(map[string]string)
+)
+
 // RootLogger retrieves the root logger, used to pass the configured logger to the scheduler core on startup
 func RootLogger() *zap.Logger {
 	once.Do(initLogger)
@@ -219,7 +231,70 @@ func GetZapConfigs() *zap.Config {
 // textual (DEBUG, INFO, WARN, ERROR, DPANIC, PANIC, or ERROR). See zapcore documentation for more details.
 func UpdateLoggingConfig(config map[string]string) {
 	once.Do(initLogger)
-	initLoggingConfig(config)
+	configLock.Lock()
+	defer configLock.Unlock()
+	loggingConfig = config
+	initLoggingConfig(mergeOverrides(config))
+}
+
+// SetLoggerLevel overrides the level of the named logger, and all its child loggers that are not configured
+// separately, at runtime. An empty name sets the default level. The override is kept until ResetLoggerLevels
+// is called, configuration updates do not remove it.
+func SetLoggerLevel(name string, level string) error {
+	once.Do(initLogger)
+	if strings.Contains(name, "..") || strings.Contains(name, " ") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return fmt.Errorf("invalid logger name: %q", name)
+	}
+	if parseLevel(level) == nil {
+		return fmt.Errorf("invalid log level: %q", level)
+	}
+	configLock.Lock()
+	defer configLock.Unlock()
+	levelOverrides[name] = level
+	initLoggingConfig(mergeOverrides(loggingConfig))
+	return nil
+}
+
+// ResetLoggerLevels removes all runtime overrides and returns to the configured log levels.
+func ResetLoggerLevels() {
+	once.Do(initLogger)
+	configLock.Lock()
+	defer configLock.Unlock()
+	levelOverrides = make(map[string]string)
+	initLoggingConfig(mergeOverrides(loggingConfig))
+}
+
+// GetLoggerLevels returns the effective level of all defined loggers and the default level, the default level
+// uses the empty string as the name.
+func GetLoggerLevels() map[string]string {
+	once.Do(initLogger)
+	levelMap := currentLoggerConfig.Load().levelMap
+	levels := make(map[string]string, len(loggers)+1)
+	levels[nullLogger] = loggerLevel(levelMap, nullLogger).String()
+	for _, handle := range loggers {
+		levels[handle.name] = loggerLevel(levelMap, handle.name).String()
+	}
+	return levels
+}
+
+// mergeOverrides returns a copy of the configuration with the runtime overrides applied.
+// Must be called while holding the configLock.
+func mergeOverrides(config map[string]string) map[string]string {
+	if len(levelOverrides) == 0 {
+		return config
+	}
+	merged := make(map[string]string, len(config)+len(levelOverrides))
+	for k, v := range config {
+		merged[k] = v
+	}
+	for name, level := range levelOverrides {
+		if name == nullLogger {
+			merged[defaultLog] = level
+		} else {
+			merged[logPrefix+name+levelSuffix] = level
+		}
+	}
+	return merged
 }
 
 // initLoggingConfig replaces the existing set of loggers with new ones configured according to the given
@@ -278,7 +353,7 @@ func initLoggingConfig(config map[string]string) {
 	for i := 0; i < len(loggers); i++ {
 		zapLoggers[i] = createLogger(levelMap, loggers[i].name)
 	}
-	newLoggerConfig := loggerConfig{loggers: zapLoggers}
+	newLoggerConfig := loggerConfig{loggers: zapLoggers, levelMap: levelMap}
 
 	// update the root zap logger level
 	zapConfigs.Level.SetLevel(minLevel)
@@ -38,7 +38,7 @@ func TestLoggerIds(t *testing.T) {
 	_ = Log(Test)
 
 	// validate logger count
-	assert.Equal(t, 28, len(loggers), "wrong logger count")
+	assert.Equal(t, 30, len(loggers), "wrong logger count")
 
 	// validate that all loggers are populated and have sequential ids
 	for i := 0; i < len(loggers); i++ {
@@ -145,6 +145,40 @@ func benchmarkScopedLoggerInfoFiltered(iterations int) int64 {
 	return (time.Since(start).Nanoseconds()) / int64(iterations)
 }
 
+func TestSetLoggerLevel(t *testing.T) {
+	UpdateLoggingConfig(map[string]string{
+		"log.level":            "WARN",
+		"log.shim.cache.level": "ERROR",
+	})
+	defer UpdateLoggingConfig(nil)
+	defer ResetLoggerLevels()
+
+	levels := GetLoggerLevels()
+	assert.Equal(t, levels[""], "warn")
+	assert.Equal(t, levels[ShimCacheTask.name], "error")
+	assert.Equal(t, levels[ShimDispatcher.name], "warn")
+
+	assert.NilError(t, SetLoggerLevel("shim.cache", "DEBUG"))
+	assert.NilError(t, SetLoggerLevel("", "INFO"))
+	levels = GetLoggerLevels()
+	assert.Equal(t, levels[""], "info")
+	assert.Equal(t, levels[ShimCacheTask.name], "debug")
+	assert.Equal(t, levels[ShimDispatcher.name], "info")
+	assert.Assert(t, Log(ShimCacheTask).Core().Enabled(zapcore.DebugLevel), "debug not enabled for cache logger")
+
+	// configuration updates keep the overrides
+	UpdateLoggingConfig(map[string]string{"log.shim.cache.level": "ERROR"})
+	assert.Equal(t, GetLoggerLevels()[ShimCacheTask.name], "debug")
+
+	assert.ErrorContains(t, SetLoggerLevel("shim..cache", "DEBUG"), "invalid logger name")
+	assert.ErrorContains(t, SetLoggerLevel("shim.cache", "LOUD"), "invalid log level")
+
+	ResetLoggerLevels()
+	levels = GetLoggerLevels()
+	assert.Equal(t, levels[ShimCacheTask.name], "error")
+	assert.Equal(t, levels[ShimDispatcher.name], "info")
+}
+
 func TestParseLevel(t *testing.T) {
 	assert.Equal(t, zapcore.DebugLevel, *parseLevel("-2"), "out of range low")
 	assert.Equal(t, zapcore.DebugLevel, *parseLevel("-1"))
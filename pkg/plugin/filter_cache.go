@@ -0,0 +1,87 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package plugin
+
+import (
+	"sync"
+
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// maximum number of pods tracked in the filter cache, the cache is reset when the limit is reached
+const filterCacheMaxPods = 10000
+
+type filterResult struct {
+	generation int64
+	status     *framework.Status
+}
+
+// filterCache keeps the rejections returned by Filter() for a pod and node combination.
+// A result is only valid for the generation of the node it was computed for, any change to the node
+// invalidates the result. All results for a pod are removed when an allocation for the pod changes.
+// Successful results are never cached: releasing a pod changes the allocation state.
+type filterCache struct {
+	results map[string]map[string]filterResult // pod UID -> node name -> result
+
+	sync.RWMutex
+}
+
+func newFilterCache() *filterCache {
+	return &filterCache{
+		results: make(map[string]map[string]filterResult),
+	}
+}
+
+// get returns the cached result for the pod and node, if the node has not changed since the result was cached.
+func (c *filterCache) get(podUID, nodeName string, generation int64) (*framework.Status, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if result, ok := c.results[podUID][nodeName]; ok && result.generation == generation {
+		return result.status, true
+	}
+	return nil, false
+}
+
+// add caches the rejection of the pod for the node, successful results are ignored.
+func (c *filterCache) add(podUID, nodeName string, generation int64, status *framework.Status) {
+	if status.IsSuccess() {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	nodes, ok := c.results[podUID]
+	if !ok {
+		if len(c.results) >= filterCacheMaxPods {
+			c.results = make(map[string]map[string]filterResult)
+		}
+		nodes = make(map[string]filterResult)
+		c.results[podUID] = nodes
+	}
+	nodes[nodeName] = filterResult{
+		generation: generation,
+		status:     status,
+	}
+}
+
+// invalidate removes all cached results for the pod.
+func (c *filterCache) invalidate(podUID string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.results, podUID)
+}
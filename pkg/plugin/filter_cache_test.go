@@ -0,0 +1,54 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+func TestFilterCache(t *testing.T) {
+	cache := newFilterCache()
+	_, ok := cache.get("pod-1", "node-1", 1)
+	assert.Assert(t, !ok, "empty cache should not return a result")
+
+	// successful results are not cached
+	cache.add("pod-1", "node-1", 1, nil)
+	_, ok = cache.get("pod-1", "node-1", 1)
+	assert.Assert(t, !ok, "successful result should not be cached")
+
+	rejected := framework.NewStatus(framework.UnschedulableAndUnresolvable, "Pod is not fit for node")
+	cache.add("pod-1", "node-1", 1, rejected)
+	cache.add("pod-1", "node-2", 5, rejected)
+	status, ok := cache.get("pod-1", "node-1", 1)
+	assert.Assert(t, ok, "rejection should be cached")
+	assert.Equal(t, status, rejected)
+	_, ok = cache.get("pod-2", "node-1", 1)
+	assert.Assert(t, !ok, "result should not be returned for another pod")
+
+	// a node change invalidates the result
+	_, ok = cache.get("pod-1", "node-1", 2)
+	assert.Assert(t, !ok, "result should not be returned for a new node generation")
+
+	cache.invalidate("pod-1")
+	_, ok = cache.get("pod-1", "node-2", 5)
+	assert.Assert(t, !ok, "results should be removed for the pod")
+}
@@ -27,10 +27,12 @@ import (
 	"go.uber.org/zap"
 	"gotest.tools/v3/assert"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/interpodaffinity"
@@ -41,6 +43,7 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/nodeunschedulable"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/nodevolumelimits"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/podtopologyspread"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
 	"k8s.io/kubernetes/pkg/util/taints"
 
 	"github.com/apache/yunikorn-k8shim/pkg/client"
@@ -2234,6 +2237,84 @@ func TestReserveNodeSelector(t *testing.T) {
 	}
 }
 
+func TestVolumeBindingStorageCapacity(t *testing.T) {
+	conf.GetSchedulerConf().SetTestMode(true)
+	waitForFirstConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	storageClass := "csi-sc"
+	storageCapacity := true
+	clientSet := fake.NewSimpleClientset(
+		&storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: storageClass},
+			Provisioner:       "csi.example.com",
+			VolumeBindingMode: &waitForFirstConsumer,
+		},
+		&storagev1.CSIDriver{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi.example.com"},
+			Spec:       storagev1.CSIDriverSpec{StorageCapacity: &storageCapacity},
+		},
+		&storagev1.CSIStorageCapacity{
+			ObjectMeta:       metav1.ObjectMeta{Name: "capacity-a", Namespace: "default"},
+			StorageClassName: storageClass,
+			NodeTopology:     &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}},
+			Capacity:         resource.NewQuantity(5*1024*1024*1024, resource.BinarySI),
+		},
+		&storagev1.CSIStorageCapacity{
+			ObjectMeta:       metav1.ObjectMeta{Name: "capacity-b", Namespace: "default"},
+			StorageClassName: storageClass,
+			NodeTopology:     &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "b"}},
+			Capacity:         resource.NewQuantity(20*1024*1024*1024, resource.BinarySI),
+		},
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "default"},
+			Spec: v1.PersistentVolumeClaimSpec{
+				StorageClassName: &storageClass,
+				AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		},
+	)
+	informerFactory := informerFactory(clientSet)
+	handle := support.NewFrameworkHandle(lister(), informerFactory, clientSet)
+	ep := enabledPlugins(volumebinding.Name)
+	predicateManager := newPredicateManagerInternal(handle, ep, ep, ep, ep)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", UID: "pod"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"},
+				},
+			}},
+		},
+	}
+	newNode := func(zone string) *framework.NodeInfo {
+		node := framework.NewNodeInfo()
+		node.SetNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-" + zone, Labels: map[string]string{"zone": zone}},
+		})
+		return node
+	}
+
+	// the CSI driver reports 5Gi in zone a, not enough for the 10Gi claim
+	plugin, err := predicateManager.Predicates(pod, newNode("a"), true)
+	assert.ErrorContains(t, err, "did not have enough free storage")
+	assert.Equal(t, plugin, volumebinding.Name)
+	plugin, err = predicateManager.Predicates(pod, newNode("b"), true)
+	assert.NilError(t, err)
+	assert.Equal(t, plugin, "")
+	// no capacity is reported for zone c
+	_, err = predicateManager.Predicates(pod, newNode("c"), true)
+	assert.ErrorContains(t, err, "did not have enough free storage")
+}
+
 func lister() *sharedListerMock {
 	return &sharedListerMock{
 		nodeLister: &nodeListerMock{
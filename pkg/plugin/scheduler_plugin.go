@@ -70,9 +70,22 @@ const (
 // If a pending or in-progress allocation is detected for a pod in PreFilter(), we remove the allocation and force the
 // pod to be rescheduled, as this means the prior allocation could not be completed successfully by the default
 // scheduler for some reason.
+//
+// Filter Cache:
+//
+// Filter() is called for each candidate node of a pod in every scheduling cycle. Rejections are cached per pod and
+// node generation so repeated calls do not re-evaluate the YuniKorn state. The cached results of a pod are removed
+// when the pod is released with a new allocation, is bound, or fails scheduling.
+//
+// Volumes:
+//
+// YuniKorn checks that the volumes of a pod can be bound on the node it selects, but does not assume or bind the
+// volumes itself. The VolumeBinding plugin of the default scheduler assumes the volumes in Reserve and binds them in
+// PreBind, as it does for pods of the default scheduler. The plugin must not be disabled in the scheduler profile.
 type YuniKornSchedulerPlugin struct {
 	sync.RWMutex
-	context *cache.Context
+	context     *cache.Context
+	filterCache *filterCache
 }
 
 // ensure all required interfaces are implemented
@@ -169,6 +182,7 @@ func (sp *YuniKornSchedulerPlugin) PreFilter(_ context.Context, _ *framework.Cyc
 				zap.String("pod", pod.Name),
 				zap.String("taskID", taskID),
 				zap.String("assignedNode", nodeID))
+			sp.filterCache.invalidate(taskID)
 			return &framework.PreFilterResult{NodeNames: sets.NewString(nodeID)}, nil
 		}
 	}
@@ -198,24 +212,30 @@ func (sp *YuniKornSchedulerPlugin) Filter(_ context.Context, _ *framework.CycleS
 	}
 
 	taskID := string(pod.UID)
+	nodeName := nodeInfo.Node().Name
+	if status, ok := sp.filterCache.get(taskID, nodeName, nodeInfo.Generation); ok {
+		return status
+	}
 	if _, task, ok := sp.getTask(appID, taskID); ok {
 		if task.GetTaskState() == cache.TaskStates().Bound {
 			// attempt to start a pod allocation. Filter() gets called once per {Pod,Node} candidate; we only want
 			// to proceed in the case where the Node we are asked about matches the one YuniKorn has selected.
 			// this check is fairly cheap (one map lookup); if we fail the check here the scheduling framework will
 			// immediately call Filter() again with a different candidate Node.
-			if sp.context.StartPodAllocation(taskID, nodeInfo.Node().Name) {
+			if sp.context.StartPodAllocation(taskID, nodeName) {
 				log.Log(log.ShimSchedulerPlugin).Info("Releasing pod for scheduling (Filter phase)",
 					zap.String("namespace", pod.Namespace),
 					zap.String("pod", pod.Name),
 					zap.String("taskID", taskID),
-					zap.String("assignedNode", nodeInfo.Node().Name))
+					zap.String("assignedNode", nodeName))
 				return nil
 			}
 		}
 	}
 
-	return framework.NewStatus(framework.UnschedulableAndUnresolvable, "Pod is not fit for node")
+	status := framework.NewStatus(framework.UnschedulableAndUnresolvable, "Pod is not fit for node")
+	sp.filterCache.add(taskID, nodeName, nodeInfo.Generation, status)
+	return status
 }
 
 func (sp *YuniKornSchedulerPlugin) EventsToRegister() []framework.ClusterEvent {
@@ -246,6 +266,7 @@ func (sp *YuniKornSchedulerPlugin) PostBind(_ context.Context, _ *framework.Cycl
 			zap.String("taskID", taskID),
 			zap.String("assignedNode", nodeName))
 		sp.context.RemovePodAllocation(taskID)
+		sp.filterCache.invalidate(taskID)
 	}
 }
 
@@ -269,10 +290,14 @@ func NewSchedulerPlugin(_ runtime.Object, handle framework.Handle) (framework.Pl
 		// we need our own informer factory here because the informers we get from the framework handle aren't yet initialized
 		informerFactory := informers.NewSharedInformerFactory(handle.ClientSet(), 0)
 		ss := shim.NewShimSchedulerForPlugin(sa, informerFactory, conf.GetSchedulerConf(), configMaps)
+		// the extenders only see the node selected by the core in the default scheduler cycle,
+		// run their filters as part of the predicates so the core can pick another node if rejected
+		ss.GetContext().SetExtenders(handle.Extenders())
 		ss.Run()
 
 		p := &YuniKornSchedulerPlugin{
-			context: ss.GetContext(),
+			context:     ss.GetContext(),
+			filterCache: newFilterCache(),
 		}
 		events.SetRecorder(handle.EventRecorder())
 		return p, nil
@@ -297,6 +322,7 @@ func (sp *YuniKornSchedulerPlugin) failTask(pod *v1.Pod, app interfaces.ManagedA
 		zap.String("pod", pod.Name),
 		zap.String("taskID", taskID))
 	sp.context.RemovePodAllocation(taskID)
+	sp.filterCache.invalidate(taskID)
 	dispatcher.Dispatch(cache.NewRejectTaskEvent(app.GetApplicationID(), taskID, fmt.Sprintf("task %s rejected by scheduler", taskID)))
 	task.SetTaskSchedulingState(interfaces.TaskSchedFailed)
 }
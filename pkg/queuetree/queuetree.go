@@ -0,0 +1,265 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package queuetree renders the queue hierarchy of a partition with the guaranteed, max and used resources of each
+// queue. The tree combines the configured limits with the live usage reported by the core and can be rendered as
+// JSON, as a graphviz DOT graph or as an SVG image, which does not need graphviz to be installed.
+package queuetree
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+// supported output formats
+const (
+	FormatJSON = "json"
+	FormatDOT  = "dot"
+	FormatSVG  = "svg"
+)
+
+// usage ratios at which a queue is highlighted
+const (
+	warnUsage = 0.8
+	fullUsage = 1.0
+)
+
+// Queue is a node in the queue tree. Resources are in the units used by the core.
+type Queue struct {
+	Name        string           `json:"name"`
+	Status      string           `json:"status,omitempty"`
+	Leaf        bool             `json:"leaf"`
+	Dynamic     bool             `json:"dynamic"`
+	Guaranteed  map[string]int64 `json:"guaranteed,omitempty"`
+	Max         map[string]int64 `json:"max,omitempty"`
+	Used        map[string]int64 `json:"used,omitempty"`
+	Pending     map[string]int64 `json:"pending,omitempty"`
+	RunningApps uint64           `json:"runningApps"`
+	Children    []*Queue         `json:"children,omitempty"`
+}
+
+// Build converts the queue information from the core into a tree, children are sorted by name
+func Build(info *dao.PartitionQueueDAOInfo) *Queue {
+	queue := &Queue{
+		Name:        info.QueueName,
+		Status:      info.Status,
+		Leaf:        info.IsLeaf,
+		Dynamic:     !info.IsManaged,
+		Guaranteed:  info.GuaranteedResource,
+		Max:         info.MaxResource,
+		Used:        info.AllocatedResource,
+		Pending:     info.PendingResource,
+		RunningApps: info.RunningApps,
+	}
+	for i := range info.Children {
+		queue.Children = append(queue.Children, Build(&info.Children[i]))
+	}
+	sort.Slice(queue.Children, func(i, j int) bool {
+		return queue.Children[i].Name < queue.Children[j].Name
+	})
+	return queue
+}
+
+// usage returns the highest ratio of used to max resource over all limited resource types, -1 if not limited
+func (q *Queue) usage() float64 {
+	ratio := -1.0
+	for name, max := range q.Max {
+		if max <= 0 {
+			continue
+		}
+		if r := float64(q.Used[name]) / float64(max); r > ratio {
+			ratio = r
+		}
+	}
+	return ratio
+}
+
+// shortName returns the last element of the queue path
+func (q *Queue) shortName() string {
+	return q.Name[strings.LastIndex(q.Name, ".")+1:]
+}
+
+// ContentType returns the content type of the format, empty if the format is not supported
+func ContentType(format string) string {
+	switch format {
+	case FormatJSON:
+		return "application/json; charset=UTF-8"
+	case FormatDOT:
+		return "text/vnd.graphviz; charset=UTF-8"
+	case FormatSVG:
+		return "image/svg+xml"
+	default:
+		return ""
+	}
+}
+
+// Render writes the tree in the given format
+func Render(w io.Writer, root *Queue, format string) error {
+	switch format {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(root)
+	case FormatDOT:
+		_, err := io.WriteString(w, RenderDOT(root))
+		return err
+	case FormatSVG:
+		_, err := io.WriteString(w, RenderSVG(root))
+		return err
+	default:
+		return fmt.Errorf("unsupported format %s", format)
+	}
+}
+
+// formatResource returns the resource as a sorted list of name: value pairs, "-" if empty
+func formatResource(resource map[string]int64) string {
+	if len(resource) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(resource))
+	for name := range resource {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %d", name, resource[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fillColor returns the colour of a queue based on the usage
+func fillColor(usage float64) string {
+	switch {
+	case usage >= fullUsage:
+		return "#f4a6a6"
+	case usage >= warnUsage:
+		return "#f9d71c"
+	default:
+		return "#ffffff"
+	}
+}
+
+// RenderDOT renders the tree as a graphviz digraph, each queue is a record with its resources
+func RenderDOT(root *Queue) string {
+	var sb strings.Builder
+	sb.WriteString("digraph queues {\n")
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=record, style=filled, fontname=\"Helvetica\"];\n")
+	writeDOT(&sb, root)
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func writeDOT(sb *strings.Builder, q *Queue) {
+	style := "filled"
+	if q.Dynamic {
+		style = "\"filled,dashed\""
+	}
+	fmt.Fprintf(sb, "  %q [label=\"{%s|guaranteed: %s\\l|max: %s\\l|used: %s\\l|pending: %s\\l}\", fillcolor=%q, style=%s];\n",
+		q.Name, escapeDOT(q.Name), escapeDOT(formatResource(q.Guaranteed)), escapeDOT(formatResource(q.Max)),
+		escapeDOT(formatResource(q.Used)), escapeDOT(formatResource(q.Pending)), fillColor(q.usage()), style)
+	for _, child := range q.Children {
+		writeDOT(sb, child)
+		fmt.Fprintf(sb, "  %q -> %q;\n", q.Name, child.Name)
+	}
+}
+
+// escapeDOT escapes the characters with a special meaning in record labels
+func escapeDOT(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `{`, `\{`, `}`, `\}`, `|`, `\|`, `<`, `\<`, `>`, `\>`)
+	return replacer.Replace(value)
+}
+
+// svg layout: every queue is a row, children are indented below their parent
+const (
+	svgIndent    = 24
+	svgRowHeight = 64
+	svgBoxHeight = 52
+	svgBoxWidth  = 520
+	svgBarWidth  = 120
+	svgMargin    = 10
+)
+
+// RenderSVG renders the tree as an indented list of queue boxes. Each box shows the resources of the queue and a
+// bar with the usage of the most used limited resource, queues without a max resource have no bar.
+func RenderSVG(root *Queue) string {
+	rows := 0
+	maxDepth := 0
+	var count func(q *Queue, depth int)
+	count = func(q *Queue, depth int) {
+		rows++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		for _, child := range q.Children {
+			count(child, depth+1)
+		}
+	}
+	count(root, 0)
+
+	width := 2*svgMargin + maxDepth*svgIndent + svgBoxWidth
+	height := 2*svgMargin + rows*svgRowHeight
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"Helvetica\" font-size=\"11\">\n", width, height)
+	row := 0
+	writeSVG(&sb, root, 0, &row)
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// writeSVG writes the queue and its children and returns the vertical centre of the queue box
+func writeSVG(sb *strings.Builder, q *Queue, depth int, row *int) int {
+	x := svgMargin + depth*svgIndent
+	y := svgMargin + *row*svgRowHeight
+	*row++
+	dash := ""
+	if q.Dynamic {
+		dash = ` stroke-dasharray="4,2"`
+	}
+	usage := q.usage()
+	fmt.Fprintf(sb, "  <g><title>%s</title>\n", html.EscapeString(q.Name))
+	fmt.Fprintf(sb, "    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"4\" fill=\"%s\" stroke=\"#555555\"%s/>\n",
+		x, y, svgBoxWidth, svgBoxHeight, fillColor(usage), dash)
+	fmt.Fprintf(sb, "    <text x=\"%d\" y=\"%d\" font-weight=\"bold\">%s</text>\n", x+6, y+14, html.EscapeString(q.shortName()))
+	fmt.Fprintf(sb, "    <text x=\"%d\" y=\"%d\">guaranteed: %s | max: %s</text>\n", x+6, y+30,
+		html.EscapeString(formatResource(q.Guaranteed)), html.EscapeString(formatResource(q.Max)))
+	fmt.Fprintf(sb, "    <text x=\"%d\" y=\"%d\">used: %s | running apps: %d</text>\n", x+6, y+45,
+		html.EscapeString(formatResource(q.Used)), q.RunningApps)
+	if usage >= 0 {
+		barX := x + svgBoxWidth - svgBarWidth - 6
+		filled := int(float64(svgBarWidth) * usage)
+		if filled > svgBarWidth {
+			filled = svgBarWidth
+		}
+		fmt.Fprintf(sb, "    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"8\" fill=\"#eeeeee\" stroke=\"#999999\"/>\n", barX, y+6, svgBarWidth)
+		fmt.Fprintf(sb, "    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"8\" fill=\"#4a90d9\"/>\n", barX, y+6, filled)
+		fmt.Fprintf(sb, "    <text x=\"%d\" y=\"%d\" text-anchor=\"end\">%d%%</text>\n", barX-4, y+14, int(usage*100))
+	}
+	sb.WriteString("  </g>\n")
+	lineX := x + svgIndent/2
+	for _, child := range q.Children {
+		childY := writeSVG(sb, child, depth+1, row)
+		fmt.Fprintf(sb, "  <path d=\"M%d %d V%d H%d\" fill=\"none\" stroke=\"#999999\"/>\n", lineX, y+svgBoxHeight, childY, lineX+svgIndent/2)
+	}
+	return y + svgBoxHeight/2
+}
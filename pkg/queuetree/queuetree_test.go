@@ -0,0 +1,114 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package queuetree
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+func queuesForTest() *dao.PartitionQueueDAOInfo {
+	return &dao.PartitionQueueDAOInfo{
+		QueueName:         "root",
+		IsManaged:         true,
+		MaxResource:       map[string]int64{"memory": 100, "vcore": 10},
+		AllocatedResource: map[string]int64{"memory": 90, "vcore": 2},
+		Children: []dao.PartitionQueueDAOInfo{
+			{
+				QueueName:          "root.b",
+				IsManaged:          false,
+				IsLeaf:             true,
+				AllocatedResource:  map[string]int64{"memory": 10},
+				GuaranteedResource: map[string]int64{"memory": 20},
+			},
+			{
+				QueueName:         "root.a",
+				IsManaged:         true,
+				IsLeaf:            true,
+				MaxResource:       map[string]int64{"memory": 50},
+				AllocatedResource: map[string]int64{"memory": 80},
+				RunningApps:       2,
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	root := Build(queuesForTest())
+	assert.Equal(t, root.Name, "root")
+	assert.Assert(t, !root.Dynamic)
+	assert.Equal(t, len(root.Children), 2)
+	// sorted by name
+	assert.Equal(t, root.Children[0].Name, "root.a")
+	assert.Equal(t, root.Children[0].RunningApps, uint64(2))
+	assert.Assert(t, root.Children[1].Dynamic)
+	assert.Equal(t, root.Children[1].Guaranteed["memory"], int64(20))
+
+	assert.Equal(t, root.usage(), 0.9)
+	assert.Equal(t, root.Children[0].usage(), 1.6)
+	assert.Equal(t, root.Children[1].usage(), -1.0)
+	assert.Equal(t, root.Children[0].shortName(), "a")
+}
+
+func TestRender(t *testing.T) {
+	root := Build(queuesForTest())
+
+	var buf bytes.Buffer
+	assert.NilError(t, Render(&buf, root, FormatJSON))
+	decoded := &Queue{}
+	assert.NilError(t, json.Unmarshal(buf.Bytes(), decoded))
+	assert.DeepEqual(t, decoded, root)
+
+	dot := RenderDOT(root)
+	assert.Assert(t, strings.HasPrefix(dot, "digraph queues {"))
+	assert.Assert(t, strings.Contains(dot, `"root" -> "root.a";`), dot)
+	assert.Assert(t, strings.Contains(dot, `max: memory: 100, vcore: 10\l`), dot)
+	assert.Assert(t, strings.Contains(dot, `"root.b" [label="{root.b|guaranteed: memory: 20\l|max: -\l`), dot)
+	assert.Assert(t, strings.Contains(dot, `style="filled,dashed"`), dot)
+	assert.Assert(t, strings.Contains(dot, `fillcolor="#f4a6a6"`), "over max queue must be highlighted")
+
+	// the svg must be well-formed xml
+	svg := RenderSVG(root)
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err != nil {
+			assert.Equal(t, err.Error(), "EOF", svg)
+			break
+		}
+	}
+	assert.Assert(t, strings.Contains(svg, "<title>root.a</title>"), svg)
+	assert.Assert(t, strings.Contains(svg, ">160%</text>"), svg)
+	assert.Assert(t, strings.Contains(svg, `stroke-dasharray`), svg)
+
+	assert.ErrorContains(t, Render(&buf, root, "png"), "unsupported format png")
+	assert.Equal(t, ContentType("png"), "")
+	assert.Equal(t, ContentType(FormatSVG), "image/svg+xml")
+}
+
+func TestEscapeDOT(t *testing.T) {
+	assert.Equal(t, escapeDOT(`a{b}|<c>"d"\`), `a\{b\}\|\<c\>\"d\"\\`)
+}
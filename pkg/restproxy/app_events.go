@@ -0,0 +1,48 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// AppEvents is the event log of an application returned by the application events endpoint
+type AppEvents struct {
+	ApplicationID string           `json:"applicationID"`
+	Events        []cache.AppEvent `json:"events"`
+}
+
+// serveAppEvents returns the events the shim recorded for the application, oldest first
+func (p *RESTProxy) serveAppEvents(w http.ResponseWriter, appID string) {
+	events, ok := p.snapshot.GetApplicationEventLog(appID)
+	if !ok {
+		http.Error(w, "no events for application "+appID, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(AppEvents{ApplicationID: appID, Events: events}); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write application events response", zap.Error(err))
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestServeAppEvents(t *testing.T) {
+	request := func(proxy *RESTProxy, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// without a provider the endpoint is not served
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+	rec := request(proxy, "/debug/application/app-1/events", validToken)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	proxy, err = NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), mockSnapshotProvider{})
+	assert.NilError(t, err, "proxy creation failed")
+	rec = request(proxy, "/debug/application/app-1/events", "other-token")
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	rec = request(proxy, "/debug/application/app-1/events", validToken)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	events := AppEvents{}
+	assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	assert.Equal(t, events.ApplicationID, "app-1")
+	assert.Equal(t, len(events.Events), 2)
+	assert.Equal(t, events.Events[0].Type, "Submitted")
+	assert.Equal(t, events.Events[1].TaskID, "task-1")
+
+	rec = request(proxy, "/debug/application/unknown/events", validToken)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+	rec = request(proxy, "/debug/application/app-1", validToken)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
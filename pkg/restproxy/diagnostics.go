@@ -0,0 +1,101 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strings"
+
+	"go.uber.org/zap"
+	authnv1 "k8s.io/api/authentication/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// The diagnostics endpoints are served by the proxy itself and only when enabled in the configuration:
+//
+//	/debug/pprof/       the standard runtime profiles, see net/http/pprof
+//	/debug/vars         the published expvar variables
+//	/debug/goroutines   a plain text dump of the stacks of all goroutines
+//
+// Besides the normal Kubernetes authentication and authorization the endpoints accept the static
+// diagnostics token from the configuration. The token allows profiling a shim that cannot reach the API server.
+const (
+	pprofPath      = "/debug/pprof/"
+	varsPath       = "/debug/vars"
+	goroutinesPath = "/debug/goroutines"
+)
+
+// diagnosticsTokenUser is the user reported for requests that authenticated with the diagnostics token
+const diagnosticsTokenUser = "yunikorn:diagnostics-token"
+
+func isDiagnosticsPath(path string) bool {
+	if path != varsPath && path != goroutinesPath && !strings.HasPrefix(path, pprofPath) {
+		return false
+	}
+	return conf.GetSchedulerConf().IsDiagnosticsEnabled()
+}
+
+// hasDiagnosticsToken returns true if the bearer token of the request matches the configured diagnostics token.
+// No request matches if the token is not configured.
+func hasDiagnosticsToken(r *http.Request) bool {
+	expected := conf.GetSchedulerConf().GetDiagnosticsToken()
+	header := r.Header.Get("Authorization")
+	if expected == "" || !strings.HasPrefix(header, bearerPrefix) {
+		return false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+func serveDiagnostics(w http.ResponseWriter, r *http.Request, user *authnv1.UserInfo) {
+	log.Log(log.ShimRESTProxy).Debug("serving diagnostics",
+		zap.String("user", user.Username),
+		zap.String("path", r.URL.Path))
+	switch r.URL.Path {
+	case varsPath:
+		expvar.Handler().ServeHTTP(w, r)
+	case goroutinesPath:
+		serveGoroutines(w)
+	case pprofPath + "cmdline":
+		pprof.Cmdline(w, r)
+	case pprofPath + "profile":
+		pprof.Profile(w, r)
+	case pprofPath + "symbol":
+		pprof.Symbol(w, r)
+	case pprofPath + "trace":
+		pprof.Trace(w, r)
+	default:
+		// the index serves the named runtime profiles, like heap or goroutine, and the list of profiles
+		pprof.Index(w, r)
+	}
+}
+
+func serveGoroutines(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	// debug level 2 prints the stacks in the same format as an unrecovered panic
+	if err := runtimepprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write goroutine dump", zap.Error(err))
+	}
+}
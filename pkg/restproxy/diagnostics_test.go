@@ -0,0 +1,97 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+const diagnosticsToken = "diagnostics-token"
+
+func TestServeDiagnostics(t *testing.T) {
+	defer func() {
+		assert.NilError(t, conf.UpdateConfigMaps([]*v1.ConfigMap{nil}, true), "UpdateConfigMap reset failed")
+	}()
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		path     string
+		token    string
+		status   int
+		contains string
+	}{
+		{"disabled", false, goroutinesPath, validToken, http.StatusNotFound, ""},
+		{"disabled token", false, varsPath, diagnosticsToken, http.StatusNotFound, ""},
+		{"goroutines", true, goroutinesPath, validToken, http.StatusOK, "goroutine "},
+		{"vars", true, varsPath, validToken, http.StatusOK, "memstats"},
+		{"pprof index", true, pprofPath, validToken, http.StatusOK, "heap"},
+		{"pprof profile", true, pprofPath + "goroutine?debug=1", validToken, http.StatusOK, "goroutine profile"},
+		{"diagnostics token", true, goroutinesPath, diagnosticsToken, http.StatusOK, "goroutine "},
+		{"forbidden", true, goroutinesPath, "other-token", http.StatusForbidden, ""},
+		{"invalid", true, varsPath, "invalid", http.StatusUnauthorized, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err = conf.UpdateConfigMaps([]*v1.ConfigMap{{Data: map[string]string{
+				conf.CMSvcDiagnostics:      strconv.FormatBool(tc.enabled),
+				conf.CMSvcDiagnosticsToken: diagnosticsToken,
+			}}}, true)
+			assert.NilError(t, err, "UpdateConfigMap failed")
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			proxy.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, tc.status, "unexpected status")
+			if tc.contains != "" {
+				assert.Assert(t, strings.Contains(rec.Body.String(), tc.contains), "unexpected body: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHasDiagnosticsToken(t *testing.T) {
+	defer func() {
+		assert.NilError(t, conf.UpdateConfigMaps([]*v1.ConfigMap{nil}, true), "UpdateConfigMap reset failed")
+	}()
+	req := httptest.NewRequest(http.MethodGet, goroutinesPath, nil)
+	req.Header.Set("Authorization", "Bearer ")
+	assert.Assert(t, !hasDiagnosticsToken(req), "empty token must not match an unset token")
+
+	err := conf.UpdateConfigMaps([]*v1.ConfigMap{{Data: map[string]string{
+		conf.CMSvcDiagnosticsToken: diagnosticsToken,
+	}}}, true)
+	assert.NilError(t, err, "UpdateConfigMap failed")
+	assert.Assert(t, !hasDiagnosticsToken(req), "empty token must not match")
+	req.Header.Set("Authorization", "Bearer "+diagnosticsToken)
+	assert.Assert(t, hasDiagnosticsToken(req), "token should match")
+	req.Header.Set("Authorization", diagnosticsToken)
+	assert.Assert(t, !hasDiagnosticsToken(req), "token without bearer prefix must not match")
+}
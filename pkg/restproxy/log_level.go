@@ -0,0 +1,66 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+	authnv1 "k8s.io/api/authentication/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// logLevelPath is served by the proxy itself, it allows changing the log levels of the shim at runtime:
+//
+//	GET    returns the effective level of each logger, the default level uses the empty name
+//	PUT    sets the level of a logger: ?logger={name}&level={level}, an empty or missing logger sets the default
+//	DELETE removes all runtime changes and returns to the levels configured in the ConfigMap
+//
+// Runtime changes take precedence over the log levels in the ConfigMap until they are removed.
+const logLevelPath = "/debug/loglevel"
+
+func isLogLevelUpdate(method string) bool {
+	return method == http.MethodPut || method == http.MethodDelete
+}
+
+func serveLogLevel(w http.ResponseWriter, r *http.Request, user *authnv1.UserInfo) {
+	switch r.Method {
+	case http.MethodPut:
+		name := r.URL.Query().Get("logger")
+		level := r.URL.Query().Get("level")
+		if err := log.SetLoggerLevel(name, level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Log(log.ShimRESTProxy).Info("log level changed",
+			zap.String("user", user.Username),
+			zap.String("logger", name),
+			zap.String("level", level))
+	case http.MethodDelete:
+		log.ResetLoggerLevels()
+		log.Log(log.ShimRESTProxy).Info("log levels reset",
+			zap.String("user", user.Username))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(log.GetLoggerLevels()); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write log level response", zap.Error(err))
+	}
+}
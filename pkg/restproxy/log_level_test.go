@@ -0,0 +1,67 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+func TestServeLogLevel(t *testing.T) {
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+	defer log.ResetLoggerLevels()
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		token  string
+		status int
+		level  string
+	}{
+		{"get", http.MethodGet, "", validToken, http.StatusOK, "info"},
+		{"set", http.MethodPut, "?logger=shim.dispatcher&level=debug", validToken, http.StatusOK, "debug"},
+		{"invalid level", http.MethodPut, "?logger=shim.dispatcher&level=loud", validToken, http.StatusBadRequest, ""},
+		{"forbidden", http.MethodPut, "?logger=shim.dispatcher&level=error", "other-token", http.StatusForbidden, ""},
+		{"after forbidden", http.MethodGet, "", validToken, http.StatusOK, "debug"},
+		{"post", http.MethodPost, "", validToken, http.StatusMethodNotAllowed, ""},
+		{"reset", http.MethodDelete, "", validToken, http.StatusOK, "info"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, logLevelPath+tc.query, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			proxy.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, tc.status, "unexpected status")
+			if tc.level != "" {
+				levels := make(map[string]string)
+				assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &levels), "response is not a level map")
+				assert.Equal(t, levels[log.ShimDispatcher.String()], tc.level, "unexpected dispatcher level")
+			}
+		})
+	}
+}
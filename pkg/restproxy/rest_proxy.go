@@ -0,0 +1,363 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/headroom"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-k8shim/pkg/queuetree"
+)
+
+// CoreWebServiceURL is the address of the REST API of the scheduler core running in the same process
+const CoreWebServiceURL = "http://localhost:9080"
+
+const bearerPrefix = "Bearer "
+
+// allowedPaths lists the read-only scheduler core endpoints that are exposed through the proxy
+var allowedPaths = []*regexp.Regexp{
+	regexp.MustCompile(`^/ws/v1/partitions$`),
+	regexp.MustCompile(`^/ws/v1/partition/[^/]+/queues$`),
+	regexp.MustCompile(`^/ws/v1/partition/[^/]+/nodes$`),
+	regexp.MustCompile(`^/ws/v1/partition/[^/]+/node/[^/]+$`),
+	regexp.MustCompile(`^/ws/v1/partition/[^/]+/queue/[^/]+/applications$`),
+	regexp.MustCompile(`^/ws/v1/partition/[^/]+/queue/[^/]+/application/[^/]+$`),
+}
+
+// headroomPath is served by the proxy itself, the headroom is calculated from the queue information of the core
+var headroomPath = regexp.MustCompile(`^/ws/v1/partition/([^/]+)/queue/([^/]+)/headroom$`)
+
+// queueTreePath is served by the proxy itself, it renders the queue hierarchy with the resources of each queue.
+// The format query parameter selects json (default), dot or svg.
+var queueTreePath = regexp.MustCompile(`^/ws/v1/partition/([^/]+)/queuetree$`)
+
+// snapshotPath is served by the proxy itself, it returns the internal state of the shim and the core configuration
+const snapshotPath = "/debug/snapshot"
+
+// appEventsPath is served by the proxy itself, it returns the event log the shim keeps for the application
+var appEventsPath = regexp.MustCompile(`^/debug/application/([^/]+)/events$`)
+
+// versionPath is served by the proxy itself, it returns the version information and enabled features of the shim
+const versionPath = "/version"
+
+// readyPath is served by the proxy itself without authentication, it is used as the readiness probe of the scheduler
+const readyPath = "/ready"
+
+// SnapshotProvider returns the internal state of the shim
+type SnapshotProvider interface {
+	GetSnapshot() cache.SnapshotDao
+	GetApplicationEventLog(appID string) ([]cache.AppEvent, bool)
+}
+
+// RESTProxy exposes a selected set of scheduler core REST endpoints to cluster users.
+// Callers authenticate with a Kubernetes bearer token which is verified using a TokenReview.
+// Access is authorized using a SubjectAccessReview for the non-resource URL of the request,
+// which means access is granted via a (Cluster)Role with matching nonResourceURLs and the get verb.
+type RESTProxy struct {
+	clientSet kubernetes.Interface
+	proxy     *httputil.ReverseProxy
+	headroom  *headroom.Client
+	snapshot  SnapshotProvider
+	coreURL   string
+	ready     func() error
+	server    *http.Server
+}
+
+// NewRESTProxy creates a proxy listening on the given address that forwards requests to the core REST API.
+// The snapshot and application event endpoints are only served if a snapshot provider is set.
+func NewRESTProxy(listenAddress, coreURL string, clientSet kubernetes.Interface, snapshot SnapshotProvider) (*RESTProxy, error) {
+	origin, err := url.Parse(coreURL)
+	if err != nil {
+		return nil, err
+	}
+	p := &RESTProxy{
+		clientSet: clientSet,
+		proxy:     httputil.NewSingleHostReverseProxy(origin),
+		headroom:  headroom.NewClient(coreURL),
+		snapshot:  snapshot,
+		coreURL:   strings.TrimSuffix(coreURL, "/"),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/ws/", p)
+	mux.Handle(snapshotPath, p)
+	mux.Handle("/debug/application/", p)
+	mux.Handle(logLevelPath, p)
+	mux.Handle(versionPath, p)
+	mux.Handle(pprofPath, p)
+	mux.Handle(varsPath, p)
+	mux.Handle(goroutinesPath, p)
+	mux.HandleFunc(readyPath, p.serveReady)
+	p.server = &http.Server{
+		Addr:              listenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return p, nil
+}
+
+// SetReadinessCheck sets the check used by the readiness endpoint, the scheduler is not ready while it returns an error
+func (p *RESTProxy) SetReadinessCheck(check func() error) {
+	p.ready = check
+}
+
+// serveReady returns 200 if the scheduler is ready and 503 with the reason otherwise
+func (p *RESTProxy) serveReady(w http.ResponseWriter, _ *http.Request) {
+	if p.ready != nil {
+		if err := p.ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (p *RESTProxy) Start() {
+	log.Log(log.ShimRESTProxy).Info("starting REST proxy", zap.String("address", p.server.Addr))
+	go func() {
+		if err := p.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Log(log.ShimRESTProxy).Error("REST proxy failed", zap.Error(err))
+		}
+	}()
+}
+
+func (p *RESTProxy) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.server.Shutdown(ctx); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to stop REST proxy", zap.Error(err))
+	}
+}
+
+// ServeHTTP checks the request against the allowed endpoints, authenticates and authorizes the caller
+// and forwards the request to the scheduler core.
+func (p *RESTProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	isLogLevel := r.URL.Path == logLevelPath
+	isDiagnostics := isDiagnosticsPath(r.URL.Path)
+	if r.Method != http.MethodGet && !(isLogLevel && isLogLevelUpdate(r.Method)) {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAllowedPath(r.URL.Path) && !headroomPath.MatchString(r.URL.Path) && !queueTreePath.MatchString(r.URL.Path) &&
+		!p.isSnapshotPath(r.URL.Path) && !p.isAppEventsPath(r.URL.Path) && !isLogLevel && r.URL.Path != versionPath &&
+		!isDiagnostics {
+		http.Error(w, "endpoint not exposed", http.StatusNotFound)
+		return
+	}
+	var user *authnv1.UserInfo
+	if isDiagnostics && hasDiagnosticsToken(r) {
+		user = &authnv1.UserInfo{Username: diagnosticsTokenUser}
+	} else if user = p.checkAccess(w, r); user == nil {
+		return
+	}
+	if match := headroomPath.FindStringSubmatch(r.URL.Path); match != nil {
+		p.serveHeadroom(w, r, match[1], match[2])
+		return
+	}
+	if match := queueTreePath.FindStringSubmatch(r.URL.Path); match != nil {
+		p.serveQueueTree(w, r, match[1])
+		return
+	}
+	if p.isSnapshotPath(r.URL.Path) {
+		p.serveSnapshot(w, r)
+		return
+	}
+	if match := appEventsPath.FindStringSubmatch(r.URL.Path); match != nil {
+		p.serveAppEvents(w, match[1])
+		return
+	}
+	if isLogLevel {
+		serveLogLevel(w, r, user)
+		return
+	}
+	if r.URL.Path == versionPath {
+		serveVersion(w)
+		return
+	}
+	if isDiagnostics {
+		serveDiagnostics(w, r, user)
+		return
+	}
+	// the token is meant for the proxy only, do not forward it to the core
+	r.Header.Del("Authorization")
+	p.proxy.ServeHTTP(w, r)
+}
+
+// checkAccess authenticates and authorizes the caller, the user is nil if access is not granted.
+// The error response has been written if the user is nil.
+func (p *RESTProxy) checkAccess(w http.ResponseWriter, r *http.Request) *authnv1.UserInfo {
+	user, err := p.authenticate(r)
+	if err != nil {
+		log.Log(log.ShimRESTProxy).Debug("authentication failed", zap.String("path", r.URL.Path), zap.Error(err))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+	allowed, err := p.authorize(r, user)
+	if err != nil {
+		log.Log(log.ShimRESTProxy).Warn("authorization check failed", zap.String("path", r.URL.Path), zap.Error(err))
+		http.Error(w, "authorization check failed", http.StatusInternalServerError)
+		return nil
+	}
+	if !allowed {
+		log.Log(log.ShimRESTProxy).Debug("access denied",
+			zap.String("user", user.Username),
+			zap.String("path", r.URL.Path))
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil
+	}
+	return user
+}
+
+func (p *RESTProxy) serveHeadroom(w http.ResponseWriter, r *http.Request, partition, queueName string) {
+	result, err := p.headroom.GetQueueHeadroom(r.Context(), partition, queueName)
+	if err != nil {
+		log.Log(log.ShimRESTProxy).Debug("headroom request failed",
+			zap.String("partition", partition),
+			zap.String("queue", queueName),
+			zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write headroom response", zap.Error(err))
+	}
+}
+
+func (p *RESTProxy) serveQueueTree(w http.ResponseWriter, r *http.Request, partition string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = queuetree.FormatJSON
+	}
+	contentType := queuetree.ContentType(format)
+	if contentType == "" {
+		http.Error(w, "unsupported format: "+format, http.StatusBadRequest)
+		return
+	}
+	root, err := p.headroom.GetQueues(r.Context(), partition)
+	if err != nil {
+		log.Log(log.ShimRESTProxy).Debug("queue tree request failed",
+			zap.String("partition", partition),
+			zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if err = queuetree.Render(w, queuetree.Build(root), format); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write queue tree response", zap.Error(err))
+	}
+}
+
+func serveVersion(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(conf.GetVersionInfo()); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write version response", zap.Error(err))
+	}
+}
+
+func (p *RESTProxy) isSnapshotPath(path string) bool {
+	return p.snapshot != nil && path == snapshotPath
+}
+
+func (p *RESTProxy) isAppEventsPath(path string) bool {
+	return p.snapshot != nil && appEventsPath.MatchString(path)
+}
+
+func (p *RESTProxy) authenticate(r *http.Request) (*authnv1.UserInfo, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return nil, errors.New("missing bearer token")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	if token == "" {
+		return nil, errors.New("empty bearer token")
+	}
+	review := &authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: token},
+	}
+	result, err := p.clientSet.AuthenticationV1().TokenReviews().Create(r.Context(), review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Status.Authenticated {
+		return nil, errors.New("token not authenticated: " + result.Status.Error)
+	}
+	return &result.Status.User, nil
+}
+
+func (p *RESTProxy) authorize(r *http.Request, user *authnv1.UserInfo) (bool, error) {
+	extra := make(map[string]authzv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+	review := &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			NonResourceAttributes: &authzv1.NonResourceAttributes{
+				Path: r.URL.Path,
+				Verb: requestVerb(r.Method),
+			},
+		},
+	}
+	result, err := p.clientSet.AuthorizationV1().SubjectAccessReviews().Create(r.Context(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+// requestVerb maps the HTTP method to the verb used for the non-resource URL authorization
+func requestVerb(method string) string {
+	switch method {
+	case http.MethodPut:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "get"
+	}
+}
+
+func isAllowedPath(path string) bool {
+	for _, re := range allowedPaths {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
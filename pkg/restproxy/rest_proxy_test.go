@@ -0,0 +1,246 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+)
+
+const (
+	validToken  = "valid-token"
+	adminUser   = "admin"
+	regularUser = "user"
+	coreBody    = `[{"queuename":"root"}]`
+	queuesBody  = `{"queuename":"root","maxResource":{"memory":100},"allocatedResource":{"memory":40},"children":[{"queuename":"root.a"}]}`
+)
+
+// fakeClientSet authenticates validToken as adminUser, any other non-empty token as regularUser
+// and only allows adminUser access.
+func fakeClientSet(sarErr error) *fake.Clientset {
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review, ok := action.(k8stesting.CreateAction).GetObject().(*authnv1.TokenReview)
+		if !ok {
+			return true, nil, errors.New("unexpected object")
+		}
+		switch review.Spec.Token {
+		case validToken:
+			review.Status = authnv1.TokenReviewStatus{Authenticated: true, User: authnv1.UserInfo{Username: adminUser}}
+		case "invalid":
+			review.Status = authnv1.TokenReviewStatus{Authenticated: false, Error: "invalid token"}
+		default:
+			review.Status = authnv1.TokenReviewStatus{Authenticated: true, User: authnv1.UserInfo{Username: regularUser}}
+		}
+		return true, review, nil
+	})
+	clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if sarErr != nil {
+			return true, nil, sarErr
+		}
+		review, ok := action.(k8stesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+		if !ok {
+			return true, nil, errors.New("unexpected object")
+		}
+		attributes := review.Spec.NonResourceAttributes
+		review.Status.Allowed = review.Spec.User == adminUser && (attributes.Verb == "get" || attributes.Path == logLevelPath)
+		return true, review, nil
+	})
+	return clientSet
+}
+
+func TestServeHTTP(t *testing.T) {
+	var forwardedAuth string
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedAuth = r.Header.Get("Authorization")
+		if r.URL.Path == "/ws/v1/partition/default/queues" {
+			_, _ = w.Write([]byte(queuesBody))
+			return
+		}
+		_, _ = w.Write([]byte(coreBody))
+	}))
+	defer core.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"partitions", http.MethodGet, "/ws/v1/partitions", validToken, http.StatusOK},
+		{"nodes", http.MethodGet, "/ws/v1/partition/default/nodes", validToken, http.StatusOK},
+		{"application", http.MethodGet, "/ws/v1/partition/default/queue/root.a/application/app-1", validToken, http.StatusOK},
+		{"not exposed", http.MethodGet, "/ws/v1/config", validToken, http.StatusNotFound},
+		{"write", http.MethodPut, "/ws/v1/partition/default/queues", validToken, http.StatusMethodNotAllowed},
+		{"no token", http.MethodGet, "/ws/v1/partition/default/queues", "", http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "/ws/v1/partition/default/queues", "invalid", http.StatusUnauthorized},
+		{"forbidden", http.MethodGet, "/ws/v1/partition/default/queues", "other-token", http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			forwardedAuth = ""
+			proxy, err := NewRESTProxy(":0", core.URL, fakeClientSet(nil), nil)
+			assert.NilError(t, err, "proxy creation failed")
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			proxy.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, tc.status, "unexpected status")
+			if tc.status == http.StatusOK {
+				assert.Equal(t, rec.Body.String(), coreBody, "unexpected body")
+				assert.Equal(t, forwardedAuth, "", "token forwarded to core")
+			}
+		})
+	}
+}
+
+func TestServeHeadroom(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(queuesBody))
+	}))
+	defer core.Close()
+	proxy, err := NewRESTProxy(":0", core.URL, fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		status int
+		body   string
+	}{
+		{"headroom", validToken, "/ws/v1/partition/default/queue/root.a/headroom", http.StatusOK, `{"partition":"default","queueName":"root.a","headroom":{"memory":60}}` + "\n"},
+		{"unknown queue", validToken, "/ws/v1/partition/default/queue/root.b/headroom", http.StatusNotFound, ""},
+		{"forbidden", "other-token", "/ws/v1/partition/default/queue/root.a/headroom", http.StatusForbidden, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			proxy.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, tc.status, "unexpected status")
+			if tc.body != "" {
+				assert.Equal(t, rec.Body.String(), tc.body, "unexpected body")
+			}
+		})
+	}
+}
+
+func TestServeHTTPAuthorizationError(t *testing.T) {
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(errors.New("api server unavailable")), nil)
+	assert.NilError(t, err, "proxy creation failed")
+	req := httptest.NewRequest(http.MethodGet, "/ws/v1/partitions", nil)
+	req.Header.Set("Authorization", "Bearer "+validToken)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusInternalServerError)
+}
+
+func TestServeVersion(t *testing.T) {
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+	req := httptest.NewRequest(http.MethodGet, versionPath, nil)
+	req.Header.Set("Authorization", "Bearer "+validToken)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK, "unexpected status")
+	info := &conf.VersionInfo{}
+	assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), info), "response is not version info")
+	assert.Equal(t, info.DeploymentMode, conf.DeploymentModeStandard)
+	assert.Assert(t, info.Features["gangScheduling"], "gang scheduling not reported as enabled")
+}
+
+func TestServeReady(t *testing.T) {
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+	var ready error
+	proxy.SetReadinessCheck(func() error {
+		return ready
+	})
+
+	// no token needed for the readiness probe
+	rec := httptest.NewRecorder()
+	proxy.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, readyPath, nil))
+	assert.Equal(t, rec.Code, http.StatusOK, "unexpected status")
+
+	ready = errors.New("circuit breaker Open")
+	rec = httptest.NewRecorder()
+	proxy.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, readyPath, nil))
+	assert.Equal(t, rec.Code, http.StatusServiceUnavailable, "unexpected status")
+	assert.Assert(t, strings.Contains(rec.Body.String(), "circuit breaker Open"), "reason not returned")
+}
+
+func TestServeQueueTree(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws/v1/partition/default/queues" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(queuesBody))
+	}))
+	defer core.Close()
+	proxy, err := NewRESTProxy(":0", core.URL, fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+
+	tests := []struct {
+		name        string
+		token       string
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"default json", validToken, "/ws/v1/partition/default/queuetree", http.StatusOK, "application/json; charset=UTF-8", `"name":"root.a"`},
+		{"dot", validToken, "/ws/v1/partition/default/queuetree?format=dot", http.StatusOK, "text/vnd.graphviz; charset=UTF-8", `"root" -> "root.a";`},
+		{"svg", validToken, "/ws/v1/partition/default/queuetree?format=svg", http.StatusOK, "image/svg+xml", "<svg"},
+		{"unknown format", validToken, "/ws/v1/partition/default/queuetree?format=png", http.StatusBadRequest, "", ""},
+		{"unknown partition", validToken, "/ws/v1/partition/other/queuetree", http.StatusNotFound, "", ""},
+		{"forbidden", "other-token", "/ws/v1/partition/default/queuetree", http.StatusForbidden, "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			proxy.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, tc.status, "unexpected status")
+			if tc.status == http.StatusOK {
+				assert.Equal(t, rec.Header().Get("Content-Type"), tc.contentType)
+				assert.Assert(t, strings.Contains(rec.Body.String(), tc.body), rec.Body.String())
+			}
+		})
+	}
+}
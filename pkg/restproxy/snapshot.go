@@ -0,0 +1,151 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	coreConfigPath = "/ws/v1/config"
+	formatTar      = "tar"
+)
+
+// Snapshot is the support bundle returned by the snapshot endpoint
+type Snapshot struct {
+	Shim            cache.SnapshotDao `json:"shim"`
+	CoreConfig      json.RawMessage   `json:"coreConfig,omitempty"`
+	CoreConfigError string            `json:"coreConfigError,omitempty"`
+}
+
+// serveSnapshot returns the snapshot as a single JSON document, or as a gzipped tarball with a file per
+// section if the format=tar query parameter is set. The format=export query parameter returns a state
+// export that includes the full state of the core and can be imported into a development cluster.
+func (p *RESTProxy) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot := Snapshot{
+		Shim: p.snapshot.GetSnapshot(),
+	}
+	coreConfig, err := p.getCoreJSON(r.Context(), coreConfigPath)
+	if err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to retrieve core configuration for snapshot", zap.Error(err))
+		snapshot.CoreConfigError = err.Error()
+	} else {
+		snapshot.CoreConfig = coreConfig
+	}
+
+	switch r.URL.Query().Get("format") {
+	case formatExport:
+		p.serveStateExport(w, r, &snapshot)
+		return
+	case formatTar:
+		name := fmt.Sprintf("yunikorn-snapshot-%s", snapshot.Shim.Timestamp.UTC().Format("20060102-150405"))
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".tar.gz"))
+		if err = writeSnapshotTar(w, name, &snapshot); err != nil {
+			log.Log(log.ShimRESTProxy).Warn("failed to write snapshot tarball", zap.Error(err))
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err = json.NewEncoder(w).Encode(snapshot); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write snapshot response", zap.Error(err))
+	}
+}
+
+// getCoreJSON retrieves a JSON document from the REST API of the core, e.g. the configuration currently used
+func (p *RESTProxy) getCoreJSON(ctx context.Context, path string) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.coreURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve %s from core: status %d", path, resp.StatusCode)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("response for %s from core is not valid JSON", path)
+	}
+	return body, nil
+}
+
+// writeSnapshotTar writes the sections of the snapshot as separate files in a directory in a gzipped tarball
+func writeSnapshotTar(w io.Writer, dir string, snapshot *Snapshot) error {
+	files := map[string]interface{}{
+		"applications.json": snapshot.Shim.Applications,
+		"pending-pods.json": snapshot.Shim.PendingPods,
+		"cache.json":        snapshot.Shim.Cache,
+	}
+	if snapshot.CoreConfig != nil {
+		files["core-config.json"] = snapshot.CoreConfig
+	} else {
+		files["core-config-error.json"] = map[string]string{"error": snapshot.CoreConfigError}
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	// fixed order to make the archive content predictable
+	for _, name := range []string{"applications.json", "pending-pods.json", "cache.json", "core-config.json", "core-config-error.json"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:    dir + "/" + name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: snapshot.Shim.Timestamp,
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err = tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,159 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache"
+)
+
+const configBody = `{"partitions":[{"name":"default"}]}`
+
+type mockSnapshotProvider struct{}
+
+func (m mockSnapshotProvider) GetSnapshot() cache.SnapshotDao {
+	return cache.SnapshotDao{
+		Timestamp:    time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		Applications: []cache.ApplicationDao{{ApplicationID: "app-1", Queue: "root.a", State: "Running"}},
+		PendingPods:  []string{"default/pod-1"},
+	}
+}
+
+func (m mockSnapshotProvider) GetApplicationEventLog(appID string) ([]cache.AppEvent, bool) {
+	if appID != "app-1" {
+		return nil, false
+	}
+	return []cache.AppEvent{
+		{Time: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC), Type: "Submitted", Message: "Application app-1 submitted"},
+		{Time: time.Date(2023, 6, 1, 12, 0, 1, 0, time.UTC), Type: "TaskReleased", TaskID: "task-1", Message: "Task pod-1 released"},
+	}, true
+}
+
+func TestServeSnapshot(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != coreConfigPath || r.Header.Get("Accept") != "application/json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(configBody))
+	}))
+	defer core.Close()
+
+	// without a provider the endpoint is not served
+	proxy, err := NewRESTProxy(":0", core.URL, fakeClientSet(nil), nil)
+	assert.NilError(t, err, "proxy creation failed")
+	rec := snapshotRequest(proxy, "", validToken)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	proxy, err = NewRESTProxy(":0", core.URL, fakeClientSet(nil), mockSnapshotProvider{})
+	assert.NilError(t, err, "proxy creation failed")
+	rec = snapshotRequest(proxy, "", "other-token")
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+
+	rec = snapshotRequest(proxy, "", validToken)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	snapshot := Snapshot{}
+	assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &snapshot))
+	assert.Equal(t, len(snapshot.Shim.Applications), 1)
+	assert.Equal(t, snapshot.Shim.Applications[0].ApplicationID, "app-1")
+	assert.DeepEqual(t, snapshot.Shim.PendingPods, []string{"default/pod-1"})
+	assert.Equal(t, string(snapshot.CoreConfig), configBody)
+	assert.Equal(t, snapshot.CoreConfigError, "")
+
+	rec = snapshotRequest(proxy, "?format=tar", validToken)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/gzip")
+	files := readTar(t, rec.Body)
+	assert.DeepEqual(t, fileNames(files), []string{
+		"yunikorn-snapshot-20230601-120000/applications.json",
+		"yunikorn-snapshot-20230601-120000/pending-pods.json",
+		"yunikorn-snapshot-20230601-120000/cache.json",
+		"yunikorn-snapshot-20230601-120000/core-config.json",
+	})
+	config := make(map[string]interface{})
+	assert.NilError(t, json.Unmarshal(files[3].data, &config))
+	assert.Assert(t, config["partitions"] != nil, "core config not in tarball")
+}
+
+func TestServeSnapshotCoreUnavailable(t *testing.T) {
+	proxy, err := NewRESTProxy(":0", "http://localhost:1", fakeClientSet(nil), mockSnapshotProvider{})
+	assert.NilError(t, err, "proxy creation failed")
+	rec := snapshotRequest(proxy, "", validToken)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	snapshot := Snapshot{}
+	assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &snapshot))
+	assert.Equal(t, len(snapshot.Shim.Applications), 1)
+	assert.Assert(t, snapshot.CoreConfig == nil, "unexpected core config")
+	assert.Assert(t, snapshot.CoreConfigError != "", "core config error not set")
+
+	rec = snapshotRequest(proxy, "?format=tar", validToken)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	files := readTar(t, rec.Body)
+	assert.Equal(t, files[len(files)-1].name, "yunikorn-snapshot-20230601-120000/core-config-error.json")
+}
+
+func snapshotRequest(proxy *RESTProxy, query, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, snapshotPath+query, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+	return rec
+}
+
+type tarFile struct {
+	name string
+	data []byte
+}
+
+func readTar(t *testing.T, r io.Reader) []tarFile {
+	gz, err := gzip.NewReader(r)
+	assert.NilError(t, err)
+	tr := tar.NewReader(gz)
+	files := make([]tarFile, 0)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NilError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NilError(t, err)
+		files = append(files, tarFile{name: header.Name, data: data})
+	}
+	return files
+}
+
+func fileNames(files []tarFile) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.name
+	}
+	return names
+}
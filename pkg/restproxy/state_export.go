@@ -0,0 +1,254 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache/external"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+const (
+	coreStatePath = "/ws/v1/fullstatedump"
+	formatExport  = "export"
+
+	// StateExportVersion is the version of the state export format, an import only accepts the same version
+	StateExportVersion = 1
+
+	// image used for the pods created from a state export, the pods are never expected to run
+	importPodImage = "registry.k8s.io/pause:3.9"
+)
+
+// StateExport is the state of the shim and the core in a format that can be imported into a development cluster
+type StateExport struct {
+	Version        int             `json:"version"`
+	Snapshot       Snapshot        `json:"snapshot"`
+	CoreState      json.RawMessage `json:"coreState,omitempty"`
+	CoreStateError string          `json:"coreStateError,omitempty"`
+}
+
+// ImportObjects are the Kubernetes objects that reproduce the state of an export
+type ImportObjects struct {
+	Namespaces      []*v1.Namespace
+	PriorityClasses []*schedulingv1.PriorityClass
+	Nodes           []*v1.Node
+	Pods            []*v1.Pod
+}
+
+// serveStateExport returns the snapshot and the full state of the core as a compressed state export
+func (p *RESTProxy) serveStateExport(w http.ResponseWriter, r *http.Request, snapshot *Snapshot) {
+	export := &StateExport{
+		Version:  StateExportVersion,
+		Snapshot: *snapshot,
+	}
+	coreState, err := p.getCoreJSON(r.Context(), coreStatePath)
+	if err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to retrieve core state for export", zap.Error(err))
+		export.CoreStateError = err.Error()
+	} else {
+		export.CoreState = coreState
+	}
+	name := fmt.Sprintf("yunikorn-state-%s.json.gz", snapshot.Shim.Timestamp.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if err = WriteStateExport(w, export); err != nil {
+		log.Log(log.ShimRESTProxy).Warn("failed to write state export", zap.Error(err))
+	}
+}
+
+// WriteStateExport writes the state export as gzip compressed JSON.
+// The compression uses gzip as zstd is not available in the dependencies of the shim.
+func WriteStateExport(w io.Writer, export *StateExport) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(export); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadStateExport reads a state export written by WriteStateExport
+func ReadStateExport(r io.Reader) (*StateExport, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("state export is not compressed: %w", err)
+	}
+	defer gz.Close()
+	export := &StateExport{}
+	if err = json.NewDecoder(gz).Decode(export); err != nil {
+		return nil, fmt.Errorf("state export is not valid: %w", err)
+	}
+	if export.Version != StateExportVersion {
+		return nil, fmt.Errorf("state export version %d is not supported, expected version %d", export.Version, StateExportVersion)
+	}
+	return export, nil
+}
+
+// ImportObjects converts the scheduler cache of the export into Kubernetes objects.
+// Nodes keep their capacity, labels and taints, pods keep their resources, placement and scheduling constraints.
+// Terminated pods are not part of the import, all containers use a pause image.
+func (e *StateExport) ImportObjects() *ImportObjects {
+	objects := &ImportObjects{}
+	cacheDao := e.Snapshot.Shim.Cache
+	for _, name := range sortedKeys(cacheDao.PriorityClasses) {
+		pc := cacheDao.PriorityClasses[name]
+		objects.PriorityClasses = append(objects.PriorityClasses, &schedulingv1.PriorityClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        pc.Name,
+				Labels:      pc.Labels,
+				Annotations: pc.Annotations,
+			},
+			Value:            pc.Value,
+			GlobalDefault:    pc.GlobalDefault,
+			PreemptionPolicy: pc.PreemptionPolicy,
+		})
+	}
+	for _, name := range sortedKeys(cacheDao.Nodes) {
+		objects.Nodes = append(objects.Nodes, importNode(cacheDao.Nodes[name]))
+	}
+	namespaces := make(map[string]bool)
+	for _, key := range sortedKeys(cacheDao.Pods) {
+		pod := cacheDao.Pods[key]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if !namespaces[pod.Namespace] {
+			namespaces[pod.Namespace] = true
+			objects.Namespaces = append(objects.Namespaces, &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace},
+			})
+		}
+		objects.Pods = append(objects.Pods, importPod(pod))
+	}
+	return objects
+}
+
+// Apply creates the objects in the cluster, objects that already exist are left unchanged.
+// Nodes are created before the pods to allow pods to reference the node they were running on.
+func (o *ImportObjects) Apply(ctx context.Context, clientSet kubernetes.Interface) error {
+	for _, ns := range o.Namespaces {
+		if _, err := clientSet.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); ignoreExists(err) != nil {
+			return fmt.Errorf("failed to create namespace %s: %w", ns.Name, err)
+		}
+	}
+	for _, pc := range o.PriorityClasses {
+		if _, err := clientSet.SchedulingV1().PriorityClasses().Create(ctx, pc, metav1.CreateOptions{}); ignoreExists(err) != nil {
+			return fmt.Errorf("failed to create priority class %s: %w", pc.Name, err)
+		}
+	}
+	for _, node := range o.Nodes {
+		if _, err := clientSet.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{}); ignoreExists(err) != nil {
+			return fmt.Errorf("failed to create node %s: %w", node.Name, err)
+		}
+	}
+	for _, pod := range o.Pods {
+		if _, err := clientSet.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{}); ignoreExists(err) != nil {
+			return fmt.Errorf("failed to create pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	log.Log(log.ShimRESTProxy).Info("state export imported",
+		zap.Int("namespaces", len(o.Namespaces)),
+		zap.Int("priorityClasses", len(o.PriorityClasses)),
+		zap.Int("nodes", len(o.Nodes)),
+		zap.Int("pods", len(o.Pods)))
+	return nil
+}
+
+func ignoreExists(err error) error {
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func importNode(node external.NodeDao) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        node.Name,
+			Labels:      node.Labels,
+			Annotations: node.Annotations,
+		},
+		Spec: v1.NodeSpec{
+			PodCIDRs: node.PodCIDRs,
+			Taints:   node.Taints,
+		},
+		Status: v1.NodeStatus{
+			Capacity:    node.Capacity,
+			Allocatable: node.Allocatable,
+			Conditions:  node.Conditions,
+			Addresses:   node.Addresses,
+			NodeInfo:    node.NodeInfo,
+		},
+	}
+}
+
+func importPod(pod external.PodDao) *v1.Pod {
+	containers := make([]v1.Container, 0, len(pod.Containers))
+	for _, container := range pod.Containers {
+		containers = append(containers, v1.Container{
+			Name:      container.Name,
+			Image:     importPodImage,
+			Resources: container.Resources,
+		})
+	}
+	name := pod.Name
+	if name == "" {
+		name = pod.GenerateName + string(pod.UID)
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   pod.Namespace,
+			Labels:      pod.Labels,
+			Annotations: pod.Annotations,
+		},
+		Spec: v1.PodSpec{
+			Containers:        containers,
+			NodeName:          pod.NodeName,
+			Affinity:          pod.Affinity,
+			NodeSelector:      pod.NodeSelector,
+			PriorityClassName: pod.PriorityClassName,
+			PreemptionPolicy:  pod.PreemptionPolicy,
+			SchedulerName:     pod.SchedulerName,
+			Tolerations:       pod.Tolerations,
+		},
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
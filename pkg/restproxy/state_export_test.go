@@ -0,0 +1,152 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restproxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/yunikorn-k8shim/pkg/cache"
+	"github.com/apache/yunikorn-k8shim/pkg/cache/external"
+)
+
+const stateBody = `{"partitions":[{"name":"default"}],"nodes":[]}`
+
+func TestServeStateExport(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case coreConfigPath:
+			_, _ = w.Write([]byte(configBody))
+		case coreStatePath:
+			_, _ = w.Write([]byte(stateBody))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer core.Close()
+
+	proxy, err := NewRESTProxy(":0", core.URL, fakeClientSet(nil), mockSnapshotProvider{})
+	assert.NilError(t, err, "proxy creation failed")
+	rec := snapshotRequest(proxy, "?format=export", validToken)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/gzip")
+	assert.Equal(t, rec.Header().Get("Content-Disposition"), `attachment; filename="yunikorn-state-20230601-120000.json.gz"`)
+	export, err := ReadStateExport(rec.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, export.Version, StateExportVersion)
+	assert.Equal(t, export.Snapshot.Shim.Applications[0].ApplicationID, "app-1")
+	assert.Equal(t, string(export.Snapshot.CoreConfig), configBody)
+	assert.Equal(t, string(export.CoreState), stateBody)
+	assert.Equal(t, export.CoreStateError, "")
+}
+
+func TestReadStateExport(t *testing.T) {
+	_, err := ReadStateExport(bytes.NewBufferString("{}"))
+	assert.ErrorContains(t, err, "not compressed")
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	_, err = gz.Write([]byte("not json"))
+	assert.NilError(t, err)
+	assert.NilError(t, gz.Close())
+	_, err = ReadStateExport(buf)
+	assert.ErrorContains(t, err, "not valid")
+
+	buf.Reset()
+	assert.NilError(t, WriteStateExport(buf, &StateExport{Version: StateExportVersion + 1}))
+	_, err = ReadStateExport(buf)
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestImportObjects(t *testing.T) {
+	export := &StateExport{
+		Version: StateExportVersion,
+		Snapshot: Snapshot{
+			Shim: cache.SnapshotDao{
+				Cache: external.SchedulerCacheDao{
+					Nodes: map[string]external.NodeDao{
+						"node-1": {
+							Name:     "node-1",
+							Labels:   map[string]string{"zone": "a"},
+							Capacity: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+						},
+					},
+					Pods: map[string]external.PodDao{
+						"ns-1/running": {
+							Namespace:     "ns-1",
+							Name:          "running",
+							NodeName:      "node-1",
+							SchedulerName: "yunikorn",
+							Labels:        map[string]string{"applicationId": "app-1"},
+							Containers: []external.ContainerDao{{
+								Name: "c1",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+								},
+							}},
+							Status: v1.PodStatus{Phase: v1.PodRunning},
+						},
+						"ns-1/pending": {
+							Namespace:     "ns-1",
+							Name:          "pending",
+							SchedulerName: "yunikorn",
+							Status:        v1.PodStatus{Phase: v1.PodPending},
+						},
+						"ns-2/done": {
+							Namespace: "ns-2",
+							Name:      "done",
+							Status:    v1.PodStatus{Phase: v1.PodSucceeded},
+						},
+					},
+				},
+			},
+		},
+	}
+	objects := export.ImportObjects()
+	assert.Equal(t, len(objects.Namespaces), 1)
+	assert.Equal(t, objects.Namespaces[0].Name, "ns-1")
+	assert.Equal(t, len(objects.Nodes), 1)
+	assert.DeepEqual(t, objects.Nodes[0].Labels, map[string]string{"zone": "a"})
+	assert.Equal(t, len(objects.Pods), 2)
+	assert.Equal(t, objects.Pods[0].Name, "pending")
+	assert.Equal(t, objects.Pods[1].Name, "running")
+	assert.Equal(t, objects.Pods[1].Spec.NodeName, "node-1")
+	assert.Equal(t, objects.Pods[1].Spec.Containers[0].Image, importPodImage)
+	assert.Equal(t, objects.Pods[1].Spec.Containers[0].Resources.Requests.Cpu().MilliValue(), int64(1000))
+
+	clientSet := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1"}})
+	assert.NilError(t, objects.Apply(context.Background(), clientSet))
+	// existing objects are skipped
+	assert.NilError(t, objects.Apply(context.Background(), clientSet))
+	pods, err := clientSet.CoreV1().Pods("ns-1").List(context.Background(), metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(pods.Items), 2)
+	_, err = clientSet.CoreV1().Nodes().Get(context.Background(), "node-1", metav1.GetOptions{})
+	assert.NilError(t, err)
+}
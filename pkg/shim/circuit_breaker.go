@@ -0,0 +1,252 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	breakerClosed   = "Closed"
+	breakerOpen     = "Open"
+	breakerHalfOpen = "HalfOpen"
+)
+
+// errBufferFull is returned for a request that is dropped because the buffer of the circuit breaker is full
+var errBufferFull = errors.New("communication with the core interrupted, buffer full: request dropped")
+
+// registrationChecker is implemented by the RM proxy of the core: the callback of an RM is only returned while
+// the RM is registered.
+type registrationChecker interface {
+	GetResourceManagerCallback(rmID string) api.ResourceManagerCallback
+}
+
+// coreCircuitBreaker wraps the scheduler API of the core. After a number of consecutive failed requests the
+// circuit opens: allocation, application and node updates are buffered instead of sent to the core, the
+// callers get client.ErrCoreRequestBuffered and the scheduler is reported as not ready.
+// After the cooldown the circuit is half-open: the shim registers again if the core lost the registration,
+// and the buffered requests are replayed in order. The circuit closes when all requests are sent, any failure
+// opens the circuit again.
+type coreCircuitBreaker struct {
+	api.SchedulerAPI
+
+	threshold  int
+	cooldown   time.Duration
+	bufferSize int
+
+	state           string
+	failures        int
+	buffer          []func() error
+	registerRequest *si.RegisterResourceManagerRequest
+	callback        api.ResourceManagerCallback
+
+	lock sync.Mutex
+}
+
+// newCoreCircuitBreaker returns the scheduler API wrapped in a circuit breaker.
+// The scheduler API is returned unchanged if the circuit breaker is disabled by setting the threshold to 0.
+func newCoreCircuitBreaker(scheduler api.SchedulerAPI, configs *conf.SchedulerConf) api.SchedulerAPI {
+	if configs.CoreBreakerThreshold <= 0 {
+		return scheduler
+	}
+	metrics.SetCoreCircuitBreakerState(metrics.CircuitBreakerClosed)
+	return &coreCircuitBreaker{
+		SchedulerAPI: scheduler,
+		threshold:    configs.CoreBreakerThreshold,
+		cooldown:     configs.CoreBreakerCooldown,
+		bufferSize:   configs.CoreBreakerBufferSize,
+		state:        breakerClosed,
+	}
+}
+
+// RegisterResourceManager registers with the core, the request is kept for the re-registration
+func (b *coreCircuitBreaker) RegisterResourceManager(request *si.RegisterResourceManagerRequest, callback api.ResourceManagerCallback) (*si.RegisterResourceManagerResponse, error) {
+	b.lock.Lock()
+	b.registerRequest = request
+	b.callback = callback
+	b.lock.Unlock()
+	return b.SchedulerAPI.RegisterResourceManager(request, callback)
+}
+
+func (b *coreCircuitBreaker) UpdateAllocation(request *si.AllocationRequest) error {
+	return b.call(func() error {
+		return b.SchedulerAPI.UpdateAllocation(request)
+	})
+}
+
+func (b *coreCircuitBreaker) UpdateApplication(request *si.ApplicationRequest) error {
+	return b.call(func() error {
+		return b.SchedulerAPI.UpdateApplication(request)
+	})
+}
+
+func (b *coreCircuitBreaker) UpdateNode(request *si.NodeRequest) error {
+	return b.call(func() error {
+		return b.SchedulerAPI.UpdateNode(request)
+	})
+}
+
+func (b *coreCircuitBreaker) getState() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// checkReady returns an error while the circuit is not closed
+func (b *coreCircuitBreaker) checkReady() error {
+	if state := b.getState(); state != breakerClosed {
+		return fmt.Errorf("communication with the core interrupted, circuit breaker %s", state)
+	}
+	return nil
+}
+
+// call sends the request to the core if the circuit is closed, and buffers it otherwise
+func (b *coreCircuitBreaker) call(request func() error) error {
+	b.lock.Lock()
+	if b.state != breakerClosed {
+		err := b.bufferLocked(request)
+		b.lock.Unlock()
+		return err
+	}
+	b.lock.Unlock()
+
+	err := request()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if err == nil {
+		b.failures = 0
+		return nil
+	}
+	b.failures++
+	if b.state == breakerClosed && b.failures >= b.threshold {
+		b.openLocked(err)
+	}
+	return err
+}
+
+// bufferLocked buffers the request, returns client.ErrCoreRequestBuffered or errBufferFull if it was dropped
+func (b *coreCircuitBreaker) bufferLocked(request func() error) error {
+	if len(b.buffer) >= b.bufferSize {
+		log.Log(log.ShimScheduler).Warn("core circuit breaker buffer full, dropping request",
+			zap.Int("bufferSize", b.bufferSize))
+		metrics.IncCoreCircuitBreakerDropped()
+		return errBufferFull
+	}
+	b.buffer = append(b.buffer, request)
+	metrics.SetCoreCircuitBreakerBuffered(len(b.buffer))
+	return client.ErrCoreRequestBuffered
+}
+
+func (b *coreCircuitBreaker) openLocked(err error) {
+	b.state = breakerOpen
+	metrics.SetCoreCircuitBreakerState(metrics.CircuitBreakerOpen)
+	log.Log(log.ShimScheduler).Warn("communication with the core failed, opening circuit breaker",
+		zap.Int("failures", b.failures),
+		zap.Duration("cooldown", b.cooldown),
+		zap.Error(err))
+	events.GetRecorder().Eventf(schedulerConfigReference(), nil, v1.EventTypeWarning, "CoreCircuitBreakerOpen", "CoreCircuitBreakerOpen",
+		"communication with the scheduler core failed %d times, buffering requests for %s: %s", b.failures, b.cooldown, err.Error())
+	time.AfterFunc(b.cooldown, b.recover)
+}
+
+// recover re-registers with the core if needed and replays the buffered requests
+func (b *coreCircuitBreaker) recover() {
+	b.lock.Lock()
+	b.state = breakerHalfOpen
+	request := b.registerRequest
+	callback := b.callback
+	metrics.SetCoreCircuitBreakerState(metrics.CircuitBreakerHalfOpen)
+	b.lock.Unlock()
+
+	if request != nil && !b.isRegistered(request.RmID) {
+		log.Log(log.ShimScheduler).Info("core lost the registration, registering again")
+		if _, err := b.SchedulerAPI.RegisterResourceManager(request, callback); err != nil {
+			b.lock.Lock()
+			b.openLocked(err)
+			b.lock.Unlock()
+			return
+		}
+	}
+
+	for {
+		b.lock.Lock()
+		if len(b.buffer) == 0 {
+			b.state = breakerClosed
+			b.failures = 0
+			metrics.SetCoreCircuitBreakerState(metrics.CircuitBreakerClosed)
+			metrics.SetCoreCircuitBreakerBuffered(0)
+			log.Log(log.ShimScheduler).Info("communication with the core restored, closing circuit breaker")
+			events.GetRecorder().Eventf(schedulerConfigReference(), nil, v1.EventTypeNormal, "CoreCircuitBreakerClosed", "CoreCircuitBreakerClosed",
+				"communication with the scheduler core restored")
+			b.lock.Unlock()
+			return
+		}
+		next := b.buffer[0]
+		b.buffer = b.buffer[1:]
+		metrics.SetCoreCircuitBreakerBuffered(len(b.buffer))
+		b.lock.Unlock()
+
+		if err := next(); err != nil {
+			b.lock.Lock()
+			// keep the order: the failed request is the first to be replayed on the next attempt
+			b.buffer = append([]func() error{next}, b.buffer...)
+			metrics.SetCoreCircuitBreakerBuffered(len(b.buffer))
+			b.failures++
+			b.openLocked(err)
+			b.lock.Unlock()
+			return
+		}
+	}
+}
+
+// isRegistered returns false if the core lost the registration of the RM. The registration is assumed to be
+// present if the wrapped scheduler API cannot be asked.
+func (b *coreCircuitBreaker) isRegistered(rmID string) bool {
+	if checker, ok := b.SchedulerAPI.(registrationChecker); ok {
+		return checker.GetResourceManagerCallback(rmID) != nil
+	}
+	return true
+}
+
+// schedulerConfigReference returns the reference to the scheduler ConfigMap, used as the object of the
+// events that describe the circuit breaker state.
+func schedulerConfigReference() *v1.ObjectReference {
+	return &v1.ObjectReference{
+		Kind:       "ConfigMap",
+		APIVersion: "v1",
+		Namespace:  conf.GetSchedulerNamespace(),
+		Name:       constants.ConfigMapName,
+	}
+}
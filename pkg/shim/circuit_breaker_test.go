@@ -0,0 +1,239 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	k8sEvents "k8s.io/client-go/tools/events"
+
+	"github.com/apache/yunikorn-core/pkg/rmproxy"
+	"github.com/apache/yunikorn-k8shim/pkg/callback"
+	"github.com/apache/yunikorn-k8shim/pkg/client"
+	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the core reports if the shim is registered
+var _ registrationChecker = &rmproxy.RMProxy{}
+
+type failingSchedulerAPI struct {
+	err         error
+	registered  int
+	callback    api.ResourceManagerCallback
+	allocations []string
+	lock        sync.Mutex
+}
+
+func (f *failingSchedulerAPI) setError(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.err = err
+}
+
+func (f *failingSchedulerAPI) getAllocations() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.allocations...)
+}
+
+// loseRegistration simulates a restart of the core: the RM is not registered anymore
+func (f *failingSchedulerAPI) loseRegistration() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.callback = nil
+}
+
+func (f *failingSchedulerAPI) getRegistered() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.registered
+}
+
+func (f *failingSchedulerAPI) RegisterResourceManager(_ *si.RegisterResourceManagerRequest, cb api.ResourceManagerCallback) (*si.RegisterResourceManagerResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.registered++
+	f.callback = cb
+	return &si.RegisterResourceManagerResponse{}, nil
+}
+
+func (f *failingSchedulerAPI) GetResourceManagerCallback(_ string) api.ResourceManagerCallback {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.callback
+}
+
+func (f *failingSchedulerAPI) UpdateAllocation(request *si.AllocationRequest) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.allocations = append(f.allocations, request.RmID)
+	return nil
+}
+
+func (f *failingSchedulerAPI) UpdateApplication(_ *si.ApplicationRequest) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.err
+}
+
+func (f *failingSchedulerAPI) UpdateNode(_ *si.NodeRequest) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.err
+}
+
+func (f *failingSchedulerAPI) UpdateConfiguration(_ *si.UpdateConfigurationRequest) error {
+	return nil
+}
+
+func newTestCircuitBreaker(core api.SchedulerAPI, bufferSize int) *coreCircuitBreaker {
+	configs := conf.GetSchedulerConf().Clone()
+	configs.CoreBreakerThreshold = 2
+	configs.CoreBreakerCooldown = 50 * time.Millisecond
+	configs.CoreBreakerBufferSize = bufferSize
+	breaker, ok := newCoreCircuitBreaker(core, configs).(*coreCircuitBreaker)
+	if !ok {
+		panic("circuit breaker not created")
+	}
+	return breaker
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	core := &failingSchedulerAPI{}
+	configs := conf.GetSchedulerConf().Clone()
+	configs.CoreBreakerThreshold = 0
+	_, ok := newCoreCircuitBreaker(core, configs).(*coreCircuitBreaker)
+	assert.Assert(t, !ok, "circuit breaker should not wrap the scheduler API when disabled")
+}
+
+func TestCircuitBreakerOpenAndRecover(t *testing.T) {
+	events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	core := &failingSchedulerAPI{}
+	breaker := newTestCircuitBreaker(core, 10)
+	_, err := breaker.RegisterResourceManager(&si.RegisterResourceManagerRequest{RmID: "rm"}, &callback.AsyncRMCallback{})
+	assert.NilError(t, err)
+	assert.NilError(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a0"}))
+	assert.Equal(t, breaker.getState(), breakerClosed)
+	assert.NilError(t, breaker.checkReady())
+
+	// failures below the threshold keep the circuit closed, a success resets the count
+	core.setError(errors.New("core failure"))
+	assert.ErrorContains(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a1"}), "core failure")
+	assert.Equal(t, breaker.getState(), breakerClosed)
+	core.setError(nil)
+	assert.NilError(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a1"}))
+
+	// the core lost the registration: the circuit opens on the threshold
+	core.loseRegistration()
+	core.setError(errors.New("received AllocationRequest, but RmID=\"rm\" not registered"))
+	assert.ErrorContains(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a2"}), "not registered")
+	assert.ErrorContains(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a2"}), "not registered")
+	assert.Equal(t, breaker.getState(), breakerOpen)
+	assert.ErrorContains(t, breaker.checkReady(), "circuit breaker Open")
+
+	// requests are buffered while the circuit is open
+	assert.Assert(t, errors.Is(breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a3"}), client.ErrCoreRequestBuffered))
+	assert.Assert(t, errors.Is(breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a4"}), client.ErrCoreRequestBuffered))
+	assert.Equal(t, len(breaker.buffer), 2)
+
+	core.setError(nil)
+	assert.NilError(t, waitForBreakerState(breaker, breakerClosed, time.Second))
+	assert.NilError(t, breaker.checkReady())
+	assert.Equal(t, core.getRegistered(), 2, "shim should register again")
+	assert.DeepEqual(t, core.getAllocations(), []string{"a0", "a1", "a3", "a4"})
+	assert.Equal(t, len(breaker.buffer), 0)
+}
+
+func TestCircuitBreakerReopen(t *testing.T) {
+	events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	core := &failingSchedulerAPI{}
+	breaker := newTestCircuitBreaker(core, 10)
+	_, err := breaker.RegisterResourceManager(&si.RegisterResourceManagerRequest{RmID: "rm"}, &callback.AsyncRMCallback{})
+	assert.NilError(t, err)
+	core.setError(errors.New("core failure"))
+	assert.Assert(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a0"}) != nil)
+	assert.Assert(t, breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a0"}) != nil)
+	assert.Equal(t, breaker.getState(), breakerOpen)
+	assert.Assert(t, errors.Is(breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a1"}), client.ErrCoreRequestBuffered))
+	assert.Assert(t, errors.Is(breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a2"}), client.ErrCoreRequestBuffered))
+
+	// replay fails: the circuit opens again and the buffer is kept in order
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, breaker.getState(), breakerOpen)
+	assert.Equal(t, core.getRegistered(), 1, "shim should not register again when the core kept the registration")
+	breaker.lock.Lock()
+	assert.Equal(t, len(breaker.buffer), 2)
+	breaker.lock.Unlock()
+
+	core.setError(nil)
+	assert.NilError(t, waitForBreakerState(breaker, breakerClosed, time.Second))
+	assert.DeepEqual(t, core.getAllocations(), []string{"a1", "a2"})
+}
+
+func TestCircuitBreakerBufferFull(t *testing.T) {
+	events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
+	defer events.SetRecorder(events.NewMockedRecorder())
+
+	core := &failingSchedulerAPI{}
+	breaker := newTestCircuitBreaker(core, 1)
+	breaker.cooldown = time.Hour
+	core.setError(errors.New("core failure"))
+	assert.Assert(t, breaker.UpdateNode(&si.NodeRequest{}) != nil)
+	assert.Assert(t, breaker.UpdateApplication(&si.ApplicationRequest{}) != nil)
+	assert.Equal(t, breaker.getState(), breakerOpen)
+
+	dropped, err := metrics.GetCoreCircuitBreakerDropped()
+	assert.NilError(t, err)
+	assert.Assert(t, errors.Is(breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a1"}), client.ErrCoreRequestBuffered))
+	assert.Assert(t, errors.Is(breaker.UpdateAllocation(&si.AllocationRequest{RmID: "a2"}), errBufferFull))
+	assert.Equal(t, len(breaker.buffer), 1)
+	current, err := metrics.GetCoreCircuitBreakerDropped()
+	assert.NilError(t, err)
+	assert.Equal(t, current, dropped+1)
+}
+
+func waitForBreakerState(breaker *coreCircuitBreaker, state string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if breaker.getState() == state {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("circuit breaker state %s, expected %s", breaker.getState(), state)
+}
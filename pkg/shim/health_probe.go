@@ -0,0 +1,79 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package shim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-k8shim/pkg/log"
+)
+
+// healthProbePath is the path of the readiness probe of the scheduler
+const healthProbePath = "/ready"
+
+// healthProbe serves the readiness of the scheduler without authentication, it does not depend on the REST proxy.
+type healthProbe struct {
+	ready  func() error
+	server *http.Server
+}
+
+func newHealthProbe(listenAddress string, ready func() error) *healthProbe {
+	p := &healthProbe{
+		ready: ready,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(healthProbePath, p.serveReady)
+	p.server = &http.Server{
+		Addr:              listenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return p
+}
+
+func (p *healthProbe) Start() {
+	log.Log(log.ShimScheduler).Info("starting health probe", zap.String("address", p.server.Addr))
+	go func() {
+		if err := p.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Log(log.ShimScheduler).Error("health probe failed", zap.Error(err))
+		}
+	}()
+}
+
+func (p *healthProbe) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.server.Shutdown(ctx); err != nil {
+		log.Log(log.ShimScheduler).Warn("failed to stop health probe", zap.Error(err))
+	}
+}
+
+// serveReady returns 200 if the scheduler is ready and 503 with the reason otherwise
+func (p *healthProbe) serveReady(w http.ResponseWriter, _ *http.Request) {
+	if err := p.ready(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
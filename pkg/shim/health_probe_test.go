@@ -0,0 +1,51 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package shim
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestHealthProbe(t *testing.T) {
+	var ready error
+	probe := newHealthProbe(":0", func() error {
+		return ready
+	})
+
+	recorder := httptest.NewRecorder()
+	probe.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, healthProbePath, nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(), "ok")
+
+	ready = errors.New("communication with the core interrupted, circuit breaker Open")
+	recorder = httptest.NewRecorder()
+	probe.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, healthProbePath, nil))
+	assert.Equal(t, recorder.Code, http.StatusServiceUnavailable)
+	assert.Assert(t, strings.Contains(recorder.Body.String(), "circuit breaker Open"))
+
+	recorder = httptest.NewRecorder()
+	probe.server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ws/v1/queues", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+}
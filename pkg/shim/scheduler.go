@@ -20,6 +20,7 @@ package shim
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"sync"
 	"time"
@@ -36,10 +37,14 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/callback"
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/metrics"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/eventstream"
+	"github.com/apache/yunikorn-k8shim/pkg/headroom"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
+	"github.com/apache/yunikorn-k8shim/pkg/restproxy"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
@@ -51,6 +56,9 @@ type KubernetesShim struct {
 	appManager           *appmgmt.AppManagementService
 	phManager            *cache.PlaceholderManager
 	callback             api.ResourceManagerCallback
+	restProxy            *restproxy.RESTProxy
+	eventStream          *eventstream.Server
+	healthProbe          *healthProbe
 	stateMachine         *fsm.FSM
 	stopChan             chan struct{}
 	lock                 *sync.RWMutex
@@ -68,7 +76,7 @@ func NewShimScheduler(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, b
 	// we have disabled re-sync to keep ourselves up-to-date
 	informerFactory := informers.NewSharedInformerFactory(kubeClient.GetClientSet(), 0)
 
-	apiFactory := client.NewAPIFactory(scheduler, informerFactory, configs, false)
+	apiFactory := client.NewAPIFactory(newCoreCircuitBreaker(scheduler, configs), informerFactory, configs, false)
 	context := cache.NewContextWithBootstrapConfigMaps(apiFactory, bootstrapConfigMaps)
 	rmCallback := callback.NewAsyncRMCallback(context)
 	appManager := appmgmt.NewAMService(context, apiFactory)
@@ -76,7 +84,7 @@ func NewShimScheduler(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, b
 }
 
 func NewShimSchedulerForPlugin(scheduler api.SchedulerAPI, informerFactory informers.SharedInformerFactory, configs *conf.SchedulerConf, bootstrapConfigMaps []*v1.ConfigMap) *KubernetesShim {
-	apiFactory := client.NewAPIFactory(scheduler, informerFactory, configs, false)
+	apiFactory := client.NewAPIFactory(newCoreCircuitBreaker(scheduler, configs), informerFactory, configs, false)
 	context := cache.NewContextWithBootstrapConfigMaps(apiFactory, bootstrapConfigMaps)
 	context.SetPluginMode(true)
 	rmCallback := callback.NewAsyncRMCallback(context)
@@ -98,6 +106,26 @@ func newShimSchedulerInternal(ctx *cache.Context, apiFactory client.APIProvider,
 		outstandingAppsFound: false,
 		stateMachine:         newSchedulerState(),
 	}
+	// the queues of the core are used to suggest remediations in the events of pods waiting for resources
+	ctx.SetQueueSource(headroom.NewClient(restproxy.CoreWebServiceURL))
+	// the REST proxy is only started if a listen address is configured
+	if address := apiFactory.GetAPIs().GetConf().RESTProxyAddress; address != "" {
+		restProxy, err := restproxy.NewRESTProxy(address, restproxy.CoreWebServiceURL, apiFactory.GetAPIs().KubeClient.GetClientSet(), ctx)
+		if err != nil {
+			log.Log(log.ShimScheduler).Error("failed to create REST proxy", zap.Error(err))
+		} else {
+			restProxy.SetReadinessCheck(ss.checkReady)
+			ss.restProxy = restProxy
+		}
+	}
+	// the event stream is only started if a listen address is configured
+	if address := apiFactory.GetAPIs().GetConf().EventStreamAddress; address != "" {
+		ss.eventStream = eventstream.NewServer(address, eventstream.GetBroker())
+	}
+	// the readiness probe is served separately, the REST proxy is optional
+	if address := apiFactory.GetAPIs().GetConf().HealthProbeAddress; address != "" {
+		ss.healthProbe = newHealthProbe(address, ss.checkReady)
+	}
 	// init dispatcher
 	dispatcher.RegisterEventHandler(dispatcher.EventTypeApp, ctx.ApplicationEventHandler())
 	dispatcher.RegisterEventHandler(dispatcher.EventTypeTask, ctx.TaskEventHandler())
@@ -201,10 +229,31 @@ func (ss *KubernetesShim) doScheduling() {
 	// add event handlers to the context
 	ss.context.AddSchedulingEventHandlers()
 
+	// orphaned placeholders are only collected after the recovery added the existing applications back
+	ss.phManager.SetApplicationLookup(func(appID string) bool {
+		return ss.context.GetApplication(appID) != nil
+	})
+
 	// run main scheduling loop
 	go wait.Until(ss.schedule, conf.GetSchedulerConf().GetSchedulingInterval(), ss.stopChan)
 	// log a message if no outstanding requests were found for a while
 	go wait.Until(ss.checkOutstandingApps, outstandingAppLogTimeout, ss.stopChan)
+	// report applications that wait for resources without progress
+	go wait.Until(ss.context.CheckStuckApplications, cache.StuckApplicationCheckInterval, ss.stopChan)
+	// expire the preemptions between queues that left the observation windows
+	go wait.Until(ss.context.UpdatePreemptionWindows, cache.PreemptionWindowUpdateInterval, ss.stopChan)
+	// report the nodes changed since the last sample
+	if interval := conf.GetSchedulerConf().GetNodeSampleInterval(); interval > 0 {
+		go wait.Until(ss.context.ReportSampledNodes, interval, ss.stopChan)
+	}
+	// write the changes of the task journal to its store
+	if conf.GetSchedulerConf().GetTaskJournal() != "" {
+		go wait.Until(ss.context.SyncTaskJournal, cache.TaskJournalSyncInterval, ss.stopChan)
+	}
+	// mirror the gang applications as PodGroups
+	if conf.GetSchedulerConf().IsPodGroupSync() {
+		go wait.Until(ss.context.SyncPodGroups, cache.PodGroupSyncInterval, ss.stopChan)
+	}
 }
 
 func (ss *KubernetesShim) registerShimLayer() error {
@@ -218,8 +267,12 @@ func (ss *KubernetesShim) registerShimLayer() error {
 		return err
 	}
 
+	// publish the version and the enabled features once the configuration is loaded
+	versionInfo := conf.GetVersionInfo()
+	metrics.SetBuildInfo(versionInfo.Version, versionInfo.CoreVersion, versionInfo.DeploymentMode, versionInfo.GoVersion, versionInfo.Features)
+
 	confMap := conf.FlattenConfigMaps(configMaps)
-	config := utils.GetCoreSchedulerConfigFromConfigMap(confMap)
+	config := ss.context.GetCoreSchedulerConfig(confMap)
 	extraConfig := utils.GetExtraConfigFromConfigMap(confMap)
 
 	registerMessage := si.RegisterResourceManagerRequest{
@@ -244,6 +297,17 @@ func (ss *KubernetesShim) registerShimLayer() error {
 	return nil
 }
 
+// checkReady returns an error while the scheduler is not running or the communication with the core is interrupted
+func (ss *KubernetesShim) checkReady() error {
+	if state := ss.GetSchedulerState(); state != SchedulerStates().Running {
+		return fmt.Errorf("scheduler is not running, state %s", state)
+	}
+	if breaker, ok := ss.apiFactory.GetAPIs().SchedulerAPI.(*coreCircuitBreaker); ok {
+		return breaker.checkReady()
+	}
+	return nil
+}
+
 func (ss *KubernetesShim) GetSchedulerState() string {
 	return ss.stateMachine.Current()
 }
@@ -303,6 +367,23 @@ func (ss *KubernetesShim) Run() {
 		log.Log(log.ShimScheduler).Fatal("failed to start app manager", zap.Error(err))
 		ss.Stop()
 	}
+
+	// run the readiness probe
+	if ss.healthProbe != nil {
+		ss.healthProbe.Start()
+	}
+
+	// run the REST proxy for the scheduler core endpoints
+	if ss.restProxy != nil {
+		ss.restProxy.Start()
+	}
+
+	// run the event stream for external consumers
+	if ss.eventStream != nil {
+		if err := ss.eventStream.Start(); err != nil {
+			log.Log(log.ShimScheduler).Error("failed to start event stream", zap.Error(err))
+		}
+	}
 }
 
 func (ss *KubernetesShim) Stop() {
@@ -315,6 +396,20 @@ func (ss *KubernetesShim) Stop() {
 		ss.appManager.Stop()
 		// stop the placeholder manager
 		ss.phManager.Stop()
+		// stop the bind workers
+		ss.context.Stop()
+		// stop the readiness probe
+		if ss.healthProbe != nil {
+			ss.healthProbe.Stop()
+		}
+		// stop the REST proxy
+		if ss.restProxy != nil {
+			ss.restProxy.Stop()
+		}
+		// stop the event stream
+		if ss.eventStream != nil {
+			ss.eventStream.Stop()
+		}
 	default:
 		log.Log(log.ShimScheduler).Info("scheduler is already stopped")
 	}
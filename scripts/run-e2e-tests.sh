@@ -144,6 +144,19 @@ function install_cluster() {
   exit_on_error "failed to wait for yunikorn scheduler pods being deployed"
 }
 
+# arguments for the upgrade tests, the build under test is reinstalled with the same values as used by the install
+function upgrade_args() {
+  if [ "${UPGRADE_FROM}" == "" ]; then
+    return
+  fi
+  VALUES="image.repository=local/yunikorn,image.tag=${SCHEDULER_IMAGE},image.pullPolicy=IfNotPresent"
+  VALUES="${VALUES},admissionController.image.repository=local/yunikorn,admissionController.image.tag=${ADMISSION_IMAGE}"
+  VALUES="${VALUES},admissionController.image.pullPolicy=IfNotPresent"
+  VALUES="${VALUES},web.image.repository=local/yunikorn,web.image.tag=${WEBTEST_IMAGE},web.image.pullPolicy=IfNotPresent"
+  # make runs the tests from the e2e directory, all paths must be absolute
+  echo "-upgrade-from-version ${UPGRADE_FROM} -helm-bin $(pwd)/${HELM} -chart-path $(cd "${CHART_PATH}" && pwd) -chart-values ${VALUES}"
+}
+
 function delete_cluster() {
   echo "deleting K8s cluster: ${CLUSTER_NAME}"
   install_tools
@@ -154,11 +167,12 @@ function delete_cluster() {
 function print_usage() {
   NAME=$(basename "$0")
   cat <<EOF
-Usage: ${NAME} -a <action> -n <kind-cluster-name> -v <kind-node-image-version> [-p <chart-path>] [--plugin]
+Usage: ${NAME} -a <action> -n <kind-cluster-name> -v <kind-node-image-version> [-p <chart-path>] [-u <chart-version>] [--plugin]
   <action>                     the action to be executed, must be either "test" or "cleanup".
   <kind-cluster-name>          the name of the K8s cluster to be created by kind
   <kind-node-image-version>    the kind node image used to provision the K8s cluster, required for "test" action
   <chart-path>                 local path to helm charts path (default is to pull from GitHub master)
+  <chart-version>              chart version of the previous release, runs the upgrade tests from that release
   --plugin                     use scheduler plugin image instead of default mode image
 
 Examples:
@@ -169,6 +183,9 @@ Examples:
 
   Use a local helm chart path:
     ${NAME} -a test -n yk8s -v kindest/node:v1.27.3 -p ../yunikorn-release/helm-charts/yunikorn
+
+  Run the upgrade tests from a previous release:
+    ${NAME} -a test -n yk8s -v kindest/node:v1.27.3 -u 1.3.0
 EOF
 }
 
@@ -185,6 +202,7 @@ GIT_CLONE=true
 SCHEDULER_IMAGE="scheduler-${DOCKER_ARCH}-latest"
 ADMISSION_IMAGE="admission-${DOCKER_ARCH}-latest"
 WEBTEST_IMAGE="webtest-${DOCKER_ARCH}-latest"
+UPGRADE_FROM=""
 
 while [[ $# -gt 0 ]]; do
 key="$1"
@@ -210,6 +228,11 @@ case ${key} in
     shift
     shift
     ;;
+  -u|--upgrade-from)
+    UPGRADE_FROM="$2"
+    shift
+    shift
+    ;;
   --plugin)
     SCHEDULER_IMAGE="scheduler-plugin-${DOCKER_ARCH}-latest"
     shift
@@ -239,6 +262,7 @@ echo "  docker arch        : ${DOCKER_ARCH}"
 echo "  scheduler image    : ${SCHEDULER_IMAGE}"
 echo "  admission image    : ${ADMISSION_IMAGE}"
 echo "  web image          : ${WEBTEST_IMAGE}"
+echo "  upgrade from       : ${UPGRADE_FROM}"
 check_opt "action" "${ACTION}"
 check_opt "kind-cluster-name" "${CLUSTER_NAME}"
 
@@ -263,7 +287,7 @@ if [ "${ACTION}" == "test" ]; then
   if [ "${OS}" == "darwin" ]; then
     sleep 5
   fi
-  make e2e_test
+  E2E_ARGS="$(upgrade_args)" make e2e_test
   exit_on_error "e2e tests failed"
 elif [ "${ACTION}" == "cleanup" ]; then
   echo "cleaning up the environment"
@@ -19,6 +19,7 @@
 package basicscheduling_test
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/onsi/ginkgo/v2"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 	tests "github.com/apache/yunikorn-k8shim/test/e2e"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
@@ -118,6 +120,23 @@ var _ = ginkgo.Describe("", func() {
 		Ω(resMap["vcore"]).To(gomega.Equal(core))
 	})
 
+	ginkgo.It("Verify_REST_API_Schema", func() {
+		appID := sleepRespPod.ObjectMeta.Labels["applicationId"]
+		ginkgo.By("Verify the REST API responses match the DAO structs")
+		var partitions []*dao.PartitionInfo
+		Ω(restClient.ValidateResponse(configmanager.PartitionsPath, &partitions)).To(gomega.Succeed())
+		var queues dao.PartitionQueueDAOInfo
+		Ω(restClient.ValidateResponse(fmt.Sprintf(configmanager.QueuesPath, "default"), &queues)).To(gomega.Succeed())
+		var apps []*dao.ApplicationDAOInfo
+		Ω(restClient.ValidateResponse(fmt.Sprintf(configmanager.AppsPath, "default", "root."+dev), &apps)).To(gomega.Succeed())
+		var app dao.ApplicationDAOInfo
+		Ω(restClient.ValidateResponse(fmt.Sprintf(configmanager.AppPath, "default", "root."+dev, appID), &app)).To(gomega.Succeed())
+		var nodes []*dao.NodeDAOInfo
+		Ω(restClient.ValidateResponse(fmt.Sprintf(configmanager.NodesPath, "default"), &nodes)).To(gomega.Succeed())
+		var health dao.SchedulerHealthDAOInfo
+		Ω(restClient.ValidateResponse(configmanager.HealthCheckPath, &health)).To(gomega.Succeed())
+	})
+
 	ginkgo.AfterEach(func() {
 		testDescription := ginkgo.CurrentSpecReport()
 		if testDescription.Failed() {
@@ -16,10 +16,13 @@
  limitations under the License.
 */
 
+// Package configmanager holds the settings of an e2e run: the cluster, the YuniKorn deployment under test and
+// where reports are written. All helpers of the framework read the global YuniKornTestConfig.
 package configmanager
 
 import (
 	"flag"
+	"os"
 	"time"
 )
 
@@ -35,33 +38,83 @@ type YuniKornTestConfigType struct {
 	YkPort      string
 	YkScheme    string
 	LogDir      string
+	ArtifactDir string
 	Plugin      bool
+	// settings of the upgrade suite, the suite is skipped if UpgradeFromVersion is empty
+	HelmBin            string
+	HelmRelease        string
+	UpgradeFromVersion string
+	UpgradeChartRepo   string
+	ChartPath          string
+	ChartValues        string
 }
 
 // YuniKornTestConfig holds the global configuration of commandline flags
 // in the ginkgo-based testing environment.
+// Suites outside this repository that do not use the commandline flags can assign
+// a config created by NewYuniKornTestConfig before the first helper is called.
 var YuniKornTestConfig = YuniKornTestConfigType{}
 
+// NewYuniKornTestConfig returns a config with the same defaults as the commandline flags.
+func NewYuniKornTestConfig() YuniKornTestConfigType {
+	return YuniKornTestConfigType{
+		LogLevel:         "debug",
+		Timeout:          24 * time.Hour,
+		KubeConfig:       "~/.kube/config",
+		LogFile:          "test-output.log",
+		YkNamespace:      "yunikorn",
+		YkHost:           DefaultYuniKornHost,
+		YkPort:           DefaultYuniKornPort,
+		YkScheme:         DefaultYuniKornScheme,
+		LogDir:           "/tmp/e2e-test-reports",
+		ArtifactDir:      os.Getenv(ArtifactDirEnv),
+		HelmBin:          "helm",
+		HelmRelease:      DefaultHelmRelease,
+		UpgradeChartRepo: DefaultChartRepo,
+	}
+}
+
 // ParseFlags parses commandline flags relevant to testing.
 func (c *YuniKornTestConfigType) ParseFlags() {
-	flag.BoolVar(&c.JSONLogs, "json-logs-enabled", false,
+	c.RegisterFlags(flag.CommandLine)
+}
+
+// RegisterFlags registers the flags relevant to testing on the given flag set.
+// It allows suites with their own flags to embed the YuniKorn settings.
+func (c *YuniKornTestConfigType) RegisterFlags(fs *flag.FlagSet) {
+	d := NewYuniKornTestConfig()
+	fs.BoolVar(&c.JSONLogs, "json-logs-enabled", d.JSONLogs,
 		"Enable json log format")
-	flag.StringVar(&c.LogLevel, "log-level", "debug",
+	fs.StringVar(&c.LogLevel, "log-level", d.LogLevel,
 		"log level one of: debug|info|error|critical")
-	flag.StringVar(&c.KubeConfig, "kube-config", "~/.kube/config",
+	fs.StringVar(&c.KubeConfig, "kube-config", d.KubeConfig,
 		"Kubeconfig to be used for tests")
-	flag.StringVar(&c.LogFile, "log-file", "test-output.log",
+	fs.StringVar(&c.LogFile, "log-file", d.LogFile,
 		"Log filename")
-	flag.DurationVar(&c.Timeout, "timeout", 24*time.Hour,
+	fs.DurationVar(&c.Timeout, "timeout", d.Timeout,
 		"Specifies timeout for test run")
-	flag.StringVar(&c.YkNamespace, "yk-namespace", "yunikorn",
+	fs.StringVar(&c.YkNamespace, "yk-namespace", d.YkNamespace,
 		"K8s Namespace in which YuniKorn service is deployed")
-	flag.StringVar(&c.YkHost, "yk-host", DefaultYuniKornHost,
+	fs.StringVar(&c.YkHost, "yk-host", d.YkHost,
 		"Hostname/IP of YuniKorn service")
-	flag.StringVar(&c.YkPort, "yk-port", DefaultYuniKornPort,
+	fs.StringVar(&c.YkPort, "yk-port", d.YkPort,
 		"External Port of YuniKorn service")
-	flag.StringVar(&c.YkScheme, "yk-scheme", DefaultYuniKornScheme,
+	fs.StringVar(&c.YkScheme, "yk-scheme", d.YkScheme,
 		"Scheme of YuniKorn web service")
-	flag.StringVar(&c.LogDir, "log-dir", "/tmp/e2e-test-reports",
+	fs.StringVar(&c.LogDir, "log-dir", d.LogDir,
 		"Directory for test log reports")
+	fs.StringVar(&c.ArtifactDir, "artifact-dir", d.ArtifactDir,
+		"Directory for the cluster dumps of failed specs, no dump is written if empty")
+	fs.StringVar(&c.HelmBin, "helm-bin", d.HelmBin,
+		"Helm binary used to upgrade the YuniKorn release")
+	fs.StringVar(&c.HelmRelease, "helm-release", d.HelmRelease,
+		"Name of the helm release of YuniKorn")
+	fs.StringVar(&c.UpgradeFromVersion, "upgrade-from-version", d.UpgradeFromVersion,
+		"Chart version of the previous release to upgrade from, the upgrade tests are skipped if empty")
+	fs.StringVar(&c.UpgradeChartRepo, "upgrade-chart-repo", d.UpgradeChartRepo,
+		"Helm repository hosting the chart of the previous release")
+	fs.StringVar(&c.ChartPath, "chart-path", d.ChartPath,
+		"Local helm chart of the build under test")
+	fs.StringVar(&c.ChartValues, "chart-values", d.ChartValues,
+		"Comma separated helm values used to install the build under test, e.g. image.tag=latest")
 }
@@ -25,6 +25,9 @@ import (
 const (
 	TestResultsPath = "test_results/"
 
+	// ArtifactDirEnv is the environment variable that sets the default artifact directory
+	ArtifactDirEnv = "YK_E2E_ARTIFACT_DIR"
+
 	// LogPerm is the permission for files that are created by this framework
 	// that contain logs, outputs etc
 	LogPerm = os.FileMode(0666)
@@ -47,12 +50,24 @@ const (
 	NodesPath        = "ws/v1/partition/%s/nodes"
 	HealthCheckPath  = "ws/v1/scheduler/healthcheck"
 	ValidateConfPath = "ws/v1/validate-conf"
+	MetricsPath      = "ws/v1/metrics"
+	EventsBatchPath  = "ws/v1/events/batch"
+	ConfigPath       = "ws/v1/config"
+	UsersUsagePath   = "ws/v1/partition/%s/usage/users"
+	UserUsagePath    = "ws/v1/partition/%s/usage/user/%s"
+	GroupsUsagePath  = "ws/v1/partition/%s/usage/groups"
+	GroupUsagePath   = "ws/v1/partition/%s/usage/group/%s"
 
 	// YuniKorn Service Details
 	DefaultYuniKornHost   = "localhost"
 	DefaultYuniKornPort   = "9080"
 	DefaultYuniKornScheme = "http"
 
+	// Helm release details
+	DefaultHelmRelease = "yunikorn"
+	DefaultChartRepo   = "https://apache.github.io/yunikorn-release"
+	ChartName          = "yunikorn"
+
 	DefaultYuniKornConfigMap = "yunikorn-configs"
 	DefaultPluginConfigMap   = "yunikorn-configs"
 	DefaultPolicyGroup       = "queues.yaml"
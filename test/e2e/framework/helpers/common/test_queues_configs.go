@@ -134,6 +134,26 @@ func AddQueue(sc *configs.SchedulerConfig, partition string, parentPathStr strin
 	return errors.New("partition not found")
 }
 
+// RemoveQueue removes the child queue with the given name from the parent queue
+func RemoveQueue(sc *configs.SchedulerConfig, partition string, parentPathStr string, name string) error {
+	parentPath := strings.Split(parentPathStr, ".")
+	p, err := getPartition(sc, partition)
+	if err != nil {
+		return err
+	}
+	parentQ, err := getQueue(p.Queues, parentPath)
+	if err != nil {
+		return err
+	}
+	for i, q := range parentQ.Queues {
+		if q.Name == name {
+			parentQ.Queues = append(parentQ.Queues[:i], parentQ.Queues[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("queue not in path")
+}
+
 func SetNodeSortPolicy(sc *configs.SchedulerConfig, partition string, policy configs.NodeSortingPolicy) error {
 	p, err := getPartition(sc, partition)
 	if err != nil {
@@ -142,3 +162,16 @@ func SetNodeSortPolicy(sc *configs.SchedulerConfig, partition string, policy con
 	p.NodeSortPolicy = policy
 	return nil
 }
+
+// PriorityQueueConfig returns the config of a queue with the priority policy and offset set. An empty policy
+// leaves the default policy of the scheduler in place, e.g. PriorityQueueConfig("batch", "fence", -100).
+func PriorityQueueConfig(name string, policy string, offset int) configs.QueueConfig {
+	properties := map[string]string{configs.PriorityOffset: strconv.Itoa(offset)}
+	if policy != "" {
+		properties[configs.PriorityPolicy] = policy
+	}
+	return configs.QueueConfig{
+		Name:       name,
+		Properties: properties,
+	}
+}
@@ -16,6 +16,8 @@
  limitations under the License.
 */
 
+// Package common contains generic helpers used by all suites: building and serialising scheduler configs,
+// report directories and random test data.
 package common
 
 import (
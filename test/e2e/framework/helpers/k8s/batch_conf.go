@@ -0,0 +1,181 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ykv1 "github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+)
+
+// GangJobConfig defines a Job whose pods are scheduled as a gang. The task group is generated from the pod template
+// of the Job and all pods of the Job are members of it.
+type GangJobConfig struct {
+	Name      string
+	Namespace string
+	// AppID is set as the applicationId label of the pods if set
+	AppID       string
+	Parallelism int32
+	// Completions defaults to Parallelism
+	Completions int32
+	// TaskGroupName defaults to the name of the Job, MinMember of the task group defaults to Parallelism
+	TaskGroupName string
+	MinMember     int32
+	// PlaceholderTimeout in seconds, no timeout is set if zero
+	PlaceholderTimeout int
+	SchedulingStyle    string
+	PodConfig          TestPodConfig
+}
+
+// GangCronJobConfig defines a CronJob that creates a gang scheduled Job on every run.
+// The pods of all runs share the application ID if Job.AppID is set.
+type GangCronJobConfig struct {
+	Name      string
+	Namespace string
+	// Schedule defaults to every minute
+	Schedule string
+	// ConcurrencyPolicy defaults to Forbid: a run is skipped while the previous run is still active
+	ConcurrencyPolicy batchv1.ConcurrencyPolicy
+	Job               GangJobConfig
+}
+
+func InitGangJob(conf GangJobConfig) (*batchv1.Job, error) {
+	template, err := initGangJobTemplate(&conf)
+	if err != nil {
+		return nil, err
+	}
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      conf.Name,
+			Namespace: conf.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism: &conf.Parallelism,
+			Completions: &conf.Completions,
+			Template:    *template,
+		},
+	}
+	return &job, nil
+}
+
+func InitGangCronJob(conf GangCronJobConfig) (*batchv1.CronJob, error) {
+	if conf.Schedule == "" {
+		conf.Schedule = "*/1 * * * *"
+	}
+	if conf.ConcurrencyPolicy == "" {
+		conf.ConcurrencyPolicy = batchv1.ForbidConcurrent
+	}
+	if conf.Job.Name == "" {
+		conf.Job.Name = conf.Name
+	}
+	job, err := InitGangJob(conf.Job)
+	if err != nil {
+		return nil, err
+	}
+	cronJob := batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      conf.Name,
+			Namespace: conf.Namespace,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          conf.Schedule,
+			ConcurrencyPolicy: conf.ConcurrencyPolicy,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: job.Spec,
+			},
+		},
+	}
+	return &cronJob, nil
+}
+
+// initGangJobTemplate builds the pod template of the Job and adds the task group generated from it.
+func initGangJobTemplate(conf *GangJobConfig) (*v1.PodTemplateSpec, error) {
+	if conf.Completions == 0 {
+		conf.Completions = conf.Parallelism
+	}
+	if conf.MinMember == 0 {
+		conf.MinMember = conf.Parallelism
+	}
+	if conf.TaskGroupName == "" {
+		conf.TaskGroupName = conf.Name
+	}
+	pod, err := InitTestPod(conf.PodConfig)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string)
+	for k, v := range pod.Labels {
+		labels[k] = v
+	}
+	if conf.AppID != "" {
+		labels["applicationId"] = conf.AppID
+	}
+	// the Job controller names the pods
+	template := &v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      labels,
+			Annotations: pod.Annotations,
+		},
+		Spec: pod.Spec,
+	}
+	// Job only supports "OnFailure" or "Never"
+	template.Spec.RestartPolicy = v1.RestartPolicyNever
+	taskGroup := TaskGroupFromPodTemplate(conf.TaskGroupName, conf.MinMember, template)
+	template.Annotations = utils.MergeMaps(template.Annotations,
+		getGangSchedulingAnnotations(conf.PlaceholderTimeout, conf.SchedulingStyle, taskGroup.Name, []*ykv1.TaskGroup{&taskGroup}))
+	return template, nil
+}
+
+// TaskGroupFromPodTemplate generates a task group for the pods created from the template: the minimum resource is
+// the effective request of a pod, the placement constraints are copied from the template.
+func TaskGroupFromPodTemplate(name string, minMember int32, template *v1.PodTemplateSpec) ykv1.TaskGroup {
+	return ykv1.TaskGroup{
+		Name:         name,
+		MinMember:    minMember,
+		MinResource:  podTemplateRequests(&template.Spec),
+		NodeSelector: template.Spec.NodeSelector,
+		Tolerations:  template.Spec.Tolerations,
+		Affinity:     template.Spec.Affinity,
+	}
+}
+
+// podTemplateRequests returns the effective requests of a pod: the sum of the container requests or the largest
+// request of an init container, whichever is higher.
+func podTemplateRequests(spec *v1.PodSpec) map[string]resource.Quantity {
+	requests := make(map[string]resource.Quantity)
+	for _, container := range spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name.String()]
+			total.Add(quantity)
+			requests[name.String()] = total
+		}
+	}
+	for _, container := range spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name.String()]; !ok || quantity.Cmp(current) > 0 {
+				requests[name.String()] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
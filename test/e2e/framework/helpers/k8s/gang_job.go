@@ -24,7 +24,6 @@ import (
 
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ykv1 "github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
@@ -70,7 +69,7 @@ func getGangSchedulingAnnotations(placeholderTimeout int,
 		if schedulingParams != "" {
 			schedulingParams += " gangSchedulingStyle=" + schedulingStyle
 		} else {
-			schedulingParams = "gangSchedulingStyle" + schedulingStyle
+			schedulingParams = "gangSchedulingStyle=" + schedulingStyle
 		}
 	}
 
@@ -102,23 +101,19 @@ func DecoratePodForGangScheduling(
 
 func InitTaskGroups(conf SleepPodConfig, mainTaskGroupName, secondTaskGroupName string, parallelism int) []*ykv1.TaskGroup {
 	tg1 := &ykv1.TaskGroup{
-		MinMember: int32(parallelism),
-		Name:      mainTaskGroupName,
-		MinResource: map[string]resource.Quantity{
-			"cpu":    resource.MustParse(strconv.FormatInt(conf.CPU, 10) + "m"),
-			"memory": resource.MustParse(strconv.FormatInt(conf.Mem, 10) + "M"),
-		},
+		MinMember:   int32(parallelism),
+		Name:        mainTaskGroupName,
+		MinResource: SleepPodMinResource(conf),
+		Tolerations: conf.Tolerations,
 	}
 
 	// create TG2 more with more members than needed, also make sure that
 	// placeholders will stay in Pending state
 	tg2 := &ykv1.TaskGroup{
-		MinMember: int32(parallelism + 1),
-		Name:      secondTaskGroupName,
-		MinResource: map[string]resource.Quantity{
-			"cpu":    resource.MustParse(strconv.FormatInt(conf.CPU, 10) + "m"),
-			"memory": resource.MustParse(strconv.FormatInt(conf.Mem, 10) + "M"),
-		},
+		MinMember:   int32(parallelism + 1),
+		Name:        secondTaskGroupName,
+		MinResource: SleepPodMinResource(conf),
+		Tolerations: conf.Tolerations,
 		NodeSelector: map[string]string{
 			"kubernetes.io/hostname": "nonexistingnode",
 		},
@@ -133,12 +128,10 @@ func InitTaskGroups(conf SleepPodConfig, mainTaskGroupName, secondTaskGroupName
 
 func InitTaskGroup(conf SleepPodConfig, taskGroupName string, parallelism int32) []*ykv1.TaskGroup {
 	tg1 := &ykv1.TaskGroup{
-		MinMember: parallelism,
-		Name:      taskGroupName,
-		MinResource: map[string]resource.Quantity{
-			"cpu":    resource.MustParse(strconv.FormatInt(conf.CPU, 10) + "m"),
-			"memory": resource.MustParse(strconv.FormatInt(conf.Mem, 10) + "M"),
-		},
+		MinMember:   parallelism,
+		Name:        taskGroupName,
+		MinResource: SleepPodMinResource(conf),
+		Tolerations: conf.Tolerations,
 	}
 
 	tGroups := make([]*ykv1.TaskGroup, 1)
@@ -14,6 +14,8 @@
  limitations under the License.
 */
 
+// Package k8s wraps the Kubernetes client for tests: KubeCtl creates, waits for and deletes workloads and the
+// pod, job and statefulset builders create the objects used by the specs.
 package k8s
 
 import (
@@ -59,8 +61,15 @@ import (
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
 )
 
+// portForwardPort is the port of the REST service in the scheduler pod
 const portForwardPort = 9080
 
+// PortForwardLocalPort returns the local port forwarded to the scheduler pod by the ginkgo process. Parallel
+// processes cannot share a local port: process 1 uses the port of the service, process N the port plus N-1.
+func PortForwardLocalPort() int {
+	return portForwardPort + ginkgo.GinkgoParallelProcess() - 1
+}
+
 type WorkloadType string
 
 const (
@@ -73,6 +82,7 @@ const (
 )
 
 var fw *portforward.PortForwarder
+var fwStopCh chan struct{}
 var lock = &sync.Mutex{}
 
 type KubeCtl struct {
@@ -155,6 +165,28 @@ func (k *KubeCtl) GetKubeConfig() (*rest.Config, error) {
 	return nil, errors.New("kubeconfig is nil")
 }
 
+// Impersonate returns a KubeCtl that sends all requests as the given user and groups. The user of the current
+// kube config must be allowed to impersonate, the impersonated user needs RBAC rules for the objects it creates.
+func (k *KubeCtl) Impersonate(user string, groups []string) (*KubeCtl, error) {
+	if k.kubeConfig == nil {
+		return nil, errors.New("kubeconfig is nil")
+	}
+	config := rest.CopyConfig(k.kubeConfig)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   groups,
+	}
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &KubeCtl{
+		clientSet:      clientSet,
+		kubeConfigPath: k.kubeConfigPath,
+		kubeConfig:     config,
+	}, nil
+}
+
 func (k *KubeCtl) GetPods(namespace string) (*v1.PodList, error) {
 	return k.clientSet.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
 }
@@ -184,11 +216,24 @@ func (k *KubeCtl) GetSchedulerPod() (string, error) {
 	return "", errors.New("YK scheduler pod not found")
 }
 
+// KillPortForwardProcess stops the port-forward to the scheduler pod, if one is running
 func (k *KubeCtl) KillPortForwardProcess() {
+	lock.Lock()
+	defer lock.Unlock()
+	stopPortForwarder()
+}
+
+// stopPortForwarder closes the listeners and stops the forwarding goroutine, the caller must hold the lock
+func stopPortForwarder() {
 	if fw != nil {
 		fw.Close()
 		fw = nil
 	}
+	// closing the listeners does not stop the forwarding goroutine, the stop channel does
+	if fwStopCh != nil {
+		close(fwStopCh)
+		fwStopCh = nil
+	}
 }
 
 func (k *KubeCtl) UpdatePodWithAnnotation(pod *v1.Pod, namespace, annotationKey, annotationVal string) (*v1.Pod, error) {
@@ -272,7 +317,10 @@ func (k *KubeCtl) PortForwardYkSchedulerPod() error {
 	}
 	stopCh := make(chan struct{}, 1)
 	readyCh := make(chan struct{})
-	errCh := make(chan error)
+	errCh := make(chan error, 1)
+	lock.Lock()
+	fwStopCh = stopCh
+	lock.Unlock()
 
 	stream := genericclioptions.IOStreams{
 		In:     os.Stdin,
@@ -294,13 +342,19 @@ func (k *KubeCtl) PortForwardYkSchedulerPod() error {
 					Namespace: configmanager.YuniKornTestConfig.YkNamespace,
 				},
 			},
-			LocalPort: portForwardPort,
+			LocalPort: PortForwardLocalPort(),
 			PodPort:   portForwardPort,
 			Streams:   stream,
 			StopCh:    stopCh,
 			ReadyCh:   readyCh,
 		})
 		if err != nil {
+			// clean up the failed forward to allow a new port-forward to be started
+			lock.Lock()
+			if fwStopCh == stopCh {
+				stopPortForwarder()
+			}
+			lock.Unlock()
 			errCh <- fmt.Errorf("unable to port-forward %s", schedulerPodName)
 		}
 	}()
@@ -574,6 +628,55 @@ func (k *KubeCtl) GetStatefulSet(name, namespace string) (*appsv1.StatefulSet, e
 	return k.clientSet.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 }
 
+// WaitForStatefulSetReady waits until all replicas of the StatefulSet are created and ready.
+func (k *KubeCtl) WaitForStatefulSetReady(namespace string, name string, timeout time.Duration) error {
+	return wait.PollImmediate(time.Millisecond*100, timeout, k.isStatefulSetReady(namespace, name))
+}
+
+// WaitForStatefulSetTerminated waits until the StatefulSet and all of its pods are removed.
+func (k *KubeCtl) WaitForStatefulSetTerminated(namespace string, name string, timeout time.Duration) error {
+	return wait.PollImmediate(time.Millisecond*100, timeout, k.isStatefulSetNotInNS(namespace, name))
+}
+
+func (k *KubeCtl) isStatefulSetReady(namespace string, name string) wait.ConditionFunc {
+	return func() (bool, error) {
+		statefulSet, err := k.GetStatefulSet(name, namespace)
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+			statefulSet.Status.ReadyReplicas == replicas, nil
+	}
+}
+
+func (k *KubeCtl) isStatefulSetNotInNS(namespace string, name string) wait.ConditionFunc {
+	return func() (bool, error) {
+		_, err := k.GetStatefulSet(name, namespace)
+		if err == nil {
+			return false, nil
+		}
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		pods, err := k.ListPods(namespace, "app="+name)
+		if err != nil {
+			return false, err
+		}
+		return len(pods.Items) == 0, nil
+	}
+}
+
+// DeletePersistentVolumeClaims removes the claims created from the volume claim templates of a StatefulSet,
+// these are not removed when the StatefulSet is deleted.
+func (k *KubeCtl) DeletePersistentVolumeClaims(namespace string, selector string) error {
+	return k.clientSet.CoreV1().PersistentVolumeClaims(namespace).DeleteCollection(context.TODO(),
+		metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: selector})
+}
+
 func (k *KubeCtl) CreatePod(pod *v1.Pod, namespace string) (*v1.Pod, error) {
 	return k.clientSet.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
 }
@@ -859,6 +962,29 @@ func (k *KubeCtl) CreateClusterRoleBinding(
 	}, metav1.CreateOptions{})
 }
 
+// CreateUserRoleBinding binds the cluster role to the user within the namespace
+func (k *KubeCtl) CreateUserRoleBinding(
+	bindingName string,
+	role string,
+	namespace string,
+	user string) (*authv1.RoleBinding, error) {
+	return k.clientSet.RbacV1().RoleBindings(namespace).Create(context.TODO(), &authv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: bindingName, Namespace: namespace},
+		Subjects: []authv1.Subject{
+			{
+				Kind:     authv1.UserKind,
+				APIGroup: authv1.GroupName,
+				Name:     user,
+			},
+		},
+		RoleRef: authv1.RoleRef{Name: role, Kind: "ClusterRole", APIGroup: authv1.GroupName},
+	}, metav1.CreateOptions{})
+}
+
+func (k *KubeCtl) DeleteRoleBinding(bindingName string, namespace string) error {
+	return k.clientSet.RbacV1().RoleBindings(namespace).Delete(context.TODO(), bindingName, metav1.DeleteOptions{})
+}
+
 func (k *KubeCtl) DeleteClusterRoleBindings(roleName string) error {
 	return k.clientSet.RbacV1().ClusterRoleBindings().Delete(context.TODO(), roleName, metav1.DeleteOptions{})
 }
@@ -941,6 +1067,27 @@ func (k *KubeCtl) WaitForPodUnschedulable(pod *v1.Pod, timeout time.Duration) er
 	return wait.PollImmediate(100*time.Millisecond, timeout, k.PodUnschedulable(pod.Namespace, pod.Name))
 }
 
+// PodUnschedulableWithReason returns a condition function that returns true if the given pod
+// failed scheduling and the shim tagged the PodScheduled condition with the given reason code.
+func (k *KubeCtl) PodUnschedulableWithReason(podNamespace, podName, reasonCode string) wait.ConditionFunc {
+	return func() (bool, error) {
+		pod, err := k.GetPod(podName, podNamespace)
+		if err != nil {
+			// This could be a connection error so retry.
+			return false, nil
+		}
+		_, cond := podutil.GetPodCondition(&pod.Status, v1.PodScheduled)
+		return cond != nil && cond.Status == v1.ConditionFalse &&
+			utils.GetSchedulingReasonCode(cond) == reasonCode, nil
+	}
+}
+
+// WaitForPodUnschedulableWithReason waits for a pod to fail scheduling with the given reason code,
+// one of the constants.SchedulingReason* codes, and returns an error if it does not happen within the timeout.
+func (k *KubeCtl) WaitForPodUnschedulableWithReason(pod *v1.Pod, reasonCode string, timeout time.Duration) error {
+	return wait.PollImmediate(100*time.Millisecond, timeout, k.PodUnschedulableWithReason(pod.Namespace, pod.Name, reasonCode))
+}
+
 func (k *KubeCtl) CreatePriorityClass(pc *schedulingv1.PriorityClass) (*schedulingv1.PriorityClass, error) {
 	return k.clientSet.SchedulingV1().PriorityClasses().Create(context.Background(), pc, metav1.CreateOptions{})
 }
@@ -949,6 +1096,67 @@ func (k *KubeCtl) DeletePriorityClass(priorityClassName string) error {
 	return k.clientSet.SchedulingV1().PriorityClasses().Delete(context.Background(), priorityClassName, metav1.DeleteOptions{})
 }
 
+func (k *KubeCtl) GetPriorityClass(priorityClassName string) (*schedulingv1.PriorityClass, error) {
+	return k.clientSet.SchedulingV1().PriorityClasses().Get(context.Background(), priorityClassName, metav1.GetOptions{})
+}
+
+// WaitForPriorityClass waits for the PriorityClass to be present, pods referencing a PriorityClass that does
+// not exist yet are rejected by the API server.
+func (k *KubeCtl) WaitForPriorityClass(priorityClassName string, timeout time.Duration) error {
+	return wait.PollImmediate(time.Millisecond*100, timeout, k.isPriorityClassPresent(priorityClassName, true))
+}
+
+// WaitForPriorityClassDeleted waits for the PriorityClass to be removed.
+func (k *KubeCtl) WaitForPriorityClassDeleted(priorityClassName string, timeout time.Duration) error {
+	return wait.PollImmediate(time.Millisecond*100, timeout, k.isPriorityClassPresent(priorityClassName, false))
+}
+
+// CreatePriorityClasses creates the PriorityClasses and waits for them to be present, pods referencing a
+// PriorityClass are rejected until it is. PriorityClasses that already exist are left unchanged.
+func (k *KubeCtl) CreatePriorityClasses(timeout time.Duration, priorityClasses ...*schedulingv1.PriorityClass) error {
+	for _, pc := range priorityClasses {
+		if _, err := k.CreatePriorityClass(pc); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return err
+		}
+		if err := k.WaitForPriorityClass(pc.Name, timeout); err != nil {
+			return fmt.Errorf("priority class %s not created: %w", pc.Name, err)
+		}
+	}
+	return nil
+}
+
+// CleanupPriorityClasses removes the PriorityClasses and waits for them to be removed.
+// PriorityClasses that do not exist are skipped, the first error is returned after all removals are tried.
+func (k *KubeCtl) CleanupPriorityClasses(timeout time.Duration, priorityClassNames ...string) error {
+	var errs []error
+	for _, name := range priorityClassNames {
+		if err := k.DeletePriorityClass(name); err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, err)
+			continue
+		}
+		if err := k.WaitForPriorityClassDeleted(name, timeout); err != nil {
+			errs = append(errs, fmt.Errorf("priority class %s not removed: %w", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (k *KubeCtl) isPriorityClassPresent(priorityClassName string, present bool) wait.ConditionFunc {
+	return func() (bool, error) {
+		_, err := k.GetPriorityClass(priorityClassName)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return !present, nil
+			}
+			return false, err
+		}
+		return present, nil
+	}
+}
+
 func (k *KubeCtl) CreateJob(job *batchv1.Job, namespace string) (*batchv1.Job, error) {
 	return k.clientSet.BatchV1().Jobs(namespace).Create(context.TODO(), job, metav1.CreateOptions{})
 }
@@ -965,6 +1173,26 @@ func (k *KubeCtl) WaitForJobPodsSucceeded(namespace string, jobName string, numP
 	return wait.PollImmediate(time.Millisecond*100, timeout, k.isNumJobPodsInDesiredState(jobName, namespace, numPods, v1.PodSucceeded))
 }
 
+// WaitForCronJobJobs waits until at least numJobs Jobs of the CronJob are created, the names of the Jobs are returned.
+func (k *KubeCtl) WaitForCronJobJobs(namespace string, cronJobName string, numJobs int, timeout time.Duration) ([]string, error) {
+	var names []string
+	err := wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
+		jobs, err := k.GetJobs(namespace)
+		if err != nil {
+			return false, err
+		}
+		names = names[:0]
+		for i := range jobs.Items {
+			job := &jobs.Items[i]
+			if owner := metav1.GetControllerOf(job); owner != nil && owner.Kind == "CronJob" && owner.Name == cronJobName {
+				names = append(names, job.Name)
+			}
+		}
+		return len(names) >= numJobs, nil
+	})
+	return names, err
+}
+
 func (k *KubeCtl) WaitForPlaceholders(namespace string, podPrefix string, numPods int, timeout time.Duration, podPhase v1.PodPhase) error {
 	return wait.PollImmediate(time.Millisecond*100, timeout, k.isNumPlaceholdersRunning(namespace, podPrefix, numPods, podPhase))
 }
@@ -19,7 +19,10 @@
 package k8s
 
 import (
+	"encoding/json"
+
 	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 type PodAnnotation struct {
@@ -34,7 +37,18 @@ const (
 	TaskGroups             = "yunikorn.apache.org/task-groups"
 	PlaceHolder            = "yunikorn.apache.org/placeholder"
 	SchedulingPolicyParams = "yunikorn.apache.org/schedulingPolicyParameters"
+	UserInfo               = "yunikorn.apache.org/user.info"
 
 	MaxCPU = "yunikorn.apache.org/namespace.max.cpu"
 	MaxMem = "yunikorn.apache.org/namespace.max.memory"
 )
+
+// UserInfoAnnotation returns the value of the user info annotation for the user and groups. The annotation is
+// only accepted by the admission controller if the submitter of the pod is listed in the external users.
+func UserInfoAnnotation(user string, groups []string) (string, error) {
+	userInfo, err := json.Marshal(si.UserGroupInformation{User: user, Groups: groups})
+	if err != nil {
+		return "", err
+	}
+	return string(userInfo), nil
+}
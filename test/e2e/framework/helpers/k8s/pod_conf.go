@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
@@ -38,9 +39,25 @@ type SleepPodConfig struct {
 	Time         int
 	CPU          int64
 	Mem          int64
+	CPULimit     int64 // millicores, no limit is set if zero
+	MemLimit     int64 // MB, no limit is set if zero
 	RequiredNode string
 	Optedout     bool
 	Labels       map[string]string
+	// ExtendedResources are added to the requests and limits of the container, e.g. nvidia.com/gpu or hugepages-2Mi
+	ExtendedResources v1.ResourceList
+	Tolerations       []v1.Toleration
+	// Affinity is used as the base affinity of the pod, the node affinity is replaced if RequiredNode is set
+	Affinity *v1.Affinity
+	// PriorityClassName sets the priority of the pod, the PriorityClass must exist before the pod is created
+	PriorityClassName string
+	// AllowPreemption sets the allow-preemption annotation if not nil, it overrides the value of the PriorityClass
+	AllowPreemption *bool
+	// TaskGroupName makes the pod a member of the task group, TaskGroups is only needed on the originator pod
+	TaskGroupName string
+	TaskGroups    []v1alpha1.TaskGroup
+	// AntiAffinityLabels adds a required pod anti-affinity: the pod is not placed on a node running a pod with these labels
+	AntiAffinityLabels map[string]string
 }
 
 // TestPodConfig template for  sleepPods
@@ -64,6 +81,9 @@ func InitSleepPod(conf SleepPodConfig) (*v1.Pod, error) {
 
 	var owners []metav1.OwnerReference
 	affinity := &v1.Affinity{}
+	if conf.Affinity != nil {
+		affinity = conf.Affinity.DeepCopy()
+	}
 	if conf.RequiredNode != "" {
 		owner := metav1.OwnerReference{APIVersion: "v1", Kind: constants.DaemonSetType, Name: "daemonset job", UID: "daemonset"}
 		owners = []metav1.OwnerReference{owner}
@@ -79,15 +99,37 @@ func InitSleepPod(conf SleepPodConfig) (*v1.Pod, error) {
 				MatchFields: fields,
 			},
 		}
-		affinity = &v1.Affinity{
-			NodeAffinity: &v1.NodeAffinity{
-				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
-					NodeSelectorTerms: terms,
-				},
+		affinity.NodeAffinity = &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: terms,
 			},
 		}
 	}
 
+	if len(conf.AntiAffinityLabels) > 0 {
+		if affinity.PodAntiAffinity == nil {
+			affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+		affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, v1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: conf.AntiAffinityLabels},
+				TopologyKey:   v1.LabelHostname,
+			})
+	}
+
+	var annotations *PodAnnotation
+	if conf.TaskGroupName != "" || len(conf.TaskGroups) > 0 || conf.AllowPreemption != nil {
+		annotations = &PodAnnotation{
+			TaskGroupName: conf.TaskGroupName,
+			TaskGroups:    conf.TaskGroups,
+		}
+		if conf.AllowPreemption != nil {
+			annotations.Other = map[string]string{
+				constants.AnnotationAllowPreemption: strconv.FormatBool(*conf.AllowPreemption),
+			}
+		}
+	}
+
 	optedOut := "true"
 	if !conf.Optedout {
 		optedOut = "false"
@@ -111,19 +153,57 @@ func InitSleepPod(conf SleepPodConfig) (*v1.Pod, error) {
 		DeletionGracePeriodSeconds: &secs,
 		Command:                    []string{"sleep", strconv.Itoa(conf.Time)},
 		Labels:                     labels,
-		Resources: &v1.ResourceRequirements{
-			Requests: v1.ResourceList{
-				"cpu":    resource.MustParse(strconv.FormatInt(conf.CPU, 10) + "m"),
-				"memory": resource.MustParse(strconv.FormatInt(conf.Mem, 10) + "M"),
-			},
-		},
-		Affinity:        affinity,
-		OwnerReferences: owners,
+		Resources:                  sleepPodResourceRequirements(conf),
+		Affinity:                   affinity,
+		Tolerations:                conf.Tolerations,
+		OwnerReferences:            owners,
+		PriorityClassName:          conf.PriorityClassName,
+		Annotations:                annotations,
 	}
 
 	return InitTestPod(testPodConfig)
 }
 
+// sleepPodResourceRequirements returns the container resources for the sleep pod.
+// Extended resources cannot be overcommitted, they are set as both request and limit.
+func sleepPodResourceRequirements(conf SleepPodConfig) *v1.ResourceRequirements {
+	requirements := &v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			"cpu":    resource.MustParse(strconv.FormatInt(conf.CPU, 10) + "m"),
+			"memory": resource.MustParse(strconv.FormatInt(conf.Mem, 10) + "M"),
+		},
+	}
+	if conf.CPULimit > 0 || conf.MemLimit > 0 || len(conf.ExtendedResources) > 0 {
+		requirements.Limits = v1.ResourceList{}
+	}
+	if conf.CPULimit > 0 {
+		requirements.Limits["cpu"] = resource.MustParse(strconv.FormatInt(conf.CPULimit, 10) + "m")
+	}
+	if conf.MemLimit > 0 {
+		requirements.Limits["memory"] = resource.MustParse(strconv.FormatInt(conf.MemLimit, 10) + "M")
+	}
+	if len(conf.ExtendedResources) > 0 {
+		// extended resources cannot be overcommitted: the limit must be equal to the request
+		for name, quantity := range conf.ExtendedResources {
+			requirements.Requests[name] = quantity.DeepCopy()
+			requirements.Limits[name] = quantity.DeepCopy()
+		}
+	}
+	return requirements
+}
+
+// SleepPodMinResource returns the resources requested by a sleep pod as a task group minResource.
+func SleepPodMinResource(conf SleepPodConfig) map[string]resource.Quantity {
+	minResource := map[string]resource.Quantity{
+		"cpu":    resource.MustParse(strconv.FormatInt(conf.CPU, 10) + "m"),
+		"memory": resource.MustParse(strconv.FormatInt(conf.Mem, 10) + "M"),
+	}
+	for name, quantity := range conf.ExtendedResources {
+		minResource[string(name)] = quantity.DeepCopy()
+	}
+	return minResource
+}
+
 type TestPodConfig struct {
 	Name                       string
 	Namespace                  string
@@ -234,11 +314,13 @@ func PodAnnotationToMap(annotations *PodAnnotation) (map[string]string, error) {
 	}
 
 	// Add TaskGroup definition with string
-	taskGroupJSON, err := json.Marshal(annotations.TaskGroups)
-	if err != nil {
-		return nil, err
+	if len(annotations.TaskGroups) > 0 {
+		taskGroupJSON, err := json.Marshal(annotations.TaskGroups)
+		if err != nil {
+			return nil, err
+		}
+		annotationsMap[TaskGroups] = string(taskGroupJSON)
 	}
-	annotationsMap[TaskGroups] = string(taskGroupJSON)
 
 	// Add non-YK annotations
 	for annKey, annVal := range annotations.Other {
@@ -0,0 +1,81 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+)
+
+type PriorityClassConfig struct {
+	Name  string
+	Value int32
+	// PreemptionPolicy defaults to PreemptLowerPriority if not set
+	PreemptionPolicy v1.PreemptionPolicy
+	// AllowPreemption sets the YuniKorn allow-preemption annotation if not nil
+	AllowPreemption *bool
+	GlobalDefault   bool
+	Description     string
+	Labels          map[string]string
+}
+
+func InitPriorityClassConfig(conf PriorityClassConfig) *schedulingv1.PriorityClass {
+	pc := &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   conf.Name,
+			Labels: conf.Labels,
+		},
+		Value:         conf.Value,
+		GlobalDefault: conf.GlobalDefault,
+		Description:   conf.Description,
+	}
+	if conf.PreemptionPolicy != "" {
+		policy := conf.PreemptionPolicy
+		pc.PreemptionPolicy = &policy
+	}
+	if conf.AllowPreemption != nil {
+		pc.Annotations = map[string]string{
+			constants.AnnotationAllowPreemption: strconv.FormatBool(*conf.AllowPreemption),
+		}
+	}
+	return pc
+}
+
+// PriorityTestPodConfig returns the config of a pod with the PriorityClass in an application of its own.
+// The pod is placed in the queue if set, the PriorityClass is only set if not empty.
+func PriorityTestPodConfig(namespace, queue, priorityClassName string, resources *v1.ResourceRequirements) TestPodConfig {
+	suffix := common.RandSeq(5)
+	labels := map[string]string{constants.LabelApplicationID: "app-priority-" + suffix}
+	if queue != "" {
+		labels[constants.LabelQueueName] = queue
+	}
+	return TestPodConfig{
+		Name:              "test-priority-" + suffix,
+		Namespace:         namespace,
+		Labels:            labels,
+		Resources:         resources,
+		PriorityClassName: priorityClassName,
+	}
+}
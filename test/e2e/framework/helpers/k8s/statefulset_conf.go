@@ -0,0 +1,114 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type StatefulSetConfig struct {
+	Name      string
+	Namespace string
+	Replicas  int32
+	// PodManagementPolicy defaults to OrderedReady: pods are created one by one in ordinal order
+	PodManagementPolicy appsv1.PodManagementPolicyType
+	PodConfig           TestPodConfig
+	// VolumeSize adds a volume claim template, and a matching volume mount, to the pods if set
+	VolumeSize       string
+	StorageClassName string
+	MountPath        string
+}
+
+const statefulSetVolumeName = "data"
+
+func InitStatefulSetConfig(conf StatefulSetConfig) (*appsv1.StatefulSet, error) {
+	pod, err := InitTestPod(conf.PodConfig)
+	if err != nil {
+		return nil, err
+	}
+	// the pod template labels are used as the selector of the StatefulSet
+	labels := make(map[string]string)
+	for k, v := range pod.Labels {
+		labels[k] = v
+	}
+	labels["app"] = conf.Name
+	pod.ObjectMeta.Labels = labels
+	// the StatefulSet controller names the pods, the name of the template must be empty
+	pod.ObjectMeta.Name = ""
+
+	if conf.PodManagementPolicy == "" {
+		conf.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+	}
+
+	statefulSet := appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      conf.Name,
+			Namespace: conf.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:            &conf.Replicas,
+			ServiceName:         conf.Name,
+			PodManagementPolicy: conf.PodManagementPolicy,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": conf.Name},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: pod.ObjectMeta,
+				Spec:       pod.Spec,
+			},
+		},
+	}
+	// StatefulSet only supports "Always"
+	statefulSet.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyAlways
+
+	if conf.VolumeSize != "" {
+		size, err := resource.ParseQuantity(conf.VolumeSize)
+		if err != nil {
+			return nil, err
+		}
+		claim := v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: statefulSetVolumeName,
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: size},
+				},
+			},
+		}
+		if conf.StorageClassName != "" {
+			claim.Spec.StorageClassName = &conf.StorageClassName
+		}
+		if conf.MountPath == "" {
+			conf.MountPath = "/data"
+		}
+		statefulSet.Spec.VolumeClaimTemplates = []v1.PersistentVolumeClaim{claim}
+		containers := statefulSet.Spec.Template.Spec.Containers
+		containers[0].VolumeMounts = append(containers[0].VolumeMounts, v1.VolumeMount{
+			Name:      statefulSetVolumeName,
+			MountPath: conf.MountPath,
+		})
+	}
+
+	return &statefulSet, nil
+}
@@ -0,0 +1,351 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package kubemark simulates large clusters for scale tests. Hollow nodes are registered with the API server
+// as regular nodes, a fake kubelet running in the test process keeps them ready and runs the pods bound to them.
+// No containers are started: the pods are reported running as soon as they are bound, which allows scheduling
+// throughput tests with thousands of nodes against a cluster without the hardware for it.
+package kubemark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+)
+
+const (
+	// LabelHollowNode is set on all hollow nodes, use it as node selector to run the test pods on hollow nodes only
+	LabelHollowNode = "yunikorn.apache.org/hollow-node"
+
+	DefaultNamePrefix        = "hollow-node"
+	DefaultHeartbeatInterval = 10 * time.Second
+
+	nodeLeaseNamespace   = "kube-node-lease"
+	nodeLeaseDuration    = 40
+	registerParallelism  = 16
+	hollowPodIPPrefix    = "10.255"
+	hollowKubeletVersion = "v0.0.0-hollow"
+)
+
+// HollowNodeConfig describes the hollow nodes to register.
+type HollowNodeConfig struct {
+	// NamePrefix of the nodes, the nodes are named <prefix>-<index>
+	NamePrefix string
+	// Count of nodes to register
+	Count int
+	// Capacity of each node, e.g. cpu, memory and pods
+	Capacity v1.ResourceList
+	// Labels set on each node in addition to LabelHollowNode
+	Labels map[string]string
+	// Taints set on each node, keeps workloads that are not part of the test off the hollow nodes
+	Taints []v1.Taint
+	// HeartbeatInterval of the fake kubelet, must be well below the node lease duration of 40 seconds
+	HeartbeatInterval time.Duration
+}
+
+// DefaultCapacity returns the capacity of a small node: 4 cores, 16GiB memory and 110 pods.
+func DefaultCapacity() v1.ResourceList {
+	return v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("4"),
+		v1.ResourceMemory: resource.MustParse("16Gi"),
+		v1.ResourcePods:   resource.MustParse("110"),
+	}
+}
+
+// HollowCluster is a set of hollow nodes with the fake kubelet that serves them.
+type HollowCluster struct {
+	clientSet *kubernetes.Clientset
+	config    HollowNodeConfig
+	names     []string
+	nodeNames map[string]bool
+	podCount  atomic.Uint32
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	stopOnce  sync.Once
+}
+
+// StartHollowNodes registers the hollow nodes and starts the fake kubelet. The nodes are ready when the call
+// returns. Call Cleanup on the returned cluster to remove the nodes, also when an error is returned.
+func StartHollowNodes(kClient *k8s.KubeCtl, config HollowNodeConfig) (*HollowCluster, error) {
+	if config.NamePrefix == "" {
+		config.NamePrefix = DefaultNamePrefix
+	}
+	if config.Capacity == nil {
+		config.Capacity = DefaultCapacity()
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	hc := &HollowCluster{
+		clientSet: kClient.GetClient(),
+		config:    config,
+		names:     make([]string, 0, config.Count),
+		nodeNames: make(map[string]bool, config.Count),
+		stopChan:  make(chan struct{}),
+	}
+	for i := 0; i < config.Count; i++ {
+		name := fmt.Sprintf("%s-%d", config.NamePrefix, i)
+		hc.names = append(hc.names, name)
+		hc.nodeNames[name] = true
+	}
+
+	names := hc.names
+	errs := make([]error, len(names))
+	workqueue.ParallelizeUntil(context.TODO(), registerParallelism, len(names), func(i int) {
+		labels := map[string]string{LabelHollowNode: "true"}
+		for k, v := range config.Labels {
+			labels[k] = v
+		}
+		if _, err := RegisterFakeNode(kClient, names[i], config.Capacity, labels, config.Taints); err != nil {
+			errs[i] = err
+			return
+		}
+		errs[i] = hc.renewLease(names[i])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return hc, err
+		}
+	}
+	fmt.Fprintf(ginkgo.GinkgoWriter, "Registered %d hollow nodes with prefix %s\n", len(names), config.NamePrefix)
+
+	hc.wg.Add(1)
+	go hc.heartbeat()
+	if err := hc.runPods(); err != nil {
+		return hc, err
+	}
+	return hc, nil
+}
+
+// RegisterFakeNode creates a node with the given capacity and marks it ready. The node is not served by a kubelet:
+// the node lifecycle controller marks it unreachable unless its lease is renewed, see StartHollowNodes.
+func RegisterFakeNode(kClient *k8s.KubeCtl, name string, capacity v1.ResourceList, labels map[string]string, taints []v1.Taint) (*v1.Node, error) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: v1.NodeSpec{
+			Taints: taints,
+		},
+	}
+	node, err := kClient.GetClient().CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hollow node %s: %w", name, err)
+	}
+	now := metav1.Now()
+	node.Status = v1.NodeStatus{
+		Capacity:    capacity,
+		Allocatable: capacity,
+		Phase:       v1.NodeRunning,
+		Conditions: []v1.NodeCondition{{
+			Type:               v1.NodeReady,
+			Status:             v1.ConditionTrue,
+			Reason:             "KubeletReady",
+			Message:            "hollow kubelet is posting ready status",
+			LastHeartbeatTime:  now,
+			LastTransitionTime: now,
+		}},
+		NodeInfo: v1.NodeSystemInfo{
+			KubeletVersion:   hollowKubeletVersion,
+			KubeProxyVersion: hollowKubeletVersion,
+			OperatingSystem:  "linux",
+			Architecture:     "amd64",
+		},
+	}
+	node, err = kClient.GetClient().CoreV1().Nodes().UpdateStatus(context.TODO(), node, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set status of hollow node %s: %w", name, err)
+	}
+	return node, nil
+}
+
+// NodeNames returns the names of the hollow nodes.
+func (hc *HollowCluster) NodeNames() []string {
+	return append([]string(nil), hc.names...)
+}
+
+// Cleanup stops the fake kubelet and deletes the hollow nodes with their leases. The pods bound to the nodes are
+// removed by the pod garbage collector of the cluster once the nodes are gone.
+func (hc *HollowCluster) Cleanup() error {
+	hc.stopOnce.Do(func() {
+		close(hc.stopChan)
+	})
+	hc.wg.Wait()
+
+	names := hc.names
+	errs := make([]error, len(names))
+	workqueue.ParallelizeUntil(context.TODO(), registerParallelism, len(names), func(i int) {
+		err := hc.clientSet.CoreV1().Nodes().Delete(context.TODO(), names[i], metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs[i] = fmt.Errorf("failed to delete hollow node %s: %w", names[i], err)
+			return
+		}
+		err = hc.clientSet.CoordinationV1().Leases(nodeLeaseNamespace).Delete(context.TODO(), names[i], metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs[i] = fmt.Errorf("failed to delete lease of hollow node %s: %w", names[i], err)
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(ginkgo.GinkgoWriter, "Deleted %d hollow nodes with prefix %s\n", len(names), hc.config.NamePrefix)
+	return nil
+}
+
+// heartbeat renews the node leases until the cluster is stopped, which keeps the nodes ready.
+func (hc *HollowCluster) heartbeat() {
+	defer hc.wg.Done()
+	ticker := time.NewTicker(hc.config.HeartbeatInterval)
+	defer ticker.Stop()
+	names := hc.names
+	for {
+		select {
+		case <-hc.stopChan:
+			return
+		case <-ticker.C:
+			workqueue.ParallelizeUntil(context.TODO(), registerParallelism, len(names), func(i int) {
+				if err := hc.renewLease(names[i]); err != nil {
+					fmt.Fprintf(ginkgo.GinkgoWriter, "Failed to renew lease of hollow node %s: %v\n", names[i], err)
+				}
+			})
+		}
+	}
+}
+
+// renewLease creates or renews the lease of the node.
+func (hc *HollowCluster) renewLease(name string) error {
+	leases := hc.clientSet.CoordinationV1().Leases(nodeLeaseNamespace)
+	now := metav1.NewMicroTime(time.Now())
+	lease, err := leases.Get(context.TODO(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		duration := int32(nodeLeaseDuration)
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &name,
+				LeaseDurationSeconds: &duration,
+				RenewTime:            &now,
+			},
+		}
+		_, err = leases.Create(context.TODO(), lease, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(context.TODO(), lease, metav1.UpdateOptions{})
+	return err
+}
+
+// runPods watches the pods bound to the hollow nodes: bound pods are reported running, deleted pods are removed
+// as no kubelet confirms their termination.
+func (hc *HollowCluster) runPods() error {
+	factory := informers.NewSharedInformerFactory(hc.clientSet, 0)
+	podInformer := factory.Core().V1().Pods().Informer()
+	handle := func(obj interface{}) {
+		pod, ok := obj.(*v1.Pod)
+		if !ok || !hc.nodeNames[pod.Spec.NodeName] {
+			return
+		}
+		if pod.DeletionTimestamp != nil {
+			hc.deletePod(pod)
+			return
+		}
+		if pod.Status.Phase == v1.PodPending {
+			hc.startPod(pod)
+		}
+	}
+	_, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(_, newObj interface{}) { handle(newObj) },
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch the pods of the hollow nodes: %w", err)
+	}
+	factory.Start(hc.stopChan)
+	if !cache.WaitForCacheSync(hc.stopChan, podInformer.HasSynced) {
+		return fmt.Errorf("failed to sync the pods of the hollow nodes")
+	}
+	return nil
+}
+
+// startPod reports the pod as running on its hollow node.
+func (hc *HollowCluster) startPod(pod *v1.Pod) {
+	now := metav1.Now()
+	podCopy := pod.DeepCopy()
+	podCopy.Status.Phase = v1.PodRunning
+	// pod IPs only need to be unique within the test, not routable
+	ip := hc.podCount.Add(1)
+	podCopy.Status.HostIP = hollowPodIPPrefix + ".0.1"
+	podCopy.Status.PodIP = fmt.Sprintf("%s.%d.%d", hollowPodIPPrefix, (ip>>8)%256, ip%256)
+	podCopy.Status.StartTime = &now
+	podCopy.Status.Conditions = []v1.PodCondition{
+		{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: now},
+		{Type: v1.PodInitialized, Status: v1.ConditionTrue, LastTransitionTime: now},
+		{Type: v1.ContainersReady, Status: v1.ConditionTrue, LastTransitionTime: now},
+		{Type: v1.PodReady, Status: v1.ConditionTrue, LastTransitionTime: now},
+	}
+	podCopy.Status.ContainerStatuses = make([]v1.ContainerStatus, 0, len(pod.Spec.Containers))
+	started := true
+	for _, container := range pod.Spec.Containers {
+		podCopy.Status.ContainerStatuses = append(podCopy.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:    container.Name,
+			Image:   container.Image,
+			Ready:   true,
+			Started: &started,
+			State:   v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: now}},
+		})
+	}
+	_, err := hc.clientSet.CoreV1().Pods(pod.Namespace).UpdateStatus(context.TODO(), podCopy, metav1.UpdateOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) && !k8serrors.IsConflict(err) {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "Failed to start pod %s/%s on hollow node %s: %v\n",
+			pod.Namespace, pod.Name, pod.Spec.NodeName, err)
+	}
+}
+
+// deletePod removes a terminating pod from its hollow node.
+func (hc *HollowCluster) deletePod(pod *v1.Pod) {
+	zero := int64(0)
+	err := hc.clientSet.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{
+		GracePeriodSeconds: &zero,
+		Preconditions:      metav1.NewUIDPreconditions(string(pod.UID)),
+	})
+	if err != nil && !k8serrors.IsNotFound(err) && !k8serrors.IsConflict(err) {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "Failed to delete pod %s/%s from hollow node %s: %v\n",
+			pod.Namespace, pod.Name, pod.Spec.NodeName, err)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+)
+
+// KillAndRestartScheduler simulates a scheduler crash in the middle of a test. The scheduler pod is deleted and
+// the call waits for the replacement pod to be running and the scheduler to report healthy. The port-forward to
+// the killed pod is always closed, on success a new port-forward to the replacement pod is running.
+func KillAndRestartScheduler(kClient *k8s.KubeCtl, timeout time.Duration) error {
+	ykNS := configmanager.YuniKornTestConfig.YkNamespace
+	selector := fmt.Sprintf("component=%s", configmanager.YKScheduler)
+
+	// the forward breaks when the pod is deleted, close it first to not leak it on failure
+	kClient.KillPortForwardProcess()
+
+	schedulerPodName, err := GetSchedulerPodName(*kClient)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ginkgo.GinkgoWriter, "Killing scheduler pod %s\n", schedulerPodName)
+	if err = kClient.DeletePod(schedulerPodName, ykNS); err != nil {
+		return fmt.Errorf("failed to delete scheduler pod %s: %w", schedulerPodName, err)
+	}
+	if err = kClient.WaitForPodBySelector(ykNS, selector, timeout); err != nil {
+		return fmt.Errorf("scheduler pod was not recreated: %w", err)
+	}
+	if err = kClient.WaitForPodBySelectorRunning(ykNS, selector, int(timeout.Seconds())); err != nil {
+		return fmt.Errorf("scheduler pod is not running: %w", err)
+	}
+
+	return waitForSchedulerHealthy(kClient, timeout)
+}
+
+// waitForSchedulerHealthy sets up the port-forward to the scheduler pod and waits for the scheduler to report
+// healthy. The scheduler might not listen yet when the pod is running, the forward and health check are retried
+// until they succeed or the timeout expires.
+func waitForSchedulerHealthy(kClient *k8s.KubeCtl, timeout time.Duration) error {
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		if fwdErr := kClient.PortForwardYkSchedulerPod(); fwdErr != nil {
+			fmt.Fprintf(ginkgo.GinkgoWriter, "Port-forward to scheduler not ready: %v\n", fwdErr)
+			kClient.KillPortForwardProcess()
+			return false, nil
+		}
+		restClient := RClient{}
+		healthCheck, healthErr := restClient.GetHealthCheck()
+		if healthErr != nil || !healthCheck.Healthy {
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		failed, _ := GetFailedHealthChecks()
+		kClient.KillPortForwardProcess()
+		return fmt.Errorf("scheduler did not become healthy: %w %s", err, failed)
+	}
+	return nil
+}
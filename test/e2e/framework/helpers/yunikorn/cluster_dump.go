@@ -0,0 +1,120 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+)
+
+// maxSpecDirLength caps the length of the spec part of the artifact directory name
+const maxSpecDirLength = 100
+
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// DumpClusterInfo writes the state of the cluster for the current spec into a directory below the configured
+// artifact directory, the directory is uploaded as a CI artifact. The dump contains per namespace the pod specs,
+// the events and the application DAOs, and the scheduler logs, queue DAOs and node DAOs.
+// All parts are dumped even if one of them fails, the errors are combined in the returned error.
+// Returns the directory written to, or an empty string if no artifact directory is configured.
+func DumpClusterInfo(namespaces []string) (string, error) {
+	if configmanager.YuniKornTestConfig.ArtifactDir == "" {
+		return "", nil
+	}
+	dir := filepath.Join(configmanager.YuniKornTestConfig.ArtifactDir, specDirName(ginkgo.CurrentSpecReport().FullText()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	var kClient k8s.KubeCtl
+	if err := kClient.SetClient(); err != nil {
+		return dir, err
+	}
+	var restClient RClient
+	var errs []error
+	for _, ns := range namespaces {
+		pods, err := kClient.GetPods(ns)
+		errs = append(errs, writeDumpFile(dir, "pods-"+ns+".json", pods, err))
+		events, err := kClient.GetEvents(ns)
+		errs = append(errs, writeDumpFile(dir, "events-"+ns+".json", events, err))
+		apps, err := restClient.GetApps(DefaultPartition, "root."+ns)
+		errs = append(errs, writeDumpFile(dir, "apps-"+ns+".json", apps, err))
+	}
+	queues, err := restClient.GetQueues(DefaultPartition)
+	errs = append(errs, writeDumpFile(dir, "queues.json", queues, err))
+	nodes, err := restClient.GetNodes(DefaultPartition)
+	errs = append(errs, writeDumpFile(dir, "nodes.json", nodes, err))
+	errs = append(errs, dumpSchedulerLogs(kClient, dir))
+	return dir, errors.Join(errs...)
+}
+
+// specDirName returns a unique directory name for the spec that is safe to use on any file system
+func specDirName(spec string) string {
+	name := unsafePathChars.ReplaceAllString(spec, "_")
+	if len(name) > maxSpecDirLength {
+		name = name[:maxSpecDirLength]
+	}
+	return fmt.Sprintf("%s-%s", name, time.Now().Format("20060102-150405"))
+}
+
+// writeDumpFile writes the object as indented JSON, or the error that occurred retrieving the object.
+// The returned error is the retrieval or the write error, nil if the object was written.
+func writeDumpFile(dir, name string, obj interface{}, getErr error) error {
+	var content []byte
+	if getErr != nil {
+		content = []byte(getErr.Error())
+	} else {
+		var err error
+		if content, err = json.MarshalIndent(obj, "", "    "); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), content, configmanager.LogPerm); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if getErr != nil {
+		return fmt.Errorf("%s: %w", name, getErr)
+	}
+	return nil
+}
+
+func dumpSchedulerLogs(kClient k8s.KubeCtl, dir string) error {
+	schedulerPod, err := GetSchedulerPodName(kClient)
+	if err != nil {
+		return fmt.Errorf("scheduler.log: %w", err)
+	}
+	logs, err := kClient.GetPodLogs(schedulerPod, configmanager.YuniKornTestConfig.YkNamespace, configmanager.YKSchedulerContainer)
+	if err != nil {
+		return fmt.Errorf("scheduler.log: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "scheduler.log"), logs, configmanager.LogPerm); err != nil {
+		return fmt.Errorf("scheduler.log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// ConfigTransaction snapshots the YuniKorn configuration and restores it when the transaction ends.
+// The rollback is registered as a ginkgo cleanup when the transaction begins, which means the
+// configuration is restored even if the spec fails or panics half way through.
+//
+// Usage inside a spec or setup node:
+//
+//	tx := yunikorn.BeginConfigTransaction()
+//	tx.Commit(func(sc *configs.SchedulerConfig) error { ... })
+type ConfigTransaction struct {
+	snapshot   *v1.ConfigMap
+	rolledBack bool
+}
+
+// BeginConfigTransaction takes a snapshot of the current YuniKorn configuration.
+// Must be called from a ginkgo setup or subject node to allow the rollback to be registered.
+func BeginConfigTransaction() *ConfigTransaction {
+	Ω(k.SetClient()).To(BeNil())
+	By("Port-forward the scheduler pod")
+	Ω(k.PortForwardYkSchedulerPod()).NotTo(HaveOccurred())
+
+	By("Snapshot the YuniKorn configuration")
+	c, err := k.GetConfigMaps(configmanager.YuniKornTestConfig.YkNamespace, configmanager.DefaultYuniKornConfigMap)
+	Ω(err).NotTo(HaveOccurred())
+	Ω(c).NotTo(BeNil())
+
+	tx := &ConfigTransaction{snapshot: c.DeepCopy()}
+	ginkgo.DeferCleanup(tx.Rollback)
+	return tx
+}
+
+// Commit replaces the scheduler config with a basic config, modified by the mutator, and waits for the
+// scheduler to load the new config. Commit can be called multiple times within one transaction,
+// the rollback always restores the snapshot taken at the start of the transaction.
+func (tx *ConfigTransaction) Commit(mutator func(sc *configs.SchedulerConfig) error) {
+	Ω(tx.rolledBack).To(gomega.BeFalse(), "commit on a transaction that was rolled back")
+	By("Enabling new scheduling config")
+	c, err := k.GetConfigMaps(configmanager.YuniKornTestConfig.YkNamespace, configmanager.DefaultYuniKornConfigMap)
+	Ω(err).NotTo(HaveOccurred())
+	Ω(c).NotTo(BeNil())
+
+	sc := common.CreateBasicConfigMap()
+	Ω(mutator(sc)).NotTo(HaveOccurred())
+
+	configStr, yamlErr := common.ToYAML(sc)
+	Ω(yamlErr).NotTo(HaveOccurred())
+	if c.Data == nil {
+		c.Data = make(map[string]string)
+	}
+	c.Data[configmanager.DefaultPolicyGroup] = configStr
+	Ω(UpdateConfigMapAndWait(c, 2*time.Minute)).NotTo(HaveOccurred())
+}
+
+// Rollback restores the configuration from the snapshot if it was changed. Only the keys that differ
+// from the snapshot are updated or removed. Calling Rollback more than once is a no-op.
+func (tx *ConfigTransaction) Rollback() {
+	if tx.rolledBack {
+		return
+	}
+	tx.rolledBack = true
+
+	Ω(k.SetClient()).To(BeNil())
+	c, err := k.GetConfigMaps(configmanager.YuniKornTestConfig.YkNamespace, configmanager.DefaultYuniKornConfigMap)
+	Ω(err).NotTo(HaveOccurred())
+	Ω(c).NotTo(BeNil())
+
+	changed := configMapDataDiff(tx.snapshot.Data, c.Data)
+	if len(changed) == 0 {
+		By("YuniKorn configuration unchanged, nothing to restore")
+		return
+	}
+	By("Restoring the YuniKorn configuration, changed keys: " + strings.Join(changed, ", "))
+
+	data := make(map[string]string, len(tx.snapshot.Data))
+	for key, value := range tx.snapshot.Data {
+		data[key] = value
+	}
+	c.Data = data
+	Ω(UpdateConfigMapAndWait(c, 2*time.Minute)).NotTo(HaveOccurred())
+}
+
+// configMapDataDiff returns the sorted keys that are added, removed or changed between the two data maps.
+func configMapDataDiff(before, after map[string]string) []string {
+	changed := make([]string, 0)
+	for key, value := range before {
+		if current, ok := after[key]; !ok || current != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,77 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+)
+
+// HelmUpgradeToPrevious replaces the running YuniKorn release in place with the previous release set via the
+// upgrade-from-version flag. The chart defaults of that release are used, including the released images.
+func HelmUpgradeToPrevious(kClient *k8s.KubeCtl, timeout time.Duration) error {
+	cfg := configmanager.YuniKornTestConfig
+	return helmUpgrade(kClient, configmanager.ChartName, timeout,
+		"--repo", cfg.UpgradeChartRepo, "--version", cfg.UpgradeFromVersion)
+}
+
+// HelmUpgradeToCurrent upgrades the running YuniKorn release in place to the build under test, using the local
+// chart and values set via the chart-path and chart-values flags.
+func HelmUpgradeToCurrent(kClient *k8s.KubeCtl, timeout time.Duration) error {
+	cfg := configmanager.YuniKornTestConfig
+	if cfg.ChartPath == "" {
+		return fmt.Errorf("chart path of the build under test is not set")
+	}
+	var args []string
+	if cfg.ChartValues != "" {
+		args = append(args, "--set", cfg.ChartValues)
+	}
+	return helmUpgrade(kClient, cfg.ChartPath, timeout, args...)
+}
+
+// helmUpgrade runs a helm upgrade of the YuniKorn release to the chart. The values of the running release are
+// reset, only the values passed in are applied. The call waits for the release to be rolled out and the scheduler
+// to report healthy, on success a new port-forward to the scheduler pod is running.
+func helmUpgrade(kClient *k8s.KubeCtl, chart string, timeout time.Duration, extraArgs ...string) error {
+	cfg := configmanager.YuniKornTestConfig
+	// the forward breaks when the scheduler pod is replaced, close it first to not leak it on failure
+	kClient.KillPortForwardProcess()
+
+	args := []string{"upgrade", cfg.HelmRelease, chart,
+		"--namespace", cfg.YkNamespace,
+		"--reset-values",
+		"--wait",
+		"--timeout", timeout.String(),
+	}
+	args = append(args, extraArgs...)
+	fmt.Fprintf(ginkgo.GinkgoWriter, "Running %s %s\n", cfg.HelmBin, strings.Join(args, " "))
+	out, err := exec.Command(cfg.HelmBin, args...).CombinedOutput()
+	fmt.Fprintf(ginkgo.GinkgoWriter, "%s\n", out)
+	if err != nil {
+		return fmt.Errorf("helm upgrade of release %s to chart %s failed: %w", cfg.HelmRelease, chart, err)
+	}
+	return waitForSchedulerHealthy(kClient, timeout)
+}
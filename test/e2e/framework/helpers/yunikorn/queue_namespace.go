@@ -0,0 +1,140 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+
+	"github.com/onsi/ginkgo/v2"
+)
+
+// QueueNamespace is a namespace and a queue of the same name under the root queue, owned by a single spec.
+// The tag placement rule places the pods of the namespace in the queue. Specs that only use their own
+// QueueNamespace can run in parallel: the queue is added to and removed from the shared configuration,
+// other queues in the configuration are left untouched.
+//
+// Usage inside a spec or setup node:
+//
+//	qns := yunikorn.NewQueueNamespace("sleep", func(q *configs.QueueConfig) { ... })
+//	k.CreatePod(pod, qns.Name)
+type QueueNamespace struct {
+	// Name of the namespace and the queue
+	Name string
+	// QueuePath is the full path of the queue in the scheduler
+	QueuePath string
+}
+
+// NewQueueNamespace creates a uniquely named namespace and adds the queue, configured by the optional mutator,
+// to the scheduler configuration. Must be called from a ginkgo setup or subject node: the namespace and the
+// queue are removed by a cleanup registered with ginkgo, also when the spec fails.
+func NewQueueNamespace(prefix string, mutator func(q *configs.QueueConfig)) *QueueNamespace {
+	Ω(k.SetClient()).To(BeNil())
+	// the port-forward of the suite is reused, each parallel process forwards its own local port
+	By("Port-forward the scheduler pod")
+	Ω(k.PortForwardYkSchedulerPod()).NotTo(HaveOccurred())
+
+	name := fmt.Sprintf("%s-%d-%s", prefix, ginkgo.GinkgoParallelProcess(), common.RandSeq(5))
+	qns := &QueueNamespace{
+		Name:      name,
+		QueuePath: configmanager.RootQueue + "." + name,
+	}
+	ginkgo.DeferCleanup(qns.tearDown)
+
+	By("Adding queue " + qns.QueuePath)
+	queue := configs.QueueConfig{Name: name}
+	if mutator != nil {
+		mutator(&queue)
+	}
+	Ω(updateSchedulerConfig(func(sc *configs.SchedulerConfig) error {
+		return common.AddQueue(sc, configmanager.DefaultPartition, configmanager.RootQueue, queue)
+	})).NotTo(HaveOccurred())
+	Ω(waitForQueue(qns.QueuePath, true, 2*time.Minute)).NotTo(HaveOccurred())
+
+	By("Creating namespace " + name)
+	ns, err := k.CreateNamespace(name, nil)
+	Ω(err).NotTo(HaveOccurred())
+	Ω(ns.Status.Phase).To(Equal(v1.NamespaceActive))
+	return qns
+}
+
+// tearDown removes the namespace with its pods and the queue from the scheduler configuration
+func (qns *QueueNamespace) tearDown() {
+	Ω(k.SetClient()).To(BeNil())
+	By("Tearing down namespace " + qns.Name)
+	Ω(k.TearDownNamespace(qns.Name)).NotTo(HaveOccurred())
+
+	By("Removing queue " + qns.QueuePath)
+	Ω(updateSchedulerConfig(func(sc *configs.SchedulerConfig) error {
+		return common.RemoveQueue(sc, configmanager.DefaultPartition, configmanager.RootQueue, qns.Name)
+	})).NotTo(HaveOccurred())
+	// the queue is drained and removed once the pods of the namespace are gone
+	Ω(waitForQueue(qns.QueuePath, false, 2*time.Minute)).NotTo(HaveOccurred())
+}
+
+// updateSchedulerConfig applies the mutator to the configuration in the YuniKorn ConfigMap. The ConfigMap is
+// shared between parallel processes: a conflicting update is retried with the latest version of the ConfigMap.
+// The checksum of the active configuration cannot be used to wait for the update, it might already have been
+// replaced by the update of another process. Callers wait for the effect of their own change instead.
+func updateSchedulerConfig(mutator func(sc *configs.SchedulerConfig) error) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		c, err := k.GetConfigMap(configmanager.DefaultYuniKornConfigMap, configmanager.YuniKornTestConfig.YkNamespace)
+		if err != nil {
+			return err
+		}
+		sc := &configs.SchedulerConfig{}
+		if err = yaml.Unmarshal([]byte(c.Data[configmanager.DefaultPolicyGroup]), sc); err != nil {
+			return err
+		}
+		if err = mutator(sc); err != nil {
+			return err
+		}
+		configStr, err := common.ToYAML(sc)
+		if err != nil {
+			return err
+		}
+		if c.Data == nil {
+			c.Data = make(map[string]string)
+		}
+		c.Data[configmanager.DefaultPolicyGroup] = configStr
+		_, err = k.UpdateConfigMap(c, configmanager.YuniKornTestConfig.YkNamespace)
+		return err
+	})
+}
+
+// waitForQueue waits until the queue is present in, or removed from, the default partition
+func waitForQueue(queuePath string, present bool, timeout time.Duration) error {
+	restClient := RClient{}
+	return wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		root, err := restClient.GetQueues(configmanager.DefaultPartition)
+		if err != nil || root == nil {
+			return false, nil // retry, the scheduler might not be reachable
+		}
+		return (findQueue(root, queuePath) != nil) == present, nil
+	})
+}
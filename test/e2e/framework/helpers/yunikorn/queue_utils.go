@@ -0,0 +1,128 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+// QueueResourceType selects which resource values of a queue are compared
+type QueueResourceType string
+
+const (
+	QueueAllocated  QueueResourceType = "allocated"
+	QueuePending    QueueResourceType = "pending"
+	QueueGuaranteed QueueResourceType = "guaranteed"
+	QueueMax        QueueResourceType = "max"
+)
+
+// GetQueueResources returns the resource values of the given type of the queue, never nil.
+func GetQueueResources(queue *dao.PartitionQueueDAOInfo, resType QueueResourceType) map[string]int64 {
+	var resources map[string]int64
+	switch resType {
+	case QueueAllocated:
+		resources = queue.AllocatedResource
+	case QueuePending:
+		resources = queue.PendingResource
+	case QueueGuaranteed:
+		resources = queue.GuaranteedResource
+	case QueueMax:
+		resources = queue.MaxResource
+	}
+	if resources == nil {
+		return map[string]int64{}
+	}
+	return resources
+}
+
+// GetQueueByPath returns the queue with the fully qualified name, e.g. "root.parent.child", at any depth of
+// the hierarchy. The lookup is case-insensitive.
+func (c *RClient) GetQueueByPath(partition string, queuePath string) (*dao.PartitionQueueDAOInfo, error) {
+	root, err := c.GetQueues(partition)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no queues returned for partition %s", partition)
+	}
+	if queue := findQueue(root, strings.ToLower(queuePath)); queue != nil {
+		return queue, nil
+	}
+	return nil, fmt.Errorf("QueueInfo not found: %s", queuePath)
+}
+
+func findQueue(queue *dao.PartitionQueueDAOInfo, queuePath string) *dao.PartitionQueueDAOInfo {
+	name := strings.ToLower(queue.QueueName)
+	if name == queuePath {
+		return queue
+	}
+	// only descend into the parents of the queue
+	if !strings.HasPrefix(queuePath, name+".") {
+		return nil
+	}
+	for i := range queue.Children {
+		if found := findQueue(&queue.Children[i], queuePath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// WaitForQueueResourceUsage waits until the named resource of the given type of the queue has the expected value.
+// A resource that is not listed by the queue is treated as zero.
+func (c *RClient) WaitForQueueResourceUsage(partition string, queuePath string, resType QueueResourceType, resource string, expected int64, timeout time.Duration) error {
+	return c.WaitForQueueResources(partition, queuePath, resType, map[string]int64{resource: expected}, timeout)
+}
+
+// WaitForQueueResources waits until all resources listed in expected have the expected value for the given type
+// of the queue. Resources that are not listed in expected are ignored, resources that are not listed by the queue
+// are treated as zero. The error on timeout contains the last values retrieved from the scheduler.
+func (c *RClient) WaitForQueueResources(partition string, queuePath string, resType QueueResourceType, expected map[string]int64, timeout time.Duration) error {
+	var last map[string]int64
+	var lastErr error
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		queue, err := c.GetQueueByPath(partition, queuePath)
+		if err != nil {
+			// the queue might not exist yet, keep polling
+			lastErr = err
+			return false, nil
+		}
+		lastErr = nil
+		last = GetQueueResources(queue, resType)
+		for name, value := range expected {
+			if last[name] != value {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		if lastErr != nil {
+			return fmt.Errorf("%s resources of queue %s not as expected: %w", resType, queuePath, lastErr)
+		}
+		return fmt.Errorf("%s resources of queue %s not as expected, expected: %v, actual: %v", resType, queuePath, expected, last)
+	}
+	return nil
+}
@@ -26,24 +26,48 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 const DefaultPartition = "default"
 
+// DefaultRetryBackoff is used for requests of a client without a Backoff set: three attempts with a doubling delay.
+var DefaultRetryBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Steps:    3,
+}
+
 type RClient struct {
 	BaseURL   *url.URL
 	UserAgent string
+	// Backoff defines the retries of a request that failed to connect or got a server error.
+	// DefaultRetryBackoff is used if not set, set Steps to 1 to disable retries.
+	Backoff *wait.Backoff
 
 	httpClient *http.Client
 }
 
+// EventRecordDAO is a batch of events as returned by the events endpoint of the scheduler
+type EventRecordDAO struct {
+	InstanceUUID string            `json:"instanceUUID"`
+	LowestID     uint64            `json:"lowestID"`
+	HighestID    uint64            `json:"highestID"`
+	EventRecords []*si.EventRecord `json:"eventRecords"`
+}
+
 func (c *RClient) newRequest(method, path string, body interface{}) (*http.Request, error) {
 	rel := &url.URL{Path: path}
 	if c.BaseURL == nil {
@@ -76,14 +100,57 @@ func (c *RClient) newRequest(method, path string, body interface{}) (*http.Reque
 	req.Header.Set("User-Agent", c.UserAgent)
 	return req, nil
 }
+
+// do sends the request and decodes the response body into v. Requests that fail to connect or get a server error
+// are retried using the backoff of the client.
 func (c *RClient) do(req *http.Request, v interface{}) (*http.Response, error) {
-	resp, err := c.httpClient.Do(req)
+	backoff := DefaultRetryBackoff
+	if c.Backoff != nil {
+		backoff = *c.Backoff
+	}
+	for {
+		resp, err := c.httpClient.Do(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			defer resp.Body.Close()
+			err = json.NewDecoder(resp.Body).Decode(v)
+			return resp, err
+		}
+		if err == nil {
+			err = fmt.Errorf("%s %s failed with status %d", req.Method, req.URL.Path, resp.StatusCode)
+			resp.Body.Close()
+		}
+		if backoff.Steps <= 1 {
+			return resp, err
+		}
+		// the body of the request is consumed by the failed attempt
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		time.Sleep(backoff.Step())
+	}
+}
+
+// doTyped sends the request and decodes the response body into v. Unlike do, a response with a status other than
+// 200 OK is returned as an error.
+func (c *RClient) doTyped(req *http.Request, v interface{}) error {
+	var body json.RawMessage
+	resp, err := c.do(req, &body)
+	if resp == nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := dao.YAPIError{}
+		if err = json.Unmarshal(body, &apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("%s %s failed with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, apiErr.Message)
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(v)
-	return resp, err
+	return json.Unmarshal(body, v)
 }
 
 func (c *RClient) GetQueues(partition string) (*dao.PartitionQueueDAOInfo, error) {
@@ -106,6 +173,67 @@ func (c *RClient) GetHealthCheck() (dao.SchedulerHealthDAOInfo, error) {
 	return healthCheck, err
 }
 
+// GetHealthCheckInfo returns the result of the named health check of the scheduler
+func (c *RClient) GetHealthCheckInfo(name string) (*dao.HealthCheckInfo, error) {
+	healthCheck, err := c.GetHealthCheck()
+	if err != nil {
+		return nil, err
+	}
+	for i := range healthCheck.HealthChecks {
+		if healthCheck.HealthChecks[i].Name == name {
+			return &healthCheck.HealthChecks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("health check %s not found", name)
+}
+
+func (c *RClient) isSchedulerHealthy() wait.ConditionFunc {
+	return func() (bool, error) {
+		healthCheck, err := c.GetHealthCheck()
+		if err != nil {
+			return false, nil
+		}
+		return healthCheck.Healthy, nil
+	}
+}
+
+// WaitForSchedulerHealthy waits until all health checks of the scheduler succeed
+func (c *RClient) WaitForSchedulerHealthy(timeout time.Duration) error {
+	return wait.PollImmediate(time.Second, timeout, c.isSchedulerHealthy())
+}
+
+// GetEventBatch returns at most count events starting with the event with ID start
+func (c *RClient) GetEventBatch(start uint64, count uint64) (*EventRecordDAO, error) {
+	req, err := c.newRequest("GET", configmanager.EventsBatchPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Set("start", strconv.FormatUint(start, 10))
+	q.Set("count", strconv.FormatUint(count, 10))
+	req.URL.RawQuery = q.Encode()
+	events := &EventRecordDAO{}
+	if err = c.doTyped(req, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetEventsForObject returns the events in the scheduler for the object, like an application ID or a node name
+func (c *RClient) GetEventsForObject(objectID string, count uint64) ([]*si.EventRecord, error) {
+	batch, err := c.GetEventBatch(0, count)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*si.EventRecord, 0)
+	for _, event := range batch.EventRecords {
+		if event.ObjectID == objectID {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
 func (c *RClient) WaitforQueueToAppear(partition string, queueName string, timeout int) error {
 	if err := wait.PollImmediate(300*time.Millisecond, time.Duration(timeout)*time.Second, c.IsQueuePresent(partition, queueName)); err != nil {
 		return err
@@ -263,6 +391,57 @@ func (c *RClient) ValidateSchedulerConfig(cm v1.ConfigMap) (*dao.ValidateConfRes
 	return validateConfResponse, err
 }
 
+// GetConfig returns the queue configuration that is active in the scheduler, including its checksum
+func (c *RClient) GetConfig() (*configs.SchedulerConfig, error) {
+	req, err := c.newRequest("GET", configmanager.ConfigPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	config := &configs.SchedulerConfig{}
+	err = c.doTyped(req, config)
+	return config, err
+}
+
+// ConfigChecksum returns the checksum the core calculates for the queue configuration content
+func ConfigChecksum(content string) string {
+	config := &configs.SchedulerConfig{}
+	configs.SetChecksum([]byte(content), config)
+	return config.Checksum
+}
+
+// WaitForConfigChecksum waits until the scheduler has loaded the queue configuration content, an empty content loads
+// the default configuration. On timeout the error contains the difference between the content and the active
+// configuration, or the reason the content was rejected.
+func WaitForConfigChecksum(content string, timeout time.Duration) error {
+	if content == "" {
+		content = configs.DefaultSchedulerConfig
+	}
+	checksum := ConfigChecksum(content)
+	restClient := RClient{}
+	var active *configs.SchedulerConfig
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		// errors are ignored, the scheduler might be restarting: keep polling until the timeout
+		if config, err := restClient.GetConfig(); err == nil {
+			active = config
+		}
+		return active != nil && active.Checksum == checksum, nil
+	})
+	if err == nil {
+		return nil
+	}
+	if active == nil {
+		return fmt.Errorf("scheduler configuration not retrieved within %v: %w", timeout, err)
+	}
+	expected, parseErr := configs.ParseAndValidateConfig([]byte(content))
+	if parseErr != nil {
+		return fmt.Errorf("scheduler configuration checksum %s not updated to %s within %v, configuration rejected: %w",
+			active.Checksum, checksum, timeout, parseErr)
+	}
+	diff := cmp.Diff(expected, active, cmpopts.IgnoreFields(configs.SchedulerConfig{}, "Checksum"), cmpopts.EquateEmpty())
+	return fmt.Errorf("scheduler configuration checksum %s not updated to %s within %v, difference (-expected +active):\n%s",
+		active.Checksum, checksum, timeout, diff)
+}
+
 func isRootSched(policy string) wait.ConditionFunc {
 	return func() (bool, error) {
 		restClient := RClient{}
@@ -398,3 +577,178 @@ func (c *RClient) GetPartitions(partition string) (*dao.PartitionQueueDAOInfo, e
 	_, err = c.do(req, &partitions)
 	return partitions, err
 }
+
+// GetMetrics retrieves the prometheus metrics exposed by the scheduler. The returned map is keyed by the
+// series as exposed, e.g. `yunikorn_root_default_queue_app{state="released"}`.
+func (c *RClient) GetMetrics() (map[string]float64, error) {
+	req, err := c.newRequest("GET", configmanager.MetricsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseMetrics(string(body)), nil
+}
+
+// GetQueueAppMetric returns the value of the application metric of the queue for the given state,
+// e.g. the number of `allocated` or `released` containers. A metric that is not exposed yet is returned as 0.
+func (c *RClient) GetQueueAppMetric(queuePath string, state string) (float64, error) {
+	metrics, err := c.GetMetrics()
+	if err != nil {
+		return 0, err
+	}
+	name := fmt.Sprintf("yunikorn_%s_queue_app{state=\"%s\"}", formatMetricName(queuePath), state)
+	return metrics[name], nil
+}
+
+func (c *RClient) GetUsersUsage(partition string) ([]*dao.UserResourceUsageDAOInfo, error) {
+	req, err := c.newRequest("GET", fmt.Sprintf(configmanager.UsersUsagePath, partition), nil)
+	if err != nil {
+		return nil, err
+	}
+	var users []*dao.UserResourceUsageDAOInfo
+	_, err = c.do(req, &users)
+	return users, err
+}
+
+func (c *RClient) GetUserUsage(partition string, user string) (*dao.UserResourceUsageDAOInfo, error) {
+	req, err := c.newRequest("GET", fmt.Sprintf(configmanager.UserUsagePath, partition, url.PathEscape(user)), nil)
+	if err != nil {
+		return nil, err
+	}
+	userUsage := new(dao.UserResourceUsageDAOInfo)
+	err = c.doTyped(req, userUsage)
+	return userUsage, err
+}
+
+func (c *RClient) GetGroupsUsage(partition string) ([]*dao.GroupResourceUsageDAOInfo, error) {
+	req, err := c.newRequest("GET", fmt.Sprintf(configmanager.GroupsUsagePath, partition), nil)
+	if err != nil {
+		return nil, err
+	}
+	var groups []*dao.GroupResourceUsageDAOInfo
+	_, err = c.do(req, &groups)
+	return groups, err
+}
+
+func (c *RClient) GetGroupUsage(partition string, group string) (*dao.GroupResourceUsageDAOInfo, error) {
+	req, err := c.newRequest("GET", fmt.Sprintf(configmanager.GroupUsagePath, partition, url.PathEscape(group)), nil)
+	if err != nil {
+		return nil, err
+	}
+	groupUsage := new(dao.GroupResourceUsageDAOInfo)
+	err = c.doTyped(req, groupUsage)
+	return groupUsage, err
+}
+
+// GetQueueUsage returns the usage tracked for the queue path in the usage tree, nil if the queue is not tracked
+func GetQueueUsage(usage *dao.ResourceUsageDAOInfo, queuePath string) *dao.ResourceUsageDAOInfo {
+	if usage == nil {
+		return nil
+	}
+	if usage.QueuePath == queuePath {
+		return usage
+	}
+	for _, child := range usage.Children {
+		if found := GetQueueUsage(child, queuePath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// GetUsageResource returns the quantity of the resource type used in the usage tree node, 0 if not used
+func GetUsageResource(usage *dao.ResourceUsageDAOInfo, resourceName string) int64 {
+	if usage == nil || usage.ResourceUsage == nil {
+		return 0
+	}
+	return int64(usage.ResourceUsage.Resources[resourceName])
+}
+
+func (c *RClient) isUserUsageInDesiredState(partition string, user string, queuePath string, runningApps int) wait.ConditionFunc {
+	return func() (bool, error) {
+		// the list is used as a user without usage is removed from the tracker and not found
+		users, err := c.GetUsersUsage(partition)
+		if err != nil {
+			return false, nil // returning nil here for wait & loop
+		}
+		var queueUsage *dao.ResourceUsageDAOInfo
+		for _, userUsage := range users {
+			if userUsage.UserName == user {
+				queueUsage = GetQueueUsage(userUsage.Queues, queuePath)
+			}
+		}
+		if queueUsage == nil {
+			return runningApps == 0, nil
+		}
+		return len(queueUsage.RunningApplications) == runningApps, nil
+	}
+}
+
+// WaitForUserUsage waits until the user is tracked with the given number of running applications in the queue
+func (c *RClient) WaitForUserUsage(partition string, user string, queuePath string, runningApps int, timeout int) error {
+	return wait.PollImmediate(time.Millisecond*300, time.Duration(timeout)*time.Second, c.isUserUsageInDesiredState(partition, user, queuePath, runningApps))
+}
+
+func (c *RClient) isGroupUsageInDesiredState(partition string, group string, queuePath string, runningApps int) wait.ConditionFunc {
+	return func() (bool, error) {
+		groups, err := c.GetGroupsUsage(partition)
+		if err != nil {
+			return false, nil // returning nil here for wait & loop
+		}
+		var queueUsage *dao.ResourceUsageDAOInfo
+		for _, groupUsage := range groups {
+			if groupUsage.GroupName == group {
+				queueUsage = GetQueueUsage(groupUsage.Queues, queuePath)
+			}
+		}
+		if queueUsage == nil {
+			return runningApps == 0, nil
+		}
+		return len(queueUsage.RunningApplications) == runningApps, nil
+	}
+}
+
+// WaitForGroupUsage waits until the group is tracked with the given number of running applications in the queue
+func (c *RClient) WaitForGroupUsage(partition string, group string, queuePath string, runningApps int, timeout int) error {
+	return wait.PollImmediate(time.Millisecond*300, time.Duration(timeout)*time.Second, c.isGroupUsageInDesiredState(partition, group, queuePath, runningApps))
+}
+
+// formatMetricName replaces the characters that are not allowed in a metric name in the same way as the core does
+func formatMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// parseMetrics parses the prometheus text format, comments and unparsable lines are skipped
+func parseMetrics(text string) map[string]float64 {
+	metrics := make(map[string]float64)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.LastIndex(line, " ")
+		if idx <= 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[idx+1:], 64)
+		if err != nil {
+			continue
+		}
+		metrics[line[:idx]] = value
+	}
+	return metrics
+}
@@ -0,0 +1,159 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yunikorn
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// ValidateSchema decodes the JSON document into v and checks the document strictly against the type of v.
+// Fields in the document that the type does not define, and fields the type defines without omitempty that are
+// missing from the document, are both reported. A difference means the REST API of the core and the DAO structs
+// used by the shim have drifted apart.
+func ValidateSchema(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var problems []string
+	checkSchema(reflect.TypeOf(v), doc, "$", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("response does not match %s: %s", reflect.TypeOf(v).Elem(), strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// ValidateResponse requests the path from the scheduler and validates the response against the type of v,
+// see ValidateSchema. On success v contains the decoded response.
+func (c *RClient) ValidateResponse(path string, v interface{}) error {
+	req, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	var body json.RawMessage
+	if err = c.doTyped(req, &body); err != nil {
+		return err
+	}
+	return ValidateSchema(body, v)
+}
+
+func checkSchema(t reflect.Type, value interface{}, path string, problems *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// null is valid for any type, custom decoding defines its own format
+	if value == nil || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]schemaField)
+		jsonFields(t, fields)
+		for _, name := range sortedKeys(fields) {
+			field := fields[name]
+			if fieldValue, ok := obj[name]; ok {
+				checkSchema(field.typ, fieldValue, path+"."+name, problems)
+			} else if !field.omitEmpty {
+				*problems = append(*problems, "missing field "+path+"."+name)
+			}
+		}
+		for _, name := range sortedKeys(obj) {
+			if _, ok := fields[name]; !ok {
+				*problems = append(*problems, "unknown field "+path+"."+name)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range list {
+			checkSchema(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, key := range sortedKeys(obj) {
+			checkSchema(t.Elem(), obj[key], path+"["+key+"]", problems)
+		}
+	default:
+	}
+}
+
+type schemaField struct {
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields collects the fields of the struct type as encoded by encoding/json, including promoted fields.
+func jsonFields(t reflect.Type, fields map[string]schemaField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				jsonFields(embedded, fields)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = schemaField{
+			typ:       f.Type,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -21,7 +21,6 @@ package yunikorn
 import (
 	"time"
 
-	"gopkg.in/yaml.v3"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
@@ -92,11 +91,6 @@ func UpdateCustomConfigMapWrapper(oldConfigMap *v1.ConfigMap, schedPolicy string
 		err = common.SetSchedulingPolicy(sc, "default", "root", schedPolicy)
 		Ω(err).NotTo(HaveOccurred())
 	}
-	// Wait for 1 second to set a new timestamp. If we don't wait for it, we may get a same timestamp.
-	time.Sleep(1 * time.Second)
-	ts, tsErr := common.SetQueueTimestamp(sc, "default", "root")
-	Ω(tsErr).NotTo(HaveOccurred())
-
 	// allow caller to customize further
 	mutatorErr := mutator(sc)
 	Ω(mutatorErr).NotTo(HaveOccurred())
@@ -104,12 +98,7 @@ func UpdateCustomConfigMapWrapper(oldConfigMap *v1.ConfigMap, schedPolicy string
 	configStr, yamlErr := common.ToYAML(sc)
 	Ω(yamlErr).NotTo(HaveOccurred())
 	c.Data[configmanager.DefaultPolicyGroup] = configStr
-	var d, err3 = k.UpdateConfigMap(c, configmanager.YuniKornTestConfig.YkNamespace)
-	Ω(err3).NotTo(HaveOccurred())
-	Ω(d).NotTo(BeNil())
-
-	err = WaitForQueueTS("root", ts, 2*time.Minute)
-	Ω(err).NotTo(HaveOccurred())
+	Ω(UpdateConfigMapAndWait(c, 2*time.Minute)).NotTo(HaveOccurred())
 }
 
 func RestoreConfigMapWrapper(oldConfigMap *v1.ConfigMap, annotation string) {
@@ -120,20 +109,18 @@ func RestoreConfigMapWrapper(oldConfigMap *v1.ConfigMap, annotation string) {
 	Ω(err).NotTo(HaveOccurred())
 	Ω(c).NotTo(BeNil())
 
-	oldSC := new(configs.SchedulerConfig)
-	err = yaml.Unmarshal([]byte(oldConfigMap.Data[configmanager.DefaultPolicyGroup]), oldSC)
-	Ω(err).NotTo(HaveOccurred())
-	ts, tsErr := common.SetQueueTimestamp(oldSC, "default", "root")
-	Ω(tsErr).NotTo(HaveOccurred())
-	c.Data[configmanager.DefaultPolicyGroup], err = common.ToYAML(oldSC)
-	Ω(err).NotTo(HaveOccurred())
-
-	var e, err3 = k.UpdateConfigMap(c, configmanager.YuniKornTestConfig.YkNamespace)
-	Ω(err3).NotTo(HaveOccurred())
-	Ω(e).NotTo(BeNil())
+	c.Data[configmanager.DefaultPolicyGroup] = oldConfigMap.Data[configmanager.DefaultPolicyGroup]
+	Ω(UpdateConfigMapAndWait(c, 2*time.Minute)).NotTo(HaveOccurred())
+}
 
-	err = WaitForQueueTS("root", ts, 2*time.Minute)
-	Ω(err).NotTo(HaveOccurred())
+// UpdateConfigMapAndWait updates the YuniKorn ConfigMap and waits until the scheduler has loaded the queue
+// configuration it contains, by comparing the checksum of the active configuration. The shim must pass the queue
+// configuration to the core unchanged, the wait times out if namespace queues are added to the configuration.
+func UpdateConfigMapAndWait(c *v1.ConfigMap, timeout time.Duration) error {
+	if _, err := k.UpdateConfigMap(c, configmanager.YuniKornTestConfig.YkNamespace); err != nil {
+		return err
+	}
+	return WaitForConfigChecksum(c.Data[configmanager.DefaultPolicyGroup], timeout)
 }
 
 var Describe = ginkgo.Describe
@@ -16,11 +16,14 @@
  limitations under the License.
 */
 
+// Package yunikorn interacts with the scheduler under test: the RClient calls the REST API and the wrappers
+// update the scheduler config map and wait for the change to be applied.
 package yunikorn
 
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/onsi/ginkgo/v2"
@@ -66,10 +69,16 @@ func GetYKUrl() string {
 	)
 }
 
+// GetYKHost returns the address of the REST service of the scheduler. The default address is the port-forward of
+// the scheduler pod, which uses a different local port in each parallel ginkgo process.
 func GetYKHost() string {
+	port := configmanager.YuniKornTestConfig.YkPort
+	if configmanager.YuniKornTestConfig.YkHost == configmanager.DefaultYuniKornHost && port == configmanager.DefaultYuniKornPort {
+		port = strconv.Itoa(k8s.PortForwardLocalPort())
+	}
 	return fmt.Sprintf("%s:%s",
 		configmanager.YuniKornTestConfig.YkHost,
-		configmanager.YuniKornTestConfig.YkPort,
+		port,
 	)
 }
 
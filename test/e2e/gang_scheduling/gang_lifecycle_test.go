@@ -0,0 +1,293 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package gangscheduling_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	"github.com/apache/yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	tests "github.com/apache/yunikorn-k8shim/test/e2e"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
+)
+
+// Lifecycle of the placeholders of a gang: creation, partial and full swap with real pods, timeout and the
+// style specific behaviour after a timeout. Assertions are made against the placeholder data of the application
+// in the core and the queue container metrics.
+var _ = Describe("GangLifecycle", func() {
+	var restClient yunikorn.RClient
+	var ns string
+	var nsQueue string
+	var appID string
+	var taskGroupName string
+	const defaultPartition = "default"
+	podResources := map[string]resource.Quantity{
+		"cpu":    resource.MustParse("10m"),
+		"memory": resource.MustParse("10M"),
+	}
+
+	BeforeEach(func() {
+		Ω(kClient.SetClient()).To(BeNil())
+		ns = "ns-" + common.RandSeq(10)
+		nsQueue = "root." + ns
+		appID = "appid-" + common.RandSeq(5)
+		taskGroupName = "group-" + common.RandSeq(5)
+		By(fmt.Sprintf("Creating namespace: %s for gang jobs", ns))
+		namespace, err := kClient.CreateNamespace(ns, nil)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(namespace.Status.Phase).To(Equal(v1.NamespaceActive))
+	})
+
+	// deployJob creates a job of sleep pods for the application, the pods are members of the task group if set
+	deployJob := func(name string, parallelism int32, annotations *k8s.PodAnnotation) *batchv1.Job {
+		podConf := k8s.TestPodConfig{
+			Labels: map[string]string{
+				"app":           "sleep-" + common.RandSeq(5),
+				"applicationId": appID,
+			},
+			Annotations: annotations,
+			Resources: &v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					"cpu":    podResources["cpu"],
+					"memory": podResources["memory"],
+				},
+			},
+		}
+		job, err := k8s.InitJobConfig(k8s.JobConfig{
+			Name:        name + "-" + common.RandSeq(5),
+			Namespace:   ns,
+			Parallelism: parallelism,
+			PodConfig:   podConf,
+		})
+		Ω(err).NotTo(HaveOccurred())
+		By(fmt.Sprintf("[%s] Deploy job %s with %d pods", appID, job.Name, parallelism))
+		_, err = kClient.CreateJob(job, ns)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(kClient.WaitForJobPodsCreated(ns, job.Name, int(parallelism), 30*time.Second)).NotTo(HaveOccurred())
+		return job
+	}
+
+	// getPlaceholderData returns the placeholder data of the task group as tracked by the core
+	getPlaceholderData := func(groupName string) *dao.PlaceholderDAOInfo {
+		appInfo, err := restClient.GetAppInfo(defaultPartition, nsQueue, appID)
+		Ω(err).NotTo(HaveOccurred())
+		for _, data := range appInfo.PlaceholderData {
+			if data.TaskGroupName == groupName {
+				return data
+			}
+		}
+		ginkgo.Fail(fmt.Sprintf("no placeholder data found for task group %s", groupName))
+		return nil
+	}
+
+	// waitForPlaceholdersTerminated waits until all placeholders of the application are removed from the cluster
+	waitForPlaceholdersTerminated := func(annotations *k8s.PodAnnotation) {
+		for _, phNames := range yunikorn.GetPlaceholderNames(annotations, appID) {
+			for _, ph := range phNames {
+				Ω(kClient.WaitForPodTerminated(ns, ph, 3*time.Minute)).NotTo(HaveOccurred())
+			}
+		}
+	}
+
+	// Test placeholder creation and the swap of part of the placeholders
+	// 1. Deploy an originator job with a task group of 4 members
+	// 2. Verify 4 placeholders are created and allocated
+	// 3. Deploy 2 real task group members
+	// 4. Verify 2 placeholders are replaced, the others keep running
+	// 5. Verify the queue metrics show the placeholder allocations and releases
+	It("Verify_Placeholder_Partial_Swap", func() {
+		annotations := &k8s.PodAnnotation{
+			TaskGroups: []v1alpha1.TaskGroup{
+				{Name: taskGroupName, MinMember: int32(4), MinResource: podResources},
+			},
+		}
+		deployJob("gang-originator", 1, annotations)
+
+		By("Verify all placeholders are allocated")
+		Ω(restClient.WaitForAppStateTransition(defaultPartition, nsQueue, appID,
+			yunikorn.States().Application.Running, 120)).NotTo(HaveOccurred())
+		phData := getPlaceholderData(taskGroupName)
+		Ω(int(phData.Count)).To(Equal(4), "Placeholder count is not correct")
+		Ω(int(phData.Replaced)).To(Equal(0), "Placeholder replacement count is not correct")
+		allocatedBefore, err := restClient.GetQueueAppMetric(nsQueue, "allocated")
+		Ω(err).NotTo(HaveOccurred())
+		Ω(allocatedBefore).To(BeNumerically(">=", 4), "placeholder allocations missing from metrics")
+		releasedBefore, err := restClient.GetQueueAppMetric(nsQueue, "released")
+		Ω(err).NotTo(HaveOccurred())
+
+		memberAnnotations := &k8s.PodAnnotation{
+			TaskGroupName: taskGroupName,
+			TaskGroups:    annotations.TaskGroups,
+		}
+		realJob := deployJob("gang-members", 2, memberAnnotations)
+		Ω(kClient.WaitForJobPods(ns, realJob.Name, 2, 3*time.Minute)).NotTo(HaveOccurred())
+
+		By("Verify 2 placeholders are replaced")
+		phData = getPlaceholderData(taskGroupName)
+		Ω(int(phData.Count)).To(Equal(4), "Placeholder count is not correct")
+		Ω(int(phData.Replaced)).To(Equal(2), "Placeholder replacement count is not correct")
+		Ω(int(phData.TimedOut)).To(Equal(0), "Placeholder timed out count is not correct")
+		phPods, err := kClient.ListPods(ns, fmt.Sprintf("applicationId=%s,placeholder=true", appID))
+		Ω(err).NotTo(HaveOccurred())
+		Ω(len(phPods.Items)).To(Equal(2), "unused placeholders should still exist")
+
+		By("Verify the swap is reflected in the queue metrics")
+		releasedAfter, err := restClient.GetQueueAppMetric(nsQueue, "released")
+		Ω(err).NotTo(HaveOccurred())
+		Ω(releasedAfter-releasedBefore).To(BeNumerically(">=", 2), "replaced placeholders not released in metrics")
+	})
+
+	// Test the full swap of all placeholders
+	// 1. Deploy an originator job with a task group of 3 members
+	// 2. Deploy 3 real task group members
+	// 3. Verify all placeholders are replaced and removed from the cluster
+	It("Verify_Placeholder_Full_Swap", func() {
+		annotations := &k8s.PodAnnotation{
+			TaskGroups: []v1alpha1.TaskGroup{
+				{Name: taskGroupName, MinMember: int32(3), MinResource: podResources},
+			},
+		}
+		deployJob("gang-originator", 1, annotations)
+		Ω(restClient.WaitForAppStateTransition(defaultPartition, nsQueue, appID,
+			yunikorn.States().Application.Running, 120)).NotTo(HaveOccurred())
+		releasedBefore, err := restClient.GetQueueAppMetric(nsQueue, "released")
+		Ω(err).NotTo(HaveOccurred())
+
+		memberAnnotations := &k8s.PodAnnotation{
+			TaskGroupName: taskGroupName,
+			TaskGroups:    annotations.TaskGroups,
+		}
+		realJob := deployJob("gang-members", 3, memberAnnotations)
+
+		By("Wait for all placeholders terminated")
+		waitForPlaceholdersTerminated(annotations)
+		Ω(kClient.WaitForJobPods(ns, realJob.Name, 3, 3*time.Minute)).NotTo(HaveOccurred())
+
+		phData := getPlaceholderData(taskGroupName)
+		Ω(int(phData.Count)).To(Equal(3), "Placeholder count is not correct")
+		Ω(int(phData.Replaced)).To(Equal(3), "Placeholder replacement count is not correct")
+		appInfo, err := restClient.GetAppInfo(defaultPartition, nsQueue, appID)
+		Ω(err).NotTo(HaveOccurred())
+		for _, alloc := range appInfo.Allocations {
+			Ω(alloc.Placeholder).To(Equal(false), "placeholder allocation left after swap")
+		}
+		releasedAfter, err := restClient.GetQueueAppMetric(nsQueue, "released")
+		Ω(err).NotTo(HaveOccurred())
+		Ω(releasedAfter-releasedBefore).To(BeNumerically(">=", 3), "replaced placeholders not released in metrics")
+	})
+
+	// Test the cleanup of placeholders that are never used
+	// 1. Deploy an originator job with a task group of 3 members and a placeholder timeout
+	// 2. Do not deploy any real task group members
+	// 3. Verify the placeholders time out and are removed from the cluster
+	It("Verify_Placeholder_Timeout_Cleanup", func() {
+		pdTimeout := 20
+		annotations := &k8s.PodAnnotation{
+			SchedulingPolicyParams: fmt.Sprintf("%s=%d", "placeholderTimeoutInSeconds", pdTimeout),
+			TaskGroups: []v1alpha1.TaskGroup{
+				{Name: taskGroupName, MinMember: int32(3), MinResource: podResources},
+			},
+		}
+		deployJob("gang-originator", 1, annotations)
+		Ω(restClient.WaitForAppStateTransition(defaultPartition, nsQueue, appID,
+			yunikorn.States().Application.Running, 120)).NotTo(HaveOccurred())
+
+		By("Wait for placeholder timeout and cleanup")
+		time.Sleep(time.Duration(pdTimeout) * time.Second)
+		waitForPlaceholdersTerminated(annotations)
+
+		phData := getPlaceholderData(taskGroupName)
+		Ω(int(phData.Count)).To(Equal(3), "Placeholder count is not correct")
+		Ω(int(phData.TimedOut)).To(Equal(3), "Placeholder timed out count is not correct")
+		Ω(int(phData.Replaced)).To(Equal(0), "Placeholder replacement count is not correct")
+		phPods, err := kClient.ListPods(ns, fmt.Sprintf("applicationId=%s,placeholder=true", appID))
+		Ω(err).NotTo(HaveOccurred())
+		Ω(len(phPods.Items)).To(Equal(0), "placeholders left after timeout")
+	})
+
+	// Test the gang scheduling style when not all placeholders can be allocated
+	// 1. Deploy a job of 2 task group members, the task group has an extra member that can never be placed
+	// 2. Wait for the placeholder timeout
+	// 3. Soft style: real pods are scheduled without placeholders, the app runs
+	//    Hard style: the app fails and no real pods are scheduled
+	ginkgo.DescribeTable("Verify_GS_Style_After_Timeout", func(style string, expectedState string, expectRunning bool) {
+		pdTimeout := 20
+		annotations := &k8s.PodAnnotation{
+			TaskGroupName: taskGroupName,
+			SchedulingPolicyParams: fmt.Sprintf("%s=%d %s=%s",
+				"placeholderTimeoutInSeconds", pdTimeout, "gangSchedulingStyle", style),
+			TaskGroups: []v1alpha1.TaskGroup{
+				{Name: taskGroupName, MinMember: int32(2), MinResource: podResources},
+				{
+					Name:         "unplaceable",
+					MinMember:    int32(1),
+					MinResource:  podResources,
+					NodeSelector: map[string]string{"kubernetes.io/hostname": "unsatisfiable_node"},
+				},
+			},
+		}
+		job := deployJob("gang-"+style, 2, annotations)
+
+		By(fmt.Sprintf("[%s] Wait for placeholder timeout, style %s", appID, style))
+		time.Sleep(time.Duration(pdTimeout) * time.Second)
+		Ω(restClient.WaitForAppStateTransition(defaultPartition, nsQueue, appID, expectedState, 60)).NotTo(HaveOccurred())
+
+		phData := getPlaceholderData(taskGroupName)
+		Ω(int(phData.Count)).To(Equal(2), "Placeholder count is not correct")
+		Ω(int(phData.Replaced)).To(Equal(0), "Placeholder replacement count is not correct")
+		if expectRunning {
+			Ω(int(phData.TimedOut)).To(Equal(2), "Placeholder timed out count is not correct")
+			Ω(kClient.WaitForJobPods(ns, job.Name, 2, 2*time.Minute)).NotTo(HaveOccurred())
+			appInfo, err := restClient.GetAppInfo(defaultPartition, nsQueue, appID)
+			Ω(err).NotTo(HaveOccurred())
+			Ω(len(appInfo.Allocations)).To(Equal(2), "Allocations count is not correct")
+			for _, alloc := range appInfo.Allocations {
+				Ω(alloc.Placeholder).To(Equal(false), "Allocation should be non placeholder")
+				Ω(alloc.PlaceholderUsed).To(Equal(false), "Allocation should not be replacement of ph")
+			}
+		} else {
+			appInfo, err := restClient.GetAppInfo(defaultPartition, nsQueue, appID)
+			Ω(err).NotTo(HaveOccurred())
+			for _, alloc := range appInfo.Allocations {
+				Ω(alloc.Placeholder).To(Equal(true), "real pod allocated for a failed hard gang")
+			}
+		}
+	},
+		ginkgo.Entry("Soft", "Soft", yunikorn.States().Application.Running, true),
+		ginkgo.Entry("Hard", "Hard", yunikorn.States().Application.Failing, false),
+	)
+
+	AfterEach(func() {
+		testDescription := ginkgo.CurrentSpecReport()
+		if testDescription.Failed() {
+			tests.LogTestClusterInfoWrapper(testDescription.FailureMessage(), []string{ns})
+			tests.LogYunikornContainer(testDescription.FailureMessage())
+		}
+		By("Tear down namespace: " + ns)
+		Ω(kClient.TearDownNamespace(ns)).NotTo(HaveOccurred())
+	})
+})
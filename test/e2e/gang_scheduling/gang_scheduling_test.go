@@ -788,13 +788,12 @@ var _ = Describe("", func() {
 		}
 
 		// Verify queue resources = 0
+		err = restClient.WaitForQueueResources(defaultPartition, nsQueue, yunikorn.QueueAllocated,
+			map[string]int64{siCommon.CPU: 0, siCommon.Memory: 0}, 30*time.Second)
+		Ω(err).NotTo(HaveOccurred(), "Placeholder allocation not removed from queue")
 		qInfo, qErr := restClient.GetQueue(defaultPartition, nsQueue)
 		Ω(qErr).NotTo(HaveOccurred())
-		var usedResource yunikorn.ResourceUsage
 		var usedPercentageResource yunikorn.ResourceUsage
-		usedResource.ParseResourceUsage(qInfo.AllocatedResource)
-		Ω(usedResource.GetResourceValue(siCommon.CPU)).Should(Equal(int64(0)), "Placeholder allocation not removed from queue")
-		Ω(usedResource.GetResourceValue(siCommon.Memory)).Should(Equal(int64(0)), "Placeholder allocation not removed from queue")
 		usedPercentageResource.ParseResourceUsage(qInfo.AbsUsedCapacity)
 		Ω(usedPercentageResource.GetResourceValue(siCommon.CPU)).Should(Equal(int64(0)), "Placeholder allocation not removed from queue")
 		Ω(usedPercentageResource.GetResourceValue(siCommon.Memory)).Should(Equal(int64(0)), "Placeholder allocation not removed from queue")
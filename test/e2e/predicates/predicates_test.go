@@ -27,6 +27,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	tests "github.com/apache/yunikorn-k8shim/test/e2e"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
@@ -485,7 +486,7 @@ var _ = Describe("Predicates", func() {
 			if t.fits {
 				err1 = kClient.WaitForPodScheduled(testPod.Namespace, testPod.Name, time.Duration(60)*time.Second)
 			} else {
-				err1 = kClient.WaitForPodUnschedulable(testPod, time.Duration(60)*time.Second)
+				err1 = kClient.WaitForPodUnschedulableWithReason(testPod, constants.SchedulingReasonPredicateFailed, time.Duration(60)*time.Second)
 			}
 			Ω(err1).NotTo(HaveOccurred())
 		},
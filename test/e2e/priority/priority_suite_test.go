@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package priority_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/reporters"
+	"github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
+)
+
+func init() {
+	configmanager.YuniKornTestConfig.ParseFlags()
+}
+
+func TestPriority(t *testing.T) {
+	ginkgo.ReportAfterSuite("TestPriority", func(report ginkgo.Report) {
+		err := common.CreateJUnitReportDir()
+		Ω(err).NotTo(gomega.HaveOccurred())
+		err = reporters.GenerateJUnitReportWithConfig(
+			report,
+			filepath.Join(configmanager.YuniKornTestConfig.LogDir, "TEST-priority_junit.xml"),
+			reporters.JunitReportConfig{OmitSpecLabels: true},
+		)
+		Ω(err).NotTo(HaveOccurred())
+	})
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "TestPriority", ginkgo.Label("TestPriority"))
+}
+
+var kubeClient k8s.KubeCtl
+
+// the names differ from the priority_scheduling suite: the PriorityClasses are cluster wide
+var lowPriorityClass = k8s.InitPriorityClassConfig(k8s.PriorityClassConfig{
+	Name:             "yk-priority-low",
+	Value:            -10,
+	PreemptionPolicy: v1.PreemptNever,
+})
+
+var mediumPriorityClass = k8s.InitPriorityClassConfig(k8s.PriorityClassConfig{
+	Name:             "yk-priority-medium",
+	Value:            10,
+	PreemptionPolicy: v1.PreemptNever,
+})
+
+var highPriorityClass = k8s.InitPriorityClassConfig(k8s.PriorityClassConfig{
+	Name:             "yk-priority-high",
+	Value:            100,
+	PreemptionPolicy: v1.PreemptNever,
+})
+
+var _ = ginkgo.BeforeSuite(func() {
+	kubeClient = k8s.KubeCtl{}
+	Expect(kubeClient.SetClient()).To(BeNil())
+	yunikorn.EnsureYuniKornConfigsPresent()
+
+	By("Creating priority classes")
+	err := kubeClient.CreatePriorityClasses(30*time.Second, lowPriorityClass, mediumPriorityClass, highPriorityClass)
+	Ω(err).ShouldNot(HaveOccurred())
+})
+
+var _ = ginkgo.AfterSuite(func() {
+	kubeClient = k8s.KubeCtl{}
+	Expect(kubeClient.SetClient()).To(BeNil())
+
+	By("Removing priority classes")
+	err := kubeClient.CleanupPriorityClasses(30*time.Second, lowPriorityClass.Name, mediumPriorityClass.Name, highPriorityClass.Name)
+	Ω(err).ShouldNot(HaveOccurred())
+})
+
+var By = ginkgo.By
+
+var Ω = gomega.Ω
+var BeNil = gomega.BeNil
+var HaveOccurred = gomega.HaveOccurred
+var Expect = gomega.Expect
+var Equal = gomega.Equal
@@ -0,0 +1,175 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package priority_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	tests "github.com/apache/yunikorn-k8shim/test/e2e"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+)
+
+const (
+	requestCPU = "100m"
+	requestMem = "100M"
+)
+
+var rr = &v1.ResourceRequirements{
+	Requests: v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(requestCPU),
+		v1.ResourceMemory: resource.MustParse(requestMem),
+	},
+}
+
+// onePodQueue returns a parent queue that fits a single test pod, its children are given as queue configs
+func onePodQueue(name string, policy string, children ...configs.QueueConfig) configs.QueueConfig {
+	queue := common.PriorityQueueConfig(name, policy, 0)
+	queue.Parent = true
+	queue.Resources = configs.Resources{Max: map[string]string{siCommon.CPU: requestCPU, siCommon.Memory: requestMem}}
+	queue.Queues = children
+	return queue
+}
+
+var _ = ginkgo.Describe("Priority", func() {
+	var ns string
+	var oldConfigMap = new(v1.ConfigMap)
+	var annotation string
+
+	ginkgo.BeforeEach(func() {
+		ns = "test-" + common.RandSeq(10)
+		By(fmt.Sprintf("Creating test namespace %s", ns))
+		namespace, err := kubeClient.CreateNamespace(ns, map[string]string{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(namespace.Status.Phase).Should(Equal(v1.NamespaceActive))
+		annotation = "ann-" + common.RandSeq(10)
+	})
+
+	// updateQueues adds the queue under root, pods are placed by the queue label using the default placement rule
+	updateQueues := func(queue configs.QueueConfig) {
+		By("Setting custom YuniKorn configuration")
+		yunikorn.UpdateCustomConfigMapWrapper(oldConfigMap, "fifo", annotation, func(sc *configs.SchedulerConfig) error {
+			// remove placement rules so we can control queue
+			sc.Partitions[0].PlacementRules = nil
+			return common.AddQueue(sc, "default", "root", queue)
+		})
+	}
+
+	ginkgo.It("Verify_Priority_Ordering_Within_Queue", func() {
+		updateQueues(onePodQueue("ordered", "", configs.QueueConfig{Name: "leaf"}))
+
+		blocker := k8s.PriorityTestPodConfig(ns, "root.ordered.leaf", "", rr)
+		low := k8s.PriorityTestPodConfig(ns, "root.ordered.leaf", lowPriorityClass.Name, rr)
+		medium := k8s.PriorityTestPodConfig(ns, "root.ordered.leaf", mediumPriorityClass.Name, rr)
+		high := k8s.PriorityTestPodConfig(ns, "root.ordered.leaf", highPriorityClass.Name, rr)
+		// submitted in an order that differs from the expected scheduling order
+		verifySchedulingOrder(ns, blocker, []k8s.TestPodConfig{medium, low, high}, []k8s.TestPodConfig{high, medium, low})
+	})
+
+	ginkgo.It("Verify_Fence_Hides_Child_Priority", func() {
+		updateQueues(onePodQueue("parent", "",
+			common.PriorityQueueConfig("fenced", "fence", 0),
+			configs.QueueConfig{Name: "open"}))
+
+		// without the fence the high priority pod would be scheduled first
+		blocker := k8s.PriorityTestPodConfig(ns, "root.parent.open", "", rr)
+		fenced := k8s.PriorityTestPodConfig(ns, "root.parent.fenced", highPriorityClass.Name, rr)
+		open := k8s.PriorityTestPodConfig(ns, "root.parent.open", mediumPriorityClass.Name, rr)
+		verifySchedulingOrder(ns, blocker, []k8s.TestPodConfig{fenced, open}, []k8s.TestPodConfig{open, fenced})
+	})
+
+	ginkgo.It("Verify_Fence_Applies_Priority_Offset", func() {
+		updateQueues(onePodQueue("parent", "",
+			common.PriorityQueueConfig("fenced", "fence", 50),
+			configs.QueueConfig{Name: "open"}))
+
+		// the fenced queue reports its offset to the parent, which is higher than the medium priority pod
+		blocker := k8s.PriorityTestPodConfig(ns, "root.parent.open", "", rr)
+		fenced := k8s.PriorityTestPodConfig(ns, "root.parent.fenced", lowPriorityClass.Name, rr)
+		open := k8s.PriorityTestPodConfig(ns, "root.parent.open", mediumPriorityClass.Name, rr)
+		verifySchedulingOrder(ns, blocker, []k8s.TestPodConfig{open, fenced}, []k8s.TestPodConfig{fenced, open})
+	})
+
+	ginkgo.AfterEach(func() {
+		testDescription := ginkgo.CurrentSpecReport()
+		if testDescription.Failed() {
+			tests.LogTestClusterInfoWrapper(testDescription.FailureMessage(), []string{ns})
+			tests.LogYunikornContainer(testDescription.FailureMessage())
+		}
+
+		By(fmt.Sprintf("Tearing down namespace %s", ns))
+		err := kubeClient.TearDownNamespace(ns)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		By("Restoring YuniKorn configuration")
+		yunikorn.RestoreConfigMapWrapper(oldConfigMap, annotation)
+	})
+})
+
+// verifySchedulingOrder runs the blocker pod to fill the queue, submits the pods and checks that the pods run
+// one at a time in the expected order while the running pod is removed.
+func verifySchedulingOrder(ns string, blockerConf k8s.TestPodConfig, submitted []k8s.TestPodConfig, expected []k8s.TestPodConfig) {
+	By("Create blocker pod to consume queue")
+	blocker, err := k8s.InitTestPod(blockerConf)
+	Ω(err).NotTo(HaveOccurred())
+	_, err = kubeClient.CreatePod(blocker, ns)
+	Ω(err).NotTo(HaveOccurred())
+	Ω(kubeClient.WaitForPodRunning(ns, blocker.Name, 30*time.Second)).NotTo(HaveOccurred())
+
+	for _, podConf := range submitted {
+		By(fmt.Sprintf("Submit pod %s with priority class %q", podConf.Name, podConf.PriorityClassName))
+		var pod *v1.Pod
+		pod, err = k8s.InitTestPod(podConf)
+		Ω(err).NotTo(HaveOccurred())
+		_, err = kubeClient.CreatePod(pod, ns)
+		Ω(err).NotTo(HaveOccurred())
+		// spread the submission times, the submission order must not decide the scheduling order
+		time.Sleep(time.Second)
+	}
+
+	By("Wait for scheduler state to settle")
+	time.Sleep(10 * time.Second)
+	ensureNotRunning(ns, expected...)
+
+	running := blocker.Name
+	for i, podConf := range expected {
+		By(fmt.Sprintf("Kill pod %s to make room for pod %s", running, podConf.Name))
+		Ω(kubeClient.DeletePod(running, ns)).NotTo(HaveOccurred())
+		Ω(kubeClient.WaitForPodRunning(ns, podConf.Name, 30*time.Second)).NotTo(HaveOccurred())
+		ensureNotRunning(ns, expected[i+1:]...)
+		running = podConf.Name
+	}
+	Ω(kubeClient.DeletePod(running, ns)).NotTo(HaveOccurred())
+}
+
+func ensureNotRunning(ns string, podConfs ...k8s.TestPodConfig) {
+	for _, podConf := range podConfs {
+		pod, err := kubeClient.GetPod(podConf.Name, ns)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(pod.Status.Phase).ShouldNot(Equal(v1.PodRunning), pod.Name)
+	}
+}
@@ -22,13 +22,12 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/ginkgo/v2/reporters"
 	"github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
-	schedulingv1 "k8s.io/api/scheduling/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
 	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
@@ -57,31 +56,23 @@ func TestPriorityScheduling(t *testing.T) {
 
 var kubeClient k8s.KubeCtl
 
-var preemptPolicyNever = v1.PreemptNever
-
-var lowPriorityClass = schedulingv1.PriorityClass{
-	ObjectMeta: metav1.ObjectMeta{
-		Name: "yk-test-low",
-	},
+var lowPriorityClass = k8s.InitPriorityClassConfig(k8s.PriorityClassConfig{
+	Name:             "yk-test-low",
 	Value:            -100,
-	PreemptionPolicy: &preemptPolicyNever,
-}
+	PreemptionPolicy: v1.PreemptNever,
+})
 
-var highPriorityClass = schedulingv1.PriorityClass{
-	ObjectMeta: metav1.ObjectMeta{
-		Name: "yk-test-high",
-	},
+var highPriorityClass = k8s.InitPriorityClassConfig(k8s.PriorityClassConfig{
+	Name:             "yk-test-high",
 	Value:            100,
-	PreemptionPolicy: &preemptPolicyNever,
-}
+	PreemptionPolicy: v1.PreemptNever,
+})
 
-var normalPriorityClass = schedulingv1.PriorityClass{
-	ObjectMeta: metav1.ObjectMeta{
-		Name: "yk-test-normal",
-	},
+var normalPriorityClass = k8s.InitPriorityClassConfig(k8s.PriorityClassConfig{
+	Name:             "yk-test-normal",
 	Value:            0,
-	PreemptionPolicy: &preemptPolicyNever,
-}
+	PreemptionPolicy: v1.PreemptNever,
+})
 
 var annotation = "ann-" + common.RandSeq(10)
 var oldConfigMap = new(v1.ConfigMap)
@@ -96,15 +87,21 @@ var _ = ginkgo.BeforeSuite(func() {
 	yunikorn.UpdateConfigMapWrapper(oldConfigMap, "", annotation)
 
 	By(fmt.Sprintf("Creating priority class %s", lowPriorityClass.Name))
-	_, err = kubeClient.CreatePriorityClass(&lowPriorityClass)
+	_, err = kubeClient.CreatePriorityClass(lowPriorityClass)
+	Ω(err).ShouldNot(HaveOccurred())
+	err = kubeClient.WaitForPriorityClass(lowPriorityClass.Name, 30*time.Second)
 	Ω(err).ShouldNot(HaveOccurred())
 
 	By(fmt.Sprintf("Creating priority class %s", highPriorityClass.Name))
-	_, err = kubeClient.CreatePriorityClass(&highPriorityClass)
+	_, err = kubeClient.CreatePriorityClass(highPriorityClass)
+	Ω(err).ShouldNot(HaveOccurred())
+	err = kubeClient.WaitForPriorityClass(highPriorityClass.Name, 30*time.Second)
 	Ω(err).ShouldNot(HaveOccurred())
 
 	By(fmt.Sprintf("Creating priority class %s", normalPriorityClass.Name))
-	_, err = kubeClient.CreatePriorityClass(&normalPriorityClass)
+	_, err = kubeClient.CreatePriorityClass(normalPriorityClass)
+	Ω(err).ShouldNot(HaveOccurred())
+	err = kubeClient.WaitForPriorityClass(normalPriorityClass.Name, 30*time.Second)
 	Ω(err).ShouldNot(HaveOccurred())
 })
 
@@ -113,16 +110,8 @@ var _ = ginkgo.AfterSuite(func() {
 	kubeClient = k8s.KubeCtl{}
 	Expect(kubeClient.SetClient()).To(BeNil())
 
-	By(fmt.Sprintf("Removing priority class %s", normalPriorityClass.Name))
-	err = kubeClient.DeletePriorityClass(normalPriorityClass.Name)
-	Ω(err).ShouldNot(HaveOccurred())
-
-	By(fmt.Sprintf("Removing priority class %s", highPriorityClass.Name))
-	err = kubeClient.DeletePriorityClass(highPriorityClass.Name)
-	Ω(err).ShouldNot(HaveOccurred())
-
-	By(fmt.Sprintf("Removing priority class %s", lowPriorityClass.Name))
-	err = kubeClient.DeletePriorityClass(lowPriorityClass.Name)
+	By("Removing priority classes")
+	err = kubeClient.CleanupPriorityClasses(30*time.Second, normalPriorityClass.Name, highPriorityClass.Name, lowPriorityClass.Name)
 	Ω(err).ShouldNot(HaveOccurred())
 
 	yunikorn.RestoreConfigMapWrapper(oldConfigMap, annotation)
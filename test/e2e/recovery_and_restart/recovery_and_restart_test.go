@@ -45,6 +45,7 @@ const (
 	taskGroupE2EPrefix   = "tg-" + taskGroupE2E
 	parallelism          = 3
 	taintKey             = "e2e_test"
+	restartTimeout       = 60 * time.Second
 )
 
 var kClient k8s.KubeCtl
@@ -86,10 +87,7 @@ var _ = ginkgo.BeforeSuite(func() {
 	gomega.Ω(err).NotTo(gomega.HaveOccurred())
 
 	ginkgo.By("Restart the scheduler pod")
-	yunikorn.RestartYunikorn(&kClient)
-
-	ginkgo.By("Port-forward scheduler pod after restart")
-	yunikorn.RestorePortForwarding(&kClient)
+	Ω(yunikorn.KillAndRestartScheduler(&kClient, restartTimeout)).NotTo(gomega.HaveOccurred())
 
 	ginkgo.By("Deploy 2nd sleep pod to the development namespace")
 	sleepObj2, podErr := k8s.InitSleepPod(sleepPod2Configs)
@@ -152,7 +150,6 @@ var _ = ginkgo.Describe("", func() {
 	ginkgo.It("Verify_SleepJobs_Restart_YK", func() {
 		kClient = k8s.KubeCtl{}
 		Ω(kClient.SetClient()).To(gomega.BeNil())
-		defer yunikorn.RestorePortForwarding(&kClient)
 
 		appID1 := normalSleepJobPrefix + "-" + common.RandSeq(5)
 		sleepPodConfig1 := k8s.SleepPodConfig{Name: "normal-sleep-job", NS: dev, Time: 300, AppID: appID1}
@@ -174,7 +171,7 @@ var _ = ginkgo.Describe("", func() {
 		Ω(createErr2).NotTo(gomega.HaveOccurred())
 
 		ginkgo.By("Restart the scheduler pod immediately")
-		yunikorn.RestartYunikorn(&kClient)
+		Ω(yunikorn.KillAndRestartScheduler(&kClient, restartTimeout)).NotTo(gomega.HaveOccurred())
 
 		ginkgo.By("Listing pods")
 		pods, err := kClient.GetPods(dev)
@@ -195,7 +192,6 @@ var _ = ginkgo.Describe("", func() {
 	ginkgo.It("Verify_GangScheduling_TwoGangs_Restart_YK", func() {
 		kClient = k8s.KubeCtl{}
 		Ω(kClient.SetClient()).To(gomega.BeNil())
-		defer yunikorn.RestorePortForwarding(&kClient)
 
 		appID := gangSleepJobPrefix + "-" + common.RandSeq(5)
 		sleepPodConfig := k8s.SleepPodConfig{Name: "gang-sleep-job", NS: dev, Time: 1, AppID: appID}
@@ -224,7 +220,7 @@ var _ = ginkgo.Describe("", func() {
 		Ω(err).NotTo(gomega.HaveOccurred())
 
 		ginkgo.By("Restart the scheduler pod")
-		yunikorn.RestartYunikorn(&kClient)
+		Ω(yunikorn.KillAndRestartScheduler(&kClient, restartTimeout)).NotTo(gomega.HaveOccurred())
 
 		// make sure that Yunikorn's internal state have been properly restored
 		ginkgo.By("Submit sleep job")
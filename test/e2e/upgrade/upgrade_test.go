@@ -0,0 +1,270 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package upgrade_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	tests "github.com/apache/yunikorn-k8shim/test/e2e"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
+)
+
+const (
+	upgradeTimeout = 5 * time.Minute
+	recoverTimeout = 60 * time.Second
+	parallelism    = 3
+	sleepTime      = 3600
+	taskGroupName  = "upgrade-group"
+	appRunning     = "Running"
+)
+
+var kClient k8s.KubeCtl
+var restClient yunikorn.RClient
+var ns string
+var queuePath string
+
+// upgradeStarted tracks if the installed release was replaced, the build under test is restored at the end
+var upgradeStarted bool
+
+// podState is the part of a pod that must not change when the scheduler is upgraded
+type podState struct {
+	uid      types.UID
+	nodeName string
+	phase    v1.PodPhase
+	restarts int32
+}
+
+var _ = ginkgo.BeforeSuite(func() {
+	if configmanager.YuniKornTestConfig.UpgradeFromVersion == "" {
+		ginkgo.Skip("upgrade-from-version is not set, skipping the upgrade tests")
+	}
+	kClient = k8s.KubeCtl{}
+	Ω(kClient.SetClient()).To(gomega.BeNil())
+	restClient = yunikorn.RClient{}
+
+	ginkgo.By("Install the previous release " + configmanager.YuniKornTestConfig.UpgradeFromVersion)
+	upgradeStarted = true
+	Ω(yunikorn.HelmUpgradeToPrevious(&kClient, upgradeTimeout)).NotTo(gomega.HaveOccurred())
+
+	ns = "upgrade-" + common.RandSeq(10)
+	queuePath = "root." + ns
+	ginkgo.By("Create namespace " + ns)
+	namespace, err := kClient.CreateNamespace(ns, nil)
+	Ω(err).NotTo(gomega.HaveOccurred())
+	Ω(namespace.Status.Phase).To(gomega.Equal(v1.NamespaceActive))
+})
+
+var _ = ginkgo.AfterSuite(func() {
+	if !upgradeStarted {
+		return
+	}
+	ginkgo.By("Tear down namespace: " + ns)
+	Ω(kClient.TearDownNamespace(ns)).NotTo(gomega.HaveOccurred())
+
+	// the suites that follow expect the build under test, also when the upgrade failed half way
+	ginkgo.By("Make sure the build under test is installed")
+	Ω(yunikorn.HelmUpgradeToCurrent(&kClient, upgradeTimeout)).NotTo(gomega.HaveOccurred())
+})
+
+var _ = ginkgo.Describe("", func() {
+	ginkgo.It("Verify_Upgrade_Keeps_Pods_And_Allocations", func() {
+		ginkgo.By("Submit a sleep job")
+		normalAppID := "normal-" + common.RandSeq(5)
+		normalPod, err := k8s.InitSleepPod(k8s.SleepPodConfig{Name: "normal-sleep", NS: ns, Time: sleepTime, AppID: normalAppID})
+		Ω(err).NotTo(gomega.HaveOccurred())
+		normalJob := k8s.InitTestJob(normalAppID, parallelism, parallelism, normalPod)
+		_, err = kClient.CreateJob(normalJob, ns)
+		Ω(err).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Submit a gang sleep job")
+		gangAppID := "gang-" + common.RandSeq(5)
+		gangPodConfig := k8s.SleepPodConfig{Name: "gang-sleep", NS: ns, Time: sleepTime, AppID: gangAppID}
+		gangPod, err := k8s.InitSleepPod(gangPodConfig)
+		Ω(err).NotTo(gomega.HaveOccurred())
+		taskGroups := k8s.InitTaskGroup(gangPodConfig, taskGroupName, parallelism)
+		gangPod = k8s.DecoratePodForGangScheduling(60, "Soft", taskGroupName, taskGroups, gangPod)
+		gangJob := k8s.InitTestJob(gangAppID, parallelism, parallelism, gangPod)
+		_, err = kClient.CreateJob(gangJob, ns)
+		Ω(err).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Submit a pod that does not fit on any node")
+		pendingAppID := "pending-" + common.RandSeq(5)
+		pendingPod, err := k8s.InitSleepPod(k8s.SleepPodConfig{Name: "pending-sleep", NS: ns, Time: sleepTime, AppID: pendingAppID, CPU: 1000 * 1000})
+		Ω(err).NotTo(gomega.HaveOccurred())
+		pendingPod, err = kClient.CreatePod(pendingPod, ns)
+		Ω(err).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Wait for the jobs to be running")
+		Ω(kClient.WaitForJobPodsRunning(ns, normalJob.Name, parallelism, recoverTimeout)).NotTo(gomega.HaveOccurred())
+		Ω(kClient.WaitForJobPodsRunning(ns, gangJob.Name, parallelism, recoverTimeout)).NotTo(gomega.HaveOccurred())
+		Ω(waitForAllocations(normalAppID, parallelism)).NotTo(gomega.HaveOccurred())
+		Ω(waitForAllocations(gangAppID, parallelism)).NotTo(gomega.HaveOccurred())
+		// all placeholders must be gone before the upgrade, the pods in the namespace are compared later on
+		Ω(waitForNoPlaceholders()).NotTo(gomega.HaveOccurred())
+		Ω(waitForPendingApp(pendingAppID)).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Record the state before the upgrade")
+		podsBefore := getPodStates()
+		queueBefore, err := restClient.GetQueue(configmanager.DefaultPartition, queuePath)
+		Ω(err).NotTo(gomega.HaveOccurred())
+		pendingBefore, err := restClient.GetAppInfo(configmanager.DefaultPartition, queuePath, pendingAppID)
+		Ω(err).NotTo(gomega.HaveOccurred())
+		verifyQueueAllocated(queueBefore)
+
+		ginkgo.By("Upgrade to the build under test")
+		Ω(yunikorn.HelmUpgradeToCurrent(&kClient, upgradeTimeout)).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Wait for the applications to be recovered")
+		for _, appID := range []string{normalAppID, gangAppID} {
+			err = restClient.WaitForAppStateTransition(configmanager.DefaultPartition, queuePath, appID, appRunning, int(recoverTimeout.Seconds()))
+			Ω(err).NotTo(gomega.HaveOccurred(), "application %s was not recovered", appID)
+		}
+		Ω(waitForPendingApp(pendingAppID)).NotTo(gomega.HaveOccurred())
+
+		ginkgo.By("Verify no pod was replaced or moved")
+		Ω(getPodStates()).To(gomega.Equal(podsBefore))
+		pendingPod, err = kClient.GetPod(pendingPod.Name, ns)
+		Ω(err).NotTo(gomega.HaveOccurred())
+		Ω(pendingPod.Spec.NodeName).To(gomega.BeEmpty())
+
+		ginkgo.By("Verify every pod is allocated exactly once")
+		verifyAllocations(normalAppID, podsBefore)
+		verifyAllocations(gangAppID, podsBefore)
+		queueAfter, err := restClient.GetQueue(configmanager.DefaultPartition, queuePath)
+		Ω(err).NotTo(gomega.HaveOccurred())
+		Ω(queueAfter.AllocatedResource).To(gomega.Equal(queueBefore.AllocatedResource))
+		verifyQueueAllocated(queueAfter)
+		pendingAfter, err := restClient.GetAppInfo(configmanager.DefaultPartition, queuePath, pendingAppID)
+		Ω(err).NotTo(gomega.HaveOccurred())
+		Ω(pendingAfter.Allocations).To(gomega.BeEmpty())
+		Ω(pendingAfter.PendingResource).To(gomega.Equal(pendingBefore.PendingResource))
+	})
+
+	ginkgo.AfterEach(func() {
+		testDescription := ginkgo.CurrentSpecReport()
+		if testDescription.Failed() {
+			tests.LogTestClusterInfoWrapper(testDescription.FailureMessage(), []string{ns})
+			tests.LogYunikornContainer(testDescription.FailureMessage())
+		}
+	})
+})
+
+// getPodStates returns the state of all pods in the test namespace keyed by pod name
+func getPodStates() map[string]podState {
+	pods, err := kClient.GetPods(ns)
+	Ω(err).NotTo(gomega.HaveOccurred())
+	states := make(map[string]podState, len(pods.Items))
+	for _, pod := range pods.Items {
+		state := podState{
+			uid:      pod.UID,
+			nodeName: pod.Spec.NodeName,
+			phase:    pod.Status.Phase,
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			state.restarts += status.RestartCount
+		}
+		fmt.Fprintf(ginkgo.GinkgoWriter, "Pod name: %-40s\tNode: %-20s\tStatus: %s\n", pod.Name, state.nodeName, state.phase)
+		states[pod.Name] = state
+	}
+	return states
+}
+
+// waitForAllocations waits until the application has the expected number of real, non placeholder, allocations
+func waitForAllocations(appID string, count int) error {
+	return wait.PollImmediate(time.Second, recoverTimeout, func() (bool, error) {
+		appInfo, err := restClient.GetAppInfo(configmanager.DefaultPartition, queuePath, appID)
+		if err != nil {
+			return false, nil
+		}
+		if len(appInfo.Allocations) != count {
+			return false, nil
+		}
+		for _, alloc := range appInfo.Allocations {
+			if alloc.Placeholder {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// waitForNoPlaceholders waits until all placeholder pods in the test namespace are deleted
+func waitForNoPlaceholders() error {
+	return wait.PollImmediate(time.Second, recoverTimeout, func() (bool, error) {
+		pods, err := kClient.ListPods(ns, "placeholder=true")
+		if err != nil {
+			return false, err
+		}
+		return len(pods.Items) == 0, nil
+	})
+}
+
+// waitForPendingApp waits until the application is known to the scheduler with a pending request
+func waitForPendingApp(appID string) error {
+	return wait.PollImmediate(time.Second, recoverTimeout, func() (bool, error) {
+		appInfo, err := restClient.GetAppInfo(configmanager.DefaultPartition, queuePath, appID)
+		if err != nil {
+			return false, nil
+		}
+		return len(appInfo.PendingResource) > 0, nil
+	})
+}
+
+// verifyAllocations checks that each pod of the application is allocated exactly once on the node it runs on
+func verifyAllocations(appID string, pods map[string]podState) {
+	appInfo, err := restClient.GetAppInfo(configmanager.DefaultPartition, queuePath, appID)
+	Ω(err).NotTo(gomega.HaveOccurred())
+	podList, err := kClient.ListPods(ns, "applicationId="+appID)
+	Ω(err).NotTo(gomega.HaveOccurred())
+	Ω(appInfo.Allocations).To(gomega.HaveLen(len(podList.Items)), "allocations of application %s", appID)
+
+	allocated := make(map[string]*dao.AllocationDAOInfo, len(appInfo.Allocations))
+	for _, alloc := range appInfo.Allocations {
+		Ω(alloc.Placeholder).To(gomega.BeFalse(), "placeholder allocation %s", alloc.AllocationKey)
+		Ω(allocated).NotTo(gomega.HaveKey(alloc.AllocationKey), "allocation %s is counted twice", alloc.AllocationKey)
+		allocated[alloc.AllocationKey] = alloc
+	}
+	for _, pod := range podList.Items {
+		state, ok := pods[pod.Name]
+		Ω(ok).To(gomega.BeTrue(), "pod %s was created during the upgrade", pod.Name)
+		alloc, ok := allocated[string(state.uid)]
+		Ω(ok).To(gomega.BeTrue(), "pod %s is not allocated", pod.Name)
+		Ω(alloc.NodeID).To(gomega.Equal(state.nodeName), "allocation node of pod %s", pod.Name)
+	}
+}
+
+// verifyQueueAllocated checks that the queue usage matches the requests of the running pods in the namespace
+func verifyQueueAllocated(queue *dao.PartitionQueueDAOInfo) {
+	pods, err := kClient.GetPods(ns)
+	Ω(err).NotTo(gomega.HaveOccurred())
+	requests := k8s.GetPodsTotalRequests(pods)
+	Ω(queue.AllocatedResource["vcore"]).To(gomega.Equal(requests.Cpu().MilliValue()))
+	Ω(queue.AllocatedResource["memory"]).To(gomega.Equal(requests.Memory().Value()))
+}
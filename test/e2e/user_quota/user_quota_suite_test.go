@@ -0,0 +1,125 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package userquota_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/reporters"
+	"github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	amConf "github.com/apache/yunikorn-k8shim/pkg/admission/conf"
+	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/configmanager"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
+)
+
+const (
+	limitedUser   = "quota-limited-user"
+	unlimitedUser = "quota-unlimited-user"
+	// users allowed to set the user info annotation, works with Minikube & KIND
+	externalUsers = "(^minikube-user$|^kubernetes-admin$)"
+)
+
+func init() {
+	configmanager.YuniKornTestConfig.ParseFlags()
+}
+
+var oldConfigMap = new(v1.ConfigMap)
+var annotation = "ann-" + common.RandSeq(10)
+var oldExternalUsers string
+var kClient k8s.KubeCtl
+
+var _ = BeforeSuite(func() {
+	kClient = k8s.KubeCtl{}
+	Ω(kClient.SetClient()).To(BeNil())
+	yunikorn.EnsureYuniKornConfigsPresent()
+	yunikorn.UpdateCustomConfigMapWrapper(oldConfigMap, "", annotation, func(sc *configs.SchedulerConfig) error {
+		// limits are set on the root queue: the namespace queues are created by the placement rule
+		sc.Partitions[0].Queues[0].Limits = []configs.Limit{{
+			Limit:           "user limit",
+			Users:           []string{limitedUser},
+			MaxApplications: 1,
+			MaxResources:    map[string]string{"memory": "1G", "vcore": "1"},
+		}}
+		return nil
+	})
+	oldExternalUsers = updateExternalUsers(externalUsers)
+})
+
+var _ = AfterSuite(func() {
+	updateExternalUsers(oldExternalUsers)
+	yunikorn.RestoreConfigMapWrapper(oldConfigMap, annotation)
+})
+
+// updateExternalUsers sets the users that are allowed to set the user info annotation and returns the old value
+func updateExternalUsers(users string) string {
+	By("Update the external users of the admission controller")
+	configMap, err := kClient.GetConfigMap(constants.ConfigMapName, configmanager.YuniKornTestConfig.YkNamespace)
+	Ω(err).NotTo(HaveOccurred())
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	oldUsers := configMap.Data[amConf.AMAccessControlExternalUsers]
+	configMap.Data[amConf.AMAccessControlExternalUsers] = users
+	_, err = kClient.UpdateConfigMap(configMap, configmanager.YuniKornTestConfig.YkNamespace)
+	Ω(err).NotTo(HaveOccurred())
+	// the admission controller does not expose its configuration, give the informer time to pick up the change
+	time.Sleep(3 * time.Second)
+	return oldUsers
+}
+
+func TestUserQuota(t *testing.T) {
+	ginkgo.ReportAfterSuite("TestUserQuota", func(report ginkgo.Report) {
+		err := common.CreateJUnitReportDir()
+		Ω(err).NotTo(gomega.HaveOccurred())
+		err = reporters.GenerateJUnitReportWithConfig(
+			report,
+			filepath.Join(configmanager.YuniKornTestConfig.LogDir, "TEST-user_quota_junit.xml"),
+			reporters.JunitReportConfig{OmitSpecLabels: true},
+		)
+		Ω(err).NotTo(HaveOccurred())
+	})
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "TestUserQuota", ginkgo.Label("TestUserQuota"))
+}
+
+// Declarations for Ginkgo DSL
+var Describe = ginkgo.Describe
+var It = ginkgo.It
+var By = ginkgo.By
+var BeforeEach = ginkgo.BeforeEach
+var AfterEach = ginkgo.AfterEach
+var BeforeSuite = ginkgo.BeforeSuite
+var AfterSuite = ginkgo.AfterSuite
+
+// Declarations for Gomega Matchers
+var Equal = gomega.Equal
+var Ω = gomega.Expect
+var BeNil = gomega.BeNil
+var HaveOccurred = gomega.HaveOccurred
+var BeEquivalentTo = gomega.BeEquivalentTo
+var HaveKey = gomega.HaveKey
@@ -0,0 +1,172 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package userquota_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	tests "github.com/apache/yunikorn-k8shim/test/e2e"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/common"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/k8s"
+	"github.com/apache/yunikorn-k8shim/test/e2e/framework/helpers/yunikorn"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const usageTimeout = 30
+
+var _ = Describe("UserQuota", func() {
+	var restClient yunikorn.RClient
+	var ns string
+	var queuePath string
+
+	BeforeEach(func() {
+		restClient = yunikorn.RClient{}
+		ns = "ns-" + common.RandSeq(10)
+		queuePath = "root." + ns
+		By(fmt.Sprintf("Create namespace %s", ns))
+		namespace, err := kClient.CreateNamespace(ns, nil)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(namespace.Status.Phase).To(Equal(v1.NamespaceActive))
+	})
+
+	// submitPod creates a sleep pod through the client and waits for it to run. The user info annotation is set if
+	// the user is not empty, the admission controller only accepts it from the configured external users.
+	submitPod := func(client *k8s.KubeCtl, user string, groups []string) *v1.Pod {
+		pod, err := k8s.InitSleepPod(k8s.SleepPodConfig{NS: ns, Time: 600, CPU: 100, Mem: 100})
+		Ω(err).NotTo(HaveOccurred())
+		if user != "" {
+			userInfo, infoErr := k8s.UserInfoAnnotation(user, groups)
+			Ω(infoErr).NotTo(HaveOccurred())
+			if pod.Annotations == nil {
+				pod.Annotations = make(map[string]string)
+			}
+			pod.Annotations[k8s.UserInfo] = userInfo
+		}
+		By(fmt.Sprintf("Deploy pod %s in namespace %s", pod.Name, ns))
+		pod, err = client.CreatePod(pod, ns)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(kClient.WaitForPodRunning(ns, pod.Name, 60*time.Second)).NotTo(HaveOccurred())
+		return pod
+	}
+
+	// verifyUserInfo checks the user info annotation that the admission controller left on the pod
+	verifyUserInfo := func(pod *v1.Pod, user string) {
+		pod, err := kClient.GetPod(pod.Name, ns)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(pod.Annotations).To(HaveKey(k8s.UserInfo))
+		var userInfo si.UserGroupInformation
+		Ω(json.Unmarshal([]byte(pod.Annotations[k8s.UserInfo]), &userInfo)).NotTo(HaveOccurred())
+		Ω(userInfo.User).To(Equal(user))
+	}
+
+	getQueueUsage := func(user string) *dao.ResourceUsageDAOInfo {
+		userUsage, err := restClient.GetUserUsage(yunikorn.DefaultPartition, user)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(userUsage.UserName).To(Equal(user))
+		queueUsage := yunikorn.GetQueueUsage(userUsage.Queues, queuePath)
+		Ω(queueUsage).NotTo(BeNil())
+		return queueUsage
+	}
+
+	It("Verify_Impersonated_User_Usage", func() {
+		user := "quota-user-" + common.RandSeq(5)
+		group := "quota-group-" + common.RandSeq(5)
+		By(fmt.Sprintf("Allow user %s to create pods in namespace %s", user, ns))
+		_, err := kClient.CreateUserRoleBinding(user+"-edit", "edit", ns, user)
+		Ω(err).NotTo(HaveOccurred())
+		userClient, err := kClient.Impersonate(user, []string{group})
+		Ω(err).NotTo(HaveOccurred())
+
+		pod := submitPod(userClient, "", nil)
+		verifyUserInfo(pod, user)
+
+		By(fmt.Sprintf("Verify the usage of user %s and group %s", user, group))
+		Ω(restClient.WaitForUserUsage(yunikorn.DefaultPartition, user, queuePath, 1, usageTimeout)).NotTo(HaveOccurred())
+		Ω(restClient.WaitForGroupUsage(yunikorn.DefaultPartition, group, queuePath, 1, usageTimeout)).NotTo(HaveOccurred())
+		queueUsage := getQueueUsage(user)
+		Ω(yunikorn.GetUsageResource(queueUsage, siCommon.CPU)).To(BeEquivalentTo(100))
+		Ω(yunikorn.GetUsageResource(queueUsage, siCommon.Memory)).To(BeEquivalentTo(100 * 1000 * 1000))
+
+		By(fmt.Sprintf("Delete pod %s and verify the usage is released", pod.Name))
+		Ω(kClient.DeletePod(pod.Name, ns)).NotTo(HaveOccurred())
+		Ω(restClient.WaitForUserUsage(yunikorn.DefaultPartition, user, queuePath, 0, usageTimeout)).NotTo(HaveOccurred())
+		Ω(restClient.WaitForGroupUsage(yunikorn.DefaultPartition, group, queuePath, 0, usageTimeout)).NotTo(HaveOccurred())
+	})
+
+	It("Verify_UserInfo_Annotation_Usage", func() {
+		user := "quota-user-" + common.RandSeq(5)
+		group := "quota-group-" + common.RandSeq(5)
+		pod := submitPod(&kClient, user, []string{group})
+		verifyUserInfo(pod, user)
+
+		By(fmt.Sprintf("Verify the usage of user %s and group %s", user, group))
+		Ω(restClient.WaitForUserUsage(yunikorn.DefaultPartition, user, queuePath, 1, usageTimeout)).NotTo(HaveOccurred())
+		Ω(restClient.WaitForGroupUsage(yunikorn.DefaultPartition, group, queuePath, 1, usageTimeout)).NotTo(HaveOccurred())
+		groupUsage, err := restClient.GetGroupUsage(yunikorn.DefaultPartition, group)
+		Ω(err).NotTo(HaveOccurred())
+		Ω(groupUsage.Applications).To(Equal([]string{pod.Labels["applicationId"]}))
+	})
+
+	It("Verify_User_Limit_Usage", func() {
+		// the core does not track applications of a user above the limit of the user, the limited user is only
+		// tracked with the first application while the applications of the other user are all tracked
+		for i := 0; i < 2; i++ {
+			submitPod(&kClient, limitedUser, []string{limitedUser})
+			submitPod(&kClient, unlimitedUser, []string{unlimitedUser})
+		}
+
+		By(fmt.Sprintf("Verify the usage of user %s is limited", limitedUser))
+		Ω(restClient.WaitForUserUsage(yunikorn.DefaultPartition, limitedUser, queuePath, 1, usageTimeout)).NotTo(HaveOccurred())
+		userUsage, err := restClient.GetUserUsage(yunikorn.DefaultPartition, limitedUser)
+		Ω(err).NotTo(HaveOccurred())
+		rootUsage := yunikorn.GetQueueUsage(userUsage.Queues, "root")
+		Ω(rootUsage).NotTo(BeNil())
+		Ω(rootUsage.MaxApplications).To(BeEquivalentTo(1))
+		Ω(rootUsage.MaxResources).NotTo(BeNil())
+		Ω(rootUsage.MaxResources.Resources[siCommon.CPU]).To(BeEquivalentTo(1000))
+		Ω(yunikorn.GetUsageResource(getQueueUsage(limitedUser), siCommon.CPU)).To(BeEquivalentTo(100))
+
+		By(fmt.Sprintf("Verify the usage of user %s is not limited", unlimitedUser))
+		Ω(restClient.WaitForUserUsage(yunikorn.DefaultPartition, unlimitedUser, queuePath, 2, usageTimeout)).NotTo(HaveOccurred())
+		Ω(yunikorn.GetUsageResource(getQueueUsage(unlimitedUser), siCommon.CPU)).To(BeEquivalentTo(200))
+	})
+
+	AfterEach(func() {
+		By("Check Yunikorn's health")
+		checks, err := yunikorn.GetFailedHealthChecks()
+		Ω(err).NotTo(HaveOccurred())
+		Ω(checks).To(Equal(""), checks)
+
+		testDescription := ginkgo.CurrentSpecReport()
+		if testDescription.Failed() {
+			tests.LogTestClusterInfoWrapper(testDescription.FailureMessage(), []string{ns})
+			tests.LogYunikornContainer(testDescription.FailureMessage())
+		}
+		By("Tearing down namespace: " + ns)
+		err = kClient.TearDownNamespace(ns)
+		Ω(err).NotTo(HaveOccurred())
+	})
+})
@@ -95,6 +95,14 @@ func RestoreConfigMapWrapper(oldConfigMap *v1.ConfigMap, annotation string) {
 
 func LogTestClusterInfoWrapper(testName string, namespaces []string) {
 	fmt.Fprintf(ginkgo.GinkgoWriter, "%s Log test cluster info\n", testName)
+	// write the artifacts first: the logging below stops at the first failure
+	dumpDir, dumpErr := yunikorn.DumpClusterInfo(namespaces)
+	if dumpDir != "" {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "%s Cluster info written to %s\n", testName, dumpDir)
+	}
+	if dumpErr != nil {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "%s Cluster info dump incomplete: %v\n", testName, dumpErr)
+	}
 	var restClient yunikorn.RClient
 	Ω(k.SetClient()).To(BeNil())
 	for _, ns := range namespaces {